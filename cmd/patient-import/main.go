@@ -0,0 +1,121 @@
+// Command patient-import loads patients from a CSV or XLSX file straight into the database, for
+// onboarding a hospital with more patients than is practical to upload through the API.
+//
+//	patient-import -staff <supervisor staff ID> [-map "Citizen No=national_id,..."] [-dry-run] [-report errors.csv] patients.xlsx
+//
+// It uses the same DB_* environment variables (or .env file) as the API server.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"go-hospital-api/internal/db"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/repository"
+	"go-hospital-api/internal/services"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	staff := flag.String("staff", "", "ID of the supervisor the patients are registered by; the patients join their hospital")
+	mapping := flag.String("map", "", `comma-separated "header=field" pairs for headers that are not a patient field name`)
+	dryRun := flag.Bool("dry-run", false, "validate every row without importing")
+	report := flag.String("report", "", "write the error report CSV to this file")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s -staff <staff ID> [flags] <file.csv|file.xlsx>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 || *staff == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	staffID, err := uuid.Parse(*staff)
+	if err != nil {
+		log.Fatalf("Invalid staff ID %q", *staff)
+	}
+	fields, err := parseMapping(*mapping)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fileName := flag.Arg(0)
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found")
+	}
+	dbConn, err := db.ConnectGORM(db.DSNFromEnv())
+	if err != nil {
+		log.Fatalf("DB connection failed: %v", err)
+	}
+	db.AutoMigrate(dbConn)
+
+	staffRepo := repository.NewStaffRepository(dbConn)
+	hospitalID, err := staffRepo.GetHospitalIDByStaffID(staffID.String())
+	if err != nil {
+		log.Fatalf("Staff %s not found: %v", staffID, err)
+	}
+	importService := services.NewPatientImportService(repository.NewPatientImportRepository(dbConn), staffRepo)
+
+	ctx := context.Background()
+	imp, err := importService.Import(ctx, hospitalID, staffID, fileName, data, fields, *dryRun, func(p entities.PatientImport) {
+		log.Printf("%d/%d rows processed, %d imported, %d failed", p.ProcessedRows, p.TotalRows, p.ImportedRows, p.FailedRows)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *report != "" {
+		errs, err := importService.Errors(ctx, imp.ID, hospitalID, staffID)
+		if err != nil {
+			log.Fatalf("Loading the error report failed: %v", err)
+		}
+		f, err := os.Create(*report)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := services.WriteImportErrorReport(f, errs); err != nil {
+			log.Fatalf("Writing the error report failed: %v", err)
+		}
+		if err := f.Close(); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	verb := "imported"
+	if imp.DryRun {
+		verb = "would be imported"
+	}
+	log.Printf("Import %s %s: %d of %d rows %s, %d failed", imp.ID, imp.Status, imp.ImportedRows, imp.TotalRows, verb, imp.FailedRows)
+	if imp.Status == entities.ImportFailed {
+		log.Fatalf("Import stopped: %s", imp.Error)
+	}
+	if imp.FailedRows > 0 {
+		os.Exit(1)
+	}
+}
+
+// parseMapping reads "header=field" pairs separated by commas
+func parseMapping(s string) (map[string]string, error) {
+	fields := make(map[string]string)
+	if strings.TrimSpace(s) == "" {
+		return fields, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		header, field, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid -map entry %q, expected header=field", pair)
+		}
+		fields[strings.TrimSpace(header)] = strings.TrimSpace(field)
+	}
+	return fields, nil
+}
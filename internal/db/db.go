@@ -1,15 +1,29 @@
 package db
 
 import (
+	"fmt"
 	"go-hospital-api/internal/entities"
 	"log"
+	"os"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
+// DSNFromEnv builds the connection string from DB_HOST, DB_USER, DB_PASSWORD, DB_NAME and DB_PORT
+func DSNFromEnv() string {
+	return fmt.Sprintf(
+		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
+		os.Getenv("DB_HOST"),
+		os.Getenv("DB_USER"),
+		os.Getenv("DB_PASSWORD"),
+		os.Getenv("DB_NAME"),
+		os.Getenv("DB_PORT"),
+	)
+}
+
 func ConnectGORM(dsn string) (*gorm.DB, error) {
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		return nil, err
 	}
@@ -17,12 +31,52 @@ func ConnectGORM(dsn string) (*gorm.DB, error) {
 	return db, nil
 }
 
+// Models lists every table, in the order they are migrated
+var Models = []any{
+	&entities.Hospital{},
+	&entities.Staff{},
+	&entities.Patient{},
+	&entities.ICD10Code{},
+	&entities.Diagnosis{},
+	&entities.Drug{},
+	&entities.PatientAllergy{},
+	&entities.Prescription{},
+	&entities.LabTest{},
+	&entities.LabOrder{},
+	&entities.LabOrderItem{},
+	&entities.LabResult{},
+	&entities.Ward{},
+	&entities.Bed{},
+	&entities.Admission{},
+	&entities.AdmissionMovement{},
+	&entities.PriceItem{},
+	&entities.Charge{},
+	&entities.Invoice{},
+	&entities.InvoiceLine{},
+	&entities.Payment{},
+	&entities.Coverage{},
+	&entities.PatientAddress{},
+	&entities.EmergencyContact{},
+	&entities.Consent{},
+	&entities.Referral{},
+	&entities.EnterprisePatient{},
+	&entities.PatientLink{},
+	&entities.MatchCandidate{},
+	&entities.AuditEvent{},
+	&entities.EmergencyAccess{},
+	&entities.RetentionPolicy{},
+	&entities.PatientVersion{},
+	&entities.IdempotencyKey{},
+	&entities.HL7Message{},
+	&entities.PatientImport{},
+	&entities.PatientImportError{},
+	&entities.OutboxEvent{},
+	&entities.WebhookSubscription{},
+	&entities.WebhookDelivery{},
+}
+
 func AutoMigrate(db *gorm.DB) {
-	if err := db.AutoMigrate(
-		&entities.Hospital{},
-		&entities.Staff{},
-		&entities.Patient{},
-	); err != nil {
+	if err := db.AutoMigrate(Models...); err != nil {
 		log.Fatalf("Migration failed: %v", err)
 	}
 }
@@ -1,6 +1,7 @@
 package dto
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,6 +13,12 @@ type ErrorResponse struct {
 	Message string `json:"message"`
 }
 
+// MessageResponse answers a request that leaves nothing to return, such as a delete
+type MessageResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
 // ---------------- Hospital ----------------
 type HospitalRequest struct {
 	Name string `json:"name" validate:"required"`
@@ -25,12 +32,19 @@ type HospitalResponse struct {
 }
 
 // ---------------- Staff ----------------
+// StaffCreateRequest registers a staff member. Role may only be empty or "staff"; a supervisor
+// assigns other roles with StaffRoleRequest.
 type StaffCreateRequest struct {
 	Username   string    `json:"username" validate:"required"`
 	Password   string    `json:"password" validate:"required"`
+	Role       string    `json:"role"`
 	HospitalID uuid.UUID `json:"hospital_id" validate:"required"`
 }
 
+type StaffRoleRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
 type StaffLoginRequest struct {
 	Username   string    `json:"username" validate:"required"`
 	Password   string    `json:"password" validate:"required"`
@@ -44,7 +58,9 @@ type StaffLoginResponse struct {
 type StaffResponse struct {
 	ID         uuid.UUID `json:"staff_id"`
 	Username   string    `json:"username"`
+	Role       string    `json:"role"`
 	HospitalID uuid.UUID `json:"hospital_id"`
+	Version    int       `json:"version"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
 }
@@ -59,25 +75,844 @@ type PatientSearchCriteria struct {
 	DateOfBirth *time.Time `json:"date_of_birth"`
 	PhoneNumber *string    `json:"phone_number"`
 	Email       *string    `json:"email"`
-	HospitalID  uuid.UUID  `json:"hospital_id"`
+	Province    *string    `json:"province"`
+	District    *string    `json:"district"`
+	// Name matches any part of the Thai or English name; every word must match.
+	Name *string `json:"name"`
+	// PatientHN matches the HN exactly; Identifier matches the HN, national ID or passport ID.
+	PatientHN  *string   `json:"patient_hn"`
+	Identifier *string   `json:"identifier"`
+	Gender     *string   `json:"gender"`
+	HospitalID uuid.UUID `json:"hospital_id"`
 }
 
 type PatientResponse struct {
+	PatientID      uuid.UUID         `json:"patient_id"`
+	FirstNameTh    string            `json:"first_name_th"`
+	MiddleNameTh   string            `json:"middle_name_th"`
+	LastNameTh     string            `json:"last_name_th"`
+	FirstNameEn    string            `json:"first_name_en"`
+	MiddleNameEn   string            `json:"middle_name_en"`
+	LastNameEn     string            `json:"last_name_en"`
+	DateOfBirth    time.Time         `json:"date_of_birth"`
+	PatientHN      string            `json:"patient_hn"`
+	NationalID     string            `json:"national_id"`
+	PassportID     string            `json:"passport_id"`
+	PhoneNumber    string            `json:"phone_number"`
+	Email          string            `json:"email"`
+	Gender         string            `json:"gender"`
+	HospitalID     uuid.UUID         `json:"hospital_id"`
+	ActiveCoverage *CoverageResponse `json:"active_coverage,omitempty"`
+	Version        int               `json:"version"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+// ---------------- Diagnosis ----------------
+type ICD10CodeResponse struct {
+	Code          string `json:"code"`
+	Edition       string `json:"edition"`
+	Description   string `json:"description"`
+	DescriptionTH string `json:"description_th"`
+	Billable      bool   `json:"billable"`
+}
+
+type ICD10ImportResponse struct {
+	Edition  string `json:"edition"`
+	Imported int    `json:"imported"`
+}
+
+type DiagnosisCreateRequest struct {
+	VisitDate time.Time `json:"visit_date" validate:"required"`
+	Code      string    `json:"code" validate:"required"`
+	Edition   string    `json:"edition"`
+	IsPrimary bool      `json:"is_primary"`
+	Note      string    `json:"note"`
+}
+
+type DiagnosisResponse struct {
+	DiagnosisID  uuid.UUID `json:"diagnosis_id"`
 	PatientID    uuid.UUID `json:"patient_id"`
-	FirstNameTh  string    `json:"first_name_th"`
-	MiddleNameTh string    `json:"middle_name_th"`
-	LastNameTh   string    `json:"last_name_th"`
-	FirstNameEn  string    `json:"first_name_en"`
-	MiddleNameEn string    `json:"middle_name_en"`
-	LastNameEn   string    `json:"last_name_en"`
-	DateOfBirth  time.Time `json:"date_of_birth"`
-	PatientHN    string    `json:"patient_hn"`
-	NationalID   string    `json:"national_id"`
-	PassportID   string    `json:"passport_id"`
-	PhoneNumber  string    `json:"phone_number"`
-	Email        string    `json:"email"`
-	Gender       string    `json:"gender"`
-	HospitalID   uuid.UUID `json:"hospital_id"`
+	VisitDate    time.Time `json:"visit_date"`
+	Code         string    `json:"code"`
+	Edition      string    `json:"edition"`
+	IsPrimary    bool      `json:"is_primary"`
+	Note         string    `json:"note"`
+	RecordedByID uuid.UUID `json:"recorded_by_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ---------------- Medication ----------------
+type DrugResponse struct {
+	DrugID      uuid.UUID `json:"drug_id"`
+	Code        string    `json:"code"`
+	Name        string    `json:"name"`
+	GenericName string    `json:"generic_name"`
+	DrugClass   string    `json:"drug_class"`
+	Strength    string    `json:"strength"`
+	Form        string    `json:"form"`
+	Route       string    `json:"route"`
+}
+
+type FormularyImportResponse struct {
+	Imported int `json:"imported"`
+}
+
+type AllergyCreateRequest struct {
+	Substance string `json:"substance" validate:"required"`
+	Reaction  string `json:"reaction"`
+	Severity  string `json:"severity"`
+}
+
+type AllergyResponse struct {
+	AllergyID    uuid.UUID `json:"allergy_id"`
+	PatientID    uuid.UUID `json:"patient_id"`
+	Substance    string    `json:"substance"`
+	Reaction     string    `json:"reaction"`
+	Severity     string    `json:"severity"`
+	RecordedByID uuid.UUID `json:"recorded_by_id"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type PrescriptionCreateRequest struct {
+	DrugID       uuid.UUID `json:"drug_id" validate:"required"`
+	Dose         string    `json:"dose" validate:"required"`
+	Route        string    `json:"route"`
+	Frequency    string    `json:"frequency" validate:"required"`
+	DurationDays int       `json:"duration_days"`
+	Note         string    `json:"note"`
+}
+
+type PrescriptionStatusRequest struct {
+	Status string `json:"status" validate:"required"`
+	Reason string `json:"reason"`
+}
+
+type MedicationCheckResponse struct {
+	Type     string `json:"type"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+type PrescriptionResponse struct {
+	PrescriptionID uuid.UUID                 `json:"prescription_id"`
+	PatientID      uuid.UUID                 `json:"patient_id"`
+	PrescriberID   uuid.UUID                 `json:"prescriber_id"`
+	Drug           DrugResponse              `json:"drug"`
+	Dose           string                    `json:"dose"`
+	Route          string                    `json:"route"`
+	Frequency      string                    `json:"frequency"`
+	DurationDays   int                       `json:"duration_days"`
+	Status         string                    `json:"status"`
+	Note           string                    `json:"note"`
+	CancelReason   string                    `json:"cancel_reason,omitempty"`
+	DispensedAt    *time.Time                `json:"dispensed_at,omitempty"`
+	CancelledAt    *time.Time                `json:"cancelled_at,omitempty"`
+	Version        int                       `json:"version"`
+	Checks         []MedicationCheckResponse `json:"checks,omitempty"`
+	CreatedAt      time.Time                 `json:"created_at"`
+	UpdatedAt      time.Time                 `json:"updated_at"`
+}
+
+type PrescriptionBlockedResponse struct {
+	Status  string                    `json:"status"`
+	Message string                    `json:"message"`
+	Checks  []MedicationCheckResponse `json:"checks"`
+}
+
+// ---------------- Laboratory ----------------
+type LabTestCreateRequest struct {
+	Code         string   `json:"code" validate:"required"`
+	Name         string   `json:"name" validate:"required"`
+	SpecimenType string   `json:"specimen_type"`
+	ValueType    string   `json:"value_type"`
+	Unit         string   `json:"unit"`
+	RefLow       *float64 `json:"ref_low"`
+	RefHigh      *float64 `json:"ref_high"`
+	RefText      string   `json:"ref_text"`
+}
+
+type LabTestResponse struct {
+	LabTestID    uuid.UUID `json:"lab_test_id"`
+	Code         string    `json:"code"`
+	Name         string    `json:"name"`
+	SpecimenType string    `json:"specimen_type"`
+	ValueType    string    `json:"value_type"`
+	Unit         string    `json:"unit"`
+	RefLow       *float64  `json:"ref_low"`
+	RefHigh      *float64  `json:"ref_high"`
+	RefText      string    `json:"ref_text"`
+}
+
+type LabOrderCreateRequest struct {
+	TestIDs      []uuid.UUID `json:"test_ids" validate:"required"`
+	Priority     string      `json:"priority"`
+	ClinicalNote string      `json:"clinical_note"`
+}
+
+type LabSpecimenRequest struct {
+	Status string `json:"status" validate:"required"`
+	Reason string `json:"reason"`
+}
+
+type LabResultRequest struct {
+	Status       string   `json:"status"`
+	ValueNumeric *float64 `json:"value_numeric"`
+	ValueText    string   `json:"value_text"`
+	Unit         string   `json:"unit"`
+	AbnormalFlag string   `json:"abnormal_flag"`
+	Comment      string   `json:"comment"`
+	AmendReason  string   `json:"amend_reason"`
+}
+
+type LabResultResponse struct {
+	LabResultID  uuid.UUID `json:"lab_result_id"`
+	Version      int       `json:"version"`
+	Status       string    `json:"status"`
+	ValueNumeric *float64  `json:"value_numeric"`
+	ValueText    string    `json:"value_text"`
+	Unit         string    `json:"unit"`
+	RefLow       *float64  `json:"ref_low"`
+	RefHigh      *float64  `json:"ref_high"`
+	RefText      string    `json:"ref_text"`
+	AbnormalFlag string    `json:"abnormal_flag"`
+	Comment      string    `json:"comment"`
+	AmendReason  string    `json:"amend_reason,omitempty"`
+	EnteredByID  uuid.UUID `json:"entered_by_id"`
 	CreatedAt    time.Time `json:"created_at"`
+}
+
+type LabOrderItemResponse struct {
+	ItemID  uuid.UUID           `json:"item_id"`
+	Test    LabTestResponse     `json:"test"`
+	Result  *LabResultResponse  `json:"result"`
+	History []LabResultResponse `json:"history"`
+}
+
+type LabOrderResponse struct {
+	LabOrderID    uuid.UUID              `json:"lab_order_id"`
+	PatientID     uuid.UUID              `json:"patient_id"`
+	OrderedByID   uuid.UUID              `json:"ordered_by_id"`
+	Priority      string                 `json:"priority"`
+	ClinicalNote  string                 `json:"clinical_note"`
+	Status        string                 `json:"status"`
+	StatusReason  string                 `json:"status_reason,omitempty"`
+	SpecimenLabel string                 `json:"specimen_label"`
+	CollectedAt   *time.Time             `json:"collected_at,omitempty"`
+	ReceivedAt    *time.Time             `json:"received_at,omitempty"`
+	Items         []LabOrderItemResponse `json:"items"`
+	Version       int                    `json:"version"`
+	CreatedAt     time.Time              `json:"created_at"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+}
+
+// ---------------- Ward & Admission ----------------
+type WardCreateRequest struct {
+	Code string `json:"code" validate:"required"`
+	Name string `json:"name" validate:"required"`
+}
+
+type BedCreateRequest struct {
+	Label string `json:"label" validate:"required"`
+}
+
+type BedStatusRequest struct {
+	Status string `json:"status" validate:"required"`
+}
+
+type BedResponse struct {
+	BedID   uuid.UUID `json:"bed_id"`
+	WardID  uuid.UUID `json:"ward_id"`
+	Label   string    `json:"label"`
+	Status  string    `json:"status"`
+	Version int       `json:"version"`
+}
+
+type WardResponse struct {
+	WardID uuid.UUID     `json:"ward_id"`
+	Code   string        `json:"code"`
+	Name   string        `json:"name"`
+	Beds   []BedResponse `json:"beds"`
+}
+
+type AdmitRequest struct {
+	BedID  uuid.UUID `json:"bed_id" validate:"required"`
+	Reason string    `json:"reason"`
+}
+
+type TransferRequest struct {
+	BedID uuid.UUID `json:"bed_id" validate:"required"`
+}
+
+type DischargeRequest struct {
+	Note string `json:"note"`
+}
+
+type AdmissionResponse struct {
+	AdmissionID    uuid.UUID  `json:"admission_id"`
+	PatientID      uuid.UUID  `json:"patient_id"`
+	BedID          uuid.UUID  `json:"bed_id"`
+	Status         string     `json:"status"`
+	Reason         string     `json:"reason"`
+	AdmittedByID   uuid.UUID  `json:"admitted_by_id"`
+	AdmittedAt     time.Time  `json:"admitted_at"`
+	DischargedByID *uuid.UUID `json:"discharged_by_id,omitempty"`
+	DischargedAt   *time.Time `json:"discharged_at,omitempty"`
+	DischargeNote  string     `json:"discharge_note,omitempty"`
+}
+
+type BedBoardBed struct {
+	BedID       uuid.UUID  `json:"bed_id"`
+	Label       string     `json:"label"`
+	Status      string     `json:"status"`
+	Version     int        `json:"version"`
+	AdmissionID *uuid.UUID `json:"admission_id,omitempty"`
+	PatientID   *uuid.UUID `json:"patient_id,omitempty"`
+	PatientHN   string     `json:"patient_hn,omitempty"`
+	PatientName string     `json:"patient_name,omitempty"`
+	AdmittedAt  *time.Time `json:"admitted_at,omitempty"`
+}
+
+type BedBoardWard struct {
+	WardID        uuid.UUID     `json:"ward_id"`
+	Code          string        `json:"code"`
+	Name          string        `json:"name"`
+	TotalBeds     int           `json:"total_beds"`
+	Available     int           `json:"available"`
+	Occupied      int           `json:"occupied"`
+	Cleaning      int           `json:"cleaning"`
+	Blocked       int           `json:"blocked"`
+	OccupancyRate float64       `json:"occupancy_rate"`
+	Beds          []BedBoardBed `json:"beds"`
+}
+
+// ---------------- Billing ----------------
+// Amounts are in baht with up to two decimals.
+type PriceItemCreateRequest struct {
+	Code      string  `json:"code" validate:"required"`
+	Name      string  `json:"name" validate:"required"`
+	Category  string  `json:"category"`
+	UnitPrice float64 `json:"unit_price" validate:"required"`
+	VATable   bool    `json:"vatable"`
+}
+
+type PriceItemResponse struct {
+	PriceItemID uuid.UUID `json:"price_item_id"`
+	Code        string    `json:"code"`
+	Name        string    `json:"name"`
+	Category    string    `json:"category"`
+	UnitPrice   float64   `json:"unit_price"`
+	VATable     bool      `json:"vatable"`
+}
+
+type ChargeCreateRequest struct {
+	PriceItemID uuid.UUID  `json:"price_item_id" validate:"required"`
+	Quantity    int        `json:"quantity" validate:"required"`
+	Description string     `json:"description"`
+	ServiceDate *time.Time `json:"service_date"`
+}
+
+type ChargeResponse struct {
+	ChargeID    uuid.UUID  `json:"charge_id"`
+	PatientID   uuid.UUID  `json:"patient_id"`
+	PriceItemID uuid.UUID  `json:"price_item_id"`
+	Code        string     `json:"code"`
+	Description string     `json:"description"`
+	Quantity    int        `json:"quantity"`
+	UnitPrice   float64    `json:"unit_price"`
+	Amount      float64    `json:"amount"`
+	VATable     bool       `json:"vatable"`
+	ServiceDate time.Time  `json:"service_date"`
+	Status      string     `json:"status"`
+	InvoiceID   *uuid.UUID `json:"invoice_id,omitempty"`
+	PostedByID  uuid.UUID  `json:"posted_by_id"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type InvoiceCreateRequest struct {
+	ChargeIDs       []uuid.UUID `json:"charge_ids"`
+	DiscountAmount  float64     `json:"discount_amount"`
+	DiscountPercent float64     `json:"discount_percent"`
+}
+
+type InvoiceLineResponse struct {
+	ChargeID    uuid.UUID `json:"charge_id"`
+	Code        string    `json:"code"`
+	Description string    `json:"description"`
+	Quantity    int       `json:"quantity"`
+	UnitPrice   float64   `json:"unit_price"`
+	Amount      float64   `json:"amount"`
+	Discount    float64   `json:"discount"`
+	VATable     bool      `json:"vatable"`
+	VATAmount   float64   `json:"vat_amount"`
+}
+
+type PaymentCreateRequest struct {
+	Method    string  `json:"method" validate:"required"`
+	Amount    float64 `json:"amount" validate:"required"`
+	Reference string  `json:"reference"`
+}
+
+type PaymentResponse struct {
+	PaymentID    uuid.UUID `json:"payment_id"`
+	Method       string    `json:"method"`
+	Amount       float64   `json:"amount"`
+	Reference    string    `json:"reference"`
+	ReceivedByID uuid.UUID `json:"received_by_id"`
+	PaidAt       time.Time `json:"paid_at"`
+}
+
+type InvoiceResponse struct {
+	InvoiceID     uuid.UUID             `json:"invoice_id"`
+	Number        string                `json:"number"`
+	PatientID     uuid.UUID             `json:"patient_id"`
+	Status        string                `json:"status"`
+	Subtotal      float64               `json:"subtotal"`
+	DiscountTotal float64               `json:"discount_total"`
+	VATRate       float64               `json:"vat_rate"`
+	VATAmount     float64               `json:"vat_amount"`
+	Total         float64               `json:"total"`
+	Paid          float64               `json:"paid"`
+	Balance       float64               `json:"balance"`
+	IssuedByID    uuid.UUID             `json:"issued_by_id"`
+	IssuedAt      time.Time             `json:"issued_at"`
+	Lines         []InvoiceLineResponse `json:"lines"`
+	Payments      []PaymentResponse     `json:"payments"`
+}
+
+type PatientBalanceResponse struct {
+	PatientID          uuid.UUID `json:"patient_id"`
+	InvoiceOutstanding float64   `json:"invoice_outstanding"`
+	UninvoicedCharges  float64   `json:"uninvoiced_charges"`
+}
+
+// ---------------- Coverage ----------------
+type CoverageCreateRequest struct {
+	Scheme              string     `json:"scheme" validate:"required"`
+	PayerName           string     `json:"payer_name"`
+	MemberNumber        string     `json:"member_number" validate:"required"`
+	ValidFrom           *time.Time `json:"valid_from"`
+	ValidTo             *time.Time `json:"valid_to"`
+	PrimaryHospitalCode string     `json:"primary_hospital_code"`
+	PrimaryHospitalName string     `json:"primary_hospital_name"`
+}
+
+type CoverageResponse struct {
+	CoverageID           uuid.UUID  `json:"coverage_id"`
+	PatientID            uuid.UUID  `json:"patient_id"`
+	Scheme               string     `json:"scheme"`
+	PayerName            string     `json:"payer_name"`
+	MemberNumber         string     `json:"member_number"`
+	ValidFrom            time.Time  `json:"valid_from"`
+	ValidTo              *time.Time `json:"valid_to,omitempty"`
+	PrimaryHospitalCode  string     `json:"primary_hospital_code,omitempty"`
+	PrimaryHospitalName  string     `json:"primary_hospital_name,omitempty"`
+	EligibilityStatus    string     `json:"eligibility_status"`
+	EligibilityMessage   string     `json:"eligibility_message,omitempty"`
+	EligibilityCheckedAt *time.Time `json:"eligibility_checked_at,omitempty"`
+	CreatedAt            time.Time  `json:"created_at"`
+}
+
+// ---------------- Addresses and emergency contacts ----------------
+type AddressRequest struct {
+	Type        string `json:"type"`
+	HouseNumber string `json:"house_number" validate:"required"`
+	Moo         string `json:"moo"`
+	Village     string `json:"village"`
+	Soi         string `json:"soi"`
+	Road        string `json:"road"`
+	Subdistrict string `json:"subdistrict" validate:"required"`
+	District    string `json:"district" validate:"required"`
+	Province    string `json:"province" validate:"required"`
+	PostalCode  string `json:"postal_code" validate:"required"`
+}
+
+type AddressResponse struct {
+	AddressID   uuid.UUID `json:"address_id"`
+	PatientID   uuid.UUID `json:"patient_id"`
+	Type        string    `json:"type"`
+	HouseNumber string    `json:"house_number"`
+	Moo         string    `json:"moo,omitempty"`
+	Village     string    `json:"village,omitempty"`
+	Soi         string    `json:"soi,omitempty"`
+	Road        string    `json:"road,omitempty"`
+	Subdistrict string    `json:"subdistrict"`
+	District    string    `json:"district"`
+	Province    string    `json:"province"`
+	PostalCode  string    `json:"postal_code"`
+	Version     int       `json:"version"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type EmergencyContactRequest struct {
+	Name         string `json:"name" validate:"required"`
+	Relationship string `json:"relationship" validate:"required"`
+	PhoneNumber  string `json:"phone_number" validate:"required"`
+	Email        string `json:"email"`
+	IsNextOfKin  bool   `json:"is_next_of_kin"`
+	Priority     int    `json:"priority"`
+}
+
+type EmergencyContactResponse struct {
+	ContactID    uuid.UUID `json:"contact_id"`
+	PatientID    uuid.UUID `json:"patient_id"`
+	Name         string    `json:"name"`
+	Relationship string    `json:"relationship"`
+	PhoneNumber  string    `json:"phone_number"`
+	Email        string    `json:"email,omitempty"`
+	IsNextOfKin  bool      `json:"is_next_of_kin"`
+	Priority     int       `json:"priority"`
+	Version      int       `json:"version"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
+
+type AdminAreaResponse struct {
+	Subdistrict string `json:"subdistrict"`
+	District    string `json:"district"`
+	Province    string `json:"province"`
+	PostalCode  string `json:"postal_code"`
+}
+
+// ---------------- Consent ----------------
+type ConsentGrantRequest struct {
+	Purpose     string     `json:"purpose" validate:"required"`
+	TextVersion string     `json:"text_version" validate:"required"`
+	GrantedAt   *time.Time `json:"granted_at"`
+}
+
+type ConsentWithdrawRequest struct {
+	Reason string `json:"reason"`
+}
+
+type ConsentResponse struct {
+	ConsentID      uuid.UUID  `json:"consent_id"`
+	PatientID      uuid.UUID  `json:"patient_id"`
+	Purpose        string     `json:"purpose"`
+	TextVersion    string     `json:"text_version"`
+	Status         string     `json:"status"`
+	GrantedAt      time.Time  `json:"granted_at"`
+	WitnessedByID  uuid.UUID  `json:"witnessed_by_id"`
+	WithdrawnAt    *time.Time `json:"withdrawn_at,omitempty"`
+	WithdrawnByID  *uuid.UUID `json:"withdrawn_by_id,omitempty"`
+	WithdrawReason string     `json:"withdraw_reason,omitempty"`
+}
+
+type ConsentCheckResponse struct {
+	PatientID uuid.UUID        `json:"patient_id"`
+	Purpose   string           `json:"purpose"`
+	Granted   bool             `json:"granted"`
+	Consent   *ConsentResponse `json:"consent,omitempty"`
+}
+
+// ---------------- Referral ----------------
+type ReferralCreateRequest struct {
+	PatientID        uuid.UUID `json:"patient_id" validate:"required"`
+	TargetHospitalID uuid.UUID `json:"target_hospital_id" validate:"required"`
+	Reason           string    `json:"reason" validate:"required"`
+	Urgency          string    `json:"urgency"`
+	ClinicalSummary  string    `json:"clinical_summary" validate:"required"`
+}
+
+type ReferralAcceptRequest struct {
+	Note            string `json:"note"`
+	AccessDays      int    `json:"access_days"`
+	RegisterPatient bool   `json:"register_patient"`
+	PatientHN       string `json:"patient_hn"`
+}
+
+type ReferralRejectRequest struct {
+	Note string `json:"note" validate:"required"`
+}
+
+type ReferralResponse struct {
+	ReferralID         uuid.UUID  `json:"referral_id"`
+	PatientID          uuid.UUID  `json:"patient_id"`
+	PatientHN          string     `json:"patient_hn"`
+	PatientName        string     `json:"patient_name"`
+	SourceHospitalID   uuid.UUID  `json:"source_hospital_id"`
+	SourceHospitalName string     `json:"source_hospital_name"`
+	TargetHospitalID   uuid.UUID  `json:"target_hospital_id"`
+	TargetHospitalName string     `json:"target_hospital_name"`
+	Reason             string     `json:"reason"`
+	Urgency            string     `json:"urgency"`
+	ClinicalSummary    string     `json:"clinical_summary"`
+	Status             string     `json:"status"`
+	ReferredByID       uuid.UUID  `json:"referred_by_id"`
+	RespondedByID      *uuid.UUID `json:"responded_by_id,omitempty"`
+	RespondedAt        *time.Time `json:"responded_at,omitempty"`
+	ResponseNote       string     `json:"response_note,omitempty"`
+	AccessExpiresAt    *time.Time `json:"access_expires_at,omitempty"`
+	LinkedPatientID    *uuid.UUID `json:"linked_patient_id,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+type ReferredPatientResponse struct {
+	ReferralID      uuid.UUID       `json:"referral_id"`
+	ClinicalSummary string          `json:"clinical_summary"`
+	AccessExpiresAt *time.Time      `json:"access_expires_at,omitempty"`
+	Patient         PatientResponse `json:"patient"`
+}
+
+// ---------------- Master patient index ----------------
+type EnterpriseLinkResponse struct {
+	EnterpriseID    uuid.UUID `json:"enterprise_id"`
+	PatientID       uuid.UUID `json:"patient_id"`
+	Method          string    `json:"method"`
+	Score           float64   `json:"score"`
+	QueuedForReview int       `json:"queued_for_review"`
+}
+
+type EnterpriseRecordResponse struct {
+	PatientID    uuid.UUID `json:"patient_id"`
+	HospitalID   uuid.UUID `json:"hospital_id"`
+	HospitalName string    `json:"hospital_name"`
+	PatientHN    string    `json:"patient_hn"`
+	Method       string    `json:"method"`
+	Score        float64   `json:"score"`
+}
+
+type EnterpriseLookupResponse struct {
+	EnterpriseID uuid.UUID                  `json:"enterprise_id"`
+	Records      []EnterpriseRecordResponse `json:"records"`
+	// RestrictedRecords counts linked records withheld for lack of data sharing consent
+	RestrictedRecords int `json:"restricted_records"`
+}
+
+// MatchSideResponse identifies one record of a match; patient details are only filled in for the caller's own hospital
+type MatchSideResponse struct {
+	HospitalID   uuid.UUID  `json:"hospital_id"`
+	HospitalName string     `json:"hospital_name"`
+	PatientID    *uuid.UUID `json:"patient_id,omitempty"`
+	PatientHN    string     `json:"patient_hn,omitempty"`
+	PatientName  string     `json:"patient_name,omitempty"`
+}
+
+type MatchCandidateResponse struct {
+	CandidateID  uuid.UUID         `json:"candidate_id"`
+	Score        float64           `json:"score"`
+	Reasons      string            `json:"reasons"`
+	Status       string            `json:"status"`
+	Patient      MatchSideResponse `json:"patient"`
+	Candidate    MatchSideResponse `json:"candidate"`
+	ReviewedByID *uuid.UUID        `json:"reviewed_by_id,omitempty"`
+	ReviewedAt   *time.Time        `json:"reviewed_at,omitempty"`
+	CreatedAt    time.Time         `json:"created_at"`
+}
+
+// ---------------- Break-the-glass ----------------
+type EmergencyAccessRequest struct {
+	PatientID       uuid.UUID `json:"patient_id" validate:"required"`
+	Reason          string    `json:"reason" validate:"required"`
+	DurationMinutes int       `json:"duration_minutes"`
+}
+
+type EmergencyAccessReviewRequest struct {
+	Outcome string `json:"outcome" validate:"required"`
+	Note    string `json:"note"`
+}
+
+type EmergencyAccessResponse struct {
+	AccessID          uuid.UUID  `json:"access_id"`
+	StaffID           uuid.UUID  `json:"staff_id"`
+	StaffUsername     string     `json:"staff_username"`
+	StaffRole         string     `json:"staff_role"`
+	StaffHospitalID   uuid.UUID  `json:"staff_hospital_id"`
+	PatientID         uuid.UUID  `json:"patient_id"`
+	PatientHN         string     `json:"patient_hn"`
+	PatientHospitalID uuid.UUID  `json:"patient_hospital_id"`
+	PatientHospital   string     `json:"patient_hospital_name"`
+	Reason            string     `json:"reason"`
+	GrantedAt         time.Time  `json:"granted_at"`
+	ExpiresAt         time.Time  `json:"expires_at"`
+	ReviewStatus      string     `json:"review_status"`
+	ReviewedByID      *uuid.UUID `json:"reviewed_by_id,omitempty"`
+	ReviewedAt        *time.Time `json:"reviewed_at,omitempty"`
+	ReviewNote        string     `json:"review_note,omitempty"`
+}
+
+type EmergencyPatientResponse struct {
+	AccessID  uuid.UUID       `json:"access_id"`
+	ExpiresAt time.Time       `json:"expires_at"`
+	Patient   PatientResponse `json:"patient"`
+}
+
+type AuditEventResponse struct {
+	EventID   uuid.UUID  `json:"event_id"`
+	StaffID   uuid.UUID  `json:"staff_id"`
+	Action    string     `json:"action"`
+	Priority  string     `json:"priority"`
+	PatientID *uuid.UUID `json:"patient_id,omitempty"`
+	Details   string     `json:"details"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// ---------------- Retention ----------------
+type PatientDeleteRequest struct {
+	Reason string `json:"reason"`
+}
+
+type HospitalDeleteRequest struct {
+	Reason string `json:"reason"`
+}
+
+type DeletedPatientResponse struct {
+	PatientID   uuid.UUID `json:"patient_id"`
+	PatientHN   string    `json:"patient_hn"`
+	PatientName string    `json:"patient_name"`
+	DeletedAt   time.Time `json:"deleted_at"`
+	// PurgeAfter is when the retention purge will anonymise or delete the record
+	PurgeAfter time.Time `json:"purge_after"`
+}
+
+type RetentionPolicyRequest struct {
+	RetentionYears int    `json:"retention_years" validate:"required"`
+	Action         string `json:"action"`
+}
+
+type RetentionPolicyResponse struct {
+	HospitalID     uuid.UUID  `json:"hospital_id"`
+	RetentionYears int        `json:"retention_years"`
+	Action         string     `json:"action"`
+	Version        int        `json:"version"`
+	UpdatedByID    *uuid.UUID `json:"updated_by_id,omitempty"`
+	UpdatedAt      *time.Time `json:"updated_at,omitempty"`
+}
+
+// ---------------- Patient history ----------------
+// PatientUpdateRequest changes only the fields that are present; an empty string clears a field
+type PatientUpdateRequest struct {
+	FirstNameTh  *string    `json:"first_name_th"`
+	MiddleNameTh *string    `json:"middle_name_th"`
+	LastNameTh   *string    `json:"last_name_th"`
+	FirstNameEn  *string    `json:"first_name_en"`
+	MiddleNameEn *string    `json:"middle_name_en"`
+	LastNameEn   *string    `json:"last_name_en"`
+	DateOfBirth  *time.Time `json:"date_of_birth"`
+	NationalID   *string    `json:"national_id"`
+	PassportID   *string    `json:"passport_id"`
+	PhoneNumber  *string    `json:"phone_number"`
+	Email        *string    `json:"email"`
+	Gender       *string    `json:"gender"`
+}
+
+type FieldChangeResponse struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+type PatientVersionResponse struct {
+	Version     int                   `json:"version"`
+	Operation   string                `json:"operation"`
+	ChangedByID *uuid.UUID            `json:"changed_by_id,omitempty"`
+	ChangedAt   time.Time             `json:"changed_at"`
+	Changes     []FieldChangeResponse `json:"changes"`
+}
+
+// ---------------- HL7 v2 interface ----------------
+type HL7MessageResponse struct {
+	MessageID          uuid.UUID  `json:"message_id"`
+	ControlID          string     `json:"control_id"`
+	SendingApplication string     `json:"sending_application"`
+	SendingFacility    string     `json:"sending_facility"`
+	MessageType        string     `json:"message_type"`
+	PatientClass       string     `json:"patient_class,omitempty"`
+	Location           string     `json:"location,omitempty"`
+	VisitNumber        string     `json:"visit_number,omitempty"`
+	Status             string     `json:"status"`
+	AckCode            string     `json:"ack_code,omitempty"`
+	Error              string     `json:"error,omitempty"`
+	PatientID          *uuid.UUID `json:"patient_id,omitempty"`
+	Attempts           int        `json:"attempts"`
+	ReceivedAt         time.Time  `json:"received_at"`
+	ProcessedAt        *time.Time `json:"processed_at,omitempty"`
+	Raw                string     `json:"raw"`
+}
+
+// ---------------- Patient import ----------------
+type PatientImportResponse struct {
+	ImportID      uuid.UUID  `json:"import_id"`
+	FileName      string     `json:"file_name"`
+	DryRun        bool       `json:"dry_run"`
+	Status        string     `json:"status"`
+	TotalRows     int        `json:"total_rows"`
+	ProcessedRows int        `json:"processed_rows"`
+	ImportedRows  int        `json:"imported_rows"`
+	FailedRows    int        `json:"failed_rows"`
+	Percent       int        `json:"percent"`
+	Error         string     `json:"error,omitempty"`
+	ErrorReport   string     `json:"error_report"`
+	CreatedAt     time.Time  `json:"created_at"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty"`
+}
+
+// ---------------- Webhooks ----------------
+type WebhookSubscriptionRequest struct {
+	URL         string `json:"url" validate:"required"`
+	Description string `json:"description"`
+	// EventTypes limits the subscription to these event types; empty means every event.
+	EventTypes []string `json:"event_types"`
+}
+
+type WebhookSubscriptionResponse struct {
+	SubscriptionID uuid.UUID `json:"subscription_id"`
+	URL            string    `json:"url"`
+	Description    string    `json:"description,omitempty"`
+	EventTypes     []string  `json:"event_types"`
+	Version        int       `json:"version"`
+	// Secret is only returned when the subscription is created.
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WebhookDeliveryResponse struct {
+	DeliveryID     uuid.UUID  `json:"delivery_id"`
+	SubscriptionID uuid.UUID  `json:"subscription_id"`
+	EventID        uuid.UUID  `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at,omitempty"`
+	LastStatusCode int        `json:"last_status_code,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
+
+// WebhookEvent is the body of every webhook request. Data is a PatientEventData or a
+// StaffEventData, depending on the type.
+type WebhookEvent struct {
+	EventID    uuid.UUID       `json:"event_id"`
+	Type       string          `json:"type"`
+	HospitalID uuid.UUID       `json:"hospital_id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data" swaggertype:"object"`
+}
+
+type PatientEventData struct {
+	PatientID    uuid.UUID  `json:"patient_id"`
+	Version      int        `json:"version"`
+	PatientHN    string     `json:"patient_hn"`
+	FirstNameTH  string     `json:"first_name_th"`
+	MiddleNameTH string     `json:"middle_name_th"`
+	LastNameTH   string     `json:"last_name_th"`
+	FirstNameEN  string     `json:"first_name_en"`
+	MiddleNameEN string     `json:"middle_name_en"`
+	LastNameEN   string     `json:"last_name_en"`
+	DateOfBirth  *time.Time `json:"date_of_birth"`
+	NationalID   string     `json:"national_id"`
+	PassportID   string     `json:"passport_id"`
+	PhoneNumber  string     `json:"phone_number"`
+	Email        string     `json:"email"`
+	Gender       string     `json:"gender"`
+	// MergedIntoID is set on patient.merged: the patient was a duplicate of this one.
+	MergedIntoID *uuid.UUID `json:"merged_into_id,omitempty"`
+}
+
+type StaffEventData struct {
+	StaffID  uuid.UUID `json:"staff_id"`
+	Username string    `json:"username"`
+	Role     string    `json:"role"`
+}
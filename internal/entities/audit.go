@@ -0,0 +1,24 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AuditNormal = "normal"
+	AuditHigh   = "high"
+)
+
+// AuditEvent records a sensitive action for later review. Events are never updated or deleted.
+type AuditEvent struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	HospitalID uuid.UUID  `gorm:"type:uuid;not null;index"`
+	StaffID    uuid.UUID  `gorm:"type:uuid;not null"`
+	Action     string     `gorm:"not null"`
+	Priority   string     `gorm:"not null;default:normal;index"`
+	PatientID  *uuid.UUID `gorm:"type:uuid;index"`
+	Details    string     `gorm:"type:text"`
+	CreatedAt  time.Time  `gorm:"index"`
+}
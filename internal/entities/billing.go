@@ -0,0 +1,114 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Money amounts are stored in satang (1/100 baht) to keep arithmetic exact.
+
+const (
+	ChargePosted   = "posted"
+	ChargeInvoiced = "invoiced"
+	ChargeVoided   = "voided"
+)
+
+const (
+	InvoiceIssued        = "issued"
+	InvoicePartiallyPaid = "partially_paid"
+	InvoicePaid          = "paid"
+)
+
+const (
+	PaymentCash      = "cash"
+	PaymentCard      = "card"
+	PaymentInsurance = "insurance"
+)
+
+// PriceItem is a billable item of a hospital's price list.
+type PriceItem struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	HospitalID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_price_item_hospital_code"`
+	Hospital   Hospital  `gorm:"foreignKey:HospitalID"`
+	Code       string    `gorm:"not null;uniqueIndex:idx_price_item_hospital_code"`
+	Name       string    `gorm:"not null"`
+	Category   string
+	UnitPrice  int64 `gorm:"not null"`
+	VATable    bool  `gorm:"not null;default:false"`
+	Active     bool  `gorm:"not null;default:true"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// Charge is a billable item posted against a patient, waiting to be invoiced.
+type Charge struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	PatientID   uuid.UUID  `gorm:"type:uuid;not null;index"`
+	Patient     Patient    `gorm:"foreignKey:PatientID"`
+	HospitalID  uuid.UUID  `gorm:"type:uuid;not null"`
+	PriceItemID uuid.UUID  `gorm:"type:uuid;not null"`
+	Code        string     `gorm:"not null"`
+	Description string     `gorm:"not null"`
+	Quantity    int        `gorm:"not null"`
+	UnitPrice   int64      `gorm:"not null"`
+	VATable     bool       `gorm:"not null"`
+	ServiceDate time.Time  `gorm:"not null"`
+	Status      string     `gorm:"not null;default:posted;index"`
+	InvoiceID   *uuid.UUID `gorm:"type:uuid;index"`
+	PostedByID  uuid.UUID  `gorm:"type:uuid;not null"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// Invoice bills a set of charges. Totals are fixed when the invoice is issued.
+type Invoice struct {
+	ID            uuid.UUID     `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	HospitalID    uuid.UUID     `gorm:"type:uuid;not null;uniqueIndex:idx_invoice_hospital_number"`
+	Hospital      Hospital      `gorm:"foreignKey:HospitalID"`
+	Number        string        `gorm:"not null;uniqueIndex:idx_invoice_hospital_number"`
+	PatientID     uuid.UUID     `gorm:"type:uuid;not null;index"`
+	Patient       Patient       `gorm:"foreignKey:PatientID"`
+	Status        string        `gorm:"not null;default:issued"`
+	Subtotal      int64         `gorm:"not null"`
+	DiscountTotal int64         `gorm:"not null"`
+	VATAmount     int64         `gorm:"not null"`
+	Total         int64         `gorm:"not null"`
+	Paid          int64         `gorm:"not null;default:0"`
+	IssuedByID    uuid.UUID     `gorm:"type:uuid;not null"`
+	IssuedAt      time.Time     `gorm:"not null"`
+	Lines         []InvoiceLine `gorm:"foreignKey:InvoiceID"`
+	Payments      []Payment     `gorm:"foreignKey:InvoiceID"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// InvoiceLine is one charge on an invoice with its share of the discount and VAT.
+type InvoiceLine struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	InvoiceID   uuid.UUID `gorm:"type:uuid;not null;index"`
+	ChargeID    uuid.UUID `gorm:"type:uuid;not null"`
+	Code        string    `gorm:"not null"`
+	Description string    `gorm:"not null"`
+	Quantity    int       `gorm:"not null"`
+	UnitPrice   int64     `gorm:"not null"`
+	Amount      int64     `gorm:"not null"`
+	Discount    int64     `gorm:"not null"`
+	VATable     bool      `gorm:"not null"`
+	VATAmount   int64     `gorm:"not null"`
+	CreatedAt   time.Time
+}
+
+// Payment is money received against an invoice.
+type Payment struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	InvoiceID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	HospitalID   uuid.UUID `gorm:"type:uuid;not null"`
+	PatientID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	Method       string    `gorm:"not null"`
+	Amount       int64     `gorm:"not null"`
+	Reference    string
+	ReceivedByID uuid.UUID `gorm:"type:uuid;not null"`
+	PaidAt       time.Time `gorm:"not null"`
+	CreatedAt    time.Time
+}
@@ -0,0 +1,40 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Consent purposes recorded under the PDPA.
+const (
+	ConsentTreatment   = "treatment"
+	ConsentDataSharing = "data_sharing"
+	ConsentMarketing   = "marketing"
+	ConsentResearch    = "research"
+)
+
+const (
+	ConsentGranted    = "granted"
+	ConsentWithdrawn  = "withdrawn"
+	ConsentSuperseded = "superseded"
+)
+
+// Consent is a patient's consent for one purpose under a given version of the consent text.
+// Granting again supersedes the previous record, so at most one consent per purpose is granted.
+type Consent struct {
+	ID             uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	PatientID      uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_consent_granted_purpose,where:status = 'granted'"`
+	Patient        Patient   `gorm:"foreignKey:PatientID"`
+	HospitalID     uuid.UUID `gorm:"type:uuid;not null;index"`
+	Purpose        string    `gorm:"not null;uniqueIndex:idx_consent_granted_purpose,where:status = 'granted'"`
+	TextVersion    string    `gorm:"not null"`
+	Status         string    `gorm:"not null;default:granted"`
+	GrantedAt      time.Time `gorm:"not null"`
+	WitnessedByID  uuid.UUID `gorm:"type:uuid;not null"`
+	WithdrawnAt    *time.Time
+	WithdrawnByID  *uuid.UUID `gorm:"type:uuid"`
+	WithdrawReason string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
@@ -0,0 +1,53 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AddressHome    = "home"
+	AddressCurrent = "current"
+	AddressWork    = "work"
+)
+
+// PatientAddress is a structured Thai address. A patient has at most one address of each type.
+type PatientAddress struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	PatientID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_patient_address_type"`
+	Patient     Patient   `gorm:"foreignKey:PatientID"`
+	HospitalID  uuid.UUID `gorm:"type:uuid;not null"`
+	Type        string    `gorm:"not null;uniqueIndex:idx_patient_address_type"`
+	HouseNumber string    `gorm:"not null"`
+	Moo         string
+	Village     string
+	Soi         string
+	Road        string
+	Subdistrict string `gorm:"not null"`
+	District    string `gorm:"not null;index:idx_patient_address_area"`
+	Province    string `gorm:"not null;index:idx_patient_address_area"`
+	PostalCode  string `gorm:"not null"`
+	// Version is raised by every change and serves as the ETag for optimistic concurrency.
+	Version   int `gorm:"not null;default:1"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// EmergencyContact is a person to call about the patient; next of kin are flagged.
+type EmergencyContact struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	PatientID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	Patient      Patient   `gorm:"foreignKey:PatientID"`
+	HospitalID   uuid.UUID `gorm:"type:uuid;not null"`
+	Name         string    `gorm:"not null"`
+	Relationship string    `gorm:"not null"`
+	PhoneNumber  string    `gorm:"not null"`
+	Email        string
+	IsNextOfKin  bool `gorm:"not null;default:false"`
+	Priority     int  `gorm:"not null;default:1"`
+	// Version is raised by every change and serves as the ETag for optimistic concurrency.
+	Version   int `gorm:"not null;default:1"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
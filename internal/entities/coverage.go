@@ -0,0 +1,52 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Payer schemes. UC is the universal coverage ("30 baht") scheme, SSS the social security
+// scheme and CSMBS the civil servant medical benefit scheme.
+const (
+	SchemeUC      = "uc"
+	SchemeSSS     = "sss"
+	SchemeCSMBS   = "csmbs"
+	SchemePrivate = "private"
+)
+
+const (
+	EligibilityUnverified = "unverified"
+	EligibilityEligible   = "eligible"
+	EligibilityIneligible = "ineligible"
+)
+
+// Coverage is a payer that covers a patient for a period, with the result of the last eligibility check.
+type Coverage struct {
+	ID                   uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	PatientID            uuid.UUID `gorm:"type:uuid;not null;index"`
+	Patient              Patient   `gorm:"foreignKey:PatientID"`
+	HospitalID           uuid.UUID `gorm:"type:uuid;not null"`
+	Scheme               string    `gorm:"not null"`
+	PayerName            string
+	MemberNumber         string     `gorm:"not null"`
+	ValidFrom            time.Time  `gorm:"type:date;not null"`
+	ValidTo              *time.Time `gorm:"type:date"`
+	PrimaryHospitalCode  string
+	PrimaryHospitalName  string
+	EligibilityStatus    string `gorm:"not null;default:unverified"`
+	EligibilityMessage   string
+	EligibilityCheckedAt *time.Time
+	RecordedByID         uuid.UUID `gorm:"type:uuid;not null"`
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+}
+
+// ActiveOn reports whether the coverage period includes the given day.
+func (c Coverage) ActiveOn(t time.Time) bool {
+	day := t.Format("2006-01-02")
+	if c.ValidFrom.Format("2006-01-02") > day {
+		return false
+	}
+	return c.ValidTo == nil || c.ValidTo.Format("2006-01-02") >= day
+}
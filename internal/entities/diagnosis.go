@@ -0,0 +1,42 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EditionICD10   = "ICD-10"
+	EditionICD10TM = "ICD-10-TM"
+)
+
+// ICD10Code is one entry of the locally imported ICD-10 / ICD-10-TM catalogue.
+type ICD10Code struct {
+	Code          string `gorm:"primaryKey"`
+	Edition       string `gorm:"primaryKey"`
+	Description   string `gorm:"not null"`
+	DescriptionTH string
+	Billable      bool `gorm:"not null;default:true"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// Diagnosis is a coded diagnosis recorded against a patient for one visit date. The visit is
+// the encounter: idx_diagnosis_primary allows one primary diagnosis per patient and visit date.
+type Diagnosis struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	PatientID    uuid.UUID `gorm:"type:uuid;not null;index:idx_diagnosis_visit;uniqueIndex:idx_diagnosis_primary,where:is_primary"`
+	Patient      Patient   `gorm:"foreignKey:PatientID"`
+	HospitalID   uuid.UUID `gorm:"type:uuid;not null"`
+	Hospital     Hospital  `gorm:"foreignKey:HospitalID"`
+	VisitDate    time.Time `gorm:"type:date;not null;index:idx_diagnosis_visit;uniqueIndex:idx_diagnosis_primary,where:is_primary"`
+	Code         string    `gorm:"not null"`
+	Edition      string    `gorm:"not null"`
+	IsPrimary    bool      `gorm:"not null;default:false"`
+	Note         string
+	RecordedByID uuid.UUID `gorm:"type:uuid;not null"`
+	RecordedBy   Staff     `gorm:"foreignKey:RecordedByID"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
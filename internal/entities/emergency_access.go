@@ -0,0 +1,34 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	EmergencyReviewPending     = "pending"
+	EmergencyReviewJustified   = "justified"
+	EmergencyReviewUnjustified = "unjustified"
+)
+
+// EmergencyAccess is a break-the-glass grant: time-limited access for one staff member to one
+// patient outside their normal hospital scope. Every grant must be reviewed by a supervisor.
+type EmergencyAccess struct {
+	ID                uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	StaffID           uuid.UUID  `gorm:"type:uuid;not null;index"`
+	Staff             Staff      `gorm:"foreignKey:StaffID"`
+	StaffHospitalID   uuid.UUID  `gorm:"type:uuid;not null;index"`
+	PatientID         uuid.UUID  `gorm:"type:uuid;not null;index"`
+	Patient           Patient    `gorm:"foreignKey:PatientID"`
+	PatientHospitalID uuid.UUID  `gorm:"type:uuid;not null;index"`
+	Reason            string     `gorm:"type:text;not null"`
+	GrantedAt         time.Time  `gorm:"not null"`
+	ExpiresAt         time.Time  `gorm:"not null"`
+	ReviewStatus      string     `gorm:"not null;default:pending;index"`
+	ReviewedByID      *uuid.UUID `gorm:"type:uuid"`
+	ReviewedAt        *time.Time
+	ReviewNote        string
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
@@ -0,0 +1,36 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	HL7Received  = "received"
+	HL7Processed = "processed"
+	HL7Failed    = "failed"
+)
+
+// HL7Message is the log of an HL7 v2 message received over MLLP. The raw text is kept so a
+// failed message can be replayed once the problem is fixed.
+type HL7Message struct {
+	ID                 uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	HospitalID         uuid.UUID `gorm:"type:uuid;not null;index"`
+	ControlID          string    `gorm:"index"`
+	SendingApplication string
+	SendingFacility    string
+	MessageType        string
+	// Visit details from PV1, for reference; admissions are managed through the ward endpoints.
+	PatientClass string
+	Location     string
+	VisitNumber  string
+	Raw          string `gorm:"type:text;not null"`
+	Status       string `gorm:"not null;default:received;index"`
+	AckCode      string
+	Error        string
+	PatientID    *uuid.UUID `gorm:"type:uuid"`
+	Attempts     int        `gorm:"not null;default:1"`
+	ReceivedAt   time.Time  `gorm:"index"`
+	ProcessedAt  *time.Time
+}
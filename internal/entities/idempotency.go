@@ -0,0 +1,24 @@
+package entities
+
+import "time"
+
+// IdempotencyKey remembers a POST made with an Idempotency-Key header so a retry of the same
+// request gets the stored response instead of running again. Keys are scoped to the caller: the
+// staff ID, or on unauthenticated routes "public:" with the hospital and username of the body.
+type IdempotencyKey struct {
+	Scope       string `gorm:"primaryKey"`
+	Key         string `gorm:"primaryKey"`
+	Method      string `gorm:"not null"`
+	Path        string `gorm:"not null"`
+	RequestHash string `gorm:"not null"`
+	// StatusCode is 0 while the first request is still running.
+	StatusCode  int
+	ContentType string
+	// ETag and Location are the response headers replayed along with the body
+	ETag        string
+	Location    string
+	Response    []byte
+	CreatedAt   time.Time
+	CompletedAt *time.Time
+	ExpiresAt   time.Time `gorm:"not null;index"`
+}
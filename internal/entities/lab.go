@@ -0,0 +1,102 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	LabValueNumeric = "numeric"
+	LabValueText    = "text"
+)
+
+// Specimen tracking states of a lab order.
+const (
+	LabOrderOrdered   = "ordered"
+	LabOrderCollected = "collected"
+	LabOrderReceived  = "received"
+	LabOrderCompleted = "completed"
+	LabOrderRejected  = "rejected"
+	LabOrderCancelled = "cancelled"
+)
+
+const (
+	LabResultPreliminary = "preliminary"
+	LabResultFinal       = "final"
+	LabResultAmended     = "amended"
+)
+
+// LabTest is a lab test catalogue entry of a hospital.
+type LabTest struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	HospitalID   uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_lab_test_hospital_code"`
+	Hospital     Hospital  `gorm:"foreignKey:HospitalID"`
+	Code         string    `gorm:"not null;uniqueIndex:idx_lab_test_hospital_code"`
+	Name         string    `gorm:"not null"`
+	SpecimenType string
+	ValueType    string `gorm:"not null;default:numeric"`
+	Unit         string
+	RefLow       *float64
+	RefHigh      *float64
+	RefText      string
+	Active       bool `gorm:"not null;default:true"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// LabOrder is a set of tests ordered by a doctor for one specimen collection.
+type LabOrder struct {
+	ID            uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	PatientID     uuid.UUID `gorm:"type:uuid;not null;index"`
+	Patient       Patient   `gorm:"foreignKey:PatientID"`
+	HospitalID    uuid.UUID `gorm:"type:uuid;not null"`
+	OrderedByID   uuid.UUID `gorm:"type:uuid;not null"`
+	OrderedBy     Staff     `gorm:"foreignKey:OrderedByID"`
+	Priority      string    `gorm:"not null;default:routine"`
+	ClinicalNote  string
+	Status        string `gorm:"not null;default:ordered;index"`
+	StatusReason  string
+	SpecimenLabel string
+	CollectedAt   *time.Time
+	CollectedByID *uuid.UUID `gorm:"type:uuid"`
+	ReceivedAt    *time.Time
+	ReceivedByID  *uuid.UUID     `gorm:"type:uuid"`
+	Items         []LabOrderItem `gorm:"foreignKey:OrderID"`
+	// Version is raised by every change and serves as the ETag for optimistic concurrency.
+	Version   int `gorm:"not null;default:1"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// LabOrderItem is one test within a lab order.
+type LabOrderItem struct {
+	ID        uuid.UUID   `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	OrderID   uuid.UUID   `gorm:"type:uuid;not null;index"`
+	LabTestID uuid.UUID   `gorm:"type:uuid;not null"`
+	LabTest   LabTest     `gorm:"foreignKey:LabTestID"`
+	Results   []LabResult `gorm:"foreignKey:OrderItemID"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// LabResult is one version of a result. Results are never updated in place: finalising or
+// amending adds a new version, so earlier values stay available for audit.
+type LabResult struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	OrderItemID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_lab_result_version"`
+	Version      int       `gorm:"not null;uniqueIndex:idx_lab_result_version"`
+	Status       string    `gorm:"not null"`
+	ValueNumeric *float64
+	ValueText    string
+	Unit         string
+	RefLow       *float64
+	RefHigh      *float64
+	RefText      string
+	AbnormalFlag string
+	Comment      string
+	AmendReason  string
+	EnteredByID  uuid.UUID `gorm:"type:uuid;not null"`
+	EnteredBy    Staff     `gorm:"foreignKey:EnteredByID"`
+	CreatedAt    time.Time
+}
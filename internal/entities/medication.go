@@ -0,0 +1,69 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	PrescriptionOrdered   = "ordered"
+	PrescriptionDispensed = "dispensed"
+	PrescriptionCancelled = "cancelled"
+)
+
+// Drug is a formulary entry of a hospital, imported from a local file.
+type Drug struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	HospitalID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_drug_hospital_code"`
+	Hospital    Hospital  `gorm:"foreignKey:HospitalID"`
+	Code        string    `gorm:"not null;uniqueIndex:idx_drug_hospital_code"`
+	Name        string    `gorm:"not null"`
+	GenericName string    `gorm:"not null;index"`
+	DrugClass   string
+	Strength    string
+	Form        string
+	Route       string
+	Active      bool `gorm:"not null;default:true"`
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+// PatientAllergy records a drug allergy by generic name or drug class.
+type PatientAllergy struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	PatientID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	Patient      Patient   `gorm:"foreignKey:PatientID"`
+	HospitalID   uuid.UUID `gorm:"type:uuid;not null"`
+	Substance    string    `gorm:"not null"`
+	Reaction     string
+	Severity     string
+	RecordedByID uuid.UUID `gorm:"type:uuid;not null"`
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// Prescription is a medication order for a patient written by a prescriber.
+type Prescription struct {
+	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	PatientID    uuid.UUID `gorm:"type:uuid;not null;index"`
+	Patient      Patient   `gorm:"foreignKey:PatientID"`
+	HospitalID   uuid.UUID `gorm:"type:uuid;not null"`
+	PrescriberID uuid.UUID `gorm:"type:uuid;not null"`
+	Prescriber   Staff     `gorm:"foreignKey:PrescriberID"`
+	DrugID       uuid.UUID `gorm:"type:uuid;not null"`
+	Drug         Drug      `gorm:"foreignKey:DrugID"`
+	Dose         string    `gorm:"not null"`
+	Route        string    `gorm:"not null"`
+	Frequency    string    `gorm:"not null"`
+	DurationDays int
+	Status       string `gorm:"not null;default:ordered;index"`
+	Note         string
+	CancelReason string
+	DispensedAt  *time.Time
+	CancelledAt  *time.Time
+	// Version is raised by every change and serves as the ETag for optimistic concurrency.
+	Version   int `gorm:"not null;default:1"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -0,0 +1,58 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// How a patient record was linked to its enterprise patient.
+const (
+	MatchNationalID    = "national_id"
+	MatchPassport      = "passport"
+	MatchProbabilistic = "probabilistic"
+	MatchManual        = "manual"
+	MatchNew           = "new"
+)
+
+const (
+	CandidatePending  = "pending"
+	CandidateLinked   = "linked"
+	CandidateRejected = "rejected"
+)
+
+// EnterprisePatient is one person across all hospitals in the master patient index.
+type EnterprisePatient struct {
+	ID        uuid.UUID     `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	Links     []PatientLink `gorm:"foreignKey:EnterpriseID"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// PatientLink assigns a hospital's patient record to an enterprise patient.
+type PatientLink struct {
+	PatientID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Patient      Patient   `gorm:"foreignKey:PatientID"`
+	EnterpriseID uuid.UUID `gorm:"type:uuid;not null;index"`
+	HospitalID   uuid.UUID `gorm:"type:uuid;not null"`
+	Method       string    `gorm:"not null"`
+	Score        float64
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// MatchCandidate is an uncertain probabilistic match waiting for a person to review it.
+type MatchCandidate struct {
+	ID                 uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	PatientID          uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_match_candidate_pair"`
+	Patient            Patient    `gorm:"foreignKey:PatientID"`
+	CandidatePatientID uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_match_candidate_pair"`
+	CandidatePatient   Patient    `gorm:"foreignKey:CandidatePatientID"`
+	Score              float64    `gorm:"not null"`
+	Reasons            string     `gorm:"type:text"`
+	Status             string     `gorm:"not null;default:pending;index"`
+	ReviewedByID       *uuid.UUID `gorm:"type:uuid"`
+	ReviewedAt         *time.Time
+	CreatedAt          time.Time
+	UpdatedAt          time.Time
+}
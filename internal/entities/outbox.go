@@ -0,0 +1,43 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Lifecycle events written to the outbox
+const (
+	EventPatientCreated  = "patient.created"
+	EventPatientUpdated  = "patient.updated"
+	EventPatientDeleted  = "patient.deleted"
+	EventPatientRestored = "patient.restored"
+	EventPatientMerged   = "patient.merged"
+	EventStaffCreated    = "staff.created"
+	EventStaffDeleted    = "staff.deleted"
+	EventStaffRestored   = "staff.restored"
+)
+
+// EventTypes lists every event type, in the order they are documented
+var EventTypes = []string{
+	EventPatientCreated, EventPatientUpdated, EventPatientDeleted, EventPatientRestored, EventPatientMerged,
+	EventStaffCreated, EventStaffDeleted, EventStaffRestored,
+}
+
+// OutboxEvent is a lifecycle event written in the same transaction as the change it describes,
+// so an event exists exactly when its change was committed. The event dispatcher hands it to the
+// event sinks and sets DispatchedAt once every sink has taken it; until then it is tried again at
+// NextAttemptAt, and FailedSinks names the comma-separated sinks that still have to take it.
+type OutboxEvent struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	HospitalID    uuid.UUID  `gorm:"type:uuid;not null;index"`
+	Type          string     `gorm:"not null"`
+	SubjectID     uuid.UUID  `gorm:"type:uuid;not null"`
+	Data          string     `gorm:"type:jsonb;not null"`
+	CreatedAt     time.Time  `gorm:"not null;index"`
+	DispatchedAt  *time.Time `gorm:"index"`
+	Attempts      int        `gorm:"not null;default:0"`
+	NextAttemptAt time.Time  `gorm:"index"`
+	FailedSinks   string
+	LastError     string
+}
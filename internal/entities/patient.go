@@ -4,8 +4,12 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+// Patient records are never hard deleted by the API. A deleted patient is kept until the
+// hospital's retention period has passed and is then anonymised or purged. An HN is unique
+// among the hospital's patients that are not deleted.
 type Patient struct {
 	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
 	FirstNameTH  string
@@ -15,14 +19,22 @@ type Patient struct {
 	MiddleNameEN string
 	LastNameEN   string
 	DateOfBirth  *time.Time
-	PatientHN    string
+	PatientHN    string `gorm:"uniqueIndex:idx_patient_hospital_hn,where:deleted_at IS NULL"`
 	NationalID   string
 	PassportID   string
 	PhoneNumber  string
 	Email        string
 	Gender       string
-	HospitalID   uuid.UUID `gorm:"type:uuid;not null"`
-	Hospital     Hospital  `gorm:"foreignKey:HospitalID"`
+	HospitalID   uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_patient_hospital_hn,priority:1"`
+	Hospital     Hospital   `gorm:"foreignKey:HospitalID"`
+	Coverages    []Coverage `gorm:"foreignKey:PatientID"`
+	// Version is raised by every change and serves as the ETag for optimistic concurrency.
+	Version      int `gorm:"not null;default:1"`
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
+	DeletedAt    gorm.DeletedAt `gorm:"index"`
+	AnonymisedAt *time.Time
+	// MergedIntoID is set on a duplicate record that was merged into another patient; it stays
+	// deleted and cannot be restored.
+	MergedIntoID *uuid.UUID `gorm:"type:uuid"`
 }
@@ -0,0 +1,47 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ImportQueued    = "queued"
+	ImportRunning   = "running"
+	ImportCompleted = "completed"
+	ImportFailed    = "failed"
+)
+
+// PatientImport is a bulk load of patients from a CSV or XLSX file. Rows are validated and
+// inserted in batches; rows that cannot be imported are listed in the import's error report.
+type PatientImport struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	HospitalID uuid.UUID `gorm:"type:uuid;not null;index"`
+	StaffID    uuid.UUID `gorm:"type:uuid;not null"`
+	FileName   string    `gorm:"not null"`
+	// DryRun imports validate every row but insert nothing.
+	DryRun        bool   `gorm:"not null;default:false"`
+	Status        string `gorm:"not null;default:queued"`
+	TotalRows     int    `gorm:"not null;default:0"`
+	ProcessedRows int    `gorm:"not null;default:0"`
+	ImportedRows  int    `gorm:"not null;default:0"`
+	FailedRows    int    `gorm:"not null;default:0"`
+	// Error is set when the whole import failed, as opposed to single rows.
+	Error      string
+	CreatedAt  time.Time
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+}
+
+// PatientImportError is one problem with one row of an import. Row is the row number in the
+// file, counting the header as row 1.
+type PatientImportError struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	ImportID   uuid.UUID `gorm:"type:uuid;not null;index"`
+	Row        int       `gorm:"not null"`
+	PatientHN  string
+	ColumnName string
+	Value      string
+	Message    string `gorm:"not null"`
+}
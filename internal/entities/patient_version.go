@@ -0,0 +1,84 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	PatientOpCreate    = "create"
+	PatientOpUpdate    = "update"
+	PatientOpDelete    = "delete"
+	PatientOpRestore   = "restore"
+	PatientOpAnonymise = "anonymise"
+	// PatientOpMerge marks a duplicate record whose data was moved to another patient.
+	PatientOpMerge = "merge"
+	// PatientOpBaseline captures a record that existed before versioning, on its first change.
+	PatientOpBaseline = "baseline"
+)
+
+// PatientSnapshot holds the versioned fields of a patient.
+type PatientSnapshot struct {
+	FirstNameTH  string     `json:"first_name_th"`
+	MiddleNameTH string     `json:"middle_name_th"`
+	LastNameTH   string     `json:"last_name_th"`
+	FirstNameEN  string     `json:"first_name_en"`
+	MiddleNameEN string     `json:"middle_name_en"`
+	LastNameEN   string     `json:"last_name_en"`
+	DateOfBirth  *time.Time `json:"date_of_birth"`
+	PatientHN    string     `json:"patient_hn"`
+	NationalID   string     `json:"national_id"`
+	PassportID   string     `json:"passport_id"`
+	PhoneNumber  string     `json:"phone_number"`
+	Email        string     `json:"email"`
+	Gender       string     `json:"gender"`
+}
+
+// PatientVersion is the state of a patient after one change. Versions are append-only and
+// numbered per patient; the newest version always matches the patient row.
+type PatientVersion struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	PatientID   uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_patient_version"`
+	Version     int        `gorm:"not null;uniqueIndex:idx_patient_version"`
+	HospitalID  uuid.UUID  `gorm:"type:uuid;not null"`
+	Operation   string     `gorm:"not null"`
+	Data        string     `gorm:"type:jsonb;not null"`
+	ChangedByID *uuid.UUID `gorm:"type:uuid"`
+	ChangedAt   time.Time  `gorm:"not null;index"`
+}
+
+func (p Patient) Snapshot() PatientSnapshot {
+	return PatientSnapshot{
+		FirstNameTH:  p.FirstNameTH,
+		MiddleNameTH: p.MiddleNameTH,
+		LastNameTH:   p.LastNameTH,
+		FirstNameEN:  p.FirstNameEN,
+		MiddleNameEN: p.MiddleNameEN,
+		LastNameEN:   p.LastNameEN,
+		DateOfBirth:  p.DateOfBirth,
+		PatientHN:    p.PatientHN,
+		NationalID:   p.NationalID,
+		PassportID:   p.PassportID,
+		PhoneNumber:  p.PhoneNumber,
+		Email:        p.Email,
+		Gender:       p.Gender,
+	}
+}
+
+// Apply copies a snapshot onto the patient, for viewing the record as of a past version.
+func (s PatientSnapshot) Apply(p *Patient) {
+	p.FirstNameTH = s.FirstNameTH
+	p.MiddleNameTH = s.MiddleNameTH
+	p.LastNameTH = s.LastNameTH
+	p.FirstNameEN = s.FirstNameEN
+	p.MiddleNameEN = s.MiddleNameEN
+	p.LastNameEN = s.LastNameEN
+	p.DateOfBirth = s.DateOfBirth
+	p.PatientHN = s.PatientHN
+	p.NationalID = s.NationalID
+	p.PassportID = s.PassportID
+	p.PhoneNumber = s.PhoneNumber
+	p.Email = s.Email
+	p.Gender = s.Gender
+}
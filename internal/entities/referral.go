@@ -0,0 +1,45 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ReferralRoutine   = "routine"
+	ReferralUrgent    = "urgent"
+	ReferralEmergency = "emergency"
+)
+
+const (
+	ReferralPending   = "pending"
+	ReferralAccepted  = "accepted"
+	ReferralRejected  = "rejected"
+	ReferralCancelled = "cancelled"
+)
+
+// Referral hands a patient over from the source hospital to a target hospital. Once accepted,
+// the target hospital may read the source patient record until AccessExpiresAt, and may have
+// registered the patient locally as LinkedPatientID.
+type Referral struct {
+	ID               uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	PatientID        uuid.UUID  `gorm:"type:uuid;not null;index"`
+	Patient          Patient    `gorm:"foreignKey:PatientID"`
+	SourceHospitalID uuid.UUID  `gorm:"type:uuid;not null;index"`
+	SourceHospital   Hospital   `gorm:"foreignKey:SourceHospitalID"`
+	TargetHospitalID uuid.UUID  `gorm:"type:uuid;not null;index"`
+	TargetHospital   Hospital   `gorm:"foreignKey:TargetHospitalID"`
+	Reason           string     `gorm:"not null"`
+	Urgency          string     `gorm:"not null;default:routine"`
+	ClinicalSummary  string     `gorm:"type:text;not null"`
+	Status           string     `gorm:"not null;default:pending;index"`
+	ReferredByID     uuid.UUID  `gorm:"type:uuid;not null"`
+	RespondedByID    *uuid.UUID `gorm:"type:uuid"`
+	RespondedAt      *time.Time
+	ResponseNote     string
+	AccessExpiresAt  *time.Time
+	LinkedPatientID  *uuid.UUID `gorm:"type:uuid"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
@@ -0,0 +1,27 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	RetentionAnonymise = "anonymise"
+	RetentionDelete    = "delete"
+)
+
+// RetentionPolicy says how long a hospital keeps deleted records and what happens to them
+// afterwards. Hospitals without a policy use the defaults of the retention service.
+type RetentionPolicy struct {
+	HospitalID     uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Hospital       Hospital  `gorm:"foreignKey:HospitalID"`
+	RetentionYears int       `gorm:"not null"`
+	Action         string    `gorm:"not null;default:anonymise"`
+	UpdatedByID    uuid.UUID `gorm:"type:uuid;not null"`
+	// Version is raised by every change and serves as the ETag for optimistic concurrency. The
+	// default policy of a hospital that never set one is version 1.
+	Version   int `gorm:"not null;default:1"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
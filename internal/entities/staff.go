@@ -4,14 +4,28 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	RoleStaff         = "staff"
+	RoleDoctor        = "doctor"
+	RoleNurse         = "nurse"
+	RoleLabTechnician = "lab_technician"
+	RolePharmacist    = "pharmacist"
+	RoleSupervisor    = "supervisor"
 )
 
 type Staff struct {
 	ID           uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
 	Username     string    `gorm:"uniqueIndex;not null"`
 	PasswordHash string    `gorm:"not null"`
+	Role         string    `gorm:"not null;default:staff"`
 	HospitalID   uuid.UUID `gorm:"type:uuid;not null"`
 	Hospital     Hospital  `gorm:"foreignKey:HospitalID"`
+	Version      int       `gorm:"not null;default:1"`
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
+	DeletedAt    gorm.DeletedAt `gorm:"index"`
+	AnonymisedAt *time.Time
 }
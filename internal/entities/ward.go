@@ -0,0 +1,80 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	BedAvailable = "available"
+	BedOccupied  = "occupied"
+	BedCleaning  = "cleaning"
+	BedBlocked   = "blocked"
+)
+
+const (
+	AdmissionActive     = "active"
+	AdmissionDischarged = "discharged"
+)
+
+const (
+	MovementAdmit     = "admit"
+	MovementTransfer  = "transfer"
+	MovementDischarge = "discharge"
+)
+
+// Ward is an inpatient ward of a hospital.
+type Ward struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	HospitalID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_ward_hospital_code"`
+	Code       string    `gorm:"not null;uniqueIndex:idx_ward_hospital_code"`
+	Name       string    `gorm:"not null"`
+	Beds       []Bed     `gorm:"foreignKey:WardID"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// Bed is a bed within a ward. Its status only becomes occupied through an admission.
+type Bed struct {
+	ID         uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	WardID     uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_bed_ward_label"`
+	HospitalID uuid.UUID `gorm:"type:uuid;not null;index"`
+	Label      string    `gorm:"not null;uniqueIndex:idx_bed_ward_label"`
+	Status     string    `gorm:"not null;default:available"`
+	// Version is raised by every change, admissions included, and serves as the ETag for
+	// optimistic concurrency.
+	Version   int `gorm:"not null;default:1"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Admission is an inpatient stay. A bed and a patient can each have at most one active admission.
+type Admission struct {
+	ID             uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	PatientID      uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_admission_active_patient,where:status = 'active'"`
+	Patient        Patient   `gorm:"foreignKey:PatientID"`
+	HospitalID     uuid.UUID `gorm:"type:uuid;not null;index"`
+	BedID          uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_admission_active_bed,where:status = 'active'"`
+	Bed            Bed       `gorm:"foreignKey:BedID"`
+	Status         string    `gorm:"not null;default:active"`
+	Reason         string
+	AdmittedByID   uuid.UUID  `gorm:"type:uuid;not null"`
+	AdmittedAt     time.Time  `gorm:"not null"`
+	DischargedByID *uuid.UUID `gorm:"type:uuid"`
+	DischargedAt   *time.Time
+	DischargeNote  string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// AdmissionMovement logs every admit, transfer and discharge of an admission.
+type AdmissionMovement struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	AdmissionID uuid.UUID  `gorm:"type:uuid;not null;index"`
+	Type        string     `gorm:"not null"`
+	FromBedID   *uuid.UUID `gorm:"type:uuid"`
+	ToBedID     *uuid.UUID `gorm:"type:uuid"`
+	StaffID     uuid.UUID  `gorm:"type:uuid;not null"`
+	CreatedAt   time.Time
+}
@@ -0,0 +1,67 @@
+package entities
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	WebhookPending   = "pending"
+	WebhookDelivered = "delivered"
+	// WebhookDead marks a delivery that failed every attempt; it stays in the dead-letter list
+	// until it is retried by hand.
+	WebhookDead = "dead"
+)
+
+// WebhookSubscription sends the hospital's events to a URL. EventTypes is a comma-separated list
+// of event types; an empty list subscribes to every event.
+type WebhookSubscription struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	HospitalID  uuid.UUID `gorm:"type:uuid;not null;index"`
+	URL         string    `gorm:"not null"`
+	Description string
+	EventTypes  string
+	// Secret signs the payloads; it is only shown when the subscription is created.
+	Secret      string    `gorm:"not null"`
+	CreatedByID uuid.UUID `gorm:"type:uuid;not null"`
+	// Version serves as the ETag for optimistic concurrency.
+	Version   int `gorm:"not null;default:1"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+}
+
+// Matches reports whether the subscription wants events of the type
+func (s WebhookSubscription) Matches(eventType string) bool {
+	if strings.TrimSpace(s.EventTypes) == "" {
+		return true
+	}
+	for _, t := range strings.Split(s.EventTypes, ",") {
+		if strings.TrimSpace(t) == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one event on its way to one subscription. The payload is fixed when the
+// delivery is created, so every attempt sends, and signs, the same body.
+type WebhookDelivery struct {
+	ID             uuid.UUID `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	SubscriptionID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_webhook_delivery_event"`
+	EventID        uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_webhook_delivery_event"`
+	HospitalID     uuid.UUID `gorm:"type:uuid;not null;index"`
+	EventType      string    `gorm:"not null"`
+	Payload        string    `gorm:"type:text;not null"`
+	Status         string    `gorm:"not null;default:pending;index"`
+	Attempts       int       `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time `gorm:"index"`
+	LastAttemptAt  *time.Time
+	LastStatusCode int
+	LastError      string
+	CreatedAt      time.Time `gorm:"index"`
+	DeliveredAt    *time.Time
+}
@@ -0,0 +1,96 @@
+package fhir
+
+import "time"
+
+type CapabilityStatement struct {
+	ResourceType   string             `json:"resourceType"`
+	Status         string             `json:"status"`
+	Date           string             `json:"date"`
+	Kind           string             `json:"kind"`
+	Software       CapabilitySoftware `json:"software"`
+	Implementation CapabilityImpl     `json:"implementation"`
+	FHIRVersion    string             `json:"fhirVersion"`
+	Format         []string           `json:"format"`
+	Rest           []CapabilityRest   `json:"rest"`
+}
+
+type CapabilitySoftware struct {
+	Name string `json:"name"`
+}
+
+type CapabilityImpl struct {
+	Description string `json:"description"`
+	URL         string `json:"url"`
+}
+
+type CapabilityRest struct {
+	Mode     string               `json:"mode"`
+	Security *CapabilitySecurity  `json:"security,omitempty"`
+	Resource []CapabilityResource `json:"resource"`
+}
+
+type CapabilitySecurity struct {
+	Description string `json:"description"`
+}
+
+type CapabilityResource struct {
+	Type              string                  `json:"type"`
+	Versioning        string                  `json:"versioning,omitempty"`
+	UpdateCreate      *bool                   `json:"updateCreate,omitempty"`
+	ConditionalUpdate *bool                   `json:"conditionalUpdate,omitempty"`
+	Interaction       []CapabilityCode        `json:"interaction"`
+	SearchParam       []CapabilitySearchParam `json:"searchParam,omitempty"`
+}
+
+type CapabilityCode struct {
+	Code string `json:"code"`
+}
+
+type CapabilitySearchParam struct {
+	Name          string `json:"name"`
+	Type          string `json:"type"`
+	Documentation string `json:"documentation,omitempty"`
+}
+
+// PatientSearchParams are the Patient search parameters the server understands, besides the
+// _count and _offset paging parameters.
+var PatientSearchParams = []CapabilitySearchParam{
+	{Name: "name", Type: "string", Documentation: "Any part of the Thai or English name; every word must match"},
+	{Name: "identifier", Type: "token", Documentation: "system|value for the HN, national ID or passport, or a bare value matching any of them"},
+	{Name: "birthdate", Type: "date", Documentation: "Exact date, YYYY-MM-DD, optionally with the eq prefix"},
+	{Name: "gender", Type: "token"},
+	{Name: "phone", Type: "token", Documentation: "Partial match"},
+	{Name: "email", Type: "token", Documentation: "Partial match"},
+}
+
+// Capabilities describes what the FHIR facade at baseURL supports
+func Capabilities(baseURL string, at time.Time) CapabilityStatement {
+	no := false
+	read := []CapabilityCode{{Code: "read"}}
+	return CapabilityStatement{
+		ResourceType:   "CapabilityStatement",
+		Status:         "active",
+		Date:           at.UTC().Format(time.RFC3339),
+		Kind:           "instance",
+		Software:       CapabilitySoftware{Name: "go-hospital-api"},
+		Implementation: CapabilityImpl{Description: "Hospital API FHIR facade", URL: baseURL},
+		FHIRVersion:    Version,
+		Format:         []string{"json"},
+		Rest: []CapabilityRest{{
+			Mode:     "server",
+			Security: &CapabilitySecurity{Description: "Bearer JWT from POST /api/staff/login; resources are limited to the staff member's hospital"},
+			Resource: []CapabilityResource{
+				{
+					Type:              "Patient",
+					Versioning:        "versioned-update",
+					UpdateCreate:      &no,
+					ConditionalUpdate: &no,
+					Interaction:       []CapabilityCode{{Code: "read"}, {Code: "search-type"}, {Code: "create"}, {Code: "update"}},
+					SearchParam:       PatientSearchParams,
+				},
+				{Type: "Practitioner", Interaction: read},
+				{Type: "PractitionerRole", Interaction: read},
+			},
+		}},
+	}
+}
@@ -0,0 +1,189 @@
+package fhir
+
+import (
+	"errors"
+	"fmt"
+	"go-hospital-api/internal/entities"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const dateLayout = "2006-01-02"
+
+var genders = map[string]string{"M": "male", "F": "female", "O": "other"}
+
+// FromPatient renders a patient as a FHIR Patient. Thai and English names become separate
+// official names, told apart by the language extension.
+func FromPatient(p entities.Patient) Patient {
+	active := !p.DeletedAt.Valid
+	r := Patient{
+		ResourceType:         "Patient",
+		ID:                   p.ID.String(),
+		Meta:                 meta(p.Version, p.UpdatedAt),
+		Active:               &active,
+		Gender:               genders[p.Gender],
+		ManagingOrganization: &Reference{Reference: "Organization/" + p.HospitalID.String()},
+	}
+	if p.Gender == "" {
+		r.Gender = "unknown"
+	}
+	if p.PatientHN != "" {
+		r.Identifier = append(r.Identifier, identifier("usual", "MR", "Medical record number", SystemHN, p.PatientHN))
+	}
+	if p.NationalID != "" {
+		r.Identifier = append(r.Identifier, identifier("official", "NI", "National unique individual identifier", SystemNationalID, p.NationalID))
+	}
+	if p.PassportID != "" {
+		r.Identifier = append(r.Identifier, identifier("official", "PPN", "Passport number", SystemPassport, p.PassportID))
+	}
+	if name, ok := humanName("th", p.FirstNameTH, p.MiddleNameTH, p.LastNameTH); ok {
+		r.Name = append(r.Name, name)
+	}
+	if name, ok := humanName("en", p.FirstNameEN, p.MiddleNameEN, p.LastNameEN); ok {
+		r.Name = append(r.Name, name)
+	}
+	if p.PhoneNumber != "" {
+		r.Telecom = append(r.Telecom, ContactPoint{System: "phone", Value: p.PhoneNumber, Use: "mobile"})
+	}
+	if p.Email != "" {
+		r.Telecom = append(r.Telecom, ContactPoint{System: "email", Value: p.Email})
+	}
+	if p.DateOfBirth != nil {
+		r.BirthDate = p.DateOfBirth.Format(dateLayout)
+	}
+	return r
+}
+
+// ToPatient reads the fields of a patient from a FHIR Patient. Identifiers of unknown systems
+// and names or telecoms beyond the first of each kind are ignored.
+func ToPatient(r Patient) (entities.Patient, error) {
+	var p entities.Patient
+	if r.ResourceType != "Patient" {
+		return p, fmt.Errorf("resourceType must be Patient, got %q", r.ResourceType)
+	}
+	for _, id := range r.Identifier {
+		value := strings.TrimSpace(id.Value)
+		switch id.System {
+		case SystemHN:
+			p.PatientHN = value
+		case SystemNationalID:
+			p.NationalID = value
+		case SystemPassport:
+			p.PassportID = value
+		}
+	}
+	seen := map[string]bool{}
+	for _, name := range r.Name {
+		lang := nameLanguage(name)
+		if seen[lang] {
+			continue
+		}
+		seen[lang] = true
+		first, middle := "", ""
+		if len(name.Given) > 0 {
+			first = strings.TrimSpace(name.Given[0])
+			middle = strings.TrimSpace(strings.Join(name.Given[1:], " "))
+		}
+		family := strings.TrimSpace(name.Family)
+		if lang == "th" {
+			p.FirstNameTH, p.MiddleNameTH, p.LastNameTH = first, middle, family
+		} else {
+			p.FirstNameEN, p.MiddleNameEN, p.LastNameEN = first, middle, family
+		}
+	}
+	for _, t := range r.Telecom {
+		switch {
+		case t.System == "phone" && p.PhoneNumber == "":
+			p.PhoneNumber = strings.TrimSpace(t.Value)
+		case t.System == "email" && p.Email == "":
+			p.Email = strings.TrimSpace(t.Value)
+		}
+	}
+	gender, err := GenderCode(r.Gender)
+	if err != nil {
+		return p, err
+	}
+	p.Gender = gender
+	if r.BirthDate != "" {
+		dob, err := time.Parse(dateLayout, r.BirthDate)
+		if err != nil {
+			return p, errors.New("birthDate must be a full date, YYYY-MM-DD")
+		}
+		p.DateOfBirth = &dob
+	}
+	return p, nil
+}
+
+// GenderCode maps a FHIR administrative gender onto the M/F/O codes stored for patients
+func GenderCode(gender string) (string, error) {
+	switch gender {
+	case "", "unknown":
+		return "", nil
+	case "male":
+		return "M", nil
+	case "female":
+		return "F", nil
+	case "other":
+		return "O", nil
+	}
+	return "", fmt.Errorf("gender must be male, female, other or unknown, got %q", gender)
+}
+
+func identifier(use, typeCode, typeDisplay, system, value string) Identifier {
+	return Identifier{
+		Use:    use,
+		Type:   &CodeableConcept{Coding: []Coding{{System: systemIdentifierType, Code: typeCode, Display: typeDisplay}}},
+		System: system,
+		Value:  value,
+	}
+}
+
+func humanName(lang, first, middle, last string) (HumanName, bool) {
+	if first == "" && middle == "" && last == "" {
+		return HumanName{}, false
+	}
+	var given []string
+	for _, g := range []string{first, middle} {
+		if g != "" {
+			given = append(given, g)
+		}
+	}
+	return HumanName{
+		Extension: []Extension{{URL: extensionLanguage, ValueCode: lang}},
+		Use:       "official",
+		Text:      strings.Join(append(given, last), " "),
+		Family:    last,
+		Given:     given,
+	}, true
+}
+
+// nameLanguage is "th" or "en": the language extension when present, otherwise "th" for names
+// written in Thai script.
+func nameLanguage(name HumanName) string {
+	for _, ext := range name.Extension {
+		if ext.URL == extensionLanguage {
+			if strings.HasPrefix(strings.ToLower(ext.ValueCode), "th") {
+				return "th"
+			}
+			return "en"
+		}
+	}
+	for _, part := range append([]string{name.Family}, name.Given...) {
+		for _, r := range part {
+			if unicode.Is(unicode.Thai, r) {
+				return "th"
+			}
+		}
+	}
+	return "en"
+}
+
+func meta(version int, updatedAt time.Time) *Meta {
+	m := &Meta{VersionID: strconv.Itoa(version)}
+	if !updatedAt.IsZero() {
+		m.LastUpdated = updatedAt.UTC().Format(time.RFC3339)
+	}
+	return m
+}
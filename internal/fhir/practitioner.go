@@ -0,0 +1,32 @@
+package fhir
+
+import "go-hospital-api/internal/entities"
+
+// FromStaff renders a staff member as a Practitioner. Staff records hold no personal name, so the
+// username stands in for it.
+func FromStaff(s entities.Staff) Practitioner {
+	active := !s.DeletedAt.Valid
+	return Practitioner{
+		ResourceType: "Practitioner",
+		ID:           s.ID.String(),
+		Meta:         meta(s.Version, s.UpdatedAt),
+		Identifier:   []Identifier{{Use: "usual", System: SystemUsername, Value: s.Username}},
+		Active:       &active,
+		Name:         []HumanName{{Use: "usual", Text: s.Username}},
+	}
+}
+
+// RoleFromStaff renders the role a staff member holds in their hospital. Each staff member has
+// exactly one role, so the PractitionerRole shares the staff ID.
+func RoleFromStaff(s entities.Staff) PractitionerRole {
+	active := !s.DeletedAt.Valid
+	return PractitionerRole{
+		ResourceType: "PractitionerRole",
+		ID:           s.ID.String(),
+		Meta:         meta(s.Version, s.UpdatedAt),
+		Active:       &active,
+		Practitioner: &Reference{Reference: "Practitioner/" + s.ID.String(), Display: s.Username},
+		Organization: &Reference{Reference: "Organization/" + s.HospitalID.String()},
+		Code:         []CodeableConcept{{Coding: []Coding{{System: SystemStaffRole, Code: s.Role}}, Text: s.Role}},
+	}
+}
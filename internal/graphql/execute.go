@@ -0,0 +1,265 @@
+// Package graphql serves the hospital schema in schema.graphql. Queries are parsed and validated
+// with gqlparser, which also measures them against the limits, and executed with graphql-go.
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"go-hospital-api/internal/services"
+	"strings"
+
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	gqlerrors "github.com/graph-gophers/graphql-go/errors"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/parser"
+	"github.com/vektah/gqlparser/v2/validator"
+)
+
+// Error codes, set as the "code" extension of an error
+const (
+	CodeParseFailed      = "GRAPHQL_PARSE_FAILED"
+	CodeValidationFailed = "GRAPHQL_VALIDATION_FAILED"
+	CodeBadUserInput     = "BAD_USER_INPUT"
+	CodeQueryTooDeep     = "QUERY_TOO_DEEP"
+	CodeQueryTooComplex  = "QUERY_TOO_COMPLEX"
+	CodeUnauthenticated  = "UNAUTHENTICATED"
+	CodeForbidden        = "FORBIDDEN"
+	CodeNotFound         = "NOT_FOUND"
+	CodeInternal         = "INTERNAL_SERVER_ERROR"
+)
+
+// Request is a query as clients send it
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// Response has no data when the request could not be executed at all: the query did not parse,
+// failed validation or exceeded the limits. Otherwise it has the data along with the errors of
+// the fields that failed, which are null in the data.
+type Response struct {
+	Data   any      `json:"data,omitempty"`
+	Errors []*Error `json:"errors,omitempty"`
+}
+
+// Location is a position in the query document; lines and columns start at 1
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error is an error of the response
+type Error struct {
+	Message    string         `json:"message"`
+	Locations  []Location     `json:"locations,omitempty"`
+	Path       []any          `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Errorf returns an Error with the given code
+func Errorf(code string, format string, args ...any) *Error {
+	return &Error{Message: fmt.Sprintf(format, args...), Extensions: map[string]any{"code": code}}
+}
+
+// resolverError is an error of a resolver; graphql-go copies its code into the response
+type resolverError struct {
+	code    string
+	message string
+}
+
+func (e *resolverError) Error() string {
+	return e.message
+}
+
+func (e *resolverError) Extensions() map[string]any {
+	return map[string]any{"code": e.code}
+}
+
+func resolverErrorf(code string, format string, args ...any) error {
+	return &resolverError{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+// Limits guard the server against expensive queries; zero means no limit. Introspection fields
+// are not counted.
+type Limits struct {
+	// MaxDepth is the deepest nesting of fields, the top-level fields being at depth 1
+	MaxDepth int
+	// MaxComplexity is the highest estimated cost. A field costs 1 plus its selection set, which
+	// a list counts DefaultListSize times, or as many times as the patients asked for.
+	MaxComplexity int
+}
+
+// DefaultListSize is the number of items a list field is assumed to return when estimating the
+// complexity of a query
+const DefaultListSize = 10
+
+// Schema is the executable hospital schema. It is safe for concurrent use.
+type Schema struct {
+	exec          *graphqlgo.Schema
+	validate      *ast.Schema
+	lookupService services.LookupServiceInterface
+}
+
+// Execute runs the query operation of the request as the staff member of ctx, see WithStaff.
+// Mutations and subscriptions are not supported.
+func (s *Schema) Execute(ctx context.Context, req Request, limits Limits) *Response {
+	c, ok := ctx.Value(staffKey{}).(caller)
+	if !ok {
+		return &Response{Errors: []*Error{Errorf(CodeUnauthenticated, "no staff member in the request context")}}
+	}
+	doc, err := parser.ParseQuery(&ast.Source{Input: req.Query})
+	if err != nil {
+		return &Response{Errors: fromGQLErrors(gqlerror.List{toGQLError(err)}, CodeParseFailed)}
+	}
+	if errs := validator.Validate(s.validate, doc); len(errs) > 0 {
+		return &Response{Errors: fromGQLErrors(errs, CodeValidationFailed)}
+	}
+	op, gqlErr := selectOperation(doc, req.OperationName)
+	if gqlErr != nil {
+		return &Response{Errors: []*Error{gqlErr}}
+	}
+	vars, err := validator.VariableValues(s.validate, op, req.Variables)
+	if err != nil {
+		return &Response{Errors: fromGQLErrors(gqlerror.List{toGQLError(err)}, CodeBadUserInput)}
+	}
+
+	m := &measurer{vars: vars, fragments: make(map[string][2]int)}
+	depth, complexity := m.measure(op.SelectionSet)
+	if limits.MaxDepth > 0 && depth > limits.MaxDepth {
+		return &Response{Errors: []*Error{Errorf(CodeQueryTooDeep, "query depth %d exceeds the maximum of %d", depth, limits.MaxDepth)}}
+	}
+	if limits.MaxComplexity > 0 && complexity > limits.MaxComplexity {
+		return &Response{Errors: []*Error{Errorf(CodeQueryTooComplex, "query complexity %d exceeds the maximum of %d", complexity, limits.MaxComplexity)}}
+	}
+
+	ctx = withLoaders(ctx, s.lookupService, c.hospitalID)
+	resp := s.exec.Exec(ctx, req.Query, op.Name, req.Variables)
+	out := &Response{}
+	if resp.Data != nil {
+		out.Data = resp.Data
+	}
+	for _, e := range resp.Errors {
+		out.Errors = append(out.Errors, fromQueryError(e))
+		// A variable graphql-go could not convert fails the whole request, yet it returns
+		// empty data with it
+		if e.Path == nil && e.ResolverError == nil {
+			out.Data = nil
+		}
+	}
+	return out
+}
+
+func selectOperation(doc *ast.QueryDocument, name string) (*ast.OperationDefinition, *Error) {
+	if len(doc.Operations) == 0 {
+		return nil, Errorf(CodeBadUserInput, "Must provide an operation.")
+	}
+	if name == "" {
+		if len(doc.Operations) > 1 {
+			return nil, Errorf(CodeBadUserInput, "Must provide operation name if query contains multiple operations.")
+		}
+		return doc.Operations[0], nil
+	}
+	op := doc.Operations.ForName(name)
+	if op == nil {
+		return nil, Errorf(CodeBadUserInput, "Unknown operation named %q.", name)
+	}
+	return op, nil
+}
+
+// measurer finds the depth and estimated complexity of a validated operation, with fragments
+// spread and regardless of @skip and @include
+type measurer struct {
+	vars map[string]any
+	// fragments holds the depth and complexity of the fragments already measured
+	fragments map[string][2]int
+}
+
+func (m *measurer) measure(set ast.SelectionSet) (depth, complexity int) {
+	for _, sel := range set {
+		var d, c int
+		switch sel := sel.(type) {
+		case *ast.Field:
+			d, c = m.measureField(sel)
+		case *ast.InlineFragment:
+			d, c = m.measure(sel.SelectionSet)
+		case *ast.FragmentSpread:
+			f, ok := m.fragments[sel.Name]
+			if !ok {
+				f[0], f[1] = m.measure(sel.Definition.SelectionSet)
+				m.fragments[sel.Name] = f
+			}
+			d, c = f[0], f[1]
+		}
+		depth = max(depth, d)
+		complexity += c
+	}
+	return depth, complexity
+}
+
+func (m *measurer) measureField(f *ast.Field) (depth, complexity int) {
+	if strings.HasPrefix(f.Name, "__") {
+		return 0, 0
+	}
+	childDepth, childComplexity := m.measure(f.SelectionSet)
+	if f.Definition.Type.Elem == nil {
+		return childDepth + 1, 1 + childComplexity
+	}
+	size := DefaultListSize
+	if f.ObjectDefinition.Name == "Query" && f.Name == "patients" {
+		first, _ := toInt(f.ArgumentMap(m.vars)["first"])
+		size = min(max(first, 1), maxPatientsPage)
+	}
+	return childDepth + 1, 1 + size*childComplexity
+}
+
+// toInt reads an Int argument, which is an int64 in the query and a float64 in the variables
+func toInt(v any) (int, bool) {
+	switch v := v.(type) {
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	}
+	return 0, false
+}
+
+func toGQLError(err error) *gqlerror.Error {
+	if e, ok := err.(*gqlerror.Error); ok {
+		return e
+	}
+	return gqlerror.Wrap(err)
+}
+
+func fromGQLErrors(errs gqlerror.List, code string) []*Error {
+	out := make([]*Error, len(errs))
+	for i, e := range errs {
+		out[i] = Errorf(code, "%s", e.Message)
+		for _, l := range e.Locations {
+			out[i].Locations = append(out[i].Locations, Location{Line: l.Line, Column: l.Column})
+		}
+	}
+	return out
+}
+
+// fromQueryError converts an error of graphql-go. An error without a code is a resolver error
+// without one, or a variable graphql-go could not convert to its scalar.
+func fromQueryError(e *gqlerrors.QueryError) *Error {
+	out := &Error{Message: e.Message, Path: e.Path, Extensions: e.Extensions}
+	for _, l := range e.Locations {
+		out.Locations = append(out.Locations, Location{Line: l.Line, Column: l.Column})
+	}
+	if out.Extensions == nil {
+		code := CodeInternal
+		if e.ResolverError == nil && e.Path == nil {
+			code = CodeBadUserInput
+		}
+		out.Extensions = map[string]any{"code": code}
+	}
+	return out
+}
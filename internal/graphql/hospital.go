@@ -0,0 +1,302 @@
+package graphql
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/services"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// DefaultLimits suit the hospital schema: a page of 20 patients with their hospital, encounters,
+// diagnoses and the staff on them stays below the complexity limit
+var DefaultLimits = Limits{MaxDepth: 8, MaxComplexity: 5000}
+
+const maxPatientsPage = 100
+
+//go:embed schema.graphql
+var hospitalSchema string
+
+type staffKey struct{}
+
+type caller struct {
+	staffID    uuid.UUID
+	hospitalID uuid.UUID
+}
+
+// WithStaff returns a context to execute a query as the staff member; every resolver of the
+// hospital schema is restricted to the staff member's hospital
+func WithStaff(ctx context.Context, staffID, hospitalID uuid.UUID) context.Context {
+	return context.WithValue(ctx, staffKey{}, caller{staffID: staffID, hospitalID: hospitalID})
+}
+
+// NewHospitalSchema serves patients with their hospital, encounters, diagnoses and the staff
+// involved, through the same services as the REST API. Related records are loaded by
+// LookupService, in batches for all the objects a query resolves at once.
+func NewHospitalSchema(patientService services.PatientServiceInterface, lookupService services.LookupServiceInterface) *Schema {
+	return &Schema{
+		// A load holds one of the parallel resolvers until its batch is queried, so a page of
+		// patients is resolved at once to load their related records together
+		exec: graphqlgo.MustParseSchema(hospitalSchema, &queryResolver{patientService: patientService},
+			graphqlgo.UseStringDescriptions(), graphqlgo.MaxParallelism(maxPatientsPage)),
+		validate:      gqlparser.MustLoadSchema(&ast.Source{Name: "schema.graphql", Input: hospitalSchema}),
+		lookupService: lookupService,
+	}
+}
+
+type queryResolver struct {
+	patientService services.PatientServiceInterface
+}
+
+func (r *queryResolver) Me(ctx context.Context) (*staffResolver, error) {
+	c := ctx.Value(staffKey{}).(caller)
+	s, err := loadStaff(ctx, c.staffID)
+	if err == nil && s == nil {
+		err = resolverErrorf(CodeNotFound, "staff member not found")
+	}
+	return s, err
+}
+
+func (r *queryResolver) Hospital(ctx context.Context) (*hospitalResolver, error) {
+	return loadHospital(ctx, ctx.Value(staffKey{}).(caller).hospitalID)
+}
+
+func (r *queryResolver) Patient(ctx context.Context, args struct {
+	ID   graphqlgo.ID
+	AsOf *DateTime
+}) (*patientResolver, error) {
+	c := ctx.Value(staffKey{}).(caller)
+	id, err := uuid.Parse(string(args.ID))
+	if err != nil {
+		return nil, resolverErrorf(CodeBadUserInput, "invalid patient ID")
+	}
+	var asOf *time.Time
+	if args.AsOf != nil {
+		asOf = &args.AsOf.Time
+	}
+	p, err := r.patientService.Get(ctx, id, c.hospitalID, asOf)
+	if err != nil {
+		return nil, serviceError(err)
+	}
+	return &patientResolver{*p}, nil
+}
+
+type patientsArgs struct {
+	Name        *string
+	PatientHN   *string
+	Identifier  *string
+	NationalID  *string
+	PassportID  *string
+	PhoneNumber *string
+	Email       *string
+	Gender      *string
+	DateOfBirth *Date
+	First       int32
+}
+
+func (r *queryResolver) Patients(ctx context.Context, args patientsArgs) ([]*patientResolver, error) {
+	c := ctx.Value(staffKey{}).(caller)
+	first := int(args.First)
+	if first < 1 || first > maxPatientsPage {
+		return nil, resolverErrorf(CodeBadUserInput, "first must be between 1 and %d", maxPatientsPage)
+	}
+	criteria := dto.PatientSearchCriteria{
+		Name:        optionalArg(args.Name),
+		PatientHN:   optionalArg(args.PatientHN),
+		Identifier:  optionalArg(args.Identifier),
+		NationalID:  optionalArg(args.NationalID),
+		PassportID:  optionalArg(args.PassportID),
+		PhoneNumber: optionalArg(args.PhoneNumber),
+		Email:       optionalArg(args.Email),
+		Gender:      optionalArg(args.Gender),
+		HospitalID:  c.hospitalID,
+	}
+	if args.DateOfBirth != nil {
+		criteria.DateOfBirth = &args.DateOfBirth.Time
+	}
+	patients, err := r.patientService.Search(ctx, criteria)
+	if err != nil {
+		return nil, serviceError(err)
+	}
+	if len(patients) > first {
+		patients = patients[:first]
+	}
+	// Search results are masked by the caller's role, as patient exports are
+	me, err := loadStaff(ctx, c.staffID)
+	if err != nil {
+		return nil, err
+	}
+	var role string
+	if me != nil {
+		role = me.s.Role
+	}
+	resolvers := make([]*patientResolver, len(patients))
+	for i, p := range patients {
+		resolvers[i] = &patientResolver{services.MaskPatient(p, role)}
+	}
+	return resolvers, nil
+}
+
+func (r *queryResolver) Staff(ctx context.Context, args struct{ ID graphqlgo.ID }) (*staffResolver, error) {
+	id, err := uuid.Parse(string(args.ID))
+	if err != nil {
+		return nil, resolverErrorf(CodeBadUserInput, "invalid staff ID")
+	}
+	return loadStaff(ctx, id)
+}
+
+// optionalArg is nil for a missing or blank string argument, which does not filter a search
+func optionalArg(v *string) *string {
+	if v == nil || strings.TrimSpace(*v) == "" {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*v)
+	return &trimmed
+}
+
+type hospitalResolver struct {
+	h entities.Hospital
+}
+
+func (r *hospitalResolver) ID() graphqlgo.ID { return graphqlgo.ID(r.h.ID.String()) }
+func (r *hospitalResolver) Name() string     { return r.h.Name }
+
+// loadHospital is nil for any hospital but the caller's
+func loadHospital(ctx context.Context, id uuid.UUID) (*hospitalResolver, error) {
+	h, ok, err := load[entities.Hospital](ctx, loadersFrom(ctx).hospitals, id)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &hospitalResolver{h}, nil
+}
+
+type staffResolver struct {
+	s entities.Staff
+}
+
+func (r *staffResolver) ID() graphqlgo.ID    { return graphqlgo.ID(r.s.ID.String()) }
+func (r *staffResolver) Username() string    { return r.s.Username }
+func (r *staffResolver) Role() string        { return r.s.Role }
+func (r *staffResolver) CreatedAt() DateTime { return DateTime{r.s.CreatedAt} }
+func (r *staffResolver) Hospital(ctx context.Context) (*hospitalResolver, error) {
+	return loadHospital(ctx, r.s.HospitalID)
+}
+
+// loadStaff is nil for a staff member of another hospital
+func loadStaff(ctx context.Context, id uuid.UUID) (*staffResolver, error) {
+	s, ok, err := load[entities.Staff](ctx, loadersFrom(ctx).staff, id)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &staffResolver{s}, nil
+}
+
+type patientResolver struct {
+	p entities.Patient
+}
+
+func (r *patientResolver) ID() graphqlgo.ID     { return graphqlgo.ID(r.p.ID.String()) }
+func (r *patientResolver) PatientHN() string    { return r.p.PatientHN }
+func (r *patientResolver) FirstNameTh() string  { return r.p.FirstNameTH }
+func (r *patientResolver) MiddleNameTh() string { return r.p.MiddleNameTH }
+func (r *patientResolver) LastNameTh() string   { return r.p.LastNameTH }
+func (r *patientResolver) FirstNameEn() string  { return r.p.FirstNameEN }
+func (r *patientResolver) MiddleNameEn() string { return r.p.MiddleNameEN }
+func (r *patientResolver) LastNameEn() string   { return r.p.LastNameEN }
+func (r *patientResolver) DateOfBirth() *Date   { return optionalDate(r.p.DateOfBirth) }
+func (r *patientResolver) NationalID() string   { return r.p.NationalID }
+func (r *patientResolver) PassportID() string   { return r.p.PassportID }
+func (r *patientResolver) PhoneNumber() string  { return r.p.PhoneNumber }
+func (r *patientResolver) Email() string        { return r.p.Email }
+func (r *patientResolver) Gender() string       { return r.p.Gender }
+func (r *patientResolver) Version() int32       { return int32(r.p.Version) }
+func (r *patientResolver) CreatedAt() DateTime  { return DateTime{r.p.CreatedAt} }
+func (r *patientResolver) UpdatedAt() DateTime  { return DateTime{r.p.UpdatedAt} }
+func (r *patientResolver) Hospital(ctx context.Context) (*hospitalResolver, error) {
+	return loadHospital(ctx, r.p.HospitalID)
+}
+
+func (r *patientResolver) Encounters(ctx context.Context) ([]*encounterResolver, error) {
+	admissions, _, err := load[[]entities.Admission](ctx, loadersFrom(ctx).admissions, r.p.ID)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*encounterResolver, len(admissions))
+	for i, a := range admissions {
+		resolvers[i] = &encounterResolver{a}
+	}
+	return resolvers, nil
+}
+
+func (r *patientResolver) Diagnoses(ctx context.Context) ([]*diagnosisResolver, error) {
+	diagnoses, _, err := load[[]entities.Diagnosis](ctx, loadersFrom(ctx).diagnoses, r.p.ID)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*diagnosisResolver, len(diagnoses))
+	for i, d := range diagnoses {
+		resolvers[i] = &diagnosisResolver{d}
+	}
+	return resolvers, nil
+}
+
+type encounterResolver struct {
+	a entities.Admission
+}
+
+func (r *encounterResolver) ID() graphqlgo.ID        { return graphqlgo.ID(r.a.ID.String()) }
+func (r *encounterResolver) Status() string          { return r.a.Status }
+func (r *encounterResolver) Reason() string          { return r.a.Reason }
+func (r *encounterResolver) BedID() graphqlgo.ID     { return graphqlgo.ID(r.a.BedID.String()) }
+func (r *encounterResolver) BedLabel() string        { return r.a.Bed.Label }
+func (r *encounterResolver) AdmittedAt() DateTime    { return DateTime{r.a.AdmittedAt} }
+func (r *encounterResolver) DischargedAt() *DateTime { return optionalDateTime(r.a.DischargedAt) }
+func (r *encounterResolver) DischargeNote() string   { return r.a.DischargeNote }
+func (r *encounterResolver) AdmittedBy(ctx context.Context) (*staffResolver, error) {
+	return loadStaff(ctx, r.a.AdmittedByID)
+}
+
+func (r *encounterResolver) DischargedBy(ctx context.Context) (*staffResolver, error) {
+	if r.a.DischargedByID == nil {
+		return nil, nil
+	}
+	return loadStaff(ctx, *r.a.DischargedByID)
+}
+
+type diagnosisResolver struct {
+	d entities.Diagnosis
+}
+
+func (r *diagnosisResolver) ID() graphqlgo.ID     { return graphqlgo.ID(r.d.ID.String()) }
+func (r *diagnosisResolver) VisitDate() Date      { return Date{r.d.VisitDate} }
+func (r *diagnosisResolver) Code() string         { return r.d.Code }
+func (r *diagnosisResolver) Edition() string      { return r.d.Edition }
+func (r *diagnosisResolver) IsPrimary() bool      { return r.d.IsPrimary }
+func (r *diagnosisResolver) Note() string         { return r.d.Note }
+func (r *diagnosisResolver) RecordedAt() DateTime { return DateTime{r.d.CreatedAt} }
+func (r *diagnosisResolver) RecordedBy(ctx context.Context) (*staffResolver, error) {
+	return loadStaff(ctx, r.d.RecordedByID)
+}
+
+// serviceError gives the service errors the codes clients can tell apart, as the REST API
+// gives them status codes
+func serviceError(err error) error {
+	code := CodeInternal
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		code = CodeBadUserInput
+	case errors.Is(err, services.ErrNotFound):
+		code = CodeNotFound
+	case errors.Is(err, services.ErrForbidden):
+		code = CodeForbidden
+	}
+	return &resolverError{code: code, message: err.Error()}
+}
@@ -0,0 +1,88 @@
+package graphql
+
+import (
+	"context"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/services"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/graph-gophers/dataloader"
+)
+
+// loaderWait is how long a loader collects the IDs of the objects resolved at the same time
+// before it queries them together
+const loaderWait = 2 * time.Millisecond
+
+type loadersKey struct{}
+
+// loaders load the records related to the objects of one request. graphql-go resolves the
+// objects of a list concurrently, so the loads of a field for all of them become one query,
+// and each record is loaded once.
+type loaders struct {
+	hospitals  *dataloader.Loader
+	staff      *dataloader.Loader
+	admissions *dataloader.Loader
+	diagnoses  *dataloader.Loader
+}
+
+// withLoaders adds the loaders of a request by a staff member of the hospital
+func withLoaders(ctx context.Context, lookupService services.LookupServiceInterface, hospitalID uuid.UUID) context.Context {
+	l := &loaders{
+		hospitals: newLoader(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]entities.Hospital, error) {
+			// Only the caller's own hospital can be read
+			return lookupService.Hospitals(ctx, slices.DeleteFunc(ids, func(id uuid.UUID) bool { return id != hospitalID }))
+		}),
+		staff: newLoader(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]entities.Staff, error) {
+			return lookupService.Staff(ctx, ids, hospitalID)
+		}),
+		admissions: newLoader(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]entities.Admission, error) {
+			return lookupService.AdmissionsByPatient(ctx, ids, hospitalID)
+		}),
+		diagnoses: newLoader(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID][]entities.Diagnosis, error) {
+			return lookupService.DiagnosesByPatient(ctx, ids, hospitalID)
+		}),
+	}
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// idKey is a UUID as a dataloader key
+type idKey uuid.UUID
+
+func (k idKey) String() string { return uuid.UUID(k).String() }
+func (k idKey) Raw() any       { return uuid.UUID(k) }
+
+// newLoader batches loads by ID into calls of load
+func newLoader[V any](load func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]V, error)) *dataloader.Loader {
+	return dataloader.NewBatchedLoader(func(ctx context.Context, keys dataloader.Keys) []*dataloader.Result {
+		ids := make([]uuid.UUID, len(keys))
+		for i, k := range keys {
+			ids[i] = k.Raw().(uuid.UUID)
+		}
+		byID, err := load(ctx, ids)
+		results := make([]*dataloader.Result, len(keys))
+		for i, id := range ids {
+			results[i] = &dataloader.Result{Error: err}
+			if v, ok := byID[id]; ok {
+				results[i].Data = v
+			}
+		}
+		return results
+	}, dataloader.WithWait(loaderWait))
+}
+
+// load returns the record l loaded for id, and whether there is one
+func load[V any](ctx context.Context, l *dataloader.Loader, id uuid.UUID) (V, bool, error) {
+	data, err := l.Load(ctx, idKey(id))()
+	if err != nil {
+		var zero V
+		return zero, false, serviceError(err)
+	}
+	v, ok := data.(V)
+	return v, ok, nil
+}
@@ -0,0 +1,73 @@
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Date is the Date scalar, a calendar date such as 1990-04-21
+type Date struct {
+	time.Time
+}
+
+func (Date) ImplementsGraphQLType(name string) bool {
+	return name == "Date"
+}
+
+func (d *Date) UnmarshalGraphQL(input any) error {
+	s, ok := input.(string)
+	if !ok {
+		return fmt.Errorf("Date cannot represent a non string value: %v", input)
+	}
+	t, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return fmt.Errorf("Date must be formatted as YYYY-MM-DD, got %q", s)
+	}
+	d.Time = t
+	return nil
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Format(time.DateOnly))
+}
+
+// DateTime is the DateTime scalar, an RFC 3339 timestamp
+type DateTime struct {
+	time.Time
+}
+
+func (DateTime) ImplementsGraphQLType(name string) bool {
+	return name == "DateTime"
+}
+
+func (t *DateTime) UnmarshalGraphQL(input any) error {
+	s, ok := input.(string)
+	if !ok {
+		return fmt.Errorf("DateTime cannot represent a non string value: %v", input)
+	}
+	parsed, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return fmt.Errorf("DateTime must be an RFC 3339 timestamp, got %q", s)
+	}
+	t.Time = parsed
+	return nil
+}
+
+func (t DateTime) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Format(time.RFC3339Nano))
+}
+
+func optionalDate(t *time.Time) *Date {
+	if t == nil {
+		return nil
+	}
+	return &Date{*t}
+}
+
+func optionalDateTime(t *time.Time) *DateTime {
+	if t == nil {
+		return nil
+	}
+	return &DateTime{*t}
+}
@@ -0,0 +1,296 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/services"
+	"go-hospital-api/internal/utils"
+	hospitalv1 "go-hospital-api/proto/hospital/v1"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+// PublicMethods can be called without a token, like their REST endpoints
+var PublicMethods = []string{hospitalv1.StaffService_CreateStaff_FullMethodName, hospitalv1.StaffService_Login_FullMethodName}
+
+// NewHospitalServer serves the staff and patient services of hospital.proto with the same service
+// implementations as the REST API, behind the given interceptors, along with server reflection.
+func NewHospitalServer(patientService services.PatientServiceInterface, staffService services.StaffServiceInterface, interceptors ...grpc.UnaryServerInterceptor) *grpc.Server {
+	s := grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...))
+	hospitalv1.RegisterStaffServiceServer(s, &staffServer{staffService: staffService})
+	hospitalv1.RegisterPatientServiceServer(s, &patientServer{patientService: patientService, staffService: staffService})
+	reflection.Register(s)
+	return s
+}
+
+type staffIDKey struct{}
+
+// AuthInterceptor verifies the token in the authorization metadata ("Bearer <token>") as the
+// REST API verifies the Authorization header, and passes the staff ID on in the context. The
+// public methods are called without a token.
+func AuthInterceptor(public ...string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+		if slices.Contains(public, info.FullMethod) {
+			return next(ctx, req)
+		}
+		auth := metadata.ValueFromIncomingContext(ctx, "authorization")
+		if len(auth) == 0 || auth[0] == "" {
+			return nil, status.Error(codes.Unauthenticated, "missing authorization metadata")
+		}
+		token, ok := strings.CutPrefix(auth[0], "Bearer ")
+		if !ok {
+			return nil, status.Error(codes.Unauthenticated, "invalid authorization metadata format")
+		}
+		staffID, err := utils.VerifyToken(token)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return next(context.WithValue(ctx, staffIDKey{}, staffID), req)
+	}
+}
+
+// LoggingInterceptor logs every call with its status and duration
+func LoggingInterceptor(logger *log.Logger) grpc.UnaryServerInterceptor {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := next(ctx, req)
+		logger.Printf("[gRPC] %s | %s | %v", info.FullMethod, status.Code(err), time.Since(start))
+		return resp, err
+	}
+}
+
+type staffServer struct {
+	hospitalv1.UnimplementedStaffServiceServer
+	staffService services.StaffServiceInterface
+}
+
+// CreateStaff registers a staff member with the staff role; the request has no role to choose
+func (s *staffServer) CreateStaff(ctx context.Context, req *hospitalv1.CreateStaffRequest) (*hospitalv1.Staff, error) {
+	if req.GetUsername() == "" || req.GetPassword() == "" {
+		return nil, status.Error(codes.InvalidArgument, "username and password are required")
+	}
+	hospitalID, err := uuid.Parse(req.GetHospitalId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid hospital_id")
+	}
+	staff := entities.Staff{
+		ID:         uuid.New(),
+		Username:   req.GetUsername(),
+		Role:       entities.RoleStaff,
+		HospitalID: hospitalID,
+	}
+	if err := s.staffService.Create(&staff, req.GetPassword()); err != nil {
+		return nil, serviceError(err)
+	}
+	return toStaffMessage(staff), nil
+}
+
+func (s *staffServer) Login(ctx context.Context, req *hospitalv1.LoginRequest) (*hospitalv1.LoginResponse, error) {
+	hospitalID, err := uuid.Parse(req.GetHospitalId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid hospital_id")
+	}
+	staff, err := s.staffService.Login(req.GetUsername(), req.GetPassword(), hospitalID)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid username or password")
+	}
+	jwtSecret := os.Getenv("JWT_SECRET")
+	if jwtSecret == "" {
+		return nil, status.Error(codes.Internal, "JWT_SECRET not configured")
+	}
+	token, err := utils.GenerateJWT(staff.ID.String(), jwtSecret)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to generate token")
+	}
+	return &hospitalv1.LoginResponse{Token: token}, nil
+}
+
+type patientServer struct {
+	hospitalv1.UnimplementedPatientServiceServer
+	patientService services.PatientServiceInterface
+	staffService   services.StaffServiceInterface
+}
+
+// currentHospital returns the caller's hospital, from the staff ID AuthInterceptor established
+func (s *patientServer) currentHospital(ctx context.Context) (uuid.UUID, error) {
+	staffID, _ := ctx.Value(staffIDKey{}).(string)
+	if staffID == "" {
+		return uuid.Nil, status.Error(codes.Unauthenticated, "missing authorization metadata")
+	}
+	hospitalID, err := s.staffService.GetHospitalIDByStaffID(staffID)
+	if err != nil {
+		return uuid.Nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+	return hospitalID, nil
+}
+
+// currentRole returns the caller's role; a staff member who cannot be found has none
+func (s *patientServer) currentRole(ctx context.Context, hospitalID uuid.UUID) (string, error) {
+	sub, _ := ctx.Value(staffIDKey{}).(string)
+	staffID, err := uuid.Parse(sub)
+	if err != nil {
+		return "", status.Error(codes.Unauthenticated, "invalid staff ID")
+	}
+	staff, err := s.staffService.GetByID(staffID, hospitalID)
+	if err != nil && !errors.Is(err, services.ErrNotFound) {
+		return "", serviceError(err)
+	}
+	if staff == nil {
+		return "", nil
+	}
+	return staff.Role, nil
+}
+
+func (s *patientServer) SearchPatients(ctx context.Context, req *hospitalv1.SearchPatientsRequest) (*hospitalv1.SearchPatientsResponse, error) {
+	hospitalID, err := s.currentHospital(ctx)
+	if err != nil {
+		return nil, err
+	}
+	criteria := dto.PatientSearchCriteria{
+		NationalID:  optionalString(req.GetNationalId()),
+		PassportID:  optionalString(req.GetPassportId()),
+		FirstName:   optionalString(req.GetFirstName()),
+		MiddleName:  optionalString(req.GetMiddleName()),
+		LastName:    optionalString(req.GetLastName()),
+		DateOfBirth: optionalTime(req.GetDateOfBirth()),
+		PhoneNumber: optionalString(req.GetPhoneNumber()),
+		Email:       optionalString(req.GetEmail()),
+		Province:    optionalString(req.GetProvince()),
+		District:    optionalString(req.GetDistrict()),
+		Name:        optionalString(req.GetName()),
+		PatientHN:   optionalString(req.GetPatientHn()),
+		Identifier:  optionalString(req.GetIdentifier()),
+		Gender:      optionalString(req.GetGender()),
+		HospitalID:  hospitalID,
+	}
+	patients, err := s.patientService.Search(ctx, criteria)
+	if err != nil {
+		return nil, serviceError(err)
+	}
+	role, err := s.currentRole(ctx, hospitalID)
+	if err != nil {
+		return nil, err
+	}
+	resp := &hospitalv1.SearchPatientsResponse{Patients: make([]*hospitalv1.Patient, len(patients))}
+	for i, p := range patients {
+		// Search results are masked by role as patient exports are
+		resp.Patients[i] = toPatientMessage(services.MaskPatient(p, role))
+	}
+	return resp, nil
+}
+
+func (s *patientServer) GetPatient(ctx context.Context, req *hospitalv1.GetPatientRequest) (*hospitalv1.Patient, error) {
+	hospitalID, err := s.currentHospital(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := uuid.Parse(req.GetPatientId())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, "invalid patient_id")
+	}
+	patient, err := s.patientService.Get(ctx, id, hospitalID, optionalTime(req.GetAsOf()))
+	if err != nil {
+		return nil, serviceError(err)
+	}
+	return toPatientMessage(*patient), nil
+}
+
+// serviceError maps the service errors as the REST API does, to the nearest gRPC codes
+func serviceError(err error) error {
+	code := codes.Internal
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		code = codes.InvalidArgument
+	case errors.Is(err, services.ErrNotFound):
+		code = codes.NotFound
+	case errors.Is(err, services.ErrConflict), errors.Is(err, gorm.ErrDuplicatedKey):
+		code = codes.AlreadyExists
+	case errors.Is(err, services.ErrForbidden):
+		code = codes.PermissionDenied
+	case errors.Is(err, services.ErrUpstream):
+		code = codes.Unavailable
+	case errors.Is(err, services.ErrPreconditionFailed):
+		code = codes.FailedPrecondition
+	}
+	return status.Error(code, err.Error())
+}
+
+func toStaffMessage(s entities.Staff) *hospitalv1.Staff {
+	return &hospitalv1.Staff{
+		StaffId:    s.ID.String(),
+		Username:   s.Username,
+		Role:       s.Role,
+		HospitalId: s.HospitalID.String(),
+		Version:    int32(s.Version),
+		CreatedAt:  timestamp(s.CreatedAt),
+		UpdatedAt:  timestamp(s.UpdatedAt),
+	}
+}
+
+func toPatientMessage(p entities.Patient) *hospitalv1.Patient {
+	m := &hospitalv1.Patient{
+		PatientId:    p.ID.String(),
+		FirstNameTh:  p.FirstNameTH,
+		MiddleNameTh: p.MiddleNameTH,
+		LastNameTh:   p.LastNameTH,
+		FirstNameEn:  p.FirstNameEN,
+		MiddleNameEn: p.MiddleNameEN,
+		LastNameEn:   p.LastNameEN,
+		PatientHn:    p.PatientHN,
+		NationalId:   p.NationalID,
+		PassportId:   p.PassportID,
+		PhoneNumber:  p.PhoneNumber,
+		Email:        p.Email,
+		Gender:       p.Gender,
+		HospitalId:   p.HospitalID.String(),
+		Version:      int32(p.Version),
+		CreatedAt:    timestamp(p.CreatedAt),
+		UpdatedAt:    timestamp(p.UpdatedAt),
+	}
+	if p.DateOfBirth != nil {
+		m.DateOfBirth = timestamp(*p.DateOfBirth)
+	}
+	return m
+}
+
+// optionalString is nil for an empty field, which does not filter a search
+func optionalString(v string) *string {
+	if v = strings.TrimSpace(v); v != "" {
+		return &v
+	}
+	return nil
+}
+
+// optionalTime is nil when the timestamp is not set
+func optionalTime(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
+// timestamp leaves a zero time unset
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
@@ -0,0 +1,448 @@
+package handlers
+
+import (
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/services"
+	"go-hospital-api/internal/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BillingHandler struct {
+	billingService services.BillingServiceInterface
+	staffService   services.StaffServiceInterface
+}
+
+func NewBillingHandler(billingService services.BillingServiceInterface, staffService services.StaffServiceInterface) *BillingHandler {
+	return &BillingHandler{
+		billingService: billingService,
+		staffService:   staffService,
+	}
+}
+
+// CreatePriceItemHandler adds an item to the caller's hospital price list
+// @Summary Create price item
+// @Tags billing
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param item body dto.PriceItemCreateRequest true "Price item (VAT-exclusive unit price in baht)"
+// @Success 201 {object} dto.PriceItemResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /price-items [post]
+func (h *BillingHandler) CreatePriceItemHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	var req dto.PriceItemCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	item := entities.PriceItem{
+		HospitalID: hospitalID,
+		Code:       req.Code,
+		Name:       req.Name,
+		Category:   req.Category,
+		UnitPrice:  utils.BahtToSatang(req.UnitPrice),
+		VATable:    req.VATable,
+	}
+	if err := h.billingService.CreatePriceItem(c.Request.Context(), &item); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": toPriceItemResponse(item)})
+}
+
+// ListPriceItemsHandler lists the caller's hospital price list
+// @Summary List price items
+// @Tags billing
+// @Produce json
+// @Security BearerAuth
+// @Param q query string false "Code prefix or name"
+// @Success 200 {object} []dto.PriceItemResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /price-items [get]
+func (h *BillingHandler) ListPriceItemsHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	items, err := h.billingService.ListPriceItems(c.Request.Context(), hospitalID, c.Query("q"))
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	resp := make([]dto.PriceItemResponse, len(items))
+	for i, item := range items {
+		resp[i] = toPriceItemResponse(item)
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
+}
+
+// PostChargeHandler captures a billable item against a patient
+// @Summary Post charge
+// @Tags billing
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Param charge body dto.ChargeCreateRequest true "Charge"
+// @Success 201 {object} dto.ChargeResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id}/charges [post]
+func (h *BillingHandler) PostChargeHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	patientID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req dto.ChargeCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	charge := entities.Charge{
+		PatientID:   patientID,
+		HospitalID:  hospitalID,
+		PriceItemID: req.PriceItemID,
+		Quantity:    req.Quantity,
+		Description: req.Description,
+		PostedByID:  staffID,
+	}
+	if req.ServiceDate != nil {
+		charge.ServiceDate = *req.ServiceDate
+	}
+	if err := h.billingService.PostCharge(c.Request.Context(), &charge); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": toChargeResponse(charge)})
+}
+
+// ListChargesHandler lists a patient's charges
+// @Summary List charges
+// @Tags billing
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Param status query string false "posted, invoiced or voided"
+// @Success 200 {object} []dto.ChargeResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id}/charges [get]
+func (h *BillingHandler) ListChargesHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	patientID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	charges, err := h.billingService.ListCharges(c.Request.Context(), patientID, hospitalID, c.Query("status"))
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	resp := make([]dto.ChargeResponse, len(charges))
+	for i, ch := range charges {
+		resp[i] = toChargeResponse(ch)
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
+}
+
+// VoidChargeHandler cancels a charge that has not been invoiced
+// @Summary Void charge
+// @Tags billing
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Charge ID"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /charges/{id}/void [post]
+func (h *BillingHandler) VoidChargeHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.billingService.VoidCharge(c.Request.Context(), id, hospitalID); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, dto.MessageResponse{Status: "success", Message: "charge voided"})
+}
+
+// CreateInvoiceHandler invoices a patient's posted charges
+// @Summary Create invoice
+// @Description Bills the given charges (all posted charges when charge_ids is empty) with an optional discount and Thai VAT on VAT-able items
+// @Tags billing
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Param invoice body dto.InvoiceCreateRequest true "Invoice"
+// @Success 201 {object} dto.InvoiceResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id}/invoices [post]
+func (h *BillingHandler) CreateInvoiceHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	patientID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req dto.InvoiceCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	invoice := entities.Invoice{PatientID: patientID, HospitalID: hospitalID, IssuedByID: staffID}
+	discount := services.InvoiceDiscount{Amount: utils.BahtToSatang(req.DiscountAmount), Percent: req.DiscountPercent}
+	if err := h.billingService.CreateInvoice(c.Request.Context(), &invoice, req.ChargeIDs, discount); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": toInvoiceResponse(invoice)})
+}
+
+// GetInvoiceHandler returns an invoice as JSON
+// @Summary Get invoice
+// @Tags billing
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Invoice ID"
+// @Success 200 {object} dto.InvoiceResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /invoices/{id} [get]
+func (h *BillingHandler) GetInvoiceHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	invoice, err := h.billingService.GetInvoice(c.Request.Context(), id, hospitalID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": toInvoiceResponse(*invoice)})
+}
+
+// InvoicePDFHandler returns a printable PDF of an invoice
+// @Summary Invoice PDF
+// @Tags billing
+// @Produce application/pdf
+// @Security BearerAuth
+// @Param id path string true "Invoice ID"
+// @Success 200 {file} file
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /invoices/{id}/pdf [get]
+func (h *BillingHandler) InvoicePDFHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	invoice, err := h.billingService.GetInvoice(c.Request.Context(), id, hospitalID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.Header("Content-Disposition", `inline; filename="`+invoice.Number+`.pdf"`)
+	c.Data(http.StatusOK, "application/pdf", services.RenderInvoicePDF(invoice))
+}
+
+// AddPaymentHandler records a payment against an invoice
+// @Summary Record payment
+// @Tags billing
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Invoice ID"
+// @Param payment body dto.PaymentCreateRequest true "cash, card or insurance payment in baht"
+// @Success 201 {object} dto.InvoiceResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse "overpayment"
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /invoices/{id}/payments [post]
+func (h *BillingHandler) AddPaymentHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req dto.PaymentCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	payment := entities.Payment{
+		InvoiceID:    id,
+		HospitalID:   hospitalID,
+		Method:       req.Method,
+		Amount:       utils.BahtToSatang(req.Amount),
+		Reference:    req.Reference,
+		ReceivedByID: staffID,
+		PaidAt:       time.Now(),
+	}
+	invoice, err := h.billingService.AddPayment(c.Request.Context(), &payment)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": toInvoiceResponse(*invoice)})
+}
+
+// BalanceHandler returns what a patient owes the caller's hospital
+// @Summary Patient balance
+// @Tags billing
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Success 200 {object} dto.PatientBalanceResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id}/balance [get]
+func (h *BillingHandler) BalanceHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	patientID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	balance, err := h.billingService.Balance(c.Request.Context(), patientID, hospitalID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": dto.PatientBalanceResponse{
+		PatientID:          patientID,
+		InvoiceOutstanding: utils.SatangToBaht(balance.Outstanding),
+		UninvoicedCharges:  utils.SatangToBaht(balance.Uninvoiced),
+	}})
+}
+
+func toPriceItemResponse(p entities.PriceItem) dto.PriceItemResponse {
+	return dto.PriceItemResponse{
+		PriceItemID: p.ID,
+		Code:        p.Code,
+		Name:        p.Name,
+		Category:    p.Category,
+		UnitPrice:   utils.SatangToBaht(p.UnitPrice),
+		VATable:     p.VATable,
+	}
+}
+
+func toChargeResponse(ch entities.Charge) dto.ChargeResponse {
+	return dto.ChargeResponse{
+		ChargeID:    ch.ID,
+		PatientID:   ch.PatientID,
+		PriceItemID: ch.PriceItemID,
+		Code:        ch.Code,
+		Description: ch.Description,
+		Quantity:    ch.Quantity,
+		UnitPrice:   utils.SatangToBaht(ch.UnitPrice),
+		Amount:      utils.SatangToBaht(int64(ch.Quantity) * ch.UnitPrice),
+		VATable:     ch.VATable,
+		ServiceDate: ch.ServiceDate,
+		Status:      ch.Status,
+		InvoiceID:   ch.InvoiceID,
+		PostedByID:  ch.PostedByID,
+		CreatedAt:   ch.CreatedAt,
+	}
+}
+
+func toInvoiceResponse(inv entities.Invoice) dto.InvoiceResponse {
+	lines := make([]dto.InvoiceLineResponse, len(inv.Lines))
+	for i, l := range inv.Lines {
+		lines[i] = dto.InvoiceLineResponse{
+			ChargeID:    l.ChargeID,
+			Code:        l.Code,
+			Description: l.Description,
+			Quantity:    l.Quantity,
+			UnitPrice:   utils.SatangToBaht(l.UnitPrice),
+			Amount:      utils.SatangToBaht(l.Amount),
+			Discount:    utils.SatangToBaht(l.Discount),
+			VATable:     l.VATable,
+			VATAmount:   utils.SatangToBaht(l.VATAmount),
+		}
+	}
+	payments := make([]dto.PaymentResponse, len(inv.Payments))
+	for i, p := range inv.Payments {
+		payments[i] = dto.PaymentResponse{
+			PaymentID:    p.ID,
+			Method:       p.Method,
+			Amount:       utils.SatangToBaht(p.Amount),
+			Reference:    p.Reference,
+			ReceivedByID: p.ReceivedByID,
+			PaidAt:       p.PaidAt,
+		}
+	}
+	return dto.InvoiceResponse{
+		InvoiceID:     inv.ID,
+		Number:        inv.Number,
+		PatientID:     inv.PatientID,
+		Status:        inv.Status,
+		Subtotal:      utils.SatangToBaht(inv.Subtotal),
+		DiscountTotal: utils.SatangToBaht(inv.DiscountTotal),
+		VATRate:       services.ThaiVATPercent,
+		VATAmount:     utils.SatangToBaht(inv.VATAmount),
+		Total:         utils.SatangToBaht(inv.Total),
+		Paid:          utils.SatangToBaht(inv.Paid),
+		Balance:       utils.SatangToBaht(inv.Total - inv.Paid),
+		IssuedByID:    inv.IssuedByID,
+		IssuedAt:      inv.IssuedAt,
+		Lines:         lines,
+		Payments:      payments,
+	}
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ConsentHandler struct {
+	consentService services.ConsentServiceInterface
+	staffService   services.StaffServiceInterface
+}
+
+func NewConsentHandler(consentService services.ConsentServiceInterface, staffService services.StaffServiceInterface) *ConsentHandler {
+	return &ConsentHandler{
+		consentService: consentService,
+		staffService:   staffService,
+	}
+}
+
+// GrantHandler records a patient's consent, witnessed by the calling staff member
+// @Summary Grant consent
+// @Description Supersedes the consent currently granted for the same purpose
+// @Tags consents
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Param consent body dto.ConsentGrantRequest true "Consent (purpose: treatment, data_sharing, marketing or research)"
+// @Success 201 {object} dto.ConsentResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id}/consents [post]
+func (h *ConsentHandler) GrantHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	patientID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req dto.ConsentGrantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	consent := entities.Consent{
+		PatientID:     patientID,
+		HospitalID:    hospitalID,
+		Purpose:       req.Purpose,
+		TextVersion:   req.TextVersion,
+		WitnessedByID: staffID,
+	}
+	if req.GrantedAt != nil {
+		consent.GrantedAt = *req.GrantedAt
+	}
+	if err := h.consentService.Grant(c.Request.Context(), &consent); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": toConsentResponse(consent)})
+}
+
+// ListHandler lists a patient's consent history, newest first
+// @Summary List consents
+// @Tags consents
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Param purpose query string false "Purpose"
+// @Success 200 {object} []dto.ConsentResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id}/consents [get]
+func (h *ConsentHandler) ListHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	patientID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	consents, err := h.consentService.ListByPatient(c.Request.Context(), patientID, hospitalID, c.Query("purpose"))
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	resp := make([]dto.ConsentResponse, len(consents))
+	for i, cs := range consents {
+		resp[i] = toConsentResponse(cs)
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
+}
+
+// CheckHandler tells whether the patient currently consents to a purpose
+// @Summary Check consent
+// @Tags consents
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Param purpose query string true "Purpose"
+// @Success 200 {object} dto.ConsentCheckResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id}/consents/check [get]
+func (h *ConsentHandler) CheckHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	patientID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	purpose := c.Query("purpose")
+	consent, err := h.consentService.Check(c.Request.Context(), patientID, hospitalID, purpose)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	resp := dto.ConsentCheckResponse{PatientID: patientID, Purpose: purpose, Granted: consent != nil}
+	if consent != nil {
+		cs := toConsentResponse(*consent)
+		resp.Purpose = consent.Purpose
+		resp.Consent = &cs
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
+}
+
+// WithdrawHandler withdraws a granted consent
+// @Summary Withdraw consent
+// @Tags consents
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Consent ID"
+// @Param withdraw body dto.ConsentWithdrawRequest false "Reason"
+// @Success 200 {object} dto.ConsentResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /consents/{id}/withdraw [post]
+func (h *ConsentHandler) WithdrawHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req dto.ConsentWithdrawRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+			return
+		}
+	}
+	consent, err := h.consentService.Withdraw(c.Request.Context(), id, hospitalID, staffID, req.Reason)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": toConsentResponse(*consent)})
+}
+
+func toConsentResponse(cs entities.Consent) dto.ConsentResponse {
+	return dto.ConsentResponse{
+		ConsentID:      cs.ID,
+		PatientID:      cs.PatientID,
+		Purpose:        cs.Purpose,
+		TextVersion:    cs.TextVersion,
+		Status:         cs.Status,
+		GrantedAt:      cs.GrantedAt,
+		WitnessedByID:  cs.WitnessedByID,
+		WithdrawnAt:    cs.WithdrawnAt,
+		WithdrawnByID:  cs.WithdrawnByID,
+		WithdrawReason: cs.WithdrawReason,
+	}
+}
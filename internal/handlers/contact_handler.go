@@ -0,0 +1,442 @@
+package handlers
+
+import (
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ContactHandler struct {
+	contactService services.ContactServiceInterface
+	staffService   services.StaffServiceInterface
+}
+
+func NewContactHandler(contactService services.ContactServiceInterface, staffService services.StaffServiceInterface) *ContactHandler {
+	return &ContactHandler{
+		contactService: contactService,
+		staffService:   staffService,
+	}
+}
+
+// AddAddressHandler adds a structured Thai address to a patient
+// @Summary Add address
+// @Description Subdistrict, district, province and postal code must match the administrative-area dataset
+// @Tags contacts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Param address body dto.AddressRequest true "Address (type: home, current or work)"
+// @Success 201 {object} dto.AddressResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id}/addresses [post]
+func (h *ContactHandler) AddAddressHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	patientID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req dto.AddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	address := toAddressEntity(req)
+	address.PatientID = patientID
+	address.HospitalID = hospitalID
+	if err := h.contactService.AddAddress(c.Request.Context(), &address); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": toAddressResponse(address)})
+}
+
+// ListAddressesHandler lists a patient's addresses
+// @Summary List addresses
+// @Tags contacts
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Success 200 {object} []dto.AddressResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id}/addresses [get]
+func (h *ContactHandler) ListAddressesHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	patientID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	addresses, err := h.contactService.ListAddresses(c.Request.Context(), patientID, hospitalID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	resp := make([]dto.AddressResponse, len(addresses))
+	for i, a := range addresses {
+		resp[i] = toAddressResponse(a)
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
+}
+
+// UpdateAddressHandler replaces a patient's address
+// @Summary Update address
+// @Tags contacts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Param addressId path string true "Address ID"
+// @Param If-Match header string true "ETag of the version being changed"
+// @Param address body dto.AddressRequest true "Address"
+// @Success 200 {object} dto.AddressResponse
+// @Header 200 {string} ETag "New version"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Failure 428 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id}/addresses/{addressId} [put]
+func (h *ContactHandler) UpdateAddressHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	patientID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	addressID, ok := parseUUIDParam(c, "addressId")
+	if !ok {
+		return
+	}
+	version, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+	var req dto.AddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	address := toAddressEntity(req)
+	address.ID = addressID
+	address.PatientID = patientID
+	address.HospitalID = hospitalID
+	address.Version = version
+	if err := h.contactService.UpdateAddress(c.Request.Context(), &address); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	setETag(c, address.Version)
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": toAddressResponse(address)})
+}
+
+// DeleteAddressHandler removes a patient's address
+// @Summary Delete address
+// @Tags contacts
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Param addressId path string true "Address ID"
+// @Param If-Match header string true "ETag of the version being deleted"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Failure 428 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id}/addresses/{addressId} [delete]
+func (h *ContactHandler) DeleteAddressHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	patientID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	addressID, ok := parseUUIDParam(c, "addressId")
+	if !ok {
+		return
+	}
+	version, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+	if err := h.contactService.DeleteAddress(c.Request.Context(), addressID, patientID, hospitalID, version); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, dto.MessageResponse{Status: "success", Message: "address deleted"})
+}
+
+// AddContactHandler adds an emergency contact to a patient
+// @Summary Add emergency contact
+// @Tags contacts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Param contact body dto.EmergencyContactRequest true "Contact (relationship: spouse, parent, child, sibling, relative, guardian, friend, caregiver or other)"
+// @Success 201 {object} dto.EmergencyContactResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id}/emergency-contacts [post]
+func (h *ContactHandler) AddContactHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	patientID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req dto.EmergencyContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	contact := toContactEntity(req)
+	contact.PatientID = patientID
+	contact.HospitalID = hospitalID
+	if err := h.contactService.AddContact(c.Request.Context(), &contact); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": toContactResponse(contact)})
+}
+
+// ListContactsHandler lists a patient's emergency contacts in priority order
+// @Summary List emergency contacts
+// @Tags contacts
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Success 200 {object} []dto.EmergencyContactResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id}/emergency-contacts [get]
+func (h *ContactHandler) ListContactsHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	patientID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	contacts, err := h.contactService.ListContacts(c.Request.Context(), patientID, hospitalID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	resp := make([]dto.EmergencyContactResponse, len(contacts))
+	for i, ct := range contacts {
+		resp[i] = toContactResponse(ct)
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
+}
+
+// UpdateContactHandler replaces a patient's emergency contact
+// @Summary Update emergency contact
+// @Tags contacts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Param contactId path string true "Contact ID"
+// @Param If-Match header string true "ETag of the version being changed"
+// @Param contact body dto.EmergencyContactRequest true "Contact"
+// @Success 200 {object} dto.EmergencyContactResponse
+// @Header 200 {string} ETag "New version"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Failure 428 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id}/emergency-contacts/{contactId} [put]
+func (h *ContactHandler) UpdateContactHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	patientID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	contactID, ok := parseUUIDParam(c, "contactId")
+	if !ok {
+		return
+	}
+	version, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+	var req dto.EmergencyContactRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	contact := toContactEntity(req)
+	contact.ID = contactID
+	contact.PatientID = patientID
+	contact.HospitalID = hospitalID
+	contact.Version = version
+	if err := h.contactService.UpdateContact(c.Request.Context(), &contact); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	setETag(c, contact.Version)
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": toContactResponse(contact)})
+}
+
+// DeleteContactHandler removes a patient's emergency contact
+// @Summary Delete emergency contact
+// @Tags contacts
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Param contactId path string true "Contact ID"
+// @Param If-Match header string true "ETag of the version being deleted"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Failure 428 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id}/emergency-contacts/{contactId} [delete]
+func (h *ContactHandler) DeleteContactHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	patientID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	contactID, ok := parseUUIDParam(c, "contactId")
+	if !ok {
+		return
+	}
+	version, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+	if err := h.contactService.DeleteContact(c.Request.Context(), contactID, patientID, hospitalID, version); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, dto.MessageResponse{Status: "success", Message: "emergency contact deleted"})
+}
+
+// SearchAreasHandler looks up subdistricts by province, district or postal code
+// @Summary Search administrative areas
+// @Tags contacts
+// @Produce json
+// @Security BearerAuth
+// @Param province query string false "Province"
+// @Param district query string false "District"
+// @Param postal_code query string false "Postal code"
+// @Success 200 {object} []dto.AdminAreaResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Router /admin-areas [get]
+func (h *ContactHandler) SearchAreasHandler(c *gin.Context) {
+	if _, _, ok := currentStaff(c, h.staffService); !ok {
+		return
+	}
+	province, district, postalCode := c.Query("province"), c.Query("district"), c.Query("postal_code")
+	if province == "" && district == "" && postalCode == "" {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: "province, district or postal_code is required"})
+		return
+	}
+	areas := h.contactService.SearchAreas(province, district, postalCode)
+	resp := make([]dto.AdminAreaResponse, len(areas))
+	for i, a := range areas {
+		resp[i] = dto.AdminAreaResponse{Subdistrict: a.Subdistrict, District: a.District, Province: a.Province, PostalCode: a.PostalCode}
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
+}
+
+func toAddressEntity(req dto.AddressRequest) entities.PatientAddress {
+	return entities.PatientAddress{
+		Type:        req.Type,
+		HouseNumber: req.HouseNumber,
+		Moo:         req.Moo,
+		Village:     req.Village,
+		Soi:         req.Soi,
+		Road:        req.Road,
+		Subdistrict: req.Subdistrict,
+		District:    req.District,
+		Province:    req.Province,
+		PostalCode:  req.PostalCode,
+	}
+}
+
+func toAddressResponse(a entities.PatientAddress) dto.AddressResponse {
+	return dto.AddressResponse{
+		AddressID:   a.ID,
+		PatientID:   a.PatientID,
+		Type:        a.Type,
+		HouseNumber: a.HouseNumber,
+		Moo:         a.Moo,
+		Village:     a.Village,
+		Soi:         a.Soi,
+		Road:        a.Road,
+		Subdistrict: a.Subdistrict,
+		District:    a.District,
+		Province:    a.Province,
+		PostalCode:  a.PostalCode,
+		Version:     a.Version,
+		UpdatedAt:   a.UpdatedAt,
+	}
+}
+
+func toContactEntity(req dto.EmergencyContactRequest) entities.EmergencyContact {
+	return entities.EmergencyContact{
+		Name:         req.Name,
+		Relationship: req.Relationship,
+		PhoneNumber:  req.PhoneNumber,
+		Email:        req.Email,
+		IsNextOfKin:  req.IsNextOfKin,
+		Priority:     req.Priority,
+	}
+}
+
+func toContactResponse(ct entities.EmergencyContact) dto.EmergencyContactResponse {
+	return dto.EmergencyContactResponse{
+		ContactID:    ct.ID,
+		PatientID:    ct.PatientID,
+		Name:         ct.Name,
+		Relationship: ct.Relationship,
+		PhoneNumber:  ct.PhoneNumber,
+		Email:        ct.Email,
+		IsNextOfKin:  ct.IsNextOfKin,
+		Priority:     ct.Priority,
+		Version:      ct.Version,
+		UpdatedAt:    ct.UpdatedAt,
+	}
+}
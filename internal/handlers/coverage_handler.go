@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CoverageHandler struct {
+	coverageService services.CoverageServiceInterface
+	staffService    services.StaffServiceInterface
+}
+
+func NewCoverageHandler(coverageService services.CoverageServiceInterface, staffService services.StaffServiceInterface) *CoverageHandler {
+	return &CoverageHandler{
+		coverageService: coverageService,
+		staffService:    staffService,
+	}
+}
+
+// AddHandler records an insurance or public scheme coverage for a patient
+// @Summary Add coverage
+// @Tags coverage
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Param coverage body dto.CoverageCreateRequest true "Coverage (scheme: uc, sss, csmbs or private)"
+// @Success 201 {object} dto.CoverageResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id}/coverages [post]
+func (h *CoverageHandler) AddHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	patientID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req dto.CoverageCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	coverage := entities.Coverage{
+		PatientID:           patientID,
+		HospitalID:          hospitalID,
+		Scheme:              req.Scheme,
+		PayerName:           req.PayerName,
+		MemberNumber:        req.MemberNumber,
+		ValidTo:             req.ValidTo,
+		PrimaryHospitalCode: req.PrimaryHospitalCode,
+		PrimaryHospitalName: req.PrimaryHospitalName,
+		RecordedByID:        staffID,
+	}
+	if req.ValidFrom != nil {
+		coverage.ValidFrom = *req.ValidFrom
+	}
+	if err := h.coverageService.Add(c.Request.Context(), &coverage); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": toCoverageResponse(coverage)})
+}
+
+// ListHandler lists a patient's coverages, newest first
+// @Summary List coverages
+// @Tags coverage
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Success 200 {object} []dto.CoverageResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id}/coverages [get]
+func (h *CoverageHandler) ListHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	patientID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	coverages, err := h.coverageService.ListByPatient(c.Request.Context(), patientID, hospitalID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	resp := make([]dto.CoverageResponse, len(coverages))
+	for i, cov := range coverages {
+		resp[i] = toCoverageResponse(cov)
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
+}
+
+// VerifyHandler runs an eligibility check with the payer
+// @Summary Verify eligibility
+// @Tags coverage
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Coverage ID"
+// @Success 200 {object} dto.CoverageResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 502 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /coverages/{id}/verify [post]
+func (h *CoverageHandler) VerifyHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	coverage, err := h.coverageService.Verify(c.Request.Context(), id, hospitalID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": toCoverageResponse(*coverage)})
+}
+
+func toCoverageResponse(cov entities.Coverage) dto.CoverageResponse {
+	return dto.CoverageResponse{
+		CoverageID:           cov.ID,
+		PatientID:            cov.PatientID,
+		Scheme:               cov.Scheme,
+		PayerName:            cov.PayerName,
+		MemberNumber:         cov.MemberNumber,
+		ValidFrom:            cov.ValidFrom,
+		ValidTo:              cov.ValidTo,
+		PrimaryHospitalCode:  cov.PrimaryHospitalCode,
+		PrimaryHospitalName:  cov.PrimaryHospitalName,
+		EligibilityStatus:    cov.EligibilityStatus,
+		EligibilityMessage:   cov.EligibilityMessage,
+		EligibilityCheckedAt: cov.EligibilityCheckedAt,
+		CreatedAt:            cov.CreatedAt,
+	}
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/services"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DiagnosisHandler struct {
+	diagnosisService services.DiagnosisServiceInterface
+	staffService     services.StaffServiceInterface
+}
+
+func NewDiagnosisHandler(diagnosisService services.DiagnosisServiceInterface, staffService services.StaffServiceInterface) *DiagnosisHandler {
+	return &DiagnosisHandler{
+		diagnosisService: diagnosisService,
+		staffService:     staffService,
+	}
+}
+
+// ImportCodesHandler imports an ICD-10 catalogue from an uploaded CSV file
+// @Summary Import ICD-10 catalogue
+// @Description Supervisors only, since the catalogue is shared by every hospital.
+// @Description Upload a CSV with columns code, description and optionally description_th, billable, edition
+// @Tags diagnoses
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "Catalogue CSV"
+// @Param edition formData string false "ICD-10 or ICD-10-TM (default ICD-10)"
+// @Success 200 {object} dto.ICD10ImportResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /icd10/import [post]
+func (h *DiagnosisHandler) ImportCodesHandler(c *gin.Context) {
+	staffID, _, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: "file is required"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	defer file.Close()
+
+	edition := c.PostForm("edition")
+	n, err := h.diagnosisService.ImportCatalogue(c.Request.Context(), file, edition, staffID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	if edition == "" {
+		edition = entities.EditionICD10
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": dto.ICD10ImportResponse{Edition: edition, Imported: n}})
+}
+
+// SearchCodesHandler searches the ICD-10 catalogue for autocomplete
+// @Summary Search ICD-10 codes
+// @Tags diagnoses
+// @Produce json
+// @Security BearerAuth
+// @Param q query string false "Code prefix or description text"
+// @Param edition query string false "ICD-10 or ICD-10-TM (default ICD-10)"
+// @Param limit query int false "Maximum results (default 20, max 100)"
+// @Success 200 {object} []dto.ICD10CodeResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /icd10/codes [get]
+func (h *DiagnosisHandler) SearchCodesHandler(c *gin.Context) {
+	if _, _, ok := currentStaff(c, h.staffService); !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	codes, err := h.diagnosisService.SearchCodes(c.Request.Context(), c.Query("q"), c.Query("edition"), limit)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	resp := make([]dto.ICD10CodeResponse, len(codes))
+	for i, code := range codes {
+		resp[i] = dto.ICD10CodeResponse{
+			Code:          code.Code,
+			Edition:       code.Edition,
+			Description:   code.Description,
+			DescriptionTH: code.DescriptionTH,
+			Billable:      code.Billable,
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
+}
+
+// CreateHandler records a coded diagnosis for a patient visit
+// @Summary Record diagnosis
+// @Tags diagnoses
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Param diagnosis body dto.DiagnosisCreateRequest true "Diagnosis"
+// @Success 201 {object} dto.DiagnosisResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id}/diagnoses [post]
+func (h *DiagnosisHandler) CreateHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	patientID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req dto.DiagnosisCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+
+	diagnosis := entities.Diagnosis{
+		PatientID:    patientID,
+		HospitalID:   hospitalID,
+		VisitDate:    req.VisitDate,
+		Code:         req.Code,
+		Edition:      req.Edition,
+		IsPrimary:    req.IsPrimary,
+		Note:         req.Note,
+		RecordedByID: staffID,
+	}
+	if err := h.diagnosisService.Record(c.Request.Context(), &diagnosis); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": toDiagnosisResponse(diagnosis)})
+}
+
+// ListHandler lists a patient's diagnoses, optionally for a single visit date
+// @Summary List diagnoses
+// @Tags diagnoses
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Param visit_date query string false "Visit date (YYYY-MM-DD)"
+// @Success 200 {object} []dto.DiagnosisResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id}/diagnoses [get]
+func (h *DiagnosisHandler) ListHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	patientID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var visitDate *time.Time
+	if v := c.Query("visit_date"); v != "" {
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: "visit_date must be YYYY-MM-DD"})
+			return
+		}
+		visitDate = &d
+	}
+
+	diagnoses, err := h.diagnosisService.ListByPatient(c.Request.Context(), patientID, hospitalID, visitDate)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	resp := make([]dto.DiagnosisResponse, len(diagnoses))
+	for i, d := range diagnoses {
+		resp[i] = toDiagnosisResponse(d)
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
+}
+
+func toDiagnosisResponse(d entities.Diagnosis) dto.DiagnosisResponse {
+	return dto.DiagnosisResponse{
+		DiagnosisID:  d.ID,
+		PatientID:    d.PatientID,
+		VisitDate:    d.VisitDate,
+		Code:         d.Code,
+		Edition:      d.Edition,
+		IsPrimary:    d.IsPrimary,
+		Note:         d.Note,
+		RecordedByID: d.RecordedByID,
+		CreatedAt:    d.CreatedAt,
+	}
+}
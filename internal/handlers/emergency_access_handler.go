@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/services"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type EmergencyAccessHandler struct {
+	emergencyAccessService services.EmergencyAccessServiceInterface
+	staffService           services.StaffServiceInterface
+}
+
+func NewEmergencyAccessHandler(emergencyAccessService services.EmergencyAccessServiceInterface, staffService services.StaffServiceInterface) *EmergencyAccessHandler {
+	return &EmergencyAccessHandler{
+		emergencyAccessService: emergencyAccessService,
+		staffService:           staffService,
+	}
+}
+
+// BreakGlassHandler grants the caller time-limited emergency access to a patient of any hospital
+// @Summary Break the glass
+// @Description Doctors and nurses only. Raises a high-priority audit event; every grant must be reviewed by a supervisor
+// @Tags break-the-glass
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param access body dto.EmergencyAccessRequest true "Patient and reason (duration_minutes default 60, max 240)"
+// @Success 201 {object} dto.EmergencyAccessResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /break-glass [post]
+func (h *EmergencyAccessHandler) BreakGlassHandler(c *gin.Context) {
+	staffID, _, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	var req dto.EmergencyAccessRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	access, err := h.emergencyAccessService.Grant(c.Request.Context(), staffID, req.PatientID, req.Reason, req.DurationMinutes)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": toEmergencyAccessResponse(*access)})
+}
+
+// PatientHandler returns the patient of an emergency access to its holder until it expires
+// @Summary Patient under emergency access
+// @Tags break-the-glass
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Emergency access ID"
+// @Success 200 {object} dto.EmergencyPatientResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /break-glass/{id}/patient [get]
+func (h *EmergencyAccessHandler) PatientHandler(c *gin.Context) {
+	staffID, _, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	access, err := h.emergencyAccessService.Patient(c.Request.Context(), id, staffID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": dto.EmergencyPatientResponse{
+		AccessID:  access.ID,
+		ExpiresAt: access.ExpiresAt,
+		Patient:   toPatientResponse(access.Patient, time.Now()),
+	}})
+}
+
+// ReportHandler lists emergency accesses by the hospital's staff or on its patients
+// @Summary Emergency access report
+// @Description Supervisors only
+// @Tags break-the-glass
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "pending, justified or unjustified"
+// @Success 200 {object} []dto.EmergencyAccessResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /break-glass/reviews [get]
+func (h *EmergencyAccessHandler) ReportHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	accesses, err := h.emergencyAccessService.Report(c.Request.Context(), hospitalID, staffID, c.Query("status"))
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	resp := make([]dto.EmergencyAccessResponse, len(accesses))
+	for i, a := range accesses {
+		resp[i] = toEmergencyAccessResponse(a)
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
+}
+
+// ReviewHandler records a supervisor's verdict on an emergency access
+// @Summary Review emergency access
+// @Description Supervisors only, never on their own access. A note is required for unjustified
+// @Tags break-the-glass
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Emergency access ID"
+// @Param review body dto.EmergencyAccessReviewRequest true "Outcome: justified or unjustified"
+// @Success 200 {object} dto.EmergencyAccessResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /break-glass/{id}/review [post]
+func (h *EmergencyAccessHandler) ReviewHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req dto.EmergencyAccessReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	access, err := h.emergencyAccessService.Review(c.Request.Context(), id, hospitalID, staffID, req.Outcome, req.Note)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": toEmergencyAccessResponse(*access)})
+}
+
+// AuditEventsHandler lists the hospital's newest audit events
+// @Summary List audit events
+// @Description Supervisors only
+// @Tags break-the-glass
+// @Produce json
+// @Security BearerAuth
+// @Param priority query string false "normal or high"
+// @Success 200 {object} []dto.AuditEventResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /audit-events [get]
+func (h *EmergencyAccessHandler) AuditEventsHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	events, err := h.emergencyAccessService.AuditEvents(c.Request.Context(), hospitalID, staffID, c.Query("priority"))
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	resp := make([]dto.AuditEventResponse, len(events))
+	for i, e := range events {
+		resp[i] = dto.AuditEventResponse{
+			EventID:   e.ID,
+			StaffID:   e.StaffID,
+			Action:    e.Action,
+			Priority:  e.Priority,
+			PatientID: e.PatientID,
+			Details:   e.Details,
+			CreatedAt: e.CreatedAt,
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
+}
+
+func toEmergencyAccessResponse(a entities.EmergencyAccess) dto.EmergencyAccessResponse {
+	return dto.EmergencyAccessResponse{
+		AccessID:          a.ID,
+		StaffID:           a.StaffID,
+		StaffUsername:     a.Staff.Username,
+		StaffRole:         a.Staff.Role,
+		StaffHospitalID:   a.StaffHospitalID,
+		PatientID:         a.PatientID,
+		PatientHN:         a.Patient.PatientHN,
+		PatientHospitalID: a.PatientHospitalID,
+		PatientHospital:   a.Patient.Hospital.Name,
+		Reason:            a.Reason,
+		GrantedAt:         a.GrantedAt,
+		ExpiresAt:         a.ExpiresAt,
+		ReviewStatus:      a.ReviewStatus,
+		ReviewedByID:      a.ReviewedByID,
+		ReviewedAt:        a.ReviewedAt,
+		ReviewNote:        a.ReviewNote,
+	}
+}
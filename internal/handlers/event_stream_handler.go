@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"fmt"
+	"go-hospital-api/internal/services"
+	"go-hospital-api/internal/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// DefaultEventStreamHeartbeat is how often an idle event stream sends a comment, so proxies do
+// not close it and the staff member is checked again
+const DefaultEventStreamHeartbeat = 15 * time.Second
+
+type EventStreamHandler struct {
+	streamService services.EventStreamServiceInterface
+	staffService  services.StaffServiceInterface
+	heartbeat     time.Duration
+}
+
+func NewEventStreamHandler(streamService services.EventStreamServiceInterface, staffService services.StaffServiceInterface, heartbeat time.Duration) *EventStreamHandler {
+	if heartbeat <= 0 {
+		heartbeat = DefaultEventStreamHeartbeat
+	}
+	return &EventStreamHandler{
+		streamService: streamService,
+		staffService:  staffService,
+		heartbeat:     heartbeat,
+	}
+}
+
+// StreamHandler streams the patient events of the caller's hospital as server-sent events
+// @Summary Patient event stream
+// @Description Server-sent events of the patients created, updated and merged in the caller's hospital. Each event has the event ID as id, the event type as event and the webhook body as data.
+// @Description A client that reconnects with Last-Event-ID gets the events it missed first. When that event is no longer kept, a reset event is sent instead and the client should reload what it shows.
+// @Description An idle stream sends a heartbeat comment, every 15 seconds by default. The stream ends when the token expires or the staff member is deleted.
+// @Tags events
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Param Last-Event-ID header string false "ID of the last event received"
+// @Success 200 {string} string "text/event-stream"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Router /events/stream [get]
+func (h *EventStreamHandler) StreamHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	sub := h.streamService.Subscribe(hospitalID, c.GetHeader("Last-Event-ID"))
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Keeps nginx from buffering the stream
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	if sub.Reset {
+		fmt.Fprint(c.Writer, "event: reset\ndata: {}\n\n")
+	}
+	for _, e := range sub.Backlog {
+		writeStreamEvent(c, e)
+	}
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case e, open := <-sub.Events:
+			if !open {
+				return
+			}
+			writeStreamEvent(c, e)
+		case <-ticker.C:
+			if !h.stillAuthorized(c, hospitalID) {
+				return
+			}
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+		}
+		c.Writer.Flush()
+	}
+}
+
+// stillAuthorized reports whether the token has not expired and the staff member still works
+// at the hospital the stream is for
+func (h *EventStreamHandler) stillAuthorized(c *gin.Context, hospitalID uuid.UUID) bool {
+	sub, err := utils.VerifyTokenFromRequest(c.Request)
+	if err != nil {
+		return false
+	}
+	current, err := h.staffService.GetHospitalIDByStaffID(sub)
+	return err == nil && current == hospitalID
+}
+
+func writeStreamEvent(c *gin.Context, e services.StreamEvent) {
+	fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Type, e.Data)
+}
@@ -0,0 +1,470 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/fhir"
+	"go-hospital-api/internal/services"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultFHIRPageSize = 20
+	maxFHIRPageSize     = 100
+	fhirBasePath        = "/fhir/R4"
+)
+
+// errUnknownIdentifierSystem marks an identifier search on a system no patient can have
+var errUnknownIdentifierSystem = errors.New("unknown identifier system")
+
+// FHIRHandler exposes patients and staff as FHIR R4 resources. Errors are returned as
+// OperationOutcome resources.
+type FHIRHandler struct {
+	patientService services.PatientServiceInterface
+	staffService   services.StaffServiceInterface
+}
+
+func NewFHIRHandler(patientService services.PatientServiceInterface, staffService services.StaffServiceInterface) *FHIRHandler {
+	return &FHIRHandler{
+		patientService: patientService,
+		staffService:   staffService,
+	}
+}
+
+// CapabilityHandler describes the FHIR interactions the server supports
+// @Summary FHIR CapabilityStatement
+// @Tags fhir
+// @Produce json
+// @Success 200 {object} fhir.CapabilityStatement
+// @Router /fhir/R4/metadata [get]
+func (h *FHIRHandler) CapabilityHandler(c *gin.Context) {
+	writeFHIR(c, http.StatusOK, fhir.Capabilities(fhirBaseURL(c), time.Now()))
+}
+
+// ReadPatientHandler returns a patient of the caller's hospital as a FHIR Patient
+// @Summary Read FHIR Patient
+// @Tags fhir
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Success 200 {object} fhir.Patient
+// @Header 200 {string} ETag "Weak ETag of the version, e.g. W/\"3\""
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} fhir.OperationOutcome
+// @Router /fhir/R4/Patient/{id} [get]
+func (h *FHIRHandler) ReadPatientHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseFHIRID(c)
+	if !ok {
+		return
+	}
+	patient, err := h.patientService.Get(c.Request.Context(), id, hospitalID, nil)
+	if err != nil {
+		writeFHIRServiceError(c, err)
+		return
+	}
+	setFHIRETag(c, patient.Version)
+	writeFHIR(c, http.StatusOK, fhir.FromPatient(*patient))
+}
+
+// SearchPatientHandler searches the caller's hospital and returns a searchset Bundle
+// @Summary Search FHIR Patients
+// @Description Paged with _count (default 20, at most 100) and _offset; the Bundle links to the next and previous pages
+// @Description Identifiers and contact details are masked or left out by the caller's role, as in patient exports
+// @Tags fhir
+// @Produce json
+// @Security BearerAuth
+// @Param name query string false "Any part of the Thai or English name"
+// @Param identifier query string false "system|value, or a bare value matching HN, national ID or passport"
+// @Param birthdate query string false "YYYY-MM-DD, optionally prefixed with eq"
+// @Param gender query string false "male, female or other"
+// @Param phone query string false "Phone number, partial match"
+// @Param email query string false "Email, partial match"
+// @Param _count query int false "Page size"
+// @Param _offset query int false "Index of the first result"
+// @Success 200 {object} fhir.Bundle
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 401 {object} dto.ErrorResponse
+// @Router /fhir/R4/Patient [get]
+func (h *FHIRHandler) SearchPatientHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	role, err := staffRole(h.staffService, staffID, hospitalID)
+	if err != nil {
+		writeFHIRServiceError(c, err)
+		return
+	}
+	query := c.Request.URL.Query()
+	count, offset, err := fhirPaging(query)
+	if err != nil {
+		writeFHIRError(c, http.StatusBadRequest, "invalid", err.Error())
+		return
+	}
+	criteria, err := patientSearchCriteria(query)
+	var (
+		patients []entities.Patient
+		total    int64
+	)
+	switch {
+	case errors.Is(err, errUnknownIdentifierSystem):
+		// No patient can match; the search still succeeds with an empty Bundle
+	case err != nil:
+		writeFHIRError(c, http.StatusBadRequest, "invalid", err.Error())
+		return
+	default:
+		criteria.HospitalID = hospitalID
+		patients, total, err = h.patientService.SearchPage(c.Request.Context(), criteria, offset, count)
+		if err != nil {
+			writeFHIRServiceError(c, err)
+			return
+		}
+	}
+
+	base := fhirBaseURL(c)
+	bundleTotal := int(total)
+	bundle := fhir.Bundle{
+		ResourceType: "Bundle",
+		Type:         "searchset",
+		Total:        &bundleTotal,
+		Entry:        []fhir.BundleEntry{},
+		Link:         []fhir.BundleLink{{Relation: "self", URL: searchPageURL(base, query, offset, count)}},
+	}
+	if offset+count < bundleTotal {
+		bundle.Link = append(bundle.Link, fhir.BundleLink{Relation: "next", URL: searchPageURL(base, query, offset+count, count)})
+	}
+	if offset > 0 {
+		bundle.Link = append(bundle.Link, fhir.BundleLink{Relation: "previous", URL: searchPageURL(base, query, max(offset-count, 0), count)})
+	}
+	for _, p := range patients {
+		bundle.Entry = append(bundle.Entry, fhir.BundleEntry{
+			FullURL:  base + "/Patient/" + p.ID.String(),
+			Resource: fhir.FromPatient(services.MaskPatient(p, role)),
+			Search:   &fhir.BundleSearch{Mode: "match"},
+		})
+	}
+	writeFHIR(c, http.StatusOK, bundle)
+}
+
+// CreatePatientHandler registers a patient in the caller's hospital from a FHIR Patient
+// @Summary Create FHIR Patient
+// @Description The resource needs an identifier with the HN system; the HN must be unique within the hospital
+// @Tags fhir
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param patient body fhir.Patient true "Patient resource"
+// @Success 201 {object} fhir.Patient
+// @Header 201 {string} Location "URL of the created patient"
+// @Header 201 {string} ETag "Weak ETag of the version"
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 409 {object} fhir.OperationOutcome
+// @Router /fhir/R4/Patient [post]
+func (h *FHIRHandler) CreatePatientHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	var resource fhir.Patient
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		writeFHIRError(c, http.StatusBadRequest, "structure", err.Error())
+		return
+	}
+	patient, err := fhir.ToPatient(resource)
+	if err != nil {
+		writeFHIRError(c, http.StatusBadRequest, "invalid", err.Error())
+		return
+	}
+	patient.HospitalID = hospitalID
+	if err := h.patientService.Create(c.Request.Context(), &patient, staffID); err != nil {
+		writeFHIRServiceError(c, err)
+		return
+	}
+	c.Header("Location", fmt.Sprintf("%s/Patient/%s", fhirBaseURL(c), patient.ID))
+	setFHIRETag(c, patient.Version)
+	writeFHIR(c, http.StatusCreated, fhir.FromPatient(patient))
+}
+
+// UpdatePatientHandler replaces a patient of the caller's hospital with a FHIR Patient
+// @Summary Update FHIR Patient
+// @Description The HN cannot be changed, and leaving out birthDate keeps the stored one. Requires If-Match with the ETag last read
+// @Tags fhir
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Param If-Match header string true "ETag of the version being replaced, e.g. W/\"3\""
+// @Param patient body fhir.Patient true "Patient resource"
+// @Success 200 {object} fhir.Patient
+// @Header 200 {string} ETag "Weak ETag of the new version"
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} fhir.OperationOutcome
+// @Failure 412 {object} fhir.OperationOutcome
+// @Failure 428 {object} fhir.OperationOutcome
+// @Router /fhir/R4/Patient/{id} [put]
+func (h *FHIRHandler) UpdatePatientHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseFHIRID(c)
+	if !ok {
+		return
+	}
+	ifMatch := c.GetHeader("If-Match")
+	if strings.TrimSpace(ifMatch) == "" {
+		writeFHIRError(c, http.StatusPreconditionRequired, "required", "If-Match header with the resource's ETag is required")
+		return
+	}
+	version, ok := parseETagVersion(ifMatch)
+	if !ok {
+		writeFHIRError(c, http.StatusBadRequest, "invalid", "If-Match must be a single ETag returned by this server")
+		return
+	}
+	var resource fhir.Patient
+	if err := c.ShouldBindJSON(&resource); err != nil {
+		writeFHIRError(c, http.StatusBadRequest, "structure", err.Error())
+		return
+	}
+	if resource.ID != "" && resource.ID != id.String() {
+		writeFHIRError(c, http.StatusBadRequest, "invalid", "resource id does not match the URL")
+		return
+	}
+	p, err := fhir.ToPatient(resource)
+	if err != nil {
+		writeFHIRError(c, http.StatusBadRequest, "invalid", err.Error())
+		return
+	}
+	req := dto.PatientUpdateRequest{
+		FirstNameTh:  &p.FirstNameTH,
+		MiddleNameTh: &p.MiddleNameTH,
+		LastNameTh:   &p.LastNameTH,
+		FirstNameEn:  &p.FirstNameEN,
+		MiddleNameEn: &p.MiddleNameEN,
+		LastNameEn:   &p.LastNameEN,
+		DateOfBirth:  p.DateOfBirth,
+		NationalID:   &p.NationalID,
+		PassportID:   &p.PassportID,
+		PhoneNumber:  &p.PhoneNumber,
+		Email:        &p.Email,
+		Gender:       &p.Gender,
+	}
+	patient, err := h.patientService.Update(c.Request.Context(), id, hospitalID, staffID, version, req)
+	if err != nil {
+		writeFHIRServiceError(c, err)
+		return
+	}
+	setFHIRETag(c, patient.Version)
+	writeFHIR(c, http.StatusOK, fhir.FromPatient(*patient))
+}
+
+// ReadPractitionerHandler returns a staff member of the caller's hospital as a Practitioner
+// @Summary Read FHIR Practitioner
+// @Tags fhir
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Staff ID"
+// @Success 200 {object} fhir.Practitioner
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} fhir.OperationOutcome
+// @Router /fhir/R4/Practitioner/{id} [get]
+func (h *FHIRHandler) ReadPractitionerHandler(c *gin.Context) {
+	staff, ok := h.staffOfHospital(c)
+	if !ok {
+		return
+	}
+	setFHIRETag(c, staff.Version)
+	writeFHIR(c, http.StatusOK, fhir.FromStaff(*staff))
+}
+
+// ReadPractitionerRoleHandler returns the role of a staff member of the caller's hospital
+// @Summary Read FHIR PractitionerRole
+// @Description A staff member has one role, so the PractitionerRole has the staff ID
+// @Tags fhir
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Staff ID"
+// @Success 200 {object} fhir.PractitionerRole
+// @Failure 400 {object} fhir.OperationOutcome
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} fhir.OperationOutcome
+// @Router /fhir/R4/PractitionerRole/{id} [get]
+func (h *FHIRHandler) ReadPractitionerRoleHandler(c *gin.Context) {
+	staff, ok := h.staffOfHospital(c)
+	if !ok {
+		return
+	}
+	setFHIRETag(c, staff.Version)
+	writeFHIR(c, http.StatusOK, fhir.RoleFromStaff(*staff))
+}
+
+func (h *FHIRHandler) staffOfHospital(c *gin.Context) (*entities.Staff, bool) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return nil, false
+	}
+	id, ok := parseFHIRID(c)
+	if !ok {
+		return nil, false
+	}
+	staff, err := h.staffService.GetByID(id, hospitalID)
+	if err != nil {
+		writeFHIRServiceError(c, err)
+		return nil, false
+	}
+	return staff, true
+}
+
+// patientSearchCriteria maps FHIR Patient search parameters onto the patient search
+func patientSearchCriteria(query url.Values) (dto.PatientSearchCriteria, error) {
+	var criteria dto.PatientSearchCriteria
+	optional := func(name string) *string {
+		if v := strings.TrimSpace(query.Get(name)); v != "" {
+			return &v
+		}
+		return nil
+	}
+	criteria.Name = optional("name")
+	criteria.PhoneNumber = optional("phone")
+	criteria.Email = optional("email")
+	if v := optional("identifier"); v != nil {
+		system, value, hasSystem := strings.Cut(*v, "|")
+		if !hasSystem || system == "" {
+			value = strings.TrimPrefix(*v, "|")
+			criteria.Identifier = &value
+		} else {
+			switch system {
+			case fhir.SystemHN:
+				criteria.PatientHN = &value
+			case fhir.SystemNationalID:
+				criteria.NationalID = &value
+			case fhir.SystemPassport:
+				criteria.PassportID = &value
+			default:
+				return criteria, errUnknownIdentifierSystem
+			}
+		}
+	}
+	if v := optional("gender"); v != nil {
+		gender, err := fhir.GenderCode(*v)
+		if err != nil {
+			return criteria, err
+		}
+		if gender == "" {
+			return criteria, errors.New("searching for an unknown gender is not supported")
+		}
+		criteria.Gender = &gender
+	}
+	if v := optional("birthdate"); v != nil {
+		dob, err := time.Parse("2006-01-02", strings.TrimPrefix(*v, "eq"))
+		if err != nil {
+			return criteria, errors.New("birthdate must be an exact date, YYYY-MM-DD or eqYYYY-MM-DD")
+		}
+		criteria.DateOfBirth = &dob
+	}
+	return criteria, nil
+}
+
+func fhirPaging(query url.Values) (count, offset int, err error) {
+	count = defaultFHIRPageSize
+	if v := query.Get("_count"); v != "" {
+		count, err = strconv.Atoi(v)
+		if err != nil || count < 1 {
+			return 0, 0, errors.New("_count must be a positive number")
+		}
+		count = min(count, maxFHIRPageSize)
+	}
+	if v := query.Get("_offset"); v != "" {
+		offset, err = strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return 0, 0, errors.New("_offset must be zero or more")
+		}
+	}
+	return count, offset, nil
+}
+
+// searchPageURL is the search URL for one page, keeping the other search parameters
+func searchPageURL(base string, query url.Values, offset, count int) string {
+	page := url.Values{}
+	for k, v := range query {
+		page[k] = v
+	}
+	page.Set("_count", strconv.Itoa(count))
+	page.Set("_offset", strconv.Itoa(offset))
+	return base + "/Patient?" + page.Encode()
+}
+
+// fhirBaseURL is the absolute URL of the FHIR base, e.g. http://host/api/fhir/R4
+func fhirBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	path := c.Request.URL.Path
+	if i := strings.Index(path, fhirBasePath); i >= 0 {
+		path = path[:i+len(fhirBasePath)]
+	}
+	return scheme + "://" + c.Request.Host + path
+}
+
+// parseFHIRID is parseUUIDParam with an OperationOutcome body
+func parseFHIRID(c *gin.Context) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		writeFHIRError(c, http.StatusBadRequest, "invalid", "invalid id")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func setFHIRETag(c *gin.Context, version int) {
+	c.Header("ETag", `W/"`+strconv.Itoa(version)+`"`)
+}
+
+func writeFHIR(c *gin.Context, status int, resource interface{}) {
+	c.Header("Content-Type", fhir.ContentType+"; charset=utf-8")
+	c.JSON(status, resource)
+}
+
+func writeFHIRError(c *gin.Context, status int, code, diagnostics string) {
+	writeFHIR(c, status, fhir.NewOperationOutcome(code, diagnostics))
+}
+
+// writeFHIRServiceError is writeServiceError with an OperationOutcome body
+func writeFHIRServiceError(c *gin.Context, err error) {
+	status := serviceErrorStatus(err)
+	code := "exception"
+	switch status {
+	case http.StatusBadRequest:
+		code = "invalid"
+	case http.StatusNotFound:
+		code = "not-found"
+	case http.StatusConflict:
+		code = "duplicate"
+	case http.StatusForbidden:
+		code = "forbidden"
+	case http.StatusPreconditionFailed:
+		code = "conflict"
+	}
+	writeFHIRError(c, status, code, err.Error())
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"go-hospital-api/internal/graphql"
+	"go-hospital-api/internal/services"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxGraphQLRequestSize caps the request body; the depth and complexity limits apply once it parses
+const maxGraphQLRequestSize = 64 << 10
+
+var errMissingQuery = errors.New("the request has no query")
+
+type GraphQLHandler struct {
+	schema       *graphql.Schema
+	limits       graphql.Limits
+	staffService services.StaffServiceInterface
+}
+
+func NewGraphQLHandler(patientService services.PatientServiceInterface, lookupService services.LookupServiceInterface, staffService services.StaffServiceInterface, limits graphql.Limits) *GraphQLHandler {
+	return &GraphQLHandler{
+		schema:       graphql.NewHospitalSchema(patientService, lookupService),
+		limits:       limits,
+		staffService: staffService,
+	}
+}
+
+// QueryHandler runs a GraphQL query as the calling staff member, within their hospital
+// @Summary GraphQL query
+// @Description Patients with their hospital, encounters, diagnoses and the staff involved, in one request. The schema can be read by introspection.
+// @Description Queries can also be sent with GET, as the query, operationName and variables parameters.
+// @Description A request that cannot be executed (syntax, validation, or the depth and complexity limits) gets 400 and errors only; otherwise 200 with the data and the errors of any fields that failed.
+// @Tags graphql
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body graphql.Request true "Query, operation name and variables"
+// @Success 200 {object} graphql.Response
+// @Failure 400 {object} graphql.Response
+// @Failure 401 {object} dto.ErrorResponse
+// @Router /graphql [post]
+func (h *GraphQLHandler) QueryHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	req, err := readGraphQLRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, graphql.Response{Errors: []*graphql.Error{graphql.Errorf(graphql.CodeBadUserInput, "%s", err)}})
+		return
+	}
+	ctx := graphql.WithStaff(c.Request.Context(), staffID, hospitalID)
+	resp := h.schema.Execute(ctx, req, h.limits)
+	status := http.StatusOK
+	if resp.Data == nil {
+		status = http.StatusBadRequest
+	}
+	c.JSON(status, resp)
+}
+
+// readGraphQLRequest reads a POSTed JSON request, or the query, operationName and variables
+// parameters of a GET
+func readGraphQLRequest(c *gin.Context) (graphql.Request, error) {
+	var req graphql.Request
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if v := c.Query("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				return req, err
+			}
+		}
+	} else {
+		if err := json.NewDecoder(http.MaxBytesReader(c.Writer, c.Request.Body, maxGraphQLRequestSize)).Decode(&req); err != nil {
+			return req, err
+		}
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		return req, errMissingQuery
+	}
+	return req, nil
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"errors"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/services"
+	"go-hospital-api/internal/utils"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// currentStaff resolves the calling staff member and their hospital from the JWT.
+// On failure it writes the error response and returns ok=false.
+func currentStaff(c *gin.Context, staffService services.StaffServiceInterface) (staffID uuid.UUID, hospitalID uuid.UUID, ok bool) {
+	sub, err := utils.VerifyTokenFromRequest(c.Request)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return uuid.Nil, uuid.Nil, false
+	}
+	hospitalID, err = staffService.GetHospitalIDByStaffID(sub)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return uuid.Nil, uuid.Nil, false
+	}
+	staffID, err = uuid.Parse(sub)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: "invalid staff ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return staffID, hospitalID, true
+}
+
+// staffRole returns the role of a staff member of the hospital. A staff member who cannot be
+// found has no role, which gives the least access.
+func staffRole(staffService services.StaffServiceInterface, staffID, hospitalID uuid.UUID) (string, error) {
+	staff, err := staffService.GetByID(staffID, hospitalID)
+	if err != nil && !errors.Is(err, services.ErrNotFound) {
+		return "", err
+	}
+	if staff == nil {
+		return "", nil
+	}
+	return staff.Role, nil
+}
+
+// parseUUIDParam reads a UUID path parameter, writing a 400 response when it is malformed.
+func parseUUIDParam(c *gin.Context, name string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param(name))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: "invalid " + name})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// setETag exposes a record version as a strong ETag, e.g. "3"
+func setETag(c *gin.Context, version int) {
+	c.Header("ETag", strconv.Quote(strconv.Itoa(version)))
+}
+
+// requireIfMatch reads the version the client last saw from If-Match. It writes 428 when the
+// header is missing and 400 when it is not a single version ETag.
+func requireIfMatch(c *gin.Context) (int, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		c.JSON(http.StatusPreconditionRequired, dto.ErrorResponse{Status: "error", Message: "If-Match header with the record's ETag is required"})
+		return 0, false
+	}
+	version, ok := parseETagVersion(header)
+	if !ok {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: "If-Match must be a single ETag returned by this API"})
+		return 0, false
+	}
+	return version, true
+}
+
+// parseETagVersion reads the version from a strong or weak ETag such as "3" or W/"3"
+func parseETagVersion(etag string) (int, bool) {
+	version, err := strconv.Atoi(strings.Trim(strings.TrimPrefix(strings.TrimSpace(etag), "W/"), `"`))
+	return version, err == nil && version > 0
+}
+
+// writeServiceError maps service sentinel errors onto HTTP status codes.
+func writeServiceError(c *gin.Context, err error) {
+	c.JSON(serviceErrorStatus(err), dto.ErrorResponse{Status: "error", Message: err.Error()})
+}
+
+func serviceErrorStatus(err error) int {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		status = http.StatusBadRequest
+	case errors.Is(err, services.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrConflict):
+		status = http.StatusConflict
+	case errors.Is(err, services.ErrForbidden):
+		status = http.StatusForbidden
+	case errors.Is(err, services.ErrUpstream):
+		status = http.StatusBadGateway
+	case errors.Is(err, services.ErrPreconditionFailed):
+		status = http.StatusPreconditionFailed
+	}
+	return status
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HL7Handler struct {
+	hl7Service   services.HL7ServiceInterface
+	staffService services.StaffServiceInterface
+}
+
+func NewHL7Handler(hl7Service services.HL7ServiceInterface, staffService services.StaffServiceInterface) *HL7Handler {
+	return &HL7Handler{
+		hl7Service:   hl7Service,
+		staffService: staffService,
+	}
+}
+
+// ListMessagesHandler lists the newest HL7 messages received over MLLP
+// @Summary List HL7 messages
+// @Description Supervisors only. Returns the newest 200 messages of the message log
+// @Tags hl7
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "received, processed or failed"
+// @Success 200 {object} []dto.HL7MessageResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /hl7/messages [get]
+func (h *HL7Handler) ListMessagesHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	messages, err := h.hl7Service.List(c.Request.Context(), hospitalID, staffID, c.Query("status"))
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	resp := make([]dto.HL7MessageResponse, len(messages))
+	for i, m := range messages {
+		resp[i] = toHL7MessageResponse(m)
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
+}
+
+// ReplayMessageHandler processes a message that was not accepted again
+// @Summary Replay an HL7 message
+// @Description Supervisors only. Changes are recorded on behalf of the caller; the sender is not sent a second acknowledgment
+// @Tags hl7
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Message ID"
+// @Success 200 {object} dto.HL7MessageResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /hl7/messages/{id}/replay [post]
+func (h *HL7Handler) ReplayMessageHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	message, err := h.hl7Service.Replay(c.Request.Context(), id, hospitalID, staffID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": toHL7MessageResponse(*message)})
+}
+
+func toHL7MessageResponse(m entities.HL7Message) dto.HL7MessageResponse {
+	return dto.HL7MessageResponse{
+		MessageID:          m.ID,
+		ControlID:          m.ControlID,
+		SendingApplication: m.SendingApplication,
+		SendingFacility:    m.SendingFacility,
+		MessageType:        m.MessageType,
+		PatientClass:       m.PatientClass,
+		Location:           m.Location,
+		VisitNumber:        m.VisitNumber,
+		Status:             m.Status,
+		AckCode:            m.AckCode,
+		Error:              m.Error,
+		PatientID:          m.PatientID,
+		Attempts:           m.Attempts,
+		ReceivedAt:         m.ReceivedAt,
+		ProcessedAt:        m.ProcessedAt,
+		Raw:                m.Raw,
+	}
+}
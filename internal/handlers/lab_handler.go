@@ -0,0 +1,357 @@
+package handlers
+
+import (
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/services"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LabHandler struct {
+	labService   services.LabServiceInterface
+	staffService services.StaffServiceInterface
+}
+
+func NewLabHandler(labService services.LabServiceInterface, staffService services.StaffServiceInterface) *LabHandler {
+	return &LabHandler{
+		labService:   labService,
+		staffService: staffService,
+	}
+}
+
+// CreateTestHandler adds a lab test to the caller's hospital catalogue
+// @Summary Create lab test
+// @Tags laboratory
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param test body dto.LabTestCreateRequest true "Lab test"
+// @Success 201 {object} dto.LabTestResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /lab/tests [post]
+func (h *LabHandler) CreateTestHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	var req dto.LabTestCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	test := entities.LabTest{
+		HospitalID:   hospitalID,
+		Code:         req.Code,
+		Name:         req.Name,
+		SpecimenType: req.SpecimenType,
+		ValueType:    req.ValueType,
+		Unit:         req.Unit,
+		RefLow:       req.RefLow,
+		RefHigh:      req.RefHigh,
+		RefText:      req.RefText,
+	}
+	if err := h.labService.CreateTest(c.Request.Context(), &test); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": toLabTestResponse(test)})
+}
+
+// SearchTestsHandler lists the caller's hospital lab test catalogue
+// @Summary Search lab tests
+// @Tags laboratory
+// @Produce json
+// @Security BearerAuth
+// @Param q query string false "Code prefix or name"
+// @Success 200 {object} []dto.LabTestResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /lab/tests [get]
+func (h *LabHandler) SearchTestsHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	tests, err := h.labService.SearchTests(c.Request.Context(), hospitalID, c.Query("q"))
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	resp := make([]dto.LabTestResponse, len(tests))
+	for i, t := range tests {
+		resp[i] = toLabTestResponse(t)
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
+}
+
+// CreateOrderHandler orders lab tests for a patient (doctors only)
+// @Summary Create lab order
+// @Tags laboratory
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Param order body dto.LabOrderCreateRequest true "Lab order"
+// @Success 201 {object} dto.LabOrderResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id}/lab-orders [post]
+func (h *LabHandler) CreateOrderHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	patientID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req dto.LabOrderCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	order := entities.LabOrder{
+		PatientID:    patientID,
+		HospitalID:   hospitalID,
+		OrderedByID:  staffID,
+		Priority:     req.Priority,
+		ClinicalNote: req.ClinicalNote,
+	}
+	if err := h.labService.CreateOrder(c.Request.Context(), &order, req.TestIDs); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	setETag(c, order.Version)
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": toLabOrderResponse(order)})
+}
+
+// ListOrdersHandler lists a patient's lab orders with their current results
+// @Summary List lab orders
+// @Tags laboratory
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Success 200 {object} []dto.LabOrderResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id}/lab-orders [get]
+func (h *LabHandler) ListOrdersHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	patientID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	orders, err := h.labService.ListOrders(c.Request.Context(), patientID, hospitalID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	resp := make([]dto.LabOrderResponse, len(orders))
+	for i, o := range orders {
+		resp[i] = toLabOrderResponse(o)
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
+}
+
+// GetOrderHandler returns a lab order with the full result history of each test
+// @Summary Get lab order
+// @Tags laboratory
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Lab order ID"
+// @Success 200 {object} dto.LabOrderResponse
+// @Header 200 {string} ETag "Current version"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /lab-orders/{id} [get]
+func (h *LabHandler) GetOrderHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	order, err := h.labService.GetOrder(c.Request.Context(), id, hospitalID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	setETag(c, order.Version)
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": toLabOrderResponse(*order)})
+}
+
+// UpdateSpecimenHandler records specimen collection, receipt, rejection or cancellation
+// @Summary Update specimen status
+// @Tags laboratory
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Lab order ID"
+// @Param If-Match header string true "ETag of the version being changed"
+// @Param specimen body dto.LabSpecimenRequest true "collected, received, rejected or cancelled"
+// @Success 200 {object} dto.LabOrderResponse
+// @Header 200 {string} ETag "New version"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Failure 428 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /lab-orders/{id}/specimen [patch]
+func (h *LabHandler) UpdateSpecimenHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	version, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+	var req dto.LabSpecimenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	order, err := h.labService.UpdateSpecimen(c.Request.Context(), id, hospitalID, staffID, req.Status, req.Reason, version)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	setETag(c, order.Version)
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": toLabOrderResponse(*order)})
+}
+
+// EnterResultHandler records a preliminary, final or amended result for one test of an order
+// @Summary Enter lab result
+// @Description Each entry adds a new version; earlier versions are kept and returned as history
+// @Tags laboratory
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Lab order ID"
+// @Param itemId path string true "Lab order item ID"
+// @Param result body dto.LabResultRequest true "Result"
+// @Success 201 {object} dto.LabResultResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /lab-orders/{id}/items/{itemId}/results [post]
+func (h *LabHandler) EnterResultHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	orderID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	itemID, ok := parseUUIDParam(c, "itemId")
+	if !ok {
+		return
+	}
+	var req dto.LabResultRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	result := entities.LabResult{
+		Status:       req.Status,
+		ValueNumeric: req.ValueNumeric,
+		ValueText:    req.ValueText,
+		Unit:         req.Unit,
+		AbnormalFlag: req.AbnormalFlag,
+		Comment:      req.Comment,
+		AmendReason:  req.AmendReason,
+		EnteredByID:  staffID,
+	}
+	if err := h.labService.EnterResult(c.Request.Context(), orderID, itemID, hospitalID, &result); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": toLabResultResponse(result)})
+}
+
+func toLabTestResponse(t entities.LabTest) dto.LabTestResponse {
+	return dto.LabTestResponse{
+		LabTestID:    t.ID,
+		Code:         t.Code,
+		Name:         t.Name,
+		SpecimenType: t.SpecimenType,
+		ValueType:    t.ValueType,
+		Unit:         t.Unit,
+		RefLow:       t.RefLow,
+		RefHigh:      t.RefHigh,
+		RefText:      t.RefText,
+	}
+}
+
+func toLabResultResponse(r entities.LabResult) dto.LabResultResponse {
+	return dto.LabResultResponse{
+		LabResultID:  r.ID,
+		Version:      r.Version,
+		Status:       r.Status,
+		ValueNumeric: r.ValueNumeric,
+		ValueText:    r.ValueText,
+		Unit:         r.Unit,
+		RefLow:       r.RefLow,
+		RefHigh:      r.RefHigh,
+		RefText:      r.RefText,
+		AbnormalFlag: r.AbnormalFlag,
+		Comment:      r.Comment,
+		AmendReason:  r.AmendReason,
+		EnteredByID:  r.EnteredByID,
+		CreatedAt:    r.CreatedAt,
+	}
+}
+
+func toLabOrderResponse(o entities.LabOrder) dto.LabOrderResponse {
+	items := make([]dto.LabOrderItemResponse, len(o.Items))
+	for i, item := range o.Items {
+		history := make([]dto.LabResultResponse, len(item.Results))
+		for j, r := range item.Results {
+			history[j] = toLabResultResponse(r)
+		}
+		items[i] = dto.LabOrderItemResponse{ItemID: item.ID, Test: toLabTestResponse(item.LabTest), History: history}
+		if n := len(history); n > 0 {
+			items[i].Result = &history[n-1]
+		}
+	}
+	return dto.LabOrderResponse{
+		LabOrderID:    o.ID,
+		PatientID:     o.PatientID,
+		OrderedByID:   o.OrderedByID,
+		Priority:      o.Priority,
+		ClinicalNote:  o.ClinicalNote,
+		Status:        o.Status,
+		StatusReason:  o.StatusReason,
+		SpecimenLabel: o.SpecimenLabel,
+		CollectedAt:   o.CollectedAt,
+		ReceivedAt:    o.ReceivedAt,
+		Items:         items,
+		Version:       o.Version,
+		CreatedAt:     o.CreatedAt,
+		UpdatedAt:     o.UpdatedAt,
+	}
+}
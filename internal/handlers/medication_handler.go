@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"errors"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MedicationHandler struct {
+	medicationService services.MedicationServiceInterface
+	staffService      services.StaffServiceInterface
+}
+
+func NewMedicationHandler(medicationService services.MedicationServiceInterface, staffService services.StaffServiceInterface) *MedicationHandler {
+	return &MedicationHandler{
+		medicationService: medicationService,
+		staffService:      staffService,
+	}
+}
+
+// ImportFormularyHandler imports the caller's hospital drug formulary from a CSV file
+// @Summary Import drug formulary
+// @Description Upload a CSV with columns code, name, generic_name and optionally drug_class, strength, form, route, active
+// @Tags medications
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "Formulary CSV"
+// @Success 200 {object} dto.FormularyImportResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /drugs/import [post]
+func (h *MedicationHandler) ImportFormularyHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: "file is required"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	defer file.Close()
+
+	n, err := h.medicationService.ImportFormulary(c.Request.Context(), hospitalID, file)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": dto.FormularyImportResponse{Imported: n}})
+}
+
+// SearchDrugsHandler searches the caller's hospital formulary
+// @Summary Search drugs
+// @Tags medications
+// @Produce json
+// @Security BearerAuth
+// @Param q query string false "Code, brand or generic name"
+// @Param limit query int false "Maximum results (default 20, max 100)"
+// @Success 200 {object} []dto.DrugResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /drugs [get]
+func (h *MedicationHandler) SearchDrugsHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	drugs, err := h.medicationService.SearchDrugs(c.Request.Context(), hospitalID, c.Query("q"), limit)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	resp := make([]dto.DrugResponse, len(drugs))
+	for i, d := range drugs {
+		resp[i] = toDrugResponse(d)
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
+}
+
+// CreateAllergyHandler records a drug allergy for a patient
+// @Summary Record drug allergy
+// @Tags medications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Param allergy body dto.AllergyCreateRequest true "Allergy"
+// @Success 201 {object} dto.AllergyResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id}/allergies [post]
+func (h *MedicationHandler) CreateAllergyHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	patientID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req dto.AllergyCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	allergy := entities.PatientAllergy{
+		PatientID:    patientID,
+		HospitalID:   hospitalID,
+		Substance:    req.Substance,
+		Reaction:     req.Reaction,
+		Severity:     req.Severity,
+		RecordedByID: staffID,
+	}
+	if err := h.medicationService.AddAllergy(c.Request.Context(), &allergy); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": toAllergyResponse(allergy)})
+}
+
+// ListAllergiesHandler lists a patient's drug allergies
+// @Summary List drug allergies
+// @Tags medications
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Success 200 {object} []dto.AllergyResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id}/allergies [get]
+func (h *MedicationHandler) ListAllergiesHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	patientID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	allergies, err := h.medicationService.ListAllergies(c.Request.Context(), patientID, hospitalID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	resp := make([]dto.AllergyResponse, len(allergies))
+	for i, a := range allergies {
+		resp[i] = toAllergyResponse(a)
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
+}
+
+// CreatePrescriptionHandler orders a medication after allergy and duplicate-therapy checks
+// @Summary Prescribe medication
+// @Description Blocking check results reject the order with 409; warnings are returned with the created order
+// @Tags medications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Param prescription body dto.PrescriptionCreateRequest true "Prescription"
+// @Success 201 {object} dto.PrescriptionResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.PrescriptionBlockedResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id}/prescriptions [post]
+func (h *MedicationHandler) CreatePrescriptionHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	patientID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req dto.PrescriptionCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+
+	prescription := entities.Prescription{
+		PatientID:    patientID,
+		HospitalID:   hospitalID,
+		PrescriberID: staffID,
+		DrugID:       req.DrugID,
+		Dose:         req.Dose,
+		Route:        req.Route,
+		Frequency:    req.Frequency,
+		DurationDays: req.DurationDays,
+		Note:         req.Note,
+	}
+	checks, err := h.medicationService.Prescribe(c.Request.Context(), &prescription)
+	if errors.Is(err, services.ErrPrescriptionBlocked) {
+		c.JSON(http.StatusConflict, dto.PrescriptionBlockedResponse{Status: "error", Message: err.Error(), Checks: toCheckResponses(checks)})
+		return
+	}
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	resp := toPrescriptionResponse(prescription)
+	resp.Checks = toCheckResponses(checks)
+	setETag(c, prescription.Version)
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": resp})
+}
+
+// ListPrescriptionsHandler lists a patient's prescriptions
+// @Summary List prescriptions
+// @Tags medications
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Param status query string false "ordered, dispensed or cancelled"
+// @Success 200 {object} []dto.PrescriptionResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id}/prescriptions [get]
+func (h *MedicationHandler) ListPrescriptionsHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	patientID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	prescriptions, err := h.medicationService.ListPrescriptions(c.Request.Context(), patientID, hospitalID, c.Query("status"))
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	resp := make([]dto.PrescriptionResponse, len(prescriptions))
+	for i, p := range prescriptions {
+		resp[i] = toPrescriptionResponse(p)
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
+}
+
+// UpdatePrescriptionStatusHandler dispenses or cancels a prescription
+// @Summary Update prescription status
+// @Tags medications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Prescription ID"
+// @Param If-Match header string true "ETag of the version being changed"
+// @Param status body dto.PrescriptionStatusRequest true "New status"
+// @Success 200 {object} dto.PrescriptionResponse
+// @Header 200 {string} ETag "New version"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Failure 428 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /prescriptions/{id}/status [patch]
+func (h *MedicationHandler) UpdatePrescriptionStatusHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	version, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+	var req dto.PrescriptionStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	p, err := h.medicationService.UpdateStatus(c.Request.Context(), id, hospitalID, req.Status, req.Reason, version)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	setETag(c, p.Version)
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": toPrescriptionResponse(*p)})
+}
+
+func toDrugResponse(d entities.Drug) dto.DrugResponse {
+	return dto.DrugResponse{
+		DrugID:      d.ID,
+		Code:        d.Code,
+		Name:        d.Name,
+		GenericName: d.GenericName,
+		DrugClass:   d.DrugClass,
+		Strength:    d.Strength,
+		Form:        d.Form,
+		Route:       d.Route,
+	}
+}
+
+func toAllergyResponse(a entities.PatientAllergy) dto.AllergyResponse {
+	return dto.AllergyResponse{
+		AllergyID:    a.ID,
+		PatientID:    a.PatientID,
+		Substance:    a.Substance,
+		Reaction:     a.Reaction,
+		Severity:     a.Severity,
+		RecordedByID: a.RecordedByID,
+		CreatedAt:    a.CreatedAt,
+	}
+}
+
+func toPrescriptionResponse(p entities.Prescription) dto.PrescriptionResponse {
+	return dto.PrescriptionResponse{
+		PrescriptionID: p.ID,
+		PatientID:      p.PatientID,
+		PrescriberID:   p.PrescriberID,
+		Drug:           toDrugResponse(p.Drug),
+		Dose:           p.Dose,
+		Route:          p.Route,
+		Frequency:      p.Frequency,
+		DurationDays:   p.DurationDays,
+		Status:         p.Status,
+		Note:           p.Note,
+		CancelReason:   p.CancelReason,
+		DispensedAt:    p.DispensedAt,
+		CancelledAt:    p.CancelledAt,
+		Version:        p.Version,
+		CreatedAt:      p.CreatedAt,
+		UpdatedAt:      p.UpdatedAt,
+	}
+}
+
+func toCheckResponses(checks []services.MedicationCheck) []dto.MedicationCheckResponse {
+	resp := make([]dto.MedicationCheckResponse, len(checks))
+	for i, c := range checks {
+		resp[i] = dto.MedicationCheckResponse{Type: c.Type, Severity: c.Severity, Message: c.Message}
+	}
+	return resp
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/services"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type MPIHandler struct {
+	mpiService   services.MPIServiceInterface
+	staffService services.StaffServiceInterface
+}
+
+func NewMPIHandler(mpiService services.MPIServiceInterface, staffService services.StaffServiceInterface) *MPIHandler {
+	return &MPIHandler{
+		mpiService:   mpiService,
+		staffService: staffService,
+	}
+}
+
+// IndexHandler links a patient into the master patient index
+// @Summary Index patient in MPI
+// @Description Links on equal national ID/passport or a high probabilistic score, queues uncertain matches for review, otherwise assigns a new enterprise ID
+// @Tags mpi
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Success 200 {object} dto.EnterpriseLinkResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id}/enterprise-link [post]
+func (h *MPIHandler) IndexHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	patientID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	link, queued, err := h.mpiService.Index(c.Request.Context(), patientID, hospitalID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": dto.EnterpriseLinkResponse{
+		EnterpriseID:    link.EnterpriseID,
+		PatientID:       link.PatientID,
+		Method:          link.Method,
+		Score:           link.Score,
+		QueuedForReview: queued,
+	}})
+}
+
+// LookupHandler returns the patient's enterprise ID and linked records
+// @Summary Enterprise lookup
+// @Description Records of other hospitals are listed only when the patient consented to data sharing there; the rest are only counted
+// @Tags mpi
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Success 200 {object} dto.EnterpriseLookupResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id}/enterprise [get]
+func (h *MPIHandler) LookupHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	patientID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	link, records, err := h.mpiService.Lookup(c.Request.Context(), patientID, hospitalID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	resp := dto.EnterpriseLookupResponse{EnterpriseID: link.EnterpriseID, Records: []dto.EnterpriseRecordResponse{}}
+	for _, r := range records {
+		if !r.Visible {
+			resp.RestrictedRecords++
+			continue
+		}
+		resp.Records = append(resp.Records, dto.EnterpriseRecordResponse{
+			PatientID:    r.Link.PatientID,
+			HospitalID:   r.Link.HospitalID,
+			HospitalName: r.Link.Patient.Hospital.Name,
+			PatientHN:    r.Link.Patient.PatientHN,
+			Method:       r.Link.Method,
+			Score:        r.Link.Score,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
+}
+
+// ReviewQueueHandler lists uncertain matches involving the caller's hospital
+// @Summary MPI review queue
+// @Tags mpi
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "pending (default), linked or rejected"
+// @Success 200 {object} []dto.MatchCandidateResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /mpi/review-queue [get]
+func (h *MPIHandler) ReviewQueueHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	candidates, err := h.mpiService.ReviewQueue(c.Request.Context(), hospitalID, c.Query("status"))
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	resp := make([]dto.MatchCandidateResponse, len(candidates))
+	for i, m := range candidates {
+		resp[i] = toMatchCandidateResponse(m, hospitalID)
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
+}
+
+// LinkCandidateHandler confirms a queued match and merges both records' enterprise IDs
+// @Summary Confirm MPI match
+// @Tags mpi
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Candidate ID"
+// @Success 200 {object} dto.MatchCandidateResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /mpi/review-queue/{id}/link [post]
+func (h *MPIHandler) LinkCandidateHandler(c *gin.Context) {
+	h.review(c, true)
+}
+
+// RejectCandidateHandler marks a queued match as different people
+// @Summary Reject MPI match
+// @Tags mpi
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Candidate ID"
+// @Success 200 {object} dto.MatchCandidateResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /mpi/review-queue/{id}/reject [post]
+func (h *MPIHandler) RejectCandidateHandler(c *gin.Context) {
+	h.review(c, false)
+}
+
+func (h *MPIHandler) review(c *gin.Context, link bool) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	candidate, err := h.mpiService.Review(c.Request.Context(), id, hospitalID, staffID, link)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": toMatchCandidateResponse(*candidate, hospitalID)})
+}
+
+func toMatchSide(p entities.Patient, hospitalID uuid.UUID) dto.MatchSideResponse {
+	side := dto.MatchSideResponse{HospitalID: p.HospitalID, HospitalName: p.Hospital.Name}
+	if p.HospitalID == hospitalID {
+		id := p.ID
+		side.PatientID = &id
+		side.PatientHN = p.PatientHN
+		side.PatientName = strings.TrimSpace(p.FirstNameTH + " " + p.LastNameTH)
+	}
+	return side
+}
+
+func toMatchCandidateResponse(m entities.MatchCandidate, hospitalID uuid.UUID) dto.MatchCandidateResponse {
+	return dto.MatchCandidateResponse{
+		CandidateID:  m.ID,
+		Score:        m.Score,
+		Reasons:      m.Reasons,
+		Status:       m.Status,
+		Patient:      toMatchSide(m.Patient, hospitalID),
+		Candidate:    toMatchSide(m.CandidatePatient, hospitalID),
+		ReviewedByID: m.ReviewedByID,
+		ReviewedAt:   m.ReviewedAt,
+		CreatedAt:    m.CreatedAt,
+	}
+}
@@ -2,34 +2,79 @@ package handlers
 
 import (
 	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/middleware"
 	"go-hospital-api/internal/services"
 	"go-hospital-api/internal/utils"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type PatientHandler struct {
 	patientService services.PatientServiceInterface
+	exportService  services.PatientExportServiceInterface
 	staffService   services.StaffServiceInterface
 }
 
-func NewPatientHandler(patientService services.PatientServiceInterface, staffService services.StaffServiceInterface) *PatientHandler {
+func NewPatientHandler(patientService services.PatientServiceInterface, exportService services.PatientExportServiceInterface, staffService services.StaffServiceInterface) *PatientHandler {
 	return &PatientHandler{
 		patientService: patientService,
+		exportService:  exportService,
 		staffService:   staffService,
 	}
 }
 
+// exportMediaTypes are the Accept header values that ask for an export instead of JSON
+var exportMediaTypes = map[string]string{
+	"text/csv": services.ExportCSV,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": services.ExportXLSX,
+	"application/x-ndjson": services.ExportNDJSON,
+	"application/ndjson":   services.ExportNDJSON,
+}
+
+// exportFormat returns the export format asked for by the format query parameter or else the
+// Accept header, or "" for the usual JSON response
+func exportFormat(c *gin.Context) string {
+	if f := strings.ToLower(c.Query("format")); f != "" {
+		if f == "json" {
+			return ""
+		}
+		return f
+	}
+	for _, part := range strings.Split(c.GetHeader("Accept"), ",") {
+		mediaType, _, _ := strings.Cut(part, ";")
+		if f, ok := exportMediaTypes[strings.ToLower(strings.TrimSpace(mediaType))]; ok {
+			return f
+		}
+	}
+	return ""
+}
+
 // SearchHandler for patient search with hospital restriction
+// @Description Returns JSON unless the format parameter or the Accept header (text/csv, application/vnd.openxmlformats-officedocument.spreadsheetml.sheet or application/x-ndjson) asks for an export.
+// @Description Exports are streamed as a download and audited. Doctors, nurses and supervisors export every column in clear; lab technicians and pharmacists get national ID, passport ID, phone and email masked; other staff get phone and email masked and cannot export national or passport IDs.
+// @Description JSON results follow the same rules: masked fields are masked and fields the role cannot export are empty.
 // @Tags patients
 // @Accept json
 // @Produce json
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Produce application/x-ndjson
 // @Security BearerAuth
 // @Param search body dto.PatientSearchCriteria  true "Search Criteria"
+// @Param format query string false "Export format" Enums(json, csv, xlsx, ndjson)
+// @Param columns query string false "Comma-separated export columns, e.g. patient_hn,first_name_th,last_name_th (default: every column the caller may export)"
 // @Success 200 {object} []dto.PatientResponse
+// @Header 200 {string} X-Total-Count "Exports only: number of patients matched when the export started"
 // @Failure 400 {object} dto.ErrorResponse
 // @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
 // @Failure 500 {object} dto.ErrorResponse
 // @Router /patients/search [post]
 func (h *PatientHandler) SearchHandler(c *gin.Context) {
@@ -49,29 +94,223 @@ func (h *PatientHandler) SearchHandler(c *gin.Context) {
 		return
 	}
 	req.HospitalID = hospitalID
+	if format := exportFormat(c); format != "" {
+		h.export(c, req, staffID, format)
+		return
+	}
 	patients, err := h.patientService.Search(c.Request.Context(), req)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Status: "error", Message: err.Error()})
 		return
 	}
+	id, err := uuid.Parse(staffID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: "invalid staff ID"})
+		return
+	}
+	role, err := staffRole(h.staffService, id, hospitalID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	now := time.Now()
 	resp := make([]dto.PatientResponse, len(patients))
 	for i, p := range patients {
-		resp[i] = dto.PatientResponse{
-			PatientID:    p.ID,
-			FirstNameTh:  p.FirstNameTH,
-			MiddleNameTh: p.MiddleNameTH,
-			LastNameTh:   p.LastNameTH,
-			DateOfBirth:  *p.DateOfBirth,
-			PatientHN:    p.PatientHN,
-			NationalID:   p.NationalID,
-			PassportID:   p.PassportID,
-			PhoneNumber:  p.PhoneNumber,
-			Email:        p.Email,
-			Gender:       p.Gender,
-			HospitalID:   p.HospitalID,
-			CreatedAt:    p.CreatedAt,
-			UpdatedAt:    p.UpdatedAt,
+		resp[i] = toPatientResponse(services.MaskPatient(p, role), now)
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
+}
+
+// export streams the search results as a file download. Once the first byte is sent the status
+// can no longer change, so a failure part way through ends the download early and is logged;
+// clients can tell from X-Total-Count that rows are missing.
+func (h *PatientHandler) export(c *gin.Context, criteria dto.PatientSearchCriteria, staffID string, format string) {
+	id, err := uuid.Parse(staffID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: "invalid staff ID"})
+		return
+	}
+	var columns []string
+	if v := c.Query("columns"); v != "" {
+		columns = strings.Split(v, ",")
+	}
+	export, err := h.exportService.Export(c.Request.Context(), criteria, id, format, columns)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	// An export is streamed and can be far too large to keep for an Idempotency-Key retry
+	middleware.SkipIdempotency(c)
+	c.Header("Content-Type", export.ContentType())
+	c.Header("Content-Disposition", `attachment; filename="patients-`+time.Now().Format("20060102-150405")+"."+export.Format+`"`)
+	c.Header("X-Total-Count", strconv.FormatInt(export.Rows, 10))
+	c.Status(http.StatusOK)
+	if n, err := export.Write(c.Request.Context(), c.Writer); err != nil {
+		log.Printf("patient export by staff %s stopped after %d of %d patients: %v", staffID, n, export.Rows, err)
+	}
+}
+
+// GetHandler returns a patient of the caller's hospital, optionally as it was at a past time
+// @Summary Get patient
+// @Tags patients
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Param as_of query string false "RFC 3339 timestamp, e.g. 2026-01-31T09:00:00+07:00"
+// @Success 200 {object} dto.PatientResponse
+// @Header 200 {string} ETag "Current version, to send as If-Match when changing the patient (not set with as_of)"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id} [get]
+func (h *PatientHandler) GetHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var asOf *time.Time
+	if v := c.Query("as_of"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: "as_of must be an RFC 3339 timestamp"})
+			return
+		}
+		asOf = &t
+	}
+	patient, err := h.patientService.Get(c.Request.Context(), id, hospitalID, asOf)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	at := time.Now()
+	if asOf != nil {
+		at = *asOf
+	} else {
+		setETag(c, patient.Version)
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": toPatientResponse(*patient, at)})
+}
+
+// UpdateHandler changes the fields present in the body; every change is kept in the patient's history
+// @Summary Update patient
+// @Tags patients
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Param If-Match header string true "ETag of the version being changed"
+// @Param patient body dto.PatientUpdateRequest true "Fields to change"
+// @Success 200 {object} dto.PatientResponse
+// @Header 200 {string} ETag "New version"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Failure 428 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id} [patch]
+func (h *PatientHandler) UpdateHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	version, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+	var req dto.PatientUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	patient, err := h.patientService.Update(c.Request.Context(), id, hospitalID, staffID, version, req)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	setETag(c, patient.Version)
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": toPatientResponse(*patient, time.Now())})
+}
+
+// HistoryHandler lists every version of a patient, newest first, with field-level changes
+// @Summary Patient change history
+// @Tags patients
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Success 200 {object} []dto.PatientVersionResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id}/history [get]
+func (h *PatientHandler) HistoryHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	history, err := h.patientService.History(c.Request.Context(), id, hospitalID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	resp := make([]dto.PatientVersionResponse, len(history))
+	for i, entry := range history {
+		changes := make([]dto.FieldChangeResponse, len(entry.Changes))
+		for j, ch := range entry.Changes {
+			changes[j] = dto.FieldChangeResponse{Field: ch.Field, From: ch.From, To: ch.To}
+		}
+		resp[i] = dto.PatientVersionResponse{
+			Version:     entry.Version.Version,
+			Operation:   entry.Version.Operation,
+			ChangedByID: entry.Version.ChangedByID,
+			ChangedAt:   entry.Version.ChangedAt,
+			Changes:     changes,
 		}
 	}
 	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
 }
+
+// toPatientResponse converts a patient with its coverages loaded; now picks the active coverage
+func toPatientResponse(p entities.Patient, now time.Time) dto.PatientResponse {
+	resp := dto.PatientResponse{
+		PatientID:    p.ID,
+		FirstNameTh:  p.FirstNameTH,
+		MiddleNameTh: p.MiddleNameTH,
+		LastNameTh:   p.LastNameTH,
+		FirstNameEn:  p.FirstNameEN,
+		MiddleNameEn: p.MiddleNameEN,
+		LastNameEn:   p.LastNameEN,
+		PatientHN:    p.PatientHN,
+		NationalID:   p.NationalID,
+		PassportID:   p.PassportID,
+		PhoneNumber:  p.PhoneNumber,
+		Email:        p.Email,
+		Gender:       p.Gender,
+		HospitalID:   p.HospitalID,
+		Version:      p.Version,
+		CreatedAt:    p.CreatedAt,
+		UpdatedAt:    p.UpdatedAt,
+	}
+	if p.DateOfBirth != nil {
+		resp.DateOfBirth = *p.DateOfBirth
+	}
+	if cov := services.ActiveCoverage(p.Coverages, now); cov != nil {
+		active := toCoverageResponse(*cov)
+		resp.ActiveCoverage = &active
+	}
+	return resp
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/services"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PatientImportHandler struct {
+	importService services.PatientImportServiceInterface
+	staffService  services.StaffServiceInterface
+}
+
+func NewPatientImportHandler(importService services.PatientImportServiceInterface, staffService services.StaffServiceInterface) *PatientImportHandler {
+	return &PatientImportHandler{
+		importService: importService,
+		staffService:  staffService,
+	}
+}
+
+// ImportPatientsHandler imports patients from an uploaded CSV or XLSX file
+// @Summary Import patients
+// @Description Supervisors only. The header row names the columns: patient_hn (required), first_name_th, middle_name_th, last_name_th, first_name_en, middle_name_en, last_name_en, date_of_birth, national_id, passport_id, phone_number, email and gender, or a common alias such as hn, dob or sex.
+// @Description Files of up to 1000 rows are imported before the response (200); larger files are imported in the background (202) and the response shows their progress.
+// @Tags patients
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file true "CSV or XLSX file, at most 50 MB"
+// @Param mapping formData string false "JSON object naming the patient field of other headers, e.g. {\"Citizen No\":\"national_id\"}"
+// @Param dry_run formData bool false "Validate every row without importing"
+// @Success 200 {object} dto.PatientImportResponse
+// @Success 202 {object} dto.PatientImportResponse
+// @Header 202 {string} Location "URL of the import"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 413 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/import [post]
+func (h *PatientImportHandler) ImportPatientsHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: "file is required"})
+		return
+	}
+	if fileHeader.Size > services.MaxImportFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, dto.ErrorResponse{Status: "error", Message: "file is larger than 50 MB"})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+
+	var mapping map[string]string
+	if m := c.PostForm("mapping"); m != "" {
+		if err := json.Unmarshal([]byte(m), &mapping); err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: "mapping must be a JSON object of header names to patient fields"})
+			return
+		}
+	}
+	dryRun := false
+	if v := c.PostForm("dry_run"); v != "" {
+		if dryRun, err = strconv.ParseBool(v); err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: "dry_run must be true or false"})
+			return
+		}
+	}
+
+	imp, err := h.importService.Start(c.Request.Context(), hospitalID, staffID, fileHeader.Filename, data, mapping, dryRun)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	resp := toPatientImportResponse(*imp)
+	if imp.Status == entities.ImportQueued || imp.Status == entities.ImportRunning {
+		c.Header("Location", "/api/patients/imports/"+imp.ID.String())
+		c.JSON(http.StatusAccepted, gin.H{"status": "success", "data": resp})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
+}
+
+// GetImportHandler returns the progress of a patient import
+// @Summary Patient import progress
+// @Description Supervisors only
+// @Tags patients
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Import ID"
+// @Success 200 {object} dto.PatientImportResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/imports/{id} [get]
+func (h *PatientImportHandler) GetImportHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	imp, err := h.importService.Get(c.Request.Context(), id, hospitalID, staffID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": toPatientImportResponse(*imp)})
+}
+
+// ImportErrorsHandler downloads the error report of a patient import
+// @Summary Patient import error report
+// @Description Supervisors only. CSV with the columns row, patient_hn, column, value and message; row counts the header as row 1. While an import runs, the report lists the problems found so far.
+// @Tags patients
+// @Produce text/csv
+// @Security BearerAuth
+// @Param id path string true "Import ID"
+// @Success 200 {string} string "CSV error report"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/imports/{id}/errors [get]
+func (h *PatientImportHandler) ImportErrorsHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	errs, err := h.importService.Errors(c.Request.Context(), id, hospitalID, staffID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	var buf bytes.Buffer
+	if err := services.WriteImportErrorReport(&buf, errs); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="import-`+id.String()+`-errors.csv"`)
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", buf.Bytes())
+}
+
+func toPatientImportResponse(imp entities.PatientImport) dto.PatientImportResponse {
+	percent := 100
+	if imp.TotalRows > 0 {
+		percent = imp.ProcessedRows * 100 / imp.TotalRows
+	}
+	return dto.PatientImportResponse{
+		ImportID:      imp.ID,
+		FileName:      imp.FileName,
+		DryRun:        imp.DryRun,
+		Status:        imp.Status,
+		TotalRows:     imp.TotalRows,
+		ProcessedRows: imp.ProcessedRows,
+		ImportedRows:  imp.ImportedRows,
+		FailedRows:    imp.FailedRows,
+		Percent:       percent,
+		Error:         imp.Error,
+		ErrorReport:   "/api/patients/imports/" + imp.ID.String() + "/errors",
+		CreatedAt:     imp.CreatedAt,
+		StartedAt:     imp.StartedAt,
+		FinishedAt:    imp.FinishedAt,
+	}
+}
@@ -0,0 +1,297 @@
+package handlers
+
+import (
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/services"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReferralHandler struct {
+	referralService services.ReferralServiceInterface
+	staffService    services.StaffServiceInterface
+}
+
+func NewReferralHandler(referralService services.ReferralServiceInterface, staffService services.StaffServiceInterface) *ReferralHandler {
+	return &ReferralHandler{
+		referralService: referralService,
+		staffService:    staffService,
+	}
+}
+
+// CreateHandler refers a patient of the caller's hospital to another hospital
+// @Summary Create referral
+// @Description Needs the patient's data_sharing consent unless the urgency is emergency
+// @Tags referrals
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param referral body dto.ReferralCreateRequest true "Referral (urgency: routine, urgent or emergency)"
+// @Success 201 {object} dto.ReferralResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /referrals [post]
+func (h *ReferralHandler) CreateHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	var req dto.ReferralCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	referral := entities.Referral{
+		PatientID:        req.PatientID,
+		SourceHospitalID: hospitalID,
+		TargetHospitalID: req.TargetHospitalID,
+		Reason:           req.Reason,
+		Urgency:          req.Urgency,
+		ClinicalSummary:  req.ClinicalSummary,
+		ReferredByID:     staffID,
+	}
+	if err := h.referralService.Create(c.Request.Context(), &referral); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": toReferralResponse(referral)})
+}
+
+// ListHandler lists referrals received or sent by the caller's hospital
+// @Summary List referrals
+// @Tags referrals
+// @Produce json
+// @Security BearerAuth
+// @Param direction query string false "incoming (default) or outgoing"
+// @Param status query string false "pending, accepted, rejected or cancelled"
+// @Success 200 {object} []dto.ReferralResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /referrals [get]
+func (h *ReferralHandler) ListHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	referrals, err := h.referralService.List(c.Request.Context(), hospitalID, c.Query("direction"), c.Query("status"))
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	resp := make([]dto.ReferralResponse, len(referrals))
+	for i, r := range referrals {
+		resp[i] = toReferralResponse(r)
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
+}
+
+// GetHandler returns a referral to its source or target hospital
+// @Summary Get referral
+// @Tags referrals
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Referral ID"
+// @Success 200 {object} dto.ReferralResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /referrals/{id} [get]
+func (h *ReferralHandler) GetHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	referral, err := h.referralService.Get(c.Request.Context(), id, hospitalID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": toReferralResponse(*referral)})
+}
+
+// AcceptHandler accepts a referral addressed to the caller's hospital
+// @Summary Accept referral
+// @Description Grants read access to the referred record for access_days (default 30, max 90); register_patient also creates a linked patient under patient_hn
+// @Tags referrals
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Referral ID"
+// @Param accept body dto.ReferralAcceptRequest false "Acceptance"
+// @Success 200 {object} dto.ReferralResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /referrals/{id}/accept [post]
+func (h *ReferralHandler) AcceptHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req dto.ReferralAcceptRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+			return
+		}
+	}
+	referral, err := h.referralService.Accept(c.Request.Context(), id, hospitalID, staffID, services.ReferralAcceptance{
+		Note:            req.Note,
+		AccessDays:      req.AccessDays,
+		RegisterPatient: req.RegisterPatient,
+		PatientHN:       req.PatientHN,
+	})
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": toReferralResponse(*referral)})
+}
+
+// RejectHandler rejects a referral addressed to the caller's hospital
+// @Summary Reject referral
+// @Tags referrals
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Referral ID"
+// @Param reject body dto.ReferralRejectRequest true "Reason for rejecting"
+// @Success 200 {object} dto.ReferralResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /referrals/{id}/reject [post]
+func (h *ReferralHandler) RejectHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req dto.ReferralRejectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	referral, err := h.referralService.Reject(c.Request.Context(), id, hospitalID, staffID, req.Note)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": toReferralResponse(*referral)})
+}
+
+// CancelHandler cancels a pending referral sent by the caller's hospital
+// @Summary Cancel referral
+// @Tags referrals
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Referral ID"
+// @Success 200 {object} dto.ReferralResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /referrals/{id}/cancel [post]
+func (h *ReferralHandler) CancelHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	referral, err := h.referralService.Cancel(c.Request.Context(), id, hospitalID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": toReferralResponse(*referral)})
+}
+
+// PatientHandler returns the referred patient record to the accepting hospital while its access lasts
+// @Summary Referred patient
+// @Tags referrals
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Referral ID"
+// @Success 200 {object} dto.ReferredPatientResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /referrals/{id}/patient [get]
+func (h *ReferralHandler) PatientHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	referral, err := h.referralService.ReferredPatient(c.Request.Context(), id, hospitalID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": dto.ReferredPatientResponse{
+		ReferralID:      referral.ID,
+		ClinicalSummary: referral.ClinicalSummary,
+		AccessExpiresAt: referral.AccessExpiresAt,
+		Patient:         toPatientResponse(referral.Patient, time.Now()),
+	}})
+}
+
+func toReferralResponse(r entities.Referral) dto.ReferralResponse {
+	return dto.ReferralResponse{
+		ReferralID:         r.ID,
+		PatientID:          r.PatientID,
+		PatientHN:          r.Patient.PatientHN,
+		PatientName:        strings.TrimSpace(r.Patient.FirstNameTH + " " + r.Patient.LastNameTH),
+		SourceHospitalID:   r.SourceHospitalID,
+		SourceHospitalName: r.SourceHospital.Name,
+		TargetHospitalID:   r.TargetHospitalID,
+		TargetHospitalName: r.TargetHospital.Name,
+		Reason:             r.Reason,
+		Urgency:            r.Urgency,
+		ClinicalSummary:    r.ClinicalSummary,
+		Status:             r.Status,
+		ReferredByID:       r.ReferredByID,
+		RespondedByID:      r.RespondedByID,
+		RespondedAt:        r.RespondedAt,
+		ResponseNote:       r.ResponseNote,
+		AccessExpiresAt:    r.AccessExpiresAt,
+		LinkedPatientID:    r.LinkedPatientID,
+		CreatedAt:          r.CreatedAt,
+	}
+}
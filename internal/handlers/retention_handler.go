@@ -0,0 +1,374 @@
+package handlers
+
+import (
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/services"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type RetentionHandler struct {
+	retentionService services.RetentionServiceInterface
+	staffService     services.StaffServiceInterface
+}
+
+func NewRetentionHandler(retentionService services.RetentionServiceInterface, staffService services.StaffServiceInterface) *RetentionHandler {
+	return &RetentionHandler{
+		retentionService: retentionService,
+		staffService:     staffService,
+	}
+}
+
+// DeletePatientHandler soft deletes a patient of the caller's hospital
+// @Summary Delete patient
+// @Description Supervisors only. The record is kept until the retention period has passed and can be restored until then
+// @Tags retention
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Param If-Match header string true "ETag of the version being deleted"
+// @Param delete body dto.PatientDeleteRequest false "Reason, kept in the audit log"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Failure 428 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id} [delete]
+func (h *RetentionHandler) DeletePatientHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	version, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+	var req dto.PatientDeleteRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+			return
+		}
+	}
+	if err := h.retentionService.DeletePatient(c.Request.Context(), id, hospitalID, staffID, version, req.Reason); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, dto.MessageResponse{Status: "success", Message: "patient deleted"})
+}
+
+// RestorePatientHandler restores a deleted patient that has not been purged yet
+// @Summary Restore patient
+// @Description Supervisors only
+// @Tags retention
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Success 200 {object} dto.PatientResponse
+// @Header 200 {string} ETag "New version"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id}/restore [post]
+func (h *RetentionHandler) RestorePatientHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	patient, err := h.retentionService.RestorePatient(c.Request.Context(), id, hospitalID, staffID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	setETag(c, patient.Version)
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": toPatientResponse(*patient, time.Now())})
+}
+
+// DeletedPatientsHandler lists deleted patients that can still be restored
+// @Summary List deleted patients
+// @Description Supervisors only
+// @Tags retention
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} []dto.DeletedPatientResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/deleted [get]
+func (h *RetentionHandler) DeletedPatientsHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	patients, err := h.retentionService.DeletedPatients(c.Request.Context(), hospitalID, staffID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	policy, err := h.retentionService.Policy(c.Request.Context(), hospitalID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	resp := make([]dto.DeletedPatientResponse, len(patients))
+	for i, p := range patients {
+		resp[i] = dto.DeletedPatientResponse{
+			PatientID:   p.ID,
+			PatientHN:   p.PatientHN,
+			PatientName: strings.TrimSpace(p.FirstNameTH + " " + p.LastNameTH),
+			DeletedAt:   p.DeletedAt.Time,
+			PurgeAfter:  p.DeletedAt.Time.AddDate(policy.RetentionYears, 0, 0),
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
+}
+
+// DeleteStaffHandler soft deletes a staff member of the caller's hospital
+// @Summary Delete staff
+// @Description Supervisors only. The staff member can no longer log in or use existing tokens
+// @Tags retention
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Staff ID"
+// @Param If-Match header string true "ETag of the version being deleted"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Failure 428 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /staff/{id} [delete]
+func (h *RetentionHandler) DeleteStaffHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	version, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+	if err := h.retentionService.DeleteStaff(c.Request.Context(), id, hospitalID, staffID, version); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, dto.MessageResponse{Status: "success", Message: "staff deleted"})
+}
+
+// RestoreStaffHandler restores a deleted staff member that has not been anonymised yet
+// @Summary Restore staff
+// @Description Supervisors only
+// @Tags retention
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Staff ID"
+// @Success 200 {object} dto.StaffResponse
+// @Header 200 {string} ETag "New version"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /staff/{id}/restore [post]
+func (h *RetentionHandler) RestoreStaffHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	staff, err := h.retentionService.RestoreStaff(c.Request.Context(), id, hospitalID, staffID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	setETag(c, staff.Version)
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": toStaffResponse(*staff)})
+}
+
+// DeleteHospitalHandler soft deletes the caller's hospital
+// @Summary Delete hospital
+// @Description Supervisors only, for their own hospital. The hospital can no longer be looked up or receive referrals; its staff and records are kept under the retention policy and it can be restored
+// @Tags retention
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Hospital ID"
+// @Param delete body dto.HospitalDeleteRequest false "Reason, kept in the audit log"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /hospitals/{id} [delete]
+func (h *RetentionHandler) DeleteHospitalHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req dto.HospitalDeleteRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+			return
+		}
+	}
+	if err := h.retentionService.DeleteHospital(c.Request.Context(), id, hospitalID, staffID, req.Reason); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, dto.MessageResponse{Status: "success", Message: "hospital deleted"})
+}
+
+// RestoreHospitalHandler restores the caller's deleted hospital
+// @Summary Restore hospital
+// @Description Supervisors only, for their own hospital
+// @Tags retention
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Hospital ID"
+// @Success 200 {object} dto.HospitalResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /hospitals/{id}/restore [post]
+func (h *RetentionHandler) RestoreHospitalHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	hospital, err := h.retentionService.RestoreHospital(c.Request.Context(), id, hospitalID, staffID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": dto.HospitalResponse{
+		HospitalID: hospital.ID,
+		Name:       hospital.Name,
+		CreatedAt:  hospital.CreatedAt,
+		UpdatedAt:  hospital.UpdatedAt,
+	}})
+}
+
+// GetPolicyHandler returns the retention policy of the caller's hospital
+// @Summary Get retention policy
+// @Tags retention
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} dto.RetentionPolicyResponse
+// @Header 200 {string} ETag "Current version, to send as If-Match when changing the policy"
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /retention-policy [get]
+func (h *RetentionHandler) GetPolicyHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	policy, err := h.retentionService.Policy(c.Request.Context(), hospitalID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	setETag(c, policy.Version)
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": toRetentionPolicyResponse(*policy)})
+}
+
+// SetPolicyHandler sets the retention policy of the caller's hospital
+// @Summary Set retention policy
+// @Description Supervisors only. retention_years is at least 5; action is anonymise (default) or delete
+// @Tags retention
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param If-Match header string true "ETag of the version being replaced; the default policy is version 1"
+// @Param policy body dto.RetentionPolicyRequest true "Retention policy"
+// @Success 200 {object} dto.RetentionPolicyResponse
+// @Header 200 {string} ETag "New version"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Failure 428 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /retention-policy [put]
+func (h *RetentionHandler) SetPolicyHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	version, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+	var req dto.RetentionPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	policy := entities.RetentionPolicy{
+		HospitalID:     hospitalID,
+		RetentionYears: req.RetentionYears,
+		Action:         strings.ToLower(strings.TrimSpace(req.Action)),
+		UpdatedByID:    staffID,
+		Version:        version,
+	}
+	if err := h.retentionService.SetPolicy(c.Request.Context(), &policy); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	setETag(c, policy.Version)
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": toRetentionPolicyResponse(policy)})
+}
+
+func toRetentionPolicyResponse(p entities.RetentionPolicy) dto.RetentionPolicyResponse {
+	resp := dto.RetentionPolicyResponse{
+		HospitalID:     p.HospitalID,
+		RetentionYears: p.RetentionYears,
+		Action:         p.Action,
+		Version:        p.Version,
+	}
+	// The default policy was never saved, so it has no author
+	if p.UpdatedByID != uuid.Nil {
+		resp.UpdatedByID = &p.UpdatedByID
+		resp.UpdatedAt = &p.UpdatedAt
+	}
+	return resp
+}
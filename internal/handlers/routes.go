@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"go-hospital-api/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handlers holds the handlers of the REST API, for RegisterRoutes
+type Handlers struct {
+	Staff           *StaffHandler
+	Patient         *PatientHandler
+	Diagnosis       *DiagnosisHandler
+	Medication      *MedicationHandler
+	Lab             *LabHandler
+	Ward            *WardHandler
+	Billing         *BillingHandler
+	Coverage        *CoverageHandler
+	Contact         *ContactHandler
+	Consent         *ConsentHandler
+	Referral        *ReferralHandler
+	MPI             *MPIHandler
+	EmergencyAccess *EmergencyAccessHandler
+	Retention       *RetentionHandler
+	FHIR            *FHIRHandler
+	HL7             *HL7Handler
+	PatientImport   *PatientImportHandler
+	Webhook         *WebhookHandler
+	GraphQL         *GraphQLHandler
+	EventStream     *EventStreamHandler
+}
+
+// RegisterRoutes mounts the REST API on api, which main serves at /api. idempotency is the
+// middleware.Idempotency handler; on authenticated routes it runs after AuthMiddleware.
+func RegisterRoutes(api *gin.RouterGroup, h Handlers, idempotency gin.HandlerFunc) {
+	// Public routes
+	api.POST("/staff/create", idempotency, h.Staff.CreateHandler)
+	api.POST("/staff/login", h.Staff.LoginHandler)
+	api.GET("/fhir/R4/metadata", h.FHIR.CapabilityHandler)
+
+	// Authenticated routes
+	auth := api.Group("")
+	auth.Use(middleware.AuthMiddleware(), idempotency)
+	auth.POST("/patients/search", h.Patient.SearchHandler)
+	auth.GET("/patients/:id", h.Patient.GetHandler)
+	auth.PATCH("/patients/:id", h.Patient.UpdateHandler)
+	auth.GET("/patients/:id/history", h.Patient.HistoryHandler)
+
+	// Diagnosis coding
+	auth.GET("/icd10/codes", h.Diagnosis.SearchCodesHandler)
+	auth.POST("/icd10/import", h.Diagnosis.ImportCodesHandler)
+	auth.POST("/patients/:id/diagnoses", h.Diagnosis.CreateHandler)
+	auth.GET("/patients/:id/diagnoses", h.Diagnosis.ListHandler)
+
+	// Medications
+	auth.POST("/drugs/import", h.Medication.ImportFormularyHandler)
+	auth.GET("/drugs", h.Medication.SearchDrugsHandler)
+	auth.POST("/patients/:id/allergies", h.Medication.CreateAllergyHandler)
+	auth.GET("/patients/:id/allergies", h.Medication.ListAllergiesHandler)
+	auth.POST("/patients/:id/prescriptions", h.Medication.CreatePrescriptionHandler)
+	auth.GET("/patients/:id/prescriptions", h.Medication.ListPrescriptionsHandler)
+	auth.PATCH("/prescriptions/:id/status", h.Medication.UpdatePrescriptionStatusHandler)
+
+	// Laboratory
+	auth.POST("/lab/tests", h.Lab.CreateTestHandler)
+	auth.GET("/lab/tests", h.Lab.SearchTestsHandler)
+	auth.POST("/patients/:id/lab-orders", h.Lab.CreateOrderHandler)
+	auth.GET("/patients/:id/lab-orders", h.Lab.ListOrdersHandler)
+	auth.GET("/lab-orders/:id", h.Lab.GetOrderHandler)
+	auth.PATCH("/lab-orders/:id/specimen", h.Lab.UpdateSpecimenHandler)
+	auth.POST("/lab-orders/:id/items/:itemId/results", h.Lab.EnterResultHandler)
+
+	// Wards, beds and admissions
+	auth.POST("/wards", h.Ward.CreateWardHandler)
+	auth.GET("/wards", h.Ward.ListWardsHandler)
+	auth.POST("/wards/:id/beds", h.Ward.CreateBedHandler)
+	auth.PATCH("/beds/:id/status", h.Ward.UpdateBedStatusHandler)
+	auth.POST("/patients/:id/admissions", h.Ward.AdmitHandler)
+	auth.POST("/admissions/:id/transfer", h.Ward.TransferHandler)
+	auth.POST("/admissions/:id/discharge", h.Ward.DischargeHandler)
+	auth.GET("/bed-board", h.Ward.BedBoardHandler)
+
+	// Billing
+	auth.POST("/price-items", h.Billing.CreatePriceItemHandler)
+	auth.GET("/price-items", h.Billing.ListPriceItemsHandler)
+	auth.POST("/patients/:id/charges", h.Billing.PostChargeHandler)
+	auth.GET("/patients/:id/charges", h.Billing.ListChargesHandler)
+	auth.POST("/charges/:id/void", h.Billing.VoidChargeHandler)
+	auth.POST("/patients/:id/invoices", h.Billing.CreateInvoiceHandler)
+	auth.GET("/invoices/:id", h.Billing.GetInvoiceHandler)
+	auth.GET("/invoices/:id/pdf", h.Billing.InvoicePDFHandler)
+	auth.POST("/invoices/:id/payments", h.Billing.AddPaymentHandler)
+	auth.GET("/patients/:id/balance", h.Billing.BalanceHandler)
+
+	// Coverage
+	auth.POST("/patients/:id/coverages", h.Coverage.AddHandler)
+	auth.GET("/patients/:id/coverages", h.Coverage.ListHandler)
+	auth.POST("/coverages/:id/verify", h.Coverage.VerifyHandler)
+
+	// Addresses and emergency contacts
+	auth.GET("/admin-areas", h.Contact.SearchAreasHandler)
+	auth.POST("/patients/:id/addresses", h.Contact.AddAddressHandler)
+	auth.GET("/patients/:id/addresses", h.Contact.ListAddressesHandler)
+	auth.PUT("/patients/:id/addresses/:addressId", h.Contact.UpdateAddressHandler)
+	auth.DELETE("/patients/:id/addresses/:addressId", h.Contact.DeleteAddressHandler)
+	auth.POST("/patients/:id/emergency-contacts", h.Contact.AddContactHandler)
+	auth.GET("/patients/:id/emergency-contacts", h.Contact.ListContactsHandler)
+	auth.PUT("/patients/:id/emergency-contacts/:contactId", h.Contact.UpdateContactHandler)
+	auth.DELETE("/patients/:id/emergency-contacts/:contactId", h.Contact.DeleteContactHandler)
+
+	// PDPA consent
+	auth.POST("/patients/:id/consents", h.Consent.GrantHandler)
+	auth.GET("/patients/:id/consents", h.Consent.ListHandler)
+	auth.GET("/patients/:id/consents/check", h.Consent.CheckHandler)
+	auth.POST("/consents/:id/withdraw", h.Consent.WithdrawHandler)
+
+	// Referrals
+	auth.POST("/referrals", h.Referral.CreateHandler)
+	auth.GET("/referrals", h.Referral.ListHandler)
+	auth.GET("/referrals/:id", h.Referral.GetHandler)
+	auth.POST("/referrals/:id/accept", h.Referral.AcceptHandler)
+	auth.POST("/referrals/:id/reject", h.Referral.RejectHandler)
+	auth.POST("/referrals/:id/cancel", h.Referral.CancelHandler)
+	auth.GET("/referrals/:id/patient", h.Referral.PatientHandler)
+
+	// Master patient index
+	auth.POST("/patients/:id/enterprise-link", h.MPI.IndexHandler)
+	auth.GET("/patients/:id/enterprise", h.MPI.LookupHandler)
+	auth.GET("/mpi/review-queue", h.MPI.ReviewQueueHandler)
+	auth.POST("/mpi/review-queue/:id/link", h.MPI.LinkCandidateHandler)
+	auth.POST("/mpi/review-queue/:id/reject", h.MPI.RejectCandidateHandler)
+
+	// Break-the-glass
+	auth.POST("/break-glass", h.EmergencyAccess.BreakGlassHandler)
+	auth.GET("/break-glass/reviews", h.EmergencyAccess.ReportHandler)
+	auth.GET("/break-glass/:id/patient", h.EmergencyAccess.PatientHandler)
+	auth.POST("/break-glass/:id/review", h.EmergencyAccess.ReviewHandler)
+	auth.GET("/audit-events", h.EmergencyAccess.AuditEventsHandler)
+
+	// Deletion and retention
+	auth.GET("/patients/deleted", h.Retention.DeletedPatientsHandler)
+	auth.DELETE("/patients/:id", h.Retention.DeletePatientHandler)
+	auth.POST("/patients/:id/restore", h.Retention.RestorePatientHandler)
+	auth.GET("/staff/:id", h.Staff.GetHandler)
+	auth.PUT("/staff/:id/role", h.Staff.SetRoleHandler)
+	auth.DELETE("/staff/:id", h.Retention.DeleteStaffHandler)
+	auth.POST("/staff/:id/restore", h.Retention.RestoreStaffHandler)
+	auth.DELETE("/hospitals/:id", h.Retention.DeleteHospitalHandler)
+	auth.POST("/hospitals/:id/restore", h.Retention.RestoreHospitalHandler)
+	auth.GET("/retention-policy", h.Retention.GetPolicyHandler)
+	auth.PUT("/retention-policy", h.Retention.SetPolicyHandler)
+
+	// FHIR R4
+	fhirR4 := auth.Group("/fhir/R4")
+	fhirR4.GET("/Patient", h.FHIR.SearchPatientHandler)
+	fhirR4.POST("/Patient", h.FHIR.CreatePatientHandler)
+	fhirR4.GET("/Patient/:id", h.FHIR.ReadPatientHandler)
+	fhirR4.PUT("/Patient/:id", h.FHIR.UpdatePatientHandler)
+	fhirR4.GET("/Practitioner/:id", h.FHIR.ReadPractitionerHandler)
+	fhirR4.GET("/PractitionerRole/:id", h.FHIR.ReadPractitionerRoleHandler)
+
+	// Patient import
+	auth.POST("/patients/import", h.PatientImport.ImportPatientsHandler)
+	auth.GET("/patients/imports/:id", h.PatientImport.GetImportHandler)
+	auth.GET("/patients/imports/:id/errors", h.PatientImport.ImportErrorsHandler)
+
+	// HL7 v2 interface
+	auth.GET("/hl7/messages", h.HL7.ListMessagesHandler)
+	auth.POST("/hl7/messages/:id/replay", h.HL7.ReplayMessageHandler)
+
+	// Webhooks
+	auth.POST("/webhooks", h.Webhook.CreateSubscriptionHandler)
+	auth.GET("/webhooks", h.Webhook.ListSubscriptionsHandler)
+	auth.DELETE("/webhooks/:id", h.Webhook.DeleteSubscriptionHandler)
+	auth.GET("/webhooks/deliveries", h.Webhook.DeliveriesHandler)
+	auth.POST("/webhooks/deliveries/:id/retry", h.Webhook.RetryDeliveryHandler)
+
+	// GraphQL
+	auth.POST("/graphql", h.GraphQL.QueryHandler)
+	auth.GET("/graphql", h.GraphQL.QueryHandler)
+
+	// Event stream
+	auth.GET("/events/stream", h.EventStream.StreamHandler)
+}
@@ -24,7 +24,7 @@ func NewStaffHandler(service services.StaffServiceInterface) *StaffHandler {
 
 // CreateHandler creates new staff
 // @Summary Create staff
-// @Description Create a new staff
+// @Description Create a new staff member with the staff role. Any other role is a 400; a supervisor assigns it with PUT /staff/{id}/role.
 // @Tags staff
 // @Accept json
 // @Produce json
@@ -43,25 +43,18 @@ func (h *StaffHandler) CreateHandler(c *gin.Context) {
 	staff := entities.Staff{
 		ID:         uuid.New(),
 		Username:   req.Username,
+		Role:       req.Role,
 		HospitalID: req.HospitalID,
 		CreatedAt:  time.Now(),
 		UpdatedAt:  time.Now(),
 	}
 
 	if err := h.service.Create(&staff, req.Password); err != nil {
-		c.JSON(http.StatusInternalServerError, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		writeServiceError(c, err)
 		return
 	}
 
-	resp := dto.StaffResponse{
-		ID:         staff.ID,
-		Username:   staff.Username,
-		HospitalID: staff.HospitalID,
-		CreatedAt:  staff.CreatedAt,
-		UpdatedAt:  staff.UpdatedAt,
-	}
-
-	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": toStaffResponse(staff)})
 }
 
 // LoginHandler authenticates staff and returns JWT token
@@ -104,3 +97,93 @@ func (h *StaffHandler) LoginHandler(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"status": "success", "data": dto.StaffLoginResponse{Token: token}})
 }
+
+// GetHandler returns a staff member of the caller's hospital
+// @Summary Get staff
+// @Tags staff
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Staff ID"
+// @Success 200 {object} dto.StaffResponse
+// @Header 200 {string} ETag "Current version, to send as If-Match when deleting the staff member"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /staff/{id} [get]
+func (h *StaffHandler) GetHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.service)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	staff, err := h.service.GetByID(id, hospitalID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	setETag(c, staff.Version)
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": toStaffResponse(*staff)})
+}
+
+// SetRoleHandler changes the role of a staff member of the caller's hospital
+// @Summary Assign staff role
+// @Description Supervisors only, and not for their own account. Roles: staff, doctor, nurse, lab_technician, pharmacist, supervisor.
+// @Tags staff
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Staff ID"
+// @Param If-Match header string true "ETag of the version being changed"
+// @Param role body dto.StaffRoleRequest true "Role"
+// @Success 200 {object} dto.StaffResponse
+// @Header 200 {string} ETag "New version"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Failure 428 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /staff/{id}/role [put]
+func (h *StaffHandler) SetRoleHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.service)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	version, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+	var req dto.StaffRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	staff, err := h.service.SetRole(c.Request.Context(), id, hospitalID, staffID, req.Role, version)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	setETag(c, staff.Version)
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": toStaffResponse(*staff)})
+}
+
+func toStaffResponse(staff entities.Staff) dto.StaffResponse {
+	return dto.StaffResponse{
+		ID:         staff.ID,
+		Username:   staff.Username,
+		Role:       staff.Role,
+		HospitalID: staff.HospitalID,
+		Version:    staff.Version,
+		CreatedAt:  staff.CreatedAt,
+		UpdatedAt:  staff.UpdatedAt,
+	}
+}
@@ -0,0 +1,361 @@
+package handlers
+
+import (
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/services"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WardHandler struct {
+	wardService  services.WardServiceInterface
+	staffService services.StaffServiceInterface
+}
+
+func NewWardHandler(wardService services.WardServiceInterface, staffService services.StaffServiceInterface) *WardHandler {
+	return &WardHandler{
+		wardService:  wardService,
+		staffService: staffService,
+	}
+}
+
+// CreateWardHandler creates a ward in the caller's hospital
+// @Summary Create ward
+// @Tags wards
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param ward body dto.WardCreateRequest true "Ward"
+// @Success 201 {object} dto.WardResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /wards [post]
+func (h *WardHandler) CreateWardHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	var req dto.WardCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	ward := entities.Ward{HospitalID: hospitalID, Code: req.Code, Name: req.Name}
+	if err := h.wardService.CreateWard(c.Request.Context(), &ward); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": toWardResponse(ward)})
+}
+
+// ListWardsHandler lists the caller's hospital wards and their beds
+// @Summary List wards
+// @Tags wards
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} []dto.WardResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /wards [get]
+func (h *WardHandler) ListWardsHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	wards, err := h.wardService.ListWards(c.Request.Context(), hospitalID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	resp := make([]dto.WardResponse, len(wards))
+	for i, w := range wards {
+		resp[i] = toWardResponse(w)
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
+}
+
+// CreateBedHandler adds a bed to a ward
+// @Summary Create bed
+// @Tags wards
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Ward ID"
+// @Param bed body dto.BedCreateRequest true "Bed"
+// @Success 201 {object} dto.BedResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /wards/{id}/beds [post]
+func (h *WardHandler) CreateBedHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	wardID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req dto.BedCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	bed := entities.Bed{WardID: wardID, HospitalID: hospitalID, Label: req.Label}
+	if err := h.wardService.CreateBed(c.Request.Context(), &bed); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	setETag(c, bed.Version)
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": toBedResponse(bed)})
+}
+
+// UpdateBedStatusHandler marks a free bed as available, cleaning or blocked
+// @Summary Update bed status
+// @Tags wards
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Bed ID"
+// @Param If-Match header string true "ETag of the version being changed"
+// @Param status body dto.BedStatusRequest true "available, cleaning or blocked"
+// @Success 200 {object} dto.BedResponse
+// @Header 200 {string} ETag "New version"
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Failure 428 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /beds/{id}/status [patch]
+func (h *WardHandler) UpdateBedStatusHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	version, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+	var req dto.BedStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	bed, err := h.wardService.UpdateBedStatus(c.Request.Context(), id, hospitalID, req.Status, version)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	setETag(c, bed.Version)
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": toBedResponse(*bed)})
+}
+
+// AdmitHandler admits a patient into an available bed
+// @Summary Admit patient
+// @Tags wards
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Patient ID"
+// @Param admission body dto.AdmitRequest true "Admission"
+// @Success 201 {object} dto.AdmissionResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse "bed taken or patient already admitted"
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /patients/{id}/admissions [post]
+func (h *WardHandler) AdmitHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	patientID, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req dto.AdmitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	admission := entities.Admission{
+		PatientID:    patientID,
+		HospitalID:   hospitalID,
+		BedID:        req.BedID,
+		Reason:       req.Reason,
+		AdmittedByID: staffID,
+	}
+	if err := h.wardService.Admit(c.Request.Context(), &admission); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": toAdmissionResponse(admission)})
+}
+
+// TransferHandler moves an admitted patient to another available bed
+// @Summary Transfer patient
+// @Tags wards
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Admission ID"
+// @Param transfer body dto.TransferRequest true "Target bed"
+// @Success 200 {object} dto.AdmissionResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /admissions/{id}/transfer [post]
+func (h *WardHandler) TransferHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req dto.TransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	admission, err := h.wardService.Transfer(c.Request.Context(), id, hospitalID, req.BedID, staffID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": toAdmissionResponse(*admission)})
+}
+
+// DischargeHandler discharges an admitted patient and sends the bed to cleaning
+// @Summary Discharge patient
+// @Tags wards
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Admission ID"
+// @Param discharge body dto.DischargeRequest false "Discharge note"
+// @Success 200 {object} dto.AdmissionResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /admissions/{id}/discharge [post]
+func (h *WardHandler) DischargeHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	var req dto.DischargeRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+			return
+		}
+	}
+	admission, err := h.wardService.Discharge(c.Request.Context(), id, hospitalID, staffID, req.Note)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": toAdmissionResponse(*admission)})
+}
+
+// BedBoardHandler shows current bed occupancy per ward
+// @Summary Bed board
+// @Tags wards
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} []dto.BedBoardWard
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /bed-board [get]
+func (h *WardHandler) BedBoardHandler(c *gin.Context) {
+	_, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	board, err := h.wardService.BedBoard(c.Request.Context(), hospitalID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	resp := make([]dto.BedBoardWard, len(board))
+	for i, occ := range board {
+		w := dto.BedBoardWard{
+			WardID:    occ.Ward.ID,
+			Code:      occ.Ward.Code,
+			Name:      occ.Ward.Name,
+			TotalBeds: len(occ.Ward.Beds),
+			Available: occ.Counts[entities.BedAvailable],
+			Occupied:  occ.Counts[entities.BedOccupied],
+			Cleaning:  occ.Counts[entities.BedCleaning],
+			Blocked:   occ.Counts[entities.BedBlocked],
+			Beds:      make([]dto.BedBoardBed, len(occ.Ward.Beds)),
+		}
+		if w.TotalBeds > 0 {
+			w.OccupancyRate = float64(w.Occupied) / float64(w.TotalBeds)
+		}
+		for j, b := range occ.Ward.Beds {
+			bed := dto.BedBoardBed{BedID: b.ID, Label: b.Label, Status: b.Status, Version: b.Version}
+			if a, ok := occ.Admissions[b.ID]; ok {
+				bed.AdmissionID = &a.ID
+				bed.PatientID = &a.PatientID
+				bed.PatientHN = a.Patient.PatientHN
+				bed.PatientName = strings.TrimSpace(a.Patient.FirstNameTH + " " + a.Patient.LastNameTH)
+				bed.AdmittedAt = &a.AdmittedAt
+			}
+			w.Beds[j] = bed
+		}
+		resp[i] = w
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
+}
+
+func toBedResponse(b entities.Bed) dto.BedResponse {
+	return dto.BedResponse{BedID: b.ID, WardID: b.WardID, Label: b.Label, Status: b.Status, Version: b.Version}
+}
+
+func toWardResponse(w entities.Ward) dto.WardResponse {
+	beds := make([]dto.BedResponse, len(w.Beds))
+	for i, b := range w.Beds {
+		beds[i] = toBedResponse(b)
+	}
+	return dto.WardResponse{WardID: w.ID, Code: w.Code, Name: w.Name, Beds: beds}
+}
+
+func toAdmissionResponse(a entities.Admission) dto.AdmissionResponse {
+	return dto.AdmissionResponse{
+		AdmissionID:    a.ID,
+		PatientID:      a.PatientID,
+		BedID:          a.BedID,
+		Status:         a.Status,
+		Reason:         a.Reason,
+		AdmittedByID:   a.AdmittedByID,
+		AdmittedAt:     a.AdmittedAt,
+		DischargedByID: a.DischargedByID,
+		DischargedAt:   a.DischargedAt,
+		DischargeNote:  a.DischargeNote,
+	}
+}
@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/services"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type WebhookHandler struct {
+	webhookService services.WebhookServiceInterface
+	staffService   services.StaffServiceInterface
+}
+
+func NewWebhookHandler(webhookService services.WebhookServiceInterface, staffService services.StaffServiceInterface) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+		staffService:   staffService,
+	}
+}
+
+// CreateSubscriptionHandler subscribes a URL to the hospital's patient and staff events
+// @Summary Create webhook subscription
+// @Description Supervisors only. Event types: patient.created, patient.updated, patient.deleted, patient.restored, patient.merged, staff.created, staff.deleted and staff.restored; none means all.
+// @Description The secret that signs the payloads is only returned here.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param subscription body dto.WebhookSubscriptionRequest true "Subscription"
+// @Success 201 {object} dto.WebhookSubscriptionResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /webhooks [post]
+func (h *WebhookHandler) CreateSubscriptionHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	var req dto.WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: err.Error()})
+		return
+	}
+	sub := entities.WebhookSubscription{
+		HospitalID:  hospitalID,
+		URL:         req.URL,
+		Description: req.Description,
+		EventTypes:  strings.Join(req.EventTypes, ","),
+	}
+	if err := h.webhookService.CreateSubscription(c.Request.Context(), &sub, staffID); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	resp := toWebhookSubscriptionResponse(sub)
+	resp.Secret = sub.Secret
+	c.JSON(http.StatusCreated, gin.H{"status": "success", "data": resp})
+}
+
+// ListSubscriptionsHandler lists the hospital's webhook subscriptions
+// @Summary List webhook subscriptions
+// @Description Supervisors only
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} []dto.WebhookSubscriptionResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /webhooks [get]
+func (h *WebhookHandler) ListSubscriptionsHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	subs, err := h.webhookService.ListSubscriptions(c.Request.Context(), hospitalID, staffID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	resp := make([]dto.WebhookSubscriptionResponse, len(subs))
+	for i, s := range subs {
+		resp[i] = toWebhookSubscriptionResponse(s)
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
+}
+
+// DeleteSubscriptionHandler removes a webhook subscription
+// @Summary Delete webhook subscription
+// @Description Supervisors only. Deliveries still pending for the subscription move to the dead-letter list
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Subscription ID"
+// @Param If-Match header string true "ETag of the version being deleted"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 412 {object} dto.ErrorResponse
+// @Failure 428 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteSubscriptionHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	version, ok := requireIfMatch(c)
+	if !ok {
+		return
+	}
+	if err := h.webhookService.DeleteSubscription(c.Request.Context(), id, hospitalID, staffID, version); err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, dto.MessageResponse{Status: "success", Message: "webhook subscription deleted"})
+}
+
+// DeliveriesHandler is the delivery log of the hospital's webhooks
+// @Summary List webhook deliveries
+// @Description Supervisors only. Returns the newest 200 deliveries; status=dead lists the dead letters, which failed every attempt
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param status query string false "pending, delivered or dead"
+// @Param subscription_id query string false "Only deliveries of this subscription"
+// @Success 200 {object} []dto.WebhookDeliveryResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /webhooks/deliveries [get]
+func (h *WebhookHandler) DeliveriesHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	var subscriptionID *uuid.UUID
+	if v := c.Query("subscription_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dto.ErrorResponse{Status: "error", Message: "invalid subscription_id"})
+			return
+		}
+		subscriptionID = &id
+	}
+	deliveries, err := h.webhookService.Deliveries(c.Request.Context(), hospitalID, staffID, c.Query("status"), subscriptionID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	resp := make([]dto.WebhookDeliveryResponse, len(deliveries))
+	for i, d := range deliveries {
+		resp[i] = toWebhookDeliveryResponse(d)
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": resp})
+}
+
+// RetryDeliveryHandler sends a dead letter again
+// @Summary Retry webhook delivery
+// @Description Supervisors only. The delivery gets a fresh round of attempts
+// @Tags webhooks
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Delivery ID"
+// @Success 200 {object} dto.WebhookDeliveryResponse
+// @Failure 400 {object} dto.ErrorResponse
+// @Failure 401 {object} dto.ErrorResponse
+// @Failure 403 {object} dto.ErrorResponse
+// @Failure 404 {object} dto.ErrorResponse
+// @Failure 409 {object} dto.ErrorResponse
+// @Failure 500 {object} dto.ErrorResponse
+// @Router /webhooks/deliveries/{id}/retry [post]
+func (h *WebhookHandler) RetryDeliveryHandler(c *gin.Context) {
+	staffID, hospitalID, ok := currentStaff(c, h.staffService)
+	if !ok {
+		return
+	}
+	id, ok := parseUUIDParam(c, "id")
+	if !ok {
+		return
+	}
+	delivery, err := h.webhookService.Retry(c.Request.Context(), id, hospitalID, staffID)
+	if err != nil {
+		writeServiceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "success", "data": toWebhookDeliveryResponse(*delivery)})
+}
+
+func toWebhookSubscriptionResponse(s entities.WebhookSubscription) dto.WebhookSubscriptionResponse {
+	types := []string{}
+	if s.EventTypes != "" {
+		types = strings.Split(s.EventTypes, ",")
+	}
+	return dto.WebhookSubscriptionResponse{
+		SubscriptionID: s.ID,
+		URL:            s.URL,
+		Description:    s.Description,
+		EventTypes:     types,
+		Version:        s.Version,
+		CreatedAt:      s.CreatedAt,
+	}
+}
+
+func toWebhookDeliveryResponse(d entities.WebhookDelivery) dto.WebhookDeliveryResponse {
+	resp := dto.WebhookDeliveryResponse{
+		DeliveryID:     d.ID,
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		LastAttemptAt:  d.LastAttemptAt,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt,
+		DeliveredAt:    d.DeliveredAt,
+	}
+	if d.Status == entities.WebhookPending {
+		next := d.NextAttemptAt
+		resp.NextAttemptAt = &next
+	}
+	return resp
+}
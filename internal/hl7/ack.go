@@ -0,0 +1,50 @@
+package hl7
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Acknowledgment codes of MSA-1 in original acknowledgment mode
+const (
+	AckAccept = "AA"
+	// AckError asks the sender to fix the message or the data it refers to and send it again.
+	AckError = "AE"
+	// AckReject means the message will never be processed, e.g. an unsupported message type.
+	AckReject = "AR"
+)
+
+// ACK builds the acknowledgment of m, addressed back to its sender. m may be nil when the
+// message could not be parsed at all.
+func ACK(m *Message, code, text string, at time.Time) string {
+	d := defaultDelimiters
+	var sendingApp, sendingFacility, receivingApp, receivingFacility, trigger, processingID, version, controlID string
+	if m != nil {
+		d = m.Delimiters
+		if msh := m.Segment("MSH"); msh != nil {
+			sendingApp, sendingFacility = msh.Field(3), msh.Field(4)
+			receivingApp, receivingFacility = msh.Field(5), msh.Field(6)
+			processingID, version = msh.Field(11), msh.Field(12)
+		}
+		_, trigger = m.Type()
+		controlID = m.ControlID()
+	}
+	if processingID == "" {
+		processingID = "P"
+	}
+	if version == "" {
+		version = "2.5"
+	}
+	f, c := string(d.Field), string(d.Component)
+	msh := []string{
+		"MSH", string([]byte{d.Component, d.Repetition, d.Escape, d.Subcomponent}),
+		receivingApp, receivingFacility, sendingApp, sendingFacility,
+		at.Format("20060102150405"), "",
+		"ACK" + c + trigger + c + "ACK",
+		strconv.FormatInt(at.UnixNano(), 36),
+		processingID, version,
+	}
+	msa := []string{"MSA", code, d.escape(controlID), d.escape(text)}
+	return strings.Join(msh, f) + "\r" + strings.Join(msa, f) + "\r"
+}
@@ -0,0 +1,208 @@
+// Package hl7 reads and writes HL7 v2 messages and their MLLP framing.
+package hl7
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrNotHL7 = errors.New("message does not start with an MSH segment")
+
+// Delimiters are the separators a message declares in MSH-1 and MSH-2.
+type Delimiters struct {
+	Field        byte
+	Component    byte
+	Repetition   byte
+	Escape       byte
+	Subcomponent byte
+}
+
+var defaultDelimiters = Delimiters{Field: '|', Component: '^', Repetition: '~', Escape: '\\', Subcomponent: '&'}
+
+type Message struct {
+	Delimiters Delimiters
+	Segments   []*Segment
+}
+
+// Segment is one line of a message. Fields are numbered as in the HL7 standard, so Field(3) of
+// a PID segment is PID-3. For MSH, Field(1) is the field separator itself.
+type Segment struct {
+	Name   string
+	fields []string
+	d      *Delimiters
+}
+
+// Field is one repetition of a field, split into components on demand.
+type Field struct {
+	raw string
+	d   *Delimiters
+}
+
+// Parse reads a message whose segments end in carriage returns; line feeds are accepted too, so
+// messages saved as text files parse as well.
+func Parse(raw string) (*Message, error) {
+	raw = strings.TrimLeft(raw, "\r\n\t ")
+	if len(raw) < 8 || !strings.HasPrefix(raw, "MSH") {
+		return nil, ErrNotHL7
+	}
+	d := Delimiters{Field: raw[3]}
+	encoding := raw[4:]
+	if i := strings.IndexByte(encoding, d.Field); i >= 0 {
+		encoding = encoding[:i]
+	}
+	if len(encoding) < 4 {
+		return nil, errors.New("MSH-2 must declare the component, repetition, escape and subcomponent separators")
+	}
+	d.Component, d.Repetition, d.Escape, d.Subcomponent = encoding[0], encoding[1], encoding[2], encoding[3]
+
+	m := &Message{Delimiters: d}
+	lines := strings.FieldsFunc(raw, func(r rune) bool { return r == '\r' || r == '\n' })
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Split(line, string(d.Field))
+		m.Segments = append(m.Segments, &Segment{Name: fields[0], fields: fields, d: &m.Delimiters})
+	}
+	return m, nil
+}
+
+// Segment returns the first segment with the given name, or nil
+func (m *Message) Segment(name string) *Segment {
+	for _, s := range m.Segments {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// Type returns the message code and trigger event of MSH-9, e.g. ADT and A01
+func (m *Message) Type() (code, trigger string) {
+	msh := m.Segment("MSH")
+	if msh == nil {
+		return "", ""
+	}
+	f := msh.First(9)
+	return f.Component(1), f.Component(2)
+}
+
+// ControlID is MSH-10, which the acknowledgement echoes
+func (m *Message) ControlID() string {
+	if msh := m.Segment("MSH"); msh != nil {
+		return msh.First(10).Component(1)
+	}
+	return ""
+}
+
+// Field returns the raw text of field n
+func (s *Segment) Field(n int) string {
+	if s.Name == "MSH" {
+		if n == 1 {
+			return string(s.d.Field)
+		}
+		n--
+	}
+	if n <= 0 || n >= len(s.fields) {
+		return ""
+	}
+	return s.fields[n]
+}
+
+// Repetitions splits field n into its repetitions
+func (s *Segment) Repetitions(n int) []Field {
+	raw := s.Field(n)
+	if raw == "" {
+		return nil
+	}
+	if s.Name == "MSH" && n == 2 {
+		return []Field{{raw: raw, d: s.d}}
+	}
+	parts := strings.Split(raw, string(s.d.Repetition))
+	fields := make([]Field, len(parts))
+	for i, p := range parts {
+		fields[i] = Field{raw: p, d: s.d}
+	}
+	return fields
+}
+
+// First returns the first repetition of field n
+func (s *Segment) First(n int) Field {
+	if reps := s.Repetitions(n); len(reps) > 0 {
+		return reps[0]
+	}
+	return Field{d: s.d}
+}
+
+// Component returns component n (1-based) with escape sequences resolved
+func (f Field) Component(n int) string {
+	parts := strings.Split(f.raw, string(f.d.Component))
+	if n <= 0 || n > len(parts) {
+		return ""
+	}
+	return f.d.unescape(parts[n-1])
+}
+
+// IsNull reports the HL7 null value "", which tells the receiver to clear a field
+func (f Field) IsNull() bool {
+	return f.raw == `""`
+}
+
+func (f Field) String() string {
+	return f.raw
+}
+
+func (d *Delimiters) unescape(s string) string {
+	if strings.IndexByte(s, d.Escape) < 0 {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != d.Escape {
+			b.WriteByte(s[i])
+			continue
+		}
+		end := strings.IndexByte(s[i+1:], d.Escape)
+		if end < 0 {
+			b.WriteString(s[i:])
+			break
+		}
+		switch s[i+1 : i+1+end] {
+		case "F":
+			b.WriteByte(d.Field)
+		case "S":
+			b.WriteByte(d.Component)
+		case "T":
+			b.WriteByte(d.Subcomponent)
+		case "R":
+			b.WriteByte(d.Repetition)
+		case "E":
+			b.WriteByte(d.Escape)
+		}
+		i += end + 1
+	}
+	return b.String()
+}
+
+func (d *Delimiters) escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case d.Escape:
+			b.WriteString(string(d.Escape) + "E" + string(d.Escape))
+		case d.Field:
+			b.WriteString(string(d.Escape) + "F" + string(d.Escape))
+		case d.Component:
+			b.WriteString(string(d.Escape) + "S" + string(d.Escape))
+		case d.Subcomponent:
+			b.WriteString(string(d.Escape) + "T" + string(d.Escape))
+		case d.Repetition:
+			b.WriteString(string(d.Escape) + "R" + string(d.Escape))
+		case '\r', '\n':
+			b.WriteByte(' ')
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,148 @@
+package hl7
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+// MLLP frames a message as <VT> message <FS><CR>.
+const (
+	startBlock     = 0x0b
+	endBlock       = 0x1c
+	carriageReturn = 0x0d
+
+	DefaultMaxMessageSize = 1 << 20
+	DefaultIdleTimeout    = 5 * time.Minute
+)
+
+var ErrMessageTooLarge = errors.New("MLLP message exceeds the maximum size")
+
+// ReadFrame reads the next MLLP frame and returns the message inside it. Bytes before the start
+// block, such as the previous frame's CR or stray line feeds, are skipped.
+func ReadFrame(r *bufio.Reader, maxSize int) ([]byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if b == startBlock {
+			break
+		}
+	}
+	var msg []byte
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		if b == endBlock {
+			// The CR after the end block is skipped with anything else before the next start block
+			return msg, nil
+		}
+		if len(msg) >= maxSize {
+			return nil, ErrMessageTooLarge
+		}
+		msg = append(msg, b)
+	}
+}
+
+// WriteFrame writes message inside an MLLP frame
+func WriteFrame(w io.Writer, message []byte) error {
+	frame := make([]byte, 0, len(message)+3)
+	frame = append(frame, startBlock)
+	frame = append(frame, message...)
+	frame = append(frame, endBlock, carriageReturn)
+	_, err := w.Write(frame)
+	return err
+}
+
+// Server accepts MLLP connections and answers every message with the acknowledgment Handler
+// returns. Messages on one connection are handled in order.
+type Server struct {
+	Handler        func(ctx context.Context, message []byte) []byte
+	MaxMessageSize int
+	// IdleTimeout closes a connection that sends nothing for this long.
+	IdleTimeout time.Duration
+}
+
+// ListenAndServe listens on addr, e.g. ":2575", until ctx is cancelled
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve accepts connections on ln until ctx is cancelled, then waits for open connections to
+// finish their current message.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.serveConn(ctx, conn)
+		}()
+	}
+}
+
+func (s *Server) serveConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.SetReadDeadline(time.Now())
+		case <-done:
+		}
+	}()
+	maxSize, idle := s.MaxMessageSize, s.IdleTimeout
+	if maxSize <= 0 {
+		maxSize = DefaultMaxMessageSize
+	}
+	if idle <= 0 {
+		idle = DefaultIdleTimeout
+	}
+	r := bufio.NewReader(conn)
+	for {
+		conn.SetReadDeadline(time.Now().Add(idle))
+		msg, err := ReadFrame(r, maxSize)
+		if err != nil {
+			if err != io.EOF && ctx.Err() == nil {
+				log.Printf("MLLP connection from %s closed: %v", conn.RemoteAddr(), err)
+			}
+			return
+		}
+		ack := s.Handler(ctx, msg)
+		if err := WriteFrame(conn, ack); err != nil {
+			log.Printf("MLLP acknowledgment to %s failed: %v", conn.RemoteAddr(), err)
+			return
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/repository"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	DefaultIdempotencyKeyTTL  = 24 * time.Hour
+	maxIdempotencyKeyLength   = 255
+	publicIdempotencyKeyScope = "public"
+	idempotencyRecorderKey    = "idempotencyRecorder"
+)
+
+// idempotencyRecorder keeps a copy of the response body so it can be stored for replay, unless
+// the handler skipped it
+type idempotencyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+	skip bool
+}
+
+func (w *idempotencyRecorder) Write(b []byte) (int, error) {
+	if !w.skip {
+		w.body.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *idempotencyRecorder) WriteString(s string) (int, error) {
+	if !w.skip {
+		w.body.WriteString(s)
+	}
+	return w.ResponseWriter.WriteString(s)
+}
+
+// SkipIdempotency keeps the response of the request from being stored for replay. Handlers call
+// it before streaming a response that is too large to keep, such as an export; a retry with the
+// same Idempotency-Key runs the request again.
+func SkipIdempotency(c *gin.Context) {
+	if w, ok := c.Get(idempotencyRecorderKey); ok {
+		recorder := w.(*idempotencyRecorder)
+		recorder.skip = true
+		recorder.body = bytes.Buffer{}
+	}
+}
+
+// Idempotency makes POST requests that carry an Idempotency-Key header safe to retry. The first
+// request with a key runs and its response is stored for ttl; a retry with the same key and body
+// gets the stored response back, with its ETag and Location headers, and Idempotent-Replayed:
+// true. Reusing a key for a different
+// request, or retrying while the first one is still running, is a 409. Responses with a 5xx
+// status, and those of handlers that call SkipIdempotency, are not stored, so the request can be
+// retried with the same key.
+//
+// Keys are scoped to the staff member set by AuthMiddleware, so it must run after it on
+// authenticated routes. On unauthenticated routes they are scoped to the hospital_id and
+// username of the JSON body, the account the request registers, so that clients do not share
+// keys.
+func Idempotency(repo repository.IdempotencyRepository, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if c.Request.Method != http.MethodPost || key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"status": "error", "message": "Idempotency-Key must be at most 255 characters"})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"status": "error", "message": "could not read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		scope := publicScope(body)
+		if staffID, ok := c.Get("staffID"); ok {
+			scope, _ = staffID.(string)
+		}
+		now := time.Now()
+		record := &entities.IdempotencyKey{
+			Scope:       scope,
+			Key:         key,
+			Method:      c.Request.Method,
+			Path:        c.Request.URL.Path,
+			RequestHash: fingerprint(c.Request, body),
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(ttl),
+		}
+		// The request may outlive the client's connection; its outcome must still be recorded.
+		ctx := context.WithoutCancel(c.Request.Context())
+		existing, err := repo.Claim(ctx, record)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"status": "error", "message": err.Error()})
+			return
+		}
+		if existing != nil {
+			switch {
+			case existing.RequestHash != record.RequestHash:
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"status": "error", "message": "Idempotency-Key was already used for a different request"})
+			case existing.CompletedAt == nil:
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"status": "error", "message": "a request with this Idempotency-Key is still being processed"})
+			default:
+				c.Header(IdempotentReplayedHeader, "true")
+				if existing.ETag != "" {
+					c.Header("ETag", existing.ETag)
+				}
+				if existing.Location != "" {
+					c.Header("Location", existing.Location)
+				}
+				c.Data(existing.StatusCode, existing.ContentType, existing.Response)
+				c.Abort()
+			}
+			return
+		}
+
+		completed := false
+		defer func() {
+			if !completed {
+				if err := repo.Release(ctx, scope, key); err != nil {
+					log.Printf("releasing idempotency key %q failed: %v", key, err)
+				}
+			}
+		}()
+
+		recorder := &idempotencyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Set(idempotencyRecorderKey, recorder)
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError || recorder.skip {
+			return
+		}
+		record.StatusCode = status
+		record.ContentType = recorder.Header().Get("Content-Type")
+		record.ETag = recorder.Header().Get("ETag")
+		record.Location = recorder.Header().Get("Location")
+		record.Response = recorder.body.Bytes()
+		if err := repo.Complete(ctx, record); err != nil {
+			log.Printf("storing response for idempotency key %q failed: %v", key, err)
+			return
+		}
+		completed = true
+	}
+}
+
+// publicScope is the scope of a key sent without a staff member: the hospital and username of
+// the body, or just "public" for a body without them
+func publicScope(body []byte) string {
+	var account struct {
+		HospitalID string `json:"hospital_id"`
+		Username   string `json:"username"`
+	}
+	if json.Unmarshal(body, &account) != nil || account.HospitalID == "" && account.Username == "" {
+		return publicIdempotencyKeyScope
+	}
+	return publicIdempotencyKeyScope + ":" + account.HospitalID + ":" + account.Username
+}
+
+// fingerprint identifies a request by method, path, query and body
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// RunIdempotencyCleanup deletes expired idempotency keys every interval until ctx is cancelled
+func RunIdempotencyCleanup(ctx context.Context, repo repository.IdempotencyRepository, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		deleted, err := repo.DeleteExpired(ctx, time.Now())
+		if err != nil {
+			log.Printf("idempotency key cleanup failed: %v", err)
+		} else if deleted > 0 {
+			log.Printf("idempotency key cleanup: %d expired keys deleted", deleted)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
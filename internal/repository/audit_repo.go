@@ -0,0 +1,37 @@
+package repository
+
+import (
+	"context"
+	"go-hospital-api/internal/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AuditRepository interface {
+	Create(ctx context.Context, event *entities.AuditEvent) error
+	List(ctx context.Context, hospitalID uuid.UUID, priority string, limit int) ([]entities.AuditEvent, error)
+}
+
+type auditRepo struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepo{db: db}
+}
+
+func (r *auditRepo) Create(ctx context.Context, event *entities.AuditEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+// List returns the newest events of a hospital, optionally of one priority
+func (r *auditRepo) List(ctx context.Context, hospitalID uuid.UUID, priority string, limit int) ([]entities.AuditEvent, error) {
+	var events []entities.AuditEvent
+	query := r.db.WithContext(ctx).Where("hospital_id = ?", hospitalID)
+	if priority != "" {
+		query = query.Where("priority = ?", priority)
+	}
+	err := query.Order("created_at DESC").Limit(limit).Find(&events).Error
+	return events, err
+}
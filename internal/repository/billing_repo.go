@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-hospital-api/internal/entities"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrChargesUnavailable = errors.New("some charges are already invoiced or voided")
+	ErrOverpayment        = errors.New("payment exceeds the outstanding amount")
+)
+
+type BillingRepository interface {
+	CreatePriceItem(ctx context.Context, item *entities.PriceItem) error
+	ListPriceItems(ctx context.Context, hospitalID uuid.UUID, query string) ([]entities.PriceItem, error)
+	GetPriceItem(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.PriceItem, error)
+	CreateCharge(ctx context.Context, charge *entities.Charge) error
+	ListCharges(ctx context.Context, patientID uuid.UUID, hospitalID uuid.UUID, status string, ids []uuid.UUID) ([]entities.Charge, error)
+	VoidCharge(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (bool, error)
+	CreateInvoice(ctx context.Context, invoice *entities.Invoice) error
+	GetInvoice(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.Invoice, error)
+	AddPayment(ctx context.Context, payment *entities.Payment) error
+	Balance(ctx context.Context, patientID uuid.UUID, hospitalID uuid.UUID) (outstanding int64, uninvoiced int64, err error)
+}
+
+type billingRepo struct {
+	db *gorm.DB
+}
+
+func NewBillingRepository(db *gorm.DB) BillingRepository {
+	return &billingRepo{db: db}
+}
+
+func (r *billingRepo) CreatePriceItem(ctx context.Context, item *entities.PriceItem) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(item).Error
+}
+
+func (r *billingRepo) ListPriceItems(ctx context.Context, hospitalID uuid.UUID, query string) ([]entities.PriceItem, error) {
+	var items []entities.PriceItem
+	q := r.db.WithContext(ctx).Where("hospital_id = ? AND active", hospitalID)
+	if query != "" {
+		q = q.Where("code ILIKE ? OR name ILIKE ?", query+"%", "%"+query+"%")
+	}
+	err := q.Order("code").Find(&items).Error
+	return items, err
+}
+
+func (r *billingRepo) GetPriceItem(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.PriceItem, error) {
+	var item entities.PriceItem
+	if err := r.db.WithContext(ctx).Where("id = ? AND hospital_id = ?", id, hospitalID).First(&item).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+func (r *billingRepo) CreateCharge(ctx context.Context, charge *entities.Charge) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(charge).Error
+}
+
+func (r *billingRepo) ListCharges(ctx context.Context, patientID uuid.UUID, hospitalID uuid.UUID, status string, ids []uuid.UUID) ([]entities.Charge, error) {
+	var charges []entities.Charge
+	q := r.db.WithContext(ctx).Where("patient_id = ? AND hospital_id = ?", patientID, hospitalID)
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	if len(ids) > 0 {
+		q = q.Where("id IN ?", ids)
+	}
+	err := q.Order("service_date, created_at").Find(&charges).Error
+	return charges, err
+}
+
+func (r *billingRepo) VoidCharge(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&entities.Charge{}).
+		Where("id = ? AND hospital_id = ? AND status = ?", id, hospitalID, entities.ChargePosted).
+		Updates(map[string]interface{}{"status": entities.ChargeVoided, "updated_at": time.Now()})
+	return res.RowsAffected > 0, res.Error
+}
+
+// CreateInvoice numbers the invoice sequentially per hospital and year, marks its charges as
+// invoiced and stores it with its lines, all in one transaction. Charges that were invoiced or
+// voided concurrently make the whole invoice fail.
+func (r *billingRepo) CreateInvoice(ctx context.Context, invoice *entities.Invoice) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "invoice:"+invoice.HospitalID.String()).Error; err != nil {
+			return err
+		}
+		year := invoice.IssuedAt.Year()
+		var count int64
+		if err := tx.Model(&entities.Invoice{}).
+			Where("hospital_id = ? AND EXTRACT(YEAR FROM issued_at) = ?", invoice.HospitalID, year).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		invoice.Number = fmt.Sprintf("INV%d-%06d", year, count+1)
+
+		chargeIDs := make([]uuid.UUID, len(invoice.Lines))
+		for i, l := range invoice.Lines {
+			chargeIDs[i] = l.ChargeID
+		}
+		res := tx.Model(&entities.Charge{}).
+			Where("id IN ? AND patient_id = ? AND hospital_id = ? AND status = ?", chargeIDs, invoice.PatientID, invoice.HospitalID, entities.ChargePosted).
+			Updates(map[string]interface{}{"status": entities.ChargeInvoiced, "invoice_id": invoice.ID, "updated_at": time.Now()})
+		if res.Error != nil {
+			return res.Error
+		}
+		if int(res.RowsAffected) != len(chargeIDs) {
+			return ErrChargesUnavailable
+		}
+		return tx.Omit("Hospital", "Patient", "Payments").Create(invoice).Error
+	})
+}
+
+func (r *billingRepo) GetInvoice(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.Invoice, error) {
+	var invoice entities.Invoice
+	err := r.db.WithContext(ctx).
+		Preload("Hospital").
+		Preload("Patient").
+		Preload("Lines", func(db *gorm.DB) *gorm.DB { return db.Order("created_at, code") }).
+		Preload("Payments", func(db *gorm.DB) *gorm.DB { return db.Order("paid_at") }).
+		Where("id = ? AND hospital_id = ?", id, hospitalID).
+		First(&invoice).Error
+	if err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// AddPayment records a payment and raises the invoice's paid amount in a single conditional
+// update, so concurrent payments can never push it past the total.
+func (r *billingRepo) AddPayment(ctx context.Context, payment *entities.Payment) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&entities.Invoice{}).
+			Where("id = ? AND hospital_id = ? AND paid + ? <= total", payment.InvoiceID, payment.HospitalID, payment.Amount).
+			Updates(map[string]interface{}{
+				"paid":       gorm.Expr("paid + ?", payment.Amount),
+				"status":     gorm.Expr("CASE WHEN paid + ? >= total THEN ? ELSE ? END", payment.Amount, entities.InvoicePaid, entities.InvoicePartiallyPaid),
+				"updated_at": time.Now(),
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrOverpayment
+		}
+		return tx.Create(payment).Error
+	})
+}
+
+// Balance returns the unpaid amount of issued invoices and the value of charges not yet invoiced (before VAT)
+func (r *billingRepo) Balance(ctx context.Context, patientID uuid.UUID, hospitalID uuid.UUID) (int64, int64, error) {
+	var outstanding, uninvoiced int64
+	err := r.db.WithContext(ctx).Model(&entities.Invoice{}).
+		Where("patient_id = ? AND hospital_id = ?", patientID, hospitalID).
+		Select("COALESCE(SUM(total - paid), 0)").Scan(&outstanding).Error
+	if err != nil {
+		return 0, 0, err
+	}
+	err = r.db.WithContext(ctx).Model(&entities.Charge{}).
+		Where("patient_id = ? AND hospital_id = ? AND status = ?", patientID, hospitalID, entities.ChargePosted).
+		Select("COALESCE(SUM(quantity * unit_price), 0)").Scan(&uninvoiced).Error
+	return outstanding, uninvoiced, err
+}
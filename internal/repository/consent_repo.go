@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-hospital-api/internal/entities"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrConsentNotGranted = errors.New("consent is not currently granted")
+
+type ConsentRepository interface {
+	Grant(ctx context.Context, consent *entities.Consent) error
+	Withdraw(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID, staffID uuid.UUID, reason string, at time.Time) error
+	GetByID(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.Consent, error)
+	ListByPatient(ctx context.Context, patientID uuid.UUID, hospitalID uuid.UUID, purpose string) ([]entities.Consent, error)
+	GetGranted(ctx context.Context, patientID uuid.UUID, hospitalID uuid.UUID, purpose string) (*entities.Consent, error)
+}
+
+type consentRepo struct {
+	db *gorm.DB
+}
+
+func NewConsentRepository(db *gorm.DB) ConsentRepository {
+	return &consentRepo{db: db}
+}
+
+// Grant stores a new consent and supersedes the one currently granted for the same purpose
+func (r *consentRepo) Grant(ctx context.Context, consent *entities.Consent) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&entities.Consent{}).
+			Where("patient_id = ? AND purpose = ? AND status = ?", consent.PatientID, consent.Purpose, entities.ConsentGranted).
+			Update("status", entities.ConsentSuperseded).Error; err != nil {
+			return err
+		}
+		return tx.Omit(clause.Associations).Create(consent).Error
+	})
+}
+
+func (r *consentRepo) Withdraw(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID, staffID uuid.UUID, reason string, at time.Time) error {
+	res := r.db.WithContext(ctx).Model(&entities.Consent{}).
+		Where("id = ? AND hospital_id = ? AND status = ?", id, hospitalID, entities.ConsentGranted).
+		Updates(map[string]interface{}{
+			"status":          entities.ConsentWithdrawn,
+			"withdrawn_at":    at,
+			"withdrawn_by_id": staffID,
+			"withdraw_reason": reason,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrConsentNotGranted
+	}
+	return nil
+}
+
+func (r *consentRepo) GetByID(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.Consent, error) {
+	var consent entities.Consent
+	if err := r.db.WithContext(ctx).Where("id = ? AND hospital_id = ?", id, hospitalID).First(&consent).Error; err != nil {
+		return nil, err
+	}
+	return &consent, nil
+}
+
+func (r *consentRepo) ListByPatient(ctx context.Context, patientID uuid.UUID, hospitalID uuid.UUID, purpose string) ([]entities.Consent, error) {
+	var consents []entities.Consent
+	query := r.db.WithContext(ctx).Where("patient_id = ? AND hospital_id = ?", patientID, hospitalID)
+	if purpose != "" {
+		query = query.Where("purpose = ?", purpose)
+	}
+	err := query.Order("granted_at DESC").Find(&consents).Error
+	return consents, err
+}
+
+func (r *consentRepo) GetGranted(ctx context.Context, patientID uuid.UUID, hospitalID uuid.UUID, purpose string) (*entities.Consent, error) {
+	var consent entities.Consent
+	err := r.db.WithContext(ctx).
+		Where("patient_id = ? AND hospital_id = ? AND purpose = ? AND status = ?", patientID, hospitalID, purpose, entities.ConsentGranted).
+		First(&consent).Error
+	if err != nil {
+		return nil, err
+	}
+	return &consent, nil
+}
@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"go-hospital-api/internal/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ContactRepository interface {
+	CreateAddress(ctx context.Context, address *entities.PatientAddress) error
+	ListAddresses(ctx context.Context, patientID uuid.UUID, hospitalID uuid.UUID) ([]entities.PatientAddress, error)
+	GetAddress(ctx context.Context, id uuid.UUID, patientID uuid.UUID, hospitalID uuid.UUID) (*entities.PatientAddress, error)
+	UpdateAddress(ctx context.Context, address *entities.PatientAddress) error
+	DeleteAddress(ctx context.Context, id uuid.UUID, patientID uuid.UUID, hospitalID uuid.UUID, version int) (bool, error)
+	CreateContact(ctx context.Context, contact *entities.EmergencyContact) error
+	ListContacts(ctx context.Context, patientID uuid.UUID, hospitalID uuid.UUID) ([]entities.EmergencyContact, error)
+	GetContact(ctx context.Context, id uuid.UUID, patientID uuid.UUID, hospitalID uuid.UUID) (*entities.EmergencyContact, error)
+	UpdateContact(ctx context.Context, contact *entities.EmergencyContact) error
+	DeleteContact(ctx context.Context, id uuid.UUID, patientID uuid.UUID, hospitalID uuid.UUID, version int) (bool, error)
+}
+
+type contactRepo struct {
+	db *gorm.DB
+}
+
+func NewContactRepository(db *gorm.DB) ContactRepository {
+	return &contactRepo{db: db}
+}
+
+func (r *contactRepo) CreateAddress(ctx context.Context, address *entities.PatientAddress) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(address).Error
+}
+
+func (r *contactRepo) ListAddresses(ctx context.Context, patientID uuid.UUID, hospitalID uuid.UUID) ([]entities.PatientAddress, error) {
+	var addresses []entities.PatientAddress
+	err := r.db.WithContext(ctx).
+		Where("patient_id = ? AND hospital_id = ?", patientID, hospitalID).
+		Order("type").
+		Find(&addresses).Error
+	return addresses, err
+}
+
+func (r *contactRepo) GetAddress(ctx context.Context, id uuid.UUID, patientID uuid.UUID, hospitalID uuid.UUID) (*entities.PatientAddress, error) {
+	var address entities.PatientAddress
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND patient_id = ? AND hospital_id = ?", id, patientID, hospitalID).
+		First(&address).Error
+	if err != nil {
+		return nil, err
+	}
+	return &address, nil
+}
+
+// UpdateAddress saves an address, provided address.Version is still the stored version, and
+// raises the version. It returns ErrStaleVersion when the version is not current.
+func (r *contactRepo) UpdateAddress(ctx context.Context, address *entities.PatientAddress) error {
+	return updateVersioned(r.db.WithContext(ctx), &entities.PatientAddress{}, address.ID, &address.Version, address)
+}
+
+// DeleteAddress deletes an address of the patient at the given version. It returns false when
+// there is no such address and ErrStaleVersion when the version is not current.
+func (r *contactRepo) DeleteAddress(ctx context.Context, id uuid.UUID, patientID uuid.UUID, hospitalID uuid.UUID, version int) (bool, error) {
+	return deleteVersioned(r.db.WithContext(ctx), &entities.PatientAddress{}, version,
+		"id = ? AND patient_id = ? AND hospital_id = ?", id, patientID, hospitalID)
+}
+
+func (r *contactRepo) CreateContact(ctx context.Context, contact *entities.EmergencyContact) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(contact).Error
+}
+
+func (r *contactRepo) ListContacts(ctx context.Context, patientID uuid.UUID, hospitalID uuid.UUID) ([]entities.EmergencyContact, error) {
+	var contacts []entities.EmergencyContact
+	err := r.db.WithContext(ctx).
+		Where("patient_id = ? AND hospital_id = ?", patientID, hospitalID).
+		Order("priority, created_at").
+		Find(&contacts).Error
+	return contacts, err
+}
+
+func (r *contactRepo) GetContact(ctx context.Context, id uuid.UUID, patientID uuid.UUID, hospitalID uuid.UUID) (*entities.EmergencyContact, error) {
+	var contact entities.EmergencyContact
+	err := r.db.WithContext(ctx).
+		Where("id = ? AND patient_id = ? AND hospital_id = ?", id, patientID, hospitalID).
+		First(&contact).Error
+	if err != nil {
+		return nil, err
+	}
+	return &contact, nil
+}
+
+// UpdateContact saves an emergency contact, provided contact.Version is still the stored
+// version, and raises the version. It returns ErrStaleVersion when the version is not current.
+func (r *contactRepo) UpdateContact(ctx context.Context, contact *entities.EmergencyContact) error {
+	return updateVersioned(r.db.WithContext(ctx), &entities.EmergencyContact{}, contact.ID, &contact.Version, contact)
+}
+
+// DeleteContact deletes an emergency contact of the patient at the given version. It returns
+// false when there is no such contact and ErrStaleVersion when the version is not current.
+func (r *contactRepo) DeleteContact(ctx context.Context, id uuid.UUID, patientID uuid.UUID, hospitalID uuid.UUID, version int) (bool, error) {
+	return deleteVersioned(r.db.WithContext(ctx), &entities.EmergencyContact{}, version,
+		"id = ? AND patient_id = ? AND hospital_id = ?", id, patientID, hospitalID)
+}
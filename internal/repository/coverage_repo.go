@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"go-hospital-api/internal/entities"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CoverageRepository interface {
+	Create(ctx context.Context, coverage *entities.Coverage) error
+	ListByPatient(ctx context.Context, patientID uuid.UUID, hospitalID uuid.UUID) ([]entities.Coverage, error)
+	GetByID(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.Coverage, error)
+	UpdateEligibility(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID, status string, message string, checkedAt time.Time) error
+}
+
+type coverageRepo struct {
+	db *gorm.DB
+}
+
+func NewCoverageRepository(db *gorm.DB) CoverageRepository {
+	return &coverageRepo{db: db}
+}
+
+func (r *coverageRepo) Create(ctx context.Context, coverage *entities.Coverage) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(coverage).Error
+}
+
+func (r *coverageRepo) ListByPatient(ctx context.Context, patientID uuid.UUID, hospitalID uuid.UUID) ([]entities.Coverage, error) {
+	var coverages []entities.Coverage
+	err := r.db.WithContext(ctx).
+		Where("patient_id = ? AND hospital_id = ?", patientID, hospitalID).
+		Order("valid_from DESC").
+		Find(&coverages).Error
+	return coverages, err
+}
+
+func (r *coverageRepo) GetByID(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.Coverage, error) {
+	var coverage entities.Coverage
+	if err := r.db.WithContext(ctx).Where("id = ? AND hospital_id = ?", id, hospitalID).First(&coverage).Error; err != nil {
+		return nil, err
+	}
+	return &coverage, nil
+}
+
+func (r *coverageRepo) UpdateEligibility(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID, status string, message string, checkedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&entities.Coverage{}).
+		Where("id = ? AND hospital_id = ?", id, hospitalID).
+		Updates(map[string]interface{}{
+			"eligibility_status":     status,
+			"eligibility_message":    message,
+			"eligibility_checked_at": checkedAt,
+		}).Error
+}
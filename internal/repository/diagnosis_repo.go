@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"go-hospital-api/internal/entities"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DiagnosisRepository interface {
+	UpsertCodes(ctx context.Context, codes []entities.ICD10Code) error
+	GetCode(ctx context.Context, code, edition string) (*entities.ICD10Code, error)
+	SearchCodes(ctx context.Context, query, edition string, limit int) ([]entities.ICD10Code, error)
+	Create(ctx context.Context, diagnosis *entities.Diagnosis) error
+	HasPrimary(ctx context.Context, patientID uuid.UUID, visitDate time.Time) (bool, error)
+	ListByPatient(ctx context.Context, patientID uuid.UUID, hospitalID uuid.UUID, visitDate *time.Time) ([]entities.Diagnosis, error)
+}
+
+type diagnosisRepo struct {
+	db *gorm.DB
+}
+
+func NewDiagnosisRepository(db *gorm.DB) DiagnosisRepository {
+	return &diagnosisRepo{db: db}
+}
+
+// UpsertCodes inserts catalogue rows in batches, overwriting descriptions and billable flags of existing codes
+func (r *diagnosisRepo) UpsertCodes(ctx context.Context, codes []entities.ICD10Code) error {
+	if len(codes) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "code"}, {Name: "edition"}},
+		DoUpdates: clause.AssignmentColumns([]string{"description", "description_th", "billable", "updated_at"}),
+	}).CreateInBatches(codes, 500).Error
+}
+
+func (r *diagnosisRepo) GetCode(ctx context.Context, code, edition string) (*entities.ICD10Code, error) {
+	var c entities.ICD10Code
+	if err := r.db.WithContext(ctx).Where("code = ? AND edition = ?", code, edition).First(&c).Error; err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// SearchCodes matches code prefixes first, then descriptions, for autocomplete
+func (r *diagnosisRepo) SearchCodes(ctx context.Context, query, edition string, limit int) ([]entities.ICD10Code, error) {
+	var codes []entities.ICD10Code
+	q := r.db.WithContext(ctx).Where("edition = ?", edition)
+	if query != "" {
+		q = q.Where("code ILIKE ? OR description ILIKE ? OR description_th ILIKE ?", query+"%", "%"+query+"%", "%"+query+"%").
+			Order(clause.Expr{SQL: "CASE WHEN code ILIKE ? THEN 0 ELSE 1 END", Vars: []interface{}{query + "%"}})
+	}
+	err := q.Order("code").Limit(limit).Find(&codes).Error
+	return codes, err
+}
+
+func (r *diagnosisRepo) Create(ctx context.Context, diagnosis *entities.Diagnosis) error {
+	return r.db.WithContext(ctx).Create(diagnosis).Error
+}
+
+func (r *diagnosisRepo) HasPrimary(ctx context.Context, patientID uuid.UUID, visitDate time.Time) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entities.Diagnosis{}).
+		Where("patient_id = ? AND visit_date = ? AND is_primary", patientID, visitDate).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *diagnosisRepo) ListByPatient(ctx context.Context, patientID uuid.UUID, hospitalID uuid.UUID, visitDate *time.Time) ([]entities.Diagnosis, error) {
+	var diagnoses []entities.Diagnosis
+	q := r.db.WithContext(ctx).Where("patient_id = ? AND hospital_id = ?", patientID, hospitalID)
+	if visitDate != nil {
+		q = q.Where("visit_date = ?", *visitDate)
+	}
+	err := q.Order("visit_date DESC, is_primary DESC, created_at").Find(&diagnoses).Error
+	return diagnoses, err
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-hospital-api/internal/entities"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrAccessAlreadyReviewed = errors.New("emergency access has already been reviewed")
+
+type EmergencyAccessRepository interface {
+	Grant(ctx context.Context, access *entities.EmergencyAccess, events []entities.AuditEvent) error
+	GetByID(ctx context.Context, id uuid.UUID) (*entities.EmergencyAccess, error)
+	ListForHospital(ctx context.Context, hospitalID uuid.UUID, reviewStatus string) ([]entities.EmergencyAccess, error)
+	Review(ctx context.Context, id uuid.UUID, status string, reviewerID uuid.UUID, note string, at time.Time) error
+}
+
+type emergencyAccessRepo struct {
+	db *gorm.DB
+}
+
+func NewEmergencyAccessRepository(db *gorm.DB) EmergencyAccessRepository {
+	return &emergencyAccessRepo{db: db}
+}
+
+// Grant stores the grant together with its audit events, so no access exists without its audit trail
+func (r *emergencyAccessRepo) Grant(ctx context.Context, access *entities.EmergencyAccess, events []entities.AuditEvent) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(clause.Associations).Create(access).Error; err != nil {
+			return err
+		}
+		return tx.Create(&events).Error
+	})
+}
+
+func (r *emergencyAccessRepo) GetByID(ctx context.Context, id uuid.UUID) (*entities.EmergencyAccess, error) {
+	var access entities.EmergencyAccess
+	err := r.db.WithContext(ctx).
+		Preload("Staff").
+		Preload("Patient.Hospital").
+		Preload("Patient.Coverages").
+		Where("id = ?", id).
+		First(&access).Error
+	if err != nil {
+		return nil, err
+	}
+	return &access, nil
+}
+
+// ListForHospital lists grants used by the hospital's staff or on the hospital's patients
+func (r *emergencyAccessRepo) ListForHospital(ctx context.Context, hospitalID uuid.UUID, reviewStatus string) ([]entities.EmergencyAccess, error) {
+	var accesses []entities.EmergencyAccess
+	query := r.db.WithContext(ctx).
+		Preload("Staff").
+		Preload("Patient.Hospital").
+		Where("staff_hospital_id = ? OR patient_hospital_id = ?", hospitalID, hospitalID)
+	if reviewStatus != "" {
+		query = query.Where("review_status = ?", reviewStatus)
+	}
+	err := query.Order("granted_at DESC").Find(&accesses).Error
+	return accesses, err
+}
+
+func (r *emergencyAccessRepo) Review(ctx context.Context, id uuid.UUID, status string, reviewerID uuid.UUID, note string, at time.Time) error {
+	res := r.db.WithContext(ctx).Model(&entities.EmergencyAccess{}).
+		Where("id = ? AND review_status = ?", id, entities.EmergencyReviewPending).
+		Updates(map[string]interface{}{
+			"review_status":  status,
+			"reviewed_by_id": reviewerID,
+			"reviewed_at":    at,
+			"review_note":    note,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrAccessAlreadyReviewed
+	}
+	return nil
+}
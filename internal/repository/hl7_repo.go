@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-hospital-api/internal/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type HL7MessageRepository interface {
+	Create(ctx context.Context, message *entities.HL7Message) error
+	Save(ctx context.Context, message *entities.HL7Message) error
+	GetByID(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.HL7Message, error)
+	FindLatest(ctx context.Context, hospitalID uuid.UUID, sendingApplication, controlID string) (*entities.HL7Message, error)
+	List(ctx context.Context, hospitalID uuid.UUID, status string, limit int) ([]entities.HL7Message, error)
+}
+
+type hl7MessageRepo struct {
+	db *gorm.DB
+}
+
+func NewHL7MessageRepository(db *gorm.DB) HL7MessageRepository {
+	return &hl7MessageRepo{db: db}
+}
+
+func (r *hl7MessageRepo) Create(ctx context.Context, message *entities.HL7Message) error {
+	return conn(ctx, r.db).Create(message).Error
+}
+
+func (r *hl7MessageRepo) Save(ctx context.Context, message *entities.HL7Message) error {
+	return conn(ctx, r.db).Save(message).Error
+}
+
+func (r *hl7MessageRepo) GetByID(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.HL7Message, error) {
+	var message entities.HL7Message
+	if err := conn(ctx, r.db).Where("id = ? AND hospital_id = ?", id, hospitalID).First(&message).Error; err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// FindLatest returns the last message a sender sent with a control ID, or nil when there is none
+func (r *hl7MessageRepo) FindLatest(ctx context.Context, hospitalID uuid.UUID, sendingApplication, controlID string) (*entities.HL7Message, error) {
+	var message entities.HL7Message
+	err := conn(ctx, r.db).
+		Where("hospital_id = ? AND sending_application = ? AND control_id = ?", hospitalID, sendingApplication, controlID).
+		Order("received_at DESC").
+		First(&message).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// List returns the newest messages of a hospital, optionally of one status
+func (r *hl7MessageRepo) List(ctx context.Context, hospitalID uuid.UUID, status string, limit int) ([]entities.HL7Message, error) {
+	var messages []entities.HL7Message
+	query := conn(ctx, r.db).Where("hospital_id = ?", hospitalID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("received_at DESC").Limit(limit).Find(&messages).Error
+	return messages, err
+}
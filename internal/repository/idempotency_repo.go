@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"go-hospital-api/internal/entities"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyRepository interface {
+	Claim(ctx context.Context, record *entities.IdempotencyKey) (*entities.IdempotencyKey, error)
+	Complete(ctx context.Context, record *entities.IdempotencyKey) error
+	Release(ctx context.Context, scope, key string) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type idempotencyRepo struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepository(db *gorm.DB) IdempotencyRepository {
+	return &idempotencyRepo{db: db}
+}
+
+// Claim stores a new key for a request about to run. When the key is already taken and not yet
+// expired, nothing is written and the existing record is returned instead; a nil result means
+// the caller owns the key. The insert is what decides, so two concurrent retries cannot both run.
+func (r *idempotencyRepo) Claim(ctx context.Context, record *entities.IdempotencyKey) (*entities.IdempotencyKey, error) {
+	var existing *entities.IdempotencyKey
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("scope = ? AND key = ? AND expires_at < ?", record.Scope, record.Key, record.CreatedAt).
+			Delete(&entities.IdempotencyKey{}).Error; err != nil {
+			return err
+		}
+		res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+		if res.Error != nil || res.RowsAffected == 1 {
+			return res.Error
+		}
+		var found entities.IdempotencyKey
+		if err := tx.Where("scope = ? AND key = ?", record.Scope, record.Key).First(&found).Error; err != nil {
+			return err
+		}
+		existing = &found
+		return nil
+	})
+	return existing, err
+}
+
+// Complete stores the response of a claimed request, as set on record, for replay
+func (r *idempotencyRepo) Complete(ctx context.Context, record *entities.IdempotencyKey) error {
+	return r.db.WithContext(ctx).Model(&entities.IdempotencyKey{}).
+		Where("scope = ? AND key = ?", record.Scope, record.Key).
+		Updates(map[string]interface{}{
+			"status_code":  record.StatusCode,
+			"content_type": record.ContentType,
+			"e_tag":        record.ETag,
+			"location":     record.Location,
+			"response":     record.Response,
+			"completed_at": time.Now(),
+		}).Error
+}
+
+// Release gives a claimed key up, so the request can be retried with it
+func (r *idempotencyRepo) Release(ctx context.Context, scope, key string) error {
+	return r.db.WithContext(ctx).Where("scope = ? AND key = ? AND completed_at IS NULL", scope, key).
+		Delete(&entities.IdempotencyKey{}).Error
+}
+
+func (r *idempotencyRepo) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).Where("expires_at < ?", before).Delete(&entities.IdempotencyKey{})
+	return res.RowsAffected, res.Error
+}
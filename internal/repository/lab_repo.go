@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-hospital-api/internal/entities"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrResultFinalised = errors.New("result is already final")
+	ErrResultNotFinal  = errors.New("result is not final")
+)
+
+type LabRepository interface {
+	CreateTest(ctx context.Context, test *entities.LabTest) error
+	SearchTests(ctx context.Context, hospitalID uuid.UUID, query string) ([]entities.LabTest, error)
+	GetTests(ctx context.Context, hospitalID uuid.UUID, ids []uuid.UUID) ([]entities.LabTest, error)
+	CreateOrder(ctx context.Context, order *entities.LabOrder) error
+	GetOrder(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.LabOrder, error)
+	ListOrders(ctx context.Context, patientID uuid.UUID, hospitalID uuid.UUID) ([]entities.LabOrder, error)
+	UpdateOrderStatus(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID, version int, from []string, updates map[string]interface{}) (bool, error)
+	AddResult(ctx context.Context, orderID uuid.UUID, result *entities.LabResult) error
+}
+
+type labRepo struct {
+	db *gorm.DB
+}
+
+func NewLabRepository(db *gorm.DB) LabRepository {
+	return &labRepo{db: db}
+}
+
+func (r *labRepo) CreateTest(ctx context.Context, test *entities.LabTest) error {
+	return r.db.WithContext(ctx).Create(test).Error
+}
+
+func (r *labRepo) SearchTests(ctx context.Context, hospitalID uuid.UUID, query string) ([]entities.LabTest, error) {
+	var tests []entities.LabTest
+	q := r.db.WithContext(ctx).Where("hospital_id = ? AND active", hospitalID)
+	if query != "" {
+		q = q.Where("code ILIKE ? OR name ILIKE ?", query+"%", "%"+query+"%")
+	}
+	err := q.Order("code").Find(&tests).Error
+	return tests, err
+}
+
+func (r *labRepo) GetTests(ctx context.Context, hospitalID uuid.UUID, ids []uuid.UUID) ([]entities.LabTest, error) {
+	var tests []entities.LabTest
+	err := r.db.WithContext(ctx).Where("hospital_id = ? AND id IN ? AND active", hospitalID, ids).Find(&tests).Error
+	return tests, err
+}
+
+// CreateOrder inserts the order together with its items
+func (r *labRepo) CreateOrder(ctx context.Context, order *entities.LabOrder) error {
+	return r.db.WithContext(ctx).Omit("Items.LabTest", "Items.Results").Create(order).Error
+}
+
+func (r *labRepo) GetOrder(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.LabOrder, error) {
+	var order entities.LabOrder
+	err := r.db.WithContext(ctx).
+		Preload("Items.LabTest").
+		Preload("Items.Results", func(db *gorm.DB) *gorm.DB { return db.Order("version") }).
+		Where("id = ? AND hospital_id = ?", id, hospitalID).
+		First(&order).Error
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (r *labRepo) ListOrders(ctx context.Context, patientID uuid.UUID, hospitalID uuid.UUID) ([]entities.LabOrder, error) {
+	var orders []entities.LabOrder
+	err := r.db.WithContext(ctx).
+		Preload("Items.LabTest").
+		Preload("Items.Results", func(db *gorm.DB) *gorm.DB { return db.Order("version") }).
+		Where("patient_id = ? AND hospital_id = ?", patientID, hospitalID).
+		Order("created_at DESC").
+		Find(&orders).Error
+	return orders, err
+}
+
+// UpdateOrderStatus applies updates and raises the version only while the order is at version
+// and in one of the given statuses. It reports whether a row changed.
+func (r *labRepo) UpdateOrderStatus(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID, version int, from []string, updates map[string]interface{}) (bool, error) {
+	updates["updated_at"] = time.Now()
+	updates["version"] = gorm.Expr("version + 1")
+	res := r.db.WithContext(ctx).Model(&entities.LabOrder{}).
+		Where("id = ? AND hospital_id = ? AND version = ? AND status IN ?", id, hospitalID, version, from).
+		Updates(updates)
+	return res.RowsAffected > 0, res.Error
+}
+
+// AddResult appends the next result version for an order item. The order row is locked so
+// concurrent entries get distinct versions and are checked against the latest result: an
+// amendment needs a final result to amend, with ErrResultNotFinal, and any other result
+// ErrResultFinalised once there is one. The order is completed once every item has a final or
+// amended result.
+func (r *labRepo) AddResult(ctx context.Context, orderID uuid.UUID, result *entities.LabResult) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var order entities.LabOrder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", orderID).First(&order).Error; err != nil {
+			return err
+		}
+
+		var latest entities.LabResult
+		if err := tx.Where("order_item_id = ?", result.OrderItemID).Order("version DESC").
+			Limit(1).Find(&latest).Error; err != nil {
+			return err
+		}
+		finalised := latest.Status == entities.LabResultFinal || latest.Status == entities.LabResultAmended
+		if result.Status == entities.LabResultAmended && !finalised {
+			return ErrResultNotFinal
+		}
+		if result.Status != entities.LabResultAmended && finalised {
+			return ErrResultFinalised
+		}
+		result.Version = latest.Version + 1
+		if err := tx.Omit(clause.Associations).Create(result).Error; err != nil {
+			return err
+		}
+
+		var pending int64
+		if err := tx.Model(&entities.LabOrderItem{}).
+			Where("order_id = ?", orderID).
+			Where("NOT EXISTS (SELECT 1 FROM lab_results r WHERE r.order_item_id = lab_order_items.id AND r.status IN ?)",
+				[]string{entities.LabResultFinal, entities.LabResultAmended}).
+			Count(&pending).Error; err != nil {
+			return err
+		}
+		if pending == 0 && order.Status != entities.LabOrderCompleted {
+			return tx.Model(&entities.LabOrder{}).Where("id = ?", orderID).
+				Updates(map[string]interface{}{"status": entities.LabOrderCompleted, "version": gorm.Expr("version + 1"), "updated_at": time.Now()}).Error
+		}
+		return nil
+	})
+}
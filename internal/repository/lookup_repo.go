@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"context"
+	"go-hospital-api/internal/entities"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LookupRepository loads the records related to many parents with one query, for the GraphQL
+// loaders. Every method but Hospitals is restricted to the given hospital.
+type LookupRepository interface {
+	Hospitals(ctx context.Context, ids []uuid.UUID) ([]entities.Hospital, error)
+	Staff(ctx context.Context, ids []uuid.UUID, hospitalID uuid.UUID) ([]entities.Staff, error)
+	AdmissionsByPatients(ctx context.Context, patientIDs []uuid.UUID, hospitalID uuid.UUID) ([]entities.Admission, error)
+	DiagnosesByPatients(ctx context.Context, patientIDs []uuid.UUID, hospitalID uuid.UUID) ([]entities.Diagnosis, error)
+}
+
+type lookupRepo struct {
+	db *gorm.DB
+}
+
+func NewLookupRepository(db *gorm.DB) LookupRepository {
+	return &lookupRepo{db: db}
+}
+
+func (r *lookupRepo) Hospitals(ctx context.Context, ids []uuid.UUID) ([]entities.Hospital, error) {
+	var hospitals []entities.Hospital
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&hospitals).Error
+	return hospitals, err
+}
+
+func (r *lookupRepo) Staff(ctx context.Context, ids []uuid.UUID, hospitalID uuid.UUID) ([]entities.Staff, error) {
+	var staff []entities.Staff
+	err := r.db.WithContext(ctx).Where("id IN ? AND hospital_id = ?", ids, hospitalID).Find(&staff).Error
+	return staff, err
+}
+
+// AdmissionsByPatients returns the admissions of the patients with their beds, newest first
+func (r *lookupRepo) AdmissionsByPatients(ctx context.Context, patientIDs []uuid.UUID, hospitalID uuid.UUID) ([]entities.Admission, error) {
+	var admissions []entities.Admission
+	err := r.db.WithContext(ctx).Preload("Bed").
+		Where("patient_id IN ? AND hospital_id = ?", patientIDs, hospitalID).
+		Order("admitted_at DESC").
+		Find(&admissions).Error
+	return admissions, err
+}
+
+// DiagnosesByPatients returns the diagnoses of the patients, latest visit first
+func (r *lookupRepo) DiagnosesByPatients(ctx context.Context, patientIDs []uuid.UUID, hospitalID uuid.UUID) ([]entities.Diagnosis, error) {
+	var diagnoses []entities.Diagnosis
+	err := r.db.WithContext(ctx).
+		Where("patient_id IN ? AND hospital_id = ?", patientIDs, hospitalID).
+		Order("visit_date DESC, is_primary DESC, created_at").
+		Find(&diagnoses).Error
+	return diagnoses, err
+}
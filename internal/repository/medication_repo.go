@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"go-hospital-api/internal/entities"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MedicationRepository interface {
+	UpsertDrugs(ctx context.Context, drugs []entities.Drug) error
+	SearchDrugs(ctx context.Context, hospitalID uuid.UUID, query string, limit int) ([]entities.Drug, error)
+	GetDrug(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.Drug, error)
+	CreateAllergy(ctx context.Context, allergy *entities.PatientAllergy) error
+	ListAllergies(ctx context.Context, patientID uuid.UUID, hospitalID uuid.UUID) ([]entities.PatientAllergy, error)
+	CreatePrescription(ctx context.Context, prescription *entities.Prescription) error
+	GetPrescription(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.Prescription, error)
+	ListPrescriptions(ctx context.Context, patientID uuid.UUID, hospitalID uuid.UUID, statuses []string) ([]entities.Prescription, error)
+	UpdatePrescriptionStatus(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID, version int, from []string, updates map[string]interface{}) (bool, error)
+}
+
+type medicationRepo struct {
+	db *gorm.DB
+}
+
+func NewMedicationRepository(db *gorm.DB) MedicationRepository {
+	return &medicationRepo{db: db}
+}
+
+// UpsertDrugs inserts formulary rows, updating existing codes of the same hospital
+func (r *medicationRepo) UpsertDrugs(ctx context.Context, drugs []entities.Drug) error {
+	if len(drugs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "hospital_id"}, {Name: "code"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "generic_name", "drug_class", "strength", "form", "route", "active", "updated_at"}),
+	}).CreateInBatches(drugs, 500).Error
+}
+
+func (r *medicationRepo) SearchDrugs(ctx context.Context, hospitalID uuid.UUID, query string, limit int) ([]entities.Drug, error) {
+	var drugs []entities.Drug
+	q := r.db.WithContext(ctx).Where("hospital_id = ? AND active", hospitalID)
+	if query != "" {
+		q = q.Where("code ILIKE ? OR name ILIKE ? OR generic_name ILIKE ?", query+"%", "%"+query+"%", "%"+query+"%")
+	}
+	err := q.Order("name").Limit(limit).Find(&drugs).Error
+	return drugs, err
+}
+
+func (r *medicationRepo) GetDrug(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.Drug, error) {
+	var drug entities.Drug
+	if err := r.db.WithContext(ctx).Where("id = ? AND hospital_id = ?", id, hospitalID).First(&drug).Error; err != nil {
+		return nil, err
+	}
+	return &drug, nil
+}
+
+func (r *medicationRepo) CreateAllergy(ctx context.Context, allergy *entities.PatientAllergy) error {
+	return r.db.WithContext(ctx).Create(allergy).Error
+}
+
+func (r *medicationRepo) ListAllergies(ctx context.Context, patientID uuid.UUID, hospitalID uuid.UUID) ([]entities.PatientAllergy, error) {
+	var allergies []entities.PatientAllergy
+	err := r.db.WithContext(ctx).Where("patient_id = ? AND hospital_id = ?", patientID, hospitalID).
+		Order("created_at").Find(&allergies).Error
+	return allergies, err
+}
+
+func (r *medicationRepo) CreatePrescription(ctx context.Context, prescription *entities.Prescription) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(prescription).Error
+}
+
+func (r *medicationRepo) GetPrescription(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.Prescription, error) {
+	var p entities.Prescription
+	if err := r.db.WithContext(ctx).Preload("Drug").Where("id = ? AND hospital_id = ?", id, hospitalID).First(&p).Error; err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *medicationRepo) ListPrescriptions(ctx context.Context, patientID uuid.UUID, hospitalID uuid.UUID, statuses []string) ([]entities.Prescription, error) {
+	var prescriptions []entities.Prescription
+	q := r.db.WithContext(ctx).Preload("Drug").Where("patient_id = ? AND hospital_id = ?", patientID, hospitalID)
+	if len(statuses) > 0 {
+		q = q.Where("status IN ?", statuses)
+	}
+	err := q.Order("created_at DESC").Find(&prescriptions).Error
+	return prescriptions, err
+}
+
+// UpdatePrescriptionStatus applies updates and raises the version only while the prescription
+// is still at version and in one of the given statuses, so concurrent transitions cannot both
+// succeed. It reports whether a row changed.
+func (r *medicationRepo) UpdatePrescriptionStatus(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID, version int, from []string, updates map[string]interface{}) (bool, error) {
+	updates["updated_at"] = time.Now()
+	updates["version"] = gorm.Expr("version + 1")
+	res := r.db.WithContext(ctx).Model(&entities.Prescription{}).
+		Where("id = ? AND hospital_id = ? AND version = ? AND status IN ?", id, hospitalID, version, from).
+		Updates(updates)
+	return res.RowsAffected > 0, res.Error
+}
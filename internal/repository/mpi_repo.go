@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-hospital-api/internal/entities"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrCandidateNotPending = errors.New("match candidate has already been reviewed")
+
+// mpiLockKey serialises changes to the index so that two hospitals registering the same person
+// at the same time end up with one enterprise patient.
+const mpiLockKey = "mpi"
+
+type MPIRepository interface {
+	GetLink(ctx context.Context, patientID uuid.UUID) (*entities.PatientLink, error)
+	FindCandidates(ctx context.Context, patient *entities.Patient, limit int) ([]entities.Patient, error)
+	Link(ctx context.Context, patient *entities.Patient, matchedPatientID *uuid.UUID, method string, score float64) (*entities.PatientLink, error)
+	AddCandidates(ctx context.Context, candidates []entities.MatchCandidate) error
+	ListLinks(ctx context.Context, enterpriseID uuid.UUID) ([]entities.PatientLink, error)
+	ListCandidates(ctx context.Context, hospitalID uuid.UUID, status string) ([]entities.MatchCandidate, error)
+	GetCandidate(ctx context.Context, id uuid.UUID) (*entities.MatchCandidate, error)
+	ResolveCandidate(ctx context.Context, candidate *entities.MatchCandidate, status string, staffID uuid.UUID, at time.Time) error
+}
+
+type mpiRepo struct {
+	db *gorm.DB
+}
+
+func NewMPIRepository(db *gorm.DB) MPIRepository {
+	return &mpiRepo{db: db}
+}
+
+func (r *mpiRepo) GetLink(ctx context.Context, patientID uuid.UUID) (*entities.PatientLink, error) {
+	var link entities.PatientLink
+	if err := r.db.WithContext(ctx).Where("patient_id = ?", patientID).First(&link).Error; err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// FindCandidates returns records of other hospitals that share an identifier, the date of birth
+// or the phone number with the patient. Only these are scored, to keep matching cheap.
+func (r *mpiRepo) FindCandidates(ctx context.Context, patient *entities.Patient, limit int) ([]entities.Patient, error) {
+	block := r.db.Where("1 = 0")
+	if patient.NationalID != "" {
+		block = block.Or("national_id = ?", patient.NationalID)
+	}
+	if patient.PassportID != "" {
+		block = block.Or("passport_id = ?", patient.PassportID)
+	}
+	if patient.DateOfBirth != nil {
+		block = block.Or("date_of_birth = ?", *patient.DateOfBirth)
+	}
+	if patient.PhoneNumber != "" {
+		block = block.Or("phone_number = ?", patient.PhoneNumber)
+	}
+	var patients []entities.Patient
+	err := r.db.WithContext(ctx).
+		Where("id <> ? AND hospital_id <> ?", patient.ID, patient.HospitalID).
+		Where(block).
+		Limit(limit).
+		Find(&patients).Error
+	return patients, err
+}
+
+// Link puts the patient in the enterprise of matchedPatientID, or in a new enterprise when it is
+// nil. A matched patient that is not indexed yet gets the new enterprise too. If the patient is
+// already linked, both enterprises are merged into the matched one.
+func (r *mpiRepo) Link(ctx context.Context, patient *entities.Patient, matchedPatientID *uuid.UUID, method string, score float64) (*entities.PatientLink, error) {
+	var link entities.PatientLink
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", mpiLockKey).Error; err != nil {
+			return err
+		}
+		var existing *entities.PatientLink
+		var current entities.PatientLink
+		if err := tx.Where("patient_id = ?", patient.ID).First(&current).Error; err == nil {
+			existing = &current
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		var enterpriseID uuid.UUID
+		if matchedPatientID != nil {
+			var matched entities.PatientLink
+			err := tx.Where("patient_id = ?", *matchedPatientID).First(&matched).Error
+			switch {
+			case err == nil:
+				enterpriseID = matched.EnterpriseID
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				if existing != nil {
+					enterpriseID = existing.EnterpriseID
+				} else if enterpriseID, err = createEnterprise(tx); err != nil {
+					return err
+				}
+				var other entities.Patient
+				if err := tx.Where("id = ?", *matchedPatientID).First(&other).Error; err != nil {
+					return err
+				}
+				if err := tx.Create(&entities.PatientLink{
+					PatientID: other.ID, EnterpriseID: enterpriseID, HospitalID: other.HospitalID, Method: method, Score: score,
+				}).Error; err != nil {
+					return err
+				}
+			default:
+				return err
+			}
+		}
+
+		if existing != nil {
+			if matchedPatientID == nil || existing.EnterpriseID == enterpriseID {
+				link = *existing
+				return nil
+			}
+			// The patient already belongs to another enterprise: fold it into the matched one
+			if err := tx.Model(&entities.PatientLink{}).
+				Where("enterprise_id = ?", existing.EnterpriseID).
+				Update("enterprise_id", enterpriseID).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("id = ?", existing.EnterpriseID).Delete(&entities.EnterprisePatient{}).Error; err != nil {
+				return err
+			}
+			return tx.Where("patient_id = ?", patient.ID).First(&link).Error
+		}
+
+		if enterpriseID == uuid.Nil {
+			id, err := createEnterprise(tx)
+			if err != nil {
+				return err
+			}
+			enterpriseID = id
+		}
+		link = entities.PatientLink{
+			PatientID: patient.ID, EnterpriseID: enterpriseID, HospitalID: patient.HospitalID, Method: method, Score: score,
+		}
+		return tx.Create(&link).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+func createEnterprise(tx *gorm.DB) (uuid.UUID, error) {
+	enterprise := entities.EnterprisePatient{ID: uuid.New()}
+	if err := tx.Omit(clause.Associations).Create(&enterprise).Error; err != nil {
+		return uuid.Nil, err
+	}
+	return enterprise.ID, nil
+}
+
+// AddCandidates queues uncertain matches; pairs already queued or reviewed are left alone
+func (r *mpiRepo) AddCandidates(ctx context.Context, candidates []entities.MatchCandidate) error {
+	if len(candidates) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).
+		Omit(clause.Associations).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&candidates).Error
+}
+
+func (r *mpiRepo) ListLinks(ctx context.Context, enterpriseID uuid.UUID) ([]entities.PatientLink, error) {
+	var links []entities.PatientLink
+	err := r.db.WithContext(ctx).
+		Preload("Patient.Hospital").
+		Where("enterprise_id = ?", enterpriseID).
+		Order("created_at").
+		Find(&links).Error
+	return links, err
+}
+
+// ListCandidates lists queued matches that involve a patient of the hospital
+func (r *mpiRepo) ListCandidates(ctx context.Context, hospitalID uuid.UUID, status string) ([]entities.MatchCandidate, error) {
+	var candidates []entities.MatchCandidate
+	query := r.db.WithContext(ctx).
+		Preload("Patient.Hospital").
+		Preload("CandidatePatient.Hospital").
+		Joins("JOIN patients p ON p.id = match_candidates.patient_id").
+		Joins("JOIN patients cp ON cp.id = match_candidates.candidate_patient_id").
+		Where("p.hospital_id = ? OR cp.hospital_id = ?", hospitalID, hospitalID)
+	if status != "" {
+		query = query.Where("match_candidates.status = ?", status)
+	}
+	err := query.Order("match_candidates.score DESC").Find(&candidates).Error
+	return candidates, err
+}
+
+func (r *mpiRepo) GetCandidate(ctx context.Context, id uuid.UUID) (*entities.MatchCandidate, error) {
+	var candidate entities.MatchCandidate
+	err := r.db.WithContext(ctx).
+		Preload("Patient.Hospital").
+		Preload("CandidatePatient.Hospital").
+		Where("id = ?", id).
+		First(&candidate).Error
+	if err != nil {
+		return nil, err
+	}
+	return &candidate, nil
+}
+
+func (r *mpiRepo) ResolveCandidate(ctx context.Context, candidate *entities.MatchCandidate, status string, staffID uuid.UUID, at time.Time) error {
+	res := r.db.WithContext(ctx).Model(&entities.MatchCandidate{}).
+		Where("id = ? AND status = ?", candidate.ID, entities.CandidatePending).
+		Updates(map[string]interface{}{"status": status, "reviewed_by_id": staffID, "reviewed_at": at})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrCandidateNotPending
+	}
+	candidate.Status = status
+	candidate.ReviewedByID = &staffID
+	candidate.ReviewedAt = &at
+	return nil
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// newOutboxEvent describes a lifecycle event; it must be written in the transaction of the change
+func newOutboxEvent(hospitalID uuid.UUID, eventType string, subjectID uuid.UUID, data any, at time.Time) (entities.OutboxEvent, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return entities.OutboxEvent{}, err
+	}
+	return entities.OutboxEvent{
+		ID:            uuid.New(),
+		HospitalID:    hospitalID,
+		Type:          eventType,
+		SubjectID:     subjectID,
+		Data:          string(b),
+		CreatedAt:     at,
+		NextAttemptAt: at,
+	}, nil
+}
+
+func addOutboxEvent(tx *gorm.DB, hospitalID uuid.UUID, eventType string, subjectID uuid.UUID, data any, at time.Time) error {
+	event, err := newOutboxEvent(hospitalID, eventType, subjectID, data, at)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&event).Error
+}
+
+func addPatientEvent(tx *gorm.DB, eventType string, p entities.Patient, at time.Time) error {
+	return addOutboxEvent(tx, p.HospitalID, eventType, p.ID, patientEventData(p), at)
+}
+
+func patientEventData(p entities.Patient) dto.PatientEventData {
+	return dto.PatientEventData{
+		PatientID:    p.ID,
+		Version:      p.Version,
+		PatientHN:    p.PatientHN,
+		FirstNameTH:  p.FirstNameTH,
+		MiddleNameTH: p.MiddleNameTH,
+		LastNameTH:   p.LastNameTH,
+		FirstNameEN:  p.FirstNameEN,
+		MiddleNameEN: p.MiddleNameEN,
+		LastNameEN:   p.LastNameEN,
+		DateOfBirth:  p.DateOfBirth,
+		NationalID:   p.NationalID,
+		PassportID:   p.PassportID,
+		PhoneNumber:  p.PhoneNumber,
+		Email:        p.Email,
+		Gender:       p.Gender,
+		MergedIntoID: p.MergedIntoID,
+	}
+}
+
+// addStaffEvent records a staff event; the password hash is never part of it
+func addStaffEvent(tx *gorm.DB, eventType string, s entities.Staff, at time.Time) error {
+	return addOutboxEvent(tx, s.HospitalID, eventType, s.ID, dto.StaffEventData{StaffID: s.ID, Username: s.Username, Role: s.Role}, at)
+}
+
+// OutboxRepository is read by the event dispatcher
+type OutboxRepository interface {
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]entities.OutboxEvent, error)
+	Save(ctx context.Context, event *entities.OutboxEvent) error
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// ClaimDue returns up to limit undispatched events whose next attempt is due, oldest first, and
+// moves that attempt lease into the future, so other servers leave them alone meanwhile.
+func (r *outboxRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]entities.OutboxEvent, error) {
+	var events []entities.OutboxEvent
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("dispatched_at IS NULL AND next_attempt_at <= ?", now).
+			Order("created_at").Limit(limit).
+			Find(&events).Error; err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+		ids := make([]uuid.UUID, len(events))
+		for i, e := range events {
+			ids[i] = e.ID
+		}
+		return tx.Model(&entities.OutboxEvent{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(lease)).Error
+	})
+	return events, err
+}
+
+func (r *outboxRepository) Save(ctx context.Context, event *entities.OutboxEvent) error {
+	return r.db.WithContext(ctx).Save(event).Error
+}
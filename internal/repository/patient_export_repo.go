@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+
+	"gorm.io/gorm"
+)
+
+type PatientExportRepository interface {
+	Count(ctx context.Context, criteria dto.PatientSearchCriteria) (int64, error)
+	Each(ctx context.Context, criteria dto.PatientSearchCriteria, fn func(entities.Patient) error) error
+}
+
+type patientExportRepo struct {
+	db *gorm.DB
+}
+
+func NewPatientExportRepository(db *gorm.DB) PatientExportRepository {
+	return &patientExportRepo{db: db}
+}
+
+func (r *patientExportRepo) Count(ctx context.Context, criteria dto.PatientSearchCriteria) (int64, error) {
+	var n int64
+	err := searchQuery(r.db.WithContext(ctx).Model(&entities.Patient{}), criteria).Count(&n).Error
+	return n, err
+}
+
+// Each passes the patients matching the criteria to fn one at a time, in HN order, while reading
+// them from a single query, so an export never holds more than one patient in memory. It stops
+// at the first error fn returns.
+func (r *patientExportRepo) Each(ctx context.Context, criteria dto.PatientSearchCriteria, fn func(entities.Patient) error) error {
+	db := r.db.WithContext(ctx)
+	rows, err := searchQuery(db.Model(&entities.Patient{}), criteria).Order("patient_hn, id").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var p entities.Patient
+		if err := db.ScanRows(rows, &p); err != nil {
+			return err
+		}
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"go-hospital-api/internal/entities"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PatientImportRepository interface {
+	Create(ctx context.Context, imp *entities.PatientImport) error
+	Save(ctx context.Context, imp *entities.PatientImport) error
+	GetByID(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.PatientImport, error)
+	AddErrors(ctx context.Context, errs []entities.PatientImportError) error
+	ListErrors(ctx context.Context, importID uuid.UUID) ([]entities.PatientImportError, error)
+	FailUnfinished(ctx context.Context, reason string) (int64, error)
+	InsertPatients(ctx context.Context, hospitalID uuid.UUID, patients []entities.Patient, staffID uuid.UUID, dryRun bool) ([]DuplicatePatient, error)
+}
+
+// DuplicatePatient is a patient of a batch whose HN or national ID is already registered
+type DuplicatePatient struct {
+	// Index is the position of the patient in the batch
+	Index int
+	// Column is patient_hn or national_id
+	Column string
+	// RegisteredHN is the HN of the patient the national ID is registered to
+	RegisteredHN string
+}
+
+type patientImportRepo struct {
+	db *gorm.DB
+}
+
+func NewPatientImportRepository(db *gorm.DB) PatientImportRepository {
+	return &patientImportRepo{db: db}
+}
+
+func (r *patientImportRepo) Create(ctx context.Context, imp *entities.PatientImport) error {
+	return r.db.WithContext(ctx).Create(imp).Error
+}
+
+func (r *patientImportRepo) Save(ctx context.Context, imp *entities.PatientImport) error {
+	return r.db.WithContext(ctx).Save(imp).Error
+}
+
+func (r *patientImportRepo) GetByID(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.PatientImport, error) {
+	var imp entities.PatientImport
+	if err := r.db.WithContext(ctx).Where("id = ? AND hospital_id = ?", id, hospitalID).First(&imp).Error; err != nil {
+		return nil, err
+	}
+	return &imp, nil
+}
+
+func (r *patientImportRepo) AddErrors(ctx context.Context, errs []entities.PatientImportError) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).CreateInBatches(errs, 500).Error
+}
+
+func (r *patientImportRepo) ListErrors(ctx context.Context, importID uuid.UUID) ([]entities.PatientImportError, error) {
+	var errs []entities.PatientImportError
+	err := r.db.WithContext(ctx).Where("import_id = ?", importID).Order("row, column_name").Find(&errs).Error
+	return errs, err
+}
+
+// FailUnfinished marks imports that were queued or running when the server stopped as failed
+func (r *patientImportRepo) FailUnfinished(ctx context.Context, reason string) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&entities.PatientImport{}).
+		Where("status IN ?", []string{entities.ImportQueued, entities.ImportRunning}).
+		Updates(map[string]any{"status": entities.ImportFailed, "error": reason, "finished_at": time.Now()})
+	return res.RowsAffected, res.Error
+}
+
+// existingIdentifiers returns which of the HNs are already registered in the hospital, and the
+// HN registered under each of the national IDs that are.
+func existingIdentifiers(tx *gorm.DB, hospitalID uuid.UUID, hns, nationalIDs []string) (map[string]bool, map[string]string, error) {
+	var found []entities.Patient
+	hnSet, nationalIDSet := make(map[string]bool), make(map[string]string)
+	if len(hns) == 0 && len(nationalIDs) == 0 {
+		return hnSet, nationalIDSet, nil
+	}
+	err := tx.Select("patient_hn", "national_id").
+		Where("hospital_id = ?", hospitalID).
+		Where("patient_hn IN ? OR national_id IN ?", hns, nationalIDs).
+		Find(&found).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, p := range found {
+		hnSet[p.PatientHN] = true
+		if p.NationalID != "" {
+			nationalIDSet[p.NationalID] = p.PatientHN
+		}
+	}
+	return hnSet, nationalIDSet, nil
+}
+
+// InsertPatients registers a batch of new patients of the hospital and their first versions in
+// one transaction, so a batch is either imported completely or not at all. Patients whose HN or
+// national ID is already registered are left out and returned. The check runs in the same
+// transaction under the hospital's registration lock, so a concurrent import or referral cannot
+// register the same identifiers in between. A dry run only checks.
+func (r *patientImportRepo) InsertPatients(ctx context.Context, hospitalID uuid.UUID, patients []entities.Patient, staffID uuid.UUID, dryRun bool) ([]DuplicatePatient, error) {
+	hns := make([]string, 0, len(patients))
+	var nationalIDs []string
+	for _, p := range patients {
+		hns = append(hns, p.PatientHN)
+		if p.NationalID != "" {
+			nationalIDs = append(nationalIDs, p.NationalID)
+		}
+	}
+	var duplicates []DuplicatePatient
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockPatientRegistration(tx, hospitalID); err != nil {
+			return err
+		}
+		existingHNs, existingNationalIDs, err := existingIdentifiers(tx, hospitalID, hns, nationalIDs)
+		if err != nil {
+			return err
+		}
+		var fresh []entities.Patient
+		for i, p := range patients {
+			switch hn, taken := existingNationalIDs[p.NationalID]; {
+			case existingHNs[p.PatientHN]:
+				duplicates = append(duplicates, DuplicatePatient{Index: i, Column: "patient_hn"})
+			case taken && p.NationalID != "":
+				duplicates = append(duplicates, DuplicatePatient{Index: i, Column: "national_id", RegisteredHN: hn})
+			default:
+				fresh = append(fresh, p)
+			}
+		}
+		if dryRun || len(fresh) == 0 {
+			return nil
+		}
+
+		now := time.Now()
+		versions := make([]entities.PatientVersion, len(fresh))
+		events := make([]entities.OutboxEvent, len(fresh))
+		for i := range fresh {
+			fresh[i].CreatedAt, fresh[i].UpdatedAt = now, now
+			v, err := newPatientVersion(fresh[i], 1, entities.PatientOpCreate, &staffID, now)
+			if err != nil {
+				return err
+			}
+			versions[i] = v
+			e, err := newOutboxEvent(fresh[i].HospitalID, entities.EventPatientCreated, fresh[i].ID, patientEventData(fresh[i]), now)
+			if err != nil {
+				return err
+			}
+			events[i] = e
+		}
+		if err := tx.Omit(clause.Associations).CreateInBatches(fresh, 500).Error; err != nil {
+			return err
+		}
+		if err := tx.CreateInBatches(versions, 500).Error; err != nil {
+			return err
+		}
+		return tx.CreateInBatches(events, 500).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return duplicates, nil
+}
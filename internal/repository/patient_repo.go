@@ -2,15 +2,40 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"go-hospital-api/internal/entities"
+	"strings"
+	"time"
 
 	"go-hospital-api/internal/dto"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrStaleVersion is returned when a conditional write finds a newer version than the caller's.
+var ErrStaleVersion = errors.New("record version is not current")
+
+// ErrMergeActiveAdmissions is returned when both patients of a merge are currently admitted.
+var ErrMergeActiveAdmissions = errors.New("both patients have an active admission")
+
+// ErrDuplicatePatient is returned when the hospital already has a patient with the HN or national ID.
+var ErrDuplicatePatient = errors.New("a patient with this HN or national ID is already registered")
+
 type PatientRepository interface {
 	Search(ctx context.Context, criteria dto.PatientSearchCriteria) ([]entities.Patient, error)
+	SearchPage(ctx context.Context, criteria dto.PatientSearchCriteria, offset, limit int) ([]entities.Patient, int64, error)
+	GetByID(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.Patient, error)
+	GetByIDAcrossHospitals(ctx context.Context, id uuid.UUID) (*entities.Patient, error)
+	Create(ctx context.Context, patient *entities.Patient, staffID uuid.UUID) error
+	Update(ctx context.Context, patient *entities.Patient, staffID uuid.UUID) error
+	SoftDelete(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID, staffID uuid.UUID, version int) (bool, error)
+	Restore(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID, staffID uuid.UUID) (bool, error)
+	ListDeleted(ctx context.Context, hospitalID uuid.UUID) ([]entities.Patient, error)
+	Merge(ctx context.Context, fromID uuid.UUID, intoID uuid.UUID, hospitalID uuid.UUID, staffID uuid.UUID) error
+	ListVersions(ctx context.Context, patientID uuid.UUID) ([]entities.PatientVersion, error)
 }
 type patientRepo struct {
 	db *gorm.DB
@@ -22,8 +47,34 @@ func NewPatientRepository(db *gorm.DB) PatientRepository {
 
 func (r *patientRepo) Search(ctx context.Context, criteria dto.PatientSearchCriteria) ([]entities.Patient, error) {
 	var patients []entities.Patient
+	err := searchQuery(conn(ctx, r.db), criteria).
+		Preload("Coverages", func(db *gorm.DB) *gorm.DB { return db.Order("valid_from DESC") }).
+		Find(&patients).Error
+	return patients, err
+}
+
+// SearchPage returns at most limit of the patients matching the criteria, in HN order and
+// starting at offset, and how many match in all
+func (r *patientRepo) SearchPage(ctx context.Context, criteria dto.PatientSearchCriteria, offset, limit int) ([]entities.Patient, int64, error) {
+	db := conn(ctx, r.db)
+	var total int64
+	if err := searchQuery(db.Model(&entities.Patient{}), criteria).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	patients := []entities.Patient{}
+	if int64(offset) >= total {
+		return patients, total, nil
+	}
+	err := searchQuery(db, criteria).
+		Preload("Coverages", func(db *gorm.DB) *gorm.DB { return db.Order("valid_from DESC") }).
+		Order("patient_hn, id").Offset(offset).Limit(limit).
+		Find(&patients).Error
+	return patients, total, err
+}
 
-	query := r.db.WithContext(ctx).Where("hospital_id = ?", criteria.HospitalID)
+// searchQuery narrows db to the hospital's patients that match every criterion given
+func searchQuery(db *gorm.DB, criteria dto.PatientSearchCriteria) *gorm.DB {
+	query := db.Where("hospital_id = ?", criteria.HospitalID)
 
 	if criteria.NationalID != nil && *criteria.NationalID != "" {
 		query = query.Where("national_id = ?", *criteria.NationalID)
@@ -49,7 +100,326 @@ func (r *patientRepo) Search(ctx context.Context, criteria dto.PatientSearchCrit
 	if criteria.Email != nil && *criteria.Email != "" {
 		query = query.Where("email ILIKE ?", "%"+*criteria.Email+"%")
 	}
+	if criteria.Name != nil {
+		for _, word := range strings.Fields(*criteria.Name) {
+			pattern := "%" + word + "%"
+			query = query.Where("(first_name_th ILIKE ? OR middle_name_th ILIKE ? OR last_name_th ILIKE ? OR first_name_en ILIKE ? OR middle_name_en ILIKE ? OR last_name_en ILIKE ?)",
+				pattern, pattern, pattern, pattern, pattern, pattern)
+		}
+	}
+	if criteria.PatientHN != nil && *criteria.PatientHN != "" {
+		query = query.Where("patient_hn = ?", *criteria.PatientHN)
+	}
+	if criteria.Identifier != nil && *criteria.Identifier != "" {
+		query = query.Where("(patient_hn = ? OR national_id = ? OR passport_id = ?)", *criteria.Identifier, *criteria.Identifier, *criteria.Identifier)
+	}
+	if criteria.Gender != nil && *criteria.Gender != "" {
+		query = query.Where("gender = ?", *criteria.Gender)
+	}
+	if (criteria.Province != nil && *criteria.Province != "") || (criteria.District != nil && *criteria.District != "") {
+		addresses := db.Session(&gorm.Session{NewDB: true}).Model(&entities.PatientAddress{}).Select("patient_id")
+		if criteria.Province != nil && *criteria.Province != "" {
+			addresses = addresses.Where("province = ?", *criteria.Province)
+		}
+		if criteria.District != nil && *criteria.District != "" {
+			addresses = addresses.Where("district = ?", *criteria.District)
+		}
+		query = query.Where("id IN (?)", addresses)
+	}
+	return query
+}
+
+// GetByID returns the patient, with its coverages, only when it belongs to the given hospital
+func (r *patientRepo) GetByID(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.Patient, error) {
+	var patient entities.Patient
+	if err := conn(ctx, r.db).Preload("Coverages").Where("id = ? AND hospital_id = ?", id, hospitalID).First(&patient).Error; err != nil {
+		return nil, err
+	}
+	return &patient, nil
+}
+
+// GetByIDAcrossHospitals ignores hospital scoping. It is reserved for sanctioned cross-hospital
+// access such as break-the-glass, whose callers must audit every use.
+func (r *patientRepo) GetByIDAcrossHospitals(ctx context.Context, id uuid.UUID) (*entities.Patient, error) {
+	var patient entities.Patient
+	if err := conn(ctx, r.db).Preload("Coverages").Where("id = ?", id).First(&patient).Error; err != nil {
+		return nil, err
+	}
+	return &patient, nil
+}
+
+// Create registers a patient and records its first version
+func (r *patientRepo) Create(ctx context.Context, patient *entities.Patient, staffID uuid.UUID) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		return createPatient(tx, patient, staffID)
+	})
+}
+
+// createPatient inserts a patient with its first version and its patient.created event, inside the
+// caller's transaction
+func createPatient(tx *gorm.DB, patient *entities.Patient, staffID uuid.UUID) error {
+	if err := tx.Omit(clause.Associations).Create(patient).Error; err != nil {
+		return err
+	}
+	if err := recordPatientVersion(tx, nil, *patient, entities.PatientOpCreate, &staffID, patient.CreatedAt); err != nil {
+		return err
+	}
+	return addPatientEvent(tx, entities.EventPatientCreated, *patient, patient.CreatedAt)
+}
+
+// lockPatientRegistration serialises the transactions that check a hospital's patient
+// identifiers before registering patients, until the transaction ends
+func lockPatientRegistration(tx *gorm.DB, hospitalID uuid.UUID) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "patient_registration:"+hospitalID.String()).Error
+}
+
+// checkPatientIdentifiers returns ErrDuplicatePatient when a patient of the hospital already has
+// the HN or the national ID. Callers run it in the transaction that registers the patient; it
+// holds the hospital's registration lock from then on.
+func checkPatientIdentifiers(tx *gorm.DB, hospitalID uuid.UUID, hn, nationalID string) error {
+	if err := lockPatientRegistration(tx, hospitalID); err != nil {
+		return err
+	}
+	query := tx.Model(&entities.Patient{}).Where("hospital_id = ?", hospitalID)
+	if nationalID != "" {
+		query = query.Where(tx.Where("patient_hn = ?", hn).Or("national_id = ?", nationalID))
+	} else {
+		query = query.Where("patient_hn = ?", hn)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrDuplicatePatient
+	}
+	return nil
+}
+
+// Update saves the versioned fields of a patient, provided patient.Version is still the stored
+// version, raises the version and appends the new state to the history. The version check is part
+// of the UPDATE itself, so concurrent writers cannot both succeed.
+func (r *patientRepo) Update(ctx context.Context, patient *entities.Patient, staffID uuid.UUID) error {
+	expected := patient.Version
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var before entities.Patient
+		if err := tx.Where("id = ? AND hospital_id = ?", patient.ID, patient.HospitalID).First(&before).Error; err != nil {
+			return err
+		}
+		patient.UpdatedAt = time.Now()
+		patient.Version = expected + 1
+		res := tx.Model(&entities.Patient{}).Where("id = ? AND version = ?", patient.ID, expected).
+			Select(append([]string{"version"}, patientVersionedColumns...)).
+			Updates(patient)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrStaleVersion
+		}
+		if err := recordPatientVersion(tx, &before, *patient, entities.PatientOpUpdate, &staffID, patient.UpdatedAt); err != nil {
+			return err
+		}
+		return addPatientEvent(tx, entities.EventPatientUpdated, *patient, patient.UpdatedAt)
+	})
+	if err != nil {
+		patient.Version = expected
+	}
+	return err
+}
+
+// SoftDelete deletes a patient of the hospital at the given version. It returns false when there
+// is no such patient and ErrStaleVersion when the version is not current.
+func (r *patientRepo) SoftDelete(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID, staffID uuid.UUID, version int) (bool, error) {
+	deleted := false
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var patient entities.Patient
+		if err := tx.Where("id = ? AND hospital_id = ?", id, hospitalID).First(&patient).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			return err
+		}
+		now := time.Now()
+		res := tx.Model(&entities.Patient{}).Where("id = ? AND version = ?", id, version).
+			Updates(map[string]interface{}{"deleted_at": now, "version": gorm.Expr("version + 1"), "updated_at": now})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrStaleVersion
+		}
+		deleted = true
+		if err := recordPatientVersion(tx, &patient, patient, entities.PatientOpDelete, &staffID, now); err != nil {
+			return err
+		}
+		patient.Version++
+		return addPatientEvent(tx, entities.EventPatientDeleted, patient, now)
+	})
+	return deleted, err
+}
+
+// Restore undeletes a patient that has not been anonymised by the retention purge yet
+func (r *patientRepo) Restore(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID, staffID uuid.UUID) (bool, error) {
+	restored := false
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		res := tx.Unscoped().Model(&entities.Patient{}).
+			Where("id = ? AND hospital_id = ? AND deleted_at IS NOT NULL AND anonymised_at IS NULL AND merged_into_id IS NULL", id, hospitalID).
+			Updates(map[string]interface{}{"deleted_at": nil, "version": gorm.Expr("version + 1"), "updated_at": now})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		restored = true
+		var patient entities.Patient
+		if err := tx.Where("id = ?", id).First(&patient).Error; err != nil {
+			return err
+		}
+		if err := recordPatientVersion(tx, &patient, patient, entities.PatientOpRestore, &staffID, now); err != nil {
+			return err
+		}
+		return addPatientEvent(tx, entities.EventPatientRestored, patient, now)
+	})
+	return restored, err
+}
 
-	err := query.Find(&patients).Error
+// ListDeleted returns the hospital's deleted patients that can still be restored
+func (r *patientRepo) ListDeleted(ctx context.Context, hospitalID uuid.UUID) ([]entities.Patient, error) {
+	var patients []entities.Patient
+	err := conn(ctx, r.db).Unscoped().
+		Where("hospital_id = ? AND deleted_at IS NOT NULL AND anonymised_at IS NULL AND merged_into_id IS NULL", hospitalID).
+		Order("deleted_at DESC").
+		Find(&patients).Error
 	return patients, err
 }
+
+// Merge moves the records of a duplicate patient to the surviving one and deletes the duplicate.
+// Addresses, granted consents and the MPI link only move when the survivor has none of the same
+// kind; the rest stay with the deleted duplicate. Its history is kept and ends with a merge version.
+func (r *patientRepo) Merge(ctx context.Context, fromID uuid.UUID, intoID uuid.UUID, hospitalID uuid.UUID, staffID uuid.UUID) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var from entities.Patient
+		if err := tx.Where("id = ? AND hospital_id = ?", fromID, hospitalID).First(&from).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id = ? AND hospital_id = ?", intoID, hospitalID).First(&entities.Patient{}).Error; err != nil {
+			return err
+		}
+		var admitted int64
+		if err := tx.Model(&entities.Admission{}).
+			Where("patient_id IN ? AND status = ?", []uuid.UUID{fromID, intoID}, entities.AdmissionActive).
+			Distinct("patient_id").Count(&admitted).Error; err != nil {
+			return err
+		}
+		if admitted > 1 {
+			return ErrMergeActiveAdmissions
+		}
+
+		for _, model := range []interface{}{
+			&entities.LabOrder{}, &entities.Admission{}, &entities.Charge{}, &entities.Invoice{}, &entities.Payment{},
+			&entities.Diagnosis{}, &entities.PatientAllergy{}, &entities.Prescription{}, &entities.Coverage{},
+			&entities.EmergencyContact{}, &entities.EmergencyAccess{}, &entities.Referral{},
+		} {
+			if err := tx.Model(model).Where("patient_id = ?", fromID).Update("patient_id", intoID).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&entities.Referral{}).Where("linked_patient_id = ?", fromID).Update("linked_patient_id", intoID).Error; err != nil {
+			return err
+		}
+		survivorAddresses := tx.Model(&entities.PatientAddress{}).Select("type").Where("patient_id = ?", intoID)
+		if err := tx.Model(&entities.PatientAddress{}).
+			Where("patient_id = ? AND type NOT IN (?)", fromID, survivorAddresses).
+			Update("patient_id", intoID).Error; err != nil {
+			return err
+		}
+		survivorConsents := tx.Model(&entities.Consent{}).Select("purpose").Where("patient_id = ? AND status = ?", intoID, entities.ConsentGranted)
+		if err := tx.Model(&entities.Consent{}).
+			Where("patient_id = ? AND (status <> ? OR purpose NOT IN (?))", fromID, entities.ConsentGranted, survivorConsents).
+			Update("patient_id", intoID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&entities.PatientLink{}).
+			Where("patient_id = ? AND NOT EXISTS (?)", fromID, tx.Model(&entities.PatientLink{}).Select("1").Where("patient_id = ?", intoID)).
+			Update("patient_id", intoID).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("patient_id = ? OR candidate_patient_id = ?", fromID, fromID).Delete(&entities.MatchCandidate{}).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&entities.Patient{}).Where("id = ?", fromID).
+			Updates(map[string]interface{}{"deleted_at": now, "merged_into_id": intoID, "version": gorm.Expr("version + 1"), "updated_at": now}).Error; err != nil {
+			return err
+		}
+		if err := recordPatientVersion(tx, &from, from, entities.PatientOpMerge, &staffID, now); err != nil {
+			return err
+		}
+		from.Version++
+		from.MergedIntoID = &intoID
+		return addPatientEvent(tx, entities.EventPatientMerged, from, now)
+	})
+}
+
+// ListVersions returns the history of a patient, oldest version first
+func (r *patientRepo) ListVersions(ctx context.Context, patientID uuid.UUID) ([]entities.PatientVersion, error) {
+	var versions []entities.PatientVersion
+	err := conn(ctx, r.db).Where("patient_id = ?", patientID).Order("version").Find(&versions).Error
+	return versions, err
+}
+
+// patientVersionedColumns are the columns an update may change; they mirror entities.PatientSnapshot
+var patientVersionedColumns = []string{
+	"first_name_th", "middle_name_th", "last_name_th",
+	"first_name_en", "middle_name_en", "last_name_en",
+	"date_of_birth", "patient_hn", "national_id", "passport_id",
+	"phone_number", "email", "gender", "updated_at",
+}
+
+// recordPatientVersion appends the state after a change to the patient's history. A patient
+// without any version yet (created before versioning) first gets its previous state recorded
+// as a baseline, so the change itself can be diffed.
+func recordPatientVersion(tx *gorm.DB, before *entities.Patient, after entities.Patient, op string, staffID *uuid.UUID, at time.Time) error {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "patient_version:"+after.ID.String()).Error; err != nil {
+		return err
+	}
+	var last int
+	if err := tx.Model(&entities.PatientVersion{}).
+		Where("patient_id = ?", after.ID).
+		Select("COALESCE(MAX(version), 0)").Scan(&last).Error; err != nil {
+		return err
+	}
+	if last == 0 && before != nil {
+		last++
+		if err := createPatientVersion(tx, *before, last, entities.PatientOpBaseline, nil, before.UpdatedAt); err != nil {
+			return err
+		}
+	}
+	return createPatientVersion(tx, after, last+1, op, staffID, at)
+}
+
+func createPatientVersion(tx *gorm.DB, patient entities.Patient, version int, op string, staffID *uuid.UUID, at time.Time) error {
+	v, err := newPatientVersion(patient, version, op, staffID, at)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&v).Error
+}
+
+func newPatientVersion(patient entities.Patient, version int, op string, staffID *uuid.UUID, at time.Time) (entities.PatientVersion, error) {
+	data, err := json.Marshal(patient.Snapshot())
+	if err != nil {
+		return entities.PatientVersion{}, err
+	}
+	return entities.PatientVersion{
+		ID:          uuid.New(),
+		PatientID:   patient.ID,
+		Version:     version,
+		HospitalID:  patient.HospitalID,
+		Operation:   op,
+		Data:        string(data),
+		ChangedByID: staffID,
+		ChangedAt:   at,
+	}, nil
+}
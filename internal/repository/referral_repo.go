@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-hospital-api/internal/entities"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrReferralNotPending = errors.New("referral is no longer pending")
+
+// ReferralResponse is the target hospital's answer to a pending referral.
+type ReferralResponse struct {
+	Status          string
+	StaffID         uuid.UUID
+	Note            string
+	At              time.Time
+	AccessExpiresAt *time.Time
+	// LinkedPatient, when set, is registered in the target hospital in the same transaction. Respond
+	// returns ErrDuplicatePatient when its HN or national ID is already registered there.
+	LinkedPatient *entities.Patient
+}
+
+type ReferralRepository interface {
+	Create(ctx context.Context, referral *entities.Referral) error
+	GetByID(ctx context.Context, id uuid.UUID) (*entities.Referral, error)
+	ListByHospital(ctx context.Context, hospitalID uuid.UUID, direction string, status string) ([]entities.Referral, error)
+	Respond(ctx context.Context, id uuid.UUID, targetHospitalID uuid.UUID, resp ReferralResponse) error
+	Cancel(ctx context.Context, id uuid.UUID, sourceHospitalID uuid.UUID) error
+	HospitalExists(ctx context.Context, id uuid.UUID) (bool, error)
+}
+
+type referralRepo struct {
+	db *gorm.DB
+}
+
+func NewReferralRepository(db *gorm.DB) ReferralRepository {
+	return &referralRepo{db: db}
+}
+
+func (r *referralRepo) Create(ctx context.Context, referral *entities.Referral) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(referral).Error
+}
+
+// GetByID loads a referral regardless of hospital; callers check that they are its source or target
+func (r *referralRepo) GetByID(ctx context.Context, id uuid.UUID) (*entities.Referral, error) {
+	var referral entities.Referral
+	err := r.db.WithContext(ctx).
+		Preload("Patient.Coverages").
+		Preload("SourceHospital").
+		Preload("TargetHospital").
+		Where("id = ?", id).
+		First(&referral).Error
+	if err != nil {
+		return nil, err
+	}
+	return &referral, nil
+}
+
+// ListByHospital lists referrals sent by (outgoing) or to (incoming) a hospital
+func (r *referralRepo) ListByHospital(ctx context.Context, hospitalID uuid.UUID, direction string, status string) ([]entities.Referral, error) {
+	var referrals []entities.Referral
+	query := r.db.WithContext(ctx).Preload("Patient").Preload("SourceHospital").Preload("TargetHospital")
+	if direction == "outgoing" {
+		query = query.Where("source_hospital_id = ?", hospitalID)
+	} else {
+		query = query.Where("target_hospital_id = ?", hospitalID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("created_at DESC").Find(&referrals).Error
+	return referrals, err
+}
+
+func (r *referralRepo) Respond(ctx context.Context, id uuid.UUID, targetHospitalID uuid.UUID, resp ReferralResponse) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{
+			"status":            resp.Status,
+			"responded_by_id":   resp.StaffID,
+			"responded_at":      resp.At,
+			"response_note":     resp.Note,
+			"access_expires_at": resp.AccessExpiresAt,
+		}
+		if resp.LinkedPatient != nil {
+			linked := resp.LinkedPatient
+			if err := checkPatientIdentifiers(tx, linked.HospitalID, linked.PatientHN, linked.NationalID); err != nil {
+				return err
+			}
+			linked.CreatedAt, linked.UpdatedAt = resp.At, resp.At
+			if err := createPatient(tx, linked, resp.StaffID); err != nil {
+				return err
+			}
+			updates["linked_patient_id"] = resp.LinkedPatient.ID
+		}
+		res := tx.Model(&entities.Referral{}).
+			Where("id = ? AND target_hospital_id = ? AND status = ?", id, targetHospitalID, entities.ReferralPending).
+			Updates(updates)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrReferralNotPending
+		}
+		return nil
+	})
+}
+
+func (r *referralRepo) Cancel(ctx context.Context, id uuid.UUID, sourceHospitalID uuid.UUID) error {
+	res := r.db.WithContext(ctx).Model(&entities.Referral{}).
+		Where("id = ? AND source_hospital_id = ? AND status = ?", id, sourceHospitalID, entities.ReferralPending).
+		Update("status", entities.ReferralCancelled)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrReferralNotPending
+	}
+	return nil
+}
+
+func (r *referralRepo) HospitalExists(ctx context.Context, id uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&entities.Hospital{}).Where("id = ?", id).Count(&count).Error
+	return count > 0, err
+}
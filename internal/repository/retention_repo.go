@@ -0,0 +1,275 @@
+package repository
+
+import (
+	"context"
+	"go-hospital-api/internal/entities"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RetentionRepository interface {
+	GetPolicy(ctx context.Context, hospitalID uuid.UUID) (*entities.RetentionPolicy, error)
+	SavePolicy(ctx context.Context, policy *entities.RetentionPolicy) error
+	Hospitals(ctx context.Context) ([]entities.Hospital, error)
+	ExpiredPatients(ctx context.Context, hospitalID uuid.UUID, deletedBefore time.Time) ([]entities.Patient, error)
+	AnonymisePatient(ctx context.Context, id uuid.UUID, at time.Time) error
+	PurgePatient(ctx context.Context, id uuid.UUID) error
+	ExpiredStaff(ctx context.Context, hospitalID uuid.UUID, deletedBefore time.Time) ([]entities.Staff, error)
+	AnonymiseStaff(ctx context.Context, id uuid.UUID, at time.Time) error
+	DeleteHospital(ctx context.Context, id uuid.UUID) (bool, error)
+	RestoreHospital(ctx context.Context, id uuid.UUID) (*entities.Hospital, error)
+}
+
+type retentionRepo struct {
+	db *gorm.DB
+}
+
+func NewRetentionRepository(db *gorm.DB) RetentionRepository {
+	return &retentionRepo{db: db}
+}
+
+func (r *retentionRepo) GetPolicy(ctx context.Context, hospitalID uuid.UUID) (*entities.RetentionPolicy, error) {
+	var policy entities.RetentionPolicy
+	if err := r.db.WithContext(ctx).Where("hospital_id = ?", hospitalID).First(&policy).Error; err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// SavePolicy stores the policy, provided policy.Version is still the version of the stored
+// policy, or 1 when the hospital never set one, and raises the version. It returns
+// ErrStaleVersion when the version is not current.
+func (r *retentionRepo) SavePolicy(ctx context.Context, policy *entities.RetentionPolicy) error {
+	db := r.db.WithContext(ctx)
+	expected := policy.Version
+	policy.Version = expected + 1
+	res := db.Model(&entities.RetentionPolicy{}).Where("hospital_id = ? AND version = ?", policy.HospitalID, expected).
+		Select("retention_years", "action", "updated_by_id", "updated_at", "version").
+		Updates(policy)
+	if res.Error == nil && res.RowsAffected == 0 && expected == 1 {
+		// The default policy; a concurrent first save makes this insert a no-op
+		res = db.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(policy)
+	}
+	if res.Error == nil && res.RowsAffected == 0 {
+		res.Error = ErrStaleVersion
+	}
+	if res.Error != nil {
+		policy.Version = expected
+	}
+	return res.Error
+}
+
+// Hospitals includes deleted hospitals, whose records are still subject to retention
+func (r *retentionRepo) Hospitals(ctx context.Context) ([]entities.Hospital, error) {
+	var hospitals []entities.Hospital
+	err := r.db.WithContext(ctx).Unscoped().Order("name").Find(&hospitals).Error
+	return hospitals, err
+}
+
+// ExpiredPatients returns patients deleted before the cut-off that still hold personal data
+func (r *retentionRepo) ExpiredPatients(ctx context.Context, hospitalID uuid.UUID, deletedBefore time.Time) ([]entities.Patient, error) {
+	var patients []entities.Patient
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("hospital_id = ? AND deleted_at < ? AND anonymised_at IS NULL", hospitalID, deletedBefore).
+		Order("deleted_at").
+		Find(&patients).Error
+	return patients, err
+}
+
+// AnonymisePatient strips everything that identifies the patient but keeps the clinical and
+// billing records for statistics. The date of birth is reduced to the year. The change history
+// is replaced by a single anonymised version, as earlier versions hold the personal data, and the
+// raw text of the patient's HL7 messages is cleared.
+func (r *retentionRepo) AnonymisePatient(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var patient entities.Patient
+		if err := tx.Unscoped().Where("id = ?", id).First(&patient).Error; err != nil {
+			return err
+		}
+		var birthYear interface{}
+		if patient.DateOfBirth != nil {
+			birthYear = time.Date(patient.DateOfBirth.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+		}
+		err := tx.Unscoped().Model(&entities.Patient{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"first_name_th":  "",
+			"middle_name_th": "",
+			"last_name_th":   "",
+			"first_name_en":  "",
+			"middle_name_en": "",
+			"last_name_en":   "",
+			"date_of_birth":  birthYear,
+			"patient_hn":     "",
+			"national_id":    "",
+			"passport_id":    "",
+			"phone_number":   "",
+			"email":          "",
+			"anonymised_at":  at,
+			"version":        gorm.Expr("version + 1"),
+			"updated_at":     at,
+		}).Error
+		if err != nil {
+			return err
+		}
+		for _, model := range []interface{}{&entities.PatientAddress{}, &entities.EmergencyContact{}, &entities.PatientLink{}, &entities.PatientVersion{}} {
+			if err := tx.Where("patient_id = ?", id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("patient_id = ? OR candidate_patient_id = ?", id, id).Delete(&entities.MatchCandidate{}).Error; err != nil {
+			return err
+		}
+		if err := removePatientCopies(tx, patient); err != nil {
+			return err
+		}
+		if err := tx.Model(&entities.HL7Message{}).Where("patient_id = ?", id).Update("raw", "").Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("id = ?", id).First(&patient).Error; err != nil {
+			return err
+		}
+		return recordPatientVersion(tx, nil, patient, entities.PatientOpAnonymise, nil, at)
+	})
+}
+
+// PurgePatient permanently removes a patient with all of its records. Audit events are kept.
+func (r *retentionRepo) PurgePatient(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var patient entities.Patient
+		if err := tx.Unscoped().Where("id = ?", id).First(&patient).Error; err != nil {
+			return err
+		}
+		if err := removePatientCopies(tx, patient); err != nil {
+			return err
+		}
+		labOrders := tx.Model(&entities.LabOrder{}).Select("id").Where("patient_id = ?", id)
+		labItems := tx.Model(&entities.LabOrderItem{}).Select("id").Where("order_id IN (?)", labOrders)
+		admissions := tx.Model(&entities.Admission{}).Select("id").Where("patient_id = ?", id)
+		invoices := tx.Model(&entities.Invoice{}).Select("id").Where("patient_id = ?", id)
+
+		steps := []struct {
+			model interface{}
+			query interface{}
+			args  []interface{}
+		}{
+			{&entities.LabResult{}, "order_item_id IN (?)", []interface{}{labItems}},
+			{&entities.LabOrderItem{}, "order_id IN (?)", []interface{}{labOrders}},
+			{&entities.LabOrder{}, "patient_id = ?", []interface{}{id}},
+			{&entities.AdmissionMovement{}, "admission_id IN (?)", []interface{}{admissions}},
+			{&entities.Admission{}, "patient_id = ?", []interface{}{id}},
+			{&entities.InvoiceLine{}, "invoice_id IN (?)", []interface{}{invoices}},
+			{&entities.Payment{}, "patient_id = ?", []interface{}{id}},
+			{&entities.Charge{}, "patient_id = ?", []interface{}{id}},
+			{&entities.Invoice{}, "patient_id = ?", []interface{}{id}},
+			{&entities.Diagnosis{}, "patient_id = ?", []interface{}{id}},
+			{&entities.PatientAllergy{}, "patient_id = ?", []interface{}{id}},
+			{&entities.Prescription{}, "patient_id = ?", []interface{}{id}},
+			{&entities.Coverage{}, "patient_id = ?", []interface{}{id}},
+			{&entities.PatientAddress{}, "patient_id = ?", []interface{}{id}},
+			{&entities.EmergencyContact{}, "patient_id = ?", []interface{}{id}},
+			{&entities.Consent{}, "patient_id = ?", []interface{}{id}},
+			{&entities.EmergencyAccess{}, "patient_id = ?", []interface{}{id}},
+			{&entities.PatientLink{}, "patient_id = ?", []interface{}{id}},
+			{&entities.MatchCandidate{}, "patient_id = ? OR candidate_patient_id = ?", []interface{}{id, id}},
+			{&entities.Referral{}, "patient_id = ?", []interface{}{id}},
+			{&entities.HL7Message{}, "patient_id = ?", []interface{}{id}},
+			{&entities.PatientVersion{}, "patient_id = ?", []interface{}{id}},
+		}
+		for _, step := range steps {
+			if err := tx.Where(step.query, step.args...).Delete(step.model).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Model(&entities.Referral{}).Where("linked_patient_id = ?", id).Update("linked_patient_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id = ?", id).Delete(&entities.Patient{}).Error
+	})
+}
+
+// removePatientCopies deletes the copies of the patient's personal data kept outside its records:
+// its events with their webhook deliveries, the stored responses of idempotent requests about it
+// and the rows of import error reports that hold its identifiers.
+func removePatientCopies(tx *gorm.DB, patient entities.Patient) error {
+	events := tx.Model(&entities.OutboxEvent{}).Select("id").Where("subject_id = ?", patient.ID)
+	if err := tx.Where("event_id IN (?)", events).Delete(&entities.WebhookDelivery{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("subject_id = ?", patient.ID).Delete(&entities.OutboxEvent{}).Error; err != nil {
+		return err
+	}
+
+	responses := tx.Where("path LIKE ?", "%"+patient.ID.String()+"%").Or("response LIKE ?", "%"+patient.ID.String()+"%")
+	// HNs are too short to look for in a response body; national IDs and passports are not
+	for _, v := range []string{patient.NationalID, patient.PassportID} {
+		if v != "" {
+			responses = responses.Or("response LIKE ?", "%"+v+"%")
+		}
+	}
+	if err := tx.Where(responses).Delete(&entities.IdempotencyKey{}).Error; err != nil {
+		return err
+	}
+
+	var identifiers []string
+	for _, v := range []string{patient.PatientHN, patient.NationalID, patient.PassportID} {
+		if v != "" {
+			identifiers = append(identifiers, v)
+		}
+	}
+	if len(identifiers) == 0 {
+		return nil
+	}
+	imports := tx.Model(&entities.PatientImport{}).Select("id").Where("hospital_id = ?", patient.HospitalID)
+	return tx.Where("import_id IN (?)", imports).
+		Where(tx.Where("patient_hn IN ?", identifiers).Or("value IN ?", identifiers)).
+		Delete(&entities.PatientImportError{}).Error
+}
+
+func (r *retentionRepo) ExpiredStaff(ctx context.Context, hospitalID uuid.UUID, deletedBefore time.Time) ([]entities.Staff, error) {
+	var staff []entities.Staff
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("hospital_id = ? AND deleted_at < ? AND anonymised_at IS NULL", hospitalID, deletedBefore).
+		Order("deleted_at").
+		Find(&staff).Error
+	return staff, err
+}
+
+// AnonymiseStaff releases the username and drops the credentials. Staff rows are never purged
+// because clinical records keep pointing at their authors.
+func (r *retentionRepo) AnonymiseStaff(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Unscoped().Model(&entities.Staff{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"username":      "deleted-" + id.String(),
+		"password_hash": "",
+		"anonymised_at": at,
+		"version":       gorm.Expr("version + 1"),
+		"updated_at":    at,
+	}).Error
+}
+
+// DeleteHospital soft deletes the hospital; it reports false when there is no such hospital or it
+// is already deleted
+func (r *retentionRepo) DeleteHospital(ctx context.Context, id uuid.UUID) (bool, error) {
+	res := r.db.WithContext(ctx).Where("id = ?", id).Delete(&entities.Hospital{})
+	return res.RowsAffected > 0, res.Error
+}
+
+// RestoreHospital undoes the deletion of a hospital and returns it, or gorm.ErrRecordNotFound
+// when the hospital is not deleted
+func (r *retentionRepo) RestoreHospital(ctx context.Context, id uuid.UUID) (*entities.Hospital, error) {
+	db := r.db.WithContext(ctx)
+	res := db.Unscoped().Model(&entities.Hospital{}).Where("id = ? AND deleted_at IS NOT NULL", id).
+		Updates(map[string]interface{}{"deleted_at": nil, "updated_at": time.Now()})
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	var hospital entities.Hospital
+	if err := db.First(&hospital, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &hospital, nil
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"errors"
 	"go-hospital-api/internal/entities"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
@@ -12,6 +13,10 @@ type StaffRepository interface {
 	Create(staff *entities.Staff) error
 	GetByUsername(username string, hospitalID uuid.UUID) (*entities.Staff, error)
 	GetHospitalIDByStaffID(staffID string) (uuid.UUID, error)
+	GetByID(staffID uuid.UUID) (*entities.Staff, error)
+	Delete(staffID uuid.UUID, hospitalID uuid.UUID, version int) (bool, error)
+	Restore(staffID uuid.UUID, hospitalID uuid.UUID) (bool, error)
+	UpdateRole(staffID uuid.UUID, hospitalID uuid.UUID, role string, version int, event *entities.AuditEvent) (*entities.Staff, error)
 }
 type staffRepository struct {
 	db *gorm.DB
@@ -22,8 +27,13 @@ func NewStaffRepository(db *gorm.DB) StaffRepository {
 }
 
 func (r *staffRepository) Create(staff *entities.Staff) error {
-	// insert into staff table
-	return r.db.Create(staff).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// insert into staff table
+		if err := tx.Create(staff).Error; err != nil {
+			return err
+		}
+		return addStaffEvent(tx, entities.EventStaffCreated, *staff, staff.CreatedAt)
+	})
 }
 
 func (r *staffRepository) GetByUsername(username string, hospitalID uuid.UUID) (*entities.Staff, error) {
@@ -46,3 +56,91 @@ func (r *staffRepository) GetHospitalIDByStaffID(staffID string) (uuid.UUID, err
 	return staff.HospitalID, nil
 
 }
+
+func (r *staffRepository) GetByID(staffID uuid.UUID) (*entities.Staff, error) {
+	var staff entities.Staff
+	if err := r.db.Where(&entities.Staff{ID: staffID}).First(&staff).Error; err != nil {
+		return nil, err
+	}
+	return &staff, nil
+}
+
+// Delete soft deletes a staff member at the given version; their tokens stop working because
+// they can no longer be looked up. It returns ErrStaleVersion when the version is not current.
+func (r *staffRepository) Delete(staffID uuid.UUID, hospitalID uuid.UUID, version int) (bool, error) {
+	deleted := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		res := tx.Model(&entities.Staff{}).
+			Where("id = ? AND hospital_id = ? AND version = ?", staffID, hospitalID, version).
+			Updates(map[string]interface{}{"deleted_at": now, "version": gorm.Expr("version + 1")})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		deleted = true
+		var staff entities.Staff
+		if err := tx.Unscoped().Where("id = ?", staffID).First(&staff).Error; err != nil {
+			return err
+		}
+		return addStaffEvent(tx, entities.EventStaffDeleted, staff, now)
+	})
+	if err != nil || deleted {
+		return deleted, err
+	}
+	var count int64
+	if err := r.db.Model(&entities.Staff{}).Where("id = ? AND hospital_id = ?", staffID, hospitalID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, ErrStaleVersion
+	}
+	return false, nil
+}
+
+func (r *staffRepository) Restore(staffID uuid.UUID, hospitalID uuid.UUID) (bool, error) {
+	restored := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		res := tx.Unscoped().Model(&entities.Staff{}).
+			Where("id = ? AND hospital_id = ? AND deleted_at IS NOT NULL AND anonymised_at IS NULL", staffID, hospitalID).
+			Updates(map[string]interface{}{"deleted_at": nil, "version": gorm.Expr("version + 1"), "updated_at": now})
+		if res.Error != nil || res.RowsAffected == 0 {
+			return res.Error
+		}
+		restored = true
+		var staff entities.Staff
+		if err := tx.Where("id = ?", staffID).First(&staff).Error; err != nil {
+			return err
+		}
+		return addStaffEvent(tx, entities.EventStaffRestored, staff, now)
+	})
+	return restored, err
+}
+
+// UpdateRole sets the role of a staff member at the given version. It returns
+// gorm.ErrRecordNotFound when the staff member is not in the hospital and ErrStaleVersion when
+// the version is not current.
+// UpdateRole stores the role together with its audit event, so no role changes without its
+// audit trail
+func (r *staffRepository) UpdateRole(staffID uuid.UUID, hospitalID uuid.UUID, role string, version int, event *entities.AuditEvent) (*entities.Staff, error) {
+	var staff entities.Staff
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&entities.Staff{}).
+			Where("id = ? AND hospital_id = ? AND version = ?", staffID, hospitalID, version).
+			Updates(map[string]interface{}{"role": role, "version": gorm.Expr("version + 1"), "updated_at": time.Now()})
+		if res.Error != nil {
+			return res.Error
+		}
+		if err := tx.Where("id = ? AND hospital_id = ?", staffID, hospitalID).First(&staff).Error; err != nil {
+			return err
+		}
+		if res.RowsAffected == 0 {
+			return ErrStaleVersion
+		}
+		return tx.Create(event).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &staff, nil
+}
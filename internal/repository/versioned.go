@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// updateVersioned saves every column of record, a pointer to a model, in the row with the id
+// whose version is still *version, and raises *version. The check is part of the UPDATE itself,
+// so concurrent writers cannot both succeed.
+func updateVersioned(db *gorm.DB, model any, id uuid.UUID, version *int, record any) error {
+	expected := *version
+	*version = expected + 1
+	res := db.Model(model).Where("id = ? AND version = ?", id, expected).
+		Select("*").Omit("id", "created_at", clause.Associations).
+		Updates(record)
+	if res.Error == nil && res.RowsAffected == 0 {
+		res.Error = ErrStaleVersion
+	}
+	if res.Error != nil {
+		*version = expected
+	}
+	return res.Error
+}
+
+// deleteVersioned deletes the row of model matching the query at the given version. It returns
+// false when no row matches and ErrStaleVersion when the row is at another version.
+func deleteVersioned(db *gorm.DB, model any, version int, query string, args ...any) (bool, error) {
+	res := db.Where(query, args...).Where("version = ?", version).Delete(model)
+	if res.Error != nil || res.RowsAffected > 0 {
+		return res.Error == nil, res.Error
+	}
+	var count int64
+	if err := db.Model(model).Where(query, args...).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, ErrStaleVersion
+	}
+	return false, nil
+}
@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"go-hospital-api/internal/entities"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrBedUnavailable         = errors.New("bed is not available")
+	ErrPatientAlreadyAdmitted = errors.New("patient already has an active admission")
+	ErrAdmissionNotActive     = errors.New("admission is not active")
+)
+
+type WardRepository interface {
+	CreateWard(ctx context.Context, ward *entities.Ward) error
+	ListWards(ctx context.Context, hospitalID uuid.UUID) ([]entities.Ward, error)
+	GetWard(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.Ward, error)
+	CreateBed(ctx context.Context, bed *entities.Bed) error
+	GetBed(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.Bed, error)
+	SetBedStatus(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID, version int, from []string, to string) (bool, error)
+	Admit(ctx context.Context, admission *entities.Admission) error
+	Transfer(ctx context.Context, admissionID uuid.UUID, hospitalID uuid.UUID, toBedID uuid.UUID, staffID uuid.UUID) error
+	Discharge(ctx context.Context, admissionID uuid.UUID, hospitalID uuid.UUID, staffID uuid.UUID, note string) error
+	GetAdmission(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.Admission, error)
+	ListActiveAdmissions(ctx context.Context, hospitalID uuid.UUID) ([]entities.Admission, error)
+}
+
+type wardRepo struct {
+	db *gorm.DB
+}
+
+func NewWardRepository(db *gorm.DB) WardRepository {
+	return &wardRepo{db: db}
+}
+
+func (r *wardRepo) CreateWard(ctx context.Context, ward *entities.Ward) error {
+	return r.db.WithContext(ctx).Omit(clause.Associations).Create(ward).Error
+}
+
+func (r *wardRepo) ListWards(ctx context.Context, hospitalID uuid.UUID) ([]entities.Ward, error) {
+	var wards []entities.Ward
+	err := r.db.WithContext(ctx).
+		Preload("Beds", func(db *gorm.DB) *gorm.DB { return db.Order("label") }).
+		Where("hospital_id = ?", hospitalID).
+		Order("code").
+		Find(&wards).Error
+	return wards, err
+}
+
+func (r *wardRepo) GetWard(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.Ward, error) {
+	var ward entities.Ward
+	if err := r.db.WithContext(ctx).Where("id = ? AND hospital_id = ?", id, hospitalID).First(&ward).Error; err != nil {
+		return nil, err
+	}
+	return &ward, nil
+}
+
+func (r *wardRepo) CreateBed(ctx context.Context, bed *entities.Bed) error {
+	return r.db.WithContext(ctx).Create(bed).Error
+}
+
+func (r *wardRepo) GetBed(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.Bed, error) {
+	var bed entities.Bed
+	if err := r.db.WithContext(ctx).Where("id = ? AND hospital_id = ?", id, hospitalID).First(&bed).Error; err != nil {
+		return nil, err
+	}
+	return &bed, nil
+}
+
+// SetBedStatus changes a bed's status and raises its version only while the bed is at version
+// and in one of the given statuses
+func (r *wardRepo) SetBedStatus(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID, version int, from []string, to string) (bool, error) {
+	res := r.db.WithContext(ctx).Model(&entities.Bed{}).
+		Where("id = ? AND hospital_id = ? AND version = ? AND status IN ?", id, hospitalID, version, from).
+		Updates(map[string]interface{}{"status": to, "version": gorm.Expr("version + 1"), "updated_at": time.Now()})
+	return res.RowsAffected > 0, res.Error
+}
+
+// claimBed marks an available bed occupied. The conditional update is what stops two
+// concurrent admissions from taking the same bed: only one of them can match the row.
+func claimBed(tx *gorm.DB, bedID, hospitalID uuid.UUID) error {
+	res := tx.Model(&entities.Bed{}).
+		Where("id = ? AND hospital_id = ? AND status = ?", bedID, hospitalID, entities.BedAvailable).
+		Updates(map[string]interface{}{"status": entities.BedOccupied, "version": gorm.Expr("version + 1"), "updated_at": time.Now()})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrBedUnavailable
+	}
+	return nil
+}
+
+func releaseBed(tx *gorm.DB, bedID uuid.UUID) error {
+	return tx.Model(&entities.Bed{}).Where("id = ?", bedID).
+		Updates(map[string]interface{}{"status": entities.BedCleaning, "version": gorm.Expr("version + 1"), "updated_at": time.Now()}).Error
+}
+
+// lockActiveAdmission loads an active admission with a row lock for the rest of the transaction
+func lockActiveAdmission(tx *gorm.DB, admissionID, hospitalID uuid.UUID) (*entities.Admission, error) {
+	var admission entities.Admission
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND hospital_id = ?", admissionID, hospitalID).
+		First(&admission).Error
+	if err != nil {
+		return nil, err
+	}
+	if admission.Status != entities.AdmissionActive {
+		return nil, ErrAdmissionNotActive
+	}
+	return &admission, nil
+}
+
+func (r *wardRepo) Admit(ctx context.Context, admission *entities.Admission) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var active int64
+		if err := tx.Model(&entities.Admission{}).
+			Where("patient_id = ? AND status = ?", admission.PatientID, entities.AdmissionActive).
+			Count(&active).Error; err != nil {
+			return err
+		}
+		if active > 0 {
+			return ErrPatientAlreadyAdmitted
+		}
+		if err := claimBed(tx, admission.BedID, admission.HospitalID); err != nil {
+			return err
+		}
+		if err := tx.Omit(clause.Associations).Create(admission).Error; err != nil {
+			if errors.Is(err, gorm.ErrDuplicatedKey) {
+				return ErrPatientAlreadyAdmitted
+			}
+			return err
+		}
+		return tx.Create(&entities.AdmissionMovement{
+			ID:          uuid.New(),
+			AdmissionID: admission.ID,
+			Type:        entities.MovementAdmit,
+			ToBedID:     &admission.BedID,
+			StaffID:     admission.AdmittedByID,
+		}).Error
+	})
+}
+
+func (r *wardRepo) Transfer(ctx context.Context, admissionID uuid.UUID, hospitalID uuid.UUID, toBedID uuid.UUID, staffID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		admission, err := lockActiveAdmission(tx, admissionID, hospitalID)
+		if err != nil {
+			return err
+		}
+		fromBedID := admission.BedID
+		if fromBedID == toBedID {
+			return ErrBedUnavailable
+		}
+		if err := claimBed(tx, toBedID, hospitalID); err != nil {
+			return err
+		}
+		if err := releaseBed(tx, fromBedID); err != nil {
+			return err
+		}
+		if err := tx.Model(&entities.Admission{}).Where("id = ?", admissionID).
+			Updates(map[string]interface{}{"bed_id": toBedID, "updated_at": time.Now()}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&entities.AdmissionMovement{
+			ID:          uuid.New(),
+			AdmissionID: admissionID,
+			Type:        entities.MovementTransfer,
+			FromBedID:   &fromBedID,
+			ToBedID:     &toBedID,
+			StaffID:     staffID,
+		}).Error
+	})
+}
+
+// Discharge ends an admission and sends its bed to cleaning
+func (r *wardRepo) Discharge(ctx context.Context, admissionID uuid.UUID, hospitalID uuid.UUID, staffID uuid.UUID, note string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		admission, err := lockActiveAdmission(tx, admissionID, hospitalID)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if err := tx.Model(&entities.Admission{}).Where("id = ?", admissionID).Updates(map[string]interface{}{
+			"status":           entities.AdmissionDischarged,
+			"discharged_at":    now,
+			"discharged_by_id": staffID,
+			"discharge_note":   note,
+			"updated_at":       now,
+		}).Error; err != nil {
+			return err
+		}
+		if err := releaseBed(tx, admission.BedID); err != nil {
+			return err
+		}
+		return tx.Create(&entities.AdmissionMovement{
+			ID:          uuid.New(),
+			AdmissionID: admissionID,
+			Type:        entities.MovementDischarge,
+			FromBedID:   &admission.BedID,
+			StaffID:     staffID,
+		}).Error
+	})
+}
+
+func (r *wardRepo) GetAdmission(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.Admission, error) {
+	var admission entities.Admission
+	if err := r.db.WithContext(ctx).Preload("Bed").Where("id = ? AND hospital_id = ?", id, hospitalID).First(&admission).Error; err != nil {
+		return nil, err
+	}
+	return &admission, nil
+}
+
+func (r *wardRepo) ListActiveAdmissions(ctx context.Context, hospitalID uuid.UUID) ([]entities.Admission, error) {
+	var admissions []entities.Admission
+	err := r.db.WithContext(ctx).Preload("Patient").
+		Where("hospital_id = ? AND status = ?", hospitalID, entities.AdmissionActive).
+		Find(&admissions).Error
+	return admissions, err
+}
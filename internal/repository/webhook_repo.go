@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"go-hospital-api/internal/entities"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, sub *entities.WebhookSubscription) error
+	ListSubscriptions(ctx context.Context, hospitalID uuid.UUID) ([]entities.WebhookSubscription, error)
+	GetSubscription(ctx context.Context, id uuid.UUID) (*entities.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID, version int) (bool, error)
+	CreateDeliveries(ctx context.Context, deliveries []entities.WebhookDelivery) error
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]entities.WebhookDelivery, error)
+	SaveDelivery(ctx context.Context, delivery *entities.WebhookDelivery) error
+	GetDelivery(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.WebhookDelivery, error)
+	ListDeliveries(ctx context.Context, hospitalID uuid.UUID, status string, subscriptionID *uuid.UUID, limit int) ([]entities.WebhookDelivery, error)
+}
+
+type webhookRepo struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository(db *gorm.DB) WebhookRepository {
+	return &webhookRepo{db: db}
+}
+
+func (r *webhookRepo) CreateSubscription(ctx context.Context, sub *entities.WebhookSubscription) error {
+	return r.db.WithContext(ctx).Create(sub).Error
+}
+
+func (r *webhookRepo) ListSubscriptions(ctx context.Context, hospitalID uuid.UUID) ([]entities.WebhookSubscription, error) {
+	var subs []entities.WebhookSubscription
+	err := r.db.WithContext(ctx).Where("hospital_id = ?", hospitalID).Order("created_at").Find(&subs).Error
+	return subs, err
+}
+
+// DeleteSubscription stops the subscription at the given version; deliveries still pending for
+// it are given up. It returns false when there is no such subscription and ErrStaleVersion when
+// the version is not current.
+func (r *webhookRepo) DeleteSubscription(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID, version int) (bool, error) {
+	deleted := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		deleted, err = deleteVersioned(tx, &entities.WebhookSubscription{}, version, "id = ? AND hospital_id = ?", id, hospitalID)
+		if err != nil || !deleted {
+			return err
+		}
+		return tx.Model(&entities.WebhookDelivery{}).
+			Where("subscription_id = ? AND status = ?", id, entities.WebhookPending).
+			Updates(map[string]interface{}{"status": entities.WebhookDead, "last_error": "subscription deleted"}).Error
+	})
+	return deleted, err
+}
+
+// CreateDeliveries queues deliveries; one that exists already for its subscription and event is
+// left alone, so an event handed over twice is still delivered once.
+func (r *webhookRepo) CreateDeliveries(ctx context.Context, deliveries []entities.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).CreateInBatches(deliveries, 500).Error
+}
+
+// ClaimDue returns up to limit pending deliveries whose next attempt is due and moves that
+// attempt lease into the future, so other servers leave them alone while they are being sent.
+func (r *webhookRepo) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]entities.WebhookDelivery, error) {
+	var deliveries []entities.WebhookDelivery
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", entities.WebhookPending, now).
+			Order("next_attempt_at").Limit(limit).
+			Find(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+		ids := make([]uuid.UUID, len(deliveries))
+		for i, d := range deliveries {
+			ids[i] = d.ID
+		}
+		return tx.Model(&entities.WebhookDelivery{}).Where("id IN ?", ids).Update("next_attempt_at", now.Add(lease)).Error
+	})
+	return deliveries, err
+}
+
+// GetSubscription returns a subscription that has not been deleted, of any hospital
+func (r *webhookRepo) GetSubscription(ctx context.Context, id uuid.UUID) (*entities.WebhookSubscription, error) {
+	var sub entities.WebhookSubscription
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&sub).Error; err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (r *webhookRepo) SaveDelivery(ctx context.Context, delivery *entities.WebhookDelivery) error {
+	return r.db.WithContext(ctx).Save(delivery).Error
+}
+
+func (r *webhookRepo) GetDelivery(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.WebhookDelivery, error) {
+	var delivery entities.WebhookDelivery
+	if err := r.db.WithContext(ctx).Where("id = ? AND hospital_id = ?", id, hospitalID).First(&delivery).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ListDeliveries returns the newest deliveries of the hospital, optionally of one status or subscription
+func (r *webhookRepo) ListDeliveries(ctx context.Context, hospitalID uuid.UUID, status string, subscriptionID *uuid.UUID, limit int) ([]entities.WebhookDelivery, error) {
+	var deliveries []entities.WebhookDelivery
+	query := r.db.WithContext(ctx).Where("hospital_id = ?", hospitalID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if subscriptionID != nil {
+		query = query.Where("subscription_id = ?", *subscriptionID)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	err := query.Order("created_at DESC").Find(&deliveries).Error
+	return deliveries, err
+}
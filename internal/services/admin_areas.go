@@ -0,0 +1,208 @@
+package services
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// thAdminAreas is the bundled subdistrict/district/province/postal code table. It lists every
+// subdistrict of the districts the hospitals currently serve, not the whole country; set
+// ADMIN_AREAS_FILE to a CSV with the same columns to validate against the full national dataset
+// instead.
+//
+//go:embed data/th_admin_areas.csv
+var thAdminAreas []byte
+
+// thProvinces lists all 77 provinces with the first two digits of their postal codes.
+//
+//go:embed data/th_provinces.csv
+var thProvinces []byte
+
+// AdminArea is one subdistrict (tambon/khwaeng) with its district, province and postal code.
+type AdminArea struct {
+	Subdistrict string
+	District    string
+	Province    string
+	PostalCode  string
+}
+
+// AdminAreas validates and looks up Thai administrative areas.
+type AdminAreas struct {
+	areas []AdminArea
+	// postalPrefixes maps every province to the first two digits of its postal codes
+	postalPrefixes map[string]string
+	// partial is set when areas lists only some districts of its provinces. An address in a
+	// district it does not list is then checked against its province only.
+	partial bool
+}
+
+// areaPrefixes are the unit words people write in front of area names. Longer forms come first
+// so that e.g. "อำเภอ" is not left half-stripped by "อ.".
+var areaPrefixes = []string{"จังหวัด", "อำเภอ", "ตำบล", "แขวง", "เขต", "จ.", "อ.", "ต."}
+
+// normalizeAreaName strips unit words and spacing so "แขวงลุมพินี" and "ลุมพินี" compare equal.
+// Bangkok's common abbreviations are expanded to its full name.
+func normalizeAreaName(name string) string {
+	name = strings.TrimSpace(name)
+	for _, p := range areaPrefixes {
+		if strings.HasPrefix(name, p) {
+			name = strings.TrimSpace(strings.TrimPrefix(name, p))
+			break
+		}
+	}
+	switch name {
+	case "กทม", "กทม.", "กรุงเทพ", "กรุงเทพฯ":
+		return "กรุงเทพมหานคร"
+	}
+	return name
+}
+
+// loadPostalPrefixes reads the bundled province table
+func loadPostalPrefixes() (map[string]string, error) {
+	table, err := newCSVTable(bytes.NewReader(thProvinces), "province", "postal_prefix")
+	if err != nil {
+		return nil, err
+	}
+	prefixes := make(map[string]string)
+	for {
+		row, err := table.next()
+		if err == io.EOF {
+			return prefixes, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		prefixes[table.field(row, "province")] = table.field(row, "postal_prefix")
+	}
+}
+
+// LoadAdminAreas reads a CSV with subdistrict, district, province and postal_code columns. The
+// CSV must list every district of the provinces it has; addresses in provinces it does not have
+// are checked against their province only.
+func LoadAdminAreas(r io.Reader) (*AdminAreas, error) {
+	prefixes, err := loadPostalPrefixes()
+	if err != nil {
+		return nil, err
+	}
+	table, err := newCSVTable(r, "subdistrict", "district", "province", "postal_code")
+	if err != nil {
+		return nil, err
+	}
+	var areas []AdminArea
+	for {
+		row, err := table.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		area := AdminArea{
+			Subdistrict: normalizeAreaName(table.field(row, "subdistrict")),
+			District:    normalizeAreaName(table.field(row, "district")),
+			Province:    normalizeAreaName(table.field(row, "province")),
+			PostalCode:  table.field(row, "postal_code"),
+		}
+		if area.Subdistrict == "" || area.District == "" || area.Province == "" || area.PostalCode == "" {
+			return nil, fmt.Errorf("%w: line %d: every column is required", ErrInvalidInput, table.line)
+		}
+		areas = append(areas, area)
+	}
+	return &AdminAreas{areas: areas, postalPrefixes: prefixes}, nil
+}
+
+// DefaultAdminAreas loads ADMIN_AREAS_FILE when it is set, and the bundled dataset otherwise.
+func DefaultAdminAreas() (*AdminAreas, error) {
+	if path := os.Getenv("ADMIN_AREAS_FILE"); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return LoadAdminAreas(f)
+	}
+	areas, err := LoadAdminAreas(bytes.NewReader(thAdminAreas))
+	if err != nil {
+		return nil, err
+	}
+	areas.partial = true
+	return areas, nil
+}
+
+// Resolve finds the area matching all four parts of an address and returns it with the
+// canonical spelling. Each mismatch is reported against the most specific part that is wrong.
+// The province and the first digits of the postal code are always checked; a district the
+// dataset does not cover is accepted as written.
+func (a *AdminAreas) Resolve(subdistrict, district, province, postalCode string) (AdminArea, error) {
+	subdistrict, district, province = normalizeAreaName(subdistrict), normalizeAreaName(district), normalizeAreaName(province)
+	postalCode = strings.TrimSpace(postalCode)
+	prefix, knownProvince := a.postalPrefixes[province]
+	if knownProvince && !isPostalCode(postalCode, prefix) {
+		return AdminArea{}, fmt.Errorf("%w: postal code %q is not in %s", ErrInvalidInput, postalCode, province)
+	}
+
+	var provinceFound, districtFound, subdistrictFound bool
+	for _, area := range a.areas {
+		if area.Province != province {
+			continue
+		}
+		provinceFound = true
+		if area.District != district {
+			continue
+		}
+		districtFound = true
+		if area.Subdistrict != subdistrict {
+			continue
+		}
+		subdistrictFound = true
+		if area.PostalCode == postalCode {
+			return area, nil
+		}
+	}
+	if knownProvince && (!provinceFound || (!districtFound && a.partial)) {
+		if subdistrict == "" || district == "" {
+			return AdminArea{}, fmt.Errorf("%w: subdistrict and district are required", ErrInvalidInput)
+		}
+		return AdminArea{Subdistrict: subdistrict, District: district, Province: province, PostalCode: postalCode}, nil
+	}
+	switch {
+	case !provinceFound:
+		return AdminArea{}, fmt.Errorf("%w: unknown province %q", ErrInvalidInput, province)
+	case !districtFound:
+		return AdminArea{}, fmt.Errorf("%w: district %q is not in %s", ErrInvalidInput, district, province)
+	case !subdistrictFound:
+		return AdminArea{}, fmt.Errorf("%w: subdistrict %q is not in %s, %s", ErrInvalidInput, subdistrict, district, province)
+	}
+	return AdminArea{}, fmt.Errorf("%w: postal code %q does not match %s, %s", ErrInvalidInput, postalCode, subdistrict, district)
+}
+
+// isPostalCode reports whether code is five digits starting with prefix
+func isPostalCode(code, prefix string) bool {
+	if len(code) != 5 || !strings.HasPrefix(code, prefix) {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Search lists areas by any combination of province, district and postal code.
+func (a *AdminAreas) Search(province, district, postalCode string) []AdminArea {
+	province, district, postalCode = normalizeAreaName(province), normalizeAreaName(district), strings.TrimSpace(postalCode)
+	var out []AdminArea
+	for _, area := range a.areas {
+		if (province == "" || area.Province == province) &&
+			(district == "" || area.District == district) &&
+			(postalCode == "" || area.PostalCode == postalCode) {
+			out = append(out, area)
+		}
+	}
+	return out
+}
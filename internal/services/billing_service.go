@@ -0,0 +1,298 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/repository"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ThaiVATPercent is the VAT rate applied to VAT-able price items. Most medical services are
+// VAT-exempt in Thailand, so price items default to non-VAT-able.
+const ThaiVATPercent = 7
+
+// InvoiceDiscount is an invoice-level discount, either a fixed amount in satang or a percentage.
+type InvoiceDiscount struct {
+	Amount  int64
+	Percent float64
+}
+
+// PatientBalance summarises what a patient owes a hospital, in satang.
+type PatientBalance struct {
+	Outstanding int64
+	Uninvoiced  int64
+}
+
+type BillingServiceInterface interface {
+	CreatePriceItem(ctx context.Context, item *entities.PriceItem) error
+	ListPriceItems(ctx context.Context, hospitalID uuid.UUID, query string) ([]entities.PriceItem, error)
+	PostCharge(ctx context.Context, charge *entities.Charge) error
+	ListCharges(ctx context.Context, patientID, hospitalID uuid.UUID, status string) ([]entities.Charge, error)
+	VoidCharge(ctx context.Context, id, hospitalID uuid.UUID) error
+	CreateInvoice(ctx context.Context, invoice *entities.Invoice, chargeIDs []uuid.UUID, discount InvoiceDiscount) error
+	GetInvoice(ctx context.Context, id, hospitalID uuid.UUID) (*entities.Invoice, error)
+	AddPayment(ctx context.Context, payment *entities.Payment) (*entities.Invoice, error)
+	Balance(ctx context.Context, patientID, hospitalID uuid.UUID) (*PatientBalance, error)
+}
+
+type BillingService struct {
+	repo        repository.BillingRepository
+	patientRepo repository.PatientRepository
+}
+
+func NewBillingService(repo repository.BillingRepository, patientRepo repository.PatientRepository) BillingServiceInterface {
+	return &BillingService{repo: repo, patientRepo: patientRepo}
+}
+
+func (s *BillingService) CreatePriceItem(ctx context.Context, item *entities.PriceItem) error {
+	item.Code = strings.TrimSpace(item.Code)
+	item.Name = strings.TrimSpace(item.Name)
+	if item.Code == "" || item.Name == "" {
+		return fmt.Errorf("%w: code and name are required", ErrInvalidInput)
+	}
+	if item.UnitPrice < 0 {
+		return fmt.Errorf("%w: unit_price must not be negative", ErrInvalidInput)
+	}
+	if item.ID == uuid.Nil {
+		item.ID = uuid.New()
+	}
+	item.Active = true
+	if err := s.repo.CreatePriceItem(ctx, item); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return fmt.Errorf("%w: price item %s already exists", ErrConflict, item.Code)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *BillingService) ListPriceItems(ctx context.Context, hospitalID uuid.UUID, query string) ([]entities.PriceItem, error) {
+	return s.repo.ListPriceItems(ctx, hospitalID, strings.TrimSpace(query))
+}
+
+// PostCharge captures a billable item against a patient at the current list price
+func (s *BillingService) PostCharge(ctx context.Context, charge *entities.Charge) error {
+	if charge.Quantity <= 0 {
+		return fmt.Errorf("%w: quantity must be positive", ErrInvalidInput)
+	}
+	if err := ensurePatient(ctx, s.patientRepo, charge.PatientID, charge.HospitalID); err != nil {
+		return err
+	}
+	item, err := s.repo.GetPriceItem(ctx, charge.PriceItemID, charge.HospitalID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: price item is not in this hospital's price list", ErrInvalidInput)
+		}
+		return err
+	}
+	if !item.Active {
+		return fmt.Errorf("%w: price item %s is inactive", ErrInvalidInput, item.Code)
+	}
+	if charge.ID == uuid.Nil {
+		charge.ID = uuid.New()
+	}
+	if charge.ServiceDate.IsZero() {
+		charge.ServiceDate = time.Now()
+	}
+	charge.Code = item.Code
+	if charge.Description == "" {
+		charge.Description = item.Name
+	}
+	charge.UnitPrice = item.UnitPrice
+	charge.VATable = item.VATable
+	charge.Status = entities.ChargePosted
+	return s.repo.CreateCharge(ctx, charge)
+}
+
+func (s *BillingService) ListCharges(ctx context.Context, patientID, hospitalID uuid.UUID, status string) ([]entities.Charge, error) {
+	return s.repo.ListCharges(ctx, patientID, hospitalID, status, nil)
+}
+
+// VoidCharge cancels a charge that has not been invoiced yet
+func (s *BillingService) VoidCharge(ctx context.Context, id, hospitalID uuid.UUID) error {
+	changed, err := s.repo.VoidCharge(ctx, id, hospitalID)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return fmt.Errorf("%w: charge not found or already invoiced", ErrConflict)
+	}
+	return nil
+}
+
+// CreateInvoice bills the given posted charges, or all posted charges of the patient when none
+// are given, applying the discount before VAT.
+func (s *BillingService) CreateInvoice(ctx context.Context, invoice *entities.Invoice, chargeIDs []uuid.UUID, discount InvoiceDiscount) error {
+	if err := ensurePatient(ctx, s.patientRepo, invoice.PatientID, invoice.HospitalID); err != nil {
+		return err
+	}
+	charges, err := s.repo.ListCharges(ctx, invoice.PatientID, invoice.HospitalID, entities.ChargePosted, chargeIDs)
+	if err != nil {
+		return err
+	}
+	if len(charges) == 0 {
+		return fmt.Errorf("%w: no uninvoiced charges to bill", ErrInvalidInput)
+	}
+	if len(chargeIDs) > 0 && len(charges) != len(chargeIDs) {
+		return fmt.Errorf("%w: some charges are already invoiced, voided or belong to another patient", ErrConflict)
+	}
+
+	lines, err := CalculateInvoiceLines(charges, discount)
+	if err != nil {
+		return err
+	}
+	if invoice.ID == uuid.Nil {
+		invoice.ID = uuid.New()
+	}
+	invoice.IssuedAt = time.Now()
+	invoice.Status = entities.InvoiceIssued
+	invoice.Lines = lines
+	invoice.Subtotal, invoice.DiscountTotal, invoice.VATAmount, invoice.Total = 0, 0, 0, 0
+	for i := range lines {
+		lines[i].ID = uuid.New()
+		lines[i].InvoiceID = invoice.ID
+		invoice.Subtotal += lines[i].Amount
+		invoice.DiscountTotal += lines[i].Discount
+		invoice.VATAmount += lines[i].VATAmount
+	}
+	invoice.Total = invoice.Subtotal - invoice.DiscountTotal + invoice.VATAmount
+
+	if err := s.repo.CreateInvoice(ctx, invoice); err != nil {
+		if errors.Is(err, repository.ErrChargesUnavailable) {
+			return fmt.Errorf("%w: %v", ErrConflict, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// CalculateInvoiceLines prices charges into invoice lines. The invoice discount is spread over
+// the lines in proportion to their amount, never more than a line's own amount, and
+// VAT is then charged on the discounted amount of VAT-able lines, rounded half up per line.
+func CalculateInvoiceLines(charges []entities.Charge, discount InvoiceDiscount) ([]entities.InvoiceLine, error) {
+	if discount.Amount < 0 || discount.Percent < 0 || discount.Percent > 100 {
+		return nil, fmt.Errorf("%w: discount must be between 0 and the invoice subtotal", ErrInvalidInput)
+	}
+	if discount.Amount > 0 && discount.Percent > 0 {
+		return nil, fmt.Errorf("%w: give either a discount amount or a percentage, not both", ErrInvalidInput)
+	}
+
+	lines := make([]entities.InvoiceLine, len(charges))
+	var subtotal int64
+	for i, c := range charges {
+		amount := int64(c.Quantity) * c.UnitPrice
+		subtotal += amount
+		lines[i] = entities.InvoiceLine{
+			ChargeID:    c.ID,
+			Code:        c.Code,
+			Description: c.Description,
+			Quantity:    c.Quantity,
+			UnitPrice:   c.UnitPrice,
+			Amount:      amount,
+			VATable:     c.VATable,
+		}
+	}
+
+	total := discount.Amount
+	if discount.Percent > 0 {
+		total = int64(float64(subtotal)*discount.Percent/100 + 0.5)
+	}
+	if total > subtotal {
+		return nil, fmt.Errorf("%w: discount must be between 0 and the invoice subtotal", ErrInvalidInput)
+	}
+
+	// Each line first gets its share rounded down. The satang left over go one at a time to the
+	// lines with the largest rounding remainders, skipping lines whose discount already equals
+	// their amount, so no line is discounted below zero.
+	rest := total
+	remainders := make([]int64, len(lines))
+	for i := range lines {
+		if subtotal > 0 {
+			share := total * lines[i].Amount
+			lines[i].Discount, remainders[i] = share/subtotal, share%subtotal
+		}
+		rest -= lines[i].Discount
+	}
+	order := make([]int, len(lines))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int { return cmp.Compare(remainders[b], remainders[a]) })
+	for rest > 0 {
+		for _, i := range order {
+			if rest > 0 && lines[i].Discount < lines[i].Amount {
+				lines[i].Discount++
+				rest--
+			}
+		}
+	}
+	for i := range lines {
+		if lines[i].VATable {
+			lines[i].VATAmount = ((lines[i].Amount-lines[i].Discount)*ThaiVATPercent + 50) / 100
+		}
+	}
+	return lines, nil
+}
+
+func (s *BillingService) GetInvoice(ctx context.Context, id, hospitalID uuid.UUID) (*entities.Invoice, error) {
+	invoice, err := s.repo.GetInvoice(ctx, id, hospitalID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: invoice", ErrNotFound)
+		}
+		return nil, err
+	}
+	return invoice, nil
+}
+
+// AddPayment records a cash, card or insurance payment against an invoice
+func (s *BillingService) AddPayment(ctx context.Context, payment *entities.Payment) (*entities.Invoice, error) {
+	switch payment.Method {
+	case entities.PaymentCash, entities.PaymentCard, entities.PaymentInsurance:
+	default:
+		return nil, fmt.Errorf("%w: method must be %s, %s or %s", ErrInvalidInput, entities.PaymentCash, entities.PaymentCard, entities.PaymentInsurance)
+	}
+	if payment.Amount <= 0 {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidInput)
+	}
+	if payment.Method != entities.PaymentCash && strings.TrimSpace(payment.Reference) == "" {
+		return nil, fmt.Errorf("%w: reference is required for %s payments", ErrInvalidInput, payment.Method)
+	}
+	invoice, err := s.GetInvoice(ctx, payment.InvoiceID, payment.HospitalID)
+	if err != nil {
+		return nil, err
+	}
+	if payment.ID == uuid.Nil {
+		payment.ID = uuid.New()
+	}
+	payment.PatientID = invoice.PatientID
+	if payment.PaidAt.IsZero() {
+		payment.PaidAt = time.Now()
+	}
+	if err := s.repo.AddPayment(ctx, payment); err != nil {
+		if errors.Is(err, repository.ErrOverpayment) {
+			return nil, fmt.Errorf("%w: %v", ErrConflict, err)
+		}
+		return nil, err
+	}
+	return s.GetInvoice(ctx, payment.InvoiceID, payment.HospitalID)
+}
+
+func (s *BillingService) Balance(ctx context.Context, patientID, hospitalID uuid.UUID) (*PatientBalance, error) {
+	if err := ensurePatient(ctx, s.patientRepo, patientID, hospitalID); err != nil {
+		return nil, err
+	}
+	outstanding, uninvoiced, err := s.repo.Balance(ctx, patientID, hospitalID)
+	if err != nil {
+		return nil, err
+	}
+	return &PatientBalance{Outstanding: outstanding, Uninvoiced: uninvoiced}, nil
+}
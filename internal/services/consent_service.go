@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/repository"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrConsentRequired is returned when patient data may not be processed for a purpose because
+// the patient has not granted consent. It is a kind of ErrForbidden.
+var ErrConsentRequired = fmt.Errorf("%w: patient consent required", ErrForbidden)
+
+// ConsentChecker is the dependency of services that share or export patient data.
+type ConsentChecker interface {
+	Check(ctx context.Context, patientID, hospitalID uuid.UUID, purpose string) (*entities.Consent, error)
+}
+
+// RequireConsent fails with ErrConsentRequired unless the patient currently consents to the purpose.
+func RequireConsent(ctx context.Context, checker ConsentChecker, patientID, hospitalID uuid.UUID, purpose string) error {
+	consent, err := checker.Check(ctx, patientID, hospitalID, purpose)
+	if err != nil {
+		return err
+	}
+	if consent == nil {
+		return fmt.Errorf("%w for %s", ErrConsentRequired, purpose)
+	}
+	return nil
+}
+
+func isValidConsentPurpose(purpose string) bool {
+	switch purpose {
+	case entities.ConsentTreatment, entities.ConsentDataSharing, entities.ConsentMarketing, entities.ConsentResearch:
+		return true
+	}
+	return false
+}
+
+type ConsentServiceInterface interface {
+	ConsentChecker
+	Grant(ctx context.Context, consent *entities.Consent) error
+	Withdraw(ctx context.Context, id, hospitalID, staffID uuid.UUID, reason string) (*entities.Consent, error)
+	ListByPatient(ctx context.Context, patientID, hospitalID uuid.UUID, purpose string) ([]entities.Consent, error)
+}
+
+type ConsentService struct {
+	repo        repository.ConsentRepository
+	patientRepo repository.PatientRepository
+}
+
+func NewConsentService(repo repository.ConsentRepository, patientRepo repository.PatientRepository) ConsentServiceInterface {
+	return &ConsentService{repo: repo, patientRepo: patientRepo}
+}
+
+// Grant records consent witnessed by a staff member, superseding any earlier consent for the purpose
+func (s *ConsentService) Grant(ctx context.Context, consent *entities.Consent) error {
+	consent.Purpose = strings.ToLower(strings.TrimSpace(consent.Purpose))
+	consent.TextVersion = strings.TrimSpace(consent.TextVersion)
+	if !isValidConsentPurpose(consent.Purpose) {
+		return fmt.Errorf("%w: purpose must be %s, %s, %s or %s", ErrInvalidInput,
+			entities.ConsentTreatment, entities.ConsentDataSharing, entities.ConsentMarketing, entities.ConsentResearch)
+	}
+	if consent.TextVersion == "" {
+		return fmt.Errorf("%w: text_version is required", ErrInvalidInput)
+	}
+	if err := ensurePatient(ctx, s.patientRepo, consent.PatientID, consent.HospitalID); err != nil {
+		return err
+	}
+	if consent.ID == uuid.Nil {
+		consent.ID = uuid.New()
+	}
+	if consent.GrantedAt.IsZero() || consent.GrantedAt.After(time.Now()) {
+		consent.GrantedAt = time.Now()
+	}
+	consent.Status = entities.ConsentGranted
+	if err := s.repo.Grant(ctx, consent); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return fmt.Errorf("%w: consent for %s is being recorded concurrently", ErrConflict, consent.Purpose)
+		}
+		return err
+	}
+	return nil
+}
+
+// Withdraw ends a granted consent; withdrawn and superseded records are kept for audit
+func (s *ConsentService) Withdraw(ctx context.Context, id, hospitalID, staffID uuid.UUID, reason string) (*entities.Consent, error) {
+	if err := s.repo.Withdraw(ctx, id, hospitalID, staffID, strings.TrimSpace(reason), time.Now()); err != nil {
+		if errors.Is(err, repository.ErrConsentNotGranted) {
+			if _, getErr := s.repo.GetByID(ctx, id, hospitalID); errors.Is(getErr, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("%w: consent", ErrNotFound)
+			}
+			return nil, fmt.Errorf("%w: %v", ErrConflict, err)
+		}
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id, hospitalID)
+}
+
+func (s *ConsentService) ListByPatient(ctx context.Context, patientID, hospitalID uuid.UUID, purpose string) ([]entities.Consent, error) {
+	return s.repo.ListByPatient(ctx, patientID, hospitalID, strings.ToLower(strings.TrimSpace(purpose)))
+}
+
+// Check returns the consent currently granted for the purpose, or nil when there is none
+func (s *ConsentService) Check(ctx context.Context, patientID, hospitalID uuid.UUID, purpose string) (*entities.Consent, error) {
+	purpose = strings.ToLower(strings.TrimSpace(purpose))
+	if !isValidConsentPurpose(purpose) {
+		return nil, fmt.Errorf("%w: unknown consent purpose %q", ErrInvalidInput, purpose)
+	}
+	consent, err := s.repo.GetGranted(ctx, patientID, hospitalID, purpose)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return consent, nil
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/repository"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// contactRelationships are the accepted relationships of an emergency contact to the patient.
+var contactRelationships = map[string]bool{
+	"spouse": true, "parent": true, "child": true, "sibling": true, "relative": true,
+	"guardian": true, "friend": true, "caregiver": true, "other": true,
+}
+
+type ContactServiceInterface interface {
+	AddAddress(ctx context.Context, address *entities.PatientAddress) error
+	ListAddresses(ctx context.Context, patientID, hospitalID uuid.UUID) ([]entities.PatientAddress, error)
+	UpdateAddress(ctx context.Context, address *entities.PatientAddress) error
+	DeleteAddress(ctx context.Context, id, patientID, hospitalID uuid.UUID, version int) error
+	AddContact(ctx context.Context, contact *entities.EmergencyContact) error
+	ListContacts(ctx context.Context, patientID, hospitalID uuid.UUID) ([]entities.EmergencyContact, error)
+	UpdateContact(ctx context.Context, contact *entities.EmergencyContact) error
+	DeleteContact(ctx context.Context, id, patientID, hospitalID uuid.UUID, version int) error
+	SearchAreas(province, district, postalCode string) []AdminArea
+}
+
+type ContactService struct {
+	repo        repository.ContactRepository
+	patientRepo repository.PatientRepository
+	areas       *AdminAreas
+}
+
+func NewContactService(repo repository.ContactRepository, patientRepo repository.PatientRepository, areas *AdminAreas) ContactServiceInterface {
+	return &ContactService{repo: repo, patientRepo: patientRepo, areas: areas}
+}
+
+// validateAddress checks the address against the administrative-area dataset and rewrites the
+// area names to their canonical spelling.
+func (s *ContactService) validateAddress(address *entities.PatientAddress) error {
+	address.Type = strings.ToLower(strings.TrimSpace(address.Type))
+	if address.Type == "" {
+		address.Type = entities.AddressHome
+	}
+	switch address.Type {
+	case entities.AddressHome, entities.AddressCurrent, entities.AddressWork:
+	default:
+		return fmt.Errorf("%w: type must be %s, %s or %s", ErrInvalidInput, entities.AddressHome, entities.AddressCurrent, entities.AddressWork)
+	}
+	address.HouseNumber = strings.TrimSpace(address.HouseNumber)
+	if address.HouseNumber == "" {
+		return fmt.Errorf("%w: house_number is required", ErrInvalidInput)
+	}
+	address.Moo = strings.TrimSpace(address.Moo)
+	address.Village = strings.TrimSpace(address.Village)
+	address.Soi = strings.TrimSpace(address.Soi)
+	address.Road = strings.TrimSpace(address.Road)
+
+	area, err := s.areas.Resolve(address.Subdistrict, address.District, address.Province, address.PostalCode)
+	if err != nil {
+		return err
+	}
+	address.Subdistrict, address.District, address.Province, address.PostalCode = area.Subdistrict, area.District, area.Province, area.PostalCode
+	return nil
+}
+
+func (s *ContactService) AddAddress(ctx context.Context, address *entities.PatientAddress) error {
+	if err := s.validateAddress(address); err != nil {
+		return err
+	}
+	if err := ensurePatient(ctx, s.patientRepo, address.PatientID, address.HospitalID); err != nil {
+		return err
+	}
+	if address.ID == uuid.Nil {
+		address.ID = uuid.New()
+	}
+	address.Version = 1
+	if err := s.repo.CreateAddress(ctx, address); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return fmt.Errorf("%w: patient already has a %s address", ErrConflict, address.Type)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *ContactService) ListAddresses(ctx context.Context, patientID, hospitalID uuid.UUID) ([]entities.PatientAddress, error) {
+	return s.repo.ListAddresses(ctx, patientID, hospitalID)
+}
+
+// UpdateAddress replaces every field of an existing address. address.Version is the version the
+// caller last saw; a newer stored version fails the update.
+func (s *ContactService) UpdateAddress(ctx context.Context, address *entities.PatientAddress) error {
+	if err := s.validateAddress(address); err != nil {
+		return err
+	}
+	existing, err := s.repo.GetAddress(ctx, address.ID, address.PatientID, address.HospitalID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: address", ErrNotFound)
+		}
+		return err
+	}
+	if existing.Version != address.Version {
+		return fmt.Errorf("%w: address is at version %d", ErrPreconditionFailed, existing.Version)
+	}
+	address.CreatedAt = existing.CreatedAt
+	if err := s.repo.UpdateAddress(ctx, address); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return fmt.Errorf("%w: patient already has a %s address", ErrConflict, address.Type)
+		}
+		return mapStaleVersion(err)
+	}
+	return nil
+}
+
+// DeleteAddress deletes an address at the version the caller last saw
+func (s *ContactService) DeleteAddress(ctx context.Context, id, patientID, hospitalID uuid.UUID, version int) error {
+	deleted, err := s.repo.DeleteAddress(ctx, id, patientID, hospitalID, version)
+	if err != nil {
+		return mapStaleVersion(err)
+	}
+	if !deleted {
+		return fmt.Errorf("%w: address", ErrNotFound)
+	}
+	return nil
+}
+
+func validateContact(contact *entities.EmergencyContact) error {
+	contact.Name = strings.TrimSpace(contact.Name)
+	contact.PhoneNumber = strings.TrimSpace(contact.PhoneNumber)
+	contact.Relationship = strings.ToLower(strings.TrimSpace(contact.Relationship))
+	contact.Email = strings.TrimSpace(contact.Email)
+	if contact.Name == "" || contact.PhoneNumber == "" {
+		return fmt.Errorf("%w: name and phone_number are required", ErrInvalidInput)
+	}
+	if !contactRelationships[contact.Relationship] {
+		return fmt.Errorf("%w: unknown relationship %q", ErrInvalidInput, contact.Relationship)
+	}
+	if contact.Priority <= 0 {
+		contact.Priority = 1
+	}
+	return nil
+}
+
+func (s *ContactService) AddContact(ctx context.Context, contact *entities.EmergencyContact) error {
+	if err := validateContact(contact); err != nil {
+		return err
+	}
+	if err := ensurePatient(ctx, s.patientRepo, contact.PatientID, contact.HospitalID); err != nil {
+		return err
+	}
+	if contact.ID == uuid.Nil {
+		contact.ID = uuid.New()
+	}
+	contact.Version = 1
+	return s.repo.CreateContact(ctx, contact)
+}
+
+func (s *ContactService) ListContacts(ctx context.Context, patientID, hospitalID uuid.UUID) ([]entities.EmergencyContact, error) {
+	return s.repo.ListContacts(ctx, patientID, hospitalID)
+}
+
+// UpdateContact replaces every field of an existing emergency contact. contact.Version is the
+// version the caller last saw; a newer stored version fails the update.
+func (s *ContactService) UpdateContact(ctx context.Context, contact *entities.EmergencyContact) error {
+	if err := validateContact(contact); err != nil {
+		return err
+	}
+	existing, err := s.repo.GetContact(ctx, contact.ID, contact.PatientID, contact.HospitalID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: emergency contact", ErrNotFound)
+		}
+		return err
+	}
+	if existing.Version != contact.Version {
+		return fmt.Errorf("%w: emergency contact is at version %d", ErrPreconditionFailed, existing.Version)
+	}
+	contact.CreatedAt = existing.CreatedAt
+	return mapStaleVersion(s.repo.UpdateContact(ctx, contact))
+}
+
+// DeleteContact deletes an emergency contact at the version the caller last saw
+func (s *ContactService) DeleteContact(ctx context.Context, id, patientID, hospitalID uuid.UUID, version int) error {
+	deleted, err := s.repo.DeleteContact(ctx, id, patientID, hospitalID, version)
+	if err != nil {
+		return mapStaleVersion(err)
+	}
+	if !deleted {
+		return fmt.Errorf("%w: emergency contact", ErrNotFound)
+	}
+	return nil
+}
+
+// SearchAreas looks up administrative areas, e.g. to fill an address form from a postal code
+func (s *ContactService) SearchAreas(province, district, postalCode string) []AdminArea {
+	return s.areas.Search(province, district, postalCode)
+}
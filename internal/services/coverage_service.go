@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/repository"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// schemePayers names the payer of each public scheme, used when no payer name is given.
+var schemePayers = map[string]string{
+	entities.SchemeUC:    "National Health Security Office",
+	entities.SchemeSSS:   "Social Security Office",
+	entities.SchemeCSMBS: "Comptroller General's Department",
+}
+
+type CoverageServiceInterface interface {
+	Add(ctx context.Context, coverage *entities.Coverage) error
+	ListByPatient(ctx context.Context, patientID, hospitalID uuid.UUID) ([]entities.Coverage, error)
+	Verify(ctx context.Context, id, hospitalID uuid.UUID) (*entities.Coverage, error)
+}
+
+type CoverageService struct {
+	repo        repository.CoverageRepository
+	patientRepo repository.PatientRepository
+	verifier    EligibilityVerifier
+}
+
+func NewCoverageService(repo repository.CoverageRepository, patientRepo repository.PatientRepository, verifier EligibilityVerifier) CoverageServiceInterface {
+	return &CoverageService{repo: repo, patientRepo: patientRepo, verifier: verifier}
+}
+
+// Add records a payer covering the patient. Private insurance needs a payer name; public
+// schemes default to their administering office.
+func (s *CoverageService) Add(ctx context.Context, coverage *entities.Coverage) error {
+	coverage.Scheme = strings.ToLower(strings.TrimSpace(coverage.Scheme))
+	coverage.MemberNumber = strings.TrimSpace(coverage.MemberNumber)
+	coverage.PayerName = strings.TrimSpace(coverage.PayerName)
+	switch coverage.Scheme {
+	case entities.SchemeUC, entities.SchemeSSS, entities.SchemeCSMBS:
+		if coverage.PayerName == "" {
+			coverage.PayerName = schemePayers[coverage.Scheme]
+		}
+	case entities.SchemePrivate:
+		if coverage.PayerName == "" {
+			return fmt.Errorf("%w: payer_name is required for private insurance", ErrInvalidInput)
+		}
+	default:
+		return fmt.Errorf("%w: scheme must be %s, %s, %s or %s", ErrInvalidInput,
+			entities.SchemeUC, entities.SchemeSSS, entities.SchemeCSMBS, entities.SchemePrivate)
+	}
+	if coverage.MemberNumber == "" {
+		return fmt.Errorf("%w: member_number is required", ErrInvalidInput)
+	}
+	if coverage.ValidFrom.IsZero() {
+		coverage.ValidFrom = time.Now()
+	}
+	coverage.ValidFrom = truncateToDate(coverage.ValidFrom)
+	if coverage.ValidTo != nil {
+		validTo := truncateToDate(*coverage.ValidTo)
+		coverage.ValidTo = &validTo
+	}
+	if coverage.ValidTo != nil && coverage.ValidTo.Before(coverage.ValidFrom) {
+		return fmt.Errorf("%w: valid_to is before valid_from", ErrInvalidInput)
+	}
+	if err := ensurePatient(ctx, s.patientRepo, coverage.PatientID, coverage.HospitalID); err != nil {
+		return err
+	}
+	if coverage.ID == uuid.Nil {
+		coverage.ID = uuid.New()
+	}
+	coverage.EligibilityStatus = entities.EligibilityUnverified
+	return s.repo.Create(ctx, coverage)
+}
+
+func (s *CoverageService) ListByPatient(ctx context.Context, patientID, hospitalID uuid.UUID) ([]entities.Coverage, error) {
+	return s.repo.ListByPatient(ctx, patientID, hospitalID)
+}
+
+// Verify asks the payer whether the coverage is currently eligible and stores the answer
+func (s *CoverageService) Verify(ctx context.Context, id, hospitalID uuid.UUID) (*entities.Coverage, error) {
+	coverage, err := s.repo.GetByID(ctx, id, hospitalID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: coverage", ErrNotFound)
+		}
+		return nil, err
+	}
+	result, err := s.verifier.Verify(ctx, *coverage)
+	if err != nil {
+		return nil, fmt.Errorf("%w: eligibility check failed: %v", ErrUpstream, err)
+	}
+	status := entities.EligibilityIneligible
+	if result.Eligible {
+		status = entities.EligibilityEligible
+	}
+	checkedAt := time.Now()
+	if err := s.repo.UpdateEligibility(ctx, coverage.ID, hospitalID, status, result.Message, checkedAt); err != nil {
+		return nil, err
+	}
+	coverage.EligibilityStatus = status
+	coverage.EligibilityMessage = result.Message
+	coverage.EligibilityCheckedAt = &checkedAt
+	return coverage, nil
+}
+
+// ActiveCoverage picks the coverage that applies on the given day: the most recently started
+// one within its validity period that has not been found ineligible.
+func ActiveCoverage(coverages []entities.Coverage, at time.Time) *entities.Coverage {
+	var active *entities.Coverage
+	for i := range coverages {
+		c := &coverages[i]
+		if !c.ActiveOn(at) || c.EligibilityStatus == entities.EligibilityIneligible {
+			continue
+		}
+		if active == nil || c.ValidFrom.After(active.ValidFrom) {
+			active = c
+		}
+	}
+	return active
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// csvTable reads a CSV file with a header row and gives access to fields by column name.
+type csvTable struct {
+	reader *csv.Reader
+	cols   map[string]int
+	line   int
+}
+
+// newCSVTable reads the header row and checks that all required columns are present.
+// Column names are matched case-insensitively.
+func newCSVTable(r io.Reader, required ...string) (*csvTable, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: cannot read CSV header: %v", ErrInvalidInput, err)
+	}
+	cols := make(map[string]int, len(header))
+	for i, h := range header {
+		cols[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))] = i
+	}
+	for _, name := range required {
+		if _, ok := cols[name]; !ok {
+			return nil, fmt.Errorf("%w: CSV header must contain a %s column", ErrInvalidInput, name)
+		}
+	}
+	return &csvTable{reader: reader, cols: cols, line: 1}, nil
+}
+
+// next returns the following row, or io.EOF when the file is exhausted.
+func (t *csvTable) next() ([]string, error) {
+	row, err := t.reader.Read()
+	if err == io.EOF {
+		return nil, err
+	}
+	t.line++
+	if err != nil {
+		return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidInput, t.line, err)
+	}
+	return row, nil
+}
+
+func (t *csvTable) field(row []string, name string) string {
+	i, ok := t.cols[name]
+	if !ok || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+// parseCSVBool accepts the usual spellings of a boolean column; empty means def.
+func parseCSVBool(v string, def bool) (bool, error) {
+	switch strings.ToLower(v) {
+	case "":
+		return def, nil
+	case "1", "true", "t", "y", "yes":
+		return true, nil
+	case "0", "false", "f", "n", "no":
+		return false, nil
+	}
+	return false, fmt.Errorf("invalid boolean value %q", v)
+}
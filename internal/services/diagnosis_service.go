@@ -0,0 +1,185 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/repository"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type DiagnosisServiceInterface interface {
+	ImportCatalogue(ctx context.Context, r io.Reader, edition string, staffID uuid.UUID) (int, error)
+	SearchCodes(ctx context.Context, query, edition string, limit int) ([]entities.ICD10Code, error)
+	Record(ctx context.Context, diagnosis *entities.Diagnosis) error
+	ListByPatient(ctx context.Context, patientID, hospitalID uuid.UUID, visitDate *time.Time) ([]entities.Diagnosis, error)
+}
+
+type DiagnosisService struct {
+	repo        repository.DiagnosisRepository
+	patientRepo repository.PatientRepository
+	staffRepo   repository.StaffRepository
+}
+
+func NewDiagnosisService(repo repository.DiagnosisRepository, patientRepo repository.PatientRepository, staffRepo repository.StaffRepository) DiagnosisServiceInterface {
+	return &DiagnosisService{repo: repo, patientRepo: patientRepo, staffRepo: staffRepo}
+}
+
+// ImportCatalogue loads an ICD-10 catalogue CSV. The header row must contain "code" and
+// "description"; "description_th", "billable" and "edition" are optional. Rows without an
+// edition column are imported under the given edition. The catalogue is shared by every
+// hospital, so only supervisors can import it.
+func (s *DiagnosisService) ImportCatalogue(ctx context.Context, r io.Reader, edition string, staffID uuid.UUID) (int, error) {
+	if err := requireSupervisor(s.staffRepo, staffID, "import the ICD-10 catalogue"); err != nil {
+		return 0, err
+	}
+	edition = normalizeEdition(edition)
+	table, err := newCSVTable(r, "code", "description")
+	if err != nil {
+		return 0, err
+	}
+
+	var codes []entities.ICD10Code
+	for {
+		row, err := table.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		code := NormalizeICDCode(table.field(row, "code"))
+		if code == "" {
+			continue
+		}
+		rowEdition := edition
+		if e := table.field(row, "edition"); e != "" {
+			rowEdition = normalizeEdition(e)
+		}
+		if rowEdition != entities.EditionICD10 && rowEdition != entities.EditionICD10TM {
+			return 0, fmt.Errorf("%w: line %d: unknown edition %q", ErrInvalidInput, table.line, rowEdition)
+		}
+		billable, err := parseCSVBool(table.field(row, "billable"), true)
+		if err != nil {
+			return 0, fmt.Errorf("%w: line %d: %v", ErrInvalidInput, table.line, err)
+		}
+		codes = append(codes, entities.ICD10Code{
+			Code:          code,
+			Edition:       rowEdition,
+			Description:   table.field(row, "description"),
+			DescriptionTH: table.field(row, "description_th"),
+			Billable:      billable,
+		})
+	}
+
+	if err := s.repo.UpsertCodes(ctx, codes); err != nil {
+		return 0, err
+	}
+	return len(codes), nil
+}
+
+// SearchCodes returns catalogue entries for autocomplete
+func (s *DiagnosisService) SearchCodes(ctx context.Context, query, edition string, limit int) ([]entities.ICD10Code, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	query = strings.TrimSpace(query)
+	if looksLikeICDCode(query) {
+		query = NormalizeICDCode(query)
+	}
+	return s.repo.SearchCodes(ctx, query, normalizeEdition(edition), limit)
+}
+
+// Record validates the code against the catalogue and stores the diagnosis.
+// Only billable codes are accepted and a visit may have a single primary diagnosis; the unique
+// index behind that rule also rejects a second primary recorded at the same time.
+func (s *DiagnosisService) Record(ctx context.Context, diagnosis *entities.Diagnosis) error {
+	if diagnosis.VisitDate.IsZero() {
+		return fmt.Errorf("%w: visit_date is required", ErrInvalidInput)
+	}
+	diagnosis.VisitDate = truncateToDate(diagnosis.VisitDate)
+	diagnosis.Code = NormalizeICDCode(diagnosis.Code)
+	diagnosis.Edition = normalizeEdition(diagnosis.Edition)
+	if diagnosis.Code == "" {
+		return fmt.Errorf("%w: code is required", ErrInvalidInput)
+	}
+
+	if err := ensurePatient(ctx, s.patientRepo, diagnosis.PatientID, diagnosis.HospitalID); err != nil {
+		return err
+	}
+
+	code, err := s.repo.GetCode(ctx, diagnosis.Code, diagnosis.Edition)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: unknown %s code %s", ErrInvalidInput, diagnosis.Edition, diagnosis.Code)
+		}
+		return err
+	}
+	if !code.Billable {
+		return fmt.Errorf("%w: %s code %s is not billable, use a more specific code", ErrInvalidInput, diagnosis.Edition, diagnosis.Code)
+	}
+
+	if diagnosis.IsPrimary {
+		exists, err := s.repo.HasPrimary(ctx, diagnosis.PatientID, diagnosis.VisitDate)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("%w: visit already has a primary diagnosis", ErrConflict)
+		}
+	}
+
+	if diagnosis.ID == uuid.Nil {
+		diagnosis.ID = uuid.New()
+	}
+	if err := s.repo.Create(ctx, diagnosis); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return fmt.Errorf("%w: visit already has a primary diagnosis", ErrConflict)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *DiagnosisService) ListByPatient(ctx context.Context, patientID, hospitalID uuid.UUID, visitDate *time.Time) ([]entities.Diagnosis, error) {
+	if visitDate != nil {
+		d := truncateToDate(*visitDate)
+		visitDate = &d
+	}
+	return s.repo.ListByPatient(ctx, patientID, hospitalID, visitDate)
+}
+
+// NormalizeICDCode upper-cases a code and strips the optional dot, so "j45.9" and "J459" match
+func NormalizeICDCode(code string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(code), ".", ""))
+}
+
+// looksLikeICDCode reports whether a search term is a code prefix such as "J4" or "e11.9"
+func looksLikeICDCode(q string) bool {
+	if len(q) < 2 || len(q) > 8 || strings.Contains(q, " ") {
+		return false
+	}
+	first, second := q[0]|0x20, q[1]
+	return first >= 'a' && first <= 'z' && second >= '0' && second <= '9'
+}
+
+func normalizeEdition(edition string) string {
+	switch strings.ToUpper(strings.TrimSpace(edition)) {
+	case "", "ICD-10", "ICD10":
+		return entities.EditionICD10
+	case "ICD-10-TM", "ICD10TM", "ICD10-TM", "TM":
+		return entities.EditionICD10TM
+	}
+	return strings.ToUpper(strings.TrimSpace(edition))
+}
+
+func truncateToDate(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
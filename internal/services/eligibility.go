@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+	"go-hospital-api/internal/entities"
+	"time"
+)
+
+// EligibilityResult is a payer's answer to an eligibility check.
+type EligibilityResult struct {
+	Eligible bool
+	Message  string
+}
+
+// EligibilityVerifier checks a coverage with its payer. Implementations backed by a payer's
+// web service should return an error only when the payer could not be asked, not when the
+// patient is ineligible.
+type EligibilityVerifier interface {
+	Verify(ctx context.Context, coverage entities.Coverage) (EligibilityResult, error)
+}
+
+// LocalEligibilityVerifier answers eligibility checks without calling any payer: a coverage is
+// eligible while its validity period includes today, unless its member number is listed in
+// Ineligible with the reason to report. It is the default verifier and the one used in tests.
+type LocalEligibilityVerifier struct {
+	Ineligible map[string]string
+	Now        func() time.Time
+}
+
+func (v *LocalEligibilityVerifier) Verify(ctx context.Context, coverage entities.Coverage) (EligibilityResult, error) {
+	now := time.Now()
+	if v.Now != nil {
+		now = v.Now()
+	}
+	if reason, ok := v.Ineligible[coverage.MemberNumber]; ok {
+		return EligibilityResult{Eligible: false, Message: reason}, nil
+	}
+	if !coverage.ActiveOn(now) {
+		return EligibilityResult{Eligible: false, Message: "coverage is outside its validity period"}, nil
+	}
+	return EligibilityResult{Eligible: true, Message: "eligible"}, nil
+}
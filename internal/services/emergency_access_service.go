@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/repository"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultEmergencyAccessMinutes is how long a break-the-glass grant lasts unless asked otherwise.
+	DefaultEmergencyAccessMinutes = 60
+	MaxEmergencyAccessMinutes     = 240
+	// MinEmergencyReasonLength keeps reasons like "urgent" from passing as a justification.
+	MinEmergencyReasonLength = 10
+
+	AuditActionBreakTheGlass   = "break_the_glass"
+	AuditActionEmergencyRead   = "emergency_access_read"
+	AuditActionEmergencyReview = "emergency_access_review"
+	auditEventLimit            = 500
+)
+
+type EmergencyAccessServiceInterface interface {
+	Grant(ctx context.Context, staffID, patientID uuid.UUID, reason string, minutes int) (*entities.EmergencyAccess, error)
+	Patient(ctx context.Context, id, staffID uuid.UUID) (*entities.EmergencyAccess, error)
+	Report(ctx context.Context, hospitalID, staffID uuid.UUID, reviewStatus string) ([]entities.EmergencyAccess, error)
+	Review(ctx context.Context, id, hospitalID, staffID uuid.UUID, outcome, note string) (*entities.EmergencyAccess, error)
+	AuditEvents(ctx context.Context, hospitalID, staffID uuid.UUID, priority string) ([]entities.AuditEvent, error)
+}
+
+type EmergencyAccessService struct {
+	repo        repository.EmergencyAccessRepository
+	auditRepo   repository.AuditRepository
+	patientRepo repository.PatientRepository
+	staffRepo   repository.StaffRepository
+}
+
+func NewEmergencyAccessService(repo repository.EmergencyAccessRepository, auditRepo repository.AuditRepository, patientRepo repository.PatientRepository, staffRepo repository.StaffRepository) EmergencyAccessServiceInterface {
+	return &EmergencyAccessService{repo: repo, auditRepo: auditRepo, patientRepo: patientRepo, staffRepo: staffRepo}
+}
+
+// Grant breaks the glass: a doctor or nurse gets access to one patient of any hospital for a
+// limited time. The grant raises a high-priority audit event in both the staff member's and the
+// patient's hospital and waits for a supervisor's review.
+func (s *EmergencyAccessService) Grant(ctx context.Context, staffID, patientID uuid.UUID, reason string, minutes int) (*entities.EmergencyAccess, error) {
+	reason = strings.TrimSpace(reason)
+	if len([]rune(reason)) < MinEmergencyReasonLength {
+		return nil, fmt.Errorf("%w: a reason of at least %d characters is required", ErrInvalidInput, MinEmergencyReasonLength)
+	}
+	if minutes == 0 {
+		minutes = DefaultEmergencyAccessMinutes
+	}
+	if minutes < 0 || minutes > MaxEmergencyAccessMinutes {
+		return nil, fmt.Errorf("%w: duration_minutes must be between 1 and %d", ErrInvalidInput, MaxEmergencyAccessMinutes)
+	}
+	staff, err := s.staffRepo.GetByID(staffID)
+	if err != nil {
+		return nil, err
+	}
+	if staff.Role != entities.RoleDoctor && staff.Role != entities.RoleNurse {
+		return nil, fmt.Errorf("%w: only doctors and nurses can break the glass", ErrForbidden)
+	}
+	patient, err := s.patientRepo.GetByIDAcrossHospitals(ctx, patientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: patient", ErrNotFound)
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	access := &entities.EmergencyAccess{
+		ID:                uuid.New(),
+		StaffID:           staff.ID,
+		StaffHospitalID:   staff.HospitalID,
+		PatientID:         patient.ID,
+		PatientHospitalID: patient.HospitalID,
+		Reason:            reason,
+		GrantedAt:         now,
+		ExpiresAt:         now.Add(time.Duration(minutes) * time.Minute),
+		ReviewStatus:      entities.EmergencyReviewPending,
+	}
+	details := fmt.Sprintf("access %s for %d minutes: %s", access.ID, minutes, reason)
+	events := []entities.AuditEvent{auditEvent(staff.HospitalID, staff.ID, AuditActionBreakTheGlass, entities.AuditHigh, &patient.ID, details)}
+	if patient.HospitalID != staff.HospitalID {
+		events = append(events, auditEvent(patient.HospitalID, staff.ID, AuditActionBreakTheGlass, entities.AuditHigh, &patient.ID, details))
+	}
+	if err := s.repo.Grant(ctx, access, events); err != nil {
+		return nil, err
+	}
+	log.Printf("AUDIT high: staff %s broke the glass for patient %s (%s)", staff.ID, patient.ID, details)
+	return s.repo.GetByID(ctx, access.ID)
+}
+
+// Patient returns the patient of a grant to the staff member who holds it, until it expires.
+// Every read is audited.
+func (s *EmergencyAccessService) Patient(ctx context.Context, id, staffID uuid.UUID) (*entities.EmergencyAccess, error) {
+	access, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: emergency access", ErrNotFound)
+		}
+		return nil, err
+	}
+	if access.StaffID != staffID {
+		return nil, fmt.Errorf("%w: emergency access", ErrNotFound)
+	}
+	if time.Now().After(access.ExpiresAt) {
+		return nil, fmt.Errorf("%w: emergency access has expired", ErrForbidden)
+	}
+	event := auditEvent(access.PatientHospitalID, staffID, AuditActionEmergencyRead, entities.AuditNormal, &access.PatientID, "access "+access.ID.String())
+	if err := s.auditRepo.Create(ctx, &event); err != nil {
+		return nil, err
+	}
+	return access, nil
+}
+
+// Report lists the grants a supervisor of the hospital has to look at: those used by its staff
+// and those on its patients.
+func (s *EmergencyAccessService) Report(ctx context.Context, hospitalID, staffID uuid.UUID, reviewStatus string) ([]entities.EmergencyAccess, error) {
+	switch reviewStatus {
+	case "", entities.EmergencyReviewPending, entities.EmergencyReviewJustified, entities.EmergencyReviewUnjustified:
+	default:
+		return nil, fmt.Errorf("%w: status must be pending, justified or unjustified", ErrInvalidInput)
+	}
+	if err := requireSupervisor(s.staffRepo, staffID, "review emergency access"); err != nil {
+		return nil, err
+	}
+	return s.repo.ListForHospital(ctx, hospitalID, reviewStatus)
+}
+
+// Review records a supervisor's verdict on a grant. Supervisors cannot review their own grants,
+// and an unjustified verdict needs a note.
+func (s *EmergencyAccessService) Review(ctx context.Context, id, hospitalID, staffID uuid.UUID, outcome, note string) (*entities.EmergencyAccess, error) {
+	note = strings.TrimSpace(note)
+	switch outcome {
+	case entities.EmergencyReviewJustified:
+	case entities.EmergencyReviewUnjustified:
+		if note == "" {
+			return nil, fmt.Errorf("%w: a note is required for an unjustified access", ErrInvalidInput)
+		}
+	default:
+		return nil, fmt.Errorf("%w: outcome must be justified or unjustified", ErrInvalidInput)
+	}
+	if err := requireSupervisor(s.staffRepo, staffID, "review emergency access"); err != nil {
+		return nil, err
+	}
+	access, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: emergency access", ErrNotFound)
+		}
+		return nil, err
+	}
+	if access.StaffHospitalID != hospitalID && access.PatientHospitalID != hospitalID {
+		return nil, fmt.Errorf("%w: emergency access", ErrNotFound)
+	}
+	if access.StaffID == staffID {
+		return nil, fmt.Errorf("%w: supervisors cannot review their own emergency access", ErrForbidden)
+	}
+	if err := s.repo.Review(ctx, id, outcome, staffID, note, time.Now()); err != nil {
+		if errors.Is(err, repository.ErrAccessAlreadyReviewed) {
+			return nil, fmt.Errorf("%w: %v", ErrConflict, err)
+		}
+		return nil, err
+	}
+	event := auditEvent(hospitalID, staffID, AuditActionEmergencyReview, entities.AuditNormal, &access.PatientID, fmt.Sprintf("access %s: %s", id, outcome))
+	if err := s.auditRepo.Create(ctx, &event); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(ctx, id)
+}
+
+// AuditEvents lists the hospital's newest audit events to supervisors
+func (s *EmergencyAccessService) AuditEvents(ctx context.Context, hospitalID, staffID uuid.UUID, priority string) ([]entities.AuditEvent, error) {
+	switch priority {
+	case "", entities.AuditNormal, entities.AuditHigh:
+	default:
+		return nil, fmt.Errorf("%w: priority must be normal or high", ErrInvalidInput)
+	}
+	if err := requireSupervisor(s.staffRepo, staffID, "read audit events"); err != nil {
+		return nil, err
+	}
+	return s.auditRepo.List(ctx, hospitalID, priority, auditEventLimit)
+}
+
+// requireSupervisor allows an action to supervisors only; action completes "only supervisors can ..."
+func requireSupervisor(staffRepo repository.StaffRepository, staffID uuid.UUID, action string) error {
+	staff, err := staffRepo.GetByID(staffID)
+	if err != nil {
+		return err
+	}
+	if staff.Role != entities.RoleSupervisor {
+		return fmt.Errorf("%w: only supervisors can %s", ErrForbidden, action)
+	}
+	return nil
+}
+
+func auditEvent(hospitalID, staffID uuid.UUID, action, priority string, patientID *uuid.UUID, details string) entities.AuditEvent {
+	return entities.AuditEvent{
+		ID:         uuid.New(),
+		HospitalID: hospitalID,
+		StaffID:    staffID,
+		Action:     action,
+		Priority:   priority,
+		PatientID:  patientID,
+		Details:    details,
+		CreatedAt:  time.Now(),
+	}
+}
@@ -0,0 +1,15 @@
+package services
+
+import "errors"
+
+// Sentinel errors returned by services. Handlers map them onto HTTP status codes,
+// so wrap them with fmt.Errorf("%w: ...") to add detail without losing the kind.
+var (
+	ErrInvalidInput = errors.New("invalid input")
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrForbidden    = errors.New("forbidden")
+	ErrUpstream     = errors.New("upstream service unavailable")
+	// ErrPreconditionFailed means the client's If-Match version is no longer current.
+	ErrPreconditionFailed = errors.New("record was changed by someone else")
+)
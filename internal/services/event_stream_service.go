@@ -0,0 +1,149 @@
+package services
+
+import (
+	"context"
+	"go-hospital-api/internal/entities"
+	"slices"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// DefaultEventStreamLogSize is how many events the event stream keeps for resuming
+const DefaultEventStreamLogSize = 1000
+
+// eventStreamBuffer is how many events a subscriber may fall behind before it is dropped
+const eventStreamBuffer = 64
+
+// streamEventTypes are the events the event stream sends
+var streamEventTypes = []string{entities.EventPatientCreated, entities.EventPatientUpdated, entities.EventPatientMerged}
+
+// StreamEvent is an event of the event stream. Data is its JSON body, the same as a webhook's.
+type StreamEvent struct {
+	ID         uuid.UUID
+	HospitalID uuid.UUID
+	Type       string
+	Data       []byte
+}
+
+// EventStreamSubscription receives the events of one hospital. Close it when done.
+type EventStreamSubscription struct {
+	// Backlog holds the logged events that came after the Last-Event-ID, oldest first
+	Backlog []StreamEvent
+	// Reset is set when the Last-Event-ID is no longer in the log, so events may have been
+	// missed and the client has to reload what it shows
+	Reset bool
+	// Events receives the events as they are published. It is closed when the subscriber falls
+	// too far behind; the client should then reconnect with its Last-Event-ID.
+	Events <-chan StreamEvent
+
+	hospitalID uuid.UUID
+	events     chan StreamEvent
+	stream     *eventStreamService
+}
+
+// Close stops the subscription
+func (s *EventStreamSubscription) Close() {
+	s.stream.unsubscribe(s)
+}
+
+// EventStreamServiceInterface is the event sink behind GET /events/stream
+type EventStreamServiceInterface interface {
+	EventSink
+	// Subscribe receives the hospital's events, starting after lastEventID when it is not empty
+	Subscribe(hospitalID uuid.UUID, lastEventID string) *EventStreamSubscription
+}
+
+type eventStreamService struct {
+	mu   sync.Mutex
+	size int
+	log  []StreamEvent
+	seen map[uuid.UUID]struct{}
+	subs map[*EventStreamSubscription]struct{}
+}
+
+// NewEventStreamService streams the patient created, updated and merged events to the staff of
+// their hospital. It keeps the last logSize events, of every hospital, for clients that
+// reconnect. Events only reach the streams of the server whose dispatcher published them.
+func NewEventStreamService(logSize int) EventStreamServiceInterface {
+	if logSize <= 0 {
+		logSize = DefaultEventStreamLogSize
+	}
+	return &eventStreamService{
+		size: logSize,
+		seen: make(map[uuid.UUID]struct{}),
+		subs: make(map[*EventStreamSubscription]struct{}),
+	}
+}
+
+func (s *eventStreamService) Name() string { return "stream" }
+
+func (s *eventStreamService) Handle(ctx context.Context, e entities.OutboxEvent) error {
+	if !slices.Contains(streamEventTypes, e.Type) {
+		return nil
+	}
+	data, err := eventEnvelope(e)
+	if err != nil {
+		return err
+	}
+	event := StreamEvent{ID: e.ID, HospitalID: e.HospitalID, Type: e.Type, Data: data}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// An event handed over again was already sent
+	if _, ok := s.seen[e.ID]; ok {
+		return nil
+	}
+	s.seen[e.ID] = struct{}{}
+	s.log = append(s.log, event)
+	if len(s.log) > s.size {
+		delete(s.seen, s.log[0].ID)
+		s.log = slices.Delete(s.log, 0, 1)
+	}
+	for sub := range s.subs {
+		if sub.hospitalID != event.HospitalID {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			// The client is too slow; it resumes from the log when it reconnects
+			delete(s.subs, sub)
+			close(sub.events)
+		}
+	}
+	return nil
+}
+
+func (s *eventStreamService) Subscribe(hospitalID uuid.UUID, lastEventID string) *EventStreamSubscription {
+	events := make(chan StreamEvent, eventStreamBuffer)
+	sub := &EventStreamSubscription{Events: events, hospitalID: hospitalID, events: events, stream: s}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if lastEventID != "" {
+		// Only an event of the caller's hospital marks where to resume
+		id, err := uuid.Parse(lastEventID)
+		i := slices.IndexFunc(s.log, func(e StreamEvent) bool { return e.ID == id && e.HospitalID == hospitalID })
+		if err != nil || i < 0 {
+			sub.Reset = true
+		} else {
+			for _, e := range s.log[i+1:] {
+				if e.HospitalID == hospitalID {
+					sub.Backlog = append(sub.Backlog, e)
+				}
+			}
+		}
+	}
+	s.subs[sub] = struct{}{}
+	return sub
+}
+
+func (s *eventStreamService) unsubscribe(sub *EventStreamSubscription) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.subs[sub]; ok {
+		delete(s.subs, sub)
+		close(sub.events)
+	}
+}
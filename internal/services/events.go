@@ -0,0 +1,243 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/repository"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	eventFirstRetry = 10 * time.Second
+	eventMaxRetry   = time.Hour
+	// eventLease keeps a claimed event from being published twice at once; it must outlast a
+	// batch of sinks that time out
+	eventLease     = 5 * time.Minute
+	eventBatchSize = 100
+	eventWorkers   = 8
+)
+
+// EventSink takes the events of the outbox, e.g. to deliver webhooks or invalidate a cache.
+// An event may be handed over more than once and events may arrive out of order, so Handle
+// must be idempotent; the event ID identifies an event. When Handle fails, the event is handed
+// over again later.
+type EventSink interface {
+	// Name identifies the sink in the outbox, so it must not change between releases
+	Name() string
+	Handle(ctx context.Context, event entities.OutboxEvent) error
+}
+
+type eventSubscription struct {
+	sink  EventSink
+	types []string
+}
+
+// EventBus hands each published event to the sinks subscribed to its type
+type EventBus struct {
+	mu   sync.RWMutex
+	subs []eventSubscription
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{}
+}
+
+// Subscribe hands the sink the events of the given types, or every event when none is given
+func (b *EventBus) Subscribe(sink EventSink, eventTypes ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs = append(b.subs, eventSubscription{sink: sink, types: eventTypes})
+}
+
+// Publish hands the event to the sinks subscribed to its type, one after the other, or only to
+// those named in sinks when it is not empty. It returns the names of the sinks that failed,
+// together with their errors.
+func (b *EventBus) Publish(ctx context.Context, event entities.OutboxEvent, sinks []string) ([]string, error) {
+	b.mu.RLock()
+	subs := b.subs
+	b.mu.RUnlock()
+
+	var (
+		failed []string
+		errs   []error
+	)
+	for _, sub := range subs {
+		name := sub.sink.Name()
+		if len(sub.types) > 0 && !slices.Contains(sub.types, event.Type) {
+			continue
+		}
+		if len(sinks) > 0 && !slices.Contains(sinks, name) {
+			continue
+		}
+		if err := sub.sink.Handle(ctx, event); err != nil {
+			failed = append(failed, name)
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return failed, errors.Join(errs...)
+}
+
+// EventDispatcher publishes the outbox events on the event bus
+type EventDispatcher struct {
+	repo repository.OutboxRepository
+	bus  *EventBus
+}
+
+func NewEventDispatcher(repo repository.OutboxRepository, bus *EventBus) *EventDispatcher {
+	return &EventDispatcher{repo: repo, bus: bus}
+}
+
+// Dispatch publishes the outbox events that are due and returns how many every sink took. An
+// event that a sink failed to take is published again to that sink, after 10 seconds and then
+// twice as long each time up to an hour, until it succeeds; sinks therefore see every committed
+// event at least once.
+func (d *EventDispatcher) Dispatch(ctx context.Context, now time.Time) (int, error) {
+	dispatched := 0
+	for {
+		events, err := d.repo.ClaimDue(ctx, now, eventLease, eventBatchSize)
+		if err != nil {
+			return dispatched, err
+		}
+		var (
+			mu    sync.Mutex
+			wg    sync.WaitGroup
+			slots = make(chan struct{}, eventWorkers)
+		)
+		for i := range events {
+			e := &events[i]
+			wg.Add(1)
+			slots <- struct{}{}
+			go func() {
+				defer func() { <-slots; wg.Done() }()
+				if d.publish(ctx, e, now) {
+					mu.Lock()
+					dispatched++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		if len(events) < eventBatchSize {
+			return dispatched, nil
+		}
+	}
+}
+
+// publish hands the event to the sinks that still have to take it and records the outcome
+func (d *EventDispatcher) publish(ctx context.Context, e *entities.OutboxEvent, now time.Time) bool {
+	var sinks []string
+	if e.FailedSinks != "" {
+		sinks = strings.Split(e.FailedSinks, ",")
+	}
+	failed, err := d.bus.Publish(ctx, *e, sinks)
+	if err != nil {
+		e.Attempts++
+		e.FailedSinks = strings.Join(failed, ",")
+		e.LastError = err.Error()
+		e.NextAttemptAt = now.Add(retryBackoff(e.Attempts, eventFirstRetry, eventMaxRetry))
+	} else {
+		dispatchedAt := now
+		e.DispatchedAt = &dispatchedAt
+		e.FailedSinks = ""
+		e.LastError = ""
+	}
+	if err := d.repo.Save(context.WithoutCancel(ctx), e); err != nil {
+		log.Printf("saving outbox event %s failed: %v", e.ID, err)
+		return false
+	}
+	return e.DispatchedAt != nil
+}
+
+// RunEventDispatcher dispatches the outbox every interval until ctx is done
+func RunEventDispatcher(ctx context.Context, d *EventDispatcher, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := d.Dispatch(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			log.Printf("event dispatch failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// retryBackoff is the wait after the given failed attempt: first, then twice as long each time
+// up to limit
+func retryBackoff(attempt int, first, limit time.Duration) time.Duration {
+	d := first
+	for i := 1; i < attempt && d < limit; i++ {
+		d *= 2
+	}
+	return min(d, limit)
+}
+
+// eventEnvelope is the JSON body that carries an event to other systems
+func eventEnvelope(e entities.OutboxEvent) ([]byte, error) {
+	return json.Marshal(dto.WebhookEvent{
+		EventID:    e.ID,
+		Type:       e.Type,
+		HospitalID: e.HospitalID,
+		OccurredAt: e.CreatedAt,
+		Data:       json.RawMessage(e.Data),
+	})
+}
+
+type logSink struct {
+	logger *log.Logger
+}
+
+// NewLogSink logs one line per event, or writes it to the standard logger when logger is nil.
+// The event data is left out, since it holds patient details.
+func NewLogSink(logger *log.Logger) EventSink {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return &logSink{logger: logger}
+}
+
+func (s *logSink) Name() string { return "log" }
+
+func (s *logSink) Handle(ctx context.Context, e entities.OutboxEvent) error {
+	s.logger.Printf("event %s %s hospital=%s subject=%s at=%s", e.ID, e.Type, e.HospitalID, e.SubjectID, e.CreatedAt.Format(time.RFC3339))
+	return nil
+}
+
+type httpSink struct {
+	name   string
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewHTTPSink POSTs every event to url in the body and with the headers of a webhook; they are
+// signed like webhooks when secret is not empty. Any 2xx response means the event was taken. A
+// client that times out after 10 seconds is used when client is nil.
+func NewHTTPSink(name, url, secret string, client *http.Client) EventSink {
+	if client == nil {
+		client = &http.Client{Timeout: webhookTimeout}
+	}
+	return &httpSink{name: name, url: url, secret: secret, client: client}
+}
+
+func (s *httpSink) Name() string { return s.name }
+
+func (s *httpSink) Handle(ctx context.Context, e entities.OutboxEvent) error {
+	body, err := eventEnvelope(e)
+	if err != nil {
+		return err
+	}
+	_, err = postEvent(ctx, s.client, s.url, s.secret, e.ID.String(), e.Type, body, time.Now())
+	return err
+}
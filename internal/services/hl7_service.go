@@ -0,0 +1,374 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/hl7"
+	"go-hospital-api/internal/repository"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const hl7MessageLimit = 200
+
+// errUnsupportedHL7 marks messages this interface never processes; they are rejected with AR
+var errUnsupportedHL7 = errors.New("unsupported message")
+
+type HL7ServiceInterface interface {
+	Receive(ctx context.Context, raw []byte) []byte
+	List(ctx context.Context, hospitalID, staffID uuid.UUID, status string) ([]entities.HL7Message, error)
+	Replay(ctx context.Context, id, hospitalID, staffID uuid.UUID) (*entities.HL7Message, error)
+}
+
+// HL7Service applies ADT messages from the hospital's legacy HIS. Received messages change
+// patients on behalf of the interface staff account and belong to its hospital.
+type HL7Service struct {
+	repo             repository.HL7MessageRepository
+	tx               repository.Transactor
+	patientService   PatientServiceInterface
+	staffRepo        repository.StaffRepository
+	interfaceStaffID uuid.UUID
+	hospitalID       uuid.UUID
+}
+
+func NewHL7Service(repo repository.HL7MessageRepository, tx repository.Transactor, patientService PatientServiceInterface, staffRepo repository.StaffRepository, interfaceStaffID, hospitalID uuid.UUID) HL7ServiceInterface {
+	return &HL7Service{
+		repo:             repo,
+		tx:               tx,
+		patientService:   patientService,
+		staffRepo:        staffRepo,
+		interfaceStaffID: interfaceStaffID,
+		hospitalID:       hospitalID,
+	}
+}
+
+// Receive logs and processes one message and returns its acknowledgment. A resent message whose
+// control ID was already accepted is acknowledged again without being applied twice.
+func (s *HL7Service) Receive(ctx context.Context, raw []byte) []byte {
+	now := time.Now()
+	record := &entities.HL7Message{
+		ID:         uuid.New(),
+		HospitalID: s.hospitalID,
+		Raw:        string(raw),
+		Status:     entities.HL7Received,
+		Attempts:   1,
+		ReceivedAt: now,
+	}
+	msg, err := hl7.Parse(string(raw))
+	if err != nil {
+		record.Status, record.AckCode, record.Error, record.ProcessedAt = entities.HL7Failed, hl7.AckReject, err.Error(), &now
+		if err := s.repo.Create(ctx, record); err != nil {
+			log.Printf("logging unparseable HL7 message failed: %v", err)
+		}
+		return []byte(hl7.ACK(nil, hl7.AckReject, err.Error(), now))
+	}
+	if msh := msg.Segment("MSH"); msh != nil {
+		record.SendingApplication = msh.First(3).Component(1)
+		record.SendingFacility = msh.First(4).Component(1)
+	}
+	code, trigger := msg.Type()
+	record.MessageType = code + "^" + trigger
+	record.ControlID = msg.ControlID()
+
+	if record.ControlID != "" {
+		previous, err := s.repo.FindLatest(ctx, s.hospitalID, record.SendingApplication, record.ControlID)
+		if err != nil {
+			return []byte(hl7.ACK(msg, hl7.AckError, err.Error(), now))
+		}
+		if previous != nil && previous.Status == entities.HL7Processed {
+			return []byte(hl7.ACK(msg, hl7.AckAccept, "duplicate of an accepted message", now))
+		}
+	}
+	if err := s.repo.Create(ctx, record); err != nil {
+		return []byte(hl7.ACK(msg, hl7.AckError, "message could not be logged: "+err.Error(), now))
+	}
+	s.process(ctx, msg, record, s.interfaceStaffID)
+	return []byte(hl7.ACK(msg, record.AckCode, record.Error, time.Now()))
+}
+
+// List returns the newest logged messages, optionally of one status
+func (s *HL7Service) List(ctx context.Context, hospitalID, staffID uuid.UUID, status string) ([]entities.HL7Message, error) {
+	switch status {
+	case "", entities.HL7Received, entities.HL7Processed, entities.HL7Failed:
+	default:
+		return nil, fmt.Errorf("%w: status must be %s, %s or %s", ErrInvalidInput, entities.HL7Received, entities.HL7Processed, entities.HL7Failed)
+	}
+	if err := requireSupervisor(s.staffRepo, staffID, "read the HL7 message log"); err != nil {
+		return nil, err
+	}
+	return s.repo.List(ctx, hospitalID, status, hl7MessageLimit)
+}
+
+// Replay processes a logged message that was not accepted again, e.g. after the data it refers
+// to has been fixed. Changes are made on behalf of the supervisor who replays it.
+func (s *HL7Service) Replay(ctx context.Context, id, hospitalID, staffID uuid.UUID) (*entities.HL7Message, error) {
+	if err := requireSupervisor(s.staffRepo, staffID, "replay HL7 messages"); err != nil {
+		return nil, err
+	}
+	record, err := s.repo.GetByID(ctx, id, hospitalID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: HL7 message", ErrNotFound)
+		}
+		return nil, err
+	}
+	if record.Status == entities.HL7Processed {
+		return nil, fmt.Errorf("%w: message was already processed", ErrConflict)
+	}
+	msg, err := hl7.Parse(record.Raw)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	record.Attempts++
+	s.process(ctx, msg, record, staffID)
+	return record, nil
+}
+
+// process applies a message and stores the outcome on its log record. The patient changes and
+// the processed status commit in one transaction, so an A40 never leaves the survivor updated
+// without the merge, and an accepted message is never applied again. A failed message changes
+// no patient.
+func (s *HL7Service) process(ctx context.Context, msg *hl7.Message, record *entities.HL7Message, staffID uuid.UUID) {
+	if pv1 := msg.Segment("PV1"); pv1 != nil {
+		record.PatientClass = pv1.First(2).Component(1)
+		location := pv1.First(3)
+		record.Location = strings.Trim(strings.Join([]string{location.Component(1), location.Component(2), location.Component(3)}, "/"), "/")
+		record.VisitNumber = pv1.First(19).Component(1)
+	}
+	err := s.tx.Transaction(ctx, func(ctx context.Context) error {
+		patientID, err := s.apply(ctx, msg, record.HospitalID, staffID)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		record.PatientID, record.ProcessedAt = patientID, &now
+		record.Status, record.AckCode, record.Error = entities.HL7Processed, hl7.AckAccept, ""
+		return s.repo.Save(ctx, record)
+	})
+	if err != nil {
+		// The patient changes were rolled back; a patient the message registered does not exist
+		now := time.Now()
+		record.PatientID, record.ProcessedAt = nil, &now
+		if errors.Is(err, errUnsupportedHL7) {
+			record.Status, record.AckCode, record.Error = entities.HL7Failed, hl7.AckReject, err.Error()
+		} else {
+			record.Status, record.AckCode, record.Error = entities.HL7Failed, hl7.AckError, err.Error()
+		}
+		if err := s.repo.Save(ctx, record); err != nil {
+			log.Printf("saving outcome of HL7 message %s failed: %v", record.ID, err)
+		}
+	}
+	if record.Status == entities.HL7Failed {
+		log.Printf("HL7 %s %s from %s failed: %s", record.MessageType, record.ControlID, record.SendingApplication, record.Error)
+	}
+}
+
+func (s *HL7Service) apply(ctx context.Context, msg *hl7.Message, hospitalID, staffID uuid.UUID) (*uuid.UUID, error) {
+	code, trigger := msg.Type()
+	if code != "ADT" {
+		return nil, fmt.Errorf("%w: message type %s^%s", errUnsupportedHL7, code, trigger)
+	}
+	pid := msg.Segment("PID")
+	switch trigger {
+	case "A01", "A04", "A08", "A40":
+		if pid == nil {
+			return nil, fmt.Errorf("%w: PID segment is missing", ErrInvalidInput)
+		}
+	default:
+		return nil, fmt.Errorf("%w: trigger event %s", errUnsupportedHL7, trigger)
+	}
+	survivorID, err := s.upsert(ctx, pid, hospitalID, staffID)
+	if err != nil || trigger != "A40" {
+		return survivorID, err
+	}
+
+	mrg := msg.Segment("MRG")
+	if mrg == nil {
+		return nil, fmt.Errorf("%w: MRG segment is missing", ErrInvalidInput)
+	}
+	ids := hl7Identifiers(mrg.Repetitions(1))
+	if ids.hn == "" {
+		return nil, fmt.Errorf("%w: MRG-1 has no medical record number", ErrInvalidInput)
+	}
+	prior, err := s.findByHN(ctx, hospitalID, ids.hn)
+	if err != nil {
+		return nil, err
+	}
+	if prior == nil {
+		return nil, fmt.Errorf("%w: prior patient with HN %s", ErrNotFound, ids.hn)
+	}
+	return survivorID, s.patientService.Merge(ctx, prior.ID, *survivorID, hospitalID, staffID)
+}
+
+// upsert updates the patient with the PID's HN, or registers one when there is none
+func (s *HL7Service) upsert(ctx context.Context, pid *hl7.Segment, hospitalID, staffID uuid.UUID) (*uuid.UUID, error) {
+	hn, req, err := patientFromPID(pid)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.findByHN(ctx, hospitalID, hn)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		patient := entities.Patient{HospitalID: hospitalID, PatientHN: hn}
+		applyPatientUpdate(&patient, req)
+		if err := s.patientService.Create(ctx, &patient, staffID); err != nil {
+			return nil, err
+		}
+		return &patient.ID, nil
+	}
+	if _, err := s.patientService.Update(ctx, existing.ID, hospitalID, staffID, existing.Version, req); err != nil {
+		return &existing.ID, err
+	}
+	return &existing.ID, nil
+}
+
+func (s *HL7Service) findByHN(ctx context.Context, hospitalID uuid.UUID, hn string) (*entities.Patient, error) {
+	patients, err := s.patientService.Search(ctx, dto.PatientSearchCriteria{HospitalID: hospitalID, PatientHN: &hn})
+	if err != nil || len(patients) == 0 {
+		return nil, err
+	}
+	return &patients[0], nil
+}
+
+type hl7PatientIdentifiers struct {
+	hn, nationalID, passportID string
+	hasNationalID, hasPassport bool
+}
+
+// hl7Identifiers reads a CX identifier list. The HN is the MR identifier, or the first untyped one.
+func hl7Identifiers(list []hl7.Field) hl7PatientIdentifiers {
+	var ids hl7PatientIdentifiers
+	untyped := ""
+	for _, f := range list {
+		value := strings.TrimSpace(f.Component(1))
+		switch typ := f.Component(5); {
+		case typ == "MR":
+			ids.hn = value
+		case typ == "NI" || typ == "CZ" || strings.HasPrefix(typ, "NN"):
+			ids.nationalID, ids.hasNationalID = value, true
+		case typ == "PPN":
+			ids.passportID, ids.hasPassport = value, true
+		case typ == "" && untyped == "":
+			untyped = value
+		}
+	}
+	if ids.hn == "" {
+		ids.hn = untyped
+	}
+	return ids
+}
+
+// patientFromPID reads the HN and the demographics of a PID segment. Empty fields leave the
+// stored value alone and the HL7 null value "" clears it.
+func patientFromPID(pid *hl7.Segment) (string, dto.PatientUpdateRequest, error) {
+	var req dto.PatientUpdateRequest
+	ids := hl7Identifiers(pid.Repetitions(3))
+	if ids.hn == "" {
+		return "", req, fmt.Errorf("%w: PID-3 has no medical record number", ErrInvalidInput)
+	}
+	if ids.hasNationalID {
+		req.NationalID = &ids.nationalID
+	}
+	if ids.hasPassport {
+		req.PassportID = &ids.passportID
+	}
+
+	seen := map[bool]bool{}
+	for _, name := range pid.Repetitions(5) {
+		family, _, _ := strings.Cut(name.Component(1), "&")
+		given, middle := name.Component(2), name.Component(3)
+		thai := isThai(family + given + middle)
+		if seen[thai] || family+given+middle == "" {
+			continue
+		}
+		seen[thai] = true
+		if thai {
+			req.FirstNameTh, req.MiddleNameTh, req.LastNameTh = &given, &middle, &family
+		} else {
+			req.FirstNameEn, req.MiddleNameEn, req.LastNameEn = &given, &middle, &family
+		}
+	}
+
+	if dob := pid.First(7).Component(1); len(dob) >= 8 {
+		t, err := time.Parse("20060102", dob[:8])
+		if err != nil {
+			return "", req, fmt.Errorf("%w: PID-7 is not a date", ErrInvalidInput)
+		}
+		req.DateOfBirth = &t
+	}
+
+	sex := pid.First(8)
+	if sex.IsNull() || sex.String() != "" {
+		gender := ""
+		switch sex.Component(1) {
+		case "M", "F", "O":
+			gender = sex.Component(1)
+		}
+		req.Gender = &gender
+	}
+
+	telecom := pid.First(13)
+	if telecom.IsNull() {
+		empty := ""
+		req.PhoneNumber, req.Email = &empty, &empty
+	}
+	for _, t := range pid.Repetitions(13) {
+		if t.IsNull() {
+			continue
+		}
+		if t.Component(3) == "Internet" || t.Component(2) == "NET" {
+			if email := t.Component(4); email != "" && req.Email == nil {
+				req.Email = &email
+			}
+			continue
+		}
+		phone := t.Component(1)
+		if phone == "" {
+			phone = t.Component(12)
+		}
+		if phone != "" && req.PhoneNumber == nil {
+			req.PhoneNumber = &phone
+		}
+	}
+	return ids.hn, req, nil
+}
+
+// applyPatientUpdate copies the fields present in req onto a new patient
+func applyPatientUpdate(p *entities.Patient, req dto.PatientUpdateRequest) {
+	set := func(field *string, value *string) {
+		if value != nil {
+			*field = *value
+		}
+	}
+	set(&p.FirstNameTH, req.FirstNameTh)
+	set(&p.MiddleNameTH, req.MiddleNameTh)
+	set(&p.LastNameTH, req.LastNameTh)
+	set(&p.FirstNameEN, req.FirstNameEn)
+	set(&p.MiddleNameEN, req.MiddleNameEn)
+	set(&p.LastNameEN, req.LastNameEn)
+	set(&p.NationalID, req.NationalID)
+	set(&p.PassportID, req.PassportID)
+	set(&p.PhoneNumber, req.PhoneNumber)
+	set(&p.Email, req.Email)
+	set(&p.Gender, req.Gender)
+	p.DateOfBirth = req.DateOfBirth
+}
+
+func isThai(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Thai, r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"fmt"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/utils"
+	"strings"
+)
+
+// RenderInvoicePDF lays out a printable A4 invoice. The invoice must be loaded with its
+// hospital, patient, lines and payments. Names are printed in English because the built-in
+// PDF fonts cannot render Thai; the HN is used when no English name is recorded.
+func RenderInvoicePDF(inv *entities.Invoice) []byte {
+	doc := utils.NewPDFDocument()
+	const left, right = 50.0, utils.PDFPageWidth - 50
+	y := utils.PDFPageHeight - 60
+
+	header := func() {
+		doc.Text(left, y, 16, true, inv.Hospital.Name)
+		doc.TextRight(right, y, 16, true, "INVOICE")
+		y -= 22
+		doc.Text(left, y, 10, false, "Invoice no: "+inv.Number)
+		doc.TextRight(right, y, 10, false, "Date: "+inv.IssuedAt.Format("02 Jan 2006"))
+		y -= 14
+		doc.Text(left, y, 10, false, "Patient: "+invoicePatientName(inv.Patient))
+		doc.TextRight(right, y, 10, false, "HN: "+inv.Patient.PatientHN)
+		y -= 24
+		doc.Text(left, y, 9, true, "Code")
+		doc.Text(left+70, y, 9, true, "Description")
+		doc.TextRight(right-190, y, 9, true, "Qty")
+		doc.TextRight(right-120, y, 9, true, "Unit price")
+		doc.TextRight(right-60, y, 9, true, "Discount")
+		doc.TextRight(right, y, 9, true, "Amount")
+		y -= 6
+		doc.Line(left, y, right, y)
+		y -= 14
+	}
+	header()
+
+	for _, l := range inv.Lines {
+		if y < 140 {
+			doc.AddPage()
+			y = utils.PDFPageHeight - 60
+			header()
+		}
+		desc := l.Description
+		if l.VATable {
+			desc += " *"
+		}
+		if len(desc) > 45 {
+			desc = desc[:42] + "..."
+		}
+		doc.Text(left, y, 9, false, l.Code)
+		doc.Text(left+70, y, 9, false, desc)
+		doc.TextRight(right-190, y, 9, false, fmt.Sprintf("%d", l.Quantity))
+		doc.TextRight(right-120, y, 9, false, utils.FormatBaht(l.UnitPrice))
+		doc.TextRight(right-60, y, 9, false, utils.FormatBaht(l.Discount))
+		doc.TextRight(right, y, 9, false, utils.FormatBaht(l.Amount-l.Discount))
+		y -= 14
+	}
+
+	doc.Line(left, y+4, right, y+4)
+	y -= 10
+	totals := []struct {
+		label  string
+		amount int64
+		bold   bool
+	}{
+		{"Subtotal", inv.Subtotal, false},
+		{"Discount", -inv.DiscountTotal, false},
+		{fmt.Sprintf("VAT %d%% (* items)", ThaiVATPercent), inv.VATAmount, false},
+		{"Total (THB)", inv.Total, true},
+		{"Paid", inv.Paid, false},
+		{"Balance due", inv.Total - inv.Paid, true},
+	}
+	for _, t := range totals {
+		doc.TextRight(right-100, y, 10, t.bold, t.label)
+		doc.TextRight(right, y, 10, t.bold, utils.FormatBaht(t.amount))
+		y -= 14
+	}
+
+	if len(inv.Payments) > 0 {
+		y -= 10
+		doc.Text(left, y, 10, true, "Payments")
+		y -= 14
+		for _, p := range inv.Payments {
+			if y < 50 {
+				doc.AddPage()
+				y = utils.PDFPageHeight - 60
+			}
+			doc.Text(left, y, 9, false, fmt.Sprintf("%s  %s  %s", p.PaidAt.Format("02 Jan 2006"), strings.ToUpper(p.Method), p.Reference))
+			doc.TextRight(right, y, 9, false, utils.FormatBaht(p.Amount))
+			y -= 12
+		}
+	}
+	return doc.Bytes()
+}
+
+func invoicePatientName(p entities.Patient) string {
+	name := strings.Join(strings.Fields(p.FirstNameEN+" "+p.MiddleNameEN+" "+p.LastNameEN), " ")
+	if name == "" {
+		return p.PatientHN
+	}
+	return name
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/repository"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type LabServiceInterface interface {
+	CreateTest(ctx context.Context, test *entities.LabTest) error
+	SearchTests(ctx context.Context, hospitalID uuid.UUID, query string) ([]entities.LabTest, error)
+	CreateOrder(ctx context.Context, order *entities.LabOrder, testIDs []uuid.UUID) error
+	GetOrder(ctx context.Context, id, hospitalID uuid.UUID) (*entities.LabOrder, error)
+	ListOrders(ctx context.Context, patientID, hospitalID uuid.UUID) ([]entities.LabOrder, error)
+	UpdateSpecimen(ctx context.Context, id, hospitalID, staffID uuid.UUID, status, reason string, version int) (*entities.LabOrder, error)
+	EnterResult(ctx context.Context, orderID, itemID, hospitalID uuid.UUID, result *entities.LabResult) error
+}
+
+type LabService struct {
+	repo        repository.LabRepository
+	patientRepo repository.PatientRepository
+	staffRepo   repository.StaffRepository
+}
+
+func NewLabService(repo repository.LabRepository, patientRepo repository.PatientRepository, staffRepo repository.StaffRepository) LabServiceInterface {
+	return &LabService{repo: repo, patientRepo: patientRepo, staffRepo: staffRepo}
+}
+
+// CreateTest adds a lab test to the hospital's catalogue
+func (s *LabService) CreateTest(ctx context.Context, test *entities.LabTest) error {
+	test.Code = strings.TrimSpace(test.Code)
+	test.Name = strings.TrimSpace(test.Name)
+	if test.Code == "" || test.Name == "" {
+		return fmt.Errorf("%w: code and name are required", ErrInvalidInput)
+	}
+	if test.ValueType == "" {
+		test.ValueType = entities.LabValueNumeric
+	}
+	if test.ValueType != entities.LabValueNumeric && test.ValueType != entities.LabValueText {
+		return fmt.Errorf("%w: value_type must be %s or %s", ErrInvalidInput, entities.LabValueNumeric, entities.LabValueText)
+	}
+	if test.RefLow != nil && test.RefHigh != nil && *test.RefLow > *test.RefHigh {
+		return fmt.Errorf("%w: ref_low must not exceed ref_high", ErrInvalidInput)
+	}
+	if test.ID == uuid.Nil {
+		test.ID = uuid.New()
+	}
+	test.Active = true
+	return s.repo.CreateTest(ctx, test)
+}
+
+func (s *LabService) SearchTests(ctx context.Context, hospitalID uuid.UUID, query string) ([]entities.LabTest, error) {
+	return s.repo.SearchTests(ctx, hospitalID, strings.TrimSpace(query))
+}
+
+// CreateOrder places a lab order. Only doctors may order tests, and every test must be
+// an active entry of the hospital's catalogue.
+func (s *LabService) CreateOrder(ctx context.Context, order *entities.LabOrder, testIDs []uuid.UUID) error {
+	if len(testIDs) == 0 {
+		return fmt.Errorf("%w: at least one test is required", ErrInvalidInput)
+	}
+	staff, err := s.staffRepo.GetByID(order.OrderedByID)
+	if err != nil {
+		return err
+	}
+	if staff.Role != entities.RoleDoctor {
+		return fmt.Errorf("%w: only doctors can order lab tests", ErrForbidden)
+	}
+	if err := ensurePatient(ctx, s.patientRepo, order.PatientID, order.HospitalID); err != nil {
+		return err
+	}
+
+	unique := make([]uuid.UUID, 0, len(testIDs))
+	seen := make(map[uuid.UUID]bool, len(testIDs))
+	for _, id := range testIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	tests, err := s.repo.GetTests(ctx, order.HospitalID, unique)
+	if err != nil {
+		return err
+	}
+	if len(tests) != len(unique) {
+		return fmt.Errorf("%w: unknown or inactive lab test", ErrInvalidInput)
+	}
+
+	if order.ID == uuid.Nil {
+		order.ID = uuid.New()
+	}
+	if order.Priority == "" {
+		order.Priority = "routine"
+	}
+	order.Status = entities.LabOrderOrdered
+	order.SpecimenLabel = "LAB-" + strings.ToUpper(order.ID.String()[:8])
+	order.Items = make([]entities.LabOrderItem, len(tests))
+	for i, t := range tests {
+		order.Items[i] = entities.LabOrderItem{ID: uuid.New(), OrderID: order.ID, LabTestID: t.ID, LabTest: t}
+	}
+	order.Version = 1
+	return s.repo.CreateOrder(ctx, order)
+}
+
+func (s *LabService) GetOrder(ctx context.Context, id, hospitalID uuid.UUID) (*entities.LabOrder, error) {
+	order, err := s.repo.GetOrder(ctx, id, hospitalID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: lab order", ErrNotFound)
+		}
+		return nil, err
+	}
+	return order, nil
+}
+
+func (s *LabService) ListOrders(ctx context.Context, patientID, hospitalID uuid.UUID) ([]entities.LabOrder, error) {
+	return s.repo.ListOrders(ctx, patientID, hospitalID)
+}
+
+// UpdateSpecimen tracks the specimen of an order still at version: ordered -> collected ->
+// received. An uncollected order can be cancelled and a collected or received specimen can be
+// rejected, both with a reason.
+func (s *LabService) UpdateSpecimen(ctx context.Context, id, hospitalID, staffID uuid.UUID, status, reason string, version int) (*entities.LabOrder, error) {
+	now := time.Now()
+	var from []string
+	updates := map[string]interface{}{"status": status}
+	switch status {
+	case entities.LabOrderCollected:
+		from = []string{entities.LabOrderOrdered}
+		updates["collected_at"] = now
+		updates["collected_by_id"] = staffID
+	case entities.LabOrderReceived:
+		from = []string{entities.LabOrderCollected}
+		updates["received_at"] = now
+		updates["received_by_id"] = staffID
+	case entities.LabOrderRejected, entities.LabOrderCancelled:
+		if strings.TrimSpace(reason) == "" {
+			return nil, fmt.Errorf("%w: reason is required", ErrInvalidInput)
+		}
+		from = []string{entities.LabOrderCollected, entities.LabOrderReceived}
+		if status == entities.LabOrderCancelled {
+			from = []string{entities.LabOrderOrdered}
+		}
+		updates["status_reason"] = reason
+	default:
+		return nil, fmt.Errorf("%w: unsupported specimen status %q", ErrInvalidInput, status)
+	}
+
+	changed, err := s.repo.UpdateOrderStatus(ctx, id, hospitalID, version, from, updates)
+	if err != nil {
+		return nil, err
+	}
+	order, err := s.GetOrder(ctx, id, hospitalID)
+	if err != nil {
+		return nil, err
+	}
+	if !changed && order.Version != version {
+		return nil, fmt.Errorf("%w: lab order is at version %d", ErrPreconditionFailed, order.Version)
+	}
+	if !changed {
+		return nil, fmt.Errorf("%w: cannot change lab order from %s to %s", ErrConflict, order.Status, status)
+	}
+	return order, nil
+}
+
+// EnterResult records a new result version for an order item. Preliminary results may be
+// replaced until one is finalised; after that only amendments with a reason are accepted.
+func (s *LabService) EnterResult(ctx context.Context, orderID, itemID, hospitalID uuid.UUID, result *entities.LabResult) error {
+	order, err := s.GetOrder(ctx, orderID, hospitalID)
+	if err != nil {
+		return err
+	}
+	var item *entities.LabOrderItem
+	for i := range order.Items {
+		if order.Items[i].ID == itemID {
+			item = &order.Items[i]
+		}
+	}
+	if item == nil {
+		return fmt.Errorf("%w: lab order item", ErrNotFound)
+	}
+	if order.Status != entities.LabOrderReceived && order.Status != entities.LabOrderCompleted {
+		return fmt.Errorf("%w: results can only be entered once the specimen is received (status is %s)", ErrConflict, order.Status)
+	}
+
+	var latest *entities.LabResult
+	if n := len(item.Results); n > 0 {
+		latest = &item.Results[n-1]
+	}
+	finalised := latest != nil && (latest.Status == entities.LabResultFinal || latest.Status == entities.LabResultAmended)
+	switch result.Status {
+	case "", entities.LabResultPreliminary, entities.LabResultFinal:
+		if result.Status == "" {
+			result.Status = entities.LabResultPreliminary
+		}
+		if finalised {
+			return fmt.Errorf("%w: result is already final, submit an amendment instead", ErrConflict)
+		}
+	case entities.LabResultAmended:
+		if !finalised {
+			return fmt.Errorf("%w: only final results can be amended", ErrConflict)
+		}
+		if strings.TrimSpace(result.AmendReason) == "" {
+			return fmt.Errorf("%w: amend_reason is required", ErrInvalidInput)
+		}
+	default:
+		return fmt.Errorf("%w: unsupported result status %q", ErrInvalidInput, result.Status)
+	}
+
+	test := item.LabTest
+	switch test.ValueType {
+	case entities.LabValueNumeric:
+		if result.ValueNumeric == nil {
+			return fmt.Errorf("%w: %s requires a numeric value", ErrInvalidInput, test.Code)
+		}
+	case entities.LabValueText:
+		if strings.TrimSpace(result.ValueText) == "" {
+			return fmt.Errorf("%w: %s requires a text value", ErrInvalidInput, test.Code)
+		}
+	}
+	if result.Unit == "" {
+		result.Unit = test.Unit
+	}
+	if result.RefLow == nil && result.RefHigh == nil && result.RefText == "" {
+		result.RefLow, result.RefHigh, result.RefText = test.RefLow, test.RefHigh, test.RefText
+	}
+	if result.AbnormalFlag == "" {
+		result.AbnormalFlag = AbnormalFlag(*result)
+	}
+
+	result.ID = uuid.New()
+	result.OrderItemID = item.ID
+	// The checks above are repeated under the order's lock, for results entered concurrently
+	err = s.repo.AddResult(ctx, order.ID, result)
+	switch {
+	case errors.Is(err, repository.ErrResultFinalised):
+		return fmt.Errorf("%w: result is already final, submit an amendment instead", ErrConflict)
+	case errors.Is(err, repository.ErrResultNotFinal):
+		return fmt.Errorf("%w: only final results can be amended", ErrConflict)
+	}
+	return err
+}
+
+// AbnormalFlag derives the flag of a result from its reference range: "L" below, "H" above,
+// "A" for a text value that differs from the expected text, and "N" otherwise. It is empty
+// when the result has no reference to compare against.
+func AbnormalFlag(r entities.LabResult) string {
+	if r.ValueNumeric != nil && (r.RefLow != nil || r.RefHigh != nil) {
+		v := *r.ValueNumeric
+		switch {
+		case r.RefLow != nil && v < *r.RefLow:
+			return "L"
+		case r.RefHigh != nil && v > *r.RefHigh:
+			return "H"
+		}
+		return "N"
+	}
+	if r.ValueText != "" && r.RefText != "" {
+		if strings.EqualFold(strings.TrimSpace(r.ValueText), strings.TrimSpace(r.RefText)) {
+			return "N"
+		}
+		return "A"
+	}
+	return ""
+}
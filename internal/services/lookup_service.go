@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// LookupServiceInterface loads records by the IDs of many parents at once and groups them by
+// ID, so that a GraphQL field of a list of patients costs one query rather than one per patient
+type LookupServiceInterface interface {
+	Hospitals(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]entities.Hospital, error)
+	Staff(ctx context.Context, ids []uuid.UUID, hospitalID uuid.UUID) (map[uuid.UUID]entities.Staff, error)
+	AdmissionsByPatient(ctx context.Context, patientIDs []uuid.UUID, hospitalID uuid.UUID) (map[uuid.UUID][]entities.Admission, error)
+	DiagnosesByPatient(ctx context.Context, patientIDs []uuid.UUID, hospitalID uuid.UUID) (map[uuid.UUID][]entities.Diagnosis, error)
+}
+
+type LookupService struct {
+	repo repository.LookupRepository
+}
+
+func NewLookupService(repo repository.LookupRepository) LookupServiceInterface {
+	return &LookupService{repo: repo}
+}
+
+func (s *LookupService) Hospitals(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]entities.Hospital, error) {
+	byID := make(map[uuid.UUID]entities.Hospital)
+	if ids = uniqueIDs(ids); len(ids) == 0 {
+		return byID, nil
+	}
+	hospitals, err := s.repo.Hospitals(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range hospitals {
+		byID[h.ID] = h
+	}
+	return byID, nil
+}
+
+// Staff leaves out staff of other hospitals
+func (s *LookupService) Staff(ctx context.Context, ids []uuid.UUID, hospitalID uuid.UUID) (map[uuid.UUID]entities.Staff, error) {
+	byID := make(map[uuid.UUID]entities.Staff)
+	if ids = uniqueIDs(ids); len(ids) == 0 {
+		return byID, nil
+	}
+	staff, err := s.repo.Staff(ctx, ids, hospitalID)
+	if err != nil {
+		return nil, err
+	}
+	for _, st := range staff {
+		byID[st.ID] = st
+	}
+	return byID, nil
+}
+
+// AdmissionsByPatient returns each patient's admissions, newest first
+func (s *LookupService) AdmissionsByPatient(ctx context.Context, patientIDs []uuid.UUID, hospitalID uuid.UUID) (map[uuid.UUID][]entities.Admission, error) {
+	byPatient := make(map[uuid.UUID][]entities.Admission)
+	if patientIDs = uniqueIDs(patientIDs); len(patientIDs) == 0 {
+		return byPatient, nil
+	}
+	admissions, err := s.repo.AdmissionsByPatients(ctx, patientIDs, hospitalID)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range admissions {
+		byPatient[a.PatientID] = append(byPatient[a.PatientID], a)
+	}
+	return byPatient, nil
+}
+
+// DiagnosesByPatient returns each patient's diagnoses, latest visit first
+func (s *LookupService) DiagnosesByPatient(ctx context.Context, patientIDs []uuid.UUID, hospitalID uuid.UUID) (map[uuid.UUID][]entities.Diagnosis, error) {
+	byPatient := make(map[uuid.UUID][]entities.Diagnosis)
+	if patientIDs = uniqueIDs(patientIDs); len(patientIDs) == 0 {
+		return byPatient, nil
+	}
+	diagnoses, err := s.repo.DiagnosesByPatients(ctx, patientIDs, hospitalID)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range diagnoses {
+		byPatient[d.PatientID] = append(byPatient[d.PatientID], d)
+	}
+	return byPatient, nil
+}
+
+// uniqueIDs drops duplicates and nil IDs, keeping the first occurrence of each
+func uniqueIDs(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(ids))
+	var unique []uuid.UUID
+	for _, id := range ids {
+		if id != uuid.Nil && !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
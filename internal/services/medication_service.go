@@ -0,0 +1,291 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/repository"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	CheckBlocking = "blocking"
+	CheckWarning  = "warning"
+
+	CheckAllergy          = "allergy"
+	CheckDuplicateTherapy = "duplicate_therapy"
+)
+
+// ErrPrescriptionBlocked is returned when a safety check prevents a prescription from being ordered.
+var ErrPrescriptionBlocked = errors.New("prescription blocked by safety checks")
+
+// MedicationCheck is the outcome of one safety check run against a new prescription.
+type MedicationCheck struct {
+	Type     string
+	Severity string
+	Message  string
+}
+
+type MedicationServiceInterface interface {
+	ImportFormulary(ctx context.Context, hospitalID uuid.UUID, r io.Reader) (int, error)
+	SearchDrugs(ctx context.Context, hospitalID uuid.UUID, query string, limit int) ([]entities.Drug, error)
+	AddAllergy(ctx context.Context, allergy *entities.PatientAllergy) error
+	ListAllergies(ctx context.Context, patientID, hospitalID uuid.UUID) ([]entities.PatientAllergy, error)
+	Prescribe(ctx context.Context, prescription *entities.Prescription) ([]MedicationCheck, error)
+	ListPrescriptions(ctx context.Context, patientID, hospitalID uuid.UUID, status string) ([]entities.Prescription, error)
+	UpdateStatus(ctx context.Context, id, hospitalID uuid.UUID, status, reason string, version int) (*entities.Prescription, error)
+}
+
+type MedicationService struct {
+	repo        repository.MedicationRepository
+	patientRepo repository.PatientRepository
+}
+
+func NewMedicationService(repo repository.MedicationRepository, patientRepo repository.PatientRepository) MedicationServiceInterface {
+	return &MedicationService{repo: repo, patientRepo: patientRepo}
+}
+
+// ImportFormulary loads a hospital's drug formulary CSV. Required columns are code, name and
+// generic_name; drug_class, strength, form, route and active are optional.
+func (s *MedicationService) ImportFormulary(ctx context.Context, hospitalID uuid.UUID, r io.Reader) (int, error) {
+	table, err := newCSVTable(r, "code", "name", "generic_name")
+	if err != nil {
+		return 0, err
+	}
+
+	var drugs []entities.Drug
+	for {
+		row, err := table.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		code := table.field(row, "code")
+		if code == "" {
+			continue
+		}
+		generic := table.field(row, "generic_name")
+		if generic == "" {
+			return 0, fmt.Errorf("%w: line %d: generic_name is required", ErrInvalidInput, table.line)
+		}
+		active, err := parseCSVBool(table.field(row, "active"), true)
+		if err != nil {
+			return 0, fmt.Errorf("%w: line %d: %v", ErrInvalidInput, table.line, err)
+		}
+		drugs = append(drugs, entities.Drug{
+			ID:          uuid.New(),
+			HospitalID:  hospitalID,
+			Code:        code,
+			Name:        table.field(row, "name"),
+			GenericName: generic,
+			DrugClass:   table.field(row, "drug_class"),
+			Strength:    table.field(row, "strength"),
+			Form:        table.field(row, "form"),
+			Route:       table.field(row, "route"),
+			Active:      active,
+		})
+	}
+
+	if err := s.repo.UpsertDrugs(ctx, drugs); err != nil {
+		return 0, err
+	}
+	return len(drugs), nil
+}
+
+func (s *MedicationService) SearchDrugs(ctx context.Context, hospitalID uuid.UUID, query string, limit int) ([]entities.Drug, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return s.repo.SearchDrugs(ctx, hospitalID, strings.TrimSpace(query), limit)
+}
+
+// AddAllergy records a drug allergy by generic name or drug class
+func (s *MedicationService) AddAllergy(ctx context.Context, allergy *entities.PatientAllergy) error {
+	allergy.Substance = strings.TrimSpace(allergy.Substance)
+	if allergy.Substance == "" {
+		return fmt.Errorf("%w: substance is required", ErrInvalidInput)
+	}
+	if err := ensurePatient(ctx, s.patientRepo, allergy.PatientID, allergy.HospitalID); err != nil {
+		return err
+	}
+	if allergy.ID == uuid.Nil {
+		allergy.ID = uuid.New()
+	}
+	return s.repo.CreateAllergy(ctx, allergy)
+}
+
+func (s *MedicationService) ListAllergies(ctx context.Context, patientID, hospitalID uuid.UUID) ([]entities.PatientAllergy, error) {
+	return s.repo.ListAllergies(ctx, patientID, hospitalID)
+}
+
+// Prescribe runs allergy and duplicate-therapy checks and stores the order when nothing blocks it.
+// The checks are returned in both cases; blocked orders fail with ErrPrescriptionBlocked.
+func (s *MedicationService) Prescribe(ctx context.Context, prescription *entities.Prescription) ([]MedicationCheck, error) {
+	if strings.TrimSpace(prescription.Dose) == "" || strings.TrimSpace(prescription.Frequency) == "" {
+		return nil, fmt.Errorf("%w: dose and frequency are required", ErrInvalidInput)
+	}
+	if prescription.DurationDays < 0 {
+		return nil, fmt.Errorf("%w: duration_days must not be negative", ErrInvalidInput)
+	}
+	if err := ensurePatient(ctx, s.patientRepo, prescription.PatientID, prescription.HospitalID); err != nil {
+		return nil, err
+	}
+	drug, err := s.repo.GetDrug(ctx, prescription.DrugID, prescription.HospitalID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: drug is not in this hospital's formulary", ErrInvalidInput)
+		}
+		return nil, err
+	}
+	if !drug.Active {
+		return nil, fmt.Errorf("%w: drug %s is inactive in the formulary", ErrInvalidInput, drug.Code)
+	}
+	if prescription.Route == "" {
+		prescription.Route = drug.Route
+	}
+	if prescription.Route == "" {
+		return nil, fmt.Errorf("%w: route is required", ErrInvalidInput)
+	}
+
+	allergies, err := s.repo.ListAllergies(ctx, prescription.PatientID, prescription.HospitalID)
+	if err != nil {
+		return nil, err
+	}
+	active, err := s.repo.ListPrescriptions(ctx, prescription.PatientID, prescription.HospitalID,
+		[]string{entities.PrescriptionOrdered, entities.PrescriptionDispensed})
+	if err != nil {
+		return nil, err
+	}
+
+	checks := CheckPrescription(*drug, allergies, active, time.Now())
+	for _, c := range checks {
+		if c.Severity == CheckBlocking {
+			return checks, ErrPrescriptionBlocked
+		}
+	}
+
+	if prescription.ID == uuid.Nil {
+		prescription.ID = uuid.New()
+	}
+	prescription.Status = entities.PrescriptionOrdered
+	prescription.Version = 1
+	if err := s.repo.CreatePrescription(ctx, prescription); err != nil {
+		return nil, err
+	}
+	prescription.Drug = *drug
+	return checks, nil
+}
+
+// CheckPrescription compares a drug against the patient's allergies and active therapies.
+// An allergy to the generic name is blocking, an allergy to the drug class is a warning, and
+// another active order of the same generic name or class is a duplicate-therapy warning.
+func CheckPrescription(drug entities.Drug, allergies []entities.PatientAllergy, active []entities.Prescription, now time.Time) []MedicationCheck {
+	var checks []MedicationCheck
+	for _, a := range allergies {
+		switch {
+		case strings.EqualFold(a.Substance, drug.GenericName):
+			checks = append(checks, MedicationCheck{
+				Type:     CheckAllergy,
+				Severity: CheckBlocking,
+				Message:  fmt.Sprintf("patient is allergic to %s (%s)", a.Substance, allergyDetail(a)),
+			})
+		case drug.DrugClass != "" && strings.EqualFold(a.Substance, drug.DrugClass):
+			checks = append(checks, MedicationCheck{
+				Type:     CheckAllergy,
+				Severity: CheckWarning,
+				Message:  fmt.Sprintf("patient is allergic to drug class %s (%s)", a.Substance, allergyDetail(a)),
+			})
+		}
+	}
+	for _, p := range active {
+		if p.DurationDays > 0 && now.After(p.CreatedAt.AddDate(0, 0, p.DurationDays)) {
+			continue
+		}
+		switch {
+		case strings.EqualFold(p.Drug.GenericName, drug.GenericName):
+			checks = append(checks, MedicationCheck{
+				Type:     CheckDuplicateTherapy,
+				Severity: CheckWarning,
+				Message:  fmt.Sprintf("patient already has an active order of %s (%s %s)", p.Drug.GenericName, p.Dose, p.Frequency),
+			})
+		case drug.DrugClass != "" && strings.EqualFold(p.Drug.DrugClass, drug.DrugClass):
+			checks = append(checks, MedicationCheck{
+				Type:     CheckDuplicateTherapy,
+				Severity: CheckWarning,
+				Message:  fmt.Sprintf("patient already has an active %s order: %s", drug.DrugClass, p.Drug.GenericName),
+			})
+		}
+	}
+	return checks
+}
+
+func (s *MedicationService) ListPrescriptions(ctx context.Context, patientID, hospitalID uuid.UUID, status string) ([]entities.Prescription, error) {
+	var statuses []string
+	if status != "" {
+		statuses = []string{status}
+	}
+	return s.repo.ListPrescriptions(ctx, patientID, hospitalID, statuses)
+}
+
+// UpdateStatus moves a prescription along ordered -> dispensed -> cancelled if it is still at
+// version. Orders may be cancelled before or after dispensing, but never re-opened.
+func (s *MedicationService) UpdateStatus(ctx context.Context, id, hospitalID uuid.UUID, status, reason string, version int) (*entities.Prescription, error) {
+	now := time.Now()
+	var from []string
+	updates := map[string]interface{}{"status": status}
+	switch status {
+	case entities.PrescriptionDispensed:
+		from = []string{entities.PrescriptionOrdered}
+		updates["dispensed_at"] = now
+	case entities.PrescriptionCancelled:
+		if strings.TrimSpace(reason) == "" {
+			return nil, fmt.Errorf("%w: reason is required to cancel", ErrInvalidInput)
+		}
+		from = []string{entities.PrescriptionOrdered, entities.PrescriptionDispensed}
+		updates["cancelled_at"] = now
+		updates["cancel_reason"] = reason
+	default:
+		return nil, fmt.Errorf("%w: status must be %s or %s", ErrInvalidInput, entities.PrescriptionDispensed, entities.PrescriptionCancelled)
+	}
+
+	changed, err := s.repo.UpdatePrescriptionStatus(ctx, id, hospitalID, version, from, updates)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.repo.GetPrescription(ctx, id, hospitalID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: prescription", ErrNotFound)
+		}
+		return nil, err
+	}
+	if !changed && p.Version != version {
+		return nil, fmt.Errorf("%w: prescription is at version %d", ErrPreconditionFailed, p.Version)
+	}
+	if !changed {
+		return nil, fmt.Errorf("%w: cannot change prescription from %s to %s", ErrConflict, p.Status, status)
+	}
+	return p, nil
+}
+
+func allergyDetail(a entities.PatientAllergy) string {
+	parts := []string{}
+	if a.Severity != "" {
+		parts = append(parts, a.Severity)
+	}
+	if a.Reaction != "" {
+		parts = append(parts, a.Reaction)
+	}
+	if len(parts) == 0 {
+		return "reaction not recorded"
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/repository"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Probabilistic matches at or above AutoLinkScore are linked straight away; those between
+// ReviewScore and AutoLinkScore go to the review queue.
+const (
+	AutoLinkScore = 0.95
+	ReviewScore   = 0.70
+)
+
+const mpiCandidateLimit = 200
+
+// matchField is one compared attribute with its weight in the match score.
+type matchField struct {
+	name   string
+	weight float64
+	value  func(p entities.Patient) string
+}
+
+var matchFields = []matchField{
+	{"date_of_birth", 3, func(p entities.Patient) string {
+		if p.DateOfBirth == nil {
+			return ""
+		}
+		return p.DateOfBirth.Format("2006-01-02")
+	}},
+	{"first_name", 2, func(p entities.Patient) string { return firstNonEmpty(p.FirstNameTH, p.FirstNameEN) }},
+	{"last_name", 2, func(p entities.Patient) string { return firstNonEmpty(p.LastNameTH, p.LastNameEN) }},
+	{"phone_number", 2, func(p entities.Patient) string { return digitsOnly(p.PhoneNumber) }},
+	{"email", 1.5, func(p entities.Patient) string { return p.Email }},
+	{"gender", 0.5, func(p entities.Patient) string { return p.Gender }},
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func digitsOnly(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+func normalizeMatchValue(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// editDistance is the Levenshtein distance between two strings, counted in runes.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+// MatchScore compares two patient records. Equal national IDs or passports are a certain match
+// and conflicting ones rule a match out. Otherwise the score is the weighted share of agreeing
+// attributes among those present in both records; names one typo apart count half. Fewer than
+// three comparable attributes never score. The reasons describe each comparison.
+func MatchScore(a, b entities.Patient) (score float64, method string, reasons []string) {
+	if a.NationalID != "" && b.NationalID != "" {
+		if a.NationalID == b.NationalID {
+			return 1, entities.MatchNationalID, []string{"national_id: equal"}
+		}
+		return 0, "", []string{"national_id: different"}
+	}
+	if a.PassportID != "" && b.PassportID != "" {
+		if strings.EqualFold(a.PassportID, b.PassportID) {
+			return 1, entities.MatchPassport, []string{"passport_id: equal"}
+		}
+		return 0, "", []string{"passport_id: different"}
+	}
+
+	var total, agreed float64
+	compared := 0
+	for _, f := range matchFields {
+		va, vb := normalizeMatchValue(f.value(a)), normalizeMatchValue(f.value(b))
+		if va == "" || vb == "" {
+			continue
+		}
+		compared++
+		total += f.weight
+		switch {
+		case va == vb:
+			agreed += f.weight
+			reasons = append(reasons, f.name+": equal")
+		case (f.name == "first_name" || f.name == "last_name") && editDistance(va, vb) == 1:
+			agreed += f.weight / 2
+			reasons = append(reasons, f.name+": similar")
+		default:
+			reasons = append(reasons, f.name+": different")
+		}
+	}
+	if compared < 3 {
+		return 0, "", append(reasons, "too few attributes to compare")
+	}
+	return agreed / total, entities.MatchProbabilistic, reasons
+}
+
+// EnterpriseRecord is a linked patient record as visible to the asking hospital.
+type EnterpriseRecord struct {
+	Link entities.PatientLink
+	// Visible is false for records of other hospitals whose patient has not consented to data sharing;
+	// only their existence is disclosed then.
+	Visible bool
+}
+
+type MPIServiceInterface interface {
+	Index(ctx context.Context, patientID, hospitalID uuid.UUID) (*entities.PatientLink, int, error)
+	Lookup(ctx context.Context, patientID, hospitalID uuid.UUID) (*entities.PatientLink, []EnterpriseRecord, error)
+	ReviewQueue(ctx context.Context, hospitalID uuid.UUID, status string) ([]entities.MatchCandidate, error)
+	Review(ctx context.Context, candidateID, hospitalID, staffID uuid.UUID, link bool) (*entities.MatchCandidate, error)
+}
+
+type MPIService struct {
+	repo        repository.MPIRepository
+	patientRepo repository.PatientRepository
+	consents    ConsentChecker
+}
+
+func NewMPIService(repo repository.MPIRepository, patientRepo repository.PatientRepository, consents ConsentChecker) MPIServiceInterface {
+	return &MPIService{repo: repo, patientRepo: patientRepo, consents: consents}
+}
+
+// Index links a patient into the enterprise index. It links on the best certain or high-scoring
+// match, queues uncertain matches for review, and otherwise assigns a new enterprise ID.
+// The number of queued matches is returned with the link.
+func (s *MPIService) Index(ctx context.Context, patientID, hospitalID uuid.UUID) (*entities.PatientLink, int, error) {
+	patient, err := s.patientRepo.GetByID(ctx, patientID, hospitalID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, fmt.Errorf("%w: patient", ErrNotFound)
+		}
+		return nil, 0, err
+	}
+	if link, err := s.repo.GetLink(ctx, patient.ID); err == nil {
+		return link, 0, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, 0, err
+	}
+
+	candidates, err := s.repo.FindCandidates(ctx, patient, mpiCandidateLimit)
+	if err != nil {
+		return nil, 0, err
+	}
+	var best *entities.Patient
+	var bestScore float64
+	bestMethod := entities.MatchNew
+	var review []entities.MatchCandidate
+	for i := range candidates {
+		score, method, reasons := MatchScore(*patient, candidates[i])
+		if score >= AutoLinkScore && score > bestScore {
+			best, bestScore, bestMethod = &candidates[i], score, method
+			continue
+		}
+		if score >= ReviewScore {
+			review = append(review, entities.MatchCandidate{
+				ID:                 uuid.New(),
+				PatientID:          patient.ID,
+				CandidatePatientID: candidates[i].ID,
+				Score:              score,
+				Reasons:            strings.Join(reasons, "; "),
+				Status:             entities.CandidatePending,
+			})
+		}
+	}
+
+	var matchedID *uuid.UUID
+	if best != nil {
+		matchedID = &best.ID
+	}
+	link, err := s.repo.Link(ctx, patient, matchedID, bestMethod, bestScore)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := s.repo.AddCandidates(ctx, review); err != nil {
+		return nil, 0, err
+	}
+	return link, len(review), nil
+}
+
+// Lookup returns the patient's enterprise link and every linked record. Records of other
+// hospitals are only shown in full when their patient consents to data sharing there.
+func (s *MPIService) Lookup(ctx context.Context, patientID, hospitalID uuid.UUID) (*entities.PatientLink, []EnterpriseRecord, error) {
+	if err := ensurePatient(ctx, s.patientRepo, patientID, hospitalID); err != nil {
+		return nil, nil, err
+	}
+	link, err := s.repo.GetLink(ctx, patientID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, fmt.Errorf("%w: patient is not in the enterprise index yet", ErrNotFound)
+		}
+		return nil, nil, err
+	}
+	links, err := s.repo.ListLinks(ctx, link.EnterpriseID)
+	if err != nil {
+		return nil, nil, err
+	}
+	records := make([]EnterpriseRecord, len(links))
+	for i, l := range links {
+		records[i] = EnterpriseRecord{Link: l, Visible: l.HospitalID == hospitalID}
+		if !records[i].Visible {
+			consent, err := s.consents.Check(ctx, l.PatientID, l.HospitalID, entities.ConsentDataSharing)
+			if err != nil {
+				return nil, nil, err
+			}
+			records[i].Visible = consent != nil
+		}
+	}
+	return link, records, nil
+}
+
+func (s *MPIService) ReviewQueue(ctx context.Context, hospitalID uuid.UUID, status string) ([]entities.MatchCandidate, error) {
+	if status == "" {
+		status = entities.CandidatePending
+	}
+	return s.repo.ListCandidates(ctx, hospitalID, status)
+}
+
+// Review confirms or rejects a queued match. Confirming merges both records' enterprises.
+// Either hospital involved may review.
+func (s *MPIService) Review(ctx context.Context, candidateID, hospitalID, staffID uuid.UUID, link bool) (*entities.MatchCandidate, error) {
+	candidate, err := s.repo.GetCandidate(ctx, candidateID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: match candidate", ErrNotFound)
+		}
+		return nil, err
+	}
+	if candidate.Patient.HospitalID != hospitalID && candidate.CandidatePatient.HospitalID != hospitalID {
+		return nil, fmt.Errorf("%w: match candidate", ErrNotFound)
+	}
+	if candidate.Status != entities.CandidatePending {
+		return nil, fmt.Errorf("%w: %v", ErrConflict, repository.ErrCandidateNotPending)
+	}
+	status := entities.CandidateRejected
+	if link {
+		status = entities.CandidateLinked
+		// Linking is idempotent, so it goes first: a failure leaves the candidate pending to retry
+		if _, err := s.repo.Link(ctx, &candidate.Patient, &candidate.CandidatePatientID, entities.MatchManual, candidate.Score); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.repo.ResolveCandidate(ctx, candidate, status, staffID, time.Now()); err != nil {
+		if errors.Is(err, repository.ErrCandidateNotPending) {
+			return nil, fmt.Errorf("%w: %v", ErrConflict, err)
+		}
+		return nil, err
+	}
+	return candidate, nil
+}
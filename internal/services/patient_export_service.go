@@ -0,0 +1,293 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/repository"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ExportCSV    = "csv"
+	ExportXLSX   = "xlsx"
+	ExportNDJSON = "ndjson"
+
+	AuditActionPatientExport = "patient_export"
+)
+
+// exportAccess is how a role may export a column
+type exportAccess int
+
+const (
+	exportDenied exportAccess = iota
+	exportMasked
+	exportClear
+)
+
+type exportColumn struct {
+	name  string
+	value func(p entities.Patient) string
+	// restricted is set on columns holding identifiers or contact details. It gives the access
+	// of roles other than doctors, nurses and supervisors, who export every column in clear; a
+	// role it does not list cannot export the column.
+	restricted map[string]exportAccess
+	mask       func(v string) string
+	// field is the patient field of a restricted column, which MaskPatient masks or clears
+	field func(p *entities.Patient) *string
+}
+
+// exportColumns are the columns of a patient export in their default order
+var exportColumns = []exportColumn{
+	{name: "patient_id", value: func(p entities.Patient) string { return p.ID.String() }},
+	{name: "patient_hn", value: func(p entities.Patient) string { return p.PatientHN }},
+	{name: "first_name_th", value: func(p entities.Patient) string { return p.FirstNameTH }},
+	{name: "middle_name_th", value: func(p entities.Patient) string { return p.MiddleNameTH }},
+	{name: "last_name_th", value: func(p entities.Patient) string { return p.LastNameTH }},
+	{name: "first_name_en", value: func(p entities.Patient) string { return p.FirstNameEN }},
+	{name: "middle_name_en", value: func(p entities.Patient) string { return p.MiddleNameEN }},
+	{name: "last_name_en", value: func(p entities.Patient) string { return p.LastNameEN }},
+	{name: "date_of_birth", value: func(p entities.Patient) string {
+		if p.DateOfBirth == nil {
+			return ""
+		}
+		return p.DateOfBirth.Format("2006-01-02")
+	}},
+	{name: "gender", value: func(p entities.Patient) string { return p.Gender }},
+	{
+		name:       "national_id",
+		value:      func(p entities.Patient) string { return p.NationalID },
+		field:      func(p *entities.Patient) *string { return &p.NationalID },
+		restricted: map[string]exportAccess{entities.RoleLabTechnician: exportMasked, entities.RolePharmacist: exportMasked},
+		mask:       func(v string) string { return maskAllBut(v, 4) },
+	},
+	{
+		name:       "passport_id",
+		value:      func(p entities.Patient) string { return p.PassportID },
+		field:      func(p *entities.Patient) *string { return &p.PassportID },
+		restricted: map[string]exportAccess{entities.RoleLabTechnician: exportMasked, entities.RolePharmacist: exportMasked},
+		mask:       func(v string) string { return maskAllBut(v, 3) },
+	},
+	{
+		name:       "phone_number",
+		value:      func(p entities.Patient) string { return p.PhoneNumber },
+		field:      func(p *entities.Patient) *string { return &p.PhoneNumber },
+		restricted: map[string]exportAccess{entities.RoleStaff: exportMasked, entities.RoleLabTechnician: exportMasked, entities.RolePharmacist: exportMasked},
+		mask:       func(v string) string { return maskAllBut(v, 4) },
+	},
+	{
+		name:       "email",
+		value:      func(p entities.Patient) string { return p.Email },
+		field:      func(p *entities.Patient) *string { return &p.Email },
+		restricted: map[string]exportAccess{entities.RoleStaff: exportMasked, entities.RoleLabTechnician: exportMasked, entities.RolePharmacist: exportMasked},
+		mask:       maskEmail,
+	},
+	{name: "created_at", value: func(p entities.Patient) string { return p.CreatedAt.UTC().Format(time.RFC3339) }},
+	{name: "updated_at", value: func(p entities.Patient) string { return p.UpdatedAt.UTC().Format(time.RFC3339) }},
+}
+
+// access returns how the role may export the column
+func (c exportColumn) access(role string) exportAccess {
+	switch role {
+	case entities.RoleDoctor, entities.RoleNurse, entities.RoleSupervisor:
+		return exportClear
+	}
+	if c.restricted == nil {
+		return exportClear
+	}
+	return c.restricted[role]
+}
+
+// MaskPatient returns the patient as a staff member of the role may see it in search results,
+// by the rules of exports: columns the role may only export masked are masked, and those it
+// cannot export are cleared.
+func MaskPatient(p entities.Patient, role string) entities.Patient {
+	for _, c := range exportColumns {
+		if c.restricted == nil {
+			continue
+		}
+		v := c.field(&p)
+		switch c.access(role) {
+		case exportDenied:
+			*v = ""
+		case exportMasked:
+			if *v != "" {
+				*v = c.mask(*v)
+			}
+		}
+	}
+	return p
+}
+
+// maskAllBut replaces all but the last keep characters of v with asterisks
+func maskAllBut(v string, keep int) string {
+	r := []rune(v)
+	for i := 0; i < len(r)-keep; i++ {
+		r[i] = '*'
+	}
+	return string(r)
+}
+
+// maskEmail keeps the first character of the mailbox and the domain: s***@example.com
+func maskEmail(v string) string {
+	at := strings.LastIndex(v, "@")
+	if at < 1 {
+		return maskAllBut(v, 0)
+	}
+	r := []rune(v[:at])
+	return string(r[0]) + "***" + v[at:]
+}
+
+// PatientExport is an export that has been authorised and audited and is ready to be written
+type PatientExport struct {
+	Format  string
+	Columns []string
+	Rows    int64
+
+	repo     repository.PatientExportRepository
+	criteria dto.PatientSearchCriteria
+	cols     []exportColumn
+	masked   []bool
+}
+
+type PatientExportServiceInterface interface {
+	Export(ctx context.Context, criteria dto.PatientSearchCriteria, staffID uuid.UUID, format string, columns []string) (*PatientExport, error)
+}
+
+type PatientExportService struct {
+	repo      repository.PatientExportRepository
+	staffRepo repository.StaffRepository
+	auditRepo repository.AuditRepository
+}
+
+func NewPatientExportService(repo repository.PatientExportRepository, staffRepo repository.StaffRepository, auditRepo repository.AuditRepository) PatientExportServiceInterface {
+	return &PatientExportService{repo: repo, staffRepo: staffRepo, auditRepo: auditRepo}
+}
+
+// Export prepares an export of the patients matching the criteria. The columns default to every
+// column the staff member's role may export; columns the role may only see masked are masked.
+// The export is audited before any patient is written, so an export that cannot be audited is
+// never written.
+func (s *PatientExportService) Export(ctx context.Context, criteria dto.PatientSearchCriteria, staffID uuid.UUID, format string, columns []string) (*PatientExport, error) {
+	switch format {
+	case ExportCSV, ExportXLSX, ExportNDJSON:
+	default:
+		return nil, fmt.Errorf("%w: format must be csv, xlsx or ndjson", ErrInvalidInput)
+	}
+	staff, err := s.staffRepo.GetByID(staffID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &PatientExport{Format: format, repo: s.repo, criteria: criteria}
+	if len(columns) == 0 {
+		for _, c := range exportColumns {
+			if c.access(staff.Role) != exportDenied {
+				export.cols = append(export.cols, c)
+			}
+		}
+	} else {
+		seen := make(map[string]bool, len(columns))
+		for _, name := range columns {
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" || seen[name] {
+				continue
+			}
+			seen[name] = true
+			col, ok := findExportColumn(name)
+			if !ok {
+				return nil, fmt.Errorf("%w: unknown column %q", ErrInvalidInput, name)
+			}
+			if col.access(staff.Role) == exportDenied {
+				return nil, fmt.Errorf("%w: role %s cannot export %s", ErrForbidden, staff.Role, name)
+			}
+			export.cols = append(export.cols, col)
+		}
+		if len(export.cols) == 0 {
+			return nil, fmt.Errorf("%w: no columns selected", ErrInvalidInput)
+		}
+	}
+	var maskedNames, clearPII []string
+	for _, c := range export.cols {
+		masked := c.access(staff.Role) == exportMasked
+		export.Columns = append(export.Columns, c.name)
+		export.masked = append(export.masked, masked)
+		if masked {
+			maskedNames = append(maskedNames, c.name)
+		} else if c.restricted != nil {
+			clearPII = append(clearPII, c.name)
+		}
+	}
+
+	if export.Rows, err = s.repo.Count(ctx, criteria); err != nil {
+		return nil, err
+	}
+
+	// Exports that contain identifiers or contact details in clear are reviewed with high priority
+	priority := entities.AuditNormal
+	if len(clearPII) > 0 {
+		priority = entities.AuditHigh
+	}
+	filter, _ := json.Marshal(criteria)
+	details := fmt.Sprintf("%s export of %d patients; columns: %s; masked: %s; criteria: %s",
+		format, export.Rows, strings.Join(export.Columns, ","), strings.Join(maskedNames, ","), filter)
+	event := auditEvent(criteria.HospitalID, staffID, AuditActionPatientExport, priority, nil, details)
+	if err := s.auditRepo.Create(ctx, &event); err != nil {
+		return nil, err
+	}
+	return export, nil
+}
+
+func findExportColumn(name string) (exportColumn, bool) {
+	for _, c := range exportColumns {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return exportColumn{}, false
+}
+
+// ContentType is the media type of the export's format
+func (e *PatientExport) ContentType() string {
+	switch e.Format {
+	case ExportXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	case ExportNDJSON:
+		return "application/x-ndjson"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Write streams the export to w and returns the number of patients written. Patients are read
+// and written one at a time, so the size of an export is not limited by memory.
+func (e *PatientExport) Write(ctx context.Context, w io.Writer) (int64, error) {
+	rw, err := newRowWriter(e.Format, w, e.Columns)
+	if err != nil {
+		return 0, err
+	}
+	var n int64
+	values := make([]string, len(e.cols))
+	err = e.repo.Each(ctx, e.criteria, func(p entities.Patient) error {
+		for i, c := range e.cols {
+			values[i] = c.value(p)
+			if e.masked[i] && values[i] != "" {
+				values[i] = c.mask(values[i])
+			}
+		}
+		if err := rw.WriteRow(values); err != nil {
+			return err
+		}
+		n++
+		return nil
+	})
+	if err != nil {
+		return n, err
+	}
+	return n, rw.Close()
+}
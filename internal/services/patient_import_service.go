@@ -0,0 +1,477 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/repository"
+	"io"
+	"log"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxImportFileSize is the largest file accepted for a patient import
+	MaxImportFileSize = 50 << 20
+	importBatchSize   = 500
+	// importSyncRowLimit is the largest import processed while the upload waits; bigger files
+	// are processed in the background.
+	importSyncRowLimit = 1000
+)
+
+// importFields are the patient fields an import can fill, named as in the API
+var importFields = map[string]bool{
+	"patient_hn": true, "first_name_th": true, "middle_name_th": true, "last_name_th": true,
+	"first_name_en": true, "middle_name_en": true, "last_name_en": true, "date_of_birth": true,
+	"national_id": true, "passport_id": true, "phone_number": true, "email": true, "gender": true,
+}
+
+// importAliases are other common header names for the import fields
+var importAliases = map[string]string{
+	"hn":          "patient_hn",
+	"dob":         "date_of_birth",
+	"birth_date":  "date_of_birth",
+	"birthdate":   "date_of_birth",
+	"citizen_id":  "national_id",
+	"id_card":     "national_id",
+	"passport":    "passport_id",
+	"passport_no": "passport_id",
+	"phone":       "phone_number",
+	"mobile":      "phone_number",
+	"sex":         "gender",
+}
+
+type PatientImportServiceInterface interface {
+	Start(ctx context.Context, hospitalID, staffID uuid.UUID, fileName string, data []byte, mapping map[string]string, dryRun bool) (*entities.PatientImport, error)
+	Import(ctx context.Context, hospitalID, staffID uuid.UUID, fileName string, data []byte, mapping map[string]string, dryRun bool, progress func(entities.PatientImport)) (*entities.PatientImport, error)
+	Get(ctx context.Context, id, hospitalID, staffID uuid.UUID) (*entities.PatientImport, error)
+	Errors(ctx context.Context, id, hospitalID, staffID uuid.UUID) ([]entities.PatientImportError, error)
+	FailUnfinished(ctx context.Context) (int64, error)
+}
+
+type PatientImportService struct {
+	repo      repository.PatientImportRepository
+	staffRepo repository.StaffRepository
+	// slots limits how many background imports run at once; the others wait as queued
+	slots chan struct{}
+}
+
+func NewPatientImportService(repo repository.PatientImportRepository, staffRepo repository.StaffRepository) PatientImportServiceInterface {
+	return &PatientImportService{repo: repo, staffRepo: staffRepo, slots: make(chan struct{}, 1)}
+}
+
+// importPlan is a parsed file: the data rows and the column of each mapped field
+type importPlan struct {
+	rows [][]string
+	cols map[string]int
+}
+
+// Start creates an import of the file. Small files are imported before Start returns; larger
+// ones are queued and processed in the background, and the returned import shows their progress.
+func (s *PatientImportService) Start(ctx context.Context, hospitalID, staffID uuid.UUID, fileName string, data []byte, mapping map[string]string, dryRun bool) (*entities.PatientImport, error) {
+	imp, plan, err := s.prepare(ctx, hospitalID, staffID, fileName, data, mapping, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	if imp.TotalRows <= importSyncRowLimit {
+		s.run(ctx, imp, plan, nil)
+		return imp, nil
+	}
+	queued := *imp
+	go func() {
+		ctx := context.WithoutCancel(ctx)
+		s.slots <- struct{}{}
+		defer func() { <-s.slots }()
+		s.run(ctx, imp, plan, nil)
+	}()
+	return &queued, nil
+}
+
+// Import imports the file before returning, reporting progress after every batch
+func (s *PatientImportService) Import(ctx context.Context, hospitalID, staffID uuid.UUID, fileName string, data []byte, mapping map[string]string, dryRun bool, progress func(entities.PatientImport)) (*entities.PatientImport, error) {
+	imp, plan, err := s.prepare(ctx, hospitalID, staffID, fileName, data, mapping, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	s.run(ctx, imp, plan, progress)
+	return imp, nil
+}
+
+func (s *PatientImportService) Get(ctx context.Context, id, hospitalID, staffID uuid.UUID) (*entities.PatientImport, error) {
+	if err := requireSupervisor(s.staffRepo, staffID, "read patient imports"); err != nil {
+		return nil, err
+	}
+	imp, err := s.repo.GetByID(ctx, id, hospitalID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: import", ErrNotFound)
+		}
+		return nil, err
+	}
+	return imp, nil
+}
+
+// Errors returns the problems found so far, ordered by row
+func (s *PatientImportService) Errors(ctx context.Context, id, hospitalID, staffID uuid.UUID) ([]entities.PatientImportError, error) {
+	imp, err := s.Get(ctx, id, hospitalID, staffID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListErrors(ctx, imp.ID)
+}
+
+// FailUnfinished marks imports that were interrupted by a restart as failed. Batches they had
+// already imported stay imported.
+func (s *PatientImportService) FailUnfinished(ctx context.Context) (int64, error) {
+	return s.repo.FailUnfinished(ctx, "interrupted by a server restart")
+}
+
+func (s *PatientImportService) prepare(ctx context.Context, hospitalID, staffID uuid.UUID, fileName string, data []byte, mapping map[string]string, dryRun bool) (*entities.PatientImport, *importPlan, error) {
+	if err := requireSupervisor(s.staffRepo, staffID, "import patients"); err != nil {
+		return nil, nil, err
+	}
+	if len(data) > MaxImportFileSize {
+		return nil, nil, fmt.Errorf("%w: file is larger than %d MB", ErrInvalidInput, MaxImportFileSize>>20)
+	}
+	rows, err := readSpreadsheet(fileName, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil, fmt.Errorf("%w: file is empty", ErrInvalidInput)
+	}
+	cols, err := importColumns(rows[0], mapping)
+	if err != nil {
+		return nil, nil, err
+	}
+	plan := &importPlan{rows: rows[1:], cols: cols}
+	total := 0
+	for _, row := range plan.rows {
+		if !blankRow(row) {
+			total++
+		}
+	}
+
+	imp := &entities.PatientImport{
+		ID:         uuid.New(),
+		HospitalID: hospitalID,
+		StaffID:    staffID,
+		FileName:   path.Base(strings.ReplaceAll(fileName, "\\", "/")),
+		DryRun:     dryRun,
+		Status:     entities.ImportQueued,
+		TotalRows:  total,
+		CreatedAt:  time.Now(),
+	}
+	if err := s.repo.Create(ctx, imp); err != nil {
+		return nil, nil, err
+	}
+	return imp, plan, nil
+}
+
+// importColumns finds the column of each patient field from the header row. mapping names the
+// field of headers that are neither a field name nor a known alias.
+func importColumns(header []string, mapping map[string]string) (map[string]int, error) {
+	byHeader := make(map[string]string, len(mapping))
+	for h, field := range mapping {
+		field = normaliseHeader(field)
+		if !importFields[field] {
+			return nil, fmt.Errorf("%w: mapping refers to unknown patient field %q", ErrInvalidInput, field)
+		}
+		byHeader[normaliseHeader(h)] = field
+	}
+	cols := make(map[string]int)
+	for i, h := range header {
+		key := normaliseHeader(h)
+		field, ok := byHeader[key]
+		if ok {
+			delete(byHeader, key)
+		} else if importFields[key] {
+			field = key
+		} else if field, ok = importAliases[key]; !ok {
+			continue
+		}
+		if _, dup := cols[field]; dup {
+			return nil, fmt.Errorf("%w: more than one column maps to %s", ErrInvalidInput, field)
+		}
+		cols[field] = i
+	}
+	for h := range byHeader {
+		return nil, fmt.Errorf("%w: mapping refers to column %q, which is not in the file", ErrInvalidInput, h)
+	}
+	if _, ok := cols["patient_hn"]; !ok {
+		return nil, fmt.Errorf("%w: the file needs a patient_hn column", ErrInvalidInput)
+	}
+	return cols, nil
+}
+
+func normaliseHeader(h string) string {
+	h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+	return strings.NewReplacer(" ", "_", "-", "_", ".", "").Replace(h)
+}
+
+// importState carries the identifiers seen in earlier rows, to find duplicates within the file
+type importState struct {
+	hnRow         map[string]int
+	nationalIDRow map[string]int
+}
+
+func (s *PatientImportService) run(ctx context.Context, imp *entities.PatientImport, plan *importPlan, progress func(entities.PatientImport)) {
+	started := time.Now()
+	imp.Status, imp.StartedAt = entities.ImportRunning, &started
+	if err := s.repo.Save(ctx, imp); err != nil {
+		s.finish(ctx, imp, err)
+		return
+	}
+	state := &importState{hnRow: map[string]int{}, nationalIDRow: map[string]int{}}
+	for start := 0; start < len(plan.rows); start += importBatchSize {
+		end := min(start+importBatchSize, len(plan.rows))
+		if err := s.runBatch(ctx, imp, plan, start, end, state); err != nil {
+			s.finish(ctx, imp, err)
+			return
+		}
+		if err := s.repo.Save(ctx, imp); err != nil {
+			s.finish(ctx, imp, err)
+			return
+		}
+		if progress != nil {
+			progress(*imp)
+		}
+	}
+	s.finish(ctx, imp, nil)
+}
+
+// runBatch validates rows [start, end) and inserts the valid ones in one transaction, which also
+// rejects the rows whose identifiers are already registered
+func (s *PatientImportService) runBatch(ctx context.Context, imp *entities.PatientImport, plan *importPlan, start, end int, state *importState) error {
+	type candidate struct {
+		row     int
+		patient entities.Patient
+	}
+	var candidates []candidate
+	var rowErrs []entities.PatientImportError
+	processed := 0
+	for i := start; i < end; i++ {
+		if blankRow(plan.rows[i]) {
+			continue
+		}
+		processed++
+		rowNum := i + 2
+		patient, errs := plan.patient(plan.rows[i], rowNum, imp)
+		if len(errs) == 0 {
+			if first, ok := state.hnRow[patient.PatientHN]; ok {
+				errs = append(errs, importError(imp, rowNum, patient.PatientHN, "patient_hn", patient.PatientHN, fmt.Sprintf("HN is also on row %d", first)))
+			}
+			if first, ok := state.nationalIDRow[patient.NationalID]; ok && patient.NationalID != "" {
+				errs = append(errs, importError(imp, rowNum, patient.PatientHN, "national_id", patient.NationalID, fmt.Sprintf("national ID is also on row %d", first)))
+			}
+		}
+		if len(errs) > 0 {
+			rowErrs = append(rowErrs, errs...)
+			imp.FailedRows++
+			continue
+		}
+		state.hnRow[patient.PatientHN] = rowNum
+		if patient.NationalID != "" {
+			state.nationalIDRow[patient.NationalID] = rowNum
+		}
+		candidates = append(candidates, candidate{row: rowNum, patient: patient})
+	}
+
+	patients := make([]entities.Patient, len(candidates))
+	for i, c := range candidates {
+		patients[i] = c.patient
+	}
+	duplicates, err := s.repo.InsertPatients(ctx, imp.HospitalID, patients, imp.StaffID, imp.DryRun)
+	if err != nil {
+		return fmt.Errorf("rows %d to %d were not imported: %w", start+2, end+1, err)
+	}
+	for _, d := range duplicates {
+		c := candidates[d.Index]
+		if d.Column == "patient_hn" {
+			rowErrs = append(rowErrs, importError(imp, c.row, c.patient.PatientHN, "patient_hn", c.patient.PatientHN, "HN is already registered"))
+		} else {
+			rowErrs = append(rowErrs, importError(imp, c.row, c.patient.PatientHN, "national_id", c.patient.NationalID, "national ID is already registered to HN "+d.RegisteredHN))
+		}
+	}
+	imp.FailedRows += len(duplicates)
+	if err := s.repo.AddErrors(ctx, rowErrs); err != nil {
+		return err
+	}
+	imp.ImportedRows += len(patients) - len(duplicates)
+	imp.ProcessedRows += processed
+	return nil
+}
+
+func (s *PatientImportService) finish(ctx context.Context, imp *entities.PatientImport, err error) {
+	now := time.Now()
+	imp.FinishedAt = &now
+	imp.Status = entities.ImportCompleted
+	if err != nil {
+		imp.Status, imp.Error = entities.ImportFailed, err.Error()
+		log.Printf("patient import %s failed: %v", imp.ID, err)
+	}
+	if err := s.repo.Save(context.WithoutCancel(ctx), imp); err != nil {
+		log.Printf("saving patient import %s failed: %v", imp.ID, err)
+	}
+}
+
+// patient builds the patient of one data row and lists what is wrong with it
+func (p *importPlan) patient(row []string, rowNum int, imp *entities.PatientImport) (entities.Patient, []entities.PatientImportError) {
+	get := func(field string) string {
+		i, ok := p.cols[field]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+	patient := entities.Patient{
+		ID:           uuid.New(),
+		HospitalID:   imp.HospitalID,
+		PatientHN:    get("patient_hn"),
+		FirstNameTH:  get("first_name_th"),
+		MiddleNameTH: get("middle_name_th"),
+		LastNameTH:   get("last_name_th"),
+		FirstNameEN:  get("first_name_en"),
+		MiddleNameEN: get("middle_name_en"),
+		LastNameEN:   get("last_name_en"),
+		NationalID:   strings.NewReplacer("-", "", " ", "").Replace(get("national_id")),
+		PassportID:   strings.ToUpper(get("passport_id")),
+		PhoneNumber:  get("phone_number"),
+		Email:        get("email"),
+		Version:      1,
+	}
+	var errs []entities.PatientImportError
+	add := func(column, value, message string) {
+		errs = append(errs, importError(imp, rowNum, patient.PatientHN, column, value, message))
+	}
+
+	if patient.PatientHN == "" {
+		add("patient_hn", "", "patient_hn is required")
+	}
+	if v := get("date_of_birth"); v != "" {
+		dob, err := parseImportDate(v)
+		if err != nil {
+			add("date_of_birth", v, "date_of_birth must be a date such as 1985-02-14 or 14/02/1985")
+		} else {
+			patient.DateOfBirth = &dob
+		}
+	}
+	if patient.NationalID != "" && !validThaiNationalID(patient.NationalID) {
+		add("national_id", get("national_id"), "national_id must be 13 digits with a valid check digit")
+	}
+	if v := get("gender"); v != "" {
+		gender, ok := importGender(v)
+		if !ok {
+			add("gender", v, "gender must be M, F or O")
+		}
+		patient.Gender = gender
+	}
+	if len(errs) == 0 {
+		if err := validatePatient(&patient); err != nil {
+			add("", "", strings.TrimPrefix(err.Error(), ErrInvalidInput.Error()+": "))
+		}
+	}
+	return patient, errs
+}
+
+func importError(imp *entities.PatientImport, row int, hn, column, value, message string) entities.PatientImportError {
+	return entities.PatientImportError{
+		ID:         uuid.New(),
+		ImportID:   imp.ID,
+		Row:        row,
+		PatientHN:  hn,
+		ColumnName: column,
+		Value:      value,
+		Message:    message,
+	}
+}
+
+func blankRow(row []string) bool {
+	for _, v := range row {
+		if strings.TrimSpace(v) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// parseImportDate accepts ISO and day-first dates, YYYYMMDD and Excel date serial numbers.
+// Years of the Buddhist era, as commonly used in Thai records, are converted.
+func parseImportDate(v string) (time.Time, error) {
+	if serial, err := strconv.ParseFloat(v, 64); err == nil && len(v) != 8 {
+		if serial < 1 || serial > 2958465 {
+			return time.Time{}, fmt.Errorf("date serial %s is out of range", v)
+		}
+		return excelSerialDate(serial), nil
+	}
+	for _, layout := range []string{"2006-01-02", "02/01/2006", "2/1/2006", "2006/01/02", "02-01-2006", "20060102", time.RFC3339, "2006-01-02 15:04:05"} {
+		t, err := time.Parse(layout, v)
+		if err != nil {
+			continue
+		}
+		if t.Year() >= 2400 {
+			t = t.AddDate(-543, 0, 0)
+		}
+		return truncateToDate(t), nil
+	}
+	return time.Time{}, fmt.Errorf("unrecognised date %q", v)
+}
+
+func importGender(v string) (string, bool) {
+	switch strings.ToUpper(strings.TrimSpace(v)) {
+	case "M", "MALE", "ชาย":
+		return "M", true
+	case "F", "FEMALE", "หญิง":
+		return "F", true
+	case "O", "OTHER":
+		return "O", true
+	case "U", "UNKNOWN":
+		return "", true
+	}
+	return "", false
+}
+
+// validThaiNationalID checks the length and the check digit of a Thai national ID: the last
+// digit is (11 - sum of the first twelve digits weighted 13 down to 2, mod 11) mod 10.
+func validThaiNationalID(id string) bool {
+	if !isDigits(id, 13) {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 12; i++ {
+		sum += int(id[i]-'0') * (13 - i)
+	}
+	return (11-sum%11)%10 == int(id[12]-'0')
+}
+
+// WriteImportErrorReport writes an import's problems as CSV, one line per problem
+func WriteImportErrorReport(w io.Writer, errs []entities.PatientImportError) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"row", "patient_hn", "column", "value", "message"}); err != nil {
+		return err
+	}
+	for _, e := range errs {
+		if err := cw.Write([]string{strconv.Itoa(e.Row), csvSafe(e.PatientHN), e.ColumnName, csvSafe(e.Value), e.Message}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvSafe keeps spreadsheet programs from running a value that was taken from an uploaded file
+// as a formula.
+func csvSafe(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
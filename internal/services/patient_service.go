@@ -2,13 +2,41 @@ package services
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"go-hospital-api/internal/dto"
 	"go-hospital-api/internal/entities"
 	"go-hospital-api/internal/repository"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
+// FieldChange is one field that differs between two versions of a patient.
+type FieldChange struct {
+	Field string
+	From  string
+	To    string
+}
+
+// PatientHistoryEntry is one version of a patient with the fields it changed.
+type PatientHistoryEntry struct {
+	Version entities.PatientVersion
+	Changes []FieldChange
+}
+
 type PatientServiceInterface interface {
 	Search(ctx context.Context, criteria dto.PatientSearchCriteria) ([]entities.Patient, error)
+	SearchPage(ctx context.Context, criteria dto.PatientSearchCriteria, offset, limit int) ([]entities.Patient, int64, error)
+	Get(ctx context.Context, id, hospitalID uuid.UUID, asOf *time.Time) (*entities.Patient, error)
+	Create(ctx context.Context, patient *entities.Patient, staffID uuid.UUID) error
+	Update(ctx context.Context, id, hospitalID, staffID uuid.UUID, version int, req dto.PatientUpdateRequest) (*entities.Patient, error)
+	History(ctx context.Context, id, hospitalID uuid.UUID) ([]PatientHistoryEntry, error)
+	Merge(ctx context.Context, fromID, intoID, hospitalID, staffID uuid.UUID) error
 }
 
 type PatientService struct {
@@ -22,5 +50,274 @@ func NewPatientService(repo repository.PatientRepository) PatientServiceInterfac
 // Search returns patients filtered by criteria and hospital restriction
 func (s *PatientService) Search(ctx context.Context, criteria dto.PatientSearchCriteria) ([]entities.Patient, error) {
 	// Always filter by hospitalID to restrict results
-	return s.repo.Search(ctx, criteria)
+	return s.repo.Search(ctx, normalizeSearchAreas(criteria))
+}
+
+// SearchPage is Search for one page of limit patients from offset, in HN order, with the number
+// of patients that match in all
+func (s *PatientService) SearchPage(ctx context.Context, criteria dto.PatientSearchCriteria, offset, limit int) ([]entities.Patient, int64, error) {
+	return s.repo.SearchPage(ctx, normalizeSearchAreas(criteria), offset, limit)
+}
+
+// normalizeSearchAreas matches address areas on the normalized name, because they are stored in
+// their canonical spelling
+func normalizeSearchAreas(criteria dto.PatientSearchCriteria) dto.PatientSearchCriteria {
+	if criteria.Province != nil {
+		province := normalizeAreaName(*criteria.Province)
+		criteria.Province = &province
+	}
+	if criteria.District != nil {
+		district := normalizeAreaName(*criteria.District)
+		criteria.District = &district
+	}
+	return criteria
+}
+
+// Get returns a patient of the hospital. With asOf it returns the record as it was at that time,
+// rebuilt from the change history.
+func (s *PatientService) Get(ctx context.Context, id, hospitalID uuid.UUID, asOf *time.Time) (*entities.Patient, error) {
+	patient, err := s.repo.GetByID(ctx, id, hospitalID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: patient", ErrNotFound)
+		}
+		return nil, err
+	}
+	if asOf == nil {
+		return patient, nil
+	}
+	if asOf.Before(patient.CreatedAt) {
+		return nil, fmt.Errorf("%w: patient did not exist at %s", ErrNotFound, asOf.Format(time.RFC3339))
+	}
+	versions, err := s.repo.ListVersions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// A patient without versions has never changed. Before the first recorded version the
+	// earliest one is the best known state: it is the baseline of a record that predates versioning.
+	var match *entities.PatientVersion
+	for i := range versions {
+		if match == nil || !versions[i].ChangedAt.After(*asOf) {
+			match = &versions[i]
+		}
+	}
+	if match != nil {
+		snapshot, err := decodeSnapshot(*match)
+		if err != nil {
+			return nil, err
+		}
+		snapshot.Apply(patient)
+		patient.UpdatedAt = match.ChangedAt
+	}
+	return patient, nil
+}
+
+// Create registers a patient in patient.HospitalID. The HN is required and must be unique within
+// the hospital.
+func (s *PatientService) Create(ctx context.Context, patient *entities.Patient, staffID uuid.UUID) error {
+	for _, field := range []*string{
+		&patient.FirstNameTH, &patient.MiddleNameTH, &patient.LastNameTH,
+		&patient.FirstNameEN, &patient.MiddleNameEN, &patient.LastNameEN,
+		&patient.PatientHN, &patient.NationalID, &patient.PassportID,
+		&patient.PhoneNumber, &patient.Email, &patient.Gender,
+	} {
+		*field = strings.TrimSpace(*field)
+	}
+	patient.Gender = strings.ToUpper(patient.Gender)
+	if patient.DateOfBirth != nil {
+		dob := truncateToDate(*patient.DateOfBirth)
+		patient.DateOfBirth = &dob
+	}
+	if patient.PatientHN == "" {
+		return fmt.Errorf("%w: patient_hn is required", ErrInvalidInput)
+	}
+	if err := validatePatient(patient); err != nil {
+		return err
+	}
+	if patient.ID == uuid.Nil {
+		patient.ID = uuid.New()
+	}
+	patient.Version = 1
+	// The unique index on the hospital and HN rejects the second of two concurrent registrations
+	if err := s.repo.Create(ctx, patient, staffID); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return fmt.Errorf("%w: HN %s is already registered", ErrConflict, patient.PatientHN)
+		}
+		return err
+	}
+	return nil
+}
+
+// Update changes the fields present in the request and records the change in the patient's
+// history. version is the one the caller last saw; a newer stored version fails the update.
+func (s *PatientService) Update(ctx context.Context, id, hospitalID, staffID uuid.UUID, version int, req dto.PatientUpdateRequest) (*entities.Patient, error) {
+	patient, err := s.Get(ctx, id, hospitalID, nil)
+	if err != nil {
+		return nil, err
+	}
+	if patient.Version != version {
+		return nil, fmt.Errorf("%w: patient is at version %d", ErrPreconditionFailed, patient.Version)
+	}
+	before := patient.Snapshot()
+	setTrimmed := func(field *string, value *string) {
+		if value != nil {
+			*field = strings.TrimSpace(*value)
+		}
+	}
+	setTrimmed(&patient.FirstNameTH, req.FirstNameTh)
+	setTrimmed(&patient.MiddleNameTH, req.MiddleNameTh)
+	setTrimmed(&patient.LastNameTH, req.LastNameTh)
+	setTrimmed(&patient.FirstNameEN, req.FirstNameEn)
+	setTrimmed(&patient.MiddleNameEN, req.MiddleNameEn)
+	setTrimmed(&patient.LastNameEN, req.LastNameEn)
+	setTrimmed(&patient.NationalID, req.NationalID)
+	setTrimmed(&patient.PassportID, req.PassportID)
+	setTrimmed(&patient.PhoneNumber, req.PhoneNumber)
+	setTrimmed(&patient.Email, req.Email)
+	setTrimmed(&patient.Gender, req.Gender)
+	patient.Gender = strings.ToUpper(patient.Gender)
+	if req.DateOfBirth != nil {
+		dob := truncateToDate(*req.DateOfBirth)
+		patient.DateOfBirth = &dob
+	}
+	if err := validatePatient(patient); err != nil {
+		return nil, err
+	}
+	if len(DiffPatientSnapshots(before, patient.Snapshot())) == 0 {
+		return patient, nil
+	}
+	if err := s.repo.Update(ctx, patient, staffID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: patient", ErrNotFound)
+		}
+		return nil, mapStaleVersion(err)
+	}
+	return patient, nil
+}
+
+// History returns every version of a patient, newest first, with its field-level changes
+func (s *PatientService) History(ctx context.Context, id, hospitalID uuid.UUID) ([]PatientHistoryEntry, error) {
+	if err := ensurePatient(ctx, s.repo, id, hospitalID); err != nil {
+		return nil, err
+	}
+	versions, err := s.repo.ListVersions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	history := make([]PatientHistoryEntry, len(versions))
+	var previous entities.PatientSnapshot
+	for i, v := range versions {
+		snapshot, err := decodeSnapshot(v)
+		if err != nil {
+			return nil, err
+		}
+		history[len(versions)-1-i] = PatientHistoryEntry{Version: v, Changes: DiffPatientSnapshots(previous, snapshot)}
+		previous = snapshot
+	}
+	return history, nil
+}
+
+// Merge folds a duplicate patient into the surviving one, which keeps its own demographics
+func (s *PatientService) Merge(ctx context.Context, fromID, intoID, hospitalID, staffID uuid.UUID) error {
+	if fromID == intoID {
+		return fmt.Errorf("%w: cannot merge a patient into itself", ErrInvalidInput)
+	}
+	if err := s.repo.Merge(ctx, fromID, intoID, hospitalID, staffID); err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return fmt.Errorf("%w: patient", ErrNotFound)
+		case errors.Is(err, repository.ErrMergeActiveAdmissions):
+			return fmt.Errorf("%w: %v", ErrConflict, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// DiffPatientSnapshots lists the fields that differ between two snapshots, named by their JSON
+// keys. Dates are compared and shown as YYYY-MM-DD.
+func DiffPatientSnapshots(from, to entities.PatientSnapshot) []FieldChange {
+	var changes []FieldChange
+	fv, tv := reflect.ValueOf(from), reflect.ValueOf(to)
+	for i := 0; i < fv.NumField(); i++ {
+		a, b := snapshotValue(fv.Field(i)), snapshotValue(tv.Field(i))
+		if a != b {
+			name := strings.Split(fv.Type().Field(i).Tag.Get("json"), ",")[0]
+			changes = append(changes, FieldChange{Field: name, From: a, To: b})
+		}
+	}
+	return changes
+}
+
+func snapshotValue(v reflect.Value) string {
+	switch value := v.Interface().(type) {
+	case string:
+		return value
+	case *time.Time:
+		if value == nil {
+			return ""
+		}
+		return value.Format("2006-01-02")
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+func decodeSnapshot(v entities.PatientVersion) (entities.PatientSnapshot, error) {
+	var snapshot entities.PatientSnapshot
+	if err := json.Unmarshal([]byte(v.Data), &snapshot); err != nil {
+		return snapshot, fmt.Errorf("decoding version %d of patient %s: %w", v.Version, v.PatientID, err)
+	}
+	return snapshot, nil
+}
+
+func validatePatient(p *entities.Patient) error {
+	if p.FirstNameTH == "" && p.FirstNameEN == "" {
+		return fmt.Errorf("%w: a first name in Thai or English is required", ErrInvalidInput)
+	}
+	if p.NationalID != "" && !isDigits(p.NationalID, 13) {
+		return fmt.Errorf("%w: national_id must be 13 digits", ErrInvalidInput)
+	}
+	if p.Email != "" && !strings.Contains(p.Email, "@") {
+		return fmt.Errorf("%w: invalid email", ErrInvalidInput)
+	}
+	switch p.Gender {
+	case "", "M", "F", "O":
+	default:
+		return fmt.Errorf("%w: gender must be M, F or O", ErrInvalidInput)
+	}
+	if p.DateOfBirth != nil && p.DateOfBirth.After(time.Now()) {
+		return fmt.Errorf("%w: date_of_birth is in the future", ErrInvalidInput)
+	}
+	return nil
+}
+
+func isDigits(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// mapStaleVersion turns a failed conditional write into ErrPreconditionFailed
+func mapStaleVersion(err error) error {
+	if errors.Is(err, repository.ErrStaleVersion) {
+		return fmt.Errorf("%w: %v", ErrPreconditionFailed, err)
+	}
+	return err
+}
+
+// ensurePatient checks that a patient exists within the hospital, hiding other hospitals' patients as not found
+func ensurePatient(ctx context.Context, repo repository.PatientRepository, patientID, hospitalID uuid.UUID) error {
+	if _, err := repo.GetByID(ctx, patientID, hospitalID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: patient", ErrNotFound)
+		}
+		return err
+	}
+	return nil
 }
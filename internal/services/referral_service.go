@@ -0,0 +1,242 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/repository"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultReferralAccessDays is how long an accepting hospital may read the referred record.
+	DefaultReferralAccessDays = 30
+	MaxReferralAccessDays     = 90
+)
+
+// ReferralAcceptance is what the target hospital chooses when accepting a referral.
+type ReferralAcceptance struct {
+	Note       string
+	AccessDays int
+	// RegisterPatient creates a linked patient record in the target hospital under PatientHN.
+	RegisterPatient bool
+	PatientHN       string
+}
+
+type ReferralServiceInterface interface {
+	Create(ctx context.Context, referral *entities.Referral) error
+	Get(ctx context.Context, id, hospitalID uuid.UUID) (*entities.Referral, error)
+	List(ctx context.Context, hospitalID uuid.UUID, direction, status string) ([]entities.Referral, error)
+	Accept(ctx context.Context, id, hospitalID, staffID uuid.UUID, acceptance ReferralAcceptance) (*entities.Referral, error)
+	Reject(ctx context.Context, id, hospitalID, staffID uuid.UUID, note string) (*entities.Referral, error)
+	Cancel(ctx context.Context, id, hospitalID uuid.UUID) (*entities.Referral, error)
+	ReferredPatient(ctx context.Context, id, hospitalID uuid.UUID) (*entities.Referral, error)
+}
+
+type ReferralService struct {
+	repo        repository.ReferralRepository
+	patientRepo repository.PatientRepository
+	consents    ConsentChecker
+}
+
+func NewReferralService(repo repository.ReferralRepository, patientRepo repository.PatientRepository, consents ConsentChecker) ReferralServiceInterface {
+	return &ReferralService{repo: repo, patientRepo: patientRepo, consents: consents}
+}
+
+// Create refers a patient of the caller's hospital to another hospital. Sharing the record needs
+// the patient's data_sharing consent, except for emergency referrals (vital interest).
+func (s *ReferralService) Create(ctx context.Context, referral *entities.Referral) error {
+	referral.Reason = strings.TrimSpace(referral.Reason)
+	referral.ClinicalSummary = strings.TrimSpace(referral.ClinicalSummary)
+	referral.Urgency = strings.ToLower(strings.TrimSpace(referral.Urgency))
+	if referral.Urgency == "" {
+		referral.Urgency = entities.ReferralRoutine
+	}
+	switch referral.Urgency {
+	case entities.ReferralRoutine, entities.ReferralUrgent, entities.ReferralEmergency:
+	default:
+		return fmt.Errorf("%w: urgency must be %s, %s or %s", ErrInvalidInput, entities.ReferralRoutine, entities.ReferralUrgent, entities.ReferralEmergency)
+	}
+	if referral.Reason == "" || referral.ClinicalSummary == "" {
+		return fmt.Errorf("%w: reason and clinical_summary are required", ErrInvalidInput)
+	}
+	if referral.TargetHospitalID == referral.SourceHospitalID {
+		return fmt.Errorf("%w: cannot refer a patient to the same hospital", ErrInvalidInput)
+	}
+	exists, err := s.repo.HospitalExists(ctx, referral.TargetHospitalID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: target hospital", ErrNotFound)
+	}
+	if err := ensurePatient(ctx, s.patientRepo, referral.PatientID, referral.SourceHospitalID); err != nil {
+		return err
+	}
+	if referral.Urgency != entities.ReferralEmergency {
+		if err := RequireConsent(ctx, s.consents, referral.PatientID, referral.SourceHospitalID, entities.ConsentDataSharing); err != nil {
+			return err
+		}
+	}
+	if referral.ID == uuid.Nil {
+		referral.ID = uuid.New()
+	}
+	referral.Status = entities.ReferralPending
+	return s.repo.Create(ctx, referral)
+}
+
+// Get returns a referral to its source or target hospital; others see it as not found
+func (s *ReferralService) Get(ctx context.Context, id, hospitalID uuid.UUID) (*entities.Referral, error) {
+	referral, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: referral", ErrNotFound)
+		}
+		return nil, err
+	}
+	if referral.SourceHospitalID != hospitalID && referral.TargetHospitalID != hospitalID {
+		return nil, fmt.Errorf("%w: referral", ErrNotFound)
+	}
+	return referral, nil
+}
+
+func (s *ReferralService) List(ctx context.Context, hospitalID uuid.UUID, direction, status string) ([]entities.Referral, error) {
+	switch direction {
+	case "":
+		direction = "incoming"
+	case "incoming", "outgoing":
+	default:
+		return nil, fmt.Errorf("%w: direction must be incoming or outgoing", ErrInvalidInput)
+	}
+	return s.repo.ListByHospital(ctx, hospitalID, direction, status)
+}
+
+// Accept grants the target hospital time-limited read access to the referred record and can
+// register the patient there as a linked record with its own HN. Registering fails with a conflict
+// when the target hospital already has a patient with that HN or the patient's national ID.
+func (s *ReferralService) Accept(ctx context.Context, id, hospitalID, staffID uuid.UUID, acceptance ReferralAcceptance) (*entities.Referral, error) {
+	if acceptance.AccessDays == 0 {
+		acceptance.AccessDays = DefaultReferralAccessDays
+	}
+	if acceptance.AccessDays < 0 || acceptance.AccessDays > MaxReferralAccessDays {
+		return nil, fmt.Errorf("%w: access_days must be between 1 and %d", ErrInvalidInput, MaxReferralAccessDays)
+	}
+	acceptance.PatientHN = strings.TrimSpace(acceptance.PatientHN)
+	if acceptance.RegisterPatient && acceptance.PatientHN == "" {
+		return nil, fmt.Errorf("%w: patient_hn is required to register the patient", ErrInvalidInput)
+	}
+	referral, err := s.incoming(ctx, id, hospitalID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	expires := now.AddDate(0, 0, acceptance.AccessDays)
+	resp := repository.ReferralResponse{
+		Status:          entities.ReferralAccepted,
+		StaffID:         staffID,
+		Note:            strings.TrimSpace(acceptance.Note),
+		At:              now,
+		AccessExpiresAt: &expires,
+	}
+	if acceptance.RegisterPatient {
+		resp.LinkedPatient = linkedPatient(referral.Patient, hospitalID, acceptance.PatientHN)
+	}
+	if err := s.repo.Respond(ctx, id, hospitalID, resp); err != nil {
+		return nil, mapReferralError(err)
+	}
+	return s.Get(ctx, id, hospitalID)
+}
+
+func (s *ReferralService) Reject(ctx context.Context, id, hospitalID, staffID uuid.UUID, note string) (*entities.Referral, error) {
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return nil, fmt.Errorf("%w: a note explaining the rejection is required", ErrInvalidInput)
+	}
+	if _, err := s.incoming(ctx, id, hospitalID); err != nil {
+		return nil, err
+	}
+	resp := repository.ReferralResponse{Status: entities.ReferralRejected, StaffID: staffID, Note: note, At: time.Now()}
+	if err := s.repo.Respond(ctx, id, hospitalID, resp); err != nil {
+		return nil, mapReferralError(err)
+	}
+	return s.Get(ctx, id, hospitalID)
+}
+
+// Cancel withdraws a referral the caller's hospital sent before the target has answered
+func (s *ReferralService) Cancel(ctx context.Context, id, hospitalID uuid.UUID) (*entities.Referral, error) {
+	referral, err := s.Get(ctx, id, hospitalID)
+	if err != nil {
+		return nil, err
+	}
+	if referral.SourceHospitalID != hospitalID {
+		return nil, fmt.Errorf("%w: only the referring hospital can cancel a referral", ErrForbidden)
+	}
+	if err := s.repo.Cancel(ctx, id, hospitalID); err != nil {
+		return nil, mapReferralError(err)
+	}
+	return s.Get(ctx, id, hospitalID)
+}
+
+// ReferredPatient gives the target hospital the source patient record while its access lasts
+func (s *ReferralService) ReferredPatient(ctx context.Context, id, hospitalID uuid.UUID) (*entities.Referral, error) {
+	referral, err := s.Get(ctx, id, hospitalID)
+	if err != nil {
+		return nil, err
+	}
+	if referral.SourceHospitalID == hospitalID {
+		return referral, nil
+	}
+	if referral.Status != entities.ReferralAccepted || referral.AccessExpiresAt == nil || time.Now().After(*referral.AccessExpiresAt) {
+		return nil, fmt.Errorf("%w: no current access to the referred patient", ErrForbidden)
+	}
+	return referral, nil
+}
+
+// incoming loads a referral addressed to the caller's hospital
+func (s *ReferralService) incoming(ctx context.Context, id, hospitalID uuid.UUID) (*entities.Referral, error) {
+	referral, err := s.Get(ctx, id, hospitalID)
+	if err != nil {
+		return nil, err
+	}
+	if referral.TargetHospitalID != hospitalID {
+		return nil, fmt.Errorf("%w: only the target hospital can answer a referral", ErrForbidden)
+	}
+	return referral, nil
+}
+
+// linkedPatient copies the demographics of the referred patient into a new record of the target hospital
+func linkedPatient(source entities.Patient, hospitalID uuid.UUID, hn string) *entities.Patient {
+	return &entities.Patient{
+		ID:           uuid.New(),
+		FirstNameTH:  source.FirstNameTH,
+		MiddleNameTH: source.MiddleNameTH,
+		LastNameTH:   source.LastNameTH,
+		FirstNameEN:  source.FirstNameEN,
+		MiddleNameEN: source.MiddleNameEN,
+		LastNameEN:   source.LastNameEN,
+		DateOfBirth:  source.DateOfBirth,
+		PatientHN:    hn,
+		NationalID:   source.NationalID,
+		PassportID:   source.PassportID,
+		PhoneNumber:  source.PhoneNumber,
+		Email:        source.Email,
+		Gender:       source.Gender,
+		HospitalID:   hospitalID,
+	}
+}
+
+func mapReferralError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrReferralNotPending), errors.Is(err, repository.ErrDuplicatePatient):
+		return fmt.Errorf("%w: %v", ErrConflict, err)
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return fmt.Errorf("%w: %v", ErrConflict, repository.ErrDuplicatePatient)
+	}
+	return err
+}
@@ -0,0 +1,313 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/repository"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultRetentionYears applies to hospitals without a retention policy.
+	DefaultRetentionYears = 10
+	// MinRetentionYears is the shortest period medical records must be kept after deletion.
+	MinRetentionYears = 5
+	MaxRetentionYears = 100
+
+	AuditActionPatientDelete   = "patient_delete"
+	AuditActionPatientRestore  = "patient_restore"
+	AuditActionStaffDelete     = "staff_delete"
+	AuditActionStaffRestore    = "staff_restore"
+	AuditActionHospitalDelete  = "hospital_delete"
+	AuditActionHospitalRestore = "hospital_restore"
+	AuditActionRetentionPurge  = "retention_purge"
+)
+
+// PurgeReport counts what one purge run did in a hospital.
+type PurgeReport struct {
+	HospitalID         uuid.UUID
+	Action             string
+	PatientsAnonymised int
+	PatientsDeleted    int
+	StaffAnonymised    int
+}
+
+type RetentionServiceInterface interface {
+	DeletePatient(ctx context.Context, id, hospitalID, staffID uuid.UUID, version int, reason string) error
+	RestorePatient(ctx context.Context, id, hospitalID, staffID uuid.UUID) (*entities.Patient, error)
+	DeletedPatients(ctx context.Context, hospitalID, staffID uuid.UUID) ([]entities.Patient, error)
+	DeleteStaff(ctx context.Context, id, hospitalID, staffID uuid.UUID, version int) error
+	RestoreStaff(ctx context.Context, id, hospitalID, staffID uuid.UUID) (*entities.Staff, error)
+	DeleteHospital(ctx context.Context, id, hospitalID, staffID uuid.UUID, reason string) error
+	RestoreHospital(ctx context.Context, id, hospitalID, staffID uuid.UUID) (*entities.Hospital, error)
+	Policy(ctx context.Context, hospitalID uuid.UUID) (*entities.RetentionPolicy, error)
+	SetPolicy(ctx context.Context, policy *entities.RetentionPolicy) error
+	Purge(ctx context.Context, now time.Time) ([]PurgeReport, error)
+}
+
+type RetentionService struct {
+	repo        repository.RetentionRepository
+	patientRepo repository.PatientRepository
+	staffRepo   repository.StaffRepository
+	auditRepo   repository.AuditRepository
+}
+
+func NewRetentionService(repo repository.RetentionRepository, patientRepo repository.PatientRepository, staffRepo repository.StaffRepository, auditRepo repository.AuditRepository) RetentionServiceInterface {
+	return &RetentionService{repo: repo, patientRepo: patientRepo, staffRepo: staffRepo, auditRepo: auditRepo}
+}
+
+// DeletePatient soft deletes a patient of the hospital at the version the caller last saw. The
+// record disappears from every patient lookup but stays in the database until the retention
+// period has passed.
+func (s *RetentionService) DeletePatient(ctx context.Context, id, hospitalID, staffID uuid.UUID, version int, reason string) error {
+	if err := requireSupervisor(s.staffRepo, staffID, "delete patients"); err != nil {
+		return err
+	}
+	deleted, err := s.patientRepo.SoftDelete(ctx, id, hospitalID, staffID, version)
+	if err != nil {
+		return mapStaleVersion(err)
+	}
+	if !deleted {
+		return fmt.Errorf("%w: patient", ErrNotFound)
+	}
+	event := auditEvent(hospitalID, staffID, AuditActionPatientDelete, entities.AuditNormal, &id, strings.TrimSpace(reason))
+	return s.auditRepo.Create(ctx, &event)
+}
+
+// RestorePatient undoes a deletion, as long as the purge has not anonymised the patient yet
+func (s *RetentionService) RestorePatient(ctx context.Context, id, hospitalID, staffID uuid.UUID) (*entities.Patient, error) {
+	if err := requireSupervisor(s.staffRepo, staffID, "restore patients"); err != nil {
+		return nil, err
+	}
+	restored, err := s.patientRepo.Restore(ctx, id, hospitalID, staffID)
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return nil, fmt.Errorf("%w: the patient's HN has been registered to another patient", ErrConflict)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !restored {
+		return nil, fmt.Errorf("%w: restorable patient", ErrNotFound)
+	}
+	event := auditEvent(hospitalID, staffID, AuditActionPatientRestore, entities.AuditNormal, &id, "")
+	if err := s.auditRepo.Create(ctx, &event); err != nil {
+		return nil, err
+	}
+	return s.patientRepo.GetByID(ctx, id, hospitalID)
+}
+
+func (s *RetentionService) DeletedPatients(ctx context.Context, hospitalID, staffID uuid.UUID) ([]entities.Patient, error) {
+	if err := requireSupervisor(s.staffRepo, staffID, "list deleted patients"); err != nil {
+		return nil, err
+	}
+	return s.patientRepo.ListDeleted(ctx, hospitalID)
+}
+
+// DeleteStaff soft deletes a staff member of the hospital at the version the caller last saw,
+// which also ends their sessions
+func (s *RetentionService) DeleteStaff(ctx context.Context, id, hospitalID, staffID uuid.UUID, version int) error {
+	if id == staffID {
+		return fmt.Errorf("%w: staff cannot delete themselves", ErrInvalidInput)
+	}
+	if err := requireSupervisor(s.staffRepo, staffID, "delete staff"); err != nil {
+		return err
+	}
+	deleted, err := s.staffRepo.Delete(id, hospitalID, version)
+	if err != nil {
+		return mapStaleVersion(err)
+	}
+	if !deleted {
+		return fmt.Errorf("%w: staff", ErrNotFound)
+	}
+	event := auditEvent(hospitalID, staffID, AuditActionStaffDelete, entities.AuditNormal, nil, "staff "+id.String())
+	return s.auditRepo.Create(ctx, &event)
+}
+
+func (s *RetentionService) RestoreStaff(ctx context.Context, id, hospitalID, staffID uuid.UUID) (*entities.Staff, error) {
+	if err := requireSupervisor(s.staffRepo, staffID, "restore staff"); err != nil {
+		return nil, err
+	}
+	restored, err := s.staffRepo.Restore(id, hospitalID)
+	if err != nil {
+		return nil, err
+	}
+	if !restored {
+		return nil, fmt.Errorf("%w: restorable staff", ErrNotFound)
+	}
+	event := auditEvent(hospitalID, staffID, AuditActionStaffRestore, entities.AuditNormal, nil, "staff "+id.String())
+	if err := s.auditRepo.Create(ctx, &event); err != nil {
+		return nil, err
+	}
+	return s.staffRepo.GetByID(id)
+}
+
+// DeleteHospital soft deletes the caller's own hospital; other hospitals are not found. The
+// hospital can no longer be looked up or receive referrals, while its staff and records are
+// kept, and purged under its retention policy, so that a supervisor can restore it.
+func (s *RetentionService) DeleteHospital(ctx context.Context, id, hospitalID, staffID uuid.UUID, reason string) error {
+	if id != hospitalID {
+		return fmt.Errorf("%w: hospital", ErrNotFound)
+	}
+	if err := requireSupervisor(s.staffRepo, staffID, "delete hospitals"); err != nil {
+		return err
+	}
+	deleted, err := s.repo.DeleteHospital(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("%w: hospital", ErrNotFound)
+	}
+	event := auditEvent(hospitalID, staffID, AuditActionHospitalDelete, entities.AuditHigh, nil, strings.TrimSpace(reason))
+	return s.auditRepo.Create(ctx, &event)
+}
+
+// RestoreHospital undoes the deletion of the caller's own hospital
+func (s *RetentionService) RestoreHospital(ctx context.Context, id, hospitalID, staffID uuid.UUID) (*entities.Hospital, error) {
+	if id != hospitalID {
+		return nil, fmt.Errorf("%w: hospital", ErrNotFound)
+	}
+	if err := requireSupervisor(s.staffRepo, staffID, "restore hospitals"); err != nil {
+		return nil, err
+	}
+	hospital, err := s.repo.RestoreHospital(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: deleted hospital", ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	event := auditEvent(hospitalID, staffID, AuditActionHospitalRestore, entities.AuditHigh, nil, "")
+	if err := s.auditRepo.Create(ctx, &event); err != nil {
+		return nil, err
+	}
+	return hospital, nil
+}
+
+// Policy returns the hospital's retention policy, or the default one when none was set
+func (s *RetentionService) Policy(ctx context.Context, hospitalID uuid.UUID) (*entities.RetentionPolicy, error) {
+	policy, err := s.repo.GetPolicy(ctx, hospitalID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &entities.RetentionPolicy{HospitalID: hospitalID, RetentionYears: DefaultRetentionYears, Action: entities.RetentionAnonymise, Version: 1}, nil
+	}
+	return policy, err
+}
+
+// SetPolicy replaces the hospital's retention policy. policy.Version is the version the caller
+// last saw; a newer stored version fails the change.
+func (s *RetentionService) SetPolicy(ctx context.Context, policy *entities.RetentionPolicy) error {
+	if policy.Action == "" {
+		policy.Action = entities.RetentionAnonymise
+	}
+	if policy.Action != entities.RetentionAnonymise && policy.Action != entities.RetentionDelete {
+		return fmt.Errorf("%w: action must be %s or %s", ErrInvalidInput, entities.RetentionAnonymise, entities.RetentionDelete)
+	}
+	if policy.RetentionYears < MinRetentionYears || policy.RetentionYears > MaxRetentionYears {
+		return fmt.Errorf("%w: retention_years must be between %d and %d", ErrInvalidInput, MinRetentionYears, MaxRetentionYears)
+	}
+	if err := requireSupervisor(s.staffRepo, policy.UpdatedByID, "change the retention policy"); err != nil {
+		return err
+	}
+	return mapStaleVersion(s.repo.SavePolicy(ctx, policy))
+}
+
+// Purge applies every hospital's retention policy to records deleted longer ago than its
+// retention period. Patients are anonymised or permanently deleted as the policy says; staff
+// are always anonymised. Everything removed is logged and summarised in an audit event.
+func (s *RetentionService) Purge(ctx context.Context, now time.Time) ([]PurgeReport, error) {
+	hospitals, err := s.repo.Hospitals(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var reports []PurgeReport
+	for _, h := range hospitals {
+		report, err := s.purgeHospital(ctx, h.ID, now)
+		if err != nil {
+			return reports, fmt.Errorf("purging hospital %s: %w", h.ID, err)
+		}
+		if report.PatientsAnonymised+report.PatientsDeleted+report.StaffAnonymised > 0 {
+			reports = append(reports, report)
+		}
+	}
+	return reports, nil
+}
+
+func (s *RetentionService) purgeHospital(ctx context.Context, hospitalID uuid.UUID, now time.Time) (PurgeReport, error) {
+	report := PurgeReport{HospitalID: hospitalID}
+	policy, err := s.Policy(ctx, hospitalID)
+	if err != nil {
+		return report, err
+	}
+	report.Action = policy.Action
+	cutoff := now.AddDate(-policy.RetentionYears, 0, 0)
+
+	patients, err := s.repo.ExpiredPatients(ctx, hospitalID, cutoff)
+	if err != nil {
+		return report, err
+	}
+	for _, p := range patients {
+		if policy.Action == entities.RetentionDelete {
+			if err := s.repo.PurgePatient(ctx, p.ID); err != nil {
+				return report, err
+			}
+			report.PatientsDeleted++
+			log.Printf("retention purge: deleted patient %s of hospital %s (deleted at %s)", p.ID, hospitalID, p.DeletedAt.Time.Format(time.RFC3339))
+			continue
+		}
+		if err := s.repo.AnonymisePatient(ctx, p.ID, now); err != nil {
+			return report, err
+		}
+		report.PatientsAnonymised++
+		log.Printf("retention purge: anonymised patient %s of hospital %s (deleted at %s)", p.ID, hospitalID, p.DeletedAt.Time.Format(time.RFC3339))
+	}
+
+	staff, err := s.repo.ExpiredStaff(ctx, hospitalID, cutoff)
+	if err != nil {
+		return report, err
+	}
+	for _, st := range staff {
+		if err := s.repo.AnonymiseStaff(ctx, st.ID, now); err != nil {
+			return report, err
+		}
+		report.StaffAnonymised++
+		log.Printf("retention purge: anonymised staff %s of hospital %s (deleted at %s)", st.ID, hospitalID, st.DeletedAt.Time.Format(time.RFC3339))
+	}
+
+	if report.PatientsAnonymised+report.PatientsDeleted+report.StaffAnonymised > 0 {
+		details := fmt.Sprintf("%d patients anonymised, %d patients deleted, %d staff anonymised (retention %d years)",
+			report.PatientsAnonymised, report.PatientsDeleted, report.StaffAnonymised, policy.RetentionYears)
+		event := auditEvent(hospitalID, uuid.Nil, AuditActionRetentionPurge, entities.AuditHigh, nil, details)
+		if err := s.auditRepo.Create(ctx, &event); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+// RunPurgeJob runs the retention purge every interval until ctx is cancelled
+func RunPurgeJob(ctx context.Context, svc RetentionServiceInterface, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		reports, err := svc.Purge(ctx, time.Now())
+		if err != nil {
+			log.Printf("retention purge failed: %v", err)
+		}
+		for _, r := range reports {
+			log.Printf("retention purge: hospital %s (%s): %d patients anonymised, %d patients deleted, %d staff anonymised",
+				r.HospitalID, r.Action, r.PatientsAnonymised, r.PatientsDeleted, r.StaffAnonymised)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -1,7 +1,9 @@
 package services
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"go-hospital-api/internal/entities"
 	"go-hospital-api/internal/repository"
 	"time"
@@ -9,14 +11,19 @@ import (
 	"golang.org/x/crypto/bcrypt"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type StaffServiceInterface interface {
 	Create(staff *entities.Staff, plainPassword string) error
 	Login(username, plainPassword string, hospitalID uuid.UUID) (*entities.Staff, error)
 	GetHospitalIDByStaffID(staffID string) (uuid.UUID, error)
+	GetByID(staffID uuid.UUID, hospitalID uuid.UUID) (*entities.Staff, error)
+	SetRole(ctx context.Context, id, hospitalID, staffID uuid.UUID, role string, version int) (*entities.Staff, error)
 }
 
+const AuditActionStaffRole = "staff_role_change"
+
 type StaffService struct {
 	repo repository.StaffRepository
 }
@@ -25,11 +32,21 @@ func NewStaffService(repo repository.StaffRepository) StaffServiceInterface {
 	return &StaffService{repo: repo}
 }
 
-// Create creates a new staff member with hashed password
+// Create registers a new staff member with hashed password. Registration needs no token, so the
+// new staff member always gets the staff role; a supervisor assigns any other role with SetRole.
 func (s *StaffService) Create(staff *entities.Staff, plainPassword string) error {
 	if staff.ID == uuid.Nil {
 		staff.ID = uuid.New()
 	}
+	if staff.Role == "" {
+		staff.Role = entities.RoleStaff
+	}
+	if !isValidRole(staff.Role) {
+		return fmt.Errorf("%w: unknown role %q", ErrInvalidInput, staff.Role)
+	}
+	if staff.Role != entities.RoleStaff {
+		return fmt.Errorf("%w: new staff get the %s role; a supervisor assigns the %s role", ErrInvalidInput, entities.RoleStaff, staff.Role)
+	}
 	hashedPwd, err := bcrypt.GenerateFromPassword([]byte(plainPassword), bcrypt.DefaultCost)
 	if err != nil {
 		return err
@@ -63,3 +80,59 @@ func (s *StaffService) GetHospitalIDByStaffID(staffID string) (uuid.UUID, error)
 
 	return hospitalID, nil
 }
+
+// GetByID returns a staff member of the given hospital; staff of other hospitals are not found
+func (s *StaffService) GetByID(staffID uuid.UUID, hospitalID uuid.UUID) (*entities.Staff, error) {
+	staff, err := s.repo.GetByID(staffID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: staff", ErrNotFound)
+		}
+		return nil, err
+	}
+	if staff.HospitalID != hospitalID {
+		return nil, fmt.Errorf("%w: staff", ErrNotFound)
+	}
+	return staff, nil
+}
+
+// SetRole changes the role of a staff member of the hospital if they are still at version.
+// Only supervisors can change roles, and not their own, so a hospital cannot lose its last
+// supervisor by accident. Every change is a high priority audit event.
+func (s *StaffService) SetRole(ctx context.Context, id, hospitalID, staffID uuid.UUID, role string, version int) (*entities.Staff, error) {
+	if !isValidRole(role) {
+		return nil, fmt.Errorf("%w: unknown role %q", ErrInvalidInput, role)
+	}
+	if id == staffID {
+		return nil, fmt.Errorf("%w: staff cannot change their own role", ErrInvalidInput)
+	}
+	if err := requireSupervisor(s.repo, staffID, "assign roles"); err != nil {
+		return nil, err
+	}
+	before, err := s.GetByID(id, hospitalID)
+	if err != nil {
+		return nil, err
+	}
+	// The event names the role being replaced, which is only known for the version read
+	if before.Version != version {
+		return nil, mapStaleVersion(repository.ErrStaleVersion)
+	}
+	details := fmt.Sprintf("staff %s: %s -> %s", id, before.Role, role)
+	event := auditEvent(hospitalID, staffID, AuditActionStaffRole, entities.AuditHigh, nil, details)
+	staff, err := s.repo.UpdateRole(id, hospitalID, role, version, &event)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: staff", ErrNotFound)
+		}
+		return nil, mapStaleVersion(err)
+	}
+	return staff, nil
+}
+
+func isValidRole(role string) bool {
+	switch role {
+	case entities.RoleStaff, entities.RoleDoctor, entities.RoleNurse, entities.RoleLabTechnician, entities.RolePharmacist, entities.RoleSupervisor:
+		return true
+	}
+	return false
+}
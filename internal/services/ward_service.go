@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/repository"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// WardOccupancy is one ward of the bed board with the active admission of each occupied bed.
+type WardOccupancy struct {
+	Ward       entities.Ward
+	Counts     map[string]int
+	Admissions map[uuid.UUID]entities.Admission
+}
+
+type WardServiceInterface interface {
+	CreateWard(ctx context.Context, ward *entities.Ward) error
+	ListWards(ctx context.Context, hospitalID uuid.UUID) ([]entities.Ward, error)
+	CreateBed(ctx context.Context, bed *entities.Bed) error
+	UpdateBedStatus(ctx context.Context, id, hospitalID uuid.UUID, status string, version int) (*entities.Bed, error)
+	Admit(ctx context.Context, admission *entities.Admission) error
+	Transfer(ctx context.Context, admissionID, hospitalID, toBedID, staffID uuid.UUID) (*entities.Admission, error)
+	Discharge(ctx context.Context, admissionID, hospitalID, staffID uuid.UUID, note string) (*entities.Admission, error)
+	BedBoard(ctx context.Context, hospitalID uuid.UUID) ([]WardOccupancy, error)
+}
+
+type WardService struct {
+	repo        repository.WardRepository
+	patientRepo repository.PatientRepository
+}
+
+func NewWardService(repo repository.WardRepository, patientRepo repository.PatientRepository) WardServiceInterface {
+	return &WardService{repo: repo, patientRepo: patientRepo}
+}
+
+func (s *WardService) CreateWard(ctx context.Context, ward *entities.Ward) error {
+	ward.Code = strings.TrimSpace(ward.Code)
+	ward.Name = strings.TrimSpace(ward.Name)
+	if ward.Code == "" || ward.Name == "" {
+		return fmt.Errorf("%w: code and name are required", ErrInvalidInput)
+	}
+	if ward.ID == uuid.Nil {
+		ward.ID = uuid.New()
+	}
+	if err := s.repo.CreateWard(ctx, ward); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return fmt.Errorf("%w: ward code %s already exists", ErrConflict, ward.Code)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *WardService) ListWards(ctx context.Context, hospitalID uuid.UUID) ([]entities.Ward, error) {
+	return s.repo.ListWards(ctx, hospitalID)
+}
+
+// CreateBed adds an available bed to a ward of the same hospital
+func (s *WardService) CreateBed(ctx context.Context, bed *entities.Bed) error {
+	bed.Label = strings.TrimSpace(bed.Label)
+	if bed.Label == "" {
+		return fmt.Errorf("%w: label is required", ErrInvalidInput)
+	}
+	if _, err := s.repo.GetWard(ctx, bed.WardID, bed.HospitalID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: ward", ErrNotFound)
+		}
+		return err
+	}
+	if bed.ID == uuid.Nil {
+		bed.ID = uuid.New()
+	}
+	bed.Status = entities.BedAvailable
+	bed.Version = 1
+	if err := s.repo.CreateBed(ctx, bed); err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return fmt.Errorf("%w: bed %s already exists in this ward", ErrConflict, bed.Label)
+		}
+		return err
+	}
+	return nil
+}
+
+// UpdateBedStatus sets a bed still at version to available, cleaning or blocked. Occupancy is
+// owned by admissions, so occupied beds cannot be changed here and nothing can be set to
+// occupied.
+func (s *WardService) UpdateBedStatus(ctx context.Context, id, hospitalID uuid.UUID, status string, version int) (*entities.Bed, error) {
+	switch status {
+	case entities.BedAvailable, entities.BedCleaning, entities.BedBlocked:
+	default:
+		return nil, fmt.Errorf("%w: status must be %s, %s or %s", ErrInvalidInput, entities.BedAvailable, entities.BedCleaning, entities.BedBlocked)
+	}
+	changed, err := s.repo.SetBedStatus(ctx, id, hospitalID, version,
+		[]string{entities.BedAvailable, entities.BedCleaning, entities.BedBlocked}, status)
+	if err != nil {
+		return nil, err
+	}
+	bed, err := s.repo.GetBed(ctx, id, hospitalID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: bed", ErrNotFound)
+		}
+		return nil, err
+	}
+	if !changed && bed.Version != version {
+		return nil, fmt.Errorf("%w: bed is at version %d", ErrPreconditionFailed, bed.Version)
+	}
+	if !changed {
+		return nil, fmt.Errorf("%w: bed is %s", ErrConflict, bed.Status)
+	}
+	return bed, nil
+}
+
+// Admit assigns an available bed to a patient of the same hospital
+func (s *WardService) Admit(ctx context.Context, admission *entities.Admission) error {
+	if err := ensurePatient(ctx, s.patientRepo, admission.PatientID, admission.HospitalID); err != nil {
+		return err
+	}
+	if _, err := s.repo.GetBed(ctx, admission.BedID, admission.HospitalID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: bed", ErrNotFound)
+		}
+		return err
+	}
+	if admission.ID == uuid.Nil {
+		admission.ID = uuid.New()
+	}
+	admission.Status = entities.AdmissionActive
+	if admission.AdmittedAt.IsZero() {
+		admission.AdmittedAt = time.Now()
+	}
+	if err := s.repo.Admit(ctx, admission); err != nil {
+		return mapAdmissionError(err)
+	}
+	return nil
+}
+
+func (s *WardService) Transfer(ctx context.Context, admissionID, hospitalID, toBedID, staffID uuid.UUID) (*entities.Admission, error) {
+	if _, err := s.repo.GetBed(ctx, toBedID, hospitalID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: bed", ErrNotFound)
+		}
+		return nil, err
+	}
+	if err := s.repo.Transfer(ctx, admissionID, hospitalID, toBedID, staffID); err != nil {
+		return nil, mapAdmissionError(err)
+	}
+	return s.repo.GetAdmission(ctx, admissionID, hospitalID)
+}
+
+func (s *WardService) Discharge(ctx context.Context, admissionID, hospitalID, staffID uuid.UUID, note string) (*entities.Admission, error) {
+	if err := s.repo.Discharge(ctx, admissionID, hospitalID, staffID, note); err != nil {
+		return nil, mapAdmissionError(err)
+	}
+	return s.repo.GetAdmission(ctx, admissionID, hospitalID)
+}
+
+// BedBoard returns current occupancy of every ward in the hospital
+func (s *WardService) BedBoard(ctx context.Context, hospitalID uuid.UUID) ([]WardOccupancy, error) {
+	wards, err := s.repo.ListWards(ctx, hospitalID)
+	if err != nil {
+		return nil, err
+	}
+	admissions, err := s.repo.ListActiveAdmissions(ctx, hospitalID)
+	if err != nil {
+		return nil, err
+	}
+	byBed := make(map[uuid.UUID]entities.Admission, len(admissions))
+	for _, a := range admissions {
+		byBed[a.BedID] = a
+	}
+
+	board := make([]WardOccupancy, len(wards))
+	for i, w := range wards {
+		occ := WardOccupancy{
+			Ward:       w,
+			Counts:     map[string]int{entities.BedAvailable: 0, entities.BedOccupied: 0, entities.BedCleaning: 0, entities.BedBlocked: 0},
+			Admissions: make(map[uuid.UUID]entities.Admission),
+		}
+		for _, b := range w.Beds {
+			occ.Counts[b.Status]++
+			if a, ok := byBed[b.ID]; ok {
+				occ.Admissions[b.ID] = a
+			}
+		}
+		board[i] = occ
+	}
+	return board, nil
+}
+
+func mapAdmissionError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return fmt.Errorf("%w: admission", ErrNotFound)
+	case errors.Is(err, repository.ErrBedUnavailable),
+		errors.Is(err, repository.ErrPatientAlreadyAdmitted),
+		errors.Is(err, repository.ErrAdmissionNotActive):
+		return fmt.Errorf("%w: %v", ErrConflict, err)
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return fmt.Errorf("%w: bed or patient already has an active admission", ErrConflict)
+	}
+	return err
+}
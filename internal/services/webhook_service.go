@@ -0,0 +1,343 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/repository"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// WebhookMaxAttempts is how often a delivery is tried before it moves to the dead-letter list
+	WebhookMaxAttempts = 10
+	webhookFirstRetry  = 30 * time.Second
+	webhookMaxRetry    = 6 * time.Hour
+	webhookTimeout     = 10 * time.Second
+	// webhookLease keeps a claimed delivery from being sent twice; it must outlast webhookTimeout
+	webhookLease     = time.Minute
+	webhookBatchSize = 100
+	webhookSenders   = 8
+	webhookListLimit = 200
+
+	// Headers of every webhook request
+	WebhookEventIDHeader   = "X-Webhook-Event-Id"
+	WebhookEventTypeHeader = "X-Webhook-Event"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// SignWebhook returns the X-Webhook-Signature of a request body sent at the given Unix time:
+// "sha256=" and the hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the subscription secret.
+// Receivers compute the same value and should reject old timestamps to stop replays.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+type WebhookServiceInterface interface {
+	CreateSubscription(ctx context.Context, sub *entities.WebhookSubscription, staffID uuid.UUID) error
+	ListSubscriptions(ctx context.Context, hospitalID, staffID uuid.UUID) ([]entities.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id, hospitalID, staffID uuid.UUID, version int) error
+	Deliveries(ctx context.Context, hospitalID, staffID uuid.UUID, status string, subscriptionID *uuid.UUID) ([]entities.WebhookDelivery, error)
+	Retry(ctx context.Context, id, hospitalID, staffID uuid.UUID) (*entities.WebhookDelivery, error)
+	Dispatch(ctx context.Context, now time.Time) (int, error)
+}
+
+type WebhookService struct {
+	repo      repository.WebhookRepository
+	staffRepo repository.StaffRepository
+	client    *http.Client
+}
+
+// NewWebhookService sends webhooks with client, or with a client that times out after 10 seconds
+// when client is nil.
+func NewWebhookService(repo repository.WebhookRepository, staffRepo repository.StaffRepository, client *http.Client) WebhookServiceInterface {
+	if client == nil {
+		client = &http.Client{Timeout: webhookTimeout}
+	}
+	return &WebhookService{repo: repo, staffRepo: staffRepo, client: client}
+}
+
+// CreateSubscription checks the URL and event types and generates the secret that signs the payloads
+func (s *WebhookService) CreateSubscription(ctx context.Context, sub *entities.WebhookSubscription, staffID uuid.UUID) error {
+	if err := requireSupervisor(s.staffRepo, staffID, "manage webhooks"); err != nil {
+		return err
+	}
+	u, err := url.Parse(strings.TrimSpace(sub.URL))
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidInput)
+	}
+	sub.URL = u.String()
+	var types []string
+	for _, t := range strings.Split(sub.EventTypes, ",") {
+		t = strings.TrimSpace(t)
+		if t == "" || slices.Contains(types, t) {
+			continue
+		}
+		if !slices.Contains(entities.EventTypes, t) {
+			return fmt.Errorf("%w: unknown event type %q; use one of %s", ErrInvalidInput, t, strings.Join(entities.EventTypes, ", "))
+		}
+		types = append(types, t)
+	}
+	sub.EventTypes = strings.Join(types, ",")
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return err
+	}
+	sub.ID = uuid.New()
+	sub.Secret = "whsec_" + hex.EncodeToString(secret)
+	sub.CreatedByID = staffID
+	sub.Version = 1
+	return s.repo.CreateSubscription(ctx, sub)
+}
+
+func (s *WebhookService) ListSubscriptions(ctx context.Context, hospitalID, staffID uuid.UUID) ([]entities.WebhookSubscription, error) {
+	if err := requireSupervisor(s.staffRepo, staffID, "manage webhooks"); err != nil {
+		return nil, err
+	}
+	return s.repo.ListSubscriptions(ctx, hospitalID)
+}
+
+// DeleteSubscription deletes a subscription at the version the caller last saw
+func (s *WebhookService) DeleteSubscription(ctx context.Context, id, hospitalID, staffID uuid.UUID, version int) error {
+	if err := requireSupervisor(s.staffRepo, staffID, "manage webhooks"); err != nil {
+		return err
+	}
+	deleted, err := s.repo.DeleteSubscription(ctx, id, hospitalID, version)
+	if err != nil {
+		return mapStaleVersion(err)
+	}
+	if !deleted {
+		return fmt.Errorf("%w: webhook subscription", ErrNotFound)
+	}
+	return nil
+}
+
+// Deliveries returns the newest 200 deliveries of the hospital; status dead lists the dead letters
+func (s *WebhookService) Deliveries(ctx context.Context, hospitalID, staffID uuid.UUID, status string, subscriptionID *uuid.UUID) ([]entities.WebhookDelivery, error) {
+	if err := requireSupervisor(s.staffRepo, staffID, "view webhook deliveries"); err != nil {
+		return nil, err
+	}
+	switch status {
+	case "", entities.WebhookPending, entities.WebhookDelivered, entities.WebhookDead:
+	default:
+		return nil, fmt.Errorf("%w: status must be pending, delivered or dead", ErrInvalidInput)
+	}
+	return s.repo.ListDeliveries(ctx, hospitalID, status, subscriptionID, webhookListLimit)
+}
+
+// Retry sends a dead letter again, with a fresh round of attempts
+func (s *WebhookService) Retry(ctx context.Context, id, hospitalID, staffID uuid.UUID) (*entities.WebhookDelivery, error) {
+	if err := requireSupervisor(s.staffRepo, staffID, "retry webhook deliveries"); err != nil {
+		return nil, err
+	}
+	delivery, err := s.repo.GetDelivery(ctx, id, hospitalID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: webhook delivery", ErrNotFound)
+		}
+		return nil, err
+	}
+	if delivery.Status != entities.WebhookDead {
+		return nil, fmt.Errorf("%w: only dead deliveries can be retried; this one is %s", ErrConflict, delivery.Status)
+	}
+	if _, err := s.repo.GetSubscription(ctx, delivery.SubscriptionID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: the subscription was deleted", ErrConflict)
+		}
+		return nil, err
+	}
+	delivery.Status = entities.WebhookPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now()
+	if err := s.repo.SaveDelivery(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Dispatch sends the deliveries that are due and returns how many were sent successfully. The
+// webhook sink queues a delivery for every committed event, and a delivery is retried until it
+// succeeds or runs out of attempts, so receivers see every event at least once and should ignore
+// event IDs they have seen before.
+func (s *WebhookService) Dispatch(ctx context.Context, now time.Time) (int, error) {
+	deliveries, err := s.repo.ClaimDue(ctx, now, webhookLease, webhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	var (
+		mu        sync.Mutex
+		delivered int
+		wg        sync.WaitGroup
+		subs      = make(map[uuid.UUID]*entities.WebhookSubscription)
+		slots     = make(chan struct{}, webhookSenders)
+	)
+	for i := range deliveries {
+		d := &deliveries[i]
+		sub, ok := subs[d.SubscriptionID]
+		if !ok {
+			sub, err = s.repo.GetSubscription(ctx, d.SubscriptionID)
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return delivered, err
+			}
+			subs[d.SubscriptionID] = sub
+		}
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer func() { <-slots; wg.Done() }()
+			if s.deliver(ctx, sub, d, now) {
+				mu.Lock()
+				delivered++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return delivered, nil
+}
+
+// deliver makes one attempt and records its outcome, scheduling the next attempt on failure
+func (s *WebhookService) deliver(ctx context.Context, sub *entities.WebhookSubscription, d *entities.WebhookDelivery, now time.Time) bool {
+	d.Attempts++
+	attemptAt := now
+	d.LastAttemptAt = &attemptAt
+	d.LastStatusCode = 0
+	d.LastError = ""
+	if sub == nil {
+		d.Status = entities.WebhookDead
+		d.LastError = "subscription deleted"
+	} else if status, err := s.send(ctx, sub, d, now); err != nil {
+		d.LastStatusCode = status
+		d.LastError = err.Error()
+		if d.Attempts >= WebhookMaxAttempts {
+			d.Status = entities.WebhookDead
+		} else {
+			d.NextAttemptAt = now.Add(retryBackoff(d.Attempts, webhookFirstRetry, webhookMaxRetry))
+		}
+	} else {
+		d.LastStatusCode = status
+		d.Status = entities.WebhookDelivered
+		d.DeliveredAt = &attemptAt
+	}
+	if err := s.repo.SaveDelivery(context.WithoutCancel(ctx), d); err != nil {
+		log.Printf("saving webhook delivery %s failed: %v", d.ID, err)
+	}
+	return d.Status == entities.WebhookDelivered
+}
+
+// send posts the signed payload; any 2xx response counts as delivered
+func (s *WebhookService) send(ctx context.Context, sub *entities.WebhookSubscription, d *entities.WebhookDelivery, now time.Time) (int, error) {
+	return postEvent(ctx, s.client, sub.URL, sub.Secret, d.EventID.String(), d.EventType, []byte(d.Payload), now)
+}
+
+// postEvent POSTs an event body with the webhook headers, signed when secret is not empty, and
+// returns the response status; any other than 2xx is an error
+func postEvent(ctx context.Context, client *http.Client, url, secret, eventID, eventType string, body []byte, now time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-hospital-api-webhooks/1")
+	req.Header.Set(WebhookEventIDHeader, eventID)
+	req.Header.Set(WebhookEventTypeHeader, eventType)
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(now.Unix(), 10))
+	if secret != "" {
+		req.Header.Set(WebhookSignatureHeader, SignWebhook(secret, now.Unix(), body))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.StatusCode, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	return resp.StatusCode, nil
+}
+
+type webhookSink struct {
+	repo repository.WebhookRepository
+}
+
+// NewWebhookSink queues a delivery of each event for every subscription of its hospital that
+// wants it
+func NewWebhookSink(repo repository.WebhookRepository) EventSink {
+	return &webhookSink{repo: repo}
+}
+
+func (s *webhookSink) Name() string { return "webhooks" }
+
+func (s *webhookSink) Handle(ctx context.Context, e entities.OutboxEvent) error {
+	subs, err := s.repo.ListSubscriptions(ctx, e.HospitalID)
+	if err != nil {
+		return err
+	}
+	var (
+		deliveries []entities.WebhookDelivery
+		payload    []byte
+		now        = time.Now()
+	)
+	for _, sub := range subs {
+		if !sub.Matches(e.Type) {
+			continue
+		}
+		if payload == nil {
+			if payload, err = eventEnvelope(e); err != nil {
+				return err
+			}
+		}
+		deliveries = append(deliveries, entities.WebhookDelivery{
+			ID:             uuid.New(),
+			SubscriptionID: sub.ID,
+			EventID:        e.ID,
+			HospitalID:     e.HospitalID,
+			EventType:      e.Type,
+			Payload:        string(payload),
+			Status:         entities.WebhookPending,
+			NextAttemptAt:  now,
+			CreatedAt:      now,
+		})
+	}
+	return s.repo.CreateDeliveries(ctx, deliveries)
+}
+
+// RunWebhookDispatcher dispatches webhooks every interval until ctx is done
+func RunWebhookDispatcher(ctx context.Context, svc WebhookServiceInterface, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := svc.Dispatch(ctx, time.Now()); err != nil && ctx.Err() == nil {
+			log.Printf("webhook dispatch failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/handlers"
+	"go-hospital-api/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockBillingService struct {
+	GetInvoiceFunc func(ctx context.Context, id, hospitalID uuid.UUID) (*entities.Invoice, error)
+	AddPaymentFunc func(ctx context.Context, payment *entities.Payment) (*entities.Invoice, error)
+}
+
+func (m *mockBillingService) CreatePriceItem(ctx context.Context, item *entities.PriceItem) error {
+	return nil
+}
+
+func (m *mockBillingService) ListPriceItems(ctx context.Context, hospitalID uuid.UUID, query string) ([]entities.PriceItem, error) {
+	return nil, nil
+}
+
+func (m *mockBillingService) PostCharge(ctx context.Context, charge *entities.Charge) error {
+	return nil
+}
+
+func (m *mockBillingService) ListCharges(ctx context.Context, patientID, hospitalID uuid.UUID, status string) ([]entities.Charge, error) {
+	return nil, nil
+}
+
+func (m *mockBillingService) VoidCharge(ctx context.Context, id, hospitalID uuid.UUID) error {
+	return nil
+}
+
+func (m *mockBillingService) CreateInvoice(ctx context.Context, invoice *entities.Invoice, chargeIDs []uuid.UUID, discount services.InvoiceDiscount) error {
+	return nil
+}
+
+func (m *mockBillingService) GetInvoice(ctx context.Context, id, hospitalID uuid.UUID) (*entities.Invoice, error) {
+	return m.GetInvoiceFunc(ctx, id, hospitalID)
+}
+
+func (m *mockBillingService) AddPayment(ctx context.Context, payment *entities.Payment) (*entities.Invoice, error) {
+	return m.AddPaymentFunc(ctx, payment)
+}
+
+func (m *mockBillingService) Balance(ctx context.Context, patientID, hospitalID uuid.UUID) (*services.PatientBalance, error) {
+	return nil, nil
+}
+
+func newBillingRouter(svc services.BillingServiceInterface) *gin.Engine {
+	h := handlers.NewBillingHandler(svc, &mockStaffService2{
+		GetHospitalIDByStaffIDFunc: func(staffID string) (uuid.UUID, error) { return uuid.New(), nil },
+	})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/invoices/:id/pdf", h.InvoicePDFHandler)
+	router.POST("/invoices/:id/payments", h.AddPaymentHandler)
+	return router
+}
+
+func sampleInvoice() *entities.Invoice {
+	return &entities.Invoice{
+		ID:        uuid.New(),
+		Number:    "INV2026-000001",
+		Hospital:  entities.Hospital{Name: "Bangkok General"},
+		Patient:   entities.Patient{PatientHN: "HN001", FirstNameEN: "Somchai", LastNameEN: "Jaidee"},
+		Status:    entities.InvoiceIssued,
+		Subtotal:  50000,
+		VATAmount: 3500,
+		Total:     53500,
+		IssuedAt:  time.Now(),
+		Lines: []entities.InvoiceLine{
+			{Code: "OPD01", Description: "Consultation", Quantity: 1, UnitPrice: 50000, Amount: 50000, VATable: true, VATAmount: 3500},
+		},
+	}
+}
+
+func TestBillingHandler_AddPaymentHandler(t *testing.T) {
+	cases := []struct {
+		name           string
+		addPaymentFunc func(ctx context.Context, payment *entities.Payment) (*entities.Invoice, error)
+		wantStatusCode int
+	}{
+		{
+			name: "positive",
+			addPaymentFunc: func(ctx context.Context, payment *entities.Payment) (*entities.Invoice, error) {
+				inv := sampleInvoice()
+				inv.Paid = payment.Amount
+				inv.Status = entities.InvoicePartiallyPaid
+				return inv, nil
+			},
+			wantStatusCode: http.StatusCreated,
+		},
+		{
+			name: "negative overpayment",
+			addPaymentFunc: func(ctx context.Context, payment *entities.Payment) (*entities.Invoice, error) {
+				return nil, fmt.Errorf("%w: payment exceeds the outstanding balance", services.ErrConflict)
+			},
+			wantStatusCode: http.StatusConflict,
+		},
+		{
+			name: "negative invoice in another hospital",
+			addPaymentFunc: func(ctx context.Context, payment *entities.Payment) (*entities.Invoice, error) {
+				return nil, fmt.Errorf("%w: invoice", services.ErrNotFound)
+			},
+			wantStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var got int64
+			router := newBillingRouter(&mockBillingService{
+				AddPaymentFunc: func(ctx context.Context, payment *entities.Payment) (*entities.Invoice, error) {
+					got = payment.Amount
+					return tc.addPaymentFunc(ctx, payment)
+				},
+			})
+			b, _ := json.Marshal(dto.PaymentCreateRequest{Method: entities.PaymentCash, Amount: 200.50})
+			req := httptest.NewRequest("POST", "/invoices/"+uuid.New().String()+"/payments", bytes.NewReader(b))
+			req.Header.Set("Authorization", generateValidToken())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Equal(t, int64(20050), got)
+		})
+	}
+}
+
+func TestBillingHandler_InvoicePDFHandler(t *testing.T) {
+	router := newBillingRouter(&mockBillingService{
+		GetInvoiceFunc: func(ctx context.Context, id, hospitalID uuid.UUID) (*entities.Invoice, error) {
+			return sampleInvoice(), nil
+		},
+	})
+	req := httptest.NewRequest("GET", "/invoices/"+uuid.New().String()+"/pdf", nil)
+	req.Header.Set("Authorization", generateValidToken())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/pdf", w.Header().Get("Content-Type"))
+	assert.True(t, bytes.HasPrefix(w.Body.Bytes(), []byte("%PDF-")))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "INV2026-000001.pdf")
+}
+
+func TestCalculateInvoiceLines(t *testing.T) {
+	charges := []entities.Charge{
+		{ID: uuid.New(), Code: "OPD01", Quantity: 1, UnitPrice: 30000, VATable: true},
+		{ID: uuid.New(), Code: "MED01", Quantity: 2, UnitPrice: 10000, VATable: false},
+	}
+
+	lines, err := services.CalculateInvoiceLines(charges, services.InvoiceDiscount{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2100), lines[0].VATAmount)
+	assert.Equal(t, int64(0), lines[1].VATAmount)
+
+	lines, err = services.CalculateInvoiceLines(charges, services.InvoiceDiscount{Percent: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3000), lines[0].Discount)
+	assert.Equal(t, int64(2000), lines[1].Discount)
+	assert.Equal(t, int64(1890), lines[0].VATAmount)
+
+	lines, err = services.CalculateInvoiceLines(charges, services.InvoiceDiscount{Amount: 100})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), lines[0].Discount+lines[1].Discount)
+
+	_, err = services.CalculateInvoiceLines(charges, services.InvoiceDiscount{Amount: 60000})
+	assert.ErrorIs(t, err, services.ErrInvalidInput)
+}
+
+func TestCalculateInvoiceLines_DiscountNeverExceedsLine(t *testing.T) {
+	// Rounded down, the shares are 999, 999 and 0 satang; the 2 satang left over must not both
+	// land on the 1 satang last line
+	charges := []entities.Charge{
+		{ID: uuid.New(), Code: "OPD01", Quantity: 1, UnitPrice: 1000, VATable: true},
+		{ID: uuid.New(), Code: "OPD02", Quantity: 1, UnitPrice: 1000, VATable: true},
+		{ID: uuid.New(), Code: "SUP01", Quantity: 1, UnitPrice: 1, VATable: true},
+	}
+	lines, err := services.CalculateInvoiceLines(charges, services.InvoiceDiscount{Amount: 2000})
+	assert.NoError(t, err)
+	var discount int64
+	for _, l := range lines {
+		assert.LessOrEqual(t, l.Discount, l.Amount, l.Code)
+		assert.GreaterOrEqual(t, l.VATAmount, int64(0), l.Code)
+		discount += l.Discount
+	}
+	assert.Equal(t, int64(2000), discount)
+	assert.Equal(t, []int64{1000, 999, 1}, []int64{lines[0].Discount, lines[1].Discount, lines[2].Discount})
+
+	// A free line takes none of the discount
+	charges = append(charges, entities.Charge{ID: uuid.New(), Code: "FREE", Quantity: 1, UnitPrice: 0})
+	lines, err = services.CalculateInvoiceLines(charges, services.InvoiceDiscount{Amount: 2001})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1000, 1000, 1, 0}, []int64{lines[0].Discount, lines[1].Discount, lines[2].Discount, lines[3].Discount})
+}
@@ -0,0 +1,313 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/handlers"
+	"go-hospital-api/internal/middleware"
+	"go-hospital-api/internal/services"
+	"go-hospital-api/pkg/client"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clientFixture serves the API routes of main with mock services, for the client SDK
+type clientFixture struct {
+	hospitalID, staffID uuid.UUID
+	patient             entities.Patient
+	logins, creates     atomic.Int32
+	url                 string
+
+	mu sync.Mutex
+	// intercept, when set, can answer a request instead of the API, e.g. to make it fail
+	intercept func(w http.ResponseWriter, r *http.Request, api http.Handler) bool
+	// requests counts the requests per method and path
+	requests map[string]int
+}
+
+func newClientFixture(t *testing.T) *clientFixture {
+	t.Setenv("JWT_SECRET", "testsecret")
+	f := &clientFixture{hospitalID: uuid.New(), staffID: uuid.New(), requests: map[string]int{}}
+	dob := time.Date(1985, 1, 2, 0, 0, 0, 0, time.UTC)
+	f.patient = entities.Patient{ID: uuid.New(), PatientHN: "HN001", FirstNameTH: "สมชาย", FirstNameEN: "Somchai", DateOfBirth: &dob, HospitalID: f.hospitalID, Version: 3}
+
+	staffService := &mockStaffService2{
+		GetHospitalIDByStaffIDFunc: func(staffID string) (uuid.UUID, error) { return f.hospitalID, nil },
+		LoginFunc: func(username, password string, hospitalID uuid.UUID) (*entities.Staff, error) {
+			f.logins.Add(1)
+			if username != "nurse1" || password != "secret" || hospitalID != f.hospitalID {
+				return nil, errors.New("invalid credentials")
+			}
+			return &entities.Staff{ID: f.staffID, Username: username, HospitalID: hospitalID}, nil
+		},
+		CreateFunc: func(staff *entities.Staff, password string) error {
+			f.creates.Add(1)
+			return nil
+		},
+	}
+	patientService := &mockPatientService{
+		SearchFunc: func(ctx context.Context, criteria dto.PatientSearchCriteria) ([]entities.Patient, error) {
+			if criteria.Name != nil && *criteria.Name != "Somchai" {
+				return []entities.Patient{}, nil
+			}
+			return []entities.Patient{f.patient}, nil
+		},
+		GetFunc: func(ctx context.Context, id, hospitalID uuid.UUID, asOf *time.Time) (*entities.Patient, error) {
+			if id != f.patient.ID || hospitalID != f.hospitalID {
+				return nil, services.ErrNotFound
+			}
+			p := f.patient
+			return &p, nil
+		},
+		UpdateFunc: func(ctx context.Context, id, hospitalID, staffID uuid.UUID, version int, req dto.PatientUpdateRequest) (*entities.Patient, error) {
+			if version != f.patient.Version {
+				return nil, services.ErrPreconditionFailed
+			}
+			p := f.patient
+			p.PhoneNumber, p.Version = *req.PhoneNumber, version+1
+			return &p, nil
+		},
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handlers.RegisterRoutes(router.Group("/api"), handlers.Handlers{
+		Staff:   handlers.NewStaffHandler(staffService),
+		Patient: handlers.NewPatientHandler(patientService, nil, staffService),
+	}, middleware.Idempotency(newMemoryIdempotencyRepo(), time.Hour))
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.requests[r.Method+" "+r.URL.Path]++
+		intercept := f.intercept
+		f.mu.Unlock()
+		if intercept == nil || !intercept(w, r, router) {
+			router.ServeHTTP(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	f.url = server.URL + "/api"
+	return f
+}
+
+func (f *clientFixture) client(t *testing.T, opts ...client.Option) *client.Client {
+	c, err := client.New(f.url, append([]client.Option{client.WithRetries(3, time.Millisecond)}, opts...)...)
+	require.NoError(t, err)
+	return c
+}
+
+func (f *clientFixture) count(method, path string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests[method+" "+path]
+}
+
+func (f *clientFixture) setIntercept(intercept func(w http.ResponseWriter, r *http.Request, api http.Handler) bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.intercept = intercept
+}
+
+// tokenExpiringIn signs a token for staffID that expires after d, with secret
+func tokenExpiringIn(t *testing.T, staffID uuid.UUID, d time.Duration, secret string) string {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   staffID.String(),
+		ExpiresAt: jwt.NewNumericDate(time.Now().Add(d)),
+	}).SignedString([]byte(secret))
+	require.NoError(t, err)
+	return token
+}
+
+func TestClient_LoginAndPatients(t *testing.T) {
+	f := newClientFixture(t)
+	c := f.client(t)
+	ctx := context.Background()
+
+	_, err := c.SearchPatients(ctx, client.PatientSearchCriteria{})
+	assert.ErrorIs(t, err, client.ErrUnauthorized)
+
+	_, err = c.Login(ctx, client.StaffLoginRequest{Username: "nurse1", Password: "wrong", HospitalID: f.hospitalID})
+	var apiErr *client.Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+	assert.Equal(t, "invalid username or password", apiErr.Message)
+
+	login, err := c.Login(ctx, client.StaffLoginRequest{Username: "nurse1", Password: "secret", HospitalID: f.hospitalID})
+	require.NoError(t, err)
+	assert.NotEmpty(t, login.Token)
+
+	patients, err := c.SearchPatients(ctx, client.PatientSearchCriteria{Name: client.String("Somchai")})
+	require.NoError(t, err)
+	require.Len(t, patients, 1)
+	assert.Equal(t, f.patient.ID, patients[0].PatientID)
+	assert.Equal(t, "สมชาย", patients[0].FirstNameTh)
+	assert.Equal(t, "1985-01-02", patients[0].DateOfBirth.Format("2006-01-02"))
+
+	patients, err = c.SearchPatients(ctx, client.PatientSearchCriteria{Name: client.String("Malee")})
+	require.NoError(t, err)
+	assert.Empty(t, patients)
+
+	patient, err := c.GetPatient(ctx, f.patient.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, patient.Version)
+
+	updated, err := c.UpdatePatient(ctx, patient.PatientID, patient.Version, client.PatientUpdateRequest{PhoneNumber: client.String("0812345678")})
+	require.NoError(t, err)
+	assert.Equal(t, "0812345678", updated.PhoneNumber)
+	assert.Equal(t, 4, updated.Version)
+
+	_, err = c.UpdatePatient(ctx, patient.PatientID, 2, client.PatientUpdateRequest{PhoneNumber: client.String("0812345678")})
+	assert.ErrorIs(t, err, client.ErrPreconditionFailed)
+
+	_, err = c.GetPatient(ctx, uuid.New())
+	assert.ErrorIs(t, err, client.ErrNotFound)
+	assert.NotErrorIs(t, err, client.ErrForbidden)
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, services.ErrNotFound.Error(), apiErr.Message)
+
+	require.NoError(t, c.Logout(ctx))
+	_, err = c.GetPatient(ctx, f.patient.ID)
+	assert.ErrorIs(t, err, client.ErrUnauthorized)
+	assert.Equal(t, int32(2), f.logins.Load())
+}
+
+func TestClient_TokenRefresh(t *testing.T) {
+	f := newClientFixture(t)
+	ctx := context.Background()
+	credentials := client.WithCredentials("nurse1", "secret", f.hospitalID)
+
+	// Logs in when it first needs a token, once for all the requests waiting for it
+	c := f.client(t, credentials)
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.GetPatient(ctx, f.patient.ID)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), f.logins.Load())
+
+	// Logs in again before the token expires
+	store := &client.MemoryTokenStore{}
+	expiring := tokenExpiringIn(t, f.staffID, 30*time.Second, "testsecret")
+	c = f.client(t, credentials, client.WithTokenStore(store), client.WithToken(expiring))
+	_, err := c.GetPatient(ctx, f.patient.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), f.logins.Load())
+	token, _ := store.Token(ctx)
+	assert.NotEqual(t, expiring, token)
+
+	// Logs in again when the API rejects the token, and repeats the request
+	c = f.client(t, credentials, client.WithToken(tokenExpiringIn(t, f.staffID, time.Hour, "othersecret")))
+	before := f.count(http.MethodGet, "/api/patients/"+f.patient.ID.String())
+	_, err = c.GetPatient(ctx, f.patient.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), f.logins.Load())
+	assert.Equal(t, before+2, f.count(http.MethodGet, "/api/patients/"+f.patient.ID.String()))
+
+	// Without credentials the rejected token is an error
+	c = f.client(t, client.WithToken(tokenExpiringIn(t, f.staffID, time.Hour, "othersecret")))
+	_, err = c.GetPatient(ctx, f.patient.ID)
+	assert.ErrorIs(t, err, client.ErrUnauthorized)
+
+	// Wrong credentials fail the request without looping
+	c = f.client(t, client.WithCredentials("nurse1", "wrong", f.hospitalID))
+	_, err = c.GetPatient(ctx, f.patient.ID)
+	assert.ErrorIs(t, err, client.ErrUnauthorized)
+	assert.Equal(t, int32(4), f.logins.Load())
+}
+
+func TestClient_Retries(t *testing.T) {
+	f := newClientFixture(t)
+	ctx := context.Background()
+	c := f.client(t, client.WithCredentials("nurse1", "secret", f.hospitalID))
+	patientPath := "/api/patients/" + f.patient.ID.String()
+
+	// A GET is retried while the API is unavailable
+	failures := 2
+	f.setIntercept(func(w http.ResponseWriter, r *http.Request, api http.Handler) bool {
+		if r.URL.Path != patientPath || failures == 0 {
+			return false
+		}
+		failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return true
+	})
+	patient, err := c.GetPatient(ctx, f.patient.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "HN001", patient.PatientHN)
+	assert.Equal(t, 3, f.count(http.MethodGet, patientPath))
+
+	// A PATCH is not, and a proxy's error page becomes a plain message
+	f.setIntercept(func(w http.ResponseWriter, r *http.Request, api http.Handler) bool {
+		w.Header().Set("Content-Type", "text/html")
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("<html><body>502 Bad Gateway</body></html>"))
+		return true
+	})
+	_, err = c.UpdatePatient(ctx, f.patient.ID, 3, client.PatientUpdateRequest{Email: client.String("somchai@example.com")})
+	assert.ErrorIs(t, err, client.ErrUpstream)
+	assert.EqualError(t, err, "hospital api: 502 bad gateway")
+	assert.Equal(t, 1, f.count(http.MethodPatch, patientPath))
+
+	// Nor is a versioned PUT: had the lost attempt been applied, a retry would fail with 412
+	staffID := uuid.New()
+	rolePath := "/api/staff/" + staffID.String() + "/role"
+	f.setIntercept(func(w http.ResponseWriter, r *http.Request, api http.Handler) bool {
+		if r.URL.Path != rolePath {
+			return false
+		}
+		w.WriteHeader(http.StatusGatewayTimeout)
+		return true
+	})
+	_, err = c.SetStaffRole(ctx, staffID, 2, "doctor")
+	assert.ErrorIs(t, err, client.ErrUnavailable)
+	assert.Equal(t, 1, f.count(http.MethodPut, rolePath))
+
+	// Retries give up eventually
+	f.setIntercept(func(w http.ResponseWriter, r *http.Request, api http.Handler) bool {
+		w.WriteHeader(http.StatusBadGateway)
+		return true
+	})
+	_, err = c.GetPatient(ctx, f.patient.ID)
+	assert.ErrorIs(t, err, client.ErrUpstream)
+	assert.Equal(t, 3+4, f.count(http.MethodGet, patientPath))
+
+	// A POST whose response was lost is retried with the same Idempotency-Key, and the API
+	// replays the response instead of creating the staff member twice
+	var keys []string
+	f.setIntercept(func(w http.ResponseWriter, r *http.Request, api http.Handler) bool {
+		if r.URL.Path != "/api/staff/create" {
+			return false
+		}
+		keys = append(keys, r.Header.Get(client.IdempotencyKeyHeader))
+		if len(keys) > 1 {
+			return false
+		}
+		api.ServeHTTP(httptest.NewRecorder(), r)
+		w.WriteHeader(http.StatusGatewayTimeout)
+		return true
+	})
+	created, err := c.CreateStaff(ctx, client.StaffCreateRequest{Username: "nurse2", Password: "secret", HospitalID: f.hospitalID})
+	require.NoError(t, err)
+	assert.Equal(t, "nurse2", created.Username)
+	assert.Equal(t, int32(1), f.creates.Load())
+	require.Len(t, keys, 2)
+	assert.NotEmpty(t, keys[0])
+	assert.Equal(t, keys[0], keys[1])
+}
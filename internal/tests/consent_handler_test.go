@@ -0,0 +1,150 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/handlers"
+	"go-hospital-api/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockConsentService struct {
+	GrantFunc    func(ctx context.Context, consent *entities.Consent) error
+	WithdrawFunc func(ctx context.Context, id, hospitalID, staffID uuid.UUID, reason string) (*entities.Consent, error)
+	CheckFunc    func(ctx context.Context, patientID, hospitalID uuid.UUID, purpose string) (*entities.Consent, error)
+}
+
+func (m *mockConsentService) Grant(ctx context.Context, consent *entities.Consent) error {
+	return m.GrantFunc(ctx, consent)
+}
+
+func (m *mockConsentService) Withdraw(ctx context.Context, id, hospitalID, staffID uuid.UUID, reason string) (*entities.Consent, error) {
+	return m.WithdrawFunc(ctx, id, hospitalID, staffID, reason)
+}
+
+func (m *mockConsentService) ListByPatient(ctx context.Context, patientID, hospitalID uuid.UUID, purpose string) ([]entities.Consent, error) {
+	return nil, nil
+}
+
+func (m *mockConsentService) Check(ctx context.Context, patientID, hospitalID uuid.UUID, purpose string) (*entities.Consent, error) {
+	return m.CheckFunc(ctx, patientID, hospitalID, purpose)
+}
+
+func newConsentRouter(svc services.ConsentServiceInterface) *gin.Engine {
+	h := handlers.NewConsentHandler(svc, &mockStaffService2{
+		GetHospitalIDByStaffIDFunc: func(staffID string) (uuid.UUID, error) { return uuid.New(), nil },
+	})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/patients/:id/consents", h.GrantHandler)
+	router.GET("/patients/:id/consents/check", h.CheckHandler)
+	router.POST("/consents/:id/withdraw", h.WithdrawHandler)
+	return router
+}
+
+func TestConsentHandler_GrantHandler(t *testing.T) {
+	var witness uuid.UUID
+	router := newConsentRouter(&mockConsentService{
+		GrantFunc: func(ctx context.Context, consent *entities.Consent) error {
+			witness = consent.WitnessedByID
+			consent.ID = uuid.New()
+			consent.Status = entities.ConsentGranted
+			return nil
+		},
+	})
+	b, _ := json.Marshal(dto.ConsentGrantRequest{Purpose: entities.ConsentDataSharing, TextVersion: "2026-01"})
+	req := httptest.NewRequest("POST", "/patients/"+uuid.New().String()+"/consents", bytes.NewReader(b))
+	req.Header.Set("Authorization", generateValidToken())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.NotEqual(t, uuid.Nil, witness)
+}
+
+func TestConsentHandler_CheckHandler(t *testing.T) {
+	cases := []struct {
+		name           string
+		checkFunc      func(ctx context.Context, patientID, hospitalID uuid.UUID, purpose string) (*entities.Consent, error)
+		wantStatusCode int
+		wantGranted    bool
+	}{
+		{
+			name: "positive granted",
+			checkFunc: func(ctx context.Context, patientID, hospitalID uuid.UUID, purpose string) (*entities.Consent, error) {
+				return &entities.Consent{ID: uuid.New(), Purpose: purpose, Status: entities.ConsentGranted, GrantedAt: time.Now()}, nil
+			},
+			wantStatusCode: http.StatusOK,
+			wantGranted:    true,
+		},
+		{
+			name: "positive not granted",
+			checkFunc: func(ctx context.Context, patientID, hospitalID uuid.UUID, purpose string) (*entities.Consent, error) {
+				return nil, nil
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "negative unknown purpose",
+			checkFunc: func(ctx context.Context, patientID, hospitalID uuid.UUID, purpose string) (*entities.Consent, error) {
+				return nil, fmt.Errorf("%w: unknown consent purpose", services.ErrInvalidInput)
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newConsentRouter(&mockConsentService{CheckFunc: tc.checkFunc})
+			req := httptest.NewRequest("GET", "/patients/"+uuid.New().String()+"/consents/check?purpose=data_sharing", nil)
+			req.Header.Set("Authorization", generateValidToken())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			if w.Code == http.StatusOK {
+				var resp struct {
+					Data dto.ConsentCheckResponse `json:"data"`
+				}
+				json.Unmarshal(w.Body.Bytes(), &resp)
+				assert.Equal(t, tc.wantGranted, resp.Data.Granted)
+			}
+		})
+	}
+}
+
+func TestConsentHandler_WithdrawHandler_AlreadyWithdrawn(t *testing.T) {
+	router := newConsentRouter(&mockConsentService{
+		WithdrawFunc: func(ctx context.Context, id, hospitalID, staffID uuid.UUID, reason string) (*entities.Consent, error) {
+			return nil, fmt.Errorf("%w: consent is not currently granted", services.ErrConflict)
+		},
+	})
+	req := httptest.NewRequest("POST", "/consents/"+uuid.New().String()+"/withdraw", nil)
+	req.Header.Set("Authorization", generateValidToken())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestRequireConsent(t *testing.T) {
+	checker := &mockConsentService{CheckFunc: func(ctx context.Context, patientID, hospitalID uuid.UUID, purpose string) (*entities.Consent, error) {
+		if purpose == entities.ConsentTreatment {
+			return &entities.Consent{Purpose: purpose, Status: entities.ConsentGranted}, nil
+		}
+		return nil, nil
+	}}
+	assert.NoError(t, services.RequireConsent(context.Background(), checker, uuid.New(), uuid.New(), entities.ConsentTreatment))
+
+	err := services.RequireConsent(context.Background(), checker, uuid.New(), uuid.New(), entities.ConsentDataSharing)
+	assert.ErrorIs(t, err, services.ErrConsentRequired)
+	assert.ErrorIs(t, err, services.ErrForbidden)
+}
@@ -0,0 +1,299 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/handlers"
+	"go-hospital-api/internal/repository"
+	"go-hospital-api/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockContactService struct {
+	AddAddressFunc    func(ctx context.Context, address *entities.PatientAddress) error
+	DeleteContactFunc func(ctx context.Context, id, patientID, hospitalID uuid.UUID, version int) error
+}
+
+func (m *mockContactService) AddAddress(ctx context.Context, address *entities.PatientAddress) error {
+	return m.AddAddressFunc(ctx, address)
+}
+
+func (m *mockContactService) ListAddresses(ctx context.Context, patientID, hospitalID uuid.UUID) ([]entities.PatientAddress, error) {
+	return nil, nil
+}
+
+func (m *mockContactService) UpdateAddress(ctx context.Context, address *entities.PatientAddress) error {
+	return nil
+}
+
+func (m *mockContactService) DeleteAddress(ctx context.Context, id, patientID, hospitalID uuid.UUID, version int) error {
+	return nil
+}
+
+func (m *mockContactService) AddContact(ctx context.Context, contact *entities.EmergencyContact) error {
+	return nil
+}
+
+func (m *mockContactService) ListContacts(ctx context.Context, patientID, hospitalID uuid.UUID) ([]entities.EmergencyContact, error) {
+	return nil, nil
+}
+
+func (m *mockContactService) UpdateContact(ctx context.Context, contact *entities.EmergencyContact) error {
+	return nil
+}
+
+func (m *mockContactService) DeleteContact(ctx context.Context, id, patientID, hospitalID uuid.UUID, version int) error {
+	return m.DeleteContactFunc(ctx, id, patientID, hospitalID, version)
+}
+
+func (m *mockContactService) SearchAreas(province, district, postalCode string) []services.AdminArea {
+	return nil
+}
+
+func newContactRouter(svc services.ContactServiceInterface) *gin.Engine {
+	h := handlers.NewContactHandler(svc, &mockStaffService2{
+		GetHospitalIDByStaffIDFunc: func(staffID string) (uuid.UUID, error) { return uuid.New(), nil },
+	})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/patients/:id/addresses", h.AddAddressHandler)
+	router.DELETE("/patients/:id/emergency-contacts/:contactId", h.DeleteContactHandler)
+	return router
+}
+
+func TestContactHandler_AddAddressHandler(t *testing.T) {
+	cases := []struct {
+		name           string
+		addAddressFunc func(ctx context.Context, address *entities.PatientAddress) error
+		wantStatusCode int
+	}{
+		{
+			name: "positive",
+			addAddressFunc: func(ctx context.Context, address *entities.PatientAddress) error {
+				address.ID = uuid.New()
+				return nil
+			},
+			wantStatusCode: http.StatusCreated,
+		},
+		{
+			name: "negative unknown district",
+			addAddressFunc: func(ctx context.Context, address *entities.PatientAddress) error {
+				return fmt.Errorf("%w: district is not in province", services.ErrInvalidInput)
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "negative duplicate address type",
+			addAddressFunc: func(ctx context.Context, address *entities.PatientAddress) error {
+				return fmt.Errorf("%w: patient already has a home address", services.ErrConflict)
+			},
+			wantStatusCode: http.StatusConflict,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newContactRouter(&mockContactService{AddAddressFunc: tc.addAddressFunc})
+			b, _ := json.Marshal(dto.AddressRequest{
+				HouseNumber: "99/1", Subdistrict: "ลุมพินี", District: "ปทุมวัน", Province: "กรุงเทพมหานคร", PostalCode: "10330",
+			})
+			req := httptest.NewRequest("POST", "/patients/"+uuid.New().String()+"/addresses", bytes.NewReader(b))
+			req.Header.Set("Authorization", generateValidToken())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+		})
+	}
+}
+
+func TestContactHandler_DeleteContactHandler(t *testing.T) {
+	cases := []struct {
+		name              string
+		ifMatch           string
+		deleteContactFunc func(ctx context.Context, id, patientID, hospitalID uuid.UUID, version int) error
+		wantStatusCode    int
+	}{
+		{
+			name:    "positive",
+			ifMatch: `"2"`,
+			deleteContactFunc: func(ctx context.Context, id, patientID, hospitalID uuid.UUID, version int) error {
+				assert.Equal(t, 2, version)
+				return nil
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:    "negative not found",
+			ifMatch: `"1"`,
+			deleteContactFunc: func(ctx context.Context, id, patientID, hospitalID uuid.UUID, version int) error {
+				return fmt.Errorf("%w: emergency contact", services.ErrNotFound)
+			},
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name:    "negative stale version",
+			ifMatch: `"1"`,
+			deleteContactFunc: func(ctx context.Context, id, patientID, hospitalID uuid.UUID, version int) error {
+				return fmt.Errorf("%w: emergency contact is at version 2", services.ErrPreconditionFailed)
+			},
+			wantStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "negative missing If-Match",
+			wantStatusCode: http.StatusPreconditionRequired,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newContactRouter(&mockContactService{DeleteContactFunc: tc.deleteContactFunc})
+			req := httptest.NewRequest("DELETE", "/patients/"+uuid.New().String()+"/emergency-contacts/"+uuid.New().String(), nil)
+			req.Header.Set("Authorization", generateValidToken())
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+		})
+	}
+}
+
+func TestContactService_StaleVersion(t *testing.T) {
+	ctx := context.Background()
+	conn := newTestDB(t)
+	hospitalID, staffID := uuid.New(), uuid.New()
+	require.NoError(t, conn.Create(&entities.Hospital{ID: hospitalID, Name: "General"}).Error)
+	patients := repository.NewPatientRepository(conn)
+	patient := &entities.Patient{ID: uuid.New(), PatientHN: "HN-CON-1", HospitalID: hospitalID, Version: 1}
+	require.NoError(t, patients.Create(ctx, patient, staffID))
+	t.Setenv("ADMIN_AREAS_FILE", "")
+	areas, err := services.DefaultAdminAreas()
+	require.NoError(t, err)
+	svc := services.NewContactService(repository.NewContactRepository(conn), patients, areas)
+
+	t.Run("address", func(t *testing.T) {
+		address := entities.PatientAddress{PatientID: patient.ID, HospitalID: hospitalID, Type: entities.AddressHome, HouseNumber: "1",
+			Subdistrict: "ลุมพินี", District: "ปทุมวัน", Province: "กรุงเทพมหานคร", PostalCode: "10330"}
+		require.NoError(t, svc.AddAddress(ctx, &address))
+		assert.Equal(t, 1, address.Version)
+
+		first, second := address, address
+		first.HouseNumber = "2"
+		require.NoError(t, svc.UpdateAddress(ctx, &first))
+		assert.Equal(t, 2, first.Version)
+		// The second writer still holds version 1
+		second.HouseNumber = "3"
+		assert.ErrorIs(t, svc.UpdateAddress(ctx, &second), services.ErrPreconditionFailed)
+		assert.Equal(t, 1, second.Version)
+
+		assert.ErrorIs(t, svc.DeleteAddress(ctx, address.ID, patient.ID, hospitalID, 1), services.ErrPreconditionFailed)
+		require.NoError(t, svc.DeleteAddress(ctx, address.ID, patient.ID, hospitalID, 2))
+		assert.ErrorIs(t, svc.DeleteAddress(ctx, address.ID, patient.ID, hospitalID, 2), services.ErrNotFound)
+	})
+
+	t.Run("emergency contact", func(t *testing.T) {
+		contact := entities.EmergencyContact{PatientID: patient.ID, HospitalID: hospitalID, Name: "Somsri", Relationship: "spouse", PhoneNumber: "0812345678"}
+		require.NoError(t, svc.AddContact(ctx, &contact))
+		assert.Equal(t, 1, contact.Version)
+
+		first, second := contact, contact
+		first.PhoneNumber = "0899999999"
+		require.NoError(t, svc.UpdateContact(ctx, &first))
+		assert.Equal(t, 2, first.Version)
+		second.Priority = 2
+		assert.ErrorIs(t, svc.UpdateContact(ctx, &second), services.ErrPreconditionFailed)
+
+		assert.ErrorIs(t, svc.DeleteContact(ctx, contact.ID, patient.ID, hospitalID, 1), services.ErrPreconditionFailed)
+		require.NoError(t, svc.DeleteContact(ctx, contact.ID, patient.ID, hospitalID, 2))
+		contacts, err := svc.ListContacts(ctx, patient.ID, hospitalID)
+		require.NoError(t, err)
+		assert.Empty(t, contacts)
+	})
+}
+
+func TestAdminAreas_Resolve(t *testing.T) {
+	t.Setenv("ADMIN_AREAS_FILE", "")
+	areas, err := services.DefaultAdminAreas()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	area, err := areas.Resolve("แขวงลุมพินี", "เขตปทุมวัน", "กทม.", "10330")
+	assert.NoError(t, err)
+	assert.Equal(t, "ลุมพินี", area.Subdistrict)
+	assert.Equal(t, "กรุงเทพมหานคร", area.Province)
+
+	_, err = areas.Resolve("ลุมพินี", "บางรัก", "กรุงเทพมหานคร", "10330")
+	assert.ErrorIs(t, err, services.ErrInvalidInput)
+	assert.Contains(t, err.Error(), "subdistrict")
+
+	_, err = areas.Resolve("ลุมพินี", "ปทุมวัน", "กรุงเทพมหานคร", "10500")
+	assert.ErrorIs(t, err, services.ErrInvalidInput)
+	assert.Contains(t, err.Error(), "postal code")
+
+	assert.Len(t, areas.Search("", "", "10330"), 4)
+
+	t.Run("outside the bundled districts", func(t *testing.T) {
+		area, err := areas.Resolve("ต.ริมใต้", "อ.แม่ริม", "จ.เชียงใหม่", "50180")
+		assert.NoError(t, err)
+		assert.Equal(t, services.AdminArea{Subdistrict: "ริมใต้", District: "แม่ริม", Province: "เชียงใหม่", PostalCode: "50180"}, area)
+
+		area, err = areas.Resolve("ตลาดใหญ่", "เมืองภูเก็ต", "ภูเก็ต", "83000")
+		assert.NoError(t, err)
+		assert.Equal(t, "ภูเก็ต", area.Province)
+
+		_, err = areas.Resolve("บางนาเหนือ", "บางนา", "กรุงเทพมหานคร", "10260")
+		assert.NoError(t, err)
+
+		_, err = areas.Resolve("ริมใต้", "แม่ริม", "เชียงใหม่", "10330")
+		assert.ErrorIs(t, err, services.ErrInvalidInput)
+		assert.Contains(t, err.Error(), "postal code")
+
+		_, err = areas.Resolve("ริมใต้", "แม่ริม", "เชียงใม่", "50180")
+		assert.ErrorIs(t, err, services.ErrInvalidInput)
+		assert.Contains(t, err.Error(), "unknown province")
+	})
+}
+
+func TestLoadAdminAreas_NationalFile(t *testing.T) {
+	areas, err := services.LoadAdminAreas(strings.NewReader("subdistrict,district,province,postal_code\n" +
+		"ริมใต้,แม่ริม,เชียงใหม่,50180\n" +
+		"ริมเหนือ,แม่ริม,เชียงใหม่,50180\n" +
+		"ศรีภูมิ,เมืองเชียงใหม่,เชียงใหม่,50200\n"))
+	require.NoError(t, err)
+
+	area, err := areas.Resolve("ตำบลริมเหนือ", "อำเภอแม่ริม", "เชียงใหม่", "50180")
+	assert.NoError(t, err)
+	assert.Equal(t, "ริมเหนือ", area.Subdistrict)
+
+	// The file lists every district of Chiang Mai, so others are rejected
+	_, err = areas.Resolve("สันทราย", "สันทรายหลวง", "เชียงใหม่", "50210")
+	assert.ErrorIs(t, err, services.ErrInvalidInput)
+	assert.Contains(t, err.Error(), "district")
+
+	_, err = areas.Resolve("ศรีภูมิ", "เมืองเชียงใหม่", "เชียงใหม่", "50180")
+	assert.ErrorIs(t, err, services.ErrInvalidInput)
+	assert.Contains(t, err.Error(), "postal code")
+
+	// Provinces the file does not have are checked against their postal prefix only
+	_, err = areas.Resolve("ในเมือง", "เมืองขอนแก่น", "ขอนแก่น", "40000")
+	assert.NoError(t, err)
+}
+
+func TestLoadAdminAreas_MissingColumn(t *testing.T) {
+	_, err := services.LoadAdminAreas(strings.NewReader("subdistrict,district,province\nลุมพินี,ปทุมวัน,กรุงเทพมหานคร\n"))
+	assert.ErrorIs(t, err, services.ErrInvalidInput)
+}
@@ -0,0 +1,197 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/handlers"
+	"go-hospital-api/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockCoverageService struct {
+	AddFunc    func(ctx context.Context, coverage *entities.Coverage) error
+	VerifyFunc func(ctx context.Context, id, hospitalID uuid.UUID) (*entities.Coverage, error)
+}
+
+func (m *mockCoverageService) Add(ctx context.Context, coverage *entities.Coverage) error {
+	return m.AddFunc(ctx, coverage)
+}
+
+func (m *mockCoverageService) ListByPatient(ctx context.Context, patientID, hospitalID uuid.UUID) ([]entities.Coverage, error) {
+	return nil, nil
+}
+
+func (m *mockCoverageService) Verify(ctx context.Context, id, hospitalID uuid.UUID) (*entities.Coverage, error) {
+	return m.VerifyFunc(ctx, id, hospitalID)
+}
+
+func newCoverageRouter(svc services.CoverageServiceInterface) *gin.Engine {
+	h := handlers.NewCoverageHandler(svc, &mockStaffService2{
+		GetHospitalIDByStaffIDFunc: func(staffID string) (uuid.UUID, error) { return uuid.New(), nil },
+	})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/patients/:id/coverages", h.AddHandler)
+	router.POST("/coverages/:id/verify", h.VerifyHandler)
+	return router
+}
+
+func TestCoverageHandler_AddHandler(t *testing.T) {
+	cases := []struct {
+		name           string
+		addFunc        func(ctx context.Context, coverage *entities.Coverage) error
+		wantStatusCode int
+	}{
+		{
+			name: "positive",
+			addFunc: func(ctx context.Context, coverage *entities.Coverage) error {
+				coverage.ID = uuid.New()
+				coverage.EligibilityStatus = entities.EligibilityUnverified
+				return nil
+			},
+			wantStatusCode: http.StatusCreated,
+		},
+		{
+			name: "negative unknown scheme",
+			addFunc: func(ctx context.Context, coverage *entities.Coverage) error {
+				return fmt.Errorf("%w: scheme", services.ErrInvalidInput)
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newCoverageRouter(&mockCoverageService{AddFunc: tc.addFunc})
+			b, _ := json.Marshal(dto.CoverageCreateRequest{Scheme: entities.SchemeSSS, MemberNumber: "1234567890123"})
+			req := httptest.NewRequest("POST", "/patients/"+uuid.New().String()+"/coverages", bytes.NewReader(b))
+			req.Header.Set("Authorization", generateValidToken())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+		})
+	}
+}
+
+func TestCoverageHandler_VerifyHandler(t *testing.T) {
+	cases := []struct {
+		name           string
+		verifyFunc     func(ctx context.Context, id, hospitalID uuid.UUID) (*entities.Coverage, error)
+		wantStatusCode int
+	}{
+		{
+			name: "positive",
+			verifyFunc: func(ctx context.Context, id, hospitalID uuid.UUID) (*entities.Coverage, error) {
+				return &entities.Coverage{ID: id, Scheme: entities.SchemeUC, EligibilityStatus: entities.EligibilityEligible}, nil
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "negative payer unreachable",
+			verifyFunc: func(ctx context.Context, id, hospitalID uuid.UUID) (*entities.Coverage, error) {
+				return nil, fmt.Errorf("%w: eligibility check failed: timeout", services.ErrUpstream)
+			},
+			wantStatusCode: http.StatusBadGateway,
+		},
+		{
+			name: "negative coverage in another hospital",
+			verifyFunc: func(ctx context.Context, id, hospitalID uuid.UUID) (*entities.Coverage, error) {
+				return nil, fmt.Errorf("%w: coverage", services.ErrNotFound)
+			},
+			wantStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newCoverageRouter(&mockCoverageService{VerifyFunc: tc.verifyFunc})
+			req := httptest.NewRequest("POST", "/coverages/"+uuid.New().String()+"/verify", nil)
+			req.Header.Set("Authorization", generateValidToken())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+		})
+	}
+}
+
+func TestLocalEligibilityVerifier(t *testing.T) {
+	now := time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+	expired := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+	v := &services.LocalEligibilityVerifier{
+		Ineligible: map[string]string{"SSS-999": "contributions not paid"},
+		Now:        func() time.Time { return now },
+	}
+
+	res, err := v.Verify(context.Background(), entities.Coverage{MemberNumber: "UC-1", ValidFrom: now.AddDate(-1, 0, 0)})
+	assert.NoError(t, err)
+	assert.True(t, res.Eligible)
+
+	res, _ = v.Verify(context.Background(), entities.Coverage{MemberNumber: "UC-1", ValidFrom: now.AddDate(-1, 0, 0), ValidTo: &expired})
+	assert.False(t, res.Eligible)
+
+	res, _ = v.Verify(context.Background(), entities.Coverage{MemberNumber: "SSS-999", ValidFrom: now.AddDate(-1, 0, 0)})
+	assert.False(t, res.Eligible)
+	assert.Equal(t, "contributions not paid", res.Message)
+}
+
+func TestActiveCoverage(t *testing.T) {
+	now := time.Date(2026, 3, 15, 10, 0, 0, 0, time.UTC)
+	lastYear := now.AddDate(-1, 0, 0)
+	expired := now.AddDate(0, -1, 0)
+	coverages := []entities.Coverage{
+		{Scheme: entities.SchemePrivate, ValidFrom: now.AddDate(0, -2, 0), EligibilityStatus: entities.EligibilityIneligible},
+		{Scheme: entities.SchemeUC, ValidFrom: lastYear, ValidTo: &expired},
+		{Scheme: entities.SchemeSSS, ValidFrom: lastYear, EligibilityStatus: entities.EligibilityEligible},
+	}
+	active := services.ActiveCoverage(coverages, now)
+	assert.NotNil(t, active)
+	assert.Equal(t, entities.SchemeSSS, active.Scheme)
+	assert.Nil(t, services.ActiveCoverage(coverages[:2], now))
+}
+
+func TestPatientHandler_SearchHandler_ActiveCoverage(t *testing.T) {
+	dob := time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC)
+	patientService := &mockPatientService{SearchFunc: func(ctx context.Context, criteria dto.PatientSearchCriteria) ([]entities.Patient, error) {
+		return []entities.Patient{
+			{ID: uuid.New(), DateOfBirth: &dob, Coverages: []entities.Coverage{
+				{ID: uuid.New(), Scheme: entities.SchemeCSMBS, MemberNumber: "1234567890123", ValidFrom: dob},
+			}},
+			{ID: uuid.New(), DateOfBirth: &dob},
+		}, nil
+	}}
+	h := handlers.NewPatientHandler(patientService, nil, &mockStaffService2{
+		GetHospitalIDByStaffIDFunc: func(staffID string) (uuid.UUID, error) { return uuid.New(), nil },
+	})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/patients/search", h.SearchHandler)
+
+	req := httptest.NewRequest("POST", "/patients/search", bytes.NewReader([]byte("{}")))
+	req.Header.Set("Authorization", generateValidToken())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if !assert.Equal(t, http.StatusOK, w.Code) {
+		return
+	}
+
+	var resp struct {
+		Data []dto.PatientResponse `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	if !assert.Len(t, resp.Data, 2) {
+		return
+	}
+	assert.Equal(t, entities.SchemeCSMBS, resp.Data[0].ActiveCoverage.Scheme)
+	assert.Nil(t, resp.Data[1].ActiveCoverage)
+}
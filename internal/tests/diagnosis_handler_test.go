@@ -0,0 +1,250 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/handlers"
+	"go-hospital-api/internal/repository"
+	"go-hospital-api/internal/services"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+type mockDiagnosisService struct {
+	ImportFunc func(ctx context.Context, r io.Reader, edition string, staffID uuid.UUID) (int, error)
+	SearchFunc func(ctx context.Context, query, edition string, limit int) ([]entities.ICD10Code, error)
+	RecordFunc func(ctx context.Context, diagnosis *entities.Diagnosis) error
+	ListFunc   func(ctx context.Context, patientID, hospitalID uuid.UUID, visitDate *time.Time) ([]entities.Diagnosis, error)
+}
+
+func (m *mockDiagnosisService) ImportCatalogue(ctx context.Context, r io.Reader, edition string, staffID uuid.UUID) (int, error) {
+	return m.ImportFunc(ctx, r, edition, staffID)
+}
+
+func (m *mockDiagnosisService) SearchCodes(ctx context.Context, query, edition string, limit int) ([]entities.ICD10Code, error) {
+	return m.SearchFunc(ctx, query, edition, limit)
+}
+
+func (m *mockDiagnosisService) Record(ctx context.Context, diagnosis *entities.Diagnosis) error {
+	return m.RecordFunc(ctx, diagnosis)
+}
+
+func (m *mockDiagnosisService) ListByPatient(ctx context.Context, patientID, hospitalID uuid.UUID, visitDate *time.Time) ([]entities.Diagnosis, error) {
+	return m.ListFunc(ctx, patientID, hospitalID, visitDate)
+}
+
+func newDiagnosisRouter(svc services.DiagnosisServiceInterface, hospitalID uuid.UUID) *gin.Engine {
+	h := handlers.NewDiagnosisHandler(svc, &mockStaffService2{
+		GetHospitalIDByStaffIDFunc: func(staffID string) (uuid.UUID, error) { return hospitalID, nil },
+	})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/icd10/codes", h.SearchCodesHandler)
+	router.POST("/icd10/import", h.ImportCodesHandler)
+	router.POST("/patients/:id/diagnoses", h.CreateHandler)
+	router.GET("/patients/:id/diagnoses", h.ListHandler)
+	return router
+}
+
+func TestDiagnosisHandler_CreateHandler(t *testing.T) {
+	hospitalID := uuid.New()
+	cases := []struct {
+		name           string
+		patientID      string
+		recordFunc     func(ctx context.Context, d *entities.Diagnosis) error
+		wantStatusCode int
+	}{
+		{
+			name:      "positive",
+			patientID: uuid.New().String(),
+			recordFunc: func(ctx context.Context, d *entities.Diagnosis) error {
+				assert.Equal(t, hospitalID, d.HospitalID)
+				d.ID = uuid.New()
+				return nil
+			},
+			wantStatusCode: http.StatusCreated,
+		},
+		{
+			name:      "negative non-billable code",
+			patientID: uuid.New().String(),
+			recordFunc: func(ctx context.Context, d *entities.Diagnosis) error {
+				return fmt.Errorf("%w: ICD-10 code J45 is not billable", services.ErrInvalidInput)
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:      "negative patient in another hospital",
+			patientID: uuid.New().String(),
+			recordFunc: func(ctx context.Context, d *entities.Diagnosis) error {
+				return fmt.Errorf("%w: patient", services.ErrNotFound)
+			},
+			wantStatusCode: http.StatusNotFound,
+		},
+		{
+			name:      "negative second primary",
+			patientID: uuid.New().String(),
+			recordFunc: func(ctx context.Context, d *entities.Diagnosis) error {
+				return fmt.Errorf("%w: visit already has a primary diagnosis", services.ErrConflict)
+			},
+			wantStatusCode: http.StatusConflict,
+		},
+		{
+			name:           "negative invalid patient id",
+			patientID:      "not-a-uuid",
+			wantStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newDiagnosisRouter(&mockDiagnosisService{RecordFunc: tc.recordFunc}, hospitalID)
+			body := dto.DiagnosisCreateRequest{VisitDate: time.Now(), Code: "J45.9", IsPrimary: true}
+			b, _ := json.Marshal(body)
+			req := httptest.NewRequest("POST", "/patients/"+tc.patientID+"/diagnoses", bytes.NewReader(b))
+			req.Header.Set("Authorization", generateValidToken())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+		})
+	}
+}
+
+func TestDiagnosisHandler_SearchCodesHandler(t *testing.T) {
+	router := newDiagnosisRouter(&mockDiagnosisService{
+		SearchFunc: func(ctx context.Context, query, edition string, limit int) ([]entities.ICD10Code, error) {
+			assert.Equal(t, "J45", query)
+			assert.Equal(t, 5, limit)
+			return []entities.ICD10Code{{Code: "J459", Edition: entities.EditionICD10, Description: "Asthma, unspecified", Billable: true}}, nil
+		},
+	}, uuid.New())
+
+	req := httptest.NewRequest("GET", "/icd10/codes?q=J45&limit=5", nil)
+	req.Header.Set("Authorization", generateValidToken())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data []dto.ICD10CodeResponse `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Len(t, resp.Data, 1)
+	assert.Equal(t, "J459", resp.Data[0].Code)
+}
+
+func TestDiagnosisHandler_ImportCodesHandler(t *testing.T) {
+	var got string
+	router := newDiagnosisRouter(&mockDiagnosisService{
+		ImportFunc: func(ctx context.Context, r io.Reader, edition string, staffID uuid.UUID) (int, error) {
+			b, _ := io.ReadAll(r)
+			got = string(b)
+			return 1, nil
+		},
+	}, uuid.New())
+
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	fw, _ := mw.CreateFormFile("file", "icd10.csv")
+	fw.Write([]byte("code,description,billable\nJ45.9,Asthma,true\n"))
+	mw.WriteField("edition", entities.EditionICD10TM)
+	mw.Close()
+
+	req := httptest.NewRequest("POST", "/icd10/import", &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", generateValidToken())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, got, "J45.9")
+}
+
+func TestDiagnosisHandler_ListHandler_InvalidVisitDate(t *testing.T) {
+	router := newDiagnosisRouter(&mockDiagnosisService{}, uuid.New())
+	req := httptest.NewRequest("GET", "/patients/"+uuid.New().String()+"/diagnoses?visit_date=01-02-2025", nil)
+	req.Header.Set("Authorization", generateValidToken())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// stubPatientRepo finds the patients it holds; its other methods are not used
+type stubPatientRepo struct {
+	repository.PatientRepository
+	patients map[uuid.UUID]entities.Patient
+}
+
+func (r *stubPatientRepo) GetByID(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.Patient, error) {
+	p, ok := r.patients[id]
+	if !ok || p.HospitalID != hospitalID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &p, nil
+}
+
+// racingDiagnosisRepo loses the race for the primary diagnosis: the check finds none, but the
+// insert hits the unique index
+type racingDiagnosisRepo struct {
+	repository.DiagnosisRepository
+	upserted int
+}
+
+func (r *racingDiagnosisRepo) UpsertCodes(ctx context.Context, codes []entities.ICD10Code) error {
+	r.upserted += len(codes)
+	return nil
+}
+
+func (r *racingDiagnosisRepo) GetCode(ctx context.Context, code, edition string) (*entities.ICD10Code, error) {
+	return &entities.ICD10Code{Code: code, Edition: edition, Billable: true}, nil
+}
+
+func (r *racingDiagnosisRepo) HasPrimary(ctx context.Context, patientID uuid.UUID, visitDate time.Time) (bool, error) {
+	return false, nil
+}
+
+func (r *racingDiagnosisRepo) Create(ctx context.Context, diagnosis *entities.Diagnosis) error {
+	return gorm.ErrDuplicatedKey
+}
+
+func TestDiagnosisService(t *testing.T) {
+	hospitalID, patientID := uuid.New(), uuid.New()
+	supervisorID, doctorID := uuid.New(), uuid.New()
+	repo := &racingDiagnosisRepo{}
+	svc := services.NewDiagnosisService(repo,
+		&stubPatientRepo{patients: map[uuid.UUID]entities.Patient{patientID: {ID: patientID, HospitalID: hospitalID}}},
+		&roleStaffRepo{roles: map[uuid.UUID]string{supervisorID: entities.RoleSupervisor, doctorID: entities.RoleDoctor}})
+	ctx := context.Background()
+
+	t.Run("only supervisors import the shared catalogue", func(t *testing.T) {
+		csv := "code,description\nJ45.9,Asthma\n"
+		_, err := svc.ImportCatalogue(ctx, strings.NewReader(csv), "", doctorID)
+		assert.ErrorIs(t, err, services.ErrForbidden)
+		assert.Zero(t, repo.upserted)
+
+		n, err := svc.ImportCatalogue(ctx, strings.NewReader(csv), "", supervisorID)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, 1, repo.upserted)
+	})
+
+	t.Run("a concurrent second primary is a conflict", func(t *testing.T) {
+		err := svc.Record(ctx, &entities.Diagnosis{
+			PatientID: patientID, HospitalID: hospitalID, VisitDate: time.Now(),
+			Code: "J45.9", IsPrimary: true, RecordedByID: doctorID,
+		})
+		assert.ErrorIs(t, err, services.ErrConflict)
+	})
+}
@@ -0,0 +1,185 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/handlers"
+	"go-hospital-api/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockEmergencyAccessService struct {
+	GrantFunc   func(ctx context.Context, staffID, patientID uuid.UUID, reason string, minutes int) (*entities.EmergencyAccess, error)
+	PatientFunc func(ctx context.Context, id, staffID uuid.UUID) (*entities.EmergencyAccess, error)
+	ReviewFunc  func(ctx context.Context, id, hospitalID, staffID uuid.UUID, outcome, note string) (*entities.EmergencyAccess, error)
+}
+
+func (m *mockEmergencyAccessService) Grant(ctx context.Context, staffID, patientID uuid.UUID, reason string, minutes int) (*entities.EmergencyAccess, error) {
+	return m.GrantFunc(ctx, staffID, patientID, reason, minutes)
+}
+
+func (m *mockEmergencyAccessService) Patient(ctx context.Context, id, staffID uuid.UUID) (*entities.EmergencyAccess, error) {
+	return m.PatientFunc(ctx, id, staffID)
+}
+
+func (m *mockEmergencyAccessService) Report(ctx context.Context, hospitalID, staffID uuid.UUID, reviewStatus string) ([]entities.EmergencyAccess, error) {
+	return nil, nil
+}
+
+func (m *mockEmergencyAccessService) Review(ctx context.Context, id, hospitalID, staffID uuid.UUID, outcome, note string) (*entities.EmergencyAccess, error) {
+	return m.ReviewFunc(ctx, id, hospitalID, staffID, outcome, note)
+}
+
+func (m *mockEmergencyAccessService) AuditEvents(ctx context.Context, hospitalID, staffID uuid.UUID, priority string) ([]entities.AuditEvent, error) {
+	return nil, nil
+}
+
+func newEmergencyAccessRouter(svc services.EmergencyAccessServiceInterface) *gin.Engine {
+	h := handlers.NewEmergencyAccessHandler(svc, &mockStaffService2{
+		GetHospitalIDByStaffIDFunc: func(staffID string) (uuid.UUID, error) { return uuid.New(), nil },
+	})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/break-glass", h.BreakGlassHandler)
+	router.GET("/break-glass/:id/patient", h.PatientHandler)
+	router.POST("/break-glass/:id/review", h.ReviewHandler)
+	return router
+}
+
+func TestEmergencyAccessHandler_BreakGlassHandler(t *testing.T) {
+	cases := []struct {
+		name           string
+		grantFunc      func(ctx context.Context, staffID, patientID uuid.UUID, reason string, minutes int) (*entities.EmergencyAccess, error)
+		wantStatusCode int
+	}{
+		{
+			name: "positive",
+			grantFunc: func(ctx context.Context, staffID, patientID uuid.UUID, reason string, minutes int) (*entities.EmergencyAccess, error) {
+				now := time.Now()
+				return &entities.EmergencyAccess{
+					ID: uuid.New(), StaffID: staffID, PatientID: patientID, Reason: reason,
+					GrantedAt: now, ExpiresAt: now.Add(time.Hour), ReviewStatus: entities.EmergencyReviewPending,
+				}, nil
+			},
+			wantStatusCode: http.StatusCreated,
+		},
+		{
+			name: "negative reason too short",
+			grantFunc: func(ctx context.Context, staffID, patientID uuid.UUID, reason string, minutes int) (*entities.EmergencyAccess, error) {
+				return nil, fmt.Errorf("%w: a reason of at least %d characters is required", services.ErrInvalidInput, services.MinEmergencyReasonLength)
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "negative not a clinician",
+			grantFunc: func(ctx context.Context, staffID, patientID uuid.UUID, reason string, minutes int) (*entities.EmergencyAccess, error) {
+				return nil, fmt.Errorf("%w: only doctors and nurses can break the glass", services.ErrForbidden)
+			},
+			wantStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newEmergencyAccessRouter(&mockEmergencyAccessService{GrantFunc: tc.grantFunc})
+			b, _ := json.Marshal(dto.EmergencyAccessRequest{PatientID: uuid.New(), Reason: "unconscious patient in ER, no records"})
+			req := httptest.NewRequest("POST", "/break-glass", bytes.NewReader(b))
+			req.Header.Set("Authorization", generateValidToken())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+		})
+	}
+}
+
+func TestEmergencyAccessHandler_PatientHandler(t *testing.T) {
+	cases := []struct {
+		name           string
+		patientFunc    func(ctx context.Context, id, staffID uuid.UUID) (*entities.EmergencyAccess, error)
+		wantStatusCode int
+	}{
+		{
+			name: "positive",
+			patientFunc: func(ctx context.Context, id, staffID uuid.UUID) (*entities.EmergencyAccess, error) {
+				return &entities.EmergencyAccess{
+					ID: id, StaffID: staffID, ExpiresAt: time.Now().Add(time.Hour),
+					Patient: entities.Patient{ID: uuid.New(), PatientHN: "HN001"},
+				}, nil
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "negative expired",
+			patientFunc: func(ctx context.Context, id, staffID uuid.UUID) (*entities.EmergencyAccess, error) {
+				return nil, fmt.Errorf("%w: emergency access has expired", services.ErrForbidden)
+			},
+			wantStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newEmergencyAccessRouter(&mockEmergencyAccessService{PatientFunc: tc.patientFunc})
+			req := httptest.NewRequest("GET", "/break-glass/"+uuid.New().String()+"/patient", nil)
+			req.Header.Set("Authorization", generateValidToken())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+		})
+	}
+}
+
+func TestEmergencyAccessHandler_ReviewHandler(t *testing.T) {
+	cases := []struct {
+		name           string
+		reviewFunc     func(ctx context.Context, id, hospitalID, staffID uuid.UUID, outcome, note string) (*entities.EmergencyAccess, error)
+		wantStatusCode int
+	}{
+		{
+			name: "positive",
+			reviewFunc: func(ctx context.Context, id, hospitalID, staffID uuid.UUID, outcome, note string) (*entities.EmergencyAccess, error) {
+				assert.Equal(t, entities.EmergencyReviewUnjustified, outcome)
+				now := time.Now()
+				return &entities.EmergencyAccess{ID: id, ReviewStatus: outcome, ReviewedByID: &staffID, ReviewedAt: &now, ReviewNote: note}, nil
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "negative already reviewed",
+			reviewFunc: func(ctx context.Context, id, hospitalID, staffID uuid.UUID, outcome, note string) (*entities.EmergencyAccess, error) {
+				return nil, fmt.Errorf("%w: emergency access has already been reviewed", services.ErrConflict)
+			},
+			wantStatusCode: http.StatusConflict,
+		},
+		{
+			name: "negative not a supervisor",
+			reviewFunc: func(ctx context.Context, id, hospitalID, staffID uuid.UUID, outcome, note string) (*entities.EmergencyAccess, error) {
+				return nil, fmt.Errorf("%w: only supervisors can review emergency access", services.ErrForbidden)
+			},
+			wantStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newEmergencyAccessRouter(&mockEmergencyAccessService{ReviewFunc: tc.reviewFunc})
+			b, _ := json.Marshal(dto.EmergencyAccessReviewRequest{Outcome: entities.EmergencyReviewUnjustified, Note: "patient was not in the ER"})
+			req := httptest.NewRequest("POST", "/break-glass/"+uuid.New().String()+"/review", bytes.NewReader(b))
+			req.Header.Set("Authorization", generateValidToken())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/services"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryOutboxRepo is an in-memory repository.OutboxRepository
+type memoryOutboxRepo struct {
+	mu     sync.Mutex
+	events []entities.OutboxEvent
+}
+
+func (m *memoryOutboxRepo) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]entities.OutboxEvent, error) {
+	var due []entities.OutboxEvent
+	for i := range m.events {
+		e := &m.events[i]
+		if e.DispatchedAt == nil && !e.NextAttemptAt.After(now) && len(due) < limit {
+			due = append(due, *e)
+			e.NextAttemptAt = now.Add(lease)
+		}
+	}
+	return due, nil
+}
+
+func (m *memoryOutboxRepo) Save(ctx context.Context, event *entities.OutboxEvent) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.events {
+		if m.events[i].ID == event.ID {
+			m.events[i] = *event
+		}
+	}
+	return nil
+}
+
+func (m *memoryOutboxRepo) get(id uuid.UUID) entities.OutboxEvent {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, e := range m.events {
+		if e.ID == id {
+			return e
+		}
+	}
+	return entities.OutboxEvent{}
+}
+
+// recordingSink remembers the events it took and fails the first failures of them
+type recordingSink struct {
+	name     string
+	mu       sync.Mutex
+	events   []uuid.UUID
+	failures int
+}
+
+func (s *recordingSink) Name() string { return s.name }
+
+func (s *recordingSink) Handle(ctx context.Context, e entities.OutboxEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, e.ID)
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("cache unavailable")
+	}
+	return nil
+}
+
+func (s *recordingSink) count(id uuid.UUID) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, e := range s.events {
+		if e == id {
+			n++
+		}
+	}
+	return n
+}
+
+func TestEventBus_Publish(t *testing.T) {
+	all := &recordingSink{name: "all"}
+	patients := &recordingSink{name: "patients"}
+	failing := &recordingSink{name: "failing", failures: 1}
+	bus := services.NewEventBus()
+	bus.Subscribe(all)
+	bus.Subscribe(patients, entities.EventPatientCreated, entities.EventPatientUpdated)
+	bus.Subscribe(failing, entities.EventStaffCreated)
+	ctx := context.Background()
+
+	created := outboxEvent(uuid.New(), entities.EventPatientCreated, dto.PatientEventData{PatientHN: "HN0001"})
+	failed, err := bus.Publish(ctx, created, nil)
+	require.NoError(t, err)
+	assert.Empty(t, failed)
+	assert.Equal(t, 1, all.count(created.ID))
+	assert.Equal(t, 1, patients.count(created.ID))
+	assert.Equal(t, 0, failing.count(created.ID))
+
+	staff := outboxEvent(uuid.New(), entities.EventStaffCreated, dto.StaffEventData{Username: "nurse01"})
+	failed, err = bus.Publish(ctx, staff, nil)
+	assert.ErrorContains(t, err, "failing: cache unavailable")
+	assert.Equal(t, []string{"failing"}, failed)
+	assert.Equal(t, 0, patients.count(staff.ID))
+
+	// Handing the event again only to the sink that failed
+	failed, err = bus.Publish(ctx, staff, failed)
+	require.NoError(t, err)
+	assert.Empty(t, failed)
+	assert.Equal(t, 1, all.count(staff.ID))
+	assert.Equal(t, 2, failing.count(staff.ID))
+}
+
+func TestEventDispatcher_Dispatch(t *testing.T) {
+	webhooks := &recordingSink{name: "webhooks"}
+	cache := &recordingSink{name: "cache", failures: 2}
+	bus := services.NewEventBus()
+	bus.Subscribe(webhooks)
+	bus.Subscribe(cache, entities.EventPatientUpdated)
+
+	hospitalID := uuid.New()
+	created := outboxEvent(hospitalID, entities.EventPatientCreated, dto.PatientEventData{PatientHN: "HN0001"})
+	updated := outboxEvent(hospitalID, entities.EventPatientUpdated, dto.PatientEventData{PatientHN: "HN0001", Version: 2})
+	repo := &memoryOutboxRepo{events: []entities.OutboxEvent{created, updated}}
+	d := services.NewEventDispatcher(repo, bus)
+	ctx := context.Background()
+
+	now := time.Now().Add(time.Second)
+	dispatched, err := d.Dispatch(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, dispatched)
+	assert.NotNil(t, repo.get(created.ID).DispatchedAt)
+	e := repo.get(updated.ID)
+	assert.Nil(t, e.DispatchedAt)
+	assert.Equal(t, 1, e.Attempts)
+	assert.Equal(t, "cache", e.FailedSinks)
+	assert.Equal(t, "cache: cache unavailable", e.LastError)
+	assert.WithinDuration(t, now.Add(10*time.Second), e.NextAttemptAt, time.Millisecond)
+
+	// Not due yet
+	dispatched, err = d.Dispatch(ctx, now.Add(5*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 0, dispatched)
+
+	_, err = d.Dispatch(ctx, now.Add(10*time.Second))
+	require.NoError(t, err)
+	assert.WithinDuration(t, now.Add(30*time.Second), repo.get(updated.ID).NextAttemptAt, time.Millisecond)
+
+	dispatched, err = d.Dispatch(ctx, now.Add(30*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 1, dispatched)
+	e = repo.get(updated.ID)
+	require.NotNil(t, e.DispatchedAt)
+	assert.Empty(t, e.FailedSinks)
+	assert.Empty(t, e.LastError)
+
+	// Sinks that took an event are not handed it again when another sink fails
+	assert.Equal(t, 1, webhooks.count(created.ID))
+	assert.Equal(t, 1, webhooks.count(updated.ID))
+	assert.Equal(t, 3, cache.count(updated.ID))
+	assert.Equal(t, 0, cache.count(created.ID))
+
+	dispatched, err = d.Dispatch(ctx, now.Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 0, dispatched)
+}
+
+func TestHTTPSink(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []*http.Request
+		bodies   [][]byte
+		status   = http.StatusAccepted
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, r)
+		bodies = append(bodies, body)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+	ctx := context.Background()
+	event := outboxEvent(uuid.New(), entities.EventPatientMerged, dto.PatientEventData{PatientHN: "HN0002", Version: 4})
+
+	sink := services.NewHTTPSink("broker", server.URL+"/events", "secret", server.Client())
+	assert.Equal(t, "broker", sink.Name())
+	require.NoError(t, sink.Handle(ctx, event))
+	require.Len(t, received, 1)
+	r := received[0]
+	assert.Equal(t, http.MethodPost, r.Method)
+	assert.Equal(t, "/events", r.URL.Path)
+	assert.Equal(t, event.ID.String(), r.Header.Get(services.WebhookEventIDHeader))
+	assert.Equal(t, entities.EventPatientMerged, r.Header.Get(services.WebhookEventTypeHeader))
+	ts, err := strconv.ParseInt(r.Header.Get(services.WebhookTimestampHeader), 10, 64)
+	require.NoError(t, err)
+	assert.Equal(t, services.SignWebhook("secret", ts, bodies[0]), r.Header.Get(services.WebhookSignatureHeader))
+	var payload dto.WebhookEvent
+	require.NoError(t, json.Unmarshal(bodies[0], &payload))
+	assert.Equal(t, event.ID, payload.EventID)
+	assert.Equal(t, event.HospitalID, payload.HospitalID)
+	assert.JSONEq(t, event.Data, string(payload.Data))
+
+	// Without a secret nothing is signed
+	require.NoError(t, services.NewHTTPSink("broker", server.URL, "", server.Client()).Handle(ctx, event))
+	assert.Empty(t, received[1].Header.Get(services.WebhookSignatureHeader))
+
+	mu.Lock()
+	status = http.StatusBadGateway
+	mu.Unlock()
+	assert.ErrorContains(t, sink.Handle(ctx, event), "HTTP 502")
+}
+
+func TestLogSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := services.NewLogSink(log.New(&buf, "", 0))
+	event := outboxEvent(uuid.New(), entities.EventPatientCreated, dto.PatientEventData{PatientHN: "HN0001", NationalID: "1103700012345"})
+	require.NoError(t, sink.Handle(context.Background(), event))
+
+	line := buf.String()
+	assert.Contains(t, line, event.ID.String())
+	assert.Contains(t, line, entities.EventPatientCreated)
+	assert.Contains(t, line, "hospital="+event.HospitalID.String())
+	// Patient details stay out of the log
+	assert.NotContains(t, line, "1103700012345")
+	assert.Equal(t, 1, strings.Count(line, "\n"))
+}
@@ -0,0 +1,227 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/handlers"
+	"go-hospital-api/internal/middleware"
+	"go-hospital-api/internal/services"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func streamEventIDs(events []services.StreamEvent) []uuid.UUID {
+	ids := make([]uuid.UUID, len(events))
+	for i, e := range events {
+		ids[i] = e.ID
+	}
+	return ids
+}
+
+func TestEventStreamService(t *testing.T) {
+	ctx := context.Background()
+	hospitalA, hospitalB := uuid.New(), uuid.New()
+	stream := services.NewEventStreamService(3)
+
+	a1 := outboxEvent(hospitalA, entities.EventPatientCreated, dto.PatientEventData{PatientHN: "HN0001"})
+	b1 := outboxEvent(hospitalB, entities.EventPatientCreated, dto.PatientEventData{PatientHN: "HN0002"})
+	deleted := outboxEvent(hospitalA, entities.EventPatientDeleted, dto.PatientEventData{PatientHN: "HN0001"})
+	a2 := outboxEvent(hospitalA, entities.EventPatientUpdated, dto.PatientEventData{PatientHN: "HN0001", Version: 2})
+	for _, e := range []entities.OutboxEvent{a1, b1, deleted, a2, a1} {
+		require.NoError(t, stream.Handle(ctx, e))
+	}
+
+	t.Run("resumes after the last event of the hospital", func(t *testing.T) {
+		sub := stream.Subscribe(hospitalA, a1.ID.String())
+		defer sub.Close()
+		assert.False(t, sub.Reset)
+		require.Equal(t, []uuid.UUID{a2.ID}, streamEventIDs(sub.Backlog))
+
+		var body dto.WebhookEvent
+		require.NoError(t, json.Unmarshal(sub.Backlog[0].Data, &body))
+		assert.Equal(t, a2.ID, body.EventID)
+		assert.Equal(t, entities.EventPatientUpdated, body.Type)
+		assert.Equal(t, hospitalA, body.HospitalID)
+	})
+
+	t.Run("an event of another hospital or an unknown ID resets", func(t *testing.T) {
+		for _, lastEventID := range []string{a1.ID.String(), uuid.NewString(), "not-an-id"} {
+			sub := stream.Subscribe(hospitalB, lastEventID)
+			assert.True(t, sub.Reset, lastEventID)
+			assert.Empty(t, sub.Backlog, lastEventID)
+			sub.Close()
+		}
+	})
+
+	t.Run("live events reach their hospital only", func(t *testing.T) {
+		sub := stream.Subscribe(hospitalA, "")
+		defer sub.Close()
+		assert.False(t, sub.Reset)
+		assert.Empty(t, sub.Backlog)
+
+		b2 := outboxEvent(hospitalB, entities.EventPatientMerged, dto.PatientEventData{PatientHN: "HN0003"})
+		a3 := outboxEvent(hospitalA, entities.EventPatientMerged, dto.PatientEventData{PatientHN: "HN0004"})
+		require.NoError(t, stream.Handle(ctx, b2))
+		require.NoError(t, stream.Handle(ctx, a3))
+		assert.Equal(t, a3.ID, (<-sub.Events).ID)
+		assert.Empty(t, sub.Events)
+	})
+
+	t.Run("evicted events reset", func(t *testing.T) {
+		sub := stream.Subscribe(hospitalA, a1.ID.String())
+		defer sub.Close()
+		assert.True(t, sub.Reset)
+	})
+
+	t.Run("a subscriber that falls behind is dropped", func(t *testing.T) {
+		sub := stream.Subscribe(hospitalA, "")
+		received := 0
+		for range 100 {
+			require.NoError(t, stream.Handle(ctx, outboxEvent(hospitalA, entities.EventPatientUpdated, dto.PatientEventData{})))
+		}
+		for range sub.Events {
+			received++
+		}
+		assert.Less(t, received, 100)
+		sub.Close()
+	})
+}
+
+// readStreamFrame reads one server-sent event, or comment, up to the blank line after it
+func readStreamFrame(t *testing.T, r *bufio.Reader) map[string]string {
+	t.Helper()
+	frame := map[string]string{}
+	for {
+		line, err := r.ReadString('\n')
+		if errors.Is(err, io.EOF) && len(frame) == 0 {
+			return nil
+		}
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return frame
+		}
+		if comment, ok := strings.CutPrefix(line, ":"); ok {
+			frame["comment"] = strings.TrimSpace(comment)
+			continue
+		}
+		field, value, _ := strings.Cut(line, ": ")
+		frame[field] = value
+	}
+}
+
+// readStreamEvent reads the next server-sent event, skipping heartbeats
+func readStreamEvent(t *testing.T, r *bufio.Reader) map[string]string {
+	t.Helper()
+	for {
+		frame := readStreamFrame(t, r)
+		if frame == nil || frame["comment"] == "" {
+			return frame
+		}
+	}
+}
+
+func TestEventStreamHandler(t *testing.T) {
+	hospitalA, hospitalB := uuid.New(), uuid.New()
+	staffID := uuid.New()
+	var (
+		mu      sync.Mutex
+		removed bool
+	)
+	staffService := &mockStaffService2{
+		GetHospitalIDByStaffIDFunc: func(id string) (uuid.UUID, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if id != staffID.String() || removed {
+				return uuid.Nil, services.ErrNotFound
+			}
+			return hospitalA, nil
+		},
+	}
+	stream := services.NewEventStreamService(10)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handlers.RegisterRoutes(router.Group("/api"), handlers.Handlers{
+		EventStream: handlers.NewEventStreamHandler(stream, staffService, 50*time.Millisecond),
+	}, middleware.Idempotency(newMemoryIdempotencyRepo(), time.Hour))
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	open := func(token, lastEventID string) *http.Response {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/events/stream", nil)
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		if lastEventID != "" {
+			req.Header.Set("Last-Event-ID", lastEventID)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := open("", "")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	a1 := outboxEvent(hospitalA, entities.EventPatientCreated, dto.PatientEventData{PatientHN: "HN0001"})
+	a2 := outboxEvent(hospitalA, entities.EventPatientUpdated, dto.PatientEventData{PatientHN: "HN0001", Version: 2})
+	b1 := outboxEvent(hospitalB, entities.EventPatientCreated, dto.PatientEventData{PatientHN: "HN0002"})
+	for _, e := range []entities.OutboxEvent{a1, b1, a2} {
+		require.NoError(t, stream.Handle(ctx, e))
+	}
+
+	resp = open(tokenForStaff(staffID), a1.ID.String())
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	body := bufio.NewReader(resp.Body)
+
+	// The missed event first, then live events of the hospital only
+	event := readStreamEvent(t, body)
+	assert.Equal(t, a2.ID.String(), event["id"])
+	assert.Equal(t, entities.EventPatientUpdated, event["event"])
+	var data dto.WebhookEvent
+	require.NoError(t, json.Unmarshal([]byte(event["data"]), &data))
+	assert.Equal(t, hospitalA, data.HospitalID)
+
+	b2 := outboxEvent(hospitalB, entities.EventPatientMerged, dto.PatientEventData{PatientHN: "HN0003"})
+	a3 := outboxEvent(hospitalA, entities.EventPatientMerged, dto.PatientEventData{PatientHN: "HN0004"})
+	require.NoError(t, stream.Handle(ctx, b2))
+	require.NoError(t, stream.Handle(ctx, a3))
+	event = readStreamEvent(t, body)
+	assert.Equal(t, a3.ID.String(), event["id"])
+	assert.Equal(t, entities.EventPatientMerged, event["event"])
+
+	// Heartbeats keep the idle stream open until the staff member is removed
+	assert.Equal(t, map[string]string{"comment": "heartbeat"}, readStreamFrame(t, body))
+	mu.Lock()
+	removed = true
+	mu.Unlock()
+	assert.Nil(t, readStreamEvent(t, body))
+
+	// A Last-Event-ID of another hospital resets instead of resuming
+	mu.Lock()
+	removed = false
+	mu.Unlock()
+	reset := open(tokenForStaff(staffID), b1.ID.String())
+	defer reset.Body.Close()
+	event = readStreamEvent(t, bufio.NewReader(reset.Body))
+	assert.Equal(t, "reset", event["event"])
+}
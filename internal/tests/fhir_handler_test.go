@@ -0,0 +1,342 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/fhir"
+	"go-hospital-api/internal/handlers"
+	"go-hospital-api/internal/repository"
+	"go-hospital-api/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFHIRRouter(patientService services.PatientServiceInterface, staffService services.StaffServiceInterface) *gin.Engine {
+	h := handlers.NewFHIRHandler(patientService, staffService)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/fhir/R4/metadata", h.CapabilityHandler)
+	router.GET("/api/fhir/R4/Patient", h.SearchPatientHandler)
+	router.POST("/api/fhir/R4/Patient", h.CreatePatientHandler)
+	router.GET("/api/fhir/R4/Patient/:id", h.ReadPatientHandler)
+	router.PUT("/api/fhir/R4/Patient/:id", h.UpdatePatientHandler)
+	router.GET("/api/fhir/R4/PractitionerRole/:id", h.ReadPractitionerRoleHandler)
+	return router
+}
+
+func fhirStaffService() *mockStaffService2 {
+	return &mockStaffService2{
+		GetHospitalIDByStaffIDFunc: func(staffID string) (uuid.UUID, error) { return uuid.New(), nil },
+	}
+}
+
+func fhirRequest(method, target, body string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Authorization", generateValidToken())
+	req.Header.Set("Content-Type", fhir.ContentType)
+	return req
+}
+
+func TestFHIRHandler_ReadPatient(t *testing.T) {
+	dob := time.Date(1990, 4, 12, 0, 0, 0, 0, time.UTC)
+	router := newFHIRRouter(&mockPatientService{
+		GetFunc: func(ctx context.Context, id, hospitalID uuid.UUID, asOf *time.Time) (*entities.Patient, error) {
+			return &entities.Patient{
+				ID: id, HospitalID: hospitalID, Version: 3,
+				FirstNameTH: "สมชาย", LastNameTH: "ใจดี", FirstNameEN: "Somchai", LastNameEN: "Jaidee",
+				PatientHN: "HN001", NationalID: "1234567890123", Gender: "M", DateOfBirth: &dob,
+				PhoneNumber: "0812345678",
+			}, nil
+		},
+	}, fhirStaffService())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, fhirRequest("GET", "/api/fhir/R4/Patient/"+uuid.New().String(), ""))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `W/"3"`, w.Header().Get("ETag"))
+	assert.Contains(t, w.Header().Get("Content-Type"), fhir.ContentType)
+
+	var resource fhir.Patient
+	json.Unmarshal(w.Body.Bytes(), &resource)
+	assert.Equal(t, "Patient", resource.ResourceType)
+	assert.Equal(t, "3", resource.Meta.VersionID)
+	assert.Equal(t, "male", resource.Gender)
+	assert.Equal(t, "1990-04-12", resource.BirthDate)
+	if assert.Len(t, resource.Identifier, 2) {
+		assert.Equal(t, fhir.SystemHN, resource.Identifier[0].System)
+		assert.Equal(t, "HN001", resource.Identifier[0].Value)
+		assert.Equal(t, fhir.SystemNationalID, resource.Identifier[1].System)
+	}
+	if assert.Len(t, resource.Name, 2) {
+		assert.Equal(t, "ใจดี", resource.Name[0].Family)
+		assert.Equal(t, []string{"Somchai"}, resource.Name[1].Given)
+	}
+}
+
+func TestFHIRHandler_ReadPatient_NotFound(t *testing.T) {
+	router := newFHIRRouter(&mockPatientService{
+		GetFunc: func(ctx context.Context, id, hospitalID uuid.UUID, asOf *time.Time) (*entities.Patient, error) {
+			return nil, fmt.Errorf("%w: patient", services.ErrNotFound)
+		},
+	}, fhirStaffService())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, fhirRequest("GET", "/api/fhir/R4/Patient/"+uuid.New().String(), ""))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	var outcome fhir.OperationOutcome
+	json.Unmarshal(w.Body.Bytes(), &outcome)
+	assert.Equal(t, "OperationOutcome", outcome.ResourceType)
+	assert.Equal(t, "not-found", outcome.Issue[0].Code)
+}
+
+func TestFHIRHandler_SearchPatient(t *testing.T) {
+	var criteria dto.PatientSearchCriteria
+	router := newFHIRRouter(&mockPatientService{
+		SearchPageFunc: func(ctx context.Context, c dto.PatientSearchCriteria, offset, limit int) ([]entities.Patient, int64, error) {
+			criteria = c
+			assert.Equal(t, 2, offset)
+			assert.Equal(t, 2, limit)
+			patients := make([]entities.Patient, limit)
+			for i := range patients {
+				patients[i] = entities.Patient{ID: uuid.New(), PatientHN: fmt.Sprintf("HN%03d", offset+i), Version: 1}
+			}
+			return patients, 5, nil
+		},
+	}, fhirStaffService())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, fhirRequest("GET", "/api/fhir/R4/Patient?identifier="+fhir.SystemHN+"|HN001&gender=female&birthdate=eq1990-04-12&name=som&_count=2&_offset=2", ""))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	if assert.NotNil(t, criteria.PatientHN) {
+		assert.Equal(t, "HN001", *criteria.PatientHN)
+	}
+	if assert.NotNil(t, criteria.Gender) {
+		assert.Equal(t, "F", *criteria.Gender)
+	}
+	if assert.NotNil(t, criteria.DateOfBirth) {
+		assert.Equal(t, "1990-04-12", criteria.DateOfBirth.Format("2006-01-02"))
+	}
+	if assert.NotNil(t, criteria.Name) {
+		assert.Equal(t, "som", *criteria.Name)
+	}
+
+	var bundle struct {
+		Type  string            `json:"type"`
+		Total int               `json:"total"`
+		Link  []fhir.BundleLink `json:"link"`
+		Entry []struct {
+			FullURL  string       `json:"fullUrl"`
+			Resource fhir.Patient `json:"resource"`
+		} `json:"entry"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &bundle)
+	assert.Equal(t, "searchset", bundle.Type)
+	assert.Equal(t, 5, bundle.Total)
+	if assert.Len(t, bundle.Entry, 2) {
+		assert.Equal(t, "HN002", bundle.Entry[0].Resource.Identifier[0].Value)
+		assert.True(t, strings.HasPrefix(bundle.Entry[0].FullURL, "http://example.com/api/fhir/R4/Patient/"))
+	}
+	relations := map[string]string{}
+	for _, l := range bundle.Link {
+		relations[l.Relation] = l.URL
+	}
+	assert.Contains(t, relations["next"], "_offset=4")
+	assert.Contains(t, relations["previous"], "_offset=0")
+	assert.Contains(t, relations["self"], "_offset=2")
+}
+
+func TestFHIRHandler_SearchPatient_UnknownIdentifierSystem(t *testing.T) {
+	router := newFHIRRouter(&mockPatientService{}, fhirStaffService())
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, fhirRequest("GET", "/api/fhir/R4/Patient?identifier=urn:other|123", ""))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"total":0`)
+}
+
+func TestFHIRHandler_CreatePatient(t *testing.T) {
+	cases := []struct {
+		name           string
+		body           string
+		createFunc     func(ctx context.Context, patient *entities.Patient, staffID uuid.UUID) error
+		wantStatusCode int
+	}{
+		{
+			name: "positive",
+			body: `{"resourceType":"Patient","identifier":[{"system":"` + fhir.SystemHN + `","value":"HN100"}],
+				"name":[{"family":"ใจดี","given":["สมหญิง"]},{"family":"Jaidee","given":["Somying","Mali"]}],
+				"gender":"female","birthDate":"1985-01-20","telecom":[{"system":"email","value":"somying@example.com"}]}`,
+			createFunc: func(ctx context.Context, patient *entities.Patient, staffID uuid.UUID) error {
+				assert.Equal(t, "HN100", patient.PatientHN)
+				assert.Equal(t, "สมหญิง", patient.FirstNameTH)
+				assert.Equal(t, "Somying", patient.FirstNameEN)
+				assert.Equal(t, "Mali", patient.MiddleNameEN)
+				assert.Equal(t, "F", patient.Gender)
+				assert.Equal(t, "somying@example.com", patient.Email)
+				patient.ID = uuid.New()
+				patient.Version = 1
+				return nil
+			},
+			wantStatusCode: http.StatusCreated,
+		},
+		{
+			name:           "negative wrong resource type",
+			body:           `{"resourceType":"Practitioner"}`,
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "negative unknown gender code",
+			body:           `{"resourceType":"Patient","gender":"M"}`,
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "negative duplicate HN",
+			body: `{"resourceType":"Patient","identifier":[{"system":"` + fhir.SystemHN + `","value":"HN100"}],"name":[{"given":["Somying"]}]}`,
+			createFunc: func(ctx context.Context, patient *entities.Patient, staffID uuid.UUID) error {
+				return fmt.Errorf("%w: HN HN100 is already registered", services.ErrConflict)
+			},
+			wantStatusCode: http.StatusConflict,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newFHIRRouter(&mockPatientService{CreateFunc: tc.createFunc}, fhirStaffService())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, fhirRequest("POST", "/api/fhir/R4/Patient", tc.body))
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			if tc.wantStatusCode == http.StatusCreated {
+				assert.Regexp(t, `^http://example\.com/api/fhir/R4/Patient/[0-9a-f-]{36}$`, w.Header().Get("Location"))
+				assert.Equal(t, `W/"1"`, w.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestFHIRHandler_UpdatePatient(t *testing.T) {
+	cases := []struct {
+		name           string
+		ifMatch        string
+		updateFunc     func(ctx context.Context, id, hospitalID, staffID uuid.UUID, version int, req dto.PatientUpdateRequest) (*entities.Patient, error)
+		wantStatusCode int
+	}{
+		{
+			name:    "positive",
+			ifMatch: `W/"2"`,
+			updateFunc: func(ctx context.Context, id, hospitalID, staffID uuid.UUID, version int, req dto.PatientUpdateRequest) (*entities.Patient, error) {
+				assert.Equal(t, 2, version)
+				assert.Equal(t, "Somchai", *req.FirstNameEn)
+				assert.Equal(t, "", *req.PhoneNumber, "fields left out are cleared")
+				return &entities.Patient{ID: id, FirstNameEN: *req.FirstNameEn, Version: 3}, nil
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "negative missing If-Match",
+			wantStatusCode: http.StatusPreconditionRequired,
+		},
+		{
+			name:    "negative stale version",
+			ifMatch: `W/"1"`,
+			updateFunc: func(ctx context.Context, id, hospitalID, staffID uuid.UUID, version int, req dto.PatientUpdateRequest) (*entities.Patient, error) {
+				return nil, fmt.Errorf("%w: patient is at version 2", services.ErrPreconditionFailed)
+			},
+			wantStatusCode: http.StatusPreconditionFailed,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newFHIRRouter(&mockPatientService{UpdateFunc: tc.updateFunc}, fhirStaffService())
+			req := fhirRequest("PUT", "/api/fhir/R4/Patient/"+uuid.New().String(), `{"resourceType":"Patient","name":[{"given":["Somchai"]}]}`)
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+		})
+	}
+}
+
+func TestFHIRHandler_UpdatePatient_IDMismatch(t *testing.T) {
+	router := newFHIRRouter(&mockPatientService{}, fhirStaffService())
+	body, _ := json.Marshal(fhir.Patient{ResourceType: "Patient", ID: uuid.New().String()})
+	req := fhirRequest("PUT", "/api/fhir/R4/Patient/"+uuid.New().String(), string(body))
+	req.Header.Set("If-Match", `W/"1"`)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestFHIRHandler_ReadPractitionerRole(t *testing.T) {
+	staffID := uuid.New()
+	router := newFHIRRouter(&mockPatientService{}, &mockStaffService2{
+		GetHospitalIDByStaffIDFunc: func(string) (uuid.UUID, error) { return uuid.New(), nil },
+		GetByIDFunc: func(id, hospitalID uuid.UUID) (*entities.Staff, error) {
+			return &entities.Staff{ID: id, Username: "dr.somsak", Role: entities.RoleDoctor, HospitalID: hospitalID, Version: 1}, nil
+		},
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, fhirRequest("GET", "/api/fhir/R4/PractitionerRole/"+staffID.String(), ""))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var role fhir.PractitionerRole
+	json.Unmarshal(w.Body.Bytes(), &role)
+	assert.Equal(t, "Practitioner/"+staffID.String(), role.Practitioner.Reference)
+	assert.Equal(t, entities.RoleDoctor, role.Code[0].Coding[0].Code)
+}
+
+func TestFHIRHandler_Capability(t *testing.T) {
+	router := newFHIRRouter(&mockPatientService{}, fhirStaffService())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/api/fhir/R4/metadata", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var statement fhir.CapabilityStatement
+	json.Unmarshal(w.Body.Bytes(), &statement)
+	assert.Equal(t, fhir.Version, statement.FHIRVersion)
+	assert.Equal(t, "Patient", statement.Rest[0].Resource[0].Type)
+}
+
+func TestPatientRepository_SearchPage(t *testing.T) {
+	ctx := context.Background()
+	conn := newTestDB(t)
+	hospitalID, otherID, staffID := uuid.New(), uuid.New(), uuid.New()
+	repo := repository.NewPatientRepository(conn)
+	for _, id := range []uuid.UUID{hospitalID, otherID} {
+		require.NoError(t, conn.Create(&entities.Hospital{ID: id, Name: id.String()}).Error)
+	}
+	for i := 4; i >= 0; i-- {
+		require.NoError(t, repo.Create(ctx, &entities.Patient{ID: uuid.New(), PatientHN: fmt.Sprintf("HN%03d", i), HospitalID: hospitalID, Version: 1}, staffID))
+	}
+	require.NoError(t, repo.Create(ctx, &entities.Patient{ID: uuid.New(), PatientHN: "HN900", HospitalID: otherID, Version: 1}, staffID))
+
+	criteria := dto.PatientSearchCriteria{HospitalID: hospitalID}
+	patients, total, err := repo.SearchPage(ctx, criteria, 2, 2)
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, total)
+	if assert.Len(t, patients, 2) {
+		assert.Equal(t, "HN002", patients[0].PatientHN)
+		assert.Equal(t, "HN003", patients[1].PatientHN)
+	}
+
+	patients, total, err = repo.SearchPage(ctx, criteria, 10, 2)
+	require.NoError(t, err)
+	assert.EqualValues(t, 5, total)
+	assert.Empty(t, patients)
+}
@@ -0,0 +1,405 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/graphql"
+	"go-hospital-api/internal/handlers"
+	"go-hospital-api/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryLookupRepo serves the GraphQL loaders from memory and counts the queries they make
+type memoryLookupRepo struct {
+	mu         sync.Mutex
+	hospitals  []entities.Hospital
+	staff      []entities.Staff
+	admissions []entities.Admission
+	diagnoses  []entities.Diagnosis
+	queries    map[string]int
+}
+
+func (r *memoryLookupRepo) count(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.queries == nil {
+		r.queries = make(map[string]int)
+	}
+	r.queries[name]++
+}
+
+func (r *memoryLookupRepo) Hospitals(ctx context.Context, ids []uuid.UUID) ([]entities.Hospital, error) {
+	r.count("hospitals")
+	var out []entities.Hospital
+	for _, h := range r.hospitals {
+		if slices.Contains(ids, h.ID) {
+			out = append(out, h)
+		}
+	}
+	return out, nil
+}
+
+func (r *memoryLookupRepo) Staff(ctx context.Context, ids []uuid.UUID, hospitalID uuid.UUID) ([]entities.Staff, error) {
+	r.count("staff")
+	var out []entities.Staff
+	for _, s := range r.staff {
+		if slices.Contains(ids, s.ID) && s.HospitalID == hospitalID {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+func (r *memoryLookupRepo) AdmissionsByPatients(ctx context.Context, patientIDs []uuid.UUID, hospitalID uuid.UUID) ([]entities.Admission, error) {
+	r.count("admissions")
+	var out []entities.Admission
+	for _, a := range r.admissions {
+		if slices.Contains(patientIDs, a.PatientID) && a.HospitalID == hospitalID {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func (r *memoryLookupRepo) DiagnosesByPatients(ctx context.Context, patientIDs []uuid.UUID, hospitalID uuid.UUID) ([]entities.Diagnosis, error) {
+	r.count("diagnoses")
+	var out []entities.Diagnosis
+	for _, d := range r.diagnoses {
+		if slices.Contains(patientIDs, d.PatientID) && d.HospitalID == hospitalID {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
+
+type graphqlFixture struct {
+	hospitalID, staffID, nurseID, otherStaffID uuid.UUID
+	patients                                   []entities.Patient
+	repo                                       *memoryLookupRepo
+	router                                     *gin.Engine
+}
+
+func newGraphQLFixture(t *testing.T, limits graphql.Limits) *graphqlFixture {
+	f := &graphqlFixture{hospitalID: uuid.New(), staffID: uuid.New(), nurseID: uuid.New(), otherStaffID: uuid.New()}
+	otherHospitalID := uuid.New()
+	dob := time.Date(1990, 4, 21, 0, 0, 0, 0, time.UTC)
+	for i, hn := range []string{"HN001", "HN002", "HN003"} {
+		f.patients = append(f.patients, entities.Patient{
+			ID:          uuid.New(),
+			PatientHN:   hn,
+			FirstNameEN: []string{"Somchai", "Malee", "Anan"}[i],
+			DateOfBirth: &dob,
+			HospitalID:  f.hospitalID,
+			Version:     1,
+		})
+	}
+	admittedAt := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	dischargedAt := admittedAt.Add(72 * time.Hour)
+	f.repo = &memoryLookupRepo{
+		hospitals: []entities.Hospital{{ID: f.hospitalID, Name: "Siriraj"}, {ID: otherHospitalID, Name: "Ramathibodi"}},
+		staff: []entities.Staff{
+			{ID: f.staffID, Username: "doctor1", Role: entities.RoleDoctor, HospitalID: f.hospitalID},
+			{ID: f.nurseID, Username: "nurse1", Role: entities.RoleNurse, HospitalID: f.hospitalID},
+			{ID: f.otherStaffID, Username: "elsewhere", Role: entities.RoleDoctor, HospitalID: otherHospitalID},
+		},
+		admissions: []entities.Admission{
+			{ID: uuid.New(), PatientID: f.patients[0].ID, HospitalID: f.hospitalID, Status: entities.AdmissionActive, Reason: "Pneumonia",
+				Bed: entities.Bed{Label: "A-01"}, AdmittedByID: f.staffID, AdmittedAt: admittedAt},
+			{ID: uuid.New(), PatientID: f.patients[0].ID, HospitalID: f.hospitalID, Status: entities.AdmissionDischarged,
+				AdmittedByID: f.nurseID, AdmittedAt: admittedAt.AddDate(0, -1, 0), DischargedByID: &f.staffID, DischargedAt: &dischargedAt},
+			{ID: uuid.New(), PatientID: f.patients[1].ID, HospitalID: f.hospitalID, Status: entities.AdmissionActive,
+				AdmittedByID: f.otherStaffID, AdmittedAt: admittedAt},
+		},
+		diagnoses: []entities.Diagnosis{
+			{ID: uuid.New(), PatientID: f.patients[0].ID, HospitalID: f.hospitalID, VisitDate: admittedAt, Code: "J18.9", Edition: entities.EditionICD10, IsPrimary: true, RecordedByID: f.staffID},
+			{ID: uuid.New(), PatientID: f.patients[2].ID, HospitalID: f.hospitalID, VisitDate: admittedAt, Code: "E11.9", Edition: entities.EditionICD10, RecordedByID: f.nurseID},
+		},
+	}
+	patientService := &mockPatientService{
+		SearchFunc: func(ctx context.Context, criteria dto.PatientSearchCriteria) ([]entities.Patient, error) {
+			assert.Equal(t, f.hospitalID, criteria.HospitalID)
+			return f.patients, nil
+		},
+		GetFunc: func(ctx context.Context, id, hospitalID uuid.UUID, asOf *time.Time) (*entities.Patient, error) {
+			assert.Equal(t, f.hospitalID, hospitalID)
+			for _, p := range f.patients {
+				if p.ID == id {
+					return &p, nil
+				}
+			}
+			return nil, services.ErrNotFound
+		},
+	}
+	staffService := &mockStaffService2{
+		GetHospitalIDByStaffIDFunc: func(staffID string) (uuid.UUID, error) { return f.hospitalID, nil },
+	}
+	h := handlers.NewGraphQLHandler(patientService, services.NewLookupService(f.repo), staffService, limits)
+	gin.SetMode(gin.TestMode)
+	f.router = gin.New()
+	f.router.POST("/graphql", h.QueryHandler)
+	f.router.GET("/graphql", h.QueryHandler)
+	return f
+}
+
+type graphqlResult struct {
+	Data   map[string]any   `json:"data"`
+	Errors []*graphql.Error `json:"errors"`
+}
+
+func (f *graphqlFixture) query(t *testing.T, query string, variables map[string]any) (int, graphqlResult) {
+	body, err := json.Marshal(graphql.Request{Query: query, Variables: variables})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", tokenForStaff(f.staffID))
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+	var result graphqlResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result), w.Body.String())
+	return w.Code, result
+}
+
+func TestGraphQL_PatientsWithRelatedData(t *testing.T) {
+	f := newGraphQLFixture(t, graphql.DefaultLimits)
+	code, result := f.query(t, `
+		query Ward {
+			patients(first: 5) {
+				id
+				hn: patientHn
+				...names
+				hospital { name }
+				encounters {
+					status
+					bedLabel
+					admittedBy { username }
+					dischargedBy { username role }
+				}
+				diagnoses { code visitDate recordedBy { username } }
+			}
+		}
+		fragment names on Patient { firstNameEn dateOfBirth }`, nil)
+	require.Equal(t, http.StatusOK, code)
+	require.Empty(t, result.Errors)
+
+	patients := result.Data["patients"].([]any)
+	require.Len(t, patients, 3)
+	first := patients[0].(map[string]any)
+	assert.Equal(t, f.patients[0].ID.String(), first["id"])
+	assert.Equal(t, "HN001", first["hn"])
+	assert.Equal(t, "Somchai", first["firstNameEn"])
+	assert.Equal(t, "1990-04-21", first["dateOfBirth"])
+	assert.Equal(t, map[string]any{"name": "Siriraj"}, first["hospital"])
+	assert.Equal(t, []any{
+		map[string]any{"status": "active", "bedLabel": "A-01", "admittedBy": map[string]any{"username": "doctor1"}, "dischargedBy": nil},
+		map[string]any{"status": "discharged", "bedLabel": "", "admittedBy": map[string]any{"username": "nurse1"},
+			"dischargedBy": map[string]any{"username": "doctor1", "role": "doctor"}},
+	}, first["encounters"])
+	assert.Equal(t, []any{map[string]any{"code": "J18.9", "visitDate": "2026-03-01", "recordedBy": map[string]any{"username": "doctor1"}}}, first["diagnoses"])
+
+	// A staff member of another hospital is not disclosed
+	second := patients[1].(map[string]any)
+	assert.Nil(t, second["encounters"].([]any)[0].(map[string]any)["admittedBy"])
+	assert.Empty(t, second["diagnoses"])
+	assert.Empty(t, patients[2].(map[string]any)["encounters"])
+
+	// One query per field, not one per patient or encounter. The staff fields load in the same
+	// batch when they resolve at the same time.
+	assert.Equal(t, 1, f.repo.queries["hospitals"])
+	assert.Equal(t, 1, f.repo.queries["admissions"])
+	assert.Equal(t, 1, f.repo.queries["diagnoses"])
+	assert.LessOrEqual(t, f.repo.queries["staff"], 3)
+}
+
+func TestGraphQL_PageLoadsInBatches(t *testing.T) {
+	f := newGraphQLFixture(t, graphql.DefaultLimits)
+	for i := range 97 {
+		f.patients = append(f.patients, entities.Patient{ID: uuid.New(), PatientHN: fmt.Sprintf("HN%03d", i+4), HospitalID: f.hospitalID})
+	}
+	code, result := f.query(t, `{ patients(first: 100) { patientHn encounters { id } diagnoses { id } } }`, nil)
+	require.Equal(t, http.StatusOK, code)
+	require.Empty(t, result.Errors)
+	require.Len(t, result.Data["patients"], 100)
+
+	// The patients of a page resolve together, so their related records load in a few batches
+	assert.Less(t, f.repo.queries["admissions"], 10)
+	assert.Less(t, f.repo.queries["diagnoses"], 10)
+}
+
+func TestGraphQL_Patient(t *testing.T) {
+	f := newGraphQLFixture(t, graphql.DefaultLimits)
+
+	code, result := f.query(t, `query($id: ID!) { patient(id: $id) { patientHn __typename } me { username hospital { id } } }`,
+		map[string]any{"id": f.patients[1].ID.String()})
+	require.Equal(t, http.StatusOK, code)
+	require.Empty(t, result.Errors)
+	assert.Equal(t, map[string]any{"patientHn": "HN002", "__typename": "Patient"}, result.Data["patient"])
+	assert.Equal(t, map[string]any{"username": "doctor1", "hospital": map[string]any{"id": f.hospitalID.String()}}, result.Data["me"])
+
+	// A missing patient is null with an error, while the other fields still resolve
+	code, result = f.query(t, `{ patient(id: "`+uuid.NewString()+`") { id } hospital { name } }`, nil)
+	require.Equal(t, http.StatusOK, code)
+	assert.Nil(t, result.Data["patient"])
+	assert.Equal(t, map[string]any{"name": "Siriraj"}, result.Data["hospital"])
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, graphql.CodeNotFound, result.Errors[0].Extensions["code"])
+	assert.Equal(t, []any{"patient"}, result.Errors[0].Path)
+
+	code, result = f.query(t, `{ patient(id: "not-a-uuid") { id } }`, nil)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, graphql.CodeBadUserInput, result.Errors[0].Extensions["code"])
+
+	// Staff of another hospital cannot be looked up
+	code, result = f.query(t, `query($id: ID!) { staff(id: $id) { username } }`, map[string]any{"id": f.otherStaffID.String()})
+	require.Equal(t, http.StatusOK, code)
+	assert.Empty(t, result.Errors)
+	assert.Nil(t, result.Data["staff"])
+}
+
+func TestGraphQL_Directives(t *testing.T) {
+	f := newGraphQLFixture(t, graphql.DefaultLimits)
+	code, result := f.query(t, `
+		query($withDiagnoses: Boolean!) {
+			patients(first: 1) {
+				patientHn
+				diagnoses @include(if: $withDiagnoses) { code }
+				encounters @skip(if: true) { id }
+			}
+		}`, map[string]any{"withDiagnoses": false})
+	require.Equal(t, http.StatusOK, code)
+	require.Empty(t, result.Errors)
+	assert.Equal(t, []any{map[string]any{"patientHn": "HN001"}}, result.Data["patients"])
+	assert.Zero(t, f.repo.queries["diagnoses"]+f.repo.queries["admissions"])
+}
+
+func TestGraphQL_RejectedQueries(t *testing.T) {
+	f := newGraphQLFixture(t, graphql.Limits{MaxDepth: 3, MaxComplexity: 200})
+	cases := []struct {
+		name      string
+		query     string
+		variables map[string]any
+		wantCode  string
+		wantMsg   string
+	}{
+		{name: "syntax error", query: `{ patients { id }`, wantCode: graphql.CodeParseFailed, wantMsg: "Expected Name, found <EOF>"},
+		{name: "unknown field", query: `{ patients { passwordHash } }`, wantCode: graphql.CodeValidationFailed, wantMsg: `Cannot query field "passwordHash" on type "Patient"`},
+		{name: "missing selection", query: `{ patients }`, wantCode: graphql.CodeValidationFailed, wantMsg: "must have a selection of subfields"},
+		{name: "unknown argument", query: `{ patients(limit: 5) { id } }`, wantCode: graphql.CodeValidationFailed, wantMsg: `Unknown argument "limit"`},
+		{name: "missing variable", query: `query($id: ID!) { patient(id: $id) { id } }`, wantCode: graphql.CodeBadUserInput, wantMsg: "must be defined"},
+		{name: "invalid variable", query: `query($n: Int) { patients(first: $n) { id } }`, variables: map[string]any{"n": "ten"}, wantCode: graphql.CodeBadUserInput, wantMsg: "cannot use string as Int"},
+		{name: "invalid scalar variable", query: `query($at: DateTime) { patient(id: "x", asOf: $at) { id } }`, variables: map[string]any{"at": "yesterday"}, wantCode: graphql.CodeBadUserInput, wantMsg: "DateTime must be an RFC 3339 timestamp"},
+		{name: "fragment cycle", query: `{ me { ...a } } fragment a on Staff { username ...b } fragment b on Staff { ...a }`, wantCode: graphql.CodeValidationFailed, wantMsg: "within itself"},
+		{name: "mutation", query: `mutation { patients { id } }`, wantCode: graphql.CodeValidationFailed, wantMsg: `Schema does not support operation type "mutation"`},
+		{name: "too deep", query: `{ patients { encounters { admittedBy { hospital { name } } } } }`, wantCode: graphql.CodeQueryTooDeep, wantMsg: "query depth 5 exceeds the maximum of 3"},
+		{name: "too complex", query: `{ patients(first: 100) { id patientHn } }`, wantCode: graphql.CodeQueryTooComplex, wantMsg: "query complexity 201 exceeds the maximum of 200"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			code, result := f.query(t, tc.query, tc.variables)
+			assert.Equal(t, http.StatusBadRequest, code)
+			assert.Nil(t, result.Data)
+			require.NotEmpty(t, result.Errors)
+			assert.Equal(t, tc.wantCode, result.Errors[0].Extensions["code"])
+			assert.Contains(t, result.Errors[0].Message, tc.wantMsg)
+		})
+	}
+	assert.Empty(t, f.repo.queries)
+
+	// Introspection does not count towards the limits
+	code, result := f.query(t, `{ __schema { queryType { name } types { name fields { name type { name ofType { name ofType { name } } } } } } }`, nil)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Empty(t, result.Errors)
+}
+
+func TestGraphQL_Introspection(t *testing.T) {
+	f := newGraphQLFixture(t, graphql.DefaultLimits)
+	code, result := f.query(t, `{
+		__schema { queryType { name } mutationType { name } directives { name } }
+		__type(name: "Patient") {
+			kind
+			fields { name type { kind name ofType { kind name } } }
+		}
+		query: __type(name: "Query") { fields { name args { name defaultValue } } }
+	}`, nil)
+	require.Equal(t, http.StatusOK, code)
+	require.Empty(t, result.Errors)
+
+	schema := result.Data["__schema"].(map[string]any)
+	assert.Equal(t, map[string]any{"name": "Query"}, schema["queryType"])
+	assert.Nil(t, schema["mutationType"])
+	assert.Subset(t, schema["directives"], []any{map[string]any{"name": "include"}, map[string]any{"name": "skip"}})
+
+	patient := result.Data["__type"].(map[string]any)
+	assert.Equal(t, "OBJECT", patient["kind"])
+	var hospitalField map[string]any
+	for _, field := range patient["fields"].([]any) {
+		if field.(map[string]any)["name"] == "hospital" {
+			hospitalField = field.(map[string]any)
+		}
+	}
+	assert.Equal(t, map[string]any{"kind": "NON_NULL", "name": nil, "ofType": map[string]any{"kind": "OBJECT", "name": "Hospital"}}, hospitalField["type"])
+
+	for _, field := range result.Data["query"].(map[string]any)["fields"].([]any) {
+		if field.(map[string]any)["name"] != "patients" {
+			continue
+		}
+		for _, arg := range field.(map[string]any)["args"].([]any) {
+			if arg.(map[string]any)["name"] == "first" {
+				assert.Equal(t, "20", arg.(map[string]any)["defaultValue"])
+			}
+		}
+	}
+}
+
+func TestGraphQL_HTTP(t *testing.T) {
+	f := newGraphQLFixture(t, graphql.DefaultLimits)
+
+	// Same authentication as the REST API
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":"{ me { id } }"}`))
+	w := httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// GET with the query and variables as parameters
+	params := url.Values{
+		"query":     {`query($n: Int) { patients(first: $n) { patientHn } }`},
+		"variables": {`{"n": 2}`},
+	}
+	req = httptest.NewRequest(http.MethodGet, "/graphql?"+params.Encode(), nil)
+	req.Header.Set("Authorization", tokenForStaff(f.staffID))
+	w = httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data":{"patients":[{"patientHn":"HN001"},{"patientHn":"HN002"}]}}`, w.Body.String())
+
+	// Fields keep the order of the query
+	req = httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query":"{ me { username id } hospital { name } }"}`))
+	req.Header.Set("Authorization", tokenForStaff(f.staffID))
+	w = httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `{"data":{"me":{"username":"doctor1","id":"`+f.staffID.String()+`"},"hospital":{"name":"Siriraj"}}}`, w.Body.String())
+
+	req = httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"variables":{}}`))
+	req.Header.Set("Authorization", tokenForStaff(f.staffID))
+	w = httptest.NewRecorder()
+	f.router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "the request has no query")
+}
@@ -0,0 +1,235 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/grpcapi"
+	"go-hospital-api/internal/services"
+	hospitalv1 "go-hospital-api/proto/hospital/v1"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// newGRPCServer serves the gRPC API on a local port, like GRPC_ADDR, and connects to it
+func newGRPCServer(t *testing.T, patientService services.PatientServiceInterface, staffService services.StaffServiceInterface) *grpc.ClientConn {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpcapi.NewHospitalServer(patientService, staffService, grpcapi.AuthInterceptor(grpcapi.PublicMethods...))
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestGRPC_Staff(t *testing.T) {
+	os.Setenv("JWT_SECRET", "testsecret")
+	hospitalID := uuid.New()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	staffID := uuid.New()
+	var created *entities.Staff
+	conn := newGRPCServer(t, &mockPatientService{}, &mockStaffService2{
+		CreateFunc: func(staff *entities.Staff, password string) error {
+			if staff.Username == "taken" {
+				return fmt.Errorf("%w: username taken", services.ErrConflict)
+			}
+			staff.Version = 1
+			staff.CreatedAt = time.Now()
+			created = staff
+			return nil
+		},
+		LoginFunc: func(username, password string, hID uuid.UUID) (*entities.Staff, error) {
+			if username != "nurse01" || hID != hospitalID || bcrypt.CompareHashAndPassword(hash, []byte(password)) != nil {
+				return nil, errors.New("invalid username or password")
+			}
+			return &entities.Staff{ID: staffID, Username: username, HospitalID: hospitalID}, nil
+		},
+	})
+	client := hospitalv1.NewStaffServiceClient(conn)
+	ctx := context.Background()
+
+	t.Run("create staff without a token", func(t *testing.T) {
+		resp, err := client.CreateStaff(ctx, &hospitalv1.CreateStaffRequest{Username: "nurse01", Password: "secret", HospitalId: hospitalID.String()})
+		require.NoError(t, err)
+		require.NotNil(t, created)
+		assert.Equal(t, entities.RoleStaff, created.Role)
+		assert.Equal(t, entities.RoleStaff, resp.GetRole())
+		assert.Equal(t, created.ID.String(), resp.GetStaffId())
+		assert.Equal(t, "nurse01", resp.GetUsername())
+		assert.Equal(t, hospitalID.String(), resp.GetHospitalId())
+		assert.EqualValues(t, 1, resp.GetVersion())
+		assert.NotNil(t, resp.GetCreatedAt())
+	})
+
+	t.Run("negative create staff", func(t *testing.T) {
+		cases := map[string]codes.Code{"taken": codes.AlreadyExists, "": codes.InvalidArgument}
+		for username, code := range cases {
+			_, err := client.CreateStaff(ctx, &hospitalv1.CreateStaffRequest{Username: username, Password: "secret", HospitalId: hospitalID.String()})
+			assert.Equal(t, code, status.Code(err), username)
+		}
+	})
+
+	t.Run("a role sent by an old client is ignored", func(t *testing.T) {
+		// Field 3 was the role before it was reserved
+		req, err := proto.Marshal(&hospitalv1.CreateStaffRequest{Username: "nurse02", Password: "secret", HospitalId: hospitalID.String()})
+		require.NoError(t, err)
+		req = append(req, 0x1a, byte(len(entities.RoleSupervisor)))
+		req = append(req, entities.RoleSupervisor...)
+		var withRole hospitalv1.CreateStaffRequest
+		require.NoError(t, proto.Unmarshal(req, &withRole))
+		resp, err := client.CreateStaff(ctx, &withRole)
+		require.NoError(t, err)
+		assert.Equal(t, entities.RoleStaff, resp.GetRole())
+		assert.Equal(t, entities.RoleStaff, created.Role)
+	})
+
+	t.Run("login", func(t *testing.T) {
+		req := &hospitalv1.LoginRequest{Username: "nurse01", Password: "secret", HospitalId: hospitalID.String()}
+		resp, err := client.Login(ctx, req)
+		require.NoError(t, err)
+		assert.NotEmpty(t, resp.GetToken())
+
+		req.Password = "wrong"
+		_, err = client.Login(ctx, req)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		assert.ErrorContains(t, err, "invalid username or password")
+	})
+}
+
+func TestGRPC_Patients(t *testing.T) {
+	hospitalID, patientID := uuid.New(), uuid.New()
+	dob := time.Date(1985, 2, 14, 0, 0, 0, 0, time.UTC)
+	patient := entities.Patient{
+		ID: patientID, PatientHN: "HN0001", FirstNameTH: "สมชาย", LastNameTH: "ใจดี", FirstNameEN: "Somchai",
+		DateOfBirth: &dob, NationalID: "1103700012345", HospitalID: hospitalID, Version: 3, CreatedAt: time.Now(),
+	}
+	var criteria dto.PatientSearchCriteria
+	var asOf *time.Time
+	conn := newGRPCServer(t, &mockPatientService{
+		SearchFunc: func(ctx context.Context, c dto.PatientSearchCriteria) ([]entities.Patient, error) {
+			criteria = c
+			return []entities.Patient{patient, patient}, nil
+		},
+		GetFunc: func(ctx context.Context, id, hID uuid.UUID, at *time.Time) (*entities.Patient, error) {
+			asOf = at
+			if id != patientID || hID != hospitalID {
+				return nil, fmt.Errorf("%w: patient", services.ErrNotFound)
+			}
+			return &patient, nil
+		},
+	}, &mockStaffService2{
+		GetHospitalIDByStaffIDFunc: func(staffID string) (uuid.UUID, error) { return hospitalID, nil },
+	})
+	client := hospitalv1.NewPatientServiceClient(conn)
+	auth := metadata.AppendToOutgoingContext(context.Background(), "authorization", generateValidToken())
+
+	t.Run("search", func(t *testing.T) {
+		resp, err := client.SearchPatients(auth, &hospitalv1.SearchPatientsRequest{Name: "สมชาย", DateOfBirth: timestamppb.New(dob)})
+		require.NoError(t, err)
+
+		assert.Equal(t, hospitalID, criteria.HospitalID)
+		require.NotNil(t, criteria.Name)
+		assert.Equal(t, "สมชาย", *criteria.Name)
+		require.NotNil(t, criteria.DateOfBirth)
+		assert.True(t, dob.Equal(*criteria.DateOfBirth))
+		// Fields left empty do not filter
+		assert.Nil(t, criteria.NationalID)
+		assert.Nil(t, criteria.PatientHN)
+
+		require.Len(t, resp.GetPatients(), 2)
+		p := resp.GetPatients()[0]
+		assert.Equal(t, patientID.String(), p.GetPatientId())
+		assert.Equal(t, "HN0001", p.GetPatientHn())
+		assert.Equal(t, "ใจดี", p.GetLastNameTh())
+		assert.EqualValues(t, 3, p.GetVersion())
+		assert.Equal(t, dob.Unix(), p.GetDateOfBirth().GetSeconds())
+		assert.Nil(t, p.GetUpdatedAt())
+	})
+
+	t.Run("get as of", func(t *testing.T) {
+		at := time.Now().Add(-time.Hour).Truncate(time.Second)
+		resp, err := client.GetPatient(auth, &hospitalv1.GetPatientRequest{PatientId: patientID.String(), AsOf: timestamppb.New(at)})
+		require.NoError(t, err)
+		assert.Equal(t, "1103700012345", resp.GetNationalId())
+		require.NotNil(t, asOf)
+		assert.True(t, at.Equal(*asOf))
+	})
+
+	cases := []struct {
+		name      string
+		ctx       context.Context
+		patientID string
+		code      codes.Code
+	}{
+		{"negative no token", context.Background(), patientID.String(), codes.Unauthenticated},
+		{"negative malformed token", metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer nope"), patientID.String(), codes.Unauthenticated},
+		{"negative invalid patient ID", auth, "HN0001", codes.InvalidArgument},
+		{"negative unknown patient", auth, uuid.NewString(), codes.NotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := client.GetPatient(tc.ctx, &hospitalv1.GetPatientRequest{PatientId: tc.patientID})
+			assert.Equal(t, tc.code, status.Code(err))
+		})
+	}
+
+	t.Run("negative unknown method", func(t *testing.T) {
+		err := conn.Invoke(auth, "/hospital.v1.PatientService/DeletePatient", &hospitalv1.GetPatientRequest{}, &hospitalv1.Patient{})
+		assert.Equal(t, codes.Unimplemented, status.Code(err))
+	})
+}
+
+func TestGRPC_Reflection(t *testing.T) {
+	conn := newGRPCServer(t, &mockPatientService{}, &mockStaffService2{})
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(context.Background())
+	require.NoError(t, err)
+	call := func(req *reflectionpb.ServerReflectionRequest) *reflectionpb.ServerReflectionResponse {
+		require.NoError(t, stream.Send(req))
+		resp, err := stream.Recv()
+		require.NoError(t, err)
+		return resp
+	}
+
+	list := call(&reflectionpb.ServerReflectionRequest{MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{ListServices: "*"}})
+	var names []string
+	for _, s := range list.GetListServicesResponse().GetService() {
+		names = append(names, s.GetName())
+	}
+	assert.ElementsMatch(t, []string{"hospital.v1.PatientService", "hospital.v1.StaffService", "grpc.reflection.v1.ServerReflection", "grpc.reflection.v1alpha.ServerReflection"}, names)
+
+	files := call(&reflectionpb.ServerReflectionRequest{MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: "hospital.v1.PatientService.GetPatient"}})
+	var paths []string
+	for _, b := range files.GetFileDescriptorResponse().GetFileDescriptorProto() {
+		var fd descriptorpb.FileDescriptorProto
+		require.NoError(t, proto.Unmarshal(b, &fd))
+		paths = append(paths, fd.GetName())
+		if fd.GetName() == "hospital/v1/hospital.proto" {
+			assert.Len(t, fd.GetService(), 2)
+			assert.Len(t, fd.GetMessageType(), 8)
+		}
+	}
+	assert.ElementsMatch(t, []string{"hospital/v1/hospital.proto", "google/protobuf/timestamp.proto"}, paths)
+
+	missing := call(&reflectionpb.ServerReflectionRequest{MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: "hospital.v1.Ward"}})
+	assert.EqualValues(t, codes.NotFound, missing.GetErrorResponse().GetErrorCode())
+}
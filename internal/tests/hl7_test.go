@@ -0,0 +1,592 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/handlers"
+	"go-hospital-api/internal/hl7"
+	"go-hospital-api/internal/repository"
+	"go-hospital-api/internal/services"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func readHL7Sample(t *testing.T, name string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", "hl7", name))
+	require.NoError(t, err)
+	return b
+}
+
+func TestHL7Parse(t *testing.T) {
+	msg, err := hl7.Parse(string(readHL7Sample(t, "a01.hl7")))
+	require.NoError(t, err)
+
+	code, trigger := msg.Type()
+	assert.Equal(t, "ADT", code)
+	assert.Equal(t, "A01", trigger)
+	assert.Equal(t, "MSG00001", msg.ControlID())
+
+	msh := msg.Segment("MSH")
+	assert.Equal(t, "|", msh.Field(1))
+	assert.Equal(t, `^~\&`, msh.Field(2))
+	assert.Equal(t, "LEGACYHIS", msh.Field(3))
+
+	pid := msg.Segment("PID")
+	ids := pid.Repetitions(3)
+	require.Len(t, ids, 2)
+	assert.Equal(t, "HN000123", ids[0].Component(1))
+	assert.Equal(t, "NI", ids[1].Component(5))
+	assert.Equal(t, "ใจดี", pid.First(5).Component(1))
+	assert.Equal(t, "19850214", pid.Field(7))
+	assert.Equal(t, "V0001", msg.Segment("PV1").Field(19))
+	assert.Nil(t, msg.Segment("MRG"))
+
+	escaped, err := hl7.Parse("MSH|^~\\&|A|B|C|D|20260101||ADT^A08|1|P|2.5\rPID|1||HN1^^^^MR||O\\T\\Brien^Pat\\S\\Lee")
+	require.NoError(t, err)
+	name := escaped.Segment("PID").First(5)
+	assert.Equal(t, "O&Brien", name.Component(1))
+	assert.Equal(t, "Pat^Lee", name.Component(2))
+
+	_, err = hl7.Parse("PID|1||HN1")
+	assert.ErrorIs(t, err, hl7.ErrNotHL7)
+}
+
+func TestHL7ACK(t *testing.T) {
+	msg, err := hl7.Parse(string(readHL7Sample(t, "a04.hl7")))
+	require.NoError(t, err)
+	at := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+
+	ack, err := hl7.Parse(hl7.ACK(msg, hl7.AckError, "HN|missing", at))
+	require.NoError(t, err)
+	msh := ack.Segment("MSH")
+	assert.Equal(t, "HOSPITALAPI", msh.Field(3))
+	assert.Equal(t, "LEGACYHIS", msh.Field(5))
+	assert.Equal(t, "20261019090000", msh.Field(7))
+	code, trigger := ack.Type()
+	assert.Equal(t, "ACK", code)
+	assert.Equal(t, "A04", trigger)
+	msa := ack.Segment("MSA")
+	assert.Equal(t, "AE", msa.Field(1))
+	assert.Equal(t, "MSG00002", msa.Field(2))
+	assert.Equal(t, "HN|missing", msa.First(3).Component(1))
+
+	reject, err := hl7.Parse(hl7.ACK(nil, hl7.AckReject, "not HL7", at))
+	require.NoError(t, err)
+	assert.Equal(t, "AR", reject.Segment("MSA").Field(1))
+}
+
+func TestHL7ReadFrame(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteString("\r\n")
+	require.NoError(t, hl7.WriteFrame(&buf, []byte("MSH|first")))
+	require.NoError(t, hl7.WriteFrame(&buf, []byte("MSH|second")))
+	r := bufio.NewReader(&buf)
+
+	first, err := hl7.ReadFrame(r, 100)
+	require.NoError(t, err)
+	assert.Equal(t, "MSH|first", string(first))
+	second, err := hl7.ReadFrame(r, 100)
+	require.NoError(t, err)
+	assert.Equal(t, "MSH|second", string(second))
+
+	buf.Reset()
+	require.NoError(t, hl7.WriteFrame(&buf, []byte(strings.Repeat("x", 20))))
+	_, err = hl7.ReadFrame(bufio.NewReader(&buf), 10)
+	assert.ErrorIs(t, err, hl7.ErrMessageTooLarge)
+}
+
+// memoryHL7Repo is an in-memory repository.HL7MessageRepository
+type memoryHL7Repo struct {
+	mu       sync.Mutex
+	messages []*entities.HL7Message
+}
+
+func (m *memoryHL7Repo) Create(ctx context.Context, message *entities.HL7Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored := *message
+	m.messages = append(m.messages, &stored)
+	return nil
+}
+
+func (m *memoryHL7Repo) Save(ctx context.Context, message *entities.HL7Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, stored := range m.messages {
+		if stored.ID == message.ID {
+			saved := *message
+			m.messages[i] = &saved
+		}
+	}
+	return nil
+}
+
+func (m *memoryHL7Repo) GetByID(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.HL7Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, stored := range m.messages {
+		if stored.ID == id && stored.HospitalID == hospitalID {
+			found := *stored
+			return &found, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memoryHL7Repo) FindLatest(ctx context.Context, hospitalID uuid.UUID, sendingApplication, controlID string) (*entities.HL7Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		stored := m.messages[i]
+		if stored.HospitalID == hospitalID && stored.SendingApplication == sendingApplication && stored.ControlID == controlID {
+			found := *stored
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *memoryHL7Repo) List(ctx context.Context, hospitalID uuid.UUID, status string, limit int) ([]entities.HL7Message, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var list []entities.HL7Message
+	for _, stored := range m.messages {
+		if stored.HospitalID == hospitalID && (status == "" || stored.Status == status) {
+			list = append(list, *stored)
+		}
+	}
+	return list, nil
+}
+
+// directTransactor runs the work without a transaction, for the in-memory repositories
+type directTransactor struct{}
+
+func (directTransactor) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// hl7Patients backs a mockPatientService with an in-memory patient list
+type hl7Patients struct {
+	mu       sync.Mutex
+	patients []*entities.Patient
+	merged   map[uuid.UUID]uuid.UUID
+}
+
+func (p *hl7Patients) service() *mockPatientService {
+	p.merged = map[uuid.UUID]uuid.UUID{}
+	return &mockPatientService{
+		SearchFunc: func(ctx context.Context, criteria dto.PatientSearchCriteria) ([]entities.Patient, error) {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			var found []entities.Patient
+			for _, patient := range p.patients {
+				if patient.HospitalID == criteria.HospitalID && patient.PatientHN == *criteria.PatientHN && p.merged[patient.ID] == uuid.Nil {
+					found = append(found, *patient)
+				}
+			}
+			return found, nil
+		},
+		CreateFunc: func(ctx context.Context, patient *entities.Patient, staffID uuid.UUID) error {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			patient.ID, patient.Version = uuid.New(), 1
+			stored := *patient
+			p.patients = append(p.patients, &stored)
+			return nil
+		},
+		UpdateFunc: func(ctx context.Context, id, hospitalID, staffID uuid.UUID, version int, req dto.PatientUpdateRequest) (*entities.Patient, error) {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			for _, patient := range p.patients {
+				if patient.ID == id {
+					if req.PhoneNumber != nil {
+						patient.PhoneNumber = *req.PhoneNumber
+					}
+					if req.Email != nil {
+						patient.Email = *req.Email
+					}
+					patient.Version++
+					return patient, nil
+				}
+			}
+			return nil, services.ErrNotFound
+		},
+		MergeFunc: func(ctx context.Context, fromID, intoID, hospitalID, staffID uuid.UUID) error {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			p.merged[fromID] = intoID
+			return nil
+		},
+	}
+}
+
+func (p *hl7Patients) byHN(hn string) *entities.Patient {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, patient := range p.patients {
+		if patient.PatientHN == hn {
+			return patient
+		}
+	}
+	return nil
+}
+
+// sendHL7 sends one message over an MLLP connection and parses the acknowledgment
+func sendHL7(t *testing.T, conn net.Conn, r *bufio.Reader, message []byte) *hl7.Segment {
+	t.Helper()
+	require.NoError(t, hl7.WriteFrame(conn, message))
+	raw, err := hl7.ReadFrame(r, hl7.DefaultMaxMessageSize)
+	require.NoError(t, err)
+	ack, err := hl7.Parse(string(raw))
+	require.NoError(t, err)
+	return ack.Segment("MSA")
+}
+
+func TestHL7Service_MLLPListener(t *testing.T) {
+	hospitalID, interfaceStaffID := uuid.New(), uuid.New()
+	repo := &memoryHL7Repo{}
+	patients := &hl7Patients{}
+	svc := services.NewHL7Service(repo, directTransactor{}, patients.service(), nil, interfaceStaffID, hospitalID)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() {
+		served <- (&hl7.Server{Handler: svc.Receive}).Serve(ctx, ln)
+	}()
+	defer func() {
+		cancel()
+		assert.NoError(t, <-served)
+	}()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	// A01 registers the patient from PID
+	msa := sendHL7(t, conn, r, readHL7Sample(t, "a01.hl7"))
+	assert.Equal(t, "AA", msa.Field(1))
+	assert.Equal(t, "MSG00001", msa.Field(2))
+	patient := patients.byHN("HN000123")
+	require.NotNil(t, patient)
+	assert.Equal(t, hospitalID, patient.HospitalID)
+	assert.Equal(t, "สมชาย", patient.FirstNameTH)
+	assert.Equal(t, "ใจดี", patient.LastNameTH)
+	assert.Equal(t, "Somchai", patient.FirstNameEN)
+	assert.Equal(t, "K", patient.MiddleNameEN)
+	assert.Equal(t, "1103700012345", patient.NationalID)
+	assert.Equal(t, "M", patient.Gender)
+	assert.Equal(t, "0812345678", patient.PhoneNumber)
+	assert.Equal(t, "somchai@example.com", patient.Email)
+	require.NotNil(t, patient.DateOfBirth)
+	assert.Equal(t, "1985-02-14", patient.DateOfBirth.Format("2006-01-02"))
+
+	// A resent message is acknowledged without being applied again
+	msa = sendHL7(t, conn, r, readHL7Sample(t, "a01.hl7"))
+	assert.Equal(t, "AA", msa.Field(1))
+	assert.Len(t, patients.patients, 1)
+
+	// A04 with a passport
+	assert.Equal(t, "AA", sendHL7(t, conn, r, readHL7Sample(t, "a04.hl7")).Field(1))
+	assert.Equal(t, "X1234567", patients.byHN("HN000456").PassportID)
+
+	// A08 clears the telecom fields with the HL7 null value
+	assert.Equal(t, "AA", sendHL7(t, conn, r, readHL7Sample(t, "a08.hl7")).Field(1))
+	patient = patients.byHN("HN000123")
+	assert.Equal(t, "", patient.PhoneNumber)
+	assert.Equal(t, "", patient.Email)
+	assert.Equal(t, "สมชาย", patient.FirstNameTH)
+	assert.Equal(t, 2, patient.Version)
+
+	// A40 fails while the prior patient is unknown
+	msa = sendHL7(t, conn, r, readHL7Sample(t, "a40.hl7"))
+	assert.Equal(t, "AE", msa.Field(1))
+	assert.Contains(t, msa.Field(3), "HN000999")
+
+	// Unsupported messages are rejected
+	msa = sendHL7(t, conn, r, []byte("MSH|^~\\&|LAB|BKKHOSP|HOSPITALAPI|BKKHOSP|20261019120000||ORU^R01|LAB1|P|2.5\rPID|1||HN000123^^^^MR"))
+	assert.Equal(t, "AR", msa.Field(1))
+	msa = sendHL7(t, conn, r, []byte("not an HL7 message"))
+	assert.Equal(t, "AR", msa.Field(1))
+
+	logged, _ := repo.List(ctx, hospitalID, "", 0)
+	require.Len(t, logged, 6)
+	assert.Equal(t, entities.HL7Processed, logged[0].Status)
+	assert.Equal(t, "I", logged[0].PatientClass)
+	assert.Equal(t, "WARD3/301/A", logged[0].Location)
+	assert.Equal(t, "V0001", logged[0].VisitNumber)
+	assert.Equal(t, "OPD", logged[1].Location)
+	assert.Equal(t, entities.HL7Failed, logged[3].Status)
+	assert.Equal(t, "AE", logged[3].AckCode)
+	assert.Equal(t, "ADT^A40", logged[3].MessageType)
+	assert.Equal(t, "ORU^R01", logged[4].MessageType)
+	assert.Equal(t, "AR", logged[5].AckCode)
+}
+
+func TestHL7Service_Replay(t *testing.T) {
+	hospitalID, supervisorID, nurseID := uuid.New(), uuid.New(), uuid.New()
+	staffRepo := &roleStaffRepo{roles: map[uuid.UUID]string{supervisorID: entities.RoleSupervisor, nurseID: "nurse"}}
+	repo := &memoryHL7Repo{}
+	patients := &hl7Patients{}
+	svc := services.NewHL7Service(repo, directTransactor{}, patients.service(), staffRepo, uuid.New(), hospitalID)
+	ctx := context.Background()
+
+	msa, err := hl7.Parse(string(svc.Receive(ctx, readHL7Sample(t, "a40.hl7"))))
+	require.NoError(t, err)
+	assert.Equal(t, "AE", msa.Segment("MSA").Field(1))
+	logged, _ := repo.List(ctx, hospitalID, entities.HL7Failed, 0)
+	require.Len(t, logged, 1)
+
+	_, err = svc.Replay(ctx, logged[0].ID, hospitalID, nurseID)
+	assert.ErrorIs(t, err, services.ErrForbidden)
+	_, err = svc.Replay(ctx, uuid.New(), hospitalID, supervisorID)
+	assert.ErrorIs(t, err, services.ErrNotFound)
+
+	// Once the prior record exists the merge goes through
+	prior := &entities.Patient{ID: uuid.New(), HospitalID: hospitalID, PatientHN: "HN000999", Version: 1}
+	patients.patients = append(patients.patients, prior)
+	replayed, err := svc.Replay(ctx, logged[0].ID, hospitalID, supervisorID)
+	require.NoError(t, err)
+	assert.Equal(t, entities.HL7Processed, replayed.Status)
+	assert.Equal(t, "AA", replayed.AckCode)
+	assert.Equal(t, 2, replayed.Attempts)
+	survivor := patients.byHN("HN000123")
+	require.NotNil(t, survivor)
+	assert.Equal(t, survivor.ID, *replayed.PatientID)
+	assert.Equal(t, survivor.ID, patients.merged[prior.ID])
+
+	_, err = svc.Replay(ctx, logged[0].ID, hospitalID, supervisorID)
+	assert.ErrorIs(t, err, services.ErrConflict)
+
+	// The sender's retransmission is now a duplicate
+	ack, err := hl7.Parse(string(svc.Receive(ctx, readHL7Sample(t, "a40.hl7"))))
+	require.NoError(t, err)
+	assert.Equal(t, "AA", ack.Segment("MSA").Field(1))
+}
+
+// roleStaffRepo is a repository.StaffRepository that only knows staff roles
+func TestHL7Service_MergeIsAtomic(t *testing.T) {
+	ctx := context.Background()
+	conn := newTestDB(t)
+	hospitalID, supervisorID := uuid.New(), uuid.New()
+	require.NoError(t, conn.Create(&entities.Hospital{ID: hospitalID, Name: "General"}).Error)
+	patientRepo := repository.NewPatientRepository(conn)
+	messages := repository.NewHL7MessageRepository(conn)
+	staffRepo := &roleStaffRepo{roles: map[uuid.UUID]string{supervisorID: entities.RoleSupervisor}}
+	svc := services.NewHL7Service(messages, repository.NewTransactor(conn), services.NewPatientService(patientRepo), staffRepo, uuid.New(), hospitalID)
+	byHN := func(hn string) []entities.Patient {
+		var found []entities.Patient
+		require.NoError(t, conn.Unscoped().Where("patient_hn = ?", hn).Find(&found).Error)
+		return found
+	}
+
+	// The merge fails because the prior patient is unknown, so the survivor is not registered either
+	ack, err := hl7.Parse(string(svc.Receive(ctx, readHL7Sample(t, "a40.hl7"))))
+	require.NoError(t, err)
+	assert.Equal(t, "AE", ack.Segment("MSA").Field(1))
+	assert.Empty(t, byHN("HN000123"))
+	logged, err := messages.List(ctx, hospitalID, entities.HL7Failed, 10)
+	require.NoError(t, err)
+	require.Len(t, logged, 1)
+	assert.Nil(t, logged[0].PatientID)
+
+	prior := &entities.Patient{ID: uuid.New(), HospitalID: hospitalID, PatientHN: "HN000999", Version: 1}
+	require.NoError(t, patientRepo.Create(ctx, prior, supervisorID))
+	replayed, err := svc.Replay(ctx, logged[0].ID, hospitalID, supervisorID)
+	require.NoError(t, err)
+	assert.Equal(t, entities.HL7Processed, replayed.Status)
+	survivor := byHN("HN000123")
+	require.Len(t, survivor, 1)
+	assert.Equal(t, survivor[0].ID, *replayed.PatientID)
+	merged := byHN("HN000999")
+	require.Len(t, merged, 1)
+	if assert.NotNil(t, merged[0].MergedIntoID) {
+		assert.Equal(t, survivor[0].ID, *merged[0].MergedIntoID)
+	}
+	stored, err := messages.GetByID(ctx, logged[0].ID, hospitalID)
+	require.NoError(t, err)
+	assert.Equal(t, entities.HL7Processed, stored.Status)
+}
+
+type roleStaffRepo struct {
+	roles map[uuid.UUID]string
+}
+
+func (r *roleStaffRepo) Create(staff *entities.Staff) error { return nil }
+
+func (r *roleStaffRepo) GetByUsername(username string, hospitalID uuid.UUID) (*entities.Staff, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (r *roleStaffRepo) GetHospitalIDByStaffID(staffID string) (uuid.UUID, error) {
+	return uuid.Nil, gorm.ErrRecordNotFound
+}
+
+func (r *roleStaffRepo) GetByID(staffID uuid.UUID) (*entities.Staff, error) {
+	role, ok := r.roles[staffID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &entities.Staff{ID: staffID, Role: role}, nil
+}
+
+func (r *roleStaffRepo) Delete(staffID uuid.UUID, hospitalID uuid.UUID, version int) (bool, error) {
+	return false, nil
+}
+
+func (r *roleStaffRepo) Restore(staffID uuid.UUID, hospitalID uuid.UUID) (bool, error) {
+	return false, nil
+}
+
+func (r *roleStaffRepo) UpdateRole(staffID uuid.UUID, hospitalID uuid.UUID, role string, version int, event *entities.AuditEvent) (*entities.Staff, error) {
+	return nil, gorm.ErrRecordNotFound
+}
+
+type mockHL7Service struct {
+	ListFunc   func(ctx context.Context, hospitalID, staffID uuid.UUID, status string) ([]entities.HL7Message, error)
+	ReplayFunc func(ctx context.Context, id, hospitalID, staffID uuid.UUID) (*entities.HL7Message, error)
+}
+
+func (m *mockHL7Service) Receive(ctx context.Context, raw []byte) []byte {
+	return nil
+}
+
+func (m *mockHL7Service) List(ctx context.Context, hospitalID, staffID uuid.UUID, status string) ([]entities.HL7Message, error) {
+	return m.ListFunc(ctx, hospitalID, staffID, status)
+}
+
+func (m *mockHL7Service) Replay(ctx context.Context, id, hospitalID, staffID uuid.UUID) (*entities.HL7Message, error) {
+	return m.ReplayFunc(ctx, id, hospitalID, staffID)
+}
+
+func newHL7Router(svc services.HL7ServiceInterface) *gin.Engine {
+	h := handlers.NewHL7Handler(svc, &mockStaffService2{
+		GetHospitalIDByStaffIDFunc: func(staffID string) (uuid.UUID, error) { return uuid.New(), nil },
+	})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/hl7/messages", h.ListMessagesHandler)
+	router.POST("/hl7/messages/:id/replay", h.ReplayMessageHandler)
+	return router
+}
+
+func TestHL7Handler_ListMessagesHandler(t *testing.T) {
+	cases := []struct {
+		name           string
+		listFunc       func(ctx context.Context, hospitalID, staffID uuid.UUID, status string) ([]entities.HL7Message, error)
+		wantStatusCode int
+	}{
+		{
+			name: "positive",
+			listFunc: func(ctx context.Context, hospitalID, staffID uuid.UUID, status string) ([]entities.HL7Message, error) {
+				return []entities.HL7Message{{ID: uuid.New(), HospitalID: hospitalID, MessageType: "ADT^A01", Status: status}}, nil
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "negative not a supervisor",
+			listFunc: func(ctx context.Context, hospitalID, staffID uuid.UUID, status string) ([]entities.HL7Message, error) {
+				return nil, fmt.Errorf("%w: only supervisors can read the HL7 message log", services.ErrForbidden)
+			},
+			wantStatusCode: http.StatusForbidden,
+		},
+		{
+			name: "negative repository error",
+			listFunc: func(ctx context.Context, hospitalID, staffID uuid.UUID, status string) ([]entities.HL7Message, error) {
+				return nil, errors.New("fail")
+			},
+			wantStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newHL7Router(&mockHL7Service{ListFunc: tc.listFunc})
+			req := httptest.NewRequest("GET", "/hl7/messages?status=failed", nil)
+			req.Header.Set("Authorization", generateValidToken())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			if w.Code == http.StatusOK {
+				var body struct {
+					Data []dto.HL7MessageResponse `json:"data"`
+				}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Len(t, body.Data, 1)
+				assert.Equal(t, "failed", body.Data[0].Status)
+			}
+		})
+	}
+}
+
+func TestHL7Handler_ReplayMessageHandler(t *testing.T) {
+	cases := []struct {
+		name           string
+		id             string
+		replayFunc     func(ctx context.Context, id, hospitalID, staffID uuid.UUID) (*entities.HL7Message, error)
+		wantStatusCode int
+	}{
+		{
+			name: "positive",
+			id:   uuid.NewString(),
+			replayFunc: func(ctx context.Context, id, hospitalID, staffID uuid.UUID) (*entities.HL7Message, error) {
+				return &entities.HL7Message{ID: id, Status: entities.HL7Processed, AckCode: "AA", Attempts: 2}, nil
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "negative invalid id",
+			id:             "not-a-uuid",
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name: "negative already processed",
+			id:   uuid.NewString(),
+			replayFunc: func(ctx context.Context, id, hospitalID, staffID uuid.UUID) (*entities.HL7Message, error) {
+				return nil, fmt.Errorf("%w: message was already processed", services.ErrConflict)
+			},
+			wantStatusCode: http.StatusConflict,
+		},
+		{
+			name: "negative not found",
+			id:   uuid.NewString(),
+			replayFunc: func(ctx context.Context, id, hospitalID, staffID uuid.UUID) (*entities.HL7Message, error) {
+				return nil, fmt.Errorf("%w: HL7 message", services.ErrNotFound)
+			},
+			wantStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newHL7Router(&mockHL7Service{ReplayFunc: tc.replayFunc})
+			req := httptest.NewRequest("POST", "/hl7/messages/"+tc.id+"/replay", nil)
+			req.Header.Set("Authorization", generateValidToken())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package tests
+
+import (
+	"context"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/middleware"
+	"go-hospital-api/internal/repository"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryIdempotencyRepo is an in-memory repository.IdempotencyRepository
+type memoryIdempotencyRepo struct {
+	mu      sync.Mutex
+	records map[string]*entities.IdempotencyKey
+}
+
+func newMemoryIdempotencyRepo() *memoryIdempotencyRepo {
+	return &memoryIdempotencyRepo{records: map[string]*entities.IdempotencyKey{}}
+}
+
+func (m *memoryIdempotencyRepo) Claim(ctx context.Context, record *entities.IdempotencyKey) (*entities.IdempotencyKey, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if existing, ok := m.records[record.Scope+"/"+record.Key]; ok && !existing.ExpiresAt.Before(record.CreatedAt) {
+		found := *existing
+		return &found, nil
+	}
+	stored := *record
+	m.records[record.Scope+"/"+record.Key] = &stored
+	return nil, nil
+}
+
+func (m *memoryIdempotencyRepo) Complete(ctx context.Context, record *entities.IdempotencyKey) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored := *record
+	now := time.Now()
+	stored.CompletedAt = &now
+	m.records[record.Scope+"/"+record.Key] = &stored
+	return nil
+}
+
+func (m *memoryIdempotencyRepo) Release(ctx context.Context, scope, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if record, ok := m.records[scope+"/"+key]; ok && record.CompletedAt == nil {
+		delete(m.records, scope+"/"+key)
+	}
+	return nil
+}
+
+func (m *memoryIdempotencyRepo) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	return 0, nil
+}
+
+// newIdempotencyRouter counts how often the handler really runs; status is what it responds with.
+func newIdempotencyRouter(repo *memoryIdempotencyRepo, calls *int, status *int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	auth := router.Group("")
+	auth.Use(middleware.AuthMiddleware(), middleware.Idempotency(repo, time.Hour))
+	auth.POST("/patients", func(c *gin.Context) {
+		*calls++
+		c.JSON(*status, gin.H{"status": "success", "call": *calls})
+	})
+	return router
+}
+
+func postWithKey(router *gin.Engine, token, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/patients", strings.NewReader(body))
+	req.Header.Set("Authorization", token)
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotency_ReplaysStoredResponse(t *testing.T) {
+	calls, status := 0, http.StatusCreated
+	router := newIdempotencyRouter(newMemoryIdempotencyRepo(), &calls, &status)
+	token := generateValidToken()
+
+	first := postWithKey(router, token, "key-1", `{"patient_hn":"HN001"}`)
+	retry := postWithKey(router, token, "key-1", `{"patient_hn":"HN001"}`)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+}
+
+func TestIdempotency_DifferentBodyConflicts(t *testing.T) {
+	calls, status := 0, http.StatusCreated
+	router := newIdempotencyRouter(newMemoryIdempotencyRepo(), &calls, &status)
+	token := generateValidToken()
+
+	postWithKey(router, token, "key-1", `{"patient_hn":"HN001"}`)
+	w := postWithKey(router, token, "key-1", `{"patient_hn":"HN002"}`)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotency_KeysAreScopedToStaff(t *testing.T) {
+	calls, status := 0, http.StatusCreated
+	router := newIdempotencyRouter(newMemoryIdempotencyRepo(), &calls, &status)
+
+	postWithKey(router, generateValidToken(), "key-1", `{}`)
+	w := postWithKey(router, generateValidToken(), "key-1", `{}`)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency_ServerErrorIsNotStored(t *testing.T) {
+	calls, status := 0, http.StatusInternalServerError
+	router := newIdempotencyRouter(newMemoryIdempotencyRepo(), &calls, &status)
+	token := generateValidToken()
+
+	postWithKey(router, token, "key-1", `{}`)
+	status = http.StatusCreated
+	w := postWithKey(router, token, "key-1", `{}`)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency_WithoutKeyRunsEveryTime(t *testing.T) {
+	calls, status := 0, http.StatusCreated
+	router := newIdempotencyRouter(newMemoryIdempotencyRepo(), &calls, &status)
+	token := generateValidToken()
+
+	postWithKey(router, token, "", `{}`)
+	postWithKey(router, token, "", `{}`)
+
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotency_InProgressConflicts(t *testing.T) {
+	repo := newMemoryIdempotencyRepo()
+	calls, status := 0, http.StatusCreated
+	router := newIdempotencyRouter(repo, &calls, &status)
+	token := generateValidToken()
+
+	// A first request that has claimed the key but not finished yet
+	postWithKey(router, token, "key-1", `{}`)
+	for _, record := range repo.records {
+		record.CompletedAt = nil
+	}
+	w := postWithKey(router, token, "key-1", `{}`)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, 1, calls)
+}
+
+func TestIdempotency_ReplaysETagAndLocation(t *testing.T) {
+	repo := newMemoryIdempotencyRepo()
+	calls := 0
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/patients", middleware.AuthMiddleware(), middleware.Idempotency(repo, time.Hour), func(c *gin.Context) {
+		calls++
+		c.Header("ETag", `"1"`)
+		c.Header("Location", "/api/patients/42")
+		c.JSON(http.StatusCreated, gin.H{"status": "success"})
+	})
+	token := generateValidToken()
+
+	postWithKey(router, token, "key-1", `{}`)
+	retry := postWithKey(router, token, "key-1", `{}`)
+
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, `"1"`, retry.Header().Get("ETag"))
+	assert.Equal(t, "/api/patients/42", retry.Header().Get("Location"))
+}
+
+func TestIdempotency_PublicKeysAreScopedToAccount(t *testing.T) {
+	repo := newMemoryIdempotencyRepo()
+	calls := 0
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/staff/create", middleware.Idempotency(repo, time.Hour), func(c *gin.Context) {
+		calls++
+		c.JSON(http.StatusCreated, gin.H{"status": "success", "call": calls})
+	})
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/staff/create", strings.NewReader(body))
+		req.Header.Set("Idempotency-Key", "key-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	nurse1 := `{"username":"nurse1","password":"secret","hospital_id":"8f0c4f7e-3b9a-4c55-9b9e-0a0f1f5e2a11"}`
+	nurse2 := `{"username":"nurse2","password":"secret","hospital_id":"8f0c4f7e-3b9a-4c55-9b9e-0a0f1f5e2a11"}`
+
+	// Another client that happens to pick the same key registers its own account
+	assert.Equal(t, http.StatusCreated, post(nurse1).Code)
+	assert.Equal(t, http.StatusCreated, post(nurse2).Code)
+	assert.Equal(t, 2, calls)
+
+	// A retry of the same registration is replayed
+	retry := post(nurse1)
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotencyRepository_CompleteStoresHeaders(t *testing.T) {
+	ctx := context.Background()
+	repo := repository.NewIdempotencyRepository(newTestDB(t))
+	now := time.Now()
+	record := &entities.IdempotencyKey{Scope: "staff-1", Key: "key-1", Method: "POST", Path: "/api/patients", RequestHash: "h", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	existing, err := repo.Claim(ctx, record)
+	require.NoError(t, err)
+	require.Nil(t, existing)
+
+	record.StatusCode, record.ContentType, record.Response = http.StatusCreated, "application/json", []byte(`{}`)
+	record.ETag, record.Location = `"1"`, "/api/patients/42"
+	require.NoError(t, repo.Complete(ctx, record))
+
+	retry := *record
+	existing, err = repo.Claim(ctx, &retry)
+	require.NoError(t, err)
+	require.NotNil(t, existing)
+	assert.Equal(t, http.StatusCreated, existing.StatusCode)
+	assert.Equal(t, `"1"`, existing.ETag)
+	assert.Equal(t, "/api/patients/42", existing.Location)
+	assert.NotNil(t, existing.CompletedAt)
+}
@@ -0,0 +1,198 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/handlers"
+	"go-hospital-api/internal/repository"
+	"go-hospital-api/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockLabService struct {
+	CreateOrderFunc func(ctx context.Context, order *entities.LabOrder, testIDs []uuid.UUID) error
+	GetOrderFunc    func(ctx context.Context, id, hospitalID uuid.UUID) (*entities.LabOrder, error)
+	EnterResultFunc func(ctx context.Context, orderID, itemID, hospitalID uuid.UUID, result *entities.LabResult) error
+}
+
+func (m *mockLabService) CreateTest(ctx context.Context, test *entities.LabTest) error {
+	return nil
+}
+
+func (m *mockLabService) SearchTests(ctx context.Context, hospitalID uuid.UUID, query string) ([]entities.LabTest, error) {
+	return nil, nil
+}
+
+func (m *mockLabService) CreateOrder(ctx context.Context, order *entities.LabOrder, testIDs []uuid.UUID) error {
+	return m.CreateOrderFunc(ctx, order, testIDs)
+}
+
+func (m *mockLabService) GetOrder(ctx context.Context, id, hospitalID uuid.UUID) (*entities.LabOrder, error) {
+	return m.GetOrderFunc(ctx, id, hospitalID)
+}
+
+func (m *mockLabService) ListOrders(ctx context.Context, patientID, hospitalID uuid.UUID) ([]entities.LabOrder, error) {
+	return nil, nil
+}
+
+func (m *mockLabService) UpdateSpecimen(ctx context.Context, id, hospitalID, staffID uuid.UUID, status, reason string, version int) (*entities.LabOrder, error) {
+	return nil, nil
+}
+
+func (m *mockLabService) EnterResult(ctx context.Context, orderID, itemID, hospitalID uuid.UUID, result *entities.LabResult) error {
+	return m.EnterResultFunc(ctx, orderID, itemID, hospitalID, result)
+}
+
+func newLabRouter(svc services.LabServiceInterface) *gin.Engine {
+	h := handlers.NewLabHandler(svc, &mockStaffService2{
+		GetHospitalIDByStaffIDFunc: func(staffID string) (uuid.UUID, error) { return uuid.New(), nil },
+	})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/patients/:id/lab-orders", h.CreateOrderHandler)
+	router.GET("/lab-orders/:id", h.GetOrderHandler)
+	router.POST("/lab-orders/:id/items/:itemId/results", h.EnterResultHandler)
+	return router
+}
+
+func TestLabHandler_CreateOrderHandler(t *testing.T) {
+	cases := []struct {
+		name           string
+		createFunc     func(ctx context.Context, order *entities.LabOrder, testIDs []uuid.UUID) error
+		wantStatusCode int
+	}{
+		{
+			name: "positive",
+			createFunc: func(ctx context.Context, order *entities.LabOrder, testIDs []uuid.UUID) error {
+				order.ID = uuid.New()
+				order.Status = entities.LabOrderOrdered
+				return nil
+			},
+			wantStatusCode: http.StatusCreated,
+		},
+		{
+			name: "negative not a doctor",
+			createFunc: func(ctx context.Context, order *entities.LabOrder, testIDs []uuid.UUID) error {
+				return fmt.Errorf("%w: only doctors can order lab tests", services.ErrForbidden)
+			},
+			wantStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newLabRouter(&mockLabService{CreateOrderFunc: tc.createFunc})
+			b, _ := json.Marshal(dto.LabOrderCreateRequest{TestIDs: []uuid.UUID{uuid.New()}})
+			req := httptest.NewRequest("POST", "/patients/"+uuid.New().String()+"/lab-orders", bytes.NewReader(b))
+			req.Header.Set("Authorization", generateValidToken())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+		})
+	}
+}
+
+func TestLabHandler_GetOrderHandler_History(t *testing.T) {
+	v1, v2 := 5.9, 6.1
+	router := newLabRouter(&mockLabService{
+		GetOrderFunc: func(ctx context.Context, id, hospitalID uuid.UUID) (*entities.LabOrder, error) {
+			return &entities.LabOrder{ID: id, Status: entities.LabOrderCompleted, Items: []entities.LabOrderItem{{
+				ID:      uuid.New(),
+				LabTest: entities.LabTest{Code: "HBA1C"},
+				Results: []entities.LabResult{
+					{Version: 1, Status: entities.LabResultFinal, ValueNumeric: &v1},
+					{Version: 2, Status: entities.LabResultAmended, ValueNumeric: &v2, AmendReason: "transcription error"},
+				},
+			}}}, nil
+		},
+	})
+	req := httptest.NewRequest("GET", "/lab-orders/"+uuid.New().String(), nil)
+	req.Header.Set("Authorization", generateValidToken())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data dto.LabOrderResponse `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Len(t, resp.Data.Items, 1)
+	assert.Len(t, resp.Data.Items[0].History, 2)
+	assert.Equal(t, 2, resp.Data.Items[0].Result.Version)
+	assert.Equal(t, v2, *resp.Data.Items[0].Result.ValueNumeric)
+}
+
+func TestLabHandler_EnterResultHandler_AlreadyFinal(t *testing.T) {
+	router := newLabRouter(&mockLabService{
+		EnterResultFunc: func(ctx context.Context, orderID, itemID, hospitalID uuid.UUID, result *entities.LabResult) error {
+			return fmt.Errorf("%w: result is already final, submit an amendment instead", services.ErrConflict)
+		},
+	})
+	v := 7.0
+	b, _ := json.Marshal(dto.LabResultRequest{Status: entities.LabResultFinal, ValueNumeric: &v})
+	req := httptest.NewRequest("POST", "/lab-orders/"+uuid.New().String()+"/items/"+uuid.New().String()+"/results", bytes.NewReader(b))
+	req.Header.Set("Authorization", generateValidToken())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestAbnormalFlag(t *testing.T) {
+	low, high := 3.5, 5.0
+	value := func(v float64) *float64 { return &v }
+
+	assert.Equal(t, "L", services.AbnormalFlag(entities.LabResult{ValueNumeric: value(3.1), RefLow: &low, RefHigh: &high}))
+	assert.Equal(t, "H", services.AbnormalFlag(entities.LabResult{ValueNumeric: value(5.4), RefLow: &low, RefHigh: &high}))
+	assert.Equal(t, "N", services.AbnormalFlag(entities.LabResult{ValueNumeric: value(4.0), RefLow: &low, RefHigh: &high}))
+	assert.Equal(t, "A", services.AbnormalFlag(entities.LabResult{ValueText: "Positive", RefText: "Negative"}))
+	assert.Equal(t, "", services.AbnormalFlag(entities.LabResult{ValueNumeric: value(4.0)}))
+}
+
+func TestLabRepository_AddResult_ChecksLatestResult(t *testing.T) {
+	ctx := context.Background()
+	conn := newTestDB(t)
+	hospitalID, staffID := uuid.New(), uuid.New()
+	require.NoError(t, conn.Create(&entities.Hospital{ID: hospitalID, Name: "General"}).Error)
+	require.NoError(t, conn.Create(&entities.Staff{ID: staffID, HospitalID: hospitalID, Username: "lab1", Role: entities.RoleLabTechnician}).Error)
+	patient := entities.Patient{ID: uuid.New(), HospitalID: hospitalID, PatientHN: "HN0001", FirstNameEN: "Somchai"}
+	require.NoError(t, conn.Create(&patient).Error)
+	test := entities.LabTest{ID: uuid.New(), HospitalID: hospitalID, Code: "GLU", Name: "Glucose", ValueType: entities.LabValueNumeric}
+	require.NoError(t, conn.Create(&test).Error)
+	order := entities.LabOrder{ID: uuid.New(), PatientID: patient.ID, HospitalID: hospitalID, OrderedByID: staffID, Status: entities.LabOrderReceived, Version: 1}
+	require.NoError(t, conn.Create(&order).Error)
+	item := entities.LabOrderItem{ID: uuid.New(), OrderID: order.ID, LabTestID: test.ID}
+	require.NoError(t, conn.Create(&item).Error)
+	repo := repository.NewLabRepository(conn)
+	value := 5.4
+	result := func(status string) *entities.LabResult {
+		return &entities.LabResult{ID: uuid.New(), OrderItemID: item.ID, Status: status, ValueNumeric: &value, EnteredByID: staffID}
+	}
+
+	assert.ErrorIs(t, repo.AddResult(ctx, order.ID, result(entities.LabResultAmended)), repository.ErrResultNotFinal)
+	require.NoError(t, repo.AddResult(ctx, order.ID, result(entities.LabResultFinal)))
+
+	// A second entry that still saw no final result, as EnterResult checked before the first
+	// one was stored, is refused under the order's lock
+	assert.ErrorIs(t, repo.AddResult(ctx, order.ID, result(entities.LabResultFinal)), repository.ErrResultFinalised)
+	assert.ErrorIs(t, repo.AddResult(ctx, order.ID, result(entities.LabResultPreliminary)), repository.ErrResultFinalised)
+
+	amended := result(entities.LabResultAmended)
+	amended.AmendReason = "recalibrated analyser"
+	require.NoError(t, repo.AddResult(ctx, order.ID, amended))
+	assert.Equal(t, 2, amended.Version)
+
+	var stored entities.LabOrder
+	require.NoError(t, conn.First(&stored, "id = ?", order.ID).Error)
+	assert.Equal(t, entities.LabOrderCompleted, stored.Status)
+}
@@ -0,0 +1,191 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/handlers"
+	"go-hospital-api/internal/services"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockMedicationService struct {
+	PrescribeFunc    func(ctx context.Context, p *entities.Prescription) ([]services.MedicationCheck, error)
+	UpdateStatusFunc func(ctx context.Context, id, hospitalID uuid.UUID, status, reason string, version int) (*entities.Prescription, error)
+}
+
+func (m *mockMedicationService) ImportFormulary(ctx context.Context, hospitalID uuid.UUID, r io.Reader) (int, error) {
+	return 0, nil
+}
+
+func (m *mockMedicationService) SearchDrugs(ctx context.Context, hospitalID uuid.UUID, query string, limit int) ([]entities.Drug, error) {
+	return nil, nil
+}
+
+func (m *mockMedicationService) AddAllergy(ctx context.Context, allergy *entities.PatientAllergy) error {
+	return nil
+}
+
+func (m *mockMedicationService) ListAllergies(ctx context.Context, patientID, hospitalID uuid.UUID) ([]entities.PatientAllergy, error) {
+	return nil, nil
+}
+
+func (m *mockMedicationService) Prescribe(ctx context.Context, p *entities.Prescription) ([]services.MedicationCheck, error) {
+	return m.PrescribeFunc(ctx, p)
+}
+
+func (m *mockMedicationService) ListPrescriptions(ctx context.Context, patientID, hospitalID uuid.UUID, status string) ([]entities.Prescription, error) {
+	return nil, nil
+}
+
+func (m *mockMedicationService) UpdateStatus(ctx context.Context, id, hospitalID uuid.UUID, status, reason string, version int) (*entities.Prescription, error) {
+	return m.UpdateStatusFunc(ctx, id, hospitalID, status, reason, version)
+}
+
+func newMedicationRouter(svc services.MedicationServiceInterface) *gin.Engine {
+	h := handlers.NewMedicationHandler(svc, &mockStaffService2{
+		GetHospitalIDByStaffIDFunc: func(staffID string) (uuid.UUID, error) { return uuid.New(), nil },
+	})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/patients/:id/prescriptions", h.CreatePrescriptionHandler)
+	router.PATCH("/prescriptions/:id/status", h.UpdatePrescriptionStatusHandler)
+	return router
+}
+
+func TestMedicationHandler_CreatePrescriptionHandler(t *testing.T) {
+	cases := []struct {
+		name           string
+		prescribeFunc  func(ctx context.Context, p *entities.Prescription) ([]services.MedicationCheck, error)
+		wantStatusCode int
+		wantChecks     int
+	}{
+		{
+			name: "positive with warning",
+			prescribeFunc: func(ctx context.Context, p *entities.Prescription) ([]services.MedicationCheck, error) {
+				p.Status = entities.PrescriptionOrdered
+				return []services.MedicationCheck{{Type: services.CheckDuplicateTherapy, Severity: services.CheckWarning, Message: "dup"}}, nil
+			},
+			wantStatusCode: http.StatusCreated,
+			wantChecks:     1,
+		},
+		{
+			name: "negative blocked by allergy",
+			prescribeFunc: func(ctx context.Context, p *entities.Prescription) ([]services.MedicationCheck, error) {
+				return []services.MedicationCheck{{Type: services.CheckAllergy, Severity: services.CheckBlocking, Message: "allergic"}}, services.ErrPrescriptionBlocked
+			},
+			wantStatusCode: http.StatusConflict,
+			wantChecks:     1,
+		},
+		{
+			name: "negative drug not in formulary",
+			prescribeFunc: func(ctx context.Context, p *entities.Prescription) ([]services.MedicationCheck, error) {
+				return nil, fmt.Errorf("%w: drug is not in this hospital's formulary", services.ErrInvalidInput)
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newMedicationRouter(&mockMedicationService{PrescribeFunc: tc.prescribeFunc})
+			body := dto.PrescriptionCreateRequest{DrugID: uuid.New(), Dose: "500 mg", Route: "PO", Frequency: "q6h", DurationDays: 5}
+			b, _ := json.Marshal(body)
+			req := httptest.NewRequest("POST", "/patients/"+uuid.New().String()+"/prescriptions", bytes.NewReader(b))
+			req.Header.Set("Authorization", generateValidToken())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+
+			var resp struct {
+				Checks []dto.MedicationCheckResponse `json:"checks"`
+				Data   dto.PrescriptionResponse      `json:"data"`
+			}
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			assert.Equal(t, tc.wantChecks, len(resp.Checks)+len(resp.Data.Checks))
+		})
+	}
+}
+
+func TestMedicationHandler_UpdatePrescriptionStatusHandler_Conflict(t *testing.T) {
+	router := newMedicationRouter(&mockMedicationService{
+		UpdateStatusFunc: func(ctx context.Context, id, hospitalID uuid.UUID, status, reason string, version int) (*entities.Prescription, error) {
+			return nil, fmt.Errorf("%w: cannot change prescription from cancelled to dispensed", services.ErrConflict)
+		},
+	})
+	b, _ := json.Marshal(dto.PrescriptionStatusRequest{Status: entities.PrescriptionDispensed})
+	req := httptest.NewRequest("PATCH", "/prescriptions/"+uuid.New().String()+"/status", bytes.NewReader(b))
+	req.Header.Set("Authorization", generateValidToken())
+	req.Header.Set("If-Match", `"1"`)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestMedicationHandler_UpdatePrescriptionStatusHandler_IfMatch(t *testing.T) {
+	router := newMedicationRouter(&mockMedicationService{
+		UpdateStatusFunc: func(ctx context.Context, id, hospitalID uuid.UUID, status, reason string, version int) (*entities.Prescription, error) {
+			if version != 3 {
+				return nil, fmt.Errorf("%w: prescription is at version 3", services.ErrPreconditionFailed)
+			}
+			return &entities.Prescription{ID: id, Status: status, Version: 4}, nil
+		},
+	})
+	cases := []struct {
+		name           string
+		ifMatch        string
+		wantStatusCode int
+	}{
+		{"missing", "", http.StatusPreconditionRequired},
+		{"stale", `"2"`, http.StatusPreconditionFailed},
+		{"current", `"3"`, http.StatusOK},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, _ := json.Marshal(dto.PrescriptionStatusRequest{Status: entities.PrescriptionDispensed})
+			req := httptest.NewRequest("PATCH", "/prescriptions/"+uuid.New().String()+"/status", bytes.NewReader(b))
+			req.Header.Set("Authorization", generateValidToken())
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			if tc.wantStatusCode == http.StatusOK {
+				assert.Equal(t, `"4"`, w.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestCheckPrescription(t *testing.T) {
+	now := time.Now()
+	amoxicillin := entities.Drug{GenericName: "Amoxicillin", DrugClass: "Penicillin"}
+
+	checks := services.CheckPrescription(amoxicillin, []entities.PatientAllergy{{Substance: "amoxicillin", Severity: "severe"}}, nil, now)
+	assert.Len(t, checks, 1)
+	assert.Equal(t, services.CheckBlocking, checks[0].Severity)
+
+	checks = services.CheckPrescription(amoxicillin, []entities.PatientAllergy{{Substance: "penicillin"}}, nil, now)
+	assert.Len(t, checks, 1)
+	assert.Equal(t, services.CheckWarning, checks[0].Severity)
+
+	active := []entities.Prescription{
+		{Drug: entities.Drug{GenericName: "Amoxicillin"}, DurationDays: 7, CreatedAt: now.AddDate(0, 0, -2)},
+		{Drug: entities.Drug{GenericName: "Dicloxacillin", DrugClass: "Penicillin"}, DurationDays: 3, CreatedAt: now.AddDate(0, 0, -10)},
+	}
+	checks = services.CheckPrescription(amoxicillin, nil, active, now)
+	assert.Len(t, checks, 1, "expired course must not count as active therapy")
+	assert.Equal(t, services.CheckDuplicateTherapy, checks[0].Type)
+}
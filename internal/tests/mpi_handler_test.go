@@ -0,0 +1,160 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/handlers"
+	"go-hospital-api/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockMPIService struct {
+	LookupFunc func(ctx context.Context, patientID, hospitalID uuid.UUID) (*entities.PatientLink, []services.EnterpriseRecord, error)
+	ReviewFunc func(ctx context.Context, candidateID, hospitalID, staffID uuid.UUID, link bool) (*entities.MatchCandidate, error)
+}
+
+func (m *mockMPIService) Index(ctx context.Context, patientID, hospitalID uuid.UUID) (*entities.PatientLink, int, error) {
+	return nil, 0, nil
+}
+
+func (m *mockMPIService) Lookup(ctx context.Context, patientID, hospitalID uuid.UUID) (*entities.PatientLink, []services.EnterpriseRecord, error) {
+	return m.LookupFunc(ctx, patientID, hospitalID)
+}
+
+func (m *mockMPIService) ReviewQueue(ctx context.Context, hospitalID uuid.UUID, status string) ([]entities.MatchCandidate, error) {
+	return nil, nil
+}
+
+func (m *mockMPIService) Review(ctx context.Context, candidateID, hospitalID, staffID uuid.UUID, link bool) (*entities.MatchCandidate, error) {
+	return m.ReviewFunc(ctx, candidateID, hospitalID, staffID, link)
+}
+
+func newMPIRouter(svc services.MPIServiceInterface, hospitalID uuid.UUID) *gin.Engine {
+	h := handlers.NewMPIHandler(svc, &mockStaffService2{
+		GetHospitalIDByStaffIDFunc: func(staffID string) (uuid.UUID, error) { return hospitalID, nil },
+	})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/patients/:id/enterprise", h.LookupHandler)
+	router.POST("/mpi/review-queue/:id/link", h.LinkCandidateHandler)
+	return router
+}
+
+func TestMPIHandler_LookupHandler(t *testing.T) {
+	hospitalID := uuid.New()
+	enterpriseID := uuid.New()
+	router := newMPIRouter(&mockMPIService{
+		LookupFunc: func(ctx context.Context, patientID, hospitalID uuid.UUID) (*entities.PatientLink, []services.EnterpriseRecord, error) {
+			own := entities.PatientLink{PatientID: patientID, EnterpriseID: enterpriseID, HospitalID: hospitalID, Method: entities.MatchNew}
+			other := entities.PatientLink{PatientID: uuid.New(), EnterpriseID: enterpriseID, HospitalID: uuid.New(), Method: entities.MatchNationalID, Score: 1}
+			hidden := entities.PatientLink{PatientID: uuid.New(), EnterpriseID: enterpriseID, HospitalID: uuid.New(), Method: entities.MatchManual}
+			return &own, []services.EnterpriseRecord{{Link: own, Visible: true}, {Link: other, Visible: true}, {Link: hidden}}, nil
+		},
+	}, hospitalID)
+	req := httptest.NewRequest("GET", "/patients/"+uuid.New().String()+"/enterprise", nil)
+	req.Header.Set("Authorization", generateValidToken())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data dto.EnterpriseLookupResponse `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, enterpriseID, resp.Data.EnterpriseID)
+	assert.Len(t, resp.Data.Records, 2)
+	assert.Equal(t, 1, resp.Data.RestrictedRecords)
+}
+
+func TestMPIHandler_LinkCandidateHandler(t *testing.T) {
+	hospitalID := uuid.New()
+	cases := []struct {
+		name           string
+		reviewFunc     func(ctx context.Context, candidateID, hospitalID, staffID uuid.UUID, link bool) (*entities.MatchCandidate, error)
+		wantStatusCode int
+	}{
+		{
+			name: "positive",
+			reviewFunc: func(ctx context.Context, candidateID, hospitalID, staffID uuid.UUID, link bool) (*entities.MatchCandidate, error) {
+				assert.True(t, link)
+				now := time.Now()
+				return &entities.MatchCandidate{
+					ID:               candidateID,
+					Status:           entities.CandidateLinked,
+					Patient:          entities.Patient{ID: uuid.New(), HospitalID: hospitalID, PatientHN: "HN001"},
+					CandidatePatient: entities.Patient{ID: uuid.New(), HospitalID: uuid.New(), PatientHN: "OTHER-HN"},
+					ReviewedByID:     &staffID,
+					ReviewedAt:       &now,
+				}, nil
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "negative already reviewed",
+			reviewFunc: func(ctx context.Context, candidateID, hospitalID, staffID uuid.UUID, link bool) (*entities.MatchCandidate, error) {
+				return nil, fmt.Errorf("%w: match candidate has already been reviewed", services.ErrConflict)
+			},
+			wantStatusCode: http.StatusConflict,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newMPIRouter(&mockMPIService{ReviewFunc: tc.reviewFunc}, hospitalID)
+			req := httptest.NewRequest("POST", "/mpi/review-queue/"+uuid.New().String()+"/link", nil)
+			req.Header.Set("Authorization", generateValidToken())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			if w.Code == http.StatusOK {
+				var resp struct {
+					Data dto.MatchCandidateResponse `json:"data"`
+				}
+				json.Unmarshal(w.Body.Bytes(), &resp)
+				assert.Equal(t, "HN001", resp.Data.Patient.PatientHN)
+				assert.Empty(t, resp.Data.Candidate.PatientHN)
+				assert.Nil(t, resp.Data.Candidate.PatientID)
+			}
+		})
+	}
+}
+
+func TestMatchScore(t *testing.T) {
+	dob := time.Date(1985, 4, 12, 0, 0, 0, 0, time.UTC)
+	base := entities.Patient{FirstNameTH: "สมชาย", LastNameTH: "ใจดี", DateOfBirth: &dob, PhoneNumber: "081-234-5678", Gender: "M"}
+
+	a, b := base, base
+	a.NationalID, b.NationalID = "1101700203451", "1101700203451"
+	score, method, _ := services.MatchScore(a, b)
+	assert.Equal(t, 1.0, score)
+	assert.Equal(t, entities.MatchNationalID, method)
+
+	b.NationalID = "3100600123456"
+	score, _, _ = services.MatchScore(a, b)
+	assert.Equal(t, 0.0, score)
+
+	a, b = base, base
+	b.PhoneNumber = "0812345678"
+	score, method, _ = services.MatchScore(a, b)
+	assert.Equal(t, 1.0, score)
+	assert.Equal(t, entities.MatchProbabilistic, method)
+
+	b.LastNameTH = "ใจดิ"
+	score, _, reasons := services.MatchScore(a, b)
+	assert.GreaterOrEqual(t, score, services.ReviewScore)
+	assert.Less(t, score, services.AutoLinkScore)
+	assert.Contains(t, reasons, "last_name: similar")
+
+	score, _, _ = services.MatchScore(entities.Patient{DateOfBirth: &dob, Gender: "F"}, entities.Patient{DateOfBirth: &dob, Gender: "F"})
+	assert.Equal(t, 0.0, score)
+}
@@ -0,0 +1,380 @@
+package tests
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/handlers"
+	"go-hospital-api/internal/middleware"
+	"go-hospital-api/internal/services"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryPatientExportRepo is a repository.PatientExportRepository over a fixed list of patients
+type memoryPatientExportRepo struct {
+	patients []entities.Patient
+	failAt   int
+}
+
+func (m *memoryPatientExportRepo) Count(ctx context.Context, criteria dto.PatientSearchCriteria) (int64, error) {
+	return int64(len(m.patients)), nil
+}
+
+func (m *memoryPatientExportRepo) Each(ctx context.Context, criteria dto.PatientSearchCriteria, fn func(entities.Patient) error) error {
+	for i, p := range m.patients {
+		if m.failAt > 0 && i == m.failAt {
+			return errors.New("connection reset")
+		}
+		if err := fn(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type memoryAuditRepo struct {
+	events []entities.AuditEvent
+}
+
+func (m *memoryAuditRepo) Create(ctx context.Context, event *entities.AuditEvent) error {
+	m.events = append(m.events, *event)
+	return nil
+}
+
+func (m *memoryAuditRepo) List(ctx context.Context, hospitalID uuid.UUID, priority string, limit int) ([]entities.AuditEvent, error) {
+	return m.events, nil
+}
+
+func exportPatients() []entities.Patient {
+	dob := time.Date(1985, 2, 14, 0, 0, 0, 0, time.UTC)
+	return []entities.Patient{
+		{ID: uuid.New(), PatientHN: "HN0001", FirstNameTH: "สมชาย", LastNameTH: "ใจดี", DateOfBirth: &dob, Gender: "M",
+			NationalID: "1101700203451", PhoneNumber: "0812345678", Email: "somchai@example.com"},
+		{ID: uuid.New(), PatientHN: "HN0002", FirstNameEN: "=HYPERLINK(\"x\")", PassportID: "AA1234567", Email: "a@b.co"},
+	}
+}
+
+func TestPatientExportService_Permissions(t *testing.T) {
+	hospitalID := uuid.New()
+	doctorID, pharmacistID, clerkID := uuid.New(), uuid.New(), uuid.New()
+	staffRepo := &roleStaffRepo{roles: map[uuid.UUID]string{
+		doctorID: entities.RoleDoctor, pharmacistID: entities.RolePharmacist, clerkID: entities.RoleStaff,
+	}}
+	audit := &memoryAuditRepo{}
+	svc := services.NewPatientExportService(&memoryPatientExportRepo{patients: exportPatients()}, staffRepo, audit)
+	ctx := context.Background()
+	criteria := dto.PatientSearchCriteria{HospitalID: hospitalID}
+
+	export, err := svc.Export(ctx, criteria, doctorID, services.ExportCSV, nil)
+	require.NoError(t, err)
+	assert.Contains(t, export.Columns, "national_id")
+	assert.Equal(t, int64(2), export.Rows)
+
+	export, err = svc.Export(ctx, criteria, clerkID, services.ExportCSV, nil)
+	require.NoError(t, err)
+	assert.NotContains(t, export.Columns, "national_id")
+	assert.NotContains(t, export.Columns, "passport_id")
+	assert.Contains(t, export.Columns, "phone_number")
+
+	_, err = svc.Export(ctx, criteria, clerkID, services.ExportCSV, []string{"patient_hn", "national_id"})
+	assert.ErrorIs(t, err, services.ErrForbidden)
+	_, err = svc.Export(ctx, criteria, doctorID, services.ExportCSV, []string{"patient_hn", "blood_type"})
+	assert.ErrorIs(t, err, services.ErrInvalidInput)
+	_, err = svc.Export(ctx, criteria, doctorID, "pdf", nil)
+	assert.ErrorIs(t, err, services.ErrInvalidInput)
+
+	export, err = svc.Export(ctx, criteria, pharmacistID, services.ExportNDJSON, []string{"patient_hn", "national_id", "passport_id", "phone_number", "email"})
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	n, err := export.Write(ctx, &buf)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.True(t, strings.HasPrefix(lines[0], `{"patient_hn":"HN0001","national_id"`), "columns keep their order: %s", lines[0])
+	var row map[string]string
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &row))
+	assert.Equal(t, "*********3451", row["national_id"])
+	assert.Equal(t, "******5678", row["phone_number"])
+	assert.Equal(t, "s***@example.com", row["email"])
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &row))
+	assert.Equal(t, "******567", row["passport_id"])
+	assert.Equal(t, "", row["national_id"])
+
+	// Every prepared export is audited, with high priority when identifiers or contact details are in clear
+	require.Len(t, audit.events, 3)
+	for _, e := range audit.events {
+		assert.Equal(t, services.AuditActionPatientExport, e.Action)
+		assert.Equal(t, hospitalID, e.HospitalID)
+	}
+	assert.Equal(t, entities.AuditHigh, audit.events[0].Priority)
+	assert.Equal(t, entities.AuditNormal, audit.events[1].Priority)
+	assert.Equal(t, entities.AuditNormal, audit.events[2].Priority)
+	assert.Equal(t, pharmacistID, audit.events[2].StaffID)
+	assert.Contains(t, audit.events[2].Details, "masked: national_id,passport_id,phone_number,email")
+}
+
+func TestPatientExport_CSVAndXLSX(t *testing.T) {
+	doctorID := uuid.New()
+	staffRepo := &roleStaffRepo{roles: map[uuid.UUID]string{doctorID: entities.RoleDoctor}}
+	svc := services.NewPatientExportService(&memoryPatientExportRepo{patients: exportPatients()}, staffRepo, &memoryAuditRepo{})
+	ctx := context.Background()
+	columns := []string{"patient_hn", "first_name_th", "first_name_en", "date_of_birth"}
+
+	export, err := svc.Export(ctx, dto.PatientSearchCriteria{}, doctorID, services.ExportCSV, columns)
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	_, err = export.Write(ctx, &buf)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(buf.String(), "\ufeff"))
+	rows, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(buf.String(), "\ufeff"))).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"patient_hn", "first_name_th", "first_name_en", "date_of_birth"},
+		{"HN0001", "สมชาย", "", "1985-02-14"},
+		{"HN0002", "", `'=HYPERLINK("x")`, ""},
+	}, rows)
+
+	export, err = svc.Export(ctx, dto.PatientSearchCriteria{}, doctorID, services.ExportXLSX, columns)
+	require.NoError(t, err)
+	buf.Reset()
+	_, err = export.Write(ctx, &buf)
+	require.NoError(t, err)
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	parts := map[string]string{}
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		b, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		parts[f.Name] = string(b)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml"} {
+		require.Contains(t, parts, name)
+		var v struct{}
+		assert.NoError(t, xml.Unmarshal([]byte(parts[name]), &v), name)
+	}
+	var sheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref  string `xml:"r,attr"`
+				Text string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	require.NoError(t, xml.Unmarshal([]byte(parts["xl/worksheets/sheet1.xml"]), &sheet))
+	require.Len(t, sheet.Rows, 3)
+	assert.Equal(t, "D1", sheet.Rows[0].Cells[3].Ref)
+	assert.Equal(t, "date_of_birth", sheet.Rows[0].Cells[3].Text)
+	assert.Equal(t, "B2", sheet.Rows[1].Cells[1].Ref)
+	assert.Equal(t, "สมชาย", sheet.Rows[1].Cells[1].Text)
+	assert.Equal(t, "C3", sheet.Rows[2].Cells[1].Ref)
+	assert.Equal(t, `=HYPERLINK("x")`, sheet.Rows[2].Cells[1].Text)
+}
+
+func tokenForStaff(staffID uuid.UUID) string {
+	os.Setenv("JWT_SECRET", "testsecret")
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"sub": staffID.String(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	tokenString, _ := token.SignedString([]byte("testsecret"))
+	return "Bearer " + tokenString
+}
+
+func TestPatientHandler_SearchExport(t *testing.T) {
+	hospitalID, nurseID, clerkID := uuid.New(), uuid.New(), uuid.New()
+	staffRepo := &roleStaffRepo{roles: map[uuid.UUID]string{nurseID: entities.RoleNurse, clerkID: entities.RoleStaff}}
+	exportRepo := &memoryPatientExportRepo{patients: exportPatients()}
+	audit := &memoryAuditRepo{}
+	patientService := &mockPatientService{
+		SearchFunc: func(ctx context.Context, criteria dto.PatientSearchCriteria) ([]entities.Patient, error) {
+			return exportPatients(), nil
+		},
+	}
+	h := handlers.NewPatientHandler(patientService, services.NewPatientExportService(exportRepo, staffRepo, audit), &mockStaffService2{
+		GetHospitalIDByStaffIDFunc: func(staffID string) (uuid.UUID, error) { return hospitalID, nil },
+	})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/patients/search", h.SearchHandler)
+
+	search := func(staffID uuid.UUID, query, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/patients/search"+query, strings.NewReader(`{"gender":"M"}`))
+		req.Header.Set("Authorization", tokenForStaff(staffID))
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	cases := []struct {
+		name            string
+		staffID         uuid.UUID
+		query           string
+		accept          string
+		wantStatusCode  int
+		wantContentType string
+		wantBody        string
+	}{
+		{
+			name:            "positive JSON by default",
+			staffID:         nurseID,
+			accept:          "application/json",
+			wantStatusCode:  http.StatusOK,
+			wantContentType: "application/json; charset=utf-8",
+			wantBody:        `"status":"success"`,
+		},
+		{
+			name:            "positive CSV by Accept header",
+			staffID:         nurseID,
+			query:           "?columns=patient_hn,national_id",
+			accept:          "text/csv, application/json;q=0.5",
+			wantStatusCode:  http.StatusOK,
+			wantContentType: "text/csv; charset=utf-8",
+			wantBody:        "\ufeffpatient_hn,national_id\nHN0001,1101700203451\nHN0002,\n",
+		},
+		{
+			name:            "positive NDJSON by format parameter",
+			staffID:         clerkID,
+			query:           "?format=ndjson&columns=patient_hn,phone_number",
+			accept:          "application/json",
+			wantStatusCode:  http.StatusOK,
+			wantContentType: "application/x-ndjson",
+			wantBody:        "{\"patient_hn\":\"HN0001\",\"phone_number\":\"******5678\"}\n{\"patient_hn\":\"HN0002\",\"phone_number\":\"\"}\n",
+		},
+		{
+			name:            "positive XLSX by format parameter",
+			staffID:         nurseID,
+			query:           "?format=xlsx",
+			wantStatusCode:  http.StatusOK,
+			wantContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+			wantBody:        "PK",
+		},
+		{
+			name:           "negative unknown format",
+			staffID:        nurseID,
+			query:          "?format=pdf",
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "negative column not allowed for role",
+			staffID:        clerkID,
+			query:          "?format=csv&columns=patient_hn,passport_id",
+			wantStatusCode: http.StatusForbidden,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := search(tc.staffID, tc.query, tc.accept)
+			assert.Equal(t, tc.wantStatusCode, w.Code, w.Body.String())
+			if tc.wantContentType != "" {
+				assert.Equal(t, tc.wantContentType, w.Header().Get("Content-Type"))
+			}
+			if strings.HasPrefix(tc.wantBody, "{") || strings.HasPrefix(tc.wantBody, "\ufeff") {
+				assert.Equal(t, tc.wantBody, w.Body.String())
+			} else {
+				assert.Contains(t, w.Body.String(), tc.wantBody)
+			}
+			if tc.wantStatusCode == http.StatusOK && tc.accept != "application/json" {
+				assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment; filename=\"patients-")
+			}
+		})
+	}
+	// The three exports were audited; the JSON search and the refused exports were not
+	assert.Len(t, audit.events, 3)
+}
+
+func TestPatientHandler_SearchExportStopsOnError(t *testing.T) {
+	nurseID := uuid.New()
+	staffRepo := &roleStaffRepo{roles: map[uuid.UUID]string{nurseID: entities.RoleNurse}}
+	exportRepo := &memoryPatientExportRepo{patients: exportPatients(), failAt: 1}
+	h := handlers.NewPatientHandler(nil, services.NewPatientExportService(exportRepo, staffRepo, &memoryAuditRepo{}), &mockStaffService2{
+		GetHospitalIDByStaffIDFunc: func(staffID string) (uuid.UUID, error) { return uuid.New(), nil },
+	})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/patients/search", h.SearchHandler)
+
+	req := httptest.NewRequest("POST", "/patients/search?format=ndjson&columns=patient_hn", strings.NewReader(`{}`))
+	req.Header.Set("Authorization", tokenForStaff(nurseID))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("X-Total-Count"))
+	scanner := bufio.NewScanner(w.Body)
+	var lines int
+	for scanner.Scan() {
+		lines++
+	}
+	// A download cut short has fewer rows than announced
+	assert.Less(t, lines, 2)
+}
+
+func TestPatientHandler_SearchExport_NotStoredForIdempotency(t *testing.T) {
+	hospitalID, nurseID := uuid.New(), uuid.New()
+	staffRepo := &roleStaffRepo{roles: map[uuid.UUID]string{nurseID: entities.RoleNurse}}
+	audit := &memoryAuditRepo{}
+	patientService := &mockPatientService{
+		SearchFunc: func(ctx context.Context, criteria dto.PatientSearchCriteria) ([]entities.Patient, error) {
+			return exportPatients(), nil
+		},
+	}
+	h := handlers.NewPatientHandler(patientService, services.NewPatientExportService(&memoryPatientExportRepo{patients: exportPatients()}, staffRepo, audit), &mockStaffService2{
+		GetHospitalIDByStaffIDFunc: func(staffID string) (uuid.UUID, error) { return hospitalID, nil },
+	})
+	idempotency := newMemoryIdempotencyRepo()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middleware.AuthMiddleware(), middleware.Idempotency(idempotency, time.Hour))
+	router.POST("/patients/search", h.SearchHandler)
+
+	search := func(key, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/patients/search"+query, strings.NewReader(`{"gender":"M"}`))
+		req.Header.Set("Authorization", tokenForStaff(nurseID))
+		req.Header.Set("Idempotency-Key", key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// An export runs again on retry and nothing is kept under its key
+	for range 2 {
+		w := search("export-1", "?format=csv")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+		assert.Empty(t, w.Header().Get(middleware.IdempotentReplayedHeader))
+	}
+	assert.Len(t, audit.events, 2)
+	assert.Empty(t, idempotency.records)
+
+	// A JSON search is still replayed
+	first := search("search-1", "")
+	require.Equal(t, http.StatusOK, first.Code)
+	retry := search("search-1", "")
+	assert.Equal(t, "true", retry.Header().Get(middleware.IdempotentReplayedHeader))
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+}
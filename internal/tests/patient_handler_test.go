@@ -5,9 +5,12 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"go-hospital-api/internal/dto"
 	"go-hospital-api/internal/entities"
 	"go-hospital-api/internal/handlers"
+	"go-hospital-api/internal/repository"
+	"go-hospital-api/internal/services"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -18,20 +21,54 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm/clause"
 )
 
 type mockPatientService struct {
-	SearchFunc func(ctx context.Context, criteria dto.PatientSearchCriteria) ([]entities.Patient, error)
+	SearchFunc     func(ctx context.Context, criteria dto.PatientSearchCriteria) ([]entities.Patient, error)
+	SearchPageFunc func(ctx context.Context, criteria dto.PatientSearchCriteria, offset, limit int) ([]entities.Patient, int64, error)
+	GetFunc        func(ctx context.Context, id, hospitalID uuid.UUID, asOf *time.Time) (*entities.Patient, error)
+	CreateFunc     func(ctx context.Context, patient *entities.Patient, staffID uuid.UUID) error
+	MergeFunc      func(ctx context.Context, fromID, intoID, hospitalID, staffID uuid.UUID) error
+	UpdateFunc     func(ctx context.Context, id, hospitalID, staffID uuid.UUID, version int, req dto.PatientUpdateRequest) (*entities.Patient, error)
+	HistoryFunc    func(ctx context.Context, id, hospitalID uuid.UUID) ([]services.PatientHistoryEntry, error)
 }
 
 func (m *mockPatientService) Search(ctx context.Context, criteria dto.PatientSearchCriteria) ([]entities.Patient, error) {
 	return m.SearchFunc(ctx, criteria)
 }
 
+func (m *mockPatientService) SearchPage(ctx context.Context, criteria dto.PatientSearchCriteria, offset, limit int) ([]entities.Patient, int64, error) {
+	return m.SearchPageFunc(ctx, criteria, offset, limit)
+}
+
+func (m *mockPatientService) Create(ctx context.Context, patient *entities.Patient, staffID uuid.UUID) error {
+	return m.CreateFunc(ctx, patient, staffID)
+}
+
+func (m *mockPatientService) Merge(ctx context.Context, fromID, intoID, hospitalID, staffID uuid.UUID) error {
+	return m.MergeFunc(ctx, fromID, intoID, hospitalID, staffID)
+}
+
+func (m *mockPatientService) Get(ctx context.Context, id, hospitalID uuid.UUID, asOf *time.Time) (*entities.Patient, error) {
+	return m.GetFunc(ctx, id, hospitalID, asOf)
+}
+
+func (m *mockPatientService) Update(ctx context.Context, id, hospitalID, staffID uuid.UUID, version int, req dto.PatientUpdateRequest) (*entities.Patient, error) {
+	return m.UpdateFunc(ctx, id, hospitalID, staffID, version, req)
+}
+
+func (m *mockPatientService) History(ctx context.Context, id, hospitalID uuid.UUID) ([]services.PatientHistoryEntry, error) {
+	return m.HistoryFunc(ctx, id, hospitalID)
+}
+
 type mockStaffService2 struct {
 	GetHospitalIDByStaffIDFunc func(staffID string) (uuid.UUID, error)
 	CreateFunc                 func(staff *entities.Staff, hospitalID string) error
 	LoginFunc                  func(email, password string, hospitalID uuid.UUID) (*entities.Staff, error)
+	GetByIDFunc                func(staffID uuid.UUID, hospitalID uuid.UUID) (*entities.Staff, error)
+	SetRoleFunc                func(ctx context.Context, id, hospitalID, staffID uuid.UUID, role string, version int) (*entities.Staff, error)
 }
 
 func (m *mockStaffService2) GetHospitalIDByStaffID(staffID string) (uuid.UUID, error) {
@@ -52,6 +89,20 @@ func (m *mockStaffService2) Login(email, password string, hospitalID uuid.UUID)
 	return nil, nil
 }
 
+func (m *mockStaffService2) GetByID(staffID uuid.UUID, hospitalID uuid.UUID) (*entities.Staff, error) {
+	if m.GetByIDFunc != nil {
+		return m.GetByIDFunc(staffID, hospitalID)
+	}
+	return nil, nil
+}
+
+func (m *mockStaffService2) SetRole(ctx context.Context, id, hospitalID, staffID uuid.UUID, role string, version int) (*entities.Staff, error) {
+	if m.SetRoleFunc != nil {
+		return m.SetRoleFunc(ctx, id, hospitalID, staffID, role, version)
+	}
+	return nil, nil
+}
+
 func TestPatientHandler_SearchHandler(t *testing.T) {
 	cases := []struct {
 		name           string
@@ -99,7 +150,7 @@ func TestPatientHandler_SearchHandler(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			patientService := &mockPatientService{SearchFunc: tc.searchFunc}
 			staffService2 := &mockStaffService2{GetHospitalIDByStaffIDFunc: tc.getHospitalID}
-			h := handlers.NewPatientHandler(patientService, staffService2)
+			h := handlers.NewPatientHandler(patientService, nil, staffService2)
 
 			gin.SetMode(gin.TestMode)
 			router := gin.New()
@@ -116,6 +167,73 @@ func TestPatientHandler_SearchHandler(t *testing.T) {
 	}
 }
 
+func TestPatientHandler_SearchHandler_MasksByRole(t *testing.T) {
+	patient := entities.Patient{
+		ID:          uuid.New(),
+		PatientHN:   "HN0001",
+		FirstNameEN: "Somchai",
+		NationalID:  "1103700012345",
+		PassportID:  "AA1234567",
+		PhoneNumber: "0812345678",
+		Email:       "somchai@example.com",
+	}
+	cases := []struct {
+		role string
+		want dto.PatientResponse
+	}{
+		{
+			role: entities.RolePharmacist,
+			want: dto.PatientResponse{NationalID: "*********2345", PassportID: "******567", PhoneNumber: "******5678", Email: "s***@example.com"},
+		},
+		{
+			role: entities.RoleStaff,
+			want: dto.PatientResponse{NationalID: "", PassportID: "", PhoneNumber: "******5678", Email: "s***@example.com"},
+		},
+		{
+			role: entities.RoleDoctor,
+			want: dto.PatientResponse{NationalID: patient.NationalID, PassportID: patient.PassportID, PhoneNumber: patient.PhoneNumber, Email: patient.Email},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.role, func(t *testing.T) {
+			patientService := &mockPatientService{SearchFunc: func(ctx context.Context, criteria dto.PatientSearchCriteria) ([]entities.Patient, error) {
+				return []entities.Patient{patient}, nil
+			}}
+			staffService2 := &mockStaffService2{
+				GetHospitalIDByStaffIDFunc: func(staffID string) (uuid.UUID, error) { return uuid.New(), nil },
+				GetByIDFunc: func(staffID, hospitalID uuid.UUID) (*entities.Staff, error) {
+					return &entities.Staff{ID: staffID, HospitalID: hospitalID, Role: tc.role}, nil
+				},
+			}
+			h := handlers.NewPatientHandler(patientService, nil, staffService2)
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.POST("/patients/search", h.SearchHandler)
+
+			req := httptest.NewRequest("POST", "/patients/search", bytes.NewReader([]byte(`{}`)))
+			req.Header.Set("Authorization", generateValidToken())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusOK, w.Code)
+
+			var body struct {
+				Data []dto.PatientResponse `json:"data"`
+			}
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+			if assert.Len(t, body.Data, 1) {
+				got := body.Data[0]
+				assert.Equal(t, "HN0001", got.PatientHN)
+				assert.Equal(t, tc.want.NationalID, got.NationalID)
+				assert.Equal(t, tc.want.PassportID, got.PassportID)
+				assert.Equal(t, tc.want.PhoneNumber, got.PhoneNumber)
+				assert.Equal(t, tc.want.Email, got.Email)
+			}
+		})
+	}
+}
+
 func generateValidToken() string {
 	os.Setenv("JWT_SECRET", "testsecret")
 	// You'll need to import the same JWT package and secret used in your login handler
@@ -127,3 +245,247 @@ func generateValidToken() string {
 	tokenString, _ := token.SignedString([]byte("testsecret"))
 	return "Bearer " + tokenString
 }
+
+func newPatientRouter(svc services.PatientServiceInterface) *gin.Engine {
+	h := handlers.NewPatientHandler(svc, nil, &mockStaffService2{
+		GetHospitalIDByStaffIDFunc: func(staffID string) (uuid.UUID, error) { return uuid.New(), nil },
+	})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/patients/:id", h.GetHandler)
+	router.PATCH("/patients/:id", h.UpdateHandler)
+	router.GET("/patients/:id/history", h.HistoryHandler)
+	return router
+}
+
+func TestPatientHandler_GetHandler(t *testing.T) {
+	cases := []struct {
+		name           string
+		query          string
+		getFunc        func(ctx context.Context, id, hospitalID uuid.UUID, asOf *time.Time) (*entities.Patient, error)
+		wantStatusCode int
+		wantETag       string
+	}{
+		{
+			name: "positive current version",
+			getFunc: func(ctx context.Context, id, hospitalID uuid.UUID, asOf *time.Time) (*entities.Patient, error) {
+				assert.Nil(t, asOf)
+				return &entities.Patient{ID: id, HospitalID: hospitalID, Version: 7}, nil
+			},
+			wantStatusCode: http.StatusOK,
+			wantETag:       `"7"`,
+		},
+		{
+			name:  "positive as of a past time",
+			query: "?as_of=2026-01-31T09:00:00%2B07:00",
+			getFunc: func(ctx context.Context, id, hospitalID uuid.UUID, asOf *time.Time) (*entities.Patient, error) {
+				if assert.NotNil(t, asOf) {
+					assert.True(t, asOf.Equal(time.Date(2026, 1, 31, 2, 0, 0, 0, time.UTC)))
+				}
+				return &entities.Patient{ID: id, FirstNameTH: "สมชาย", HospitalID: hospitalID}, nil
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:           "negative invalid as_of",
+			query:          "?as_of=yesterday",
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:  "negative before the patient existed",
+			query: "?as_of=2000-01-01T00:00:00Z",
+			getFunc: func(ctx context.Context, id, hospitalID uuid.UUID, asOf *time.Time) (*entities.Patient, error) {
+				return nil, fmt.Errorf("%w: patient did not exist at %s", services.ErrNotFound, asOf.Format(time.RFC3339))
+			},
+			wantStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newPatientRouter(&mockPatientService{GetFunc: tc.getFunc})
+			req := httptest.NewRequest("GET", "/patients/"+uuid.New().String()+tc.query, nil)
+			req.Header.Set("Authorization", generateValidToken())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Equal(t, tc.wantETag, w.Header().Get("ETag"))
+		})
+	}
+}
+
+func TestPatientHandler_GetHandler_ActiveCoverage(t *testing.T) {
+	conn := newTestDB(t)
+	hospitalID, staffID := uuid.New(), uuid.New()
+	require.NoError(t, conn.Create(&entities.Hospital{ID: hospitalID, Name: "General"}).Error)
+	repo := repository.NewPatientRepository(conn)
+	patient := &entities.Patient{ID: uuid.New(), PatientHN: "HN-COV-1", HospitalID: hospitalID, Version: 1}
+	require.NoError(t, repo.Create(context.Background(), patient, staffID))
+	lastYear := time.Now().AddDate(-1, 0, 0)
+	for _, c := range []entities.Coverage{
+		{ID: uuid.New(), Scheme: entities.SchemeSSS, MemberNumber: "SSS-OLD", ValidFrom: lastYear.AddDate(-1, 0, 0), ValidTo: &lastYear},
+		{ID: uuid.New(), Scheme: entities.SchemeUC, MemberNumber: "UC-1", ValidFrom: lastYear, EligibilityStatus: entities.EligibilityEligible},
+	} {
+		c.PatientID, c.HospitalID, c.RecordedByID = patient.ID, hospitalID, staffID
+		require.NoError(t, conn.Omit(clause.Associations).Create(&c).Error)
+	}
+
+	h := handlers.NewPatientHandler(services.NewPatientService(repo), nil, &mockStaffService2{
+		GetHospitalIDByStaffIDFunc: func(staffID string) (uuid.UUID, error) { return hospitalID, nil },
+	})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/patients/:id", h.GetHandler)
+	req := httptest.NewRequest("GET", "/patients/"+patient.ID.String(), nil)
+	req.Header.Set("Authorization", generateValidToken())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data dto.PatientResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	if assert.NotNil(t, resp.Data.ActiveCoverage) {
+		assert.Equal(t, entities.SchemeUC, resp.Data.ActiveCoverage.Scheme)
+		assert.Equal(t, "UC-1", resp.Data.ActiveCoverage.MemberNumber)
+	}
+}
+
+func TestPatientHandler_UpdateHandler(t *testing.T) {
+	cases := []struct {
+		name           string
+		ifMatch        string
+		updateFunc     func(ctx context.Context, id, hospitalID, staffID uuid.UUID, version int, req dto.PatientUpdateRequest) (*entities.Patient, error)
+		wantStatusCode int
+		wantETag       string
+	}{
+		{
+			name:    "positive",
+			ifMatch: `"3"`,
+			updateFunc: func(ctx context.Context, id, hospitalID, staffID uuid.UUID, version int, req dto.PatientUpdateRequest) (*entities.Patient, error) {
+				assert.Equal(t, 3, version)
+				if assert.NotNil(t, req.PhoneNumber) {
+					assert.Equal(t, "0899999999", *req.PhoneNumber)
+				}
+				assert.Nil(t, req.NationalID)
+				return &entities.Patient{ID: id, PhoneNumber: *req.PhoneNumber, HospitalID: hospitalID, Version: version + 1}, nil
+			},
+			wantStatusCode: http.StatusOK,
+			wantETag:       `"4"`,
+		},
+		{
+			name:    "positive weak etag",
+			ifMatch: `W/"3"`,
+			updateFunc: func(ctx context.Context, id, hospitalID, staffID uuid.UUID, version int, req dto.PatientUpdateRequest) (*entities.Patient, error) {
+				assert.Equal(t, 3, version)
+				return &entities.Patient{ID: id, HospitalID: hospitalID, Version: version + 1}, nil
+			},
+			wantStatusCode: http.StatusOK,
+			wantETag:       `"4"`,
+		},
+		{
+			name:           "negative missing If-Match",
+			wantStatusCode: http.StatusPreconditionRequired,
+		},
+		{
+			name:           "negative malformed If-Match",
+			ifMatch:        `"abc"`,
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:    "negative changed by someone else",
+			ifMatch: `"3"`,
+			updateFunc: func(ctx context.Context, id, hospitalID, staffID uuid.UUID, version int, req dto.PatientUpdateRequest) (*entities.Patient, error) {
+				return nil, fmt.Errorf("%w: patient is at version 4", services.ErrPreconditionFailed)
+			},
+			wantStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:    "negative invalid national id",
+			ifMatch: `"3"`,
+			updateFunc: func(ctx context.Context, id, hospitalID, staffID uuid.UUID, version int, req dto.PatientUpdateRequest) (*entities.Patient, error) {
+				return nil, fmt.Errorf("%w: national_id must be 13 digits", services.ErrInvalidInput)
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newPatientRouter(&mockPatientService{UpdateFunc: tc.updateFunc})
+			req := httptest.NewRequest("PATCH", "/patients/"+uuid.New().String(), bytes.NewBufferString(`{"phone_number":"0899999999"}`))
+			req.Header.Set("Authorization", generateValidToken())
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			assert.Equal(t, tc.wantETag, w.Header().Get("ETag"))
+		})
+	}
+}
+
+func TestPatientHandler_HistoryHandler(t *testing.T) {
+	staffID := uuid.New()
+	router := newPatientRouter(&mockPatientService{
+		HistoryFunc: func(ctx context.Context, id, hospitalID uuid.UUID) ([]services.PatientHistoryEntry, error) {
+			return []services.PatientHistoryEntry{
+				{
+					Version: entities.PatientVersion{Version: 2, Operation: entities.PatientOpUpdate, ChangedByID: &staffID, ChangedAt: time.Now()},
+					Changes: []services.FieldChange{{Field: "phone_number", From: "0811111111", To: "0899999999"}},
+				},
+				{Version: entities.PatientVersion{Version: 1, Operation: entities.PatientOpBaseline, ChangedAt: time.Now().Add(-time.Hour)}},
+			}, nil
+		},
+	})
+	req := httptest.NewRequest("GET", "/patients/"+uuid.New().String()+"/history", nil)
+	req.Header.Set("Authorization", generateValidToken())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data []dto.PatientVersionResponse `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if assert.Len(t, resp.Data, 2) {
+		assert.Equal(t, 2, resp.Data[0].Version)
+		assert.Equal(t, []dto.FieldChangeResponse{{Field: "phone_number", From: "0811111111", To: "0899999999"}}, resp.Data[0].Changes)
+	}
+}
+
+func TestDiffPatientSnapshots(t *testing.T) {
+	dob := time.Date(1980, 5, 15, 0, 0, 0, 0, time.UTC)
+	sameDay := time.Date(1980, 5, 15, 0, 0, 0, 0, time.FixedZone("ICT", 7*3600))
+	before := entities.PatientSnapshot{FirstNameTH: "สมชาย", LastNameTH: "ใจดี", PhoneNumber: "0811111111", DateOfBirth: &dob}
+	after := entities.PatientSnapshot{FirstNameTH: "สมชาย", LastNameTH: "ใจงาม", NationalID: "1234567890123", DateOfBirth: &sameDay}
+
+	changes := services.DiffPatientSnapshots(before, after)
+	assert.Equal(t, []services.FieldChange{
+		{Field: "last_name_th", From: "ใจดี", To: "ใจงาม"},
+		{Field: "national_id", From: "", To: "1234567890123"},
+		{Field: "phone_number", From: "0811111111", To: ""},
+	}, changes)
+	assert.Empty(t, services.DiffPatientSnapshots(after, after))
+}
+
+func TestPatientService_Create_DuplicateHN(t *testing.T) {
+	ctx := context.Background()
+	conn := newTestDB(t)
+	hospitalID, otherHospitalID, staffID := uuid.New(), uuid.New(), uuid.New()
+	require.NoError(t, conn.Create(&entities.Hospital{ID: hospitalID, Name: "General"}).Error)
+	require.NoError(t, conn.Create(&entities.Hospital{ID: otherHospitalID, Name: "Other"}).Error)
+	svc := services.NewPatientService(repository.NewPatientRepository(conn))
+
+	first := &entities.Patient{FirstNameEN: "Somchai", LastNameEN: "Dee", PatientHN: "HN-DUP-1", HospitalID: hospitalID}
+	require.NoError(t, svc.Create(ctx, first, staffID))
+	err := svc.Create(ctx, &entities.Patient{FirstNameEN: "Somchai", LastNameEN: "Dee", PatientHN: "HN-DUP-1", HospitalID: hospitalID}, staffID)
+	assert.ErrorIs(t, err, services.ErrConflict)
+	assert.NoError(t, svc.Create(ctx, &entities.Patient{FirstNameEN: "Somchai", LastNameEN: "Dee", PatientHN: "HN-DUP-1", HospitalID: otherHospitalID}, staffID))
+
+	// A deleted patient's HN can be registered again
+	require.NoError(t, conn.Delete(&entities.Patient{}, "id = ?", first.ID).Error)
+	assert.NoError(t, svc.Create(ctx, &entities.Patient{FirstNameEN: "Somchai", LastNameEN: "Dee", PatientHN: "HN-DUP-1", HospitalID: hospitalID}, staffID))
+}
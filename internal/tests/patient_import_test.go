@@ -0,0 +1,546 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/handlers"
+	"go-hospital-api/internal/repository"
+	"go-hospital-api/internal/services"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// memoryPatientImportRepo is an in-memory repository.PatientImportRepository
+type memoryPatientImportRepo struct {
+	mu       sync.Mutex
+	imports  map[uuid.UUID]entities.PatientImport
+	errs     []entities.PatientImportError
+	patients []entities.Patient
+	batches  int
+}
+
+func newMemoryPatientImportRepo(existing ...entities.Patient) *memoryPatientImportRepo {
+	return &memoryPatientImportRepo{imports: map[uuid.UUID]entities.PatientImport{}, patients: existing}
+}
+
+func (m *memoryPatientImportRepo) Create(ctx context.Context, imp *entities.PatientImport) error {
+	return m.Save(ctx, imp)
+}
+
+func (m *memoryPatientImportRepo) Save(ctx context.Context, imp *entities.PatientImport) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.imports[imp.ID] = *imp
+	return nil
+}
+
+func (m *memoryPatientImportRepo) GetByID(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.PatientImport, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	imp, ok := m.imports[id]
+	if !ok || imp.HospitalID != hospitalID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &imp, nil
+}
+
+func (m *memoryPatientImportRepo) AddErrors(ctx context.Context, errs []entities.PatientImportError) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errs = append(m.errs, errs...)
+	return nil
+}
+
+func (m *memoryPatientImportRepo) ListErrors(ctx context.Context, importID uuid.UUID) ([]entities.PatientImportError, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var errs []entities.PatientImportError
+	for _, e := range m.errs {
+		if e.ImportID == importID {
+			errs = append(errs, e)
+		}
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Row < errs[j].Row })
+	return errs, nil
+}
+
+func (m *memoryPatientImportRepo) FailUnfinished(ctx context.Context, reason string) (int64, error) {
+	return 0, nil
+}
+
+func (m *memoryPatientImportRepo) InsertPatients(ctx context.Context, hospitalID uuid.UUID, patients []entities.Patient, staffID uuid.UUID, dryRun bool) ([]repository.DuplicatePatient, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	hnSet, nationalIDSet := map[string]bool{}, map[string]string{}
+	for _, p := range m.patients {
+		if p.HospitalID != hospitalID {
+			continue
+		}
+		hnSet[p.PatientHN] = true
+		if p.NationalID != "" {
+			nationalIDSet[p.NationalID] = p.PatientHN
+		}
+	}
+	var duplicates []repository.DuplicatePatient
+	var fresh []entities.Patient
+	for i, p := range patients {
+		switch hn, taken := nationalIDSet[p.NationalID]; {
+		case hnSet[p.PatientHN]:
+			duplicates = append(duplicates, repository.DuplicatePatient{Index: i, Column: "patient_hn"})
+		case taken && p.NationalID != "":
+			duplicates = append(duplicates, repository.DuplicatePatient{Index: i, Column: "national_id", RegisteredHN: hn})
+		default:
+			fresh = append(fresh, p)
+		}
+	}
+	if !dryRun && len(fresh) > 0 {
+		m.patients = append(m.patients, fresh...)
+		m.batches++
+	}
+	return duplicates, nil
+}
+
+func (m *memoryPatientImportRepo) byHN(hn string) *entities.Patient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.patients {
+		if m.patients[i].PatientHN == hn {
+			return &m.patients[i]
+		}
+	}
+	return nil
+}
+
+// thaiNationalID completes twelve digits with the check digit
+func thaiNationalID(first12 string) string {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		sum += int(first12[i]-'0') * (13 - i)
+	}
+	return fmt.Sprintf("%s%d", first12, (11-sum%11)%10)
+}
+
+func newImportService(repo *memoryPatientImportRepo, supervisorID uuid.UUID) services.PatientImportServiceInterface {
+	return services.NewPatientImportService(repo, &roleStaffRepo{roles: map[uuid.UUID]string{supervisorID: entities.RoleSupervisor}})
+}
+
+func TestPatientImportService_CSV(t *testing.T) {
+	hospitalID, supervisorID := uuid.New(), uuid.New()
+	registered := entities.Patient{ID: uuid.New(), HospitalID: hospitalID, PatientHN: "HN900", NationalID: thaiNationalID("310010000000")}
+	repo := newMemoryPatientImportRepo(registered)
+	svc := newImportService(repo, supervisorID)
+
+	validID := thaiNationalID("110370001234")
+	badChecksum := validID[:12] + fmt.Sprint((int(validID[12]-'0')+1)%10)
+	file := strings.Join([]string{
+		"HN,First Name TH,Last Name TH,first_name_en,Citizen No,DOB,Sex,Email",
+		"HN001,สมชาย,ใจดี,Somchai," + validID + ",14/02/2528,ชาย,somchai@example.com",
+		"HN002,สมหญิง,ใจดี,,123,1990-01-01,F,",
+		"HN003,,,Jane,,31-31-1990,X,",
+		"HN001,ซ้ำ,ซ้ำ,,,,,",
+		",,,,,,,",
+		"HN900,มี,แล้ว,,,,,",
+		"HN004,บัตร,ซ้ำ,," + registered.NationalID + ",,,",
+		"HN005,,,,,,,",
+		"HN006,ทดสอบ,,Test," + badChecksum + ",19900101,M,not-an-email",
+	}, "\n")
+
+	imp, err := svc.Start(context.Background(), hospitalID, supervisorID, "patients.csv", []byte(file), map[string]string{"Citizen No": "national_id"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, entities.ImportCompleted, imp.Status)
+	assert.Equal(t, 8, imp.TotalRows)
+	assert.Equal(t, 8, imp.ProcessedRows)
+	assert.Equal(t, 1, imp.ImportedRows)
+	assert.Equal(t, 7, imp.FailedRows)
+	assert.Equal(t, 1, repo.batches)
+
+	patient := repo.byHN("HN001")
+	require.NotNil(t, patient)
+	assert.Equal(t, "สมชาย", patient.FirstNameTH)
+	assert.Equal(t, "Somchai", patient.FirstNameEN)
+	assert.Equal(t, validID, patient.NationalID)
+	assert.Equal(t, "M", patient.Gender)
+	require.NotNil(t, patient.DateOfBirth)
+	assert.Equal(t, "1985-02-14", patient.DateOfBirth.Format("2006-01-02"))
+	assert.Equal(t, hospitalID, patient.HospitalID)
+
+	errs, err := svc.Errors(context.Background(), imp.ID, hospitalID, supervisorID)
+	require.NoError(t, err)
+	got := map[string]string{}
+	for _, e := range errs {
+		got[fmt.Sprintf("%d:%s", e.Row, e.ColumnName)] = e.Message
+	}
+	assert.Contains(t, got["3:national_id"], "check digit")
+	assert.Contains(t, got["4:date_of_birth"], "date")
+	assert.Contains(t, got["4:gender"], "M, F or O")
+	assert.Equal(t, "HN is also on row 2", got["5:patient_hn"])
+	assert.Equal(t, "HN is already registered", got["7:patient_hn"])
+	assert.Equal(t, "national ID is already registered to HN HN900", got["8:national_id"])
+	assert.Contains(t, got["9:"], "first name")
+	assert.Contains(t, got["10:national_id"], "check digit")
+	assert.Len(t, errs, 8)
+
+	var report bytes.Buffer
+	require.NoError(t, services.WriteImportErrorReport(&report, errs))
+	lines, err := csv.NewReader(&report).ReadAll()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"row", "patient_hn", "column", "value", "message"}, lines[0])
+	assert.Len(t, lines, 9)
+}
+
+func TestPatientImportService_Validation(t *testing.T) {
+	hospitalID, supervisorID, nurseID := uuid.New(), uuid.New(), uuid.New()
+	svc := services.NewPatientImportService(newMemoryPatientImportRepo(), &roleStaffRepo{roles: map[uuid.UUID]string{supervisorID: entities.RoleSupervisor, nurseID: "nurse"}})
+	ctx := context.Background()
+
+	_, err := svc.Start(ctx, hospitalID, nurseID, "patients.csv", []byte("patient_hn\nHN1"), nil, false)
+	assert.ErrorIs(t, err, services.ErrForbidden)
+	_, err = svc.Start(ctx, hospitalID, supervisorID, "patients.csv", []byte("first_name_th\nสมชาย"), nil, false)
+	assert.ErrorIs(t, err, services.ErrInvalidInput)
+	_, err = svc.Start(ctx, hospitalID, supervisorID, "patients.csv", []byte("HN,Name\nHN1,A"), map[string]string{"Name": "nickname"}, false)
+	assert.ErrorIs(t, err, services.ErrInvalidInput)
+	_, err = svc.Start(ctx, hospitalID, supervisorID, "patients.csv", []byte("HN\nHN1"), map[string]string{"Name": "first_name_en"}, false)
+	assert.ErrorIs(t, err, services.ErrInvalidInput)
+	_, err = svc.Start(ctx, hospitalID, supervisorID, "patients.pdf", []byte("HN\nHN1"), nil, false)
+	assert.ErrorIs(t, err, services.ErrInvalidInput)
+	_, err = svc.Start(ctx, hospitalID, supervisorID, "patients.xlsx", []byte("not a workbook"), nil, false)
+	assert.ErrorIs(t, err, services.ErrInvalidInput)
+	_, err = svc.Get(ctx, uuid.New(), hospitalID, supervisorID)
+	assert.ErrorIs(t, err, services.ErrNotFound)
+}
+
+// buildXLSX writes a minimal workbook whose first sheet holds rows. Strings go to the shared
+// string table, except those starting with "inline:", which become inline strings; numbers are
+// written as numeric cells.
+func buildXLSX(t *testing.T, rows [][]string) []byte {
+	t.Helper()
+	var shared []string
+	var sheet strings.Builder
+	sheet.WriteString(`<?xml version="1.0" encoding="UTF-8"?><worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i, row := range rows {
+		fmt.Fprintf(&sheet, `<row r="%d">`, i+1)
+		for j, v := range row {
+			ref := fmt.Sprintf("%c%d", 'A'+j, i+1)
+			switch {
+			case v == "":
+			case strings.HasPrefix(v, "inline:"):
+				fmt.Fprintf(&sheet, `<c r="%s" t="inlineStr"><is><t>%s</t></is></c>`, ref, strings.TrimPrefix(v, "inline:"))
+			case strings.HasPrefix(v, "num:"):
+				fmt.Fprintf(&sheet, `<c r="%s"><v>%s</v></c>`, ref, strings.TrimPrefix(v, "num:"))
+			default:
+				fmt.Fprintf(&sheet, `<c r="%s" t="s"><v>%d</v></c>`, ref, len(shared))
+				shared = append(shared, v)
+			}
+		}
+		sheet.WriteString(`</row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+
+	var sst strings.Builder
+	sst.WriteString(`<?xml version="1.0" encoding="UTF-8"?><sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	for _, s := range shared {
+		fmt.Fprintf(&sst, `<si><t>%s</t></si>`, s)
+	}
+	sst.WriteString(`</sst>`)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"xl/workbook.xml":            `<?xml version="1.0" encoding="UTF-8"?><workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Patients" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8"?><Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/sharedStrings.xml":       sst.String(),
+		"xl/worksheets/sheet1.xml":   sheet.String(),
+	} {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func TestPatientImportService_XLSX(t *testing.T) {
+	hospitalID, supervisorID := uuid.New(), uuid.New()
+	repo := newMemoryPatientImportRepo()
+	svc := newImportService(repo, supervisorID)
+
+	data := buildXLSX(t, [][]string{
+		{"patient_hn", "first_name_en", "last_name_en", "date_of_birth", "phone_number"},
+		{"HN101", "inline:Jane", "Smith", "num:31092", "num:812345678"},
+		{},
+		{"HN102", "John", "", "1990-05-01", ""},
+	})
+	imp, err := svc.Start(context.Background(), hospitalID, supervisorID, "patients.xlsx", data, nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, entities.ImportCompleted, imp.Status)
+	assert.Equal(t, 2, imp.ImportedRows)
+
+	jane := repo.byHN("HN101")
+	require.NotNil(t, jane)
+	assert.Equal(t, "Jane", jane.FirstNameEN)
+	require.NotNil(t, jane.DateOfBirth)
+	assert.Equal(t, "1985-02-14", jane.DateOfBirth.Format("2006-01-02"))
+	assert.Equal(t, "812345678", jane.PhoneNumber)
+	assert.NotNil(t, repo.byHN("HN102"))
+}
+
+func TestPatientImportService_DryRun(t *testing.T) {
+	hospitalID, supervisorID := uuid.New(), uuid.New()
+	repo := newMemoryPatientImportRepo()
+	svc := newImportService(repo, supervisorID)
+
+	imp, err := svc.Start(context.Background(), hospitalID, supervisorID, "patients.csv", []byte("hn,first_name_en\nHN1,Ann\nHN2,\n"), nil, true)
+	require.NoError(t, err)
+	assert.True(t, imp.DryRun)
+	assert.Equal(t, 1, imp.ImportedRows)
+	assert.Equal(t, 1, imp.FailedRows)
+	assert.Empty(t, repo.patients)
+}
+
+func TestPatientImportService_Background(t *testing.T) {
+	hospitalID, supervisorID := uuid.New(), uuid.New()
+	repo := newMemoryPatientImportRepo()
+	svc := newImportService(repo, supervisorID)
+
+	var file strings.Builder
+	file.WriteString("patient_hn,first_name_en\n")
+	for i := 0; i < 1201; i++ {
+		fmt.Fprintf(&file, "HN%05d,Patient %d\n", i, i)
+	}
+	imp, err := svc.Start(context.Background(), hospitalID, supervisorID, "large.csv", []byte(file.String()), nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, entities.ImportQueued, imp.Status)
+	assert.Equal(t, 1201, imp.TotalRows)
+
+	require.Eventually(t, func() bool {
+		current, err := svc.Get(context.Background(), imp.ID, hospitalID, supervisorID)
+		return err == nil && current.Status == entities.ImportCompleted
+	}, 5*time.Second, 10*time.Millisecond)
+	done, _ := svc.Get(context.Background(), imp.ID, hospitalID, supervisorID)
+	assert.Equal(t, 1201, done.ImportedRows)
+	assert.Equal(t, 1201, done.ProcessedRows)
+	assert.NotNil(t, done.FinishedAt)
+	assert.Equal(t, 3, repo.batches)
+}
+
+type mockPatientImportService struct {
+	StartFunc  func(ctx context.Context, hospitalID, staffID uuid.UUID, fileName string, data []byte, mapping map[string]string, dryRun bool) (*entities.PatientImport, error)
+	GetFunc    func(ctx context.Context, id, hospitalID, staffID uuid.UUID) (*entities.PatientImport, error)
+	ErrorsFunc func(ctx context.Context, id, hospitalID, staffID uuid.UUID) ([]entities.PatientImportError, error)
+}
+
+func (m *mockPatientImportService) Start(ctx context.Context, hospitalID, staffID uuid.UUID, fileName string, data []byte, mapping map[string]string, dryRun bool) (*entities.PatientImport, error) {
+	return m.StartFunc(ctx, hospitalID, staffID, fileName, data, mapping, dryRun)
+}
+
+func (m *mockPatientImportService) Import(ctx context.Context, hospitalID, staffID uuid.UUID, fileName string, data []byte, mapping map[string]string, dryRun bool, progress func(entities.PatientImport)) (*entities.PatientImport, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (m *mockPatientImportService) Get(ctx context.Context, id, hospitalID, staffID uuid.UUID) (*entities.PatientImport, error) {
+	return m.GetFunc(ctx, id, hospitalID, staffID)
+}
+
+func (m *mockPatientImportService) Errors(ctx context.Context, id, hospitalID, staffID uuid.UUID) ([]entities.PatientImportError, error) {
+	return m.ErrorsFunc(ctx, id, hospitalID, staffID)
+}
+
+func (m *mockPatientImportService) FailUnfinished(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func newPatientImportRouter(svc services.PatientImportServiceInterface) *gin.Engine {
+	h := handlers.NewPatientImportHandler(svc, &mockStaffService2{
+		GetHospitalIDByStaffIDFunc: func(staffID string) (uuid.UUID, error) { return uuid.New(), nil },
+	})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/patients/import", h.ImportPatientsHandler)
+	router.GET("/patients/imports/:id", h.GetImportHandler)
+	router.GET("/patients/imports/:id/errors", h.ImportErrorsHandler)
+	return router
+}
+
+func TestPatientImportHandler_ImportPatientsHandler(t *testing.T) {
+	cases := []struct {
+		name           string
+		withFile       bool
+		mapping        string
+		startFunc      func(ctx context.Context, hospitalID, staffID uuid.UUID, fileName string, data []byte, mapping map[string]string, dryRun bool) (*entities.PatientImport, error)
+		wantStatusCode int
+	}{
+		{
+			name:     "positive finished",
+			withFile: true,
+			mapping:  `{"Citizen No":"national_id"}`,
+			startFunc: func(ctx context.Context, hospitalID, staffID uuid.UUID, fileName string, data []byte, mapping map[string]string, dryRun bool) (*entities.PatientImport, error) {
+				if fileName != "patients.csv" || mapping["Citizen No"] != "national_id" || !dryRun {
+					return nil, errors.New("unexpected arguments")
+				}
+				return &entities.PatientImport{ID: uuid.New(), Status: entities.ImportCompleted, TotalRows: 1, ProcessedRows: 1, ImportedRows: 1}, nil
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:     "positive queued",
+			withFile: true,
+			startFunc: func(ctx context.Context, hospitalID, staffID uuid.UUID, fileName string, data []byte, mapping map[string]string, dryRun bool) (*entities.PatientImport, error) {
+				return &entities.PatientImport{ID: uuid.New(), Status: entities.ImportQueued, TotalRows: 5000}, nil
+			},
+			wantStatusCode: http.StatusAccepted,
+		},
+		{
+			name:           "negative missing file",
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:           "negative invalid mapping",
+			withFile:       true,
+			mapping:        `["national_id"]`,
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:     "negative not a supervisor",
+			withFile: true,
+			startFunc: func(ctx context.Context, hospitalID, staffID uuid.UUID, fileName string, data []byte, mapping map[string]string, dryRun bool) (*entities.PatientImport, error) {
+				return nil, fmt.Errorf("%w: only supervisors can import patients", services.ErrForbidden)
+			},
+			wantStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newPatientImportRouter(&mockPatientImportService{StartFunc: tc.startFunc})
+			var buf bytes.Buffer
+			mw := multipart.NewWriter(&buf)
+			if tc.withFile {
+				fw, _ := mw.CreateFormFile("file", "patients.csv")
+				fw.Write([]byte("HN,Citizen No\nHN1,1103700012345\n"))
+			}
+			if tc.mapping != "" {
+				mw.WriteField("mapping", tc.mapping)
+			}
+			mw.WriteField("dry_run", "true")
+			mw.Close()
+
+			req := httptest.NewRequest("POST", "/patients/import", &buf)
+			req.Header.Set("Content-Type", mw.FormDataContentType())
+			req.Header.Set("Authorization", generateValidToken())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			if w.Code == http.StatusAccepted {
+				var body struct {
+					Data struct {
+						ImportID uuid.UUID `json:"import_id"`
+						Percent  int       `json:"percent"`
+					} `json:"data"`
+				}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+				assert.Equal(t, "/api/patients/imports/"+body.Data.ImportID.String(), w.Header().Get("Location"))
+				assert.Equal(t, 0, body.Data.Percent)
+			}
+		})
+	}
+}
+
+func TestPatientImportHandler_GetImportHandler(t *testing.T) {
+	id := uuid.New()
+	router := newPatientImportRouter(&mockPatientImportService{
+		GetFunc: func(ctx context.Context, importID, hospitalID, staffID uuid.UUID) (*entities.PatientImport, error) {
+			if importID != id {
+				return nil, fmt.Errorf("%w: import", services.ErrNotFound)
+			}
+			return &entities.PatientImport{ID: id, Status: entities.ImportRunning, TotalRows: 4000, ProcessedRows: 1000}, nil
+		},
+	})
+
+	req := httptest.NewRequest("GET", "/patients/imports/"+id.String(), nil)
+	req.Header.Set("Authorization", generateValidToken())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"percent":25`)
+
+	req = httptest.NewRequest("GET", "/patients/imports/"+uuid.New().String(), nil)
+	req.Header.Set("Authorization", generateValidToken())
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestPatientImportHandler_ImportErrorsHandler(t *testing.T) {
+	router := newPatientImportRouter(&mockPatientImportService{
+		ErrorsFunc: func(ctx context.Context, id, hospitalID, staffID uuid.UUID) ([]entities.PatientImportError, error) {
+			return []entities.PatientImportError{
+				{Row: 3, PatientHN: "HN2", ColumnName: "date_of_birth", Value: "=cmd()", Message: "date_of_birth must be a date"},
+			}, nil
+		},
+	})
+
+	req := httptest.NewRequest("GET", "/patients/imports/"+uuid.New().String()+"/errors", nil)
+	req.Header.Set("Authorization", generateValidToken())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+	assert.Equal(t, "row,patient_hn,column,value,message\n3,HN2,date_of_birth,'=cmd(),date_of_birth must be a date\n", w.Body.String())
+}
+
+func TestPatientImportRepository_InsertPatients(t *testing.T) {
+	ctx := context.Background()
+	conn := newTestDB(t)
+	hospitalID, staffID := uuid.New(), uuid.New()
+	require.NoError(t, conn.Create(&entities.Hospital{ID: hospitalID, Name: "General"}).Error)
+	nationalID := thaiNationalID("310010000000")
+	require.NoError(t, repository.NewPatientRepository(conn).Create(ctx,
+		&entities.Patient{ID: uuid.New(), HospitalID: hospitalID, PatientHN: "HN900", NationalID: nationalID, Version: 1}, staffID))
+	repo := repository.NewPatientImportRepository(conn)
+
+	batch := []entities.Patient{
+		{ID: uuid.New(), HospitalID: hospitalID, PatientHN: "HN900", Version: 1},
+		{ID: uuid.New(), HospitalID: hospitalID, PatientHN: "HN901", NationalID: nationalID, Version: 1},
+		{ID: uuid.New(), HospitalID: hospitalID, PatientHN: "HN902", Version: 1},
+	}
+	duplicates, err := repo.InsertPatients(ctx, hospitalID, batch, staffID, true)
+	require.NoError(t, err)
+	assert.Len(t, duplicates, 2)
+	var count int64
+	require.NoError(t, conn.Model(&entities.Patient{}).Count(&count).Error)
+	assert.Equal(t, int64(1), count, "a dry run inserts nothing")
+
+	duplicates, err = repo.InsertPatients(ctx, hospitalID, batch, staffID, false)
+	require.NoError(t, err)
+	assert.Equal(t, []repository.DuplicatePatient{
+		{Index: 0, Column: "patient_hn"},
+		{Index: 1, Column: "national_id", RegisteredHN: "HN900"},
+	}, duplicates)
+	require.NoError(t, conn.Model(&entities.Patient{}).Where("patient_hn = ?", "HN902").Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+
+	// The batch inserted before is now registered
+	duplicates, err = repo.InsertPatients(ctx, hospitalID, batch[2:], staffID, false)
+	require.NoError(t, err)
+	assert.Equal(t, []repository.DuplicatePatient{{Index: 0, Column: "patient_hn"}}, duplicates)
+}
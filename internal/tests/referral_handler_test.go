@@ -0,0 +1,219 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/handlers"
+	"go-hospital-api/internal/repository"
+	"go-hospital-api/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockReferralService struct {
+	CreateFunc          func(ctx context.Context, referral *entities.Referral) error
+	AcceptFunc          func(ctx context.Context, id, hospitalID, staffID uuid.UUID, acceptance services.ReferralAcceptance) (*entities.Referral, error)
+	ReferredPatientFunc func(ctx context.Context, id, hospitalID uuid.UUID) (*entities.Referral, error)
+}
+
+func (m *mockReferralService) Create(ctx context.Context, referral *entities.Referral) error {
+	return m.CreateFunc(ctx, referral)
+}
+
+func (m *mockReferralService) Get(ctx context.Context, id, hospitalID uuid.UUID) (*entities.Referral, error) {
+	return nil, nil
+}
+
+func (m *mockReferralService) List(ctx context.Context, hospitalID uuid.UUID, direction, status string) ([]entities.Referral, error) {
+	return nil, nil
+}
+
+func (m *mockReferralService) Accept(ctx context.Context, id, hospitalID, staffID uuid.UUID, acceptance services.ReferralAcceptance) (*entities.Referral, error) {
+	return m.AcceptFunc(ctx, id, hospitalID, staffID, acceptance)
+}
+
+func (m *mockReferralService) Reject(ctx context.Context, id, hospitalID, staffID uuid.UUID, note string) (*entities.Referral, error) {
+	return nil, nil
+}
+
+func (m *mockReferralService) Cancel(ctx context.Context, id, hospitalID uuid.UUID) (*entities.Referral, error) {
+	return nil, nil
+}
+
+func (m *mockReferralService) ReferredPatient(ctx context.Context, id, hospitalID uuid.UUID) (*entities.Referral, error) {
+	return m.ReferredPatientFunc(ctx, id, hospitalID)
+}
+
+func newReferralRouter(svc services.ReferralServiceInterface) *gin.Engine {
+	h := handlers.NewReferralHandler(svc, &mockStaffService2{
+		GetHospitalIDByStaffIDFunc: func(staffID string) (uuid.UUID, error) { return uuid.New(), nil },
+	})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/referrals", h.CreateHandler)
+	router.POST("/referrals/:id/accept", h.AcceptHandler)
+	router.GET("/referrals/:id/patient", h.PatientHandler)
+	return router
+}
+
+func TestReferralHandler_CreateHandler(t *testing.T) {
+	cases := []struct {
+		name           string
+		createFunc     func(ctx context.Context, referral *entities.Referral) error
+		wantStatusCode int
+	}{
+		{
+			name: "positive",
+			createFunc: func(ctx context.Context, referral *entities.Referral) error {
+				referral.ID = uuid.New()
+				referral.Status = entities.ReferralPending
+				return nil
+			},
+			wantStatusCode: http.StatusCreated,
+		},
+		{
+			name: "negative no data sharing consent",
+			createFunc: func(ctx context.Context, referral *entities.Referral) error {
+				return fmt.Errorf("%w for %s", services.ErrConsentRequired, entities.ConsentDataSharing)
+			},
+			wantStatusCode: http.StatusForbidden,
+		},
+		{
+			name: "negative unknown target hospital",
+			createFunc: func(ctx context.Context, referral *entities.Referral) error {
+				return fmt.Errorf("%w: target hospital", services.ErrNotFound)
+			},
+			wantStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newReferralRouter(&mockReferralService{CreateFunc: tc.createFunc})
+			b, _ := json.Marshal(dto.ReferralCreateRequest{
+				PatientID: uuid.New(), TargetHospitalID: uuid.New(), Reason: "cardiac surgery", ClinicalSummary: "NSTEMI, needs CABG",
+			})
+			req := httptest.NewRequest("POST", "/referrals", bytes.NewReader(b))
+			req.Header.Set("Authorization", generateValidToken())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+		})
+	}
+}
+
+func TestReferralHandler_AcceptHandler(t *testing.T) {
+	cases := []struct {
+		name           string
+		acceptFunc     func(ctx context.Context, id, hospitalID, staffID uuid.UUID, acceptance services.ReferralAcceptance) (*entities.Referral, error)
+		wantStatusCode int
+	}{
+		{
+			name: "positive register linked patient",
+			acceptFunc: func(ctx context.Context, id, hospitalID, staffID uuid.UUID, acceptance services.ReferralAcceptance) (*entities.Referral, error) {
+				assert.True(t, acceptance.RegisterPatient)
+				assert.Equal(t, "HN-T-001", acceptance.PatientHN)
+				linked := uuid.New()
+				return &entities.Referral{ID: id, TargetHospitalID: hospitalID, Status: entities.ReferralAccepted, LinkedPatientID: &linked}, nil
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "negative already answered",
+			acceptFunc: func(ctx context.Context, id, hospitalID, staffID uuid.UUID, acceptance services.ReferralAcceptance) (*entities.Referral, error) {
+				return nil, fmt.Errorf("%w: referral is no longer pending", services.ErrConflict)
+			},
+			wantStatusCode: http.StatusConflict,
+		},
+		{
+			name: "negative source hospital cannot accept",
+			acceptFunc: func(ctx context.Context, id, hospitalID, staffID uuid.UUID, acceptance services.ReferralAcceptance) (*entities.Referral, error) {
+				return nil, fmt.Errorf("%w: only the target hospital can answer a referral", services.ErrForbidden)
+			},
+			wantStatusCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newReferralRouter(&mockReferralService{AcceptFunc: tc.acceptFunc})
+			b, _ := json.Marshal(dto.ReferralAcceptRequest{RegisterPatient: true, PatientHN: "HN-T-001"})
+			req := httptest.NewRequest("POST", "/referrals/"+uuid.New().String()+"/accept", bytes.NewReader(b))
+			req.Header.Set("Authorization", generateValidToken())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+		})
+	}
+}
+
+func TestReferralHandler_PatientHandler(t *testing.T) {
+	expires := time.Now().Add(24 * time.Hour)
+	router := newReferralRouter(&mockReferralService{
+		ReferredPatientFunc: func(ctx context.Context, id, hospitalID uuid.UUID) (*entities.Referral, error) {
+			return &entities.Referral{
+				ID:              id,
+				ClinicalSummary: "NSTEMI",
+				AccessExpiresAt: &expires,
+				Patient:         entities.Patient{ID: uuid.New(), PatientHN: "HN001"},
+			}, nil
+		},
+	})
+	req := httptest.NewRequest("GET", "/referrals/"+uuid.New().String()+"/patient", nil)
+	req.Header.Set("Authorization", generateValidToken())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data dto.ReferredPatientResponse `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, "HN001", resp.Data.Patient.PatientHN)
+	assert.Equal(t, "NSTEMI", resp.Data.ClinicalSummary)
+}
+
+type duplicateReferralRepo struct {
+	repository.ReferralRepository
+	referral entities.Referral
+}
+
+func (r *duplicateReferralRepo) GetByID(ctx context.Context, id uuid.UUID) (*entities.Referral, error) {
+	referral := r.referral
+	return &referral, nil
+}
+
+func (r *duplicateReferralRepo) Respond(ctx context.Context, id uuid.UUID, targetHospitalID uuid.UUID, resp repository.ReferralResponse) error {
+	if resp.LinkedPatient != nil && resp.LinkedPatient.PatientHN == "HN-TAKEN" {
+		return repository.ErrDuplicatePatient
+	}
+	return nil
+}
+
+func TestReferralService_AcceptDuplicateLinkedPatient(t *testing.T) {
+	target := uuid.New()
+	repo := &duplicateReferralRepo{referral: entities.Referral{
+		ID:               uuid.New(),
+		SourceHospitalID: uuid.New(),
+		TargetHospitalID: target,
+		Status:           entities.ReferralPending,
+		Patient:          entities.Patient{NationalID: "1234567890123"},
+	}}
+	svc := services.NewReferralService(repo, nil, nil)
+
+	_, err := svc.Accept(context.Background(), repo.referral.ID, target, uuid.New(), services.ReferralAcceptance{RegisterPatient: true, PatientHN: "HN-TAKEN"})
+	assert.ErrorIs(t, err, services.ErrConflict)
+
+	_, err = svc.Accept(context.Background(), repo.referral.ID, target, uuid.New(), services.ReferralAcceptance{RegisterPatient: true, PatientHN: "HN-NEW"})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,401 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/handlers"
+	"go-hospital-api/internal/repository"
+	"go-hospital-api/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type mockRetentionService struct {
+	DeletePatientFunc   func(ctx context.Context, id, hospitalID, staffID uuid.UUID, version int, reason string) error
+	RestorePatientFunc  func(ctx context.Context, id, hospitalID, staffID uuid.UUID) (*entities.Patient, error)
+	DeletedPatientsFunc func(ctx context.Context, hospitalID, staffID uuid.UUID) ([]entities.Patient, error)
+	SetPolicyFunc       func(ctx context.Context, policy *entities.RetentionPolicy) error
+}
+
+func (m *mockRetentionService) DeletePatient(ctx context.Context, id, hospitalID, staffID uuid.UUID, version int, reason string) error {
+	return m.DeletePatientFunc(ctx, id, hospitalID, staffID, version, reason)
+}
+
+func (m *mockRetentionService) RestorePatient(ctx context.Context, id, hospitalID, staffID uuid.UUID) (*entities.Patient, error) {
+	return m.RestorePatientFunc(ctx, id, hospitalID, staffID)
+}
+
+func (m *mockRetentionService) DeletedPatients(ctx context.Context, hospitalID, staffID uuid.UUID) ([]entities.Patient, error) {
+	return m.DeletedPatientsFunc(ctx, hospitalID, staffID)
+}
+
+func (m *mockRetentionService) DeleteStaff(ctx context.Context, id, hospitalID, staffID uuid.UUID, version int) error {
+	return nil
+}
+
+func (m *mockRetentionService) RestoreStaff(ctx context.Context, id, hospitalID, staffID uuid.UUID) (*entities.Staff, error) {
+	return nil, nil
+}
+
+func (m *mockRetentionService) DeleteHospital(ctx context.Context, id, hospitalID, staffID uuid.UUID, reason string) error {
+	return nil
+}
+
+func (m *mockRetentionService) RestoreHospital(ctx context.Context, id, hospitalID, staffID uuid.UUID) (*entities.Hospital, error) {
+	return nil, nil
+}
+
+func (m *mockRetentionService) Policy(ctx context.Context, hospitalID uuid.UUID) (*entities.RetentionPolicy, error) {
+	return &entities.RetentionPolicy{HospitalID: hospitalID, RetentionYears: services.DefaultRetentionYears, Action: entities.RetentionAnonymise}, nil
+}
+
+func (m *mockRetentionService) SetPolicy(ctx context.Context, policy *entities.RetentionPolicy) error {
+	return m.SetPolicyFunc(ctx, policy)
+}
+
+func (m *mockRetentionService) Purge(ctx context.Context, now time.Time) ([]services.PurgeReport, error) {
+	return nil, nil
+}
+
+func newRetentionRouter(svc services.RetentionServiceInterface) *gin.Engine {
+	h := handlers.NewRetentionHandler(svc, &mockStaffService2{
+		GetHospitalIDByStaffIDFunc: func(staffID string) (uuid.UUID, error) { return uuid.New(), nil },
+	})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/patients/deleted", h.DeletedPatientsHandler)
+	router.DELETE("/patients/:id", h.DeletePatientHandler)
+	router.POST("/patients/:id/restore", h.RestorePatientHandler)
+	router.PUT("/retention-policy", h.SetPolicyHandler)
+	return router
+}
+
+func TestRetentionHandler_DeletePatientHandler(t *testing.T) {
+	cases := []struct {
+		name           string
+		deleteFunc     func(ctx context.Context, id, hospitalID, staffID uuid.UUID, version int, reason string) error
+		wantStatusCode int
+	}{
+		{
+			name: "positive",
+			deleteFunc: func(ctx context.Context, id, hospitalID, staffID uuid.UUID, version int, reason string) error {
+				assert.Equal(t, "duplicate registration", reason)
+				assert.Equal(t, 2, version)
+				return nil
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "negative changed since it was read",
+			deleteFunc: func(ctx context.Context, id, hospitalID, staffID uuid.UUID, version int, reason string) error {
+				return fmt.Errorf("%w: record version is not current", services.ErrPreconditionFailed)
+			},
+			wantStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name: "negative not a supervisor",
+			deleteFunc: func(ctx context.Context, id, hospitalID, staffID uuid.UUID, version int, reason string) error {
+				return fmt.Errorf("%w: only supervisors can delete patients", services.ErrForbidden)
+			},
+			wantStatusCode: http.StatusForbidden,
+		},
+		{
+			name: "negative patient of another hospital",
+			deleteFunc: func(ctx context.Context, id, hospitalID, staffID uuid.UUID, version int, reason string) error {
+				return fmt.Errorf("%w: patient", services.ErrNotFound)
+			},
+			wantStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newRetentionRouter(&mockRetentionService{DeletePatientFunc: tc.deleteFunc})
+			b, _ := json.Marshal(dto.PatientDeleteRequest{Reason: "duplicate registration"})
+			req := httptest.NewRequest("DELETE", "/patients/"+uuid.New().String(), bytes.NewReader(b))
+			req.Header.Set("Authorization", generateValidToken())
+			req.Header.Set("If-Match", `"2"`)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+		})
+	}
+}
+
+func TestRetentionHandler_DeletePatientHandler_MissingIfMatch(t *testing.T) {
+	router := newRetentionRouter(&mockRetentionService{})
+	req := httptest.NewRequest("DELETE", "/patients/"+uuid.New().String(), nil)
+	req.Header.Set("Authorization", generateValidToken())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusPreconditionRequired, w.Code)
+}
+
+func TestRetentionHandler_RestorePatientHandler(t *testing.T) {
+	cases := []struct {
+		name           string
+		restoreFunc    func(ctx context.Context, id, hospitalID, staffID uuid.UUID) (*entities.Patient, error)
+		wantStatusCode int
+	}{
+		{
+			name: "positive",
+			restoreFunc: func(ctx context.Context, id, hospitalID, staffID uuid.UUID) (*entities.Patient, error) {
+				return &entities.Patient{ID: id, PatientHN: "HN001", HospitalID: hospitalID}, nil
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name: "negative already anonymised",
+			restoreFunc: func(ctx context.Context, id, hospitalID, staffID uuid.UUID) (*entities.Patient, error) {
+				return nil, fmt.Errorf("%w: restorable patient", services.ErrNotFound)
+			},
+			wantStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newRetentionRouter(&mockRetentionService{RestorePatientFunc: tc.restoreFunc})
+			req := httptest.NewRequest("POST", "/patients/"+uuid.New().String()+"/restore", nil)
+			req.Header.Set("Authorization", generateValidToken())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+		})
+	}
+}
+
+func TestRetentionHandler_DeletedPatientsHandler(t *testing.T) {
+	deletedAt := time.Date(2026, 1, 15, 9, 0, 0, 0, time.UTC)
+	router := newRetentionRouter(&mockRetentionService{
+		DeletedPatientsFunc: func(ctx context.Context, hospitalID, staffID uuid.UUID) ([]entities.Patient, error) {
+			return []entities.Patient{{ID: uuid.New(), PatientHN: "HN001", DeletedAt: gorm.DeletedAt{Time: deletedAt, Valid: true}}}, nil
+		},
+	})
+	req := httptest.NewRequest("GET", "/patients/deleted", nil)
+	req.Header.Set("Authorization", generateValidToken())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data []dto.DeletedPatientResponse `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if assert.Len(t, resp.Data, 1) {
+		assert.True(t, resp.Data[0].PurgeAfter.Equal(deletedAt.AddDate(services.DefaultRetentionYears, 0, 0)))
+	}
+}
+
+func TestRetentionHandler_SetPolicyHandler(t *testing.T) {
+	cases := []struct {
+		name           string
+		ifMatch        string
+		setPolicyFunc  func(ctx context.Context, policy *entities.RetentionPolicy) error
+		wantStatusCode int
+	}{
+		{
+			name:    "positive",
+			ifMatch: `"1"`,
+			setPolicyFunc: func(ctx context.Context, policy *entities.RetentionPolicy) error {
+				assert.Equal(t, entities.RetentionDelete, policy.Action)
+				assert.Equal(t, 1, policy.Version)
+				policy.Version++
+				policy.UpdatedAt = time.Now()
+				return nil
+			},
+			wantStatusCode: http.StatusOK,
+		},
+		{
+			name:    "negative shorter than the legal minimum",
+			ifMatch: `"1"`,
+			setPolicyFunc: func(ctx context.Context, policy *entities.RetentionPolicy) error {
+				return fmt.Errorf("%w: retention_years must be between %d and %d", services.ErrInvalidInput, services.MinRetentionYears, services.MaxRetentionYears)
+			},
+			wantStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:    "negative stale version",
+			ifMatch: `"1"`,
+			setPolicyFunc: func(ctx context.Context, policy *entities.RetentionPolicy) error {
+				return fmt.Errorf("%w: retention policy was changed", services.ErrPreconditionFailed)
+			},
+			wantStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:           "negative missing If-Match",
+			wantStatusCode: http.StatusPreconditionRequired,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newRetentionRouter(&mockRetentionService{SetPolicyFunc: tc.setPolicyFunc})
+			b, _ := json.Marshal(dto.RetentionPolicyRequest{RetentionYears: 10, Action: "Delete"})
+			req := httptest.NewRequest("PUT", "/retention-policy", bytes.NewReader(b))
+			req.Header.Set("Authorization", generateValidToken())
+			if tc.ifMatch != "" {
+				req.Header.Set("If-Match", tc.ifMatch)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+			if tc.wantStatusCode == http.StatusOK {
+				assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+			}
+		})
+	}
+}
+
+func TestRetentionRepository_RemovesPatientCopies(t *testing.T) {
+	ctx := context.Background()
+	for _, action := range []string{entities.RetentionAnonymise, entities.RetentionDelete} {
+		t.Run(action, func(t *testing.T) {
+			conn := newTestDB(t)
+			hospitalID, staffID := uuid.New(), uuid.New()
+			require.NoError(t, conn.Create(&entities.Hospital{ID: hospitalID, Name: "General"}).Error)
+
+			patients := repository.NewPatientRepository(conn)
+			patient := &entities.Patient{ID: uuid.New(), PatientHN: "HN-RET-1", NationalID: "1103700000011", FirstNameEN: "Somchai", HospitalID: hospitalID, Version: 1}
+			other := &entities.Patient{ID: uuid.New(), PatientHN: "HN-RET-2", NationalID: "1103700000029", FirstNameEN: "Somsri", HospitalID: hospitalID, Version: 1}
+			for _, p := range []*entities.Patient{patient, other} {
+				require.NoError(t, patients.Create(ctx, p, staffID))
+			}
+			_, err := patients.SoftDelete(ctx, patient.ID, hospitalID, staffID, 1)
+			require.NoError(t, err)
+
+			var events []entities.OutboxEvent
+			require.NoError(t, conn.Find(&events).Error)
+			require.Len(t, events, 3)
+			for _, e := range events {
+				require.NoError(t, conn.Create(&entities.WebhookDelivery{ID: uuid.New(), SubscriptionID: uuid.New(), EventID: e.ID, HospitalID: hospitalID, EventType: e.Type, Payload: e.Data}).Error)
+			}
+			hl7ID := uuid.New()
+			require.NoError(t, conn.Create(&entities.HL7Message{ID: hl7ID, HospitalID: hospitalID, Raw: "PID|1||HN-RET-1||Somchai", PatientID: &patient.ID}).Error)
+			for key, response := range map[string]string{
+				"create":  `{"id":"` + patient.ID.String() + `"}`,
+				"search":  `[{"national_id":"1103700000011"}]`,
+				"unknown": `{"id":"` + other.ID.String() + `"}`,
+			} {
+				require.NoError(t, conn.Create(&entities.IdempotencyKey{Scope: staffID.String(), Key: key, Method: "POST", Path: "/api/patients", RequestHash: key, Response: []byte(response)}).Error)
+			}
+			require.NoError(t, conn.Create(&entities.IdempotencyKey{Scope: staffID.String(), Key: "merge", Method: "POST", Path: "/api/patients/" + patient.ID.String() + "/merge", RequestHash: "merge"}).Error)
+			imp := entities.PatientImport{ID: uuid.New(), HospitalID: hospitalID, StaffID: staffID, FileName: "patients.csv"}
+			require.NoError(t, conn.Create(&imp).Error)
+			for _, e := range []entities.PatientImportError{
+				{ID: uuid.New(), ImportID: imp.ID, Row: 2, PatientHN: "HN-RET-1", ColumnName: "email", Value: "not-an-email", Message: "invalid email"},
+				{ID: uuid.New(), ImportID: imp.ID, Row: 3, PatientHN: "HN-NEW", ColumnName: "national_id", Value: "1103700000011", Message: "national ID is already registered"},
+				{ID: uuid.New(), ImportID: imp.ID, Row: 4, PatientHN: "HN-RET-2", ColumnName: "gender", Value: "X", Message: "invalid gender"},
+			} {
+				require.NoError(t, conn.Create(&e).Error)
+			}
+
+			retention := repository.NewRetentionRepository(conn)
+			if action == entities.RetentionDelete {
+				require.NoError(t, retention.PurgePatient(ctx, patient.ID))
+			} else {
+				require.NoError(t, retention.AnonymisePatient(ctx, patient.ID, time.Now()))
+			}
+
+			// Only the copies of the other patient are left
+			var remaining []entities.OutboxEvent
+			require.NoError(t, conn.Find(&remaining).Error)
+			require.Len(t, remaining, 1)
+			assert.Equal(t, other.ID, remaining[0].SubjectID)
+			var deliveries []entities.WebhookDelivery
+			require.NoError(t, conn.Find(&deliveries).Error)
+			require.Len(t, deliveries, 1)
+			assert.Equal(t, remaining[0].ID, deliveries[0].EventID)
+			var keys []entities.IdempotencyKey
+			require.NoError(t, conn.Find(&keys).Error)
+			require.Len(t, keys, 1)
+			assert.Equal(t, "unknown", keys[0].Key)
+			var importErrors []entities.PatientImportError
+			require.NoError(t, conn.Find(&importErrors).Error)
+			require.Len(t, importErrors, 1)
+			assert.Equal(t, "HN-RET-2", importErrors[0].PatientHN)
+
+			var messages []entities.HL7Message
+			require.NoError(t, conn.Find(&messages).Error)
+			if action == entities.RetentionDelete {
+				assert.Empty(t, messages)
+			} else {
+				require.Len(t, messages, 1)
+				assert.Empty(t, messages[0].Raw)
+			}
+		})
+	}
+}
+
+func TestRetentionRepository_SavePolicyStaleVersion(t *testing.T) {
+	ctx := context.Background()
+	conn := newTestDB(t)
+	hospitalID := uuid.New()
+	require.NoError(t, conn.Create(&entities.Hospital{ID: hospitalID, Name: "General"}).Error)
+	repo := repository.NewRetentionRepository(conn)
+	policy := func(version int) *entities.RetentionPolicy {
+		return &entities.RetentionPolicy{HospitalID: hospitalID, RetentionYears: 10, Action: entities.RetentionDelete, UpdatedByID: uuid.New(), Version: version}
+	}
+
+	// Only one of two writers that saw the default policy gets to save it
+	first := policy(1)
+	require.NoError(t, repo.SavePolicy(ctx, first))
+	assert.Equal(t, 2, first.Version)
+	second := policy(1)
+	assert.ErrorIs(t, repo.SavePolicy(ctx, second), repository.ErrStaleVersion)
+	assert.Equal(t, 1, second.Version)
+
+	third := policy(2)
+	third.RetentionYears = 20
+	require.NoError(t, repo.SavePolicy(ctx, third))
+	stored, err := repo.GetPolicy(ctx, hospitalID)
+	require.NoError(t, err)
+	assert.Equal(t, 3, stored.Version)
+	assert.Equal(t, 20, stored.RetentionYears)
+}
+
+func TestRetentionService_DeleteAndRestoreHospital(t *testing.T) {
+	ctx := context.Background()
+	conn := newTestDB(t)
+	hospitalID, otherID := uuid.New(), uuid.New()
+	require.NoError(t, conn.Create(&entities.Hospital{ID: hospitalID, Name: "General"}).Error)
+	require.NoError(t, conn.Create(&entities.Hospital{ID: otherID, Name: "Other"}).Error)
+	supervisor := entities.Staff{ID: uuid.New(), HospitalID: hospitalID, Username: "admin1", Role: entities.RoleSupervisor}
+	nurse := entities.Staff{ID: uuid.New(), HospitalID: hospitalID, Username: "nurse1", Role: entities.RoleNurse}
+	require.NoError(t, conn.Create(&supervisor).Error)
+	require.NoError(t, conn.Create(&nurse).Error)
+	svc := services.NewRetentionService(repository.NewRetentionRepository(conn), repository.NewPatientRepository(conn),
+		repository.NewStaffRepository(conn), repository.NewAuditRepository(conn))
+
+	assert.ErrorIs(t, svc.DeleteHospital(ctx, hospitalID, hospitalID, nurse.ID, ""), services.ErrForbidden)
+	assert.ErrorIs(t, svc.DeleteHospital(ctx, otherID, hospitalID, supervisor.ID, ""), services.ErrNotFound)
+	_, err := svc.RestoreHospital(ctx, hospitalID, hospitalID, supervisor.ID)
+	assert.ErrorIs(t, err, services.ErrNotFound)
+
+	require.NoError(t, svc.DeleteHospital(ctx, hospitalID, hospitalID, supervisor.ID, "merged into Other"))
+	assert.ErrorIs(t, conn.First(&entities.Hospital{}, "id = ?", hospitalID).Error, gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, svc.DeleteHospital(ctx, hospitalID, hospitalID, supervisor.ID, ""), services.ErrNotFound)
+
+	// The hospital's staff are kept, so its supervisor can restore it
+	restored, err := svc.RestoreHospital(ctx, hospitalID, hospitalID, supervisor.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "General", restored.Name)
+	require.NoError(t, conn.First(&entities.Hospital{}, "id = ?", hospitalID).Error)
+
+	var actions []string
+	require.NoError(t, conn.Model(&entities.AuditEvent{}).Where("hospital_id = ? AND priority = ?", hospitalID, entities.AuditHigh).
+		Order("created_at").Pluck("action", &actions).Error)
+	assert.Equal(t, []string{services.AuditActionHospitalDelete, services.AuditActionHospitalRestore}, actions)
+}
@@ -2,11 +2,15 @@ package tests
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"go-hospital-api/internal/dto"
 	"go-hospital-api/internal/entities"
 	"go-hospital-api/internal/handlers"
+	"go-hospital-api/internal/repository"
+	"go-hospital-api/internal/services"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -16,11 +20,15 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
 )
 
 type mockStaffService struct {
-	CreateFunc func(*entities.Staff, string) error
-	LoginFunc  func(string, string, uuid.UUID) (*entities.Staff, error)
+	CreateFunc  func(*entities.Staff, string) error
+	LoginFunc   func(string, string, uuid.UUID) (*entities.Staff, error)
+	GetByIDFunc func(uuid.UUID, uuid.UUID) (*entities.Staff, error)
+	SetRoleFunc func(ctx context.Context, id, hospitalID, staffID uuid.UUID, role string, version int) (*entities.Staff, error)
 }
 
 func (m *mockStaffService) Create(staff *entities.Staff, password string) error {
@@ -32,6 +40,12 @@ func (m *mockStaffService) Login(username, password string, hospitalID uuid.UUID
 func (m *mockStaffService) GetHospitalIDByStaffID(staffID string) (uuid.UUID, error) {
 	return uuid.New(), nil
 }
+func (m *mockStaffService) GetByID(staffID uuid.UUID, hospitalID uuid.UUID) (*entities.Staff, error) {
+	return m.GetByIDFunc(staffID, hospitalID)
+}
+func (m *mockStaffService) SetRole(ctx context.Context, id, hospitalID, staffID uuid.UUID, role string, version int) (*entities.Staff, error) {
+	return m.SetRoleFunc(ctx, id, hospitalID, staffID, role, version)
+}
 
 func TestStaffHandler_CreateHandler_Positive(t *testing.T) {
 	gin.SetMode(gin.TestMode)
@@ -83,6 +97,29 @@ func TestStaffHandler_CreateHandler_Negative_EmptyHospitalID(t *testing.T) {
 	assert.Equal(t, "error", response["status"], "expected error status")
 }
 
+func TestStaffHandler_CreateHandler_Negative_UnknownRole(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	h := handlers.NewStaffHandler(&mockStaffService{
+		CreateFunc: func(staff *entities.Staff, password string) error {
+			return fmt.Errorf("%w: unknown role %q", services.ErrInvalidInput, staff.Role)
+		},
+	})
+	router.POST("/staff", h.CreateHandler)
+	body := dto.StaffCreateRequest{
+		Username:   "testuser",
+		Password:   "password",
+		Role:       "janitor",
+		HospitalID: uuid.New(),
+	}
+	b, _ := json.Marshal(body)
+	req := httptest.NewRequest("POST", "/staff", bytes.NewReader(b))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code, "expected status 400")
+}
+
 func TestStaffHandler_LoginHandler_Positive(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	router := gin.New()
@@ -128,3 +165,208 @@ func TestStaffHandler_LoginHandler_Negative_WrongPassword(t *testing.T) {
 	router.ServeHTTP(w, req)
 	assert.Equal(t, http.StatusUnauthorized, w.Code, "expected status 401")
 }
+
+func TestStaffHandler_GetHandler_Positive_ETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	h := handlers.NewStaffHandler(&mockStaffService{
+		GetByIDFunc: func(staffID, hospitalID uuid.UUID) (*entities.Staff, error) {
+			return &entities.Staff{ID: staffID, Username: "nurse1", HospitalID: hospitalID, Version: 5}, nil
+		},
+	})
+	router.GET("/staff/:id", h.GetHandler)
+	req := httptest.NewRequest("GET", "/staff/"+uuid.New().String(), nil)
+	req.Header.Set("Authorization", generateValidToken())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"5"`, w.Header().Get("ETag"))
+}
+
+func TestStaffHandler_GetHandler_Negative_OtherHospital(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	h := handlers.NewStaffHandler(&mockStaffService{
+		GetByIDFunc: func(staffID, hospitalID uuid.UUID) (*entities.Staff, error) {
+			return nil, fmt.Errorf("%w: staff", services.ErrNotFound)
+		},
+	})
+	router.GET("/staff/:id", h.GetHandler)
+	req := httptest.NewRequest("GET", "/staff/"+uuid.New().String(), nil)
+	req.Header.Set("Authorization", generateValidToken())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// memoryStaffRepo is an in-memory repository.StaffRepository
+type memoryStaffRepo struct {
+	staff  map[uuid.UUID]entities.Staff
+	events []entities.AuditEvent
+}
+
+func (m *memoryStaffRepo) Create(staff *entities.Staff) error {
+	staff.Version = 1
+	m.staff[staff.ID] = *staff
+	return nil
+}
+
+func (m *memoryStaffRepo) GetByUsername(username string, hospitalID uuid.UUID) (*entities.Staff, error) {
+	for _, s := range m.staff {
+		if s.Username == username && s.HospitalID == hospitalID {
+			return &s, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memoryStaffRepo) GetHospitalIDByStaffID(staffID string) (uuid.UUID, error) {
+	id, _ := uuid.Parse(staffID)
+	s, ok := m.staff[id]
+	if !ok {
+		return uuid.Nil, gorm.ErrRecordNotFound
+	}
+	return s.HospitalID, nil
+}
+
+func (m *memoryStaffRepo) GetByID(staffID uuid.UUID) (*entities.Staff, error) {
+	s, ok := m.staff[staffID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &s, nil
+}
+
+func (m *memoryStaffRepo) Delete(staffID uuid.UUID, hospitalID uuid.UUID, version int) (bool, error) {
+	return false, nil
+}
+
+func (m *memoryStaffRepo) Restore(staffID uuid.UUID, hospitalID uuid.UUID) (bool, error) {
+	return false, nil
+}
+
+func (m *memoryStaffRepo) UpdateRole(staffID uuid.UUID, hospitalID uuid.UUID, role string, version int, event *entities.AuditEvent) (*entities.Staff, error) {
+	s, ok := m.staff[staffID]
+	if !ok || s.HospitalID != hospitalID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if s.Version != version {
+		return nil, repository.ErrStaleVersion
+	}
+	s.Role = role
+	s.Version++
+	m.staff[staffID] = s
+	m.events = append(m.events, *event)
+	return &s, nil
+}
+
+func TestStaffHandler_CreateHandler_PublicRoleIsStaff(t *testing.T) {
+	hospitalID := uuid.New()
+	repo := &memoryStaffRepo{staff: map[uuid.UUID]entities.Staff{}}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	h := handlers.NewStaffHandler(services.NewStaffService(repo))
+	router.POST("/staff", h.CreateHandler)
+	create := func(username, role string) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(dto.StaffCreateRequest{Username: username, Password: "secret", Role: role, HospitalID: hospitalID})
+		req := httptest.NewRequest("POST", "/staff", bytes.NewReader(b))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, role := range []string{entities.RoleSupervisor, entities.RoleDoctor, entities.RoleNurse} {
+		w := create("self-"+role, role)
+		assert.Equal(t, http.StatusBadRequest, w.Code, role)
+	}
+	assert.Empty(t, repo.staff)
+
+	for _, role := range []string{"", entities.RoleStaff} {
+		w := create("clerk"+role, role)
+		require.Equal(t, http.StatusOK, w.Code, role)
+		var resp struct{ Data dto.StaffResponse }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		assert.Equal(t, entities.RoleStaff, resp.Data.Role)
+		assert.Equal(t, entities.RoleStaff, repo.staff[resp.Data.ID].Role)
+	}
+}
+
+func TestStaffHandler_SetRoleHandler(t *testing.T) {
+	hospitalID, otherHospitalID := uuid.New(), uuid.New()
+	supervisorID, clerkID, otherID := uuid.New(), uuid.New(), uuid.New()
+	repo := &memoryStaffRepo{staff: map[uuid.UUID]entities.Staff{
+		supervisorID: {ID: supervisorID, Role: entities.RoleSupervisor, HospitalID: hospitalID, Version: 1},
+		clerkID:      {ID: clerkID, Role: entities.RoleStaff, HospitalID: hospitalID, Version: 1},
+		otherID:      {ID: otherID, Role: entities.RoleStaff, HospitalID: otherHospitalID, Version: 1},
+	}}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	h := handlers.NewStaffHandler(services.NewStaffService(repo))
+	router.PUT("/staff/:id/role", h.SetRoleHandler)
+	setRole := func(callerID, id uuid.UUID, ifMatch, role string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("PUT", "/staff/"+id.String()+"/role", bytes.NewBufferString(`{"role":"`+role+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", tokenForStaff(callerID))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusForbidden, setRole(clerkID, supervisorID, `"1"`, entities.RoleStaff).Code, "not a supervisor")
+	assert.Equal(t, http.StatusBadRequest, setRole(supervisorID, supervisorID, `"1"`, entities.RoleStaff).Code, "own role")
+	assert.Equal(t, http.StatusPreconditionRequired, setRole(supervisorID, clerkID, "", entities.RoleDoctor).Code)
+	assert.Equal(t, http.StatusBadRequest, setRole(supervisorID, clerkID, `"1"`, "janitor").Code)
+	assert.Equal(t, http.StatusNotFound, setRole(supervisorID, otherID, `"1"`, entities.RoleDoctor).Code, "other hospital")
+
+	w := setRole(supervisorID, clerkID, `"1"`, entities.RoleDoctor)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+	assert.Equal(t, entities.RoleDoctor, repo.staff[clerkID].Role)
+	require.Len(t, repo.events, 1)
+	assert.Equal(t, services.AuditActionStaffRole, repo.events[0].Action)
+	assert.Equal(t, entities.AuditHigh, repo.events[0].Priority)
+	assert.Equal(t, fmt.Sprintf("staff %s: %s -> %s", clerkID, entities.RoleStaff, entities.RoleDoctor), repo.events[0].Details)
+
+	assert.Equal(t, http.StatusPreconditionFailed, setRole(supervisorID, clerkID, `"1"`, entities.RoleNurse).Code, "stale version")
+	assert.Equal(t, entities.RoleDoctor, repo.staff[clerkID].Role)
+	assert.Len(t, repo.events, 1)
+}
+
+func TestStaffRepository_UpdateRoleRecordsAuditEvent(t *testing.T) {
+	conn := newTestDB(t)
+	hospitalID := uuid.New()
+	require.NoError(t, conn.Create(&entities.Hospital{ID: hospitalID, Name: "General"}).Error)
+	staff := entities.Staff{ID: uuid.New(), HospitalID: hospitalID, Username: "clerk1", Role: entities.RoleStaff}
+	require.NoError(t, conn.Create(&staff).Error)
+	repo := repository.NewStaffRepository(conn)
+	event := func() *entities.AuditEvent {
+		e := entities.AuditEvent{ID: uuid.New(), HospitalID: hospitalID, StaffID: uuid.New(), Action: services.AuditActionStaffRole, Priority: entities.AuditHigh}
+		return &e
+	}
+
+	updated, err := repo.UpdateRole(staff.ID, hospitalID, entities.RoleDoctor, 1, event())
+	require.NoError(t, err)
+	assert.Equal(t, 2, updated.Version)
+
+	// A stale version changes nothing and records nothing
+	_, err = repo.UpdateRole(staff.ID, hospitalID, entities.RoleNurse, 1, event())
+	assert.ErrorIs(t, err, repository.ErrStaleVersion)
+
+	// An event that cannot be stored rolls the role change back
+	duplicate := event()
+	require.NoError(t, conn.Create(duplicate).Error)
+	_, err = repo.UpdateRole(staff.ID, hospitalID, entities.RoleNurse, 2, duplicate)
+	assert.Error(t, err)
+
+	var stored entities.Staff
+	require.NoError(t, conn.First(&stored, "id = ?", staff.ID).Error)
+	assert.Equal(t, entities.RoleDoctor, stored.Role)
+	assert.Equal(t, 2, stored.Version)
+	var events int64
+	require.NoError(t, conn.Model(&entities.AuditEvent{}).Where("action = ?", services.AuditActionStaffRole).Count(&events).Error)
+	assert.Equal(t, int64(2), events)
+}
@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"go-hospital-api/internal/db"
+	"regexp"
+	"strings"
+	"testing"
+
+	sqlitedriver "github.com/glebarez/go-sqlite"
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func init() {
+	// Transactions take Postgres advisory locks, which a single connection does not need
+	sqlitedriver.MustRegisterDeterministicScalarFunction("hashtext", 1, func(ctx *sqlitedriver.FunctionContext, args []driver.Value) (driver.Value, error) {
+		return int64(0), nil
+	})
+	sqlitedriver.MustRegisterScalarFunction("pg_advisory_xact_lock", 1, func(ctx *sqlitedriver.FunctionContext, args []driver.Value) (driver.Value, error) {
+		return nil, nil
+	})
+	// Postgres matches LIKE against bytea as well as text; SQLite never matches a blob
+	sqlitedriver.MustRegisterDeterministicScalarFunction("like", 2, func(ctx *sqlitedriver.FunctionContext, args []driver.Value) (driver.Value, error) {
+		if args[0] == nil || args[1] == nil {
+			return nil, nil
+		}
+		pattern := regexp.QuoteMeta(sqlText(args[0]))
+		pattern = strings.NewReplacer("%", ".*", "_", ".").Replace(pattern)
+		return regexp.MustCompile("(?is)^" + pattern + "$").MatchString(sqlText(args[1])), nil
+	})
+}
+
+func sqlText(v driver.Value) string {
+	if b, ok := v.([]byte); ok {
+		return string(b)
+	}
+	return fmt.Sprint(v)
+}
+
+// newTestDB opens an in-memory SQLite database with every table, for repository tests. SQLite has
+// no uuid_generate_v4, so those column defaults are dropped and rows need their IDs set.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	conn, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{TranslateError: true, Logger: logger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := conn.DB()
+	require.NoError(t, err)
+	// Every connection would open a database of its own
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	for _, model := range db.Models {
+		stmt := &gorm.Statement{DB: conn}
+		require.NoError(t, stmt.Parse(model))
+		for _, field := range stmt.Schema.Fields {
+			if strings.Contains(field.DefaultValue, "uuid_generate_v4") {
+				field.DefaultValue, field.HasDefaultValue, field.DefaultValueInterface = "", false, nil
+			}
+		}
+	}
+	require.NoError(t, conn.AutoMigrate(db.Models...))
+	return conn
+}
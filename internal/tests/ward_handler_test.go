@@ -0,0 +1,177 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/handlers"
+	"go-hospital-api/internal/repository"
+	"go-hospital-api/internal/services"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockWardService struct {
+	AdmitFunc    func(ctx context.Context, admission *entities.Admission) error
+	BedBoardFunc func(ctx context.Context, hospitalID uuid.UUID) ([]services.WardOccupancy, error)
+}
+
+func (m *mockWardService) CreateWard(ctx context.Context, ward *entities.Ward) error {
+	return nil
+}
+
+func (m *mockWardService) ListWards(ctx context.Context, hospitalID uuid.UUID) ([]entities.Ward, error) {
+	return nil, nil
+}
+
+func (m *mockWardService) CreateBed(ctx context.Context, bed *entities.Bed) error {
+	return nil
+}
+
+func (m *mockWardService) UpdateBedStatus(ctx context.Context, id, hospitalID uuid.UUID, status string, version int) (*entities.Bed, error) {
+	return nil, nil
+}
+
+func (m *mockWardService) Admit(ctx context.Context, admission *entities.Admission) error {
+	return m.AdmitFunc(ctx, admission)
+}
+
+func (m *mockWardService) Transfer(ctx context.Context, admissionID, hospitalID, toBedID, staffID uuid.UUID) (*entities.Admission, error) {
+	return nil, nil
+}
+
+func (m *mockWardService) Discharge(ctx context.Context, admissionID, hospitalID, staffID uuid.UUID, note string) (*entities.Admission, error) {
+	return nil, nil
+}
+
+func (m *mockWardService) BedBoard(ctx context.Context, hospitalID uuid.UUID) ([]services.WardOccupancy, error) {
+	return m.BedBoardFunc(ctx, hospitalID)
+}
+
+func newWardRouter(svc services.WardServiceInterface) *gin.Engine {
+	h := handlers.NewWardHandler(svc, &mockStaffService2{
+		GetHospitalIDByStaffIDFunc: func(staffID string) (uuid.UUID, error) { return uuid.New(), nil },
+	})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/patients/:id/admissions", h.AdmitHandler)
+	router.GET("/bed-board", h.BedBoardHandler)
+	return router
+}
+
+func TestWardHandler_AdmitHandler(t *testing.T) {
+	cases := []struct {
+		name           string
+		admitFunc      func(ctx context.Context, admission *entities.Admission) error
+		wantStatusCode int
+	}{
+		{
+			name: "positive",
+			admitFunc: func(ctx context.Context, admission *entities.Admission) error {
+				admission.ID = uuid.New()
+				admission.Status = entities.AdmissionActive
+				return nil
+			},
+			wantStatusCode: http.StatusCreated,
+		},
+		{
+			name: "negative bed already taken",
+			admitFunc: func(ctx context.Context, admission *entities.Admission) error {
+				return fmt.Errorf("%w: bed is not available", services.ErrConflict)
+			},
+			wantStatusCode: http.StatusConflict,
+		},
+		{
+			name: "negative bed in another hospital",
+			admitFunc: func(ctx context.Context, admission *entities.Admission) error {
+				return fmt.Errorf("%w: bed", services.ErrNotFound)
+			},
+			wantStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newWardRouter(&mockWardService{AdmitFunc: tc.admitFunc})
+			b, _ := json.Marshal(dto.AdmitRequest{BedID: uuid.New(), Reason: "pneumonia"})
+			req := httptest.NewRequest("POST", "/patients/"+uuid.New().String()+"/admissions", bytes.NewReader(b))
+			req.Header.Set("Authorization", generateValidToken())
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tc.wantStatusCode, w.Code)
+		})
+	}
+}
+
+func TestWardHandler_BedBoardHandler(t *testing.T) {
+	occupied := entities.Bed{ID: uuid.New(), Label: "A-01", Status: entities.BedOccupied}
+	free := entities.Bed{ID: uuid.New(), Label: "A-02", Status: entities.BedAvailable}
+	router := newWardRouter(&mockWardService{
+		BedBoardFunc: func(ctx context.Context, hospitalID uuid.UUID) ([]services.WardOccupancy, error) {
+			return []services.WardOccupancy{{
+				Ward:   entities.Ward{ID: uuid.New(), Code: "MED1", Name: "Medicine 1", Beds: []entities.Bed{occupied, free}},
+				Counts: map[string]int{entities.BedOccupied: 1, entities.BedAvailable: 1},
+				Admissions: map[uuid.UUID]entities.Admission{occupied.ID: {
+					ID:         uuid.New(),
+					BedID:      occupied.ID,
+					Patient:    entities.Patient{PatientHN: "HN001", FirstNameTH: "สมชาย", LastNameTH: "ใจดี"},
+					AdmittedAt: time.Now(),
+				}},
+			}}, nil
+		},
+	})
+	req := httptest.NewRequest("GET", "/bed-board", nil)
+	req.Header.Set("Authorization", generateValidToken())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp struct {
+		Data []dto.BedBoardWard `json:"data"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Len(t, resp.Data, 1)
+	assert.Equal(t, 2, resp.Data[0].TotalBeds)
+	assert.Equal(t, 1, resp.Data[0].Occupied)
+	assert.Equal(t, 0.5, resp.Data[0].OccupancyRate)
+	assert.Equal(t, "HN001", resp.Data[0].Beds[0].PatientHN)
+	assert.Nil(t, resp.Data[0].Beds[1].PatientID)
+}
+
+func TestWardService_UpdateBedStatus_StaleVersion(t *testing.T) {
+	ctx := context.Background()
+	conn := newTestDB(t)
+	hospitalID := uuid.New()
+	require.NoError(t, conn.Create(&entities.Hospital{ID: hospitalID, Name: "General"}).Error)
+	ward := entities.Ward{ID: uuid.New(), HospitalID: hospitalID, Code: "W1", Name: "Medicine"}
+	require.NoError(t, conn.Create(&ward).Error)
+	svc := services.NewWardService(repository.NewWardRepository(conn), repository.NewPatientRepository(conn))
+	bed := entities.Bed{ID: uuid.New(), WardID: ward.ID, HospitalID: hospitalID, Label: "B1"}
+	require.NoError(t, svc.CreateBed(ctx, &bed))
+	assert.Equal(t, 1, bed.Version)
+
+	blocked, err := svc.UpdateBedStatus(ctx, bed.ID, hospitalID, entities.BedBlocked, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, blocked.Version)
+
+	// A second writer that still saw the bed at version 1 cannot make it available again
+	_, err = svc.UpdateBedStatus(ctx, bed.ID, hospitalID, entities.BedAvailable, 1)
+	assert.ErrorIs(t, err, services.ErrPreconditionFailed)
+	var stored entities.Bed
+	require.NoError(t, conn.First(&stored, "id = ?", bed.ID).Error)
+	assert.Equal(t, entities.BedBlocked, stored.Status)
+
+	available, err := svc.UpdateBedStatus(ctx, bed.ID, hospitalID, entities.BedAvailable, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 3, available.Version)
+}
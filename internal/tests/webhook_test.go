@@ -0,0 +1,392 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/entities"
+	"go-hospital-api/internal/handlers"
+	"go-hospital-api/internal/repository"
+	"go-hospital-api/internal/services"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+// memoryWebhookRepo is an in-memory repository.WebhookRepository
+type memoryWebhookRepo struct {
+	mu         sync.Mutex
+	subs       []entities.WebhookSubscription
+	deliveries []entities.WebhookDelivery
+}
+
+func outboxEvent(hospitalID uuid.UUID, eventType string, data any) entities.OutboxEvent {
+	b, _ := json.Marshal(data)
+	now := time.Now()
+	return entities.OutboxEvent{ID: uuid.New(), HospitalID: hospitalID, Type: eventType, SubjectID: uuid.New(), Data: string(b), CreatedAt: now, NextAttemptAt: now}
+}
+
+func (m *memoryWebhookRepo) CreateSubscription(ctx context.Context, sub *entities.WebhookSubscription) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	sub.CreatedAt = time.Now()
+	m.subs = append(m.subs, *sub)
+	return nil
+}
+
+func (m *memoryWebhookRepo) ListSubscriptions(ctx context.Context, hospitalID uuid.UUID) ([]entities.WebhookSubscription, error) {
+	var subs []entities.WebhookSubscription
+	for _, s := range m.subs {
+		if s.HospitalID == hospitalID {
+			subs = append(subs, s)
+		}
+	}
+	return subs, nil
+}
+
+func (m *memoryWebhookRepo) GetSubscription(ctx context.Context, id uuid.UUID) (*entities.WebhookSubscription, error) {
+	for _, s := range m.subs {
+		if s.ID == id {
+			return &s, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memoryWebhookRepo) DeleteSubscription(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID, version int) (bool, error) {
+	for i, s := range m.subs {
+		if s.ID == id && s.HospitalID == hospitalID {
+			if s.Version != version {
+				return false, repository.ErrStaleVersion
+			}
+			m.subs = append(m.subs[:i], m.subs[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *memoryWebhookRepo) CreateDeliveries(ctx context.Context, deliveries []entities.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, d := range deliveries {
+		if !slices.ContainsFunc(m.deliveries, func(e entities.WebhookDelivery) bool {
+			return e.SubscriptionID == d.SubscriptionID && e.EventID == d.EventID
+		}) {
+			m.deliveries = append(m.deliveries, d)
+		}
+	}
+	return nil
+}
+
+func (m *memoryWebhookRepo) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]entities.WebhookDelivery, error) {
+	var due []entities.WebhookDelivery
+	for i := range m.deliveries {
+		d := &m.deliveries[i]
+		if d.Status == entities.WebhookPending && !d.NextAttemptAt.After(now) && len(due) < limit {
+			due = append(due, *d)
+			d.NextAttemptAt = now.Add(lease)
+		}
+	}
+	return due, nil
+}
+
+func (m *memoryWebhookRepo) SaveDelivery(ctx context.Context, delivery *entities.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.deliveries {
+		if m.deliveries[i].ID == delivery.ID {
+			m.deliveries[i] = *delivery
+		}
+	}
+	return nil
+}
+
+func (m *memoryWebhookRepo) GetDelivery(ctx context.Context, id uuid.UUID, hospitalID uuid.UUID) (*entities.WebhookDelivery, error) {
+	for _, d := range m.deliveries {
+		if d.ID == id && d.HospitalID == hospitalID {
+			return &d, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (m *memoryWebhookRepo) ListDeliveries(ctx context.Context, hospitalID uuid.UUID, status string, subscriptionID *uuid.UUID, limit int) ([]entities.WebhookDelivery, error) {
+	var list []entities.WebhookDelivery
+	for _, d := range m.deliveries {
+		if d.HospitalID == hospitalID && (status == "" || d.Status == status) && (subscriptionID == nil || d.SubscriptionID == *subscriptionID) {
+			list = append(list, d)
+		}
+	}
+	return list, nil
+}
+
+func TestWebhookService_CreateSubscription(t *testing.T) {
+	hospitalID, supervisorID, nurseID := uuid.New(), uuid.New(), uuid.New()
+	staffRepo := &roleStaffRepo{roles: map[uuid.UUID]string{supervisorID: entities.RoleSupervisor, nurseID: entities.RoleNurse}}
+	repo := &memoryWebhookRepo{}
+	svc := services.NewWebhookService(repo, staffRepo, nil)
+	ctx := context.Background()
+
+	sub := entities.WebhookSubscription{HospitalID: hospitalID, URL: "https://lab.example.com/hooks", EventTypes: "patient.created, patient.merged,patient.created"}
+	require.NoError(t, svc.CreateSubscription(ctx, &sub, supervisorID))
+	assert.NotEqual(t, uuid.Nil, sub.ID)
+	assert.True(t, strings.HasPrefix(sub.Secret, "whsec_"))
+	assert.Len(t, sub.Secret, len("whsec_")+48)
+	assert.Equal(t, "patient.created,patient.merged", sub.EventTypes)
+	assert.Equal(t, supervisorID, sub.CreatedByID)
+
+	cases := []struct {
+		name    string
+		sub     entities.WebhookSubscription
+		staffID uuid.UUID
+		wantErr error
+	}{
+		{"negative not a supervisor", entities.WebhookSubscription{URL: "https://lab.example.com/hooks"}, nurseID, services.ErrForbidden},
+		{"negative relative URL", entities.WebhookSubscription{URL: "/hooks"}, supervisorID, services.ErrInvalidInput},
+		{"negative other scheme", entities.WebhookSubscription{URL: "ftp://lab.example.com/hooks"}, supervisorID, services.ErrInvalidInput},
+		{"negative unknown event type", entities.WebhookSubscription{URL: "https://lab.example.com/hooks", EventTypes: "patient.admitted"}, supervisorID, services.ErrInvalidInput},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := svc.CreateSubscription(ctx, &tc.sub, tc.staffID)
+			assert.ErrorIs(t, err, tc.wantErr)
+		})
+	}
+	assert.Len(t, repo.subs, 1)
+}
+
+func TestWebhookService_Dispatch(t *testing.T) {
+	hospitalID, otherHospitalID, supervisorID := uuid.New(), uuid.New(), uuid.New()
+	staffRepo := &roleStaffRepo{roles: map[uuid.UUID]string{supervisorID: entities.RoleSupervisor}}
+
+	var (
+		mu       sync.Mutex
+		received []*http.Request
+		bodies   [][]byte
+		failures = 2
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, r)
+		bodies = append(bodies, body)
+		if failures > 0 {
+			failures--
+			http.Error(w, "lab system busy", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	repo := &memoryWebhookRepo{}
+	svc := services.NewWebhookService(repo, staffRepo, server.Client())
+	ctx := context.Background()
+	sub := entities.WebhookSubscription{HospitalID: hospitalID, URL: server.URL + "/hooks", EventTypes: "patient.created"}
+	require.NoError(t, svc.CreateSubscription(ctx, &sub, supervisorID))
+
+	event := outboxEvent(hospitalID, entities.EventPatientCreated, dto.PatientEventData{PatientID: uuid.New(), PatientHN: "HN0001", Version: 1})
+	sink := services.NewWebhookSink(repo)
+	for _, e := range []entities.OutboxEvent{
+		event,
+		outboxEvent(hospitalID, entities.EventPatientUpdated, dto.PatientEventData{PatientID: uuid.New(), PatientHN: "HN0002"}),
+		outboxEvent(otherHospitalID, entities.EventPatientCreated, dto.PatientEventData{PatientID: uuid.New(), PatientHN: "HN0003"}),
+		event,
+	} {
+		require.NoError(t, sink.Handle(ctx, e))
+	}
+	// Only the matching event of the subscriber's hospital becomes a delivery, once
+	require.Len(t, repo.deliveries, 1)
+
+	now := time.Now().Add(time.Second)
+	delivered, err := svc.Dispatch(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 0, delivered)
+	d := repo.deliveries[0]
+	assert.Equal(t, event.ID, d.EventID)
+	assert.Equal(t, entities.WebhookPending, d.Status)
+	assert.Equal(t, 1, d.Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, d.LastStatusCode)
+	assert.Equal(t, "HTTP 503: lab system busy", d.LastError)
+	assert.WithinDuration(t, now.Add(30*time.Second), d.NextAttemptAt, time.Millisecond)
+
+	// Not due yet
+	delivered, err = svc.Dispatch(ctx, now.Add(10*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 0, delivered)
+	assert.Len(t, received, 1)
+
+	_, err = svc.Dispatch(ctx, now.Add(30*time.Second))
+	require.NoError(t, err)
+	assert.WithinDuration(t, now.Add(90*time.Second), repo.deliveries[0].NextAttemptAt, time.Millisecond)
+
+	delivered, err = svc.Dispatch(ctx, now.Add(90*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	d = repo.deliveries[0]
+	assert.Equal(t, entities.WebhookDelivered, d.Status)
+	assert.Equal(t, 3, d.Attempts)
+	assert.Equal(t, http.StatusNoContent, d.LastStatusCode)
+	assert.Empty(t, d.LastError)
+	require.NotNil(t, d.DeliveredAt)
+
+	// Every attempt sends the same body, signed with the subscription secret
+	require.Len(t, received, 3)
+	for i, r := range received {
+		assert.Equal(t, bodies[0], bodies[i])
+		assert.Equal(t, "/hooks", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, event.ID.String(), r.Header.Get(services.WebhookEventIDHeader))
+		assert.Equal(t, entities.EventPatientCreated, r.Header.Get(services.WebhookEventTypeHeader))
+		ts, err := strconv.ParseInt(r.Header.Get(services.WebhookTimestampHeader), 10, 64)
+		require.NoError(t, err)
+		assert.Equal(t, services.SignWebhook(sub.Secret, ts, bodies[i]), r.Header.Get(services.WebhookSignatureHeader))
+		assert.NotEqual(t, services.SignWebhook("whsec_other", ts, bodies[i]), r.Header.Get(services.WebhookSignatureHeader))
+	}
+	var payload dto.WebhookEvent
+	require.NoError(t, json.Unmarshal(bodies[0], &payload))
+	assert.Equal(t, event.ID, payload.EventID)
+	assert.Equal(t, entities.EventPatientCreated, payload.Type)
+	assert.Equal(t, hospitalID, payload.HospitalID)
+	var data dto.PatientEventData
+	require.NoError(t, json.Unmarshal(payload.Data, &data))
+	assert.Equal(t, "HN0001", data.PatientHN)
+}
+
+func TestWebhookService_DeadLetter(t *testing.T) {
+	hospitalID, supervisorID := uuid.New(), uuid.New()
+	staffRepo := &roleStaffRepo{roles: map[uuid.UUID]string{supervisorID: entities.RoleSupervisor}}
+	var calls int
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	repo := &memoryWebhookRepo{}
+	svc := services.NewWebhookService(repo, staffRepo, server.Client())
+	ctx := context.Background()
+	sub := entities.WebhookSubscription{HospitalID: hospitalID, URL: server.URL}
+	require.NoError(t, svc.CreateSubscription(ctx, &sub, supervisorID))
+	event := outboxEvent(hospitalID, entities.EventStaffCreated, dto.StaffEventData{StaffID: uuid.New(), Username: "nurse01", Role: entities.RoleNurse})
+	require.NoError(t, services.NewWebhookSink(repo).Handle(ctx, event))
+
+	at := time.Now().Add(time.Second)
+	for i := 0; i < services.WebhookMaxAttempts; i++ {
+		_, err := svc.Dispatch(ctx, at)
+		require.NoError(t, err)
+		at = at.Add(7 * time.Hour)
+	}
+	assert.Equal(t, services.WebhookMaxAttempts, calls)
+	dead, err := svc.Deliveries(ctx, hospitalID, supervisorID, entities.WebhookDead, nil)
+	require.NoError(t, err)
+	require.Len(t, dead, 1)
+	assert.Equal(t, services.WebhookMaxAttempts, dead[0].Attempts)
+
+	// A dead letter is not tried again until it is retried by hand
+	_, err = svc.Dispatch(ctx, at)
+	require.NoError(t, err)
+	assert.Equal(t, services.WebhookMaxAttempts, calls)
+
+	retried, err := svc.Retry(ctx, dead[0].ID, hospitalID, supervisorID)
+	require.NoError(t, err)
+	assert.Equal(t, entities.WebhookPending, retried.Status)
+	assert.Equal(t, 0, retried.Attempts)
+	_, err = svc.Retry(ctx, dead[0].ID, hospitalID, supervisorID)
+	assert.ErrorIs(t, err, services.ErrConflict)
+	_, err = svc.Retry(ctx, dead[0].ID, uuid.New(), supervisorID)
+	assert.ErrorIs(t, err, services.ErrNotFound)
+
+	_, err = svc.Dispatch(ctx, time.Now().Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, services.WebhookMaxAttempts+1, calls)
+
+	_, err = svc.Deliveries(ctx, hospitalID, supervisorID, "failed", nil)
+	assert.ErrorIs(t, err, services.ErrInvalidInput)
+}
+
+func TestWebhookHandler(t *testing.T) {
+	hospitalID, supervisorID, nurseID := uuid.New(), uuid.New(), uuid.New()
+	staffRepo := &roleStaffRepo{roles: map[uuid.UUID]string{supervisorID: entities.RoleSupervisor, nurseID: entities.RoleNurse}}
+	repo := &memoryWebhookRepo{}
+	h := handlers.NewWebhookHandler(services.NewWebhookService(repo, staffRepo, nil), &mockStaffService2{
+		GetHospitalIDByStaffIDFunc: func(staffID string) (uuid.UUID, error) { return hospitalID, nil },
+	})
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/webhooks", h.CreateSubscriptionHandler)
+	router.GET("/webhooks", h.ListSubscriptionsHandler)
+	router.DELETE("/webhooks/:id", h.DeleteSubscriptionHandler)
+	router.GET("/webhooks/deliveries", h.DeliveriesHandler)
+	router.POST("/webhooks/deliveries/:id/retry", h.RetryDeliveryHandler)
+
+	call := func(method, path string, staffID uuid.UUID, body string, ifMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", tokenForStaff(staffID))
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := call("POST", "/webhooks", supervisorID, `{"url":"https://pacs.example.com/events","event_types":["patient.created","patient.merged"]}`, "")
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created struct {
+		Data dto.WebhookSubscriptionResponse `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, []string{"patient.created", "patient.merged"}, created.Data.EventTypes)
+	assert.NotEmpty(t, created.Data.Secret)
+
+	w = call("GET", "/webhooks", supervisorID, "", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), created.Data.SubscriptionID.String())
+	assert.NotContains(t, w.Body.String(), created.Data.Secret)
+
+	cases := []struct {
+		name           string
+		method, path   string
+		staffID        uuid.UUID
+		body           string
+		ifMatch        string
+		wantStatusCode int
+	}{
+		{"negative create as nurse", "POST", "/webhooks", nurseID, `{"url":"https://pacs.example.com/events"}`, "", http.StatusForbidden},
+		{"negative create with bad URL", "POST", "/webhooks", supervisorID, `{"url":"pacs"}`, "", http.StatusBadRequest},
+		{"negative list as nurse", "GET", "/webhooks", nurseID, "", "", http.StatusForbidden},
+		{"positive deliveries", "GET", "/webhooks/deliveries?status=dead", supervisorID, "", "", http.StatusOK},
+		{"negative deliveries of unknown status", "GET", "/webhooks/deliveries?status=failed", supervisorID, "", "", http.StatusBadRequest},
+		{"negative deliveries of bad subscription ID", "GET", "/webhooks/deliveries?subscription_id=x", supervisorID, "", "", http.StatusBadRequest},
+		{"negative retry unknown delivery", "POST", "/webhooks/deliveries/" + uuid.NewString() + "/retry", supervisorID, "", "", http.StatusNotFound},
+		{"negative delete unknown subscription", "DELETE", "/webhooks/" + uuid.NewString(), supervisorID, "", `"1"`, http.StatusNotFound},
+		{"negative delete without If-Match", "DELETE", "/webhooks/" + created.Data.SubscriptionID.String(), supervisorID, "", "", http.StatusPreconditionRequired},
+		{"negative delete stale version", "DELETE", "/webhooks/" + created.Data.SubscriptionID.String(), supervisorID, "", `"2"`, http.StatusPreconditionFailed},
+		{"positive delete", "DELETE", "/webhooks/" + created.Data.SubscriptionID.String(), supervisorID, "", `"1"`, http.StatusOK},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := call(tc.method, tc.path, tc.staffID, tc.body, tc.ifMatch)
+			assert.Equal(t, tc.wantStatusCode, w.Code, w.Body.String())
+		})
+	}
+	assert.Empty(t, repo.subs)
+}
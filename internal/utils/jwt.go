@@ -32,7 +32,11 @@ func VerifyTokenFromRequest(r *http.Request) (string, error) {
 	if len(parts) != 2 || parts[0] != "Bearer" {
 		return "", errors.New("invalid authorization header format")
 	}
-	tokenStr := parts[1]
+	return VerifyToken(parts[1])
+}
+
+// VerifyToken verifies a JWT and returns its subject, the staff ID
+func VerifyToken(tokenStr string) (string, error) {
 	godotenv.Load()
 	secret := os.Getenv("JWT_SECRET")
 
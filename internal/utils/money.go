@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// BahtToSatang converts a baht amount from a request into satang, rounding to the nearest satang
+func BahtToSatang(baht float64) int64 {
+	return int64(math.Round(baht * 100))
+}
+
+// SatangToBaht converts satang into baht for responses
+func SatangToBaht(satang int64) float64 {
+	return float64(satang) / 100
+}
+
+// FormatBaht renders satang as a baht amount with thousands separators, e.g. "1,234.50"
+func FormatBaht(satang int64) string {
+	sign := ""
+	if satang < 0 {
+		sign = "-"
+		satang = -satang
+	}
+	whole := fmt.Sprintf("%d", satang/100)
+	var b strings.Builder
+	for i, r := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	return fmt.Sprintf("%s%s.%02d", sign, b.String(), satang%100)
+}
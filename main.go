@@ -1,18 +1,26 @@
 package main
 
 import (
-	"fmt"
+	"context"
 	"go-hospital-api/internal/db"
+	"go-hospital-api/internal/graphql"
+	"go-hospital-api/internal/grpcapi"
 	"go-hospital-api/internal/handlers"
+	"go-hospital-api/internal/hl7"
 	"go-hospital-api/internal/middleware"
 	"go-hospital-api/internal/repository"
 	"go-hospital-api/internal/services"
 	"log"
+	"net"
+	"net/url"
 	"os"
+	"strconv"
+	"time"
 
 	_ "go-hospital-api/docs" // Import the docs package to generate Swagger docs
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -34,15 +42,7 @@ func main() {
 		log.Println("No .env file found")
 	}
 
-	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-		os.Getenv("DB_PORT"),
-	)
-	dbConn, err := db.ConnectGORM(dsn)
+	dbConn, err := db.ConnectGORM(db.DSNFromEnv())
 	if err != nil {
 		log.Fatalf("DB connection failed: %v", err)
 	}
@@ -52,30 +52,256 @@ func main() {
 	// Wire repositories
 	staffRepo := repository.NewStaffRepository(dbConn)
 	patientRepo := repository.NewPatientRepository(dbConn)
+	diagnosisRepo := repository.NewDiagnosisRepository(dbConn)
+	medicationRepo := repository.NewMedicationRepository(dbConn)
+	labRepo := repository.NewLabRepository(dbConn)
+	wardRepo := repository.NewWardRepository(dbConn)
+	billingRepo := repository.NewBillingRepository(dbConn)
+	coverageRepo := repository.NewCoverageRepository(dbConn)
+	contactRepo := repository.NewContactRepository(dbConn)
+	consentRepo := repository.NewConsentRepository(dbConn)
+	referralRepo := repository.NewReferralRepository(dbConn)
+	mpiRepo := repository.NewMPIRepository(dbConn)
+	auditRepo := repository.NewAuditRepository(dbConn)
+	emergencyAccessRepo := repository.NewEmergencyAccessRepository(dbConn)
+	retentionRepo := repository.NewRetentionRepository(dbConn)
+	idempotencyRepo := repository.NewIdempotencyRepository(dbConn)
+	hl7Repo := repository.NewHL7MessageRepository(dbConn)
+	patientImportRepo := repository.NewPatientImportRepository(dbConn)
+	patientExportRepo := repository.NewPatientExportRepository(dbConn)
+	webhookRepo := repository.NewWebhookRepository(dbConn)
+	outboxRepo := repository.NewOutboxRepository(dbConn)
+	lookupRepo := repository.NewLookupRepository(dbConn)
 
 	// Wire services (use interfaces)
 	staffService := services.NewStaffService(staffRepo)
 	patientService := services.NewPatientService(patientRepo)
+	diagnosisService := services.NewDiagnosisService(diagnosisRepo, patientRepo, staffRepo)
+	medicationService := services.NewMedicationService(medicationRepo, patientRepo)
+	labService := services.NewLabService(labRepo, patientRepo, staffRepo)
+	wardService := services.NewWardService(wardRepo, patientRepo)
+	billingService := services.NewBillingService(billingRepo, patientRepo)
+	coverageService := services.NewCoverageService(coverageRepo, patientRepo, &services.LocalEligibilityVerifier{})
+	adminAreas, err := services.DefaultAdminAreas()
+	if err != nil {
+		log.Fatalf("Loading administrative areas failed: %v", err)
+	}
+	contactService := services.NewContactService(contactRepo, patientRepo, adminAreas)
+	consentService := services.NewConsentService(consentRepo, patientRepo)
+	referralService := services.NewReferralService(referralRepo, patientRepo, consentService)
+	mpiService := services.NewMPIService(mpiRepo, patientRepo, consentService)
+	emergencyAccessService := services.NewEmergencyAccessService(emergencyAccessRepo, auditRepo, patientRepo, staffRepo)
+	retentionService := services.NewRetentionService(retentionRepo, patientRepo, staffRepo, auditRepo)
+	patientImportService := services.NewPatientImportService(patientImportRepo, staffRepo)
+	patientExportService := services.NewPatientExportService(patientExportRepo, staffRepo, auditRepo)
+	webhookService := services.NewWebhookService(webhookRepo, staffRepo, nil)
+	lookupService := services.NewLookupService(lookupRepo)
+
+	// Event sinks: webhooks always, the log with EVENT_LOG_SINK=true and another system with EVENT_SINK_URL
+	eventBus := services.NewEventBus()
+	eventBus.Subscribe(services.NewWebhookSink(webhookRepo))
+	if v := os.Getenv("EVENT_LOG_SINK"); v != "" {
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			log.Fatalf("Invalid EVENT_LOG_SINK %q", v)
+		}
+		if enabled {
+			eventBus.Subscribe(services.NewLogSink(nil))
+		}
+	}
+	if sinkURL := os.Getenv("EVENT_SINK_URL"); sinkURL != "" {
+		if u, err := url.Parse(sinkURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			log.Fatalf("Invalid EVENT_SINK_URL %q", sinkURL)
+		}
+		eventBus.Subscribe(services.NewHTTPSink("http", sinkURL, os.Getenv("EVENT_SINK_SECRET"), nil))
+	}
+
+	// Patient event stream (EVENT_STREAM_LOG_SIZE events kept for resuming, default 1000)
+	eventStreamLogSize := services.DefaultEventStreamLogSize
+	if v := os.Getenv("EVENT_STREAM_LOG_SIZE"); v != "" {
+		eventStreamLogSize, err = strconv.Atoi(v)
+		if err != nil || eventStreamLogSize <= 0 {
+			log.Fatalf("Invalid EVENT_STREAM_LOG_SIZE %q", v)
+		}
+	}
+	eventStreamService := services.NewEventStreamService(eventStreamLogSize)
+	eventBus.Subscribe(eventStreamService)
+	eventDispatcher := services.NewEventDispatcher(outboxRepo, eventBus)
+
+	// HL7 interface account (HL7_STAFF_ID); received messages belong to its hospital
+	var hl7StaffID, hl7HospitalID uuid.UUID
+	if v := os.Getenv("HL7_STAFF_ID"); v != "" {
+		hl7StaffID, err = uuid.Parse(v)
+		if err != nil {
+			log.Fatalf("Invalid HL7_STAFF_ID %q", v)
+		}
+		hl7HospitalID, err = staffService.GetHospitalIDByStaffID(v)
+		if err != nil {
+			log.Fatalf("Loading HL7_STAFF_ID %s failed: %v", v, err)
+		}
+	}
+	hl7Service := services.NewHL7Service(hl7Repo, repository.NewTransactor(dbConn), patientService, staffRepo, hl7StaffID, hl7HospitalID)
 
 	// Wire handlers (use interfaces)
 	staffHandler := handlers.NewStaffHandler(staffService)
-	patientHandler := handlers.NewPatientHandler(patientService, staffService)
+	patientHandler := handlers.NewPatientHandler(patientService, patientExportService, staffService)
+	diagnosisHandler := handlers.NewDiagnosisHandler(diagnosisService, staffService)
+	medicationHandler := handlers.NewMedicationHandler(medicationService, staffService)
+	labHandler := handlers.NewLabHandler(labService, staffService)
+	wardHandler := handlers.NewWardHandler(wardService, staffService)
+	billingHandler := handlers.NewBillingHandler(billingService, staffService)
+	coverageHandler := handlers.NewCoverageHandler(coverageService, staffService)
+	contactHandler := handlers.NewContactHandler(contactService, staffService)
+	consentHandler := handlers.NewConsentHandler(consentService, staffService)
+	referralHandler := handlers.NewReferralHandler(referralService, staffService)
+	mpiHandler := handlers.NewMPIHandler(mpiService, staffService)
+	emergencyAccessHandler := handlers.NewEmergencyAccessHandler(emergencyAccessService, staffService)
+	retentionHandler := handlers.NewRetentionHandler(retentionService, staffService)
+	fhirHandler := handlers.NewFHIRHandler(patientService, staffService)
+	hl7Handler := handlers.NewHL7Handler(hl7Service, staffService)
+	patientImportHandler := handlers.NewPatientImportHandler(patientImportService, staffService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService, staffService)
+
+	// GraphQL query limits (GRAPHQL_MAX_DEPTH, default 8; GRAPHQL_MAX_COMPLEXITY, default 5000)
+	graphqlLimits := graphql.DefaultLimits
+	if v := os.Getenv("GRAPHQL_MAX_DEPTH"); v != "" {
+		graphqlLimits.MaxDepth, err = strconv.Atoi(v)
+		if err != nil || graphqlLimits.MaxDepth <= 0 {
+			log.Fatalf("Invalid GRAPHQL_MAX_DEPTH %q", v)
+		}
+	}
+	if v := os.Getenv("GRAPHQL_MAX_COMPLEXITY"); v != "" {
+		graphqlLimits.MaxComplexity, err = strconv.Atoi(v)
+		if err != nil || graphqlLimits.MaxComplexity <= 0 {
+			log.Fatalf("Invalid GRAPHQL_MAX_COMPLEXITY %q", v)
+		}
+	}
+	graphqlHandler := handlers.NewGraphQLHandler(patientService, lookupService, staffService, graphqlLimits)
+
+	// Event stream heartbeat (EVENT_STREAM_HEARTBEAT, default 15s)
+	eventStreamHeartbeat := handlers.DefaultEventStreamHeartbeat
+	if v := os.Getenv("EVENT_STREAM_HEARTBEAT"); v != "" {
+		eventStreamHeartbeat, err = time.ParseDuration(v)
+		if err != nil || eventStreamHeartbeat <= 0 {
+			log.Fatalf("Invalid EVENT_STREAM_HEARTBEAT %q", v)
+		}
+	}
+	eventStreamHandler := handlers.NewEventStreamHandler(eventStreamService, staffService, eventStreamHeartbeat)
+
+	// Retention purge job (RETENTION_PURGE_INTERVAL, e.g. 24h; "off" disables it)
+	purgeInterval := os.Getenv("RETENTION_PURGE_INTERVAL")
+	if purgeInterval == "" {
+		purgeInterval = "24h"
+	}
+	if purgeInterval != "off" {
+		interval, err := time.ParseDuration(purgeInterval)
+		if err != nil || interval <= 0 {
+			log.Fatalf("Invalid RETENTION_PURGE_INTERVAL %q", purgeInterval)
+		}
+		go services.RunPurgeJob(context.Background(), retentionService, interval)
+	}
+
+	// Outbox event dispatcher (EVENT_DISPATCH_INTERVAL, default 5s; "off" disables it)
+	eventInterval := os.Getenv("EVENT_DISPATCH_INTERVAL")
+	if eventInterval == "" {
+		eventInterval = "5s"
+	}
+	if eventInterval != "off" {
+		interval, err := time.ParseDuration(eventInterval)
+		if err != nil || interval <= 0 {
+			log.Fatalf("Invalid EVENT_DISPATCH_INTERVAL %q", eventInterval)
+		}
+		go services.RunEventDispatcher(context.Background(), eventDispatcher, interval)
+	}
+
+	// Webhook dispatcher (WEBHOOK_DISPATCH_INTERVAL, default 5s; "off" disables it)
+	dispatchInterval := os.Getenv("WEBHOOK_DISPATCH_INTERVAL")
+	if dispatchInterval == "" {
+		dispatchInterval = "5s"
+	}
+	if dispatchInterval != "off" {
+		interval, err := time.ParseDuration(dispatchInterval)
+		if err != nil || interval <= 0 {
+			log.Fatalf("Invalid WEBHOOK_DISPATCH_INTERVAL %q", dispatchInterval)
+		}
+		go services.RunWebhookDispatcher(context.Background(), webhookService, interval)
+	}
+
+	// Patient imports still running when the server stopped cannot be resumed
+	if n, err := patientImportService.FailUnfinished(context.Background()); err != nil {
+		log.Printf("Marking interrupted patient imports failed: %v", err)
+	} else if n > 0 {
+		log.Printf("Marked %d interrupted patient imports as failed", n)
+	}
+
+	// Idempotency keys (IDEMPOTENCY_KEY_TTL, default 24h)
+	idempotencyTTL := middleware.DefaultIdempotencyKeyTTL
+	if v := os.Getenv("IDEMPOTENCY_KEY_TTL"); v != "" {
+		idempotencyTTL, err = time.ParseDuration(v)
+		if err != nil || idempotencyTTL <= 0 {
+			log.Fatalf("Invalid IDEMPOTENCY_KEY_TTL %q", v)
+		}
+	}
+	idempotency := middleware.Idempotency(idempotencyRepo, idempotencyTTL)
+	go middleware.RunIdempotencyCleanup(context.Background(), idempotencyRepo, time.Hour)
+
+	// HL7 v2 MLLP listener (HL7_MLLP_ADDR, e.g. :2575; unset disables it)
+	if addr := os.Getenv("HL7_MLLP_ADDR"); addr != "" {
+		if hl7StaffID == uuid.Nil {
+			log.Fatal("HL7_MLLP_ADDR requires HL7_STAFF_ID")
+		}
+		mllp := &hl7.Server{Handler: hl7Service.Receive}
+		go func() {
+			log.Printf("HL7 MLLP listener starting on %s", addr)
+			if err := mllp.ListenAndServe(context.Background(), addr); err != nil {
+				log.Fatalf("HL7 MLLP listener failed: %v", err)
+			}
+		}()
+	}
+
+	// gRPC API (GRPC_ADDR, e.g. :9090; unset disables it)
+	if addr := os.Getenv("GRPC_ADDR"); addr != "" {
+		grpcServer := grpcapi.NewHospitalServer(patientService, staffService,
+			grpcapi.LoggingInterceptor(nil), grpcapi.AuthInterceptor(grpcapi.PublicMethods...))
+		lis, err := net.Listen("tcp", addr)
+		if err != nil {
+			log.Fatalf("gRPC server failed: %v", err)
+		}
+		go func() {
+			log.Printf("gRPC server starting on %s", addr)
+			if err := grpcServer.Serve(lis); err != nil {
+				log.Fatalf("gRPC server failed: %v", err)
+			}
+		}()
+	}
 
 	r := gin.Default()
 
 	// Swagger UI endpoint (http://localhost:8080/swagger/index.html)
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	api := r.Group("/api")
-
-	// Public routes
-	api.POST("/staff/create", staffHandler.CreateHandler)
-	api.POST("/staff/login", staffHandler.LoginHandler)
-
-	// Authenticated routes
-	auth := api.Group("")
-	auth.Use(middleware.AuthMiddleware())
-	auth.POST("/patients/search", patientHandler.SearchHandler)
+	handlers.RegisterRoutes(r.Group("/api"), handlers.Handlers{
+		Staff:           staffHandler,
+		Patient:         patientHandler,
+		Diagnosis:       diagnosisHandler,
+		Medication:      medicationHandler,
+		Lab:             labHandler,
+		Ward:            wardHandler,
+		Billing:         billingHandler,
+		Coverage:        coverageHandler,
+		Contact:         contactHandler,
+		Consent:         consentHandler,
+		Referral:        referralHandler,
+		MPI:             mpiHandler,
+		EmergencyAccess: emergencyAccessHandler,
+		Retention:       retentionHandler,
+		FHIR:            fhirHandler,
+		HL7:             hl7Handler,
+		PatientImport:   patientImportHandler,
+		Webhook:         webhookHandler,
+		GraphQL:         graphqlHandler,
+		EventStream:     eventStreamHandler,
+	}, idempotency)
 
 	port := os.Getenv("PORT")
 	if port == "" {
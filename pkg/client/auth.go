@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// refreshBefore is how long before it expires a token is replaced, so that it does not expire
+// while a request is on its way
+const refreshBefore = time.Minute
+
+// TokenStore keeps the token the client sends with its requests
+type TokenStore interface {
+	// Token returns the stored token, or "" when there is none
+	Token(ctx context.Context) (string, error)
+	SetToken(ctx context.Context, token string) error
+}
+
+// MemoryTokenStore is the default TokenStore, which keeps the token in memory
+type MemoryTokenStore struct {
+	mu    sync.RWMutex
+	token string
+}
+
+func (s *MemoryTokenStore) Token(ctx context.Context) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.token, nil
+}
+
+func (s *MemoryTokenStore) SetToken(ctx context.Context, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = token
+	return nil
+}
+
+// Login logs in as a staff member and stores the token for the following requests. The
+// credentials are kept, so that the client can log in again when the token expires.
+func (c *Client) Login(ctx context.Context, req StaffLoginRequest) (*StaffLoginResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	resp, err := c.login(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	c.credentials = &req
+	return resp, nil
+}
+
+// Logout forgets the token and the credentials. Tokens cannot be revoked; the token stays
+// valid until it expires.
+func (c *Client) Logout(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.credentials = nil
+	return c.tokens.SetToken(ctx, "")
+}
+
+func (c *Client) login(ctx context.Context, req StaffLoginRequest) (*StaffLoginResponse, error) {
+	var resp StaffLoginResponse
+	if err := c.call(ctx, &request{method: http.MethodPost, path: "/staff/login", body: req, public: true, readOnly: true}, &resp); err != nil {
+		return nil, err
+	}
+	if err := c.tokens.SetToken(ctx, resp.Token); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// currentToken returns the stored token, logging in first when there is none or it is about to
+// expire and the client has credentials
+func (c *Client) currentToken(ctx context.Context) (string, error) {
+	token, err := c.tokens.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	if token != "" && !expiresSoon(token) {
+		return token, nil
+	}
+	return c.refresh(ctx, token)
+}
+
+// refresh logs in again to replace stale, unless another request has already done so. Without
+// credentials it returns stale.
+func (c *Client) refresh(ctx context.Context, stale string) (string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.credentials == nil {
+		return stale, nil
+	}
+	token, err := c.tokens.Token(ctx)
+	if err != nil {
+		return "", err
+	}
+	if token != stale && token != "" && !expiresSoon(token) {
+		return token, nil
+	}
+	resp, err := c.login(ctx, *c.credentials)
+	if err != nil {
+		return "", err
+	}
+	return resp.Token, nil
+}
+
+// expiresSoon reads the expiry of a token without verifying it, which only the API can do. A
+// token without a readable expiry is used until the API rejects it.
+func expiresSoon(token string) bool {
+	var claims jwt.RegisteredClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(token, &claims); err != nil || claims.ExpiresAt == nil {
+		return false
+	}
+	return time.Until(claims.ExpiresAt.Time) < refreshBefore
+}
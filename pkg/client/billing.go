@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// CreatePriceItem adds an item to the hospital's price list
+func (c *Client) CreatePriceItem(ctx context.Context, req PriceItemCreateRequest) (*PriceItemResponse, error) {
+	return fetch[*PriceItemResponse](ctx, c, &request{method: http.MethodPost, path: "/price-items", body: req})
+}
+
+// ListPriceItems searches the price list
+func (c *Client) ListPriceItems(ctx context.Context, q string) ([]PriceItemResponse, error) {
+	return fetch[[]PriceItemResponse](ctx, c, &request{method: http.MethodGet, path: "/price-items", query: query("q", q)})
+}
+
+// PostCharge charges a patient for a price item
+func (c *Client) PostCharge(ctx context.Context, patientID uuid.UUID, req ChargeCreateRequest) (*ChargeResponse, error) {
+	return fetch[*ChargeResponse](ctx, c, &request{method: http.MethodPost, path: path("patients", patientID, "charges"), body: req})
+}
+
+// ListCharges lists the charges of a patient, only those with status unless it is empty
+func (c *Client) ListCharges(ctx context.Context, patientID uuid.UUID, status string) ([]ChargeResponse, error) {
+	return fetch[[]ChargeResponse](ctx, c, &request{method: http.MethodGet, path: path("patients", patientID, "charges"), query: query("status", status)})
+}
+
+// VoidCharge voids a charge that has not been invoiced
+func (c *Client) VoidCharge(ctx context.Context, id uuid.UUID) error {
+	return c.call(ctx, &request{method: http.MethodPost, path: path("charges", id, "void")}, nil)
+}
+
+// CreateInvoice invoices charges of a patient
+func (c *Client) CreateInvoice(ctx context.Context, patientID uuid.UUID, req InvoiceCreateRequest) (*InvoiceResponse, error) {
+	return fetch[*InvoiceResponse](ctx, c, &request{method: http.MethodPost, path: path("patients", patientID, "invoices"), body: req})
+}
+
+// GetInvoice returns an invoice with its lines and payments
+func (c *Client) GetInvoice(ctx context.Context, id uuid.UUID) (*InvoiceResponse, error) {
+	return fetch[*InvoiceResponse](ctx, c, &request{method: http.MethodGet, path: path("invoices", id)})
+}
+
+// InvoicePDF returns an invoice as a PDF document
+func (c *Client) InvoicePDF(ctx context.Context, id uuid.UUID) ([]byte, error) {
+	return c.download(ctx, &request{method: http.MethodGet, path: path("invoices", id, "pdf"), header: http.Header{"Accept": {"application/pdf"}}})
+}
+
+// AddPayment records a payment of an invoice and returns the invoice
+func (c *Client) AddPayment(ctx context.Context, invoiceID uuid.UUID, req PaymentCreateRequest) (*InvoiceResponse, error) {
+	return fetch[*InvoiceResponse](ctx, c, &request{method: http.MethodPost, path: path("invoices", invoiceID, "payments"), body: req})
+}
+
+// PatientBalance returns what a patient owes
+func (c *Client) PatientBalance(ctx context.Context, patientID uuid.UUID) (*PatientBalanceResponse, error) {
+	return fetch[*PatientBalanceResponse](ctx, c, &request{method: http.MethodGet, path: path("patients", patientID, "balance")})
+}
+
+// AddCoverage adds an insurance or government scheme coverage to a patient
+func (c *Client) AddCoverage(ctx context.Context, patientID uuid.UUID, req CoverageCreateRequest) (*CoverageResponse, error) {
+	return fetch[*CoverageResponse](ctx, c, &request{method: http.MethodPost, path: path("patients", patientID, "coverages"), body: req})
+}
+
+// ListCoverages lists the coverages of a patient
+func (c *Client) ListCoverages(ctx context.Context, patientID uuid.UUID) ([]CoverageResponse, error) {
+	return fetch[[]CoverageResponse](ctx, c, &request{method: http.MethodGet, path: path("patients", patientID, "coverages")})
+}
+
+// VerifyCoverage checks the eligibility of a coverage with its payer
+func (c *Client) VerifyCoverage(ctx context.Context, id uuid.UUID) (*CoverageResponse, error) {
+	return fetch[*CoverageResponse](ctx, c, &request{method: http.MethodPost, path: path("coverages", id, "verify")})
+}
@@ -0,0 +1,364 @@
+// Package client is a Go client for the Hospital API.
+//
+//	c, err := client.New("https://hospital.example.com/api",
+//		client.WithCredentials("nurse1", os.Getenv("HOSPITAL_PASSWORD"), hospitalID))
+//	if err != nil {
+//		return err
+//	}
+//	patients, err := c.SearchPatients(ctx, client.PatientSearchCriteria{Name: client.String("สมชาย")})
+//	if errors.Is(err, client.ErrForbidden) {
+//		...
+//	}
+//
+// The client logs in when it first needs a token, and again when the token is about to expire or
+// is rejected. GET, PUT and POST requests are retried on connection errors and on 429, 502, 503 and
+// 504 responses; each POST carries an Idempotency-Key so that the server runs it at most once.
+// PATCH, DELETE and PUT requests with If-Match are not retried, since repeating one that did
+// succeed would fail.
+//
+// Error responses are returned as *Error, which matches ErrNotFound and the other errors of this
+// package with errors.Is.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// IdempotencyKeyHeader makes a POST safe to retry; see the README
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	DefaultMaxRetries = 3
+	DefaultRetryWait  = 500 * time.Millisecond
+	// maxRetryWait caps the wait between attempts, including one asked for with Retry-After
+	maxRetryWait = 30 * time.Second
+)
+
+// Client calls the Hospital API. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	tokens     TokenStore
+	maxRetries int
+	retryWait  time.Duration
+
+	// mu guards credentials and lets one request at a time log in
+	mu          sync.Mutex
+	credentials *StaffLoginRequest
+
+	initialToken string
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sends the requests with h instead of http.DefaultClient
+func WithHTTPClient(h *http.Client) Option {
+	return func(c *Client) { c.httpClient = h }
+}
+
+// WithToken starts with a token from an earlier login
+func WithToken(token string) Option {
+	return func(c *Client) { c.initialToken = token }
+}
+
+// WithCredentials lets the client log in by itself, when it first needs a token and again when
+// the token expires
+func WithCredentials(username, password string, hospitalID uuid.UUID) Option {
+	return func(c *Client) {
+		c.credentials = &StaffLoginRequest{Username: username, Password: password, HospitalID: hospitalID}
+	}
+}
+
+// WithTokenStore keeps the token in store instead of in memory, e.g. to share it between processes
+func WithTokenStore(store TokenStore) Option {
+	return func(c *Client) { c.tokens = store }
+}
+
+// WithRetries sets how many times a failed request is retried (0 disables retries) and the wait
+// before the first retry, which doubles with every further one
+func WithRetries(max int, wait time.Duration) Option {
+	return func(c *Client) { c.maxRetries, c.retryWait = max, wait }
+}
+
+// New returns a client of the API at baseURL, the URL the routes are under, e.g.
+// http://localhost:8080/api
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q", baseURL)
+	}
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		maxRetries: DefaultMaxRetries,
+		retryWait:  DefaultRetryWait,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if c.maxRetries < 0 {
+		return nil, errors.New("the number of retries cannot be negative")
+	}
+	if c.tokens == nil {
+		c.tokens = &MemoryTokenStore{}
+	}
+	if c.initialToken != "" {
+		if err := c.tokens.SetToken(context.Background(), c.initialToken); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// request is one call of the API
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	header http.Header
+	// body is sent as JSON, unless it is a *multipartBody
+	body any
+	// public requests are sent without a token
+	public bool
+	// readOnly POSTs, such as a search, are retried without an idempotency key
+	readOnly bool
+}
+
+// call sends r and decodes the data of the response into out, unless out is nil
+func (c *Client) call(ctx context.Context, r *request, out any) error {
+	resp, err := c.do(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		return nil
+	}
+	envelope := struct {
+		Data any `json:"data"`
+	}{Data: out}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("decoding the response of %s %s: %w", r.method, r.path, err)
+	}
+	return nil
+}
+
+// fetch sends r and returns the data of the response as a T, e.g. a *PatientResponse
+func fetch[T any](ctx context.Context, c *Client, r *request) (T, error) {
+	var out T
+	err := c.call(ctx, r, &out)
+	return out, err
+}
+
+// do sends r, retrying it when that is safe, and returns the response of a successful request.
+// Error responses are returned as *Error.
+func (c *Client) do(ctx context.Context, r *request) (*http.Response, error) {
+	body, contentType, err := r.encode()
+	if err != nil {
+		return nil, err
+	}
+	header := r.header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	if r.method == http.MethodPost && !r.readOnly && header.Get(IdempotencyKeyHeader) == "" {
+		header.Set(IdempotencyKeyHeader, uuid.NewString())
+	}
+	// A PUT with If-Match is not repeated, as a PATCH is not: once it succeeds the version
+	// it names is stale
+	retryable := r.method == http.MethodGet || r.method == http.MethodPost ||
+		r.method == http.MethodPut && header.Get("If-Match") == ""
+	target := c.baseURL + r.path
+	if len(r.query) > 0 {
+		target += "?" + r.query.Encode()
+	}
+
+	loggedIn := false
+	for retries := 0; ; {
+		req, err := http.NewRequestWithContext(ctx, r.method, target, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header = header.Clone()
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		if req.Header.Get("Accept") == "" {
+			req.Header.Set("Accept", "application/json")
+		}
+		var token string
+		if !r.public {
+			if token, err = c.currentToken(ctx); err != nil {
+				return nil, err
+			}
+			if token != "" {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+
+		resp, err := c.httpClient.Do(req)
+		if err == nil && resp.StatusCode == http.StatusUnauthorized && !r.public && !loggedIn {
+			// The token expired or was revoked: log in again, once, and repeat the request
+			loggedIn = true
+			fresh, loginErr := c.refresh(ctx, token)
+			if loginErr != nil {
+				resp.Body.Close()
+				return nil, loginErr
+			}
+			if fresh != token {
+				drain(resp)
+				continue
+			}
+		}
+		if retryable && retries < c.maxRetries && ctx.Err() == nil && shouldRetry(resp, err) {
+			wait := c.backoff(retries, resp)
+			if resp != nil {
+				drain(resp)
+			}
+			retries++
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(wait):
+			}
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode >= 300 {
+			defer resp.Body.Close()
+			return nil, readError(resp)
+		}
+		return resp, nil
+	}
+}
+
+// shouldRetry reports whether a request that got resp or err may succeed when sent again
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// backoff is the wait before retry n (from 0): Retry-After if the server sent it, otherwise
+// retryWait doubled n times, with jitter so that clients do not retry in step
+func (c *Client) backoff(n int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds >= 0 {
+			return min(time.Duration(seconds)*time.Second, maxRetryWait)
+		}
+	}
+	wait := min(c.retryWait<<n, maxRetryWait)
+	if wait <= 0 {
+		return 0
+	}
+	return wait/2 + rand.N(wait/2+1)
+}
+
+// drain reads the rest of a response that is not used, so the connection can be reused
+func drain(resp *http.Response) {
+	io.Copy(io.Discard, io.LimitReader(resp.Body, maxErrorBodySize))
+	resp.Body.Close()
+}
+
+// encode returns the body of r and its content type
+func (r *request) encode() ([]byte, string, error) {
+	switch body := r.body.(type) {
+	case nil:
+		return nil, "", nil
+	case *multipartBody:
+		return body.data, body.contentType, nil
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, "", fmt.Errorf("encoding the body of %s %s: %w", r.method, r.path, err)
+		}
+		return data, "application/json", nil
+	}
+}
+
+// multipartBody is a file upload, kept in memory so that it can be sent again
+type multipartBody struct {
+	data        []byte
+	contentType string
+}
+
+// newMultipartBody encodes file as the "file" field of a form with fields
+func newMultipartBody(filename string, file io.Reader, fields map[string]string) (*multipartBody, error) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for name, value := range fields {
+		if err := w.WriteField(name, value); err != nil {
+			return nil, err
+		}
+	}
+	part, err := w.CreateFormFile("file", filename)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", filename, err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return &multipartBody{data: buf.Bytes(), contentType: w.FormDataContentType()}, nil
+}
+
+// download sends r and returns the body of the response, for the routes that return a file
+func (c *Client) download(ctx context.Context, r *request) ([]byte, error) {
+	resp, err := c.do(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+// path joins the path segments of a route, escaping them
+func path(segments ...any) string {
+	var b strings.Builder
+	for _, s := range segments {
+		b.WriteByte('/')
+		b.WriteString(url.PathEscape(fmt.Sprint(s)))
+	}
+	return b.String()
+}
+
+// ifMatch is the If-Match header for a record version, like the ETag the API returns
+func ifMatch(version int) http.Header {
+	return http.Header{"If-Match": {strconv.Quote(strconv.Itoa(version))}}
+}
+
+// query builds query parameters, leaving out the empty ones
+func query(pairs ...string) url.Values {
+	values := url.Values{}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] != "" {
+			values.Set(pairs[i], pairs[i+1])
+		}
+	}
+	return values
+}
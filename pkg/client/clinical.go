@@ -0,0 +1,126 @@
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// limitParam is the limit query parameter, left out when it is 0 so the API default applies
+func limitParam(limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+	return strconv.Itoa(limit)
+}
+
+// ImportICD10Codes uploads an ICD-10 catalogue of the given edition, "ICD-10" or "ICD-10-TM".
+// Only supervisors can import it.
+func (c *Client) ImportICD10Codes(ctx context.Context, filename string, file io.Reader, edition string) (*ICD10ImportResponse, error) {
+	body, err := newMultipartBody(filename, file, map[string]string{"edition": edition})
+	if err != nil {
+		return nil, err
+	}
+	return fetch[*ICD10ImportResponse](ctx, c, &request{method: http.MethodPost, path: "/icd10/import", body: body})
+}
+
+// SearchICD10Codes searches the ICD-10 codes by code or description. An empty edition searches
+// every edition and a limit of 0 uses the API default.
+func (c *Client) SearchICD10Codes(ctx context.Context, q, edition string, limit int) ([]ICD10CodeResponse, error) {
+	return fetch[[]ICD10CodeResponse](ctx, c, &request{method: http.MethodGet, path: "/icd10/codes", query: query("q", q, "edition", edition, "limit", limitParam(limit))})
+}
+
+// RecordDiagnosis records a diagnosis of a patient
+func (c *Client) RecordDiagnosis(ctx context.Context, patientID uuid.UUID, req DiagnosisCreateRequest) (*DiagnosisResponse, error) {
+	return fetch[*DiagnosisResponse](ctx, c, &request{method: http.MethodPost, path: path("patients", patientID, "diagnoses"), body: req})
+}
+
+// ListDiagnoses lists the diagnoses of a patient, only those of one visit date unless it is zero
+func (c *Client) ListDiagnoses(ctx context.Context, patientID uuid.UUID, visitDate time.Time) ([]DiagnosisResponse, error) {
+	var date string
+	if !visitDate.IsZero() {
+		date = visitDate.Format("2006-01-02")
+	}
+	return fetch[[]DiagnosisResponse](ctx, c, &request{method: http.MethodGet, path: path("patients", patientID, "diagnoses"), query: query("visit_date", date)})
+}
+
+// ImportFormulary uploads the hospital's drug formulary
+func (c *Client) ImportFormulary(ctx context.Context, filename string, file io.Reader) (*FormularyImportResponse, error) {
+	body, err := newMultipartBody(filename, file, nil)
+	if err != nil {
+		return nil, err
+	}
+	return fetch[*FormularyImportResponse](ctx, c, &request{method: http.MethodPost, path: "/drugs/import", body: body})
+}
+
+// SearchDrugs searches the formulary. A limit of 0 uses the API default.
+func (c *Client) SearchDrugs(ctx context.Context, q string, limit int) ([]DrugResponse, error) {
+	return fetch[[]DrugResponse](ctx, c, &request{method: http.MethodGet, path: "/drugs", query: query("q", q, "limit", limitParam(limit))})
+}
+
+// RecordAllergy records a drug allergy of a patient
+func (c *Client) RecordAllergy(ctx context.Context, patientID uuid.UUID, req AllergyCreateRequest) (*AllergyResponse, error) {
+	return fetch[*AllergyResponse](ctx, c, &request{method: http.MethodPost, path: path("patients", patientID, "allergies"), body: req})
+}
+
+// ListAllergies lists the drug allergies of a patient
+func (c *Client) ListAllergies(ctx context.Context, patientID uuid.UUID) ([]AllergyResponse, error) {
+	return fetch[[]AllergyResponse](ctx, c, &request{method: http.MethodGet, path: path("patients", patientID, "allergies")})
+}
+
+// Prescribe prescribes a drug to a patient. A prescription blocked by an allergy or interaction
+// fails with ErrConflict, and the *Error lists the checks that blocked it.
+func (c *Client) Prescribe(ctx context.Context, patientID uuid.UUID, req PrescriptionCreateRequest) (*PrescriptionResponse, error) {
+	return fetch[*PrescriptionResponse](ctx, c, &request{method: http.MethodPost, path: path("patients", patientID, "prescriptions"), body: req})
+}
+
+// ListPrescriptions lists the prescriptions of a patient, only those with status unless it is empty
+func (c *Client) ListPrescriptions(ctx context.Context, patientID uuid.UUID, status string) ([]PrescriptionResponse, error) {
+	return fetch[[]PrescriptionResponse](ctx, c, &request{method: http.MethodGet, path: path("patients", patientID, "prescriptions"), query: query("status", status)})
+}
+
+// UpdatePrescriptionStatus dispenses or cancels a prescription if it is still at version, and
+// fails with ErrPreconditionFailed otherwise
+func (c *Client) UpdatePrescriptionStatus(ctx context.Context, id uuid.UUID, version int, req PrescriptionStatusRequest) (*PrescriptionResponse, error) {
+	return fetch[*PrescriptionResponse](ctx, c, &request{method: http.MethodPatch, path: path("prescriptions", id, "status"), header: ifMatch(version), body: req})
+}
+
+// CreateLabTest adds a test to the hospital's lab catalogue
+func (c *Client) CreateLabTest(ctx context.Context, req LabTestCreateRequest) (*LabTestResponse, error) {
+	return fetch[*LabTestResponse](ctx, c, &request{method: http.MethodPost, path: "/lab/tests", body: req})
+}
+
+// SearchLabTests searches the lab catalogue
+func (c *Client) SearchLabTests(ctx context.Context, q string) ([]LabTestResponse, error) {
+	return fetch[[]LabTestResponse](ctx, c, &request{method: http.MethodGet, path: "/lab/tests", query: query("q", q)})
+}
+
+// CreateLabOrder orders lab tests for a patient
+func (c *Client) CreateLabOrder(ctx context.Context, patientID uuid.UUID, req LabOrderCreateRequest) (*LabOrderResponse, error) {
+	return fetch[*LabOrderResponse](ctx, c, &request{method: http.MethodPost, path: path("patients", patientID, "lab-orders"), body: req})
+}
+
+// ListLabOrders lists the lab orders of a patient
+func (c *Client) ListLabOrders(ctx context.Context, patientID uuid.UUID) ([]LabOrderResponse, error) {
+	return fetch[[]LabOrderResponse](ctx, c, &request{method: http.MethodGet, path: path("patients", patientID, "lab-orders")})
+}
+
+// GetLabOrder returns a lab order with its results
+func (c *Client) GetLabOrder(ctx context.Context, id uuid.UUID) (*LabOrderResponse, error) {
+	return fetch[*LabOrderResponse](ctx, c, &request{method: http.MethodGet, path: path("lab-orders", id)})
+}
+
+// UpdateSpecimen records that the specimen of a lab order was collected, received or rejected
+// if the order is still at version, and fails with ErrPreconditionFailed otherwise
+func (c *Client) UpdateSpecimen(ctx context.Context, orderID uuid.UUID, version int, req LabSpecimenRequest) (*LabOrderResponse, error) {
+	return fetch[*LabOrderResponse](ctx, c, &request{method: http.MethodPatch, path: path("lab-orders", orderID, "specimen"), header: ifMatch(version), body: req})
+}
+
+// EnterLabResult enters the result of one test of a lab order
+func (c *Client) EnterLabResult(ctx context.Context, orderID, itemID uuid.UUID, req LabResultRequest) (*LabResultResponse, error) {
+	return fetch[*LabResultResponse](ctx, c, &request{method: http.MethodPost, path: path("lab-orders", orderID, "items", itemID, "results"), body: req})
+}
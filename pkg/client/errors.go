@@ -0,0 +1,86 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Errors that an *Error matches with errors.Is, by its status code
+var (
+	ErrInvalidInput       = errors.New("invalid input")                        // 400
+	ErrUnauthorized       = errors.New("unauthorized")                         // 401
+	ErrForbidden          = errors.New("forbidden")                            // 403
+	ErrNotFound           = errors.New("not found")                            // 404
+	ErrConflict           = errors.New("conflict")                             // 409
+	ErrPreconditionFailed = errors.New("record changed since it was read")     // 412
+	ErrUpstream           = errors.New("upstream service failed")              // 502
+	ErrUnavailable        = errors.New("service unavailable, try again later") // 429, 503, 504
+)
+
+// maxErrorBodySize caps how much of an error response is read
+const maxErrorBodySize = 64 << 10
+
+// Error is an error response of the API
+type Error struct {
+	StatusCode int
+	// Message is the message of the dto.ErrorResponse body, or the status text when the body
+	// was not one (e.g. from a proxy in front of the API)
+	Message string
+	// Checks are the allergy and interaction checks that blocked a prescription
+	Checks []MedicationCheckResponse
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("hospital api: %d %s", e.StatusCode, e.Message)
+}
+
+// Is reports whether the error has the status code of target, one of the errors of this package
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrInvalidInput:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrPreconditionFailed:
+		return e.StatusCode == http.StatusPreconditionFailed
+	case ErrUpstream:
+		return e.StatusCode == http.StatusBadGateway
+	case ErrUnavailable:
+		return e.StatusCode == http.StatusTooManyRequests || e.StatusCode == http.StatusServiceUnavailable || e.StatusCode == http.StatusGatewayTimeout
+	}
+	return false
+}
+
+// readError turns an error response into an *Error
+func readError(resp *http.Response) *Error {
+	e := &Error{StatusCode: resp.StatusCode}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	var payload struct {
+		Message string                    `json:"message"`
+		Checks  []MedicationCheckResponse `json:"checks"`
+		// Errors is the body of a GraphQL query that could not run
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if json.Unmarshal(body, &payload) == nil && payload.Message != "" {
+		e.Message, e.Checks = payload.Message, payload.Checks
+	} else if len(payload.Errors) > 0 {
+		e.Message = payload.Errors[0].Message
+	} else if text := strings.TrimSpace(string(body)); text != "" && len(text) <= 200 && !strings.HasPrefix(text, "<") {
+		e.Message = text
+	} else {
+		e.Message = strings.ToLower(http.StatusText(resp.StatusCode))
+	}
+	return e
+}
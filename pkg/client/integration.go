@@ -0,0 +1,90 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ListHL7Messages lists the newest HL7 v2 messages received, only those with status unless it is empty
+func (c *Client) ListHL7Messages(ctx context.Context, status string) ([]HL7MessageResponse, error) {
+	return fetch[[]HL7MessageResponse](ctx, c, &request{method: http.MethodGet, path: "/hl7/messages", query: query("status", status)})
+}
+
+// ReplayHL7Message applies a received HL7 message again, e.g. after fixing what made it fail
+func (c *Client) ReplayHL7Message(ctx context.Context, id uuid.UUID) (*HL7MessageResponse, error) {
+	return fetch[*HL7MessageResponse](ctx, c, &request{method: http.MethodPost, path: path("hl7", "messages", id, "replay")})
+}
+
+// CreateWebhook subscribes a URL to events. The response holds the signing secret, which is
+// only returned this once.
+func (c *Client) CreateWebhook(ctx context.Context, req WebhookSubscriptionRequest) (*WebhookSubscriptionResponse, error) {
+	return fetch[*WebhookSubscriptionResponse](ctx, c, &request{method: http.MethodPost, path: "/webhooks", body: req})
+}
+
+// ListWebhooks lists the webhook subscriptions of the caller's hospital
+func (c *Client) ListWebhooks(ctx context.Context) ([]WebhookSubscriptionResponse, error) {
+	return fetch[[]WebhookSubscriptionResponse](ctx, c, &request{method: http.MethodGet, path: "/webhooks"})
+}
+
+// DeleteWebhook removes a webhook subscription if it is still at version
+func (c *Client) DeleteWebhook(ctx context.Context, id uuid.UUID, version int) error {
+	return c.call(ctx, &request{method: http.MethodDelete, path: path("webhooks", id), header: ifMatch(version)}, nil)
+}
+
+// WebhookDeliveries lists the newest webhook deliveries, only those with status and of one
+// subscription unless they are empty
+func (c *Client) WebhookDeliveries(ctx context.Context, status string, subscriptionID uuid.UUID) ([]WebhookDeliveryResponse, error) {
+	var subscription string
+	if subscriptionID != uuid.Nil {
+		subscription = subscriptionID.String()
+	}
+	return fetch[[]WebhookDeliveryResponse](ctx, c, &request{method: http.MethodGet, path: "/webhooks/deliveries", query: query("status", status, "subscription_id", subscription)})
+}
+
+// RetryWebhookDelivery sends a dead letter again
+func (c *Client) RetryWebhookDelivery(ctx context.Context, id uuid.UUID) (*WebhookDeliveryResponse, error) {
+	return fetch[*WebhookDeliveryResponse](ctx, c, &request{method: http.MethodPost, path: path("webhooks", "deliveries", id, "retry")})
+}
+
+// GraphQLErrors are the errors of the fields of a GraphQL query that failed
+type GraphQLErrors []*GraphQLError
+
+func (e GraphQLErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Message
+	}
+	return "graphql: " + strings.Join(messages, "; ")
+}
+
+// GraphQL runs a GraphQL query and decodes its data into data. Fields that failed are null in
+// the data and their errors are returned as GraphQLErrors. A query that cannot run, e.g. one
+// that is too deep, fails with an *Error matching ErrInvalidInput.
+func (c *Client) GraphQL(ctx context.Context, q string, variables map[string]any, data any) error {
+	resp, err := c.do(ctx, &request{method: http.MethodPost, path: "/graphql", body: GraphQLRequest{Query: q, Variables: variables}, readOnly: true})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	var result struct {
+		Data   json.RawMessage `json:"data"`
+		Errors GraphQLErrors   `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("decoding the GraphQL response: %w", err)
+	}
+	if data != nil && len(result.Data) > 0 {
+		if err := json.Unmarshal(result.Data, data); err != nil {
+			return fmt.Errorf("decoding the GraphQL data: %w", err)
+		}
+	}
+	if len(result.Errors) > 0 {
+		return result.Errors
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SearchPatients returns the patients of the caller's hospital that match criteria. Empty
+// criteria do not filter.
+func (c *Client) SearchPatients(ctx context.Context, criteria PatientSearchCriteria) ([]PatientResponse, error) {
+	return fetch[[]PatientResponse](ctx, c, &request{method: http.MethodPost, path: "/patients/search", body: criteria, readOnly: true})
+}
+
+// Export is a patient export being downloaded. Close it when done.
+type Export struct {
+	io.ReadCloser
+	ContentType string
+	Filename    string
+	// Total is the number of matching patients; a download with fewer rows was cut short
+	Total int64
+}
+
+// ExportPatients downloads the patients that match criteria as "csv", "xlsx" or "ndjson", with
+// the given columns or else every column the caller may export
+func (c *Client) ExportPatients(ctx context.Context, criteria PatientSearchCriteria, format string, columns ...string) (*Export, error) {
+	resp, err := c.do(ctx, &request{
+		method:   http.MethodPost,
+		path:     "/patients/search",
+		query:    query("format", format, "columns", strings.Join(columns, ",")),
+		header:   http.Header{"Accept": {"*/*"}},
+		body:     criteria,
+		readOnly: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	export := &Export{ReadCloser: resp.Body, ContentType: resp.Header.Get("Content-Type")}
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		export.Filename = params["filename"]
+	}
+	export.Total, _ = strconv.ParseInt(resp.Header.Get("X-Total-Count"), 10, 64)
+	return export, nil
+}
+
+// GetPatient returns a patient of the caller's hospital. Its Version is what UpdatePatient and
+// DeletePatient expect.
+func (c *Client) GetPatient(ctx context.Context, id uuid.UUID) (*PatientResponse, error) {
+	return fetch[*PatientResponse](ctx, c, &request{method: http.MethodGet, path: path("patients", id)})
+}
+
+// GetPatientAsOf returns a patient as they were at a past time
+func (c *Client) GetPatientAsOf(ctx context.Context, id uuid.UUID, at time.Time) (*PatientResponse, error) {
+	return fetch[*PatientResponse](ctx, c, &request{method: http.MethodGet, path: path("patients", id), query: query("as_of", at.Format(time.RFC3339))})
+}
+
+// UpdatePatient changes the fields set in req if the patient is still at version, and fails
+// with ErrPreconditionFailed otherwise
+func (c *Client) UpdatePatient(ctx context.Context, id uuid.UUID, version int, req PatientUpdateRequest) (*PatientResponse, error) {
+	return fetch[*PatientResponse](ctx, c, &request{method: http.MethodPatch, path: path("patients", id), header: ifMatch(version), body: req})
+}
+
+// PatientHistory returns every version of a patient with the fields it changed
+func (c *Client) PatientHistory(ctx context.Context, id uuid.UUID) ([]PatientVersionResponse, error) {
+	return fetch[[]PatientVersionResponse](ctx, c, &request{method: http.MethodGet, path: path("patients", id, "history")})
+}
+
+// DeletePatient deletes a patient if they are still at version, and fails with
+// ErrPreconditionFailed otherwise. The reason is kept in the audit log.
+func (c *Client) DeletePatient(ctx context.Context, id uuid.UUID, version int, reason string) error {
+	return c.call(ctx, &request{method: http.MethodDelete, path: path("patients", id), header: ifMatch(version), body: PatientDeleteRequest{Reason: reason}}, nil)
+}
+
+// RestorePatient restores a deleted patient
+func (c *Client) RestorePatient(ctx context.Context, id uuid.UUID) (*PatientResponse, error) {
+	return fetch[*PatientResponse](ctx, c, &request{method: http.MethodPost, path: path("patients", id, "restore")})
+}
+
+// DeletedPatients lists the deleted patients that can still be restored
+func (c *Client) DeletedPatients(ctx context.Context) ([]DeletedPatientResponse, error) {
+	return fetch[[]DeletedPatientResponse](ctx, c, &request{method: http.MethodGet, path: "/patients/deleted"})
+}
+
+// PatientImportOptions are the optional settings of ImportPatients
+type PatientImportOptions struct {
+	// Mapping maps column headers of the file onto patient fields, e.g. "Citizen No": "national_id"
+	Mapping map[string]string
+	// DryRun checks the file without importing it
+	DryRun bool
+}
+
+// ImportPatients uploads a CSV or XLSX file of patients. Large files are imported in the
+// background; poll GetPatientImport until the import is no longer queued or running.
+func (c *Client) ImportPatients(ctx context.Context, filename string, file io.Reader, opts PatientImportOptions) (*PatientImportResponse, error) {
+	fields := map[string]string{"dry_run": strconv.FormatBool(opts.DryRun)}
+	if len(opts.Mapping) > 0 {
+		mapping, err := json.Marshal(opts.Mapping)
+		if err != nil {
+			return nil, err
+		}
+		fields["mapping"] = string(mapping)
+	}
+	body, err := newMultipartBody(filename, file, fields)
+	if err != nil {
+		return nil, err
+	}
+	return fetch[*PatientImportResponse](ctx, c, &request{method: http.MethodPost, path: "/patients/import", body: body})
+}
+
+// GetPatientImport returns the progress of a patient import
+func (c *Client) GetPatientImport(ctx context.Context, id uuid.UUID) (*PatientImportResponse, error) {
+	return fetch[*PatientImportResponse](ctx, c, &request{method: http.MethodGet, path: path("patients", "imports", id)})
+}
+
+// PatientImportErrors returns the CSV report of the rows an import skipped
+func (c *Client) PatientImportErrors(ctx context.Context, id uuid.UUID) ([]byte, error) {
+	return c.download(ctx, &request{method: http.MethodGet, path: path("patients", "imports", id, "errors"), header: http.Header{"Accept": {"text/csv"}}})
+}
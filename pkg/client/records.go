@@ -0,0 +1,174 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// SearchAdminAreas looks up Thai provinces, districts and subdistricts, e.g. to fill an address
+func (c *Client) SearchAdminAreas(ctx context.Context, province, district, postalCode string) ([]AdminAreaResponse, error) {
+	return fetch[[]AdminAreaResponse](ctx, c, &request{method: http.MethodGet, path: "/admin-areas", query: query("province", province, "district", district, "postal_code", postalCode)})
+}
+
+// AddAddress adds an address to a patient
+func (c *Client) AddAddress(ctx context.Context, patientID uuid.UUID, req AddressRequest) (*AddressResponse, error) {
+	return fetch[*AddressResponse](ctx, c, &request{method: http.MethodPost, path: path("patients", patientID, "addresses"), body: req})
+}
+
+// ListAddresses lists the addresses of a patient
+func (c *Client) ListAddresses(ctx context.Context, patientID uuid.UUID) ([]AddressResponse, error) {
+	return fetch[[]AddressResponse](ctx, c, &request{method: http.MethodGet, path: path("patients", patientID, "addresses")})
+}
+
+// UpdateAddress replaces an address of a patient if it is still at version, and fails with
+// ErrPreconditionFailed otherwise
+func (c *Client) UpdateAddress(ctx context.Context, patientID, addressID uuid.UUID, version int, req AddressRequest) (*AddressResponse, error) {
+	return fetch[*AddressResponse](ctx, c, &request{method: http.MethodPut, path: path("patients", patientID, "addresses", addressID), header: ifMatch(version), body: req})
+}
+
+// DeleteAddress deletes an address of a patient if it is still at version
+func (c *Client) DeleteAddress(ctx context.Context, patientID, addressID uuid.UUID, version int) error {
+	return c.call(ctx, &request{method: http.MethodDelete, path: path("patients", patientID, "addresses", addressID), header: ifMatch(version)}, nil)
+}
+
+// AddEmergencyContact adds an emergency contact to a patient
+func (c *Client) AddEmergencyContact(ctx context.Context, patientID uuid.UUID, req EmergencyContactRequest) (*EmergencyContactResponse, error) {
+	return fetch[*EmergencyContactResponse](ctx, c, &request{method: http.MethodPost, path: path("patients", patientID, "emergency-contacts"), body: req})
+}
+
+// ListEmergencyContacts lists the emergency contacts of a patient
+func (c *Client) ListEmergencyContacts(ctx context.Context, patientID uuid.UUID) ([]EmergencyContactResponse, error) {
+	return fetch[[]EmergencyContactResponse](ctx, c, &request{method: http.MethodGet, path: path("patients", patientID, "emergency-contacts")})
+}
+
+// UpdateEmergencyContact replaces an emergency contact of a patient if it is still at version,
+// and fails with ErrPreconditionFailed otherwise
+func (c *Client) UpdateEmergencyContact(ctx context.Context, patientID, contactID uuid.UUID, version int, req EmergencyContactRequest) (*EmergencyContactResponse, error) {
+	return fetch[*EmergencyContactResponse](ctx, c, &request{method: http.MethodPut, path: path("patients", patientID, "emergency-contacts", contactID), header: ifMatch(version), body: req})
+}
+
+// DeleteEmergencyContact deletes an emergency contact of a patient if it is still at version
+func (c *Client) DeleteEmergencyContact(ctx context.Context, patientID, contactID uuid.UUID, version int) error {
+	return c.call(ctx, &request{method: http.MethodDelete, path: path("patients", patientID, "emergency-contacts", contactID), header: ifMatch(version)}, nil)
+}
+
+// GrantConsent records a patient's PDPA consent to a purpose
+func (c *Client) GrantConsent(ctx context.Context, patientID uuid.UUID, req ConsentGrantRequest) (*ConsentResponse, error) {
+	return fetch[*ConsentResponse](ctx, c, &request{method: http.MethodPost, path: path("patients", patientID, "consents"), body: req})
+}
+
+// ListConsents lists the consents of a patient, only those for purpose unless it is empty
+func (c *Client) ListConsents(ctx context.Context, patientID uuid.UUID, purpose string) ([]ConsentResponse, error) {
+	return fetch[[]ConsentResponse](ctx, c, &request{method: http.MethodGet, path: path("patients", patientID, "consents"), query: query("purpose", purpose)})
+}
+
+// CheckConsent reports whether a patient currently consents to purpose
+func (c *Client) CheckConsent(ctx context.Context, patientID uuid.UUID, purpose string) (*ConsentCheckResponse, error) {
+	return fetch[*ConsentCheckResponse](ctx, c, &request{method: http.MethodGet, path: path("patients", patientID, "consents", "check"), query: query("purpose", purpose)})
+}
+
+// WithdrawConsent withdraws a consent
+func (c *Client) WithdrawConsent(ctx context.Context, id uuid.UUID, req ConsentWithdrawRequest) (*ConsentResponse, error) {
+	return fetch[*ConsentResponse](ctx, c, &request{method: http.MethodPost, path: path("consents", id, "withdraw"), body: req})
+}
+
+// CreateReferral refers a patient to another hospital
+func (c *Client) CreateReferral(ctx context.Context, req ReferralCreateRequest) (*ReferralResponse, error) {
+	return fetch[*ReferralResponse](ctx, c, &request{method: http.MethodPost, path: "/referrals", body: req})
+}
+
+// ListReferrals lists the "incoming" (the default) or "outgoing" referrals, only those with
+// status unless it is empty
+func (c *Client) ListReferrals(ctx context.Context, direction, status string) ([]ReferralResponse, error) {
+	return fetch[[]ReferralResponse](ctx, c, &request{method: http.MethodGet, path: "/referrals", query: query("direction", direction, "status", status)})
+}
+
+// GetReferral returns a referral from or to the caller's hospital
+func (c *Client) GetReferral(ctx context.Context, id uuid.UUID) (*ReferralResponse, error) {
+	return fetch[*ReferralResponse](ctx, c, &request{method: http.MethodGet, path: path("referrals", id)})
+}
+
+// AcceptReferral accepts an incoming referral
+func (c *Client) AcceptReferral(ctx context.Context, id uuid.UUID, req ReferralAcceptRequest) (*ReferralResponse, error) {
+	return fetch[*ReferralResponse](ctx, c, &request{method: http.MethodPost, path: path("referrals", id, "accept"), body: req})
+}
+
+// RejectReferral rejects an incoming referral
+func (c *Client) RejectReferral(ctx context.Context, id uuid.UUID, req ReferralRejectRequest) (*ReferralResponse, error) {
+	return fetch[*ReferralResponse](ctx, c, &request{method: http.MethodPost, path: path("referrals", id, "reject"), body: req})
+}
+
+// CancelReferral cancels an outgoing referral
+func (c *Client) CancelReferral(ctx context.Context, id uuid.UUID) (*ReferralResponse, error) {
+	return fetch[*ReferralResponse](ctx, c, &request{method: http.MethodPost, path: path("referrals", id, "cancel")})
+}
+
+// ReferredPatient returns the patient of an incoming referral
+func (c *Client) ReferredPatient(ctx context.Context, referralID uuid.UUID) (*ReferredPatientResponse, error) {
+	return fetch[*ReferredPatientResponse](ctx, c, &request{method: http.MethodGet, path: path("referrals", referralID, "patient")})
+}
+
+// IndexPatient links a patient to an enterprise record of the master patient index
+func (c *Client) IndexPatient(ctx context.Context, patientID uuid.UUID) (*EnterpriseLinkResponse, error) {
+	return fetch[*EnterpriseLinkResponse](ctx, c, &request{method: http.MethodPost, path: path("patients", patientID, "enterprise-link")})
+}
+
+// EnterpriseLookup returns the records of other hospitals linked to the same person as a patient
+func (c *Client) EnterpriseLookup(ctx context.Context, patientID uuid.UUID) (*EnterpriseLookupResponse, error) {
+	return fetch[*EnterpriseLookupResponse](ctx, c, &request{method: http.MethodGet, path: path("patients", patientID, "enterprise")})
+}
+
+// MPIReviewQueue lists the possible matches waiting for review, or those with status
+func (c *Client) MPIReviewQueue(ctx context.Context, status string) ([]MatchCandidateResponse, error) {
+	return fetch[[]MatchCandidateResponse](ctx, c, &request{method: http.MethodGet, path: "/mpi/review-queue", query: query("status", status)})
+}
+
+// ConfirmMPIMatch links the two records of a possible match
+func (c *Client) ConfirmMPIMatch(ctx context.Context, id uuid.UUID) (*MatchCandidateResponse, error) {
+	return fetch[*MatchCandidateResponse](ctx, c, &request{method: http.MethodPost, path: path("mpi", "review-queue", id, "link")})
+}
+
+// RejectMPIMatch records that a possible match is two different people
+func (c *Client) RejectMPIMatch(ctx context.Context, id uuid.UUID) (*MatchCandidateResponse, error) {
+	return fetch[*MatchCandidateResponse](ctx, c, &request{method: http.MethodPost, path: path("mpi", "review-queue", id, "reject")})
+}
+
+// BreakGlass grants emergency access to a patient the caller could not otherwise see
+func (c *Client) BreakGlass(ctx context.Context, req EmergencyAccessRequest) (*EmergencyAccessResponse, error) {
+	return fetch[*EmergencyAccessResponse](ctx, c, &request{method: http.MethodPost, path: "/break-glass", body: req})
+}
+
+// EmergencyAccessPatient returns the patient of an emergency access while it lasts
+func (c *Client) EmergencyAccessPatient(ctx context.Context, accessID uuid.UUID) (*EmergencyPatientResponse, error) {
+	return fetch[*EmergencyPatientResponse](ctx, c, &request{method: http.MethodGet, path: path("break-glass", accessID, "patient")})
+}
+
+// EmergencyAccessReport lists the emergency accesses for review, only those with review status
+// unless it is empty
+func (c *Client) EmergencyAccessReport(ctx context.Context, status string) ([]EmergencyAccessResponse, error) {
+	return fetch[[]EmergencyAccessResponse](ctx, c, &request{method: http.MethodGet, path: "/break-glass/reviews", query: query("status", status)})
+}
+
+// ReviewEmergencyAccess records the outcome of the review of an emergency access
+func (c *Client) ReviewEmergencyAccess(ctx context.Context, id uuid.UUID, req EmergencyAccessReviewRequest) (*EmergencyAccessResponse, error) {
+	return fetch[*EmergencyAccessResponse](ctx, c, &request{method: http.MethodPost, path: path("break-glass", id, "review"), body: req})
+}
+
+// AuditEvents lists the audit events of the caller's hospital, only those with priority unless
+// it is empty
+func (c *Client) AuditEvents(ctx context.Context, priority string) ([]AuditEventResponse, error) {
+	return fetch[[]AuditEventResponse](ctx, c, &request{method: http.MethodGet, path: "/audit-events", query: query("priority", priority)})
+}
+
+// RetentionPolicy returns how long the caller's hospital keeps deleted patients
+func (c *Client) RetentionPolicy(ctx context.Context) (*RetentionPolicyResponse, error) {
+	return fetch[*RetentionPolicyResponse](ctx, c, &request{method: http.MethodGet, path: "/retention-policy"})
+}
+
+// SetRetentionPolicy changes how long the caller's hospital keeps deleted patients, provided the
+// policy is still at version; the default policy is version 1
+func (c *Client) SetRetentionPolicy(ctx context.Context, version int, req RetentionPolicyRequest) (*RetentionPolicyResponse, error) {
+	return fetch[*RetentionPolicyResponse](ctx, c, &request{method: http.MethodPut, path: "/retention-policy", header: ifMatch(version), body: req})
+}
@@ -0,0 +1,35 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// CreateStaff creates a staff account with the staff role. It needs no token.
+func (c *Client) CreateStaff(ctx context.Context, req StaffCreateRequest) (*StaffResponse, error) {
+	return fetch[*StaffResponse](ctx, c, &request{method: http.MethodPost, path: "/staff/create", body: req, public: true})
+}
+
+// GetStaff returns a staff member of the caller's hospital
+func (c *Client) GetStaff(ctx context.Context, id uuid.UUID) (*StaffResponse, error) {
+	return fetch[*StaffResponse](ctx, c, &request{method: http.MethodGet, path: path("staff", id)})
+}
+
+// DeleteStaff deletes a staff member if they are still at version, and fails with
+// ErrPreconditionFailed otherwise
+func (c *Client) DeleteStaff(ctx context.Context, id uuid.UUID, version int) error {
+	return c.call(ctx, &request{method: http.MethodDelete, path: path("staff", id), header: ifMatch(version)}, nil)
+}
+
+// RestoreStaff restores a deleted staff member
+func (c *Client) RestoreStaff(ctx context.Context, id uuid.UUID) (*StaffResponse, error) {
+	return fetch[*StaffResponse](ctx, c, &request{method: http.MethodPost, path: path("staff", id, "restore")})
+}
+
+// SetStaffRole assigns a role to a staff member if they are still at version, and fails with
+// ErrPreconditionFailed otherwise. Only supervisors can assign roles.
+func (c *Client) SetStaffRole(ctx context.Context, id uuid.UUID, version int, role string) (*StaffResponse, error) {
+	return fetch[*StaffResponse](ctx, c, &request{method: http.MethodPut, path: path("staff", id, "role"), header: ifMatch(version), body: StaffRoleRequest{Role: role}})
+}
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"go-hospital-api/internal/dto"
+	"go-hospital-api/internal/graphql"
+	"time"
+)
+
+// The request and response bodies are those of the server, so they cannot drift apart.
+
+// Staff
+type (
+	StaffCreateRequest = dto.StaffCreateRequest
+	StaffLoginRequest  = dto.StaffLoginRequest
+	StaffLoginResponse = dto.StaffLoginResponse
+	StaffResponse      = dto.StaffResponse
+	StaffRoleRequest   = dto.StaffRoleRequest
+)
+
+// Patients
+type (
+	PatientSearchCriteria  = dto.PatientSearchCriteria
+	PatientResponse        = dto.PatientResponse
+	PatientUpdateRequest   = dto.PatientUpdateRequest
+	PatientVersionResponse = dto.PatientVersionResponse
+	FieldChangeResponse    = dto.FieldChangeResponse
+	PatientDeleteRequest   = dto.PatientDeleteRequest
+	DeletedPatientResponse = dto.DeletedPatientResponse
+	PatientImportResponse  = dto.PatientImportResponse
+)
+
+// Diagnoses, medications and laboratory
+type (
+	ICD10CodeResponse         = dto.ICD10CodeResponse
+	ICD10ImportResponse       = dto.ICD10ImportResponse
+	DiagnosisCreateRequest    = dto.DiagnosisCreateRequest
+	DiagnosisResponse         = dto.DiagnosisResponse
+	DrugResponse              = dto.DrugResponse
+	FormularyImportResponse   = dto.FormularyImportResponse
+	AllergyCreateRequest      = dto.AllergyCreateRequest
+	AllergyResponse           = dto.AllergyResponse
+	PrescriptionCreateRequest = dto.PrescriptionCreateRequest
+	PrescriptionStatusRequest = dto.PrescriptionStatusRequest
+	PrescriptionResponse      = dto.PrescriptionResponse
+	MedicationCheckResponse   = dto.MedicationCheckResponse
+	LabTestCreateRequest      = dto.LabTestCreateRequest
+	LabTestResponse           = dto.LabTestResponse
+	LabOrderCreateRequest     = dto.LabOrderCreateRequest
+	LabOrderResponse          = dto.LabOrderResponse
+	LabOrderItemResponse      = dto.LabOrderItemResponse
+	LabSpecimenRequest        = dto.LabSpecimenRequest
+	LabResultRequest          = dto.LabResultRequest
+	LabResultResponse         = dto.LabResultResponse
+)
+
+// Wards, beds and admissions
+type (
+	WardCreateRequest = dto.WardCreateRequest
+	WardResponse      = dto.WardResponse
+	BedCreateRequest  = dto.BedCreateRequest
+	BedStatusRequest  = dto.BedStatusRequest
+	BedResponse       = dto.BedResponse
+	AdmitRequest      = dto.AdmitRequest
+	TransferRequest   = dto.TransferRequest
+	DischargeRequest  = dto.DischargeRequest
+	AdmissionResponse = dto.AdmissionResponse
+	BedBoardWard      = dto.BedBoardWard
+	BedBoardBed       = dto.BedBoardBed
+)
+
+// Billing and coverage
+type (
+	PriceItemCreateRequest = dto.PriceItemCreateRequest
+	PriceItemResponse      = dto.PriceItemResponse
+	ChargeCreateRequest    = dto.ChargeCreateRequest
+	ChargeResponse         = dto.ChargeResponse
+	InvoiceCreateRequest   = dto.InvoiceCreateRequest
+	InvoiceResponse        = dto.InvoiceResponse
+	InvoiceLineResponse    = dto.InvoiceLineResponse
+	PaymentCreateRequest   = dto.PaymentCreateRequest
+	PaymentResponse        = dto.PaymentResponse
+	PatientBalanceResponse = dto.PatientBalanceResponse
+	CoverageCreateRequest  = dto.CoverageCreateRequest
+	CoverageResponse       = dto.CoverageResponse
+)
+
+// Addresses, emergency contacts, consent and referrals
+type (
+	AddressRequest           = dto.AddressRequest
+	AddressResponse          = dto.AddressResponse
+	EmergencyContactRequest  = dto.EmergencyContactRequest
+	EmergencyContactResponse = dto.EmergencyContactResponse
+	AdminAreaResponse        = dto.AdminAreaResponse
+	ConsentGrantRequest      = dto.ConsentGrantRequest
+	ConsentWithdrawRequest   = dto.ConsentWithdrawRequest
+	ConsentResponse          = dto.ConsentResponse
+	ConsentCheckResponse     = dto.ConsentCheckResponse
+	ReferralCreateRequest    = dto.ReferralCreateRequest
+	ReferralAcceptRequest    = dto.ReferralAcceptRequest
+	ReferralRejectRequest    = dto.ReferralRejectRequest
+	ReferralResponse         = dto.ReferralResponse
+	ReferredPatientResponse  = dto.ReferredPatientResponse
+)
+
+// Master patient index, break-the-glass access and retention
+type (
+	EnterpriseLinkResponse       = dto.EnterpriseLinkResponse
+	EnterpriseLookupResponse     = dto.EnterpriseLookupResponse
+	EnterpriseRecordResponse     = dto.EnterpriseRecordResponse
+	MatchCandidateResponse       = dto.MatchCandidateResponse
+	MatchSideResponse            = dto.MatchSideResponse
+	EmergencyAccessRequest       = dto.EmergencyAccessRequest
+	EmergencyAccessReviewRequest = dto.EmergencyAccessReviewRequest
+	EmergencyAccessResponse      = dto.EmergencyAccessResponse
+	EmergencyPatientResponse     = dto.EmergencyPatientResponse
+	AuditEventResponse           = dto.AuditEventResponse
+	RetentionPolicyRequest       = dto.RetentionPolicyRequest
+	RetentionPolicyResponse      = dto.RetentionPolicyResponse
+)
+
+// HL7, webhooks and GraphQL
+type (
+	HL7MessageResponse          = dto.HL7MessageResponse
+	WebhookSubscriptionRequest  = dto.WebhookSubscriptionRequest
+	WebhookSubscriptionResponse = dto.WebhookSubscriptionResponse
+	WebhookDeliveryResponse     = dto.WebhookDeliveryResponse
+	WebhookEvent                = dto.WebhookEvent
+	PatientEventData            = dto.PatientEventData
+	StaffEventData              = dto.StaffEventData
+	GraphQLRequest              = graphql.Request
+	GraphQLError                = graphql.Error
+)
+
+// String returns a pointer to s, for the optional fields of PatientSearchCriteria and PatientUpdateRequest
+func String(s string) *string {
+	return &s
+}
+
+// Time returns a pointer to t, for the optional date fields of PatientSearchCriteria and PatientUpdateRequest
+func Time(t time.Time) *time.Time {
+	return &t
+}
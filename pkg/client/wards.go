@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// CreateWard creates a ward
+func (c *Client) CreateWard(ctx context.Context, req WardCreateRequest) (*WardResponse, error) {
+	return fetch[*WardResponse](ctx, c, &request{method: http.MethodPost, path: "/wards", body: req})
+}
+
+// ListWards lists the wards of the caller's hospital with their beds
+func (c *Client) ListWards(ctx context.Context) ([]WardResponse, error) {
+	return fetch[[]WardResponse](ctx, c, &request{method: http.MethodGet, path: "/wards"})
+}
+
+// CreateBed adds a bed to a ward
+func (c *Client) CreateBed(ctx context.Context, wardID uuid.UUID, req BedCreateRequest) (*BedResponse, error) {
+	return fetch[*BedResponse](ctx, c, &request{method: http.MethodPost, path: path("wards", wardID, "beds"), body: req})
+}
+
+// UpdateBedStatus sets the status of a bed that is not occupied if it is still at version, and
+// fails with ErrPreconditionFailed otherwise
+func (c *Client) UpdateBedStatus(ctx context.Context, id uuid.UUID, version int, req BedStatusRequest) (*BedResponse, error) {
+	return fetch[*BedResponse](ctx, c, &request{method: http.MethodPatch, path: path("beds", id, "status"), header: ifMatch(version), body: req})
+}
+
+// Admit admits a patient to a bed
+func (c *Client) Admit(ctx context.Context, patientID uuid.UUID, req AdmitRequest) (*AdmissionResponse, error) {
+	return fetch[*AdmissionResponse](ctx, c, &request{method: http.MethodPost, path: path("patients", patientID, "admissions"), body: req})
+}
+
+// Transfer moves an admitted patient to another bed
+func (c *Client) Transfer(ctx context.Context, admissionID uuid.UUID, req TransferRequest) (*AdmissionResponse, error) {
+	return fetch[*AdmissionResponse](ctx, c, &request{method: http.MethodPost, path: path("admissions", admissionID, "transfer"), body: req})
+}
+
+// Discharge discharges an admitted patient and frees their bed
+func (c *Client) Discharge(ctx context.Context, admissionID uuid.UUID, req DischargeRequest) (*AdmissionResponse, error) {
+	return fetch[*AdmissionResponse](ctx, c, &request{method: http.MethodPost, path: path("admissions", admissionID, "discharge"), body: req})
+}
+
+// BedBoard returns every ward with the status and occupant of each bed
+func (c *Client) BedBoard(ctx context.Context) ([]BedBoardWard, error) {
+	return fetch[[]BedBoardWard](ctx, c, &request{method: http.MethodGet, path: "/bed-board"})
+}
@@ -0,0 +1,878 @@
+// gRPC API of the hospital service. It mirrors the staff and patient endpoints of the REST API
+// and is served on GRPC_ADDR. Calls other than CreateStaff and Login need the token from Login
+// in the "authorization" metadata as "Bearer <token>".
+//
+// The Go code next to it is generated; run buf generate in proto/ after changing this file.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.7
+// 	protoc        (unknown)
+// source: hospital/v1/hospital.proto
+
+package hospitalv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// New staff always get the staff role; a supervisor assigns other roles through the REST API.
+type CreateStaffRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Password      string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	HospitalId    string                 `protobuf:"bytes,4,opt,name=hospital_id,json=hospitalId,proto3" json:"hospital_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateStaffRequest) Reset() {
+	*x = CreateStaffRequest{}
+	mi := &file_hospital_v1_hospital_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateStaffRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateStaffRequest) ProtoMessage() {}
+
+func (x *CreateStaffRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hospital_v1_hospital_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateStaffRequest.ProtoReflect.Descriptor instead.
+func (*CreateStaffRequest) Descriptor() ([]byte, []int) {
+	return file_hospital_v1_hospital_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *CreateStaffRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *CreateStaffRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+func (x *CreateStaffRequest) GetHospitalId() string {
+	if x != nil {
+		return x.HospitalId
+	}
+	return ""
+}
+
+type Staff struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StaffId       string                 `protobuf:"bytes,1,opt,name=staff_id,json=staffId,proto3" json:"staff_id,omitempty"`
+	Username      string                 `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	Role          string                 `protobuf:"bytes,3,opt,name=role,proto3" json:"role,omitempty"`
+	HospitalId    string                 `protobuf:"bytes,4,opt,name=hospital_id,json=hospitalId,proto3" json:"hospital_id,omitempty"`
+	Version       int32                  `protobuf:"varint,5,opt,name=version,proto3" json:"version,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Staff) Reset() {
+	*x = Staff{}
+	mi := &file_hospital_v1_hospital_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Staff) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Staff) ProtoMessage() {}
+
+func (x *Staff) ProtoReflect() protoreflect.Message {
+	mi := &file_hospital_v1_hospital_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Staff.ProtoReflect.Descriptor instead.
+func (*Staff) Descriptor() ([]byte, []int) {
+	return file_hospital_v1_hospital_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Staff) GetStaffId() string {
+	if x != nil {
+		return x.StaffId
+	}
+	return ""
+}
+
+func (x *Staff) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *Staff) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *Staff) GetHospitalId() string {
+	if x != nil {
+		return x.HospitalId
+	}
+	return ""
+}
+
+func (x *Staff) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Staff) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Staff) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type LoginRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Username      string                 `protobuf:"bytes,1,opt,name=username,proto3" json:"username,omitempty"`
+	Password      string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	HospitalId    string                 `protobuf:"bytes,3,opt,name=hospital_id,json=hospitalId,proto3" json:"hospital_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LoginRequest) Reset() {
+	*x = LoginRequest{}
+	mi := &file_hospital_v1_hospital_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LoginRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoginRequest) ProtoMessage() {}
+
+func (x *LoginRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hospital_v1_hospital_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoginRequest.ProtoReflect.Descriptor instead.
+func (*LoginRequest) Descriptor() ([]byte, []int) {
+	return file_hospital_v1_hospital_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *LoginRequest) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *LoginRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+func (x *LoginRequest) GetHospitalId() string {
+	if x != nil {
+		return x.HospitalId
+	}
+	return ""
+}
+
+type LoginResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LoginResponse) Reset() {
+	*x = LoginResponse{}
+	mi := &file_hospital_v1_hospital_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LoginResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LoginResponse) ProtoMessage() {}
+
+func (x *LoginResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_hospital_v1_hospital_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LoginResponse.ProtoReflect.Descriptor instead.
+func (*LoginResponse) Descriptor() ([]byte, []int) {
+	return file_hospital_v1_hospital_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *LoginResponse) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+type SearchPatientsRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	NationalId  string                 `protobuf:"bytes,1,opt,name=national_id,json=nationalId,proto3" json:"national_id,omitempty"`
+	PassportId  string                 `protobuf:"bytes,2,opt,name=passport_id,json=passportId,proto3" json:"passport_id,omitempty"`
+	FirstName   string                 `protobuf:"bytes,3,opt,name=first_name,json=firstName,proto3" json:"first_name,omitempty"`
+	MiddleName  string                 `protobuf:"bytes,4,opt,name=middle_name,json=middleName,proto3" json:"middle_name,omitempty"`
+	LastName    string                 `protobuf:"bytes,5,opt,name=last_name,json=lastName,proto3" json:"last_name,omitempty"`
+	DateOfBirth *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=date_of_birth,json=dateOfBirth,proto3" json:"date_of_birth,omitempty"`
+	PhoneNumber string                 `protobuf:"bytes,7,opt,name=phone_number,json=phoneNumber,proto3" json:"phone_number,omitempty"`
+	Email       string                 `protobuf:"bytes,8,opt,name=email,proto3" json:"email,omitempty"`
+	Province    string                 `protobuf:"bytes,9,opt,name=province,proto3" json:"province,omitempty"`
+	District    string                 `protobuf:"bytes,10,opt,name=district,proto3" json:"district,omitempty"`
+	// name matches any part of the Thai or English name; every word must match.
+	Name string `protobuf:"bytes,11,opt,name=name,proto3" json:"name,omitempty"`
+	// patient_hn matches the HN exactly; identifier matches the HN, national ID or passport ID.
+	PatientHn     string `protobuf:"bytes,12,opt,name=patient_hn,json=patientHn,proto3" json:"patient_hn,omitempty"`
+	Identifier    string `protobuf:"bytes,13,opt,name=identifier,proto3" json:"identifier,omitempty"`
+	Gender        string `protobuf:"bytes,14,opt,name=gender,proto3" json:"gender,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchPatientsRequest) Reset() {
+	*x = SearchPatientsRequest{}
+	mi := &file_hospital_v1_hospital_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchPatientsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchPatientsRequest) ProtoMessage() {}
+
+func (x *SearchPatientsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hospital_v1_hospital_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchPatientsRequest.ProtoReflect.Descriptor instead.
+func (*SearchPatientsRequest) Descriptor() ([]byte, []int) {
+	return file_hospital_v1_hospital_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SearchPatientsRequest) GetNationalId() string {
+	if x != nil {
+		return x.NationalId
+	}
+	return ""
+}
+
+func (x *SearchPatientsRequest) GetPassportId() string {
+	if x != nil {
+		return x.PassportId
+	}
+	return ""
+}
+
+func (x *SearchPatientsRequest) GetFirstName() string {
+	if x != nil {
+		return x.FirstName
+	}
+	return ""
+}
+
+func (x *SearchPatientsRequest) GetMiddleName() string {
+	if x != nil {
+		return x.MiddleName
+	}
+	return ""
+}
+
+func (x *SearchPatientsRequest) GetLastName() string {
+	if x != nil {
+		return x.LastName
+	}
+	return ""
+}
+
+func (x *SearchPatientsRequest) GetDateOfBirth() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DateOfBirth
+	}
+	return nil
+}
+
+func (x *SearchPatientsRequest) GetPhoneNumber() string {
+	if x != nil {
+		return x.PhoneNumber
+	}
+	return ""
+}
+
+func (x *SearchPatientsRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *SearchPatientsRequest) GetProvince() string {
+	if x != nil {
+		return x.Province
+	}
+	return ""
+}
+
+func (x *SearchPatientsRequest) GetDistrict() string {
+	if x != nil {
+		return x.District
+	}
+	return ""
+}
+
+func (x *SearchPatientsRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SearchPatientsRequest) GetPatientHn() string {
+	if x != nil {
+		return x.PatientHn
+	}
+	return ""
+}
+
+func (x *SearchPatientsRequest) GetIdentifier() string {
+	if x != nil {
+		return x.Identifier
+	}
+	return ""
+}
+
+func (x *SearchPatientsRequest) GetGender() string {
+	if x != nil {
+		return x.Gender
+	}
+	return ""
+}
+
+type SearchPatientsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Patients      []*Patient             `protobuf:"bytes,1,rep,name=patients,proto3" json:"patients,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchPatientsResponse) Reset() {
+	*x = SearchPatientsResponse{}
+	mi := &file_hospital_v1_hospital_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchPatientsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchPatientsResponse) ProtoMessage() {}
+
+func (x *SearchPatientsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_hospital_v1_hospital_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchPatientsResponse.ProtoReflect.Descriptor instead.
+func (*SearchPatientsResponse) Descriptor() ([]byte, []int) {
+	return file_hospital_v1_hospital_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SearchPatientsResponse) GetPatients() []*Patient {
+	if x != nil {
+		return x.Patients
+	}
+	return nil
+}
+
+type GetPatientRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PatientId     string                 `protobuf:"bytes,1,opt,name=patient_id,json=patientId,proto3" json:"patient_id,omitempty"`
+	AsOf          *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=as_of,json=asOf,proto3" json:"as_of,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPatientRequest) Reset() {
+	*x = GetPatientRequest{}
+	mi := &file_hospital_v1_hospital_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPatientRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPatientRequest) ProtoMessage() {}
+
+func (x *GetPatientRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_hospital_v1_hospital_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPatientRequest.ProtoReflect.Descriptor instead.
+func (*GetPatientRequest) Descriptor() ([]byte, []int) {
+	return file_hospital_v1_hospital_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetPatientRequest) GetPatientId() string {
+	if x != nil {
+		return x.PatientId
+	}
+	return ""
+}
+
+func (x *GetPatientRequest) GetAsOf() *timestamppb.Timestamp {
+	if x != nil {
+		return x.AsOf
+	}
+	return nil
+}
+
+type Patient struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PatientId     string                 `protobuf:"bytes,1,opt,name=patient_id,json=patientId,proto3" json:"patient_id,omitempty"`
+	FirstNameTh   string                 `protobuf:"bytes,2,opt,name=first_name_th,json=firstNameTh,proto3" json:"first_name_th,omitempty"`
+	MiddleNameTh  string                 `protobuf:"bytes,3,opt,name=middle_name_th,json=middleNameTh,proto3" json:"middle_name_th,omitempty"`
+	LastNameTh    string                 `protobuf:"bytes,4,opt,name=last_name_th,json=lastNameTh,proto3" json:"last_name_th,omitempty"`
+	FirstNameEn   string                 `protobuf:"bytes,5,opt,name=first_name_en,json=firstNameEn,proto3" json:"first_name_en,omitempty"`
+	MiddleNameEn  string                 `protobuf:"bytes,6,opt,name=middle_name_en,json=middleNameEn,proto3" json:"middle_name_en,omitempty"`
+	LastNameEn    string                 `protobuf:"bytes,7,opt,name=last_name_en,json=lastNameEn,proto3" json:"last_name_en,omitempty"`
+	DateOfBirth   *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=date_of_birth,json=dateOfBirth,proto3" json:"date_of_birth,omitempty"`
+	PatientHn     string                 `protobuf:"bytes,9,opt,name=patient_hn,json=patientHn,proto3" json:"patient_hn,omitempty"`
+	NationalId    string                 `protobuf:"bytes,10,opt,name=national_id,json=nationalId,proto3" json:"national_id,omitempty"`
+	PassportId    string                 `protobuf:"bytes,11,opt,name=passport_id,json=passportId,proto3" json:"passport_id,omitempty"`
+	PhoneNumber   string                 `protobuf:"bytes,12,opt,name=phone_number,json=phoneNumber,proto3" json:"phone_number,omitempty"`
+	Email         string                 `protobuf:"bytes,13,opt,name=email,proto3" json:"email,omitempty"`
+	Gender        string                 `protobuf:"bytes,14,opt,name=gender,proto3" json:"gender,omitempty"`
+	HospitalId    string                 `protobuf:"bytes,15,opt,name=hospital_id,json=hospitalId,proto3" json:"hospital_id,omitempty"`
+	Version       int32                  `protobuf:"varint,16,opt,name=version,proto3" json:"version,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,17,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,18,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Patient) Reset() {
+	*x = Patient{}
+	mi := &file_hospital_v1_hospital_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Patient) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Patient) ProtoMessage() {}
+
+func (x *Patient) ProtoReflect() protoreflect.Message {
+	mi := &file_hospital_v1_hospital_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Patient.ProtoReflect.Descriptor instead.
+func (*Patient) Descriptor() ([]byte, []int) {
+	return file_hospital_v1_hospital_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Patient) GetPatientId() string {
+	if x != nil {
+		return x.PatientId
+	}
+	return ""
+}
+
+func (x *Patient) GetFirstNameTh() string {
+	if x != nil {
+		return x.FirstNameTh
+	}
+	return ""
+}
+
+func (x *Patient) GetMiddleNameTh() string {
+	if x != nil {
+		return x.MiddleNameTh
+	}
+	return ""
+}
+
+func (x *Patient) GetLastNameTh() string {
+	if x != nil {
+		return x.LastNameTh
+	}
+	return ""
+}
+
+func (x *Patient) GetFirstNameEn() string {
+	if x != nil {
+		return x.FirstNameEn
+	}
+	return ""
+}
+
+func (x *Patient) GetMiddleNameEn() string {
+	if x != nil {
+		return x.MiddleNameEn
+	}
+	return ""
+}
+
+func (x *Patient) GetLastNameEn() string {
+	if x != nil {
+		return x.LastNameEn
+	}
+	return ""
+}
+
+func (x *Patient) GetDateOfBirth() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DateOfBirth
+	}
+	return nil
+}
+
+func (x *Patient) GetPatientHn() string {
+	if x != nil {
+		return x.PatientHn
+	}
+	return ""
+}
+
+func (x *Patient) GetNationalId() string {
+	if x != nil {
+		return x.NationalId
+	}
+	return ""
+}
+
+func (x *Patient) GetPassportId() string {
+	if x != nil {
+		return x.PassportId
+	}
+	return ""
+}
+
+func (x *Patient) GetPhoneNumber() string {
+	if x != nil {
+		return x.PhoneNumber
+	}
+	return ""
+}
+
+func (x *Patient) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *Patient) GetGender() string {
+	if x != nil {
+		return x.Gender
+	}
+	return ""
+}
+
+func (x *Patient) GetHospitalId() string {
+	if x != nil {
+		return x.HospitalId
+	}
+	return ""
+}
+
+func (x *Patient) GetVersion() int32 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Patient) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Patient) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+var File_hospital_v1_hospital_proto protoreflect.FileDescriptor
+
+const file_hospital_v1_hospital_proto_rawDesc = "" +
+	"\n" +
+	"\x1ahospital/v1/hospital.proto\x12\vhospital.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"y\n" +
+	"\x12CreateStaffRequest\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12\x1a\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\x12\x1f\n" +
+	"\vhospital_id\x18\x04 \x01(\tR\n" +
+	"hospitalIdJ\x04\b\x03\x10\x04R\x04role\"\x83\x02\n" +
+	"\x05Staff\x12\x19\n" +
+	"\bstaff_id\x18\x01 \x01(\tR\astaffId\x12\x1a\n" +
+	"\busername\x18\x02 \x01(\tR\busername\x12\x12\n" +
+	"\x04role\x18\x03 \x01(\tR\x04role\x12\x1f\n" +
+	"\vhospital_id\x18\x04 \x01(\tR\n" +
+	"hospitalId\x12\x18\n" +
+	"\aversion\x18\x05 \x01(\x05R\aversion\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"g\n" +
+	"\fLoginRequest\x12\x1a\n" +
+	"\busername\x18\x01 \x01(\tR\busername\x12\x1a\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword\x12\x1f\n" +
+	"\vhospital_id\x18\x03 \x01(\tR\n" +
+	"hospitalId\"%\n" +
+	"\rLoginResponse\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\"\xd2\x03\n" +
+	"\x15SearchPatientsRequest\x12\x1f\n" +
+	"\vnational_id\x18\x01 \x01(\tR\n" +
+	"nationalId\x12\x1f\n" +
+	"\vpassport_id\x18\x02 \x01(\tR\n" +
+	"passportId\x12\x1d\n" +
+	"\n" +
+	"first_name\x18\x03 \x01(\tR\tfirstName\x12\x1f\n" +
+	"\vmiddle_name\x18\x04 \x01(\tR\n" +
+	"middleName\x12\x1b\n" +
+	"\tlast_name\x18\x05 \x01(\tR\blastName\x12>\n" +
+	"\rdate_of_birth\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\vdateOfBirth\x12!\n" +
+	"\fphone_number\x18\a \x01(\tR\vphoneNumber\x12\x14\n" +
+	"\x05email\x18\b \x01(\tR\x05email\x12\x1a\n" +
+	"\bprovince\x18\t \x01(\tR\bprovince\x12\x1a\n" +
+	"\bdistrict\x18\n" +
+	" \x01(\tR\bdistrict\x12\x12\n" +
+	"\x04name\x18\v \x01(\tR\x04name\x12\x1d\n" +
+	"\n" +
+	"patient_hn\x18\f \x01(\tR\tpatientHn\x12\x1e\n" +
+	"\n" +
+	"identifier\x18\r \x01(\tR\n" +
+	"identifier\x12\x16\n" +
+	"\x06gender\x18\x0e \x01(\tR\x06gender\"J\n" +
+	"\x16SearchPatientsResponse\x120\n" +
+	"\bpatients\x18\x01 \x03(\v2\x14.hospital.v1.PatientR\bpatients\"c\n" +
+	"\x11GetPatientRequest\x12\x1d\n" +
+	"\n" +
+	"patient_id\x18\x01 \x01(\tR\tpatientId\x12/\n" +
+	"\x05as_of\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\x04asOf\"\xa3\x05\n" +
+	"\aPatient\x12\x1d\n" +
+	"\n" +
+	"patient_id\x18\x01 \x01(\tR\tpatientId\x12\"\n" +
+	"\rfirst_name_th\x18\x02 \x01(\tR\vfirstNameTh\x12$\n" +
+	"\x0emiddle_name_th\x18\x03 \x01(\tR\fmiddleNameTh\x12 \n" +
+	"\flast_name_th\x18\x04 \x01(\tR\n" +
+	"lastNameTh\x12\"\n" +
+	"\rfirst_name_en\x18\x05 \x01(\tR\vfirstNameEn\x12$\n" +
+	"\x0emiddle_name_en\x18\x06 \x01(\tR\fmiddleNameEn\x12 \n" +
+	"\flast_name_en\x18\a \x01(\tR\n" +
+	"lastNameEn\x12>\n" +
+	"\rdate_of_birth\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\vdateOfBirth\x12\x1d\n" +
+	"\n" +
+	"patient_hn\x18\t \x01(\tR\tpatientHn\x12\x1f\n" +
+	"\vnational_id\x18\n" +
+	" \x01(\tR\n" +
+	"nationalId\x12\x1f\n" +
+	"\vpassport_id\x18\v \x01(\tR\n" +
+	"passportId\x12!\n" +
+	"\fphone_number\x18\f \x01(\tR\vphoneNumber\x12\x14\n" +
+	"\x05email\x18\r \x01(\tR\x05email\x12\x16\n" +
+	"\x06gender\x18\x0e \x01(\tR\x06gender\x12\x1f\n" +
+	"\vhospital_id\x18\x0f \x01(\tR\n" +
+	"hospitalId\x12\x18\n" +
+	"\aversion\x18\x10 \x01(\x05R\aversion\x129\n" +
+	"\n" +
+	"created_at\x18\x11 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\x12 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt2\x92\x01\n" +
+	"\fStaffService\x12B\n" +
+	"\vCreateStaff\x12\x1f.hospital.v1.CreateStaffRequest\x1a\x12.hospital.v1.Staff\x12>\n" +
+	"\x05Login\x12\x19.hospital.v1.LoginRequest\x1a\x1a.hospital.v1.LoginResponse2\xaf\x01\n" +
+	"\x0ePatientService\x12Y\n" +
+	"\x0eSearchPatients\x12\".hospital.v1.SearchPatientsRequest\x1a#.hospital.v1.SearchPatientsResponse\x12B\n" +
+	"\n" +
+	"GetPatient\x12\x1e.hospital.v1.GetPatientRequest\x1a\x14.hospital.v1.PatientB.Z,go-hospital-api/proto/hospital/v1;hospitalv1b\x06proto3"
+
+var (
+	file_hospital_v1_hospital_proto_rawDescOnce sync.Once
+	file_hospital_v1_hospital_proto_rawDescData []byte
+)
+
+func file_hospital_v1_hospital_proto_rawDescGZIP() []byte {
+	file_hospital_v1_hospital_proto_rawDescOnce.Do(func() {
+		file_hospital_v1_hospital_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_hospital_v1_hospital_proto_rawDesc), len(file_hospital_v1_hospital_proto_rawDesc)))
+	})
+	return file_hospital_v1_hospital_proto_rawDescData
+}
+
+var file_hospital_v1_hospital_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_hospital_v1_hospital_proto_goTypes = []any{
+	(*CreateStaffRequest)(nil),     // 0: hospital.v1.CreateStaffRequest
+	(*Staff)(nil),                  // 1: hospital.v1.Staff
+	(*LoginRequest)(nil),           // 2: hospital.v1.LoginRequest
+	(*LoginResponse)(nil),          // 3: hospital.v1.LoginResponse
+	(*SearchPatientsRequest)(nil),  // 4: hospital.v1.SearchPatientsRequest
+	(*SearchPatientsResponse)(nil), // 5: hospital.v1.SearchPatientsResponse
+	(*GetPatientRequest)(nil),      // 6: hospital.v1.GetPatientRequest
+	(*Patient)(nil),                // 7: hospital.v1.Patient
+	(*timestamppb.Timestamp)(nil),  // 8: google.protobuf.Timestamp
+}
+var file_hospital_v1_hospital_proto_depIdxs = []int32{
+	8,  // 0: hospital.v1.Staff.created_at:type_name -> google.protobuf.Timestamp
+	8,  // 1: hospital.v1.Staff.updated_at:type_name -> google.protobuf.Timestamp
+	8,  // 2: hospital.v1.SearchPatientsRequest.date_of_birth:type_name -> google.protobuf.Timestamp
+	7,  // 3: hospital.v1.SearchPatientsResponse.patients:type_name -> hospital.v1.Patient
+	8,  // 4: hospital.v1.GetPatientRequest.as_of:type_name -> google.protobuf.Timestamp
+	8,  // 5: hospital.v1.Patient.date_of_birth:type_name -> google.protobuf.Timestamp
+	8,  // 6: hospital.v1.Patient.created_at:type_name -> google.protobuf.Timestamp
+	8,  // 7: hospital.v1.Patient.updated_at:type_name -> google.protobuf.Timestamp
+	0,  // 8: hospital.v1.StaffService.CreateStaff:input_type -> hospital.v1.CreateStaffRequest
+	2,  // 9: hospital.v1.StaffService.Login:input_type -> hospital.v1.LoginRequest
+	4,  // 10: hospital.v1.PatientService.SearchPatients:input_type -> hospital.v1.SearchPatientsRequest
+	6,  // 11: hospital.v1.PatientService.GetPatient:input_type -> hospital.v1.GetPatientRequest
+	1,  // 12: hospital.v1.StaffService.CreateStaff:output_type -> hospital.v1.Staff
+	3,  // 13: hospital.v1.StaffService.Login:output_type -> hospital.v1.LoginResponse
+	5,  // 14: hospital.v1.PatientService.SearchPatients:output_type -> hospital.v1.SearchPatientsResponse
+	7,  // 15: hospital.v1.PatientService.GetPatient:output_type -> hospital.v1.Patient
+	12, // [12:16] is the sub-list for method output_type
+	8,  // [8:12] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_hospital_v1_hospital_proto_init() }
+func file_hospital_v1_hospital_proto_init() {
+	if File_hospital_v1_hospital_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_hospital_v1_hospital_proto_rawDesc), len(file_hospital_v1_hospital_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   2,
+		},
+		GoTypes:           file_hospital_v1_hospital_proto_goTypes,
+		DependencyIndexes: file_hospital_v1_hospital_proto_depIdxs,
+		MessageInfos:      file_hospital_v1_hospital_proto_msgTypes,
+	}.Build()
+	File_hospital_v1_hospital_proto = out.File
+	file_hospital_v1_hospital_proto_goTypes = nil
+	file_hospital_v1_hospital_proto_depIdxs = nil
+}